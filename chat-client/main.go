@@ -3,11 +3,13 @@ package main
 import (
 	"bufio"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"os/signal"
 	"strconv"
 	"strings"
+	"sync"
 	"syscall"
 	"time"
 
@@ -26,6 +28,14 @@ var outputChan = make(chan string, 100)
 var expectingMessageContentForRecipient string      // 如果不为空，下一个输入是发给此接收者的消息内容
 var expectingGroupMessageContentForGroup uint32 = 0 // 如果不为0，下一个输入是发给此群组的消息内容
 
+// exportDownload 正在下载的导出文件，分片响应在消息处理协程中写入
+var exportDownload struct {
+	sync.Mutex
+	exportID uint
+	path     string
+	file     *os.File
+}
+
 func main() {
 	go consoleOutputRoutine() // Start a goroutine to handle all console output
 
@@ -140,20 +150,118 @@ mainLoop:
 			handleRegister(args)
 		case "/login":
 			handleLogin(args)
+		case "/guest":
+			handleGuestLogin(args)
+		case "/botlogin":
+			handleBotLogin(args)
 		case "/msg":
 			handleSendMsg(args) // This will now set expectingMessageContentForRecipient if needed
 		case "/groupmsg":
 			handleSendGroupMsg(args) // 设置expectingGroupMessageContentForGroup
+		case "/groupfile":
+			handleSendGroupFile(args)
 		case "/history":
 			handleHistory(args)
 		case "/grouphistory":
 			handleGroupHistory(args)
+		case "/addfriend":
+			handleAddFriend(args)
+		case "/accept":
+			handleFriendRequest(args, true)
+		case "/reject":
+			handleFriendRequest(args, false)
+		case "/friendrequests":
+			handleSimpleRequest(cli.SendGetFriendRequestsReq, "好友申请列表")
+		case "/friends":
+			handleSimpleRequest(cli.SendGetFriendListReq, "好友列表")
+		case "/unfriend":
+			handleRemoveFriend(args)
+		case "/remark":
+			handleFriendRemark(args)
+		case "/profile":
+			handleGetProfile(args)
+		case "/setprofile":
+			handleSetProfile(args)
+		case "/searchusers":
+			handleSearchUsers(args)
+		case "/passwd":
+			handleChangePassword(args)
+		case "/forgotpassword":
+			handleRequestPasswordReset(args)
+		case "/resetpassword":
+			handleResetPassword(args)
+		case "/sendverify":
+			handleSendVerifyEmail()
+		case "/verifyemail":
+			handleVerifyEmail(args)
+		case "/unlock":
+			handleUnlockAccount(args)
+		case "/2fa":
+			handleTwoFactorLogin(args)
+		case "/totp":
+			handleTOTP(args)
+		case "/loginhistory":
+			handleLoginHistory(args)
+		case "/deleteaccount":
+			handleDeleteAccount(args)
+		case "/export":
+			handleRequestDataExport()
+		case "/download":
+			handleDownloadExport(args)
+		case "/bot":
+			handleBot(args)
+		case "/webhook":
+			handleBotWebhook(args)
+		case "/hook":
+			handleIncomingWebhook(args)
+		case "/block":
+			handleBlockUser(args)
+		case "/unblock":
+			handleUnblockUser(args)
+		case "/blocklist":
+			handleSimpleRequest(cli.SendGetBlockListReq, "屏蔽列表")
+		case "/dmprivacy":
+			handleDMPrivacy(args)
 		case "/creategroup":
 			handleCreateGroup(args)
 		case "/joingroup":
 			handleJoinGroup(args)
 		case "/leavegroup":
 			handleLeaveGroup(args)
+		case "/members":
+			handleGroupMembers(args)
+		case "/searchgroups":
+			handleSearchGroups(args)
+		case "/setdirectory":
+			handleSetGroupDirectory(args)
+		case "/invite":
+			handleInviteMember(args)
+		case "/ban":
+			handleBanMember(args)
+		case "/unban":
+			handleUnbanMember(args)
+		case "/mute":
+			handleMuteMember(args)
+		case "/banlist":
+			handleBanList(args)
+		case "/setrole":
+			handleSetMemberRole(args)
+		case "/saverole":
+			handleSaveRole(args)
+		case "/deleterole":
+			handleDeleteRole(args)
+		case "/roles":
+			handleGroupRoles(args)
+		case "/announce":
+			handleUpdateAnnouncement(args)
+		case "/announcehistory":
+			handleAnnouncementHistory(args)
+		case "/ackannounce":
+			handleAckAnnouncement(args)
+		case "/pin":
+			handlePinMessage(args, true)
+		case "/unpin":
+			handlePinMessage(args, false)
 		case "/help":
 			handleHelp()
 		case "/quit", "/exit":
@@ -205,25 +313,96 @@ func ensureLoggedIn() bool {
 
 func handleConnect(args []string) {
 	targetAddr := serverAddr
-	if len(args) > 0 {
+	if len(args) > 0 && !isConnectOption(args[0]) {
 		targetAddr = args[0]
+		args = args[1:]
+	}
+	tlsOptions, err := parseConnectTLS(args)
+	if err != nil {
+		outputChan <- "错误: " + err.Error()
+		outputChan <- "用法: /connect [host:port] [tls] [ca=证书路径] [name=服务器名] [insecure] [cert=证书路径 key=私钥路径]"
+		return
 	}
 	if cli != nil {
 		outputChan <- "关闭现有连接..."
 		cli.Close()
 	}
-	var err error
-	newCli, err := client.NewChatClient(targetAddr)
+	newCli, err := client.NewChatClient(targetAddr, client.WithTLS(tlsOptions), client.WithReconnect(client.DefaultReconnectPolicy()))
+	if errors.Is(err, client.ErrHandshakeRejected) {
+		outputChan <- fmt.Sprintf("服务器 %s 不接受当前客户端 (%v)，请升级客户端。", targetAddr, err)
+		return
+	}
 	if err != nil {
 		outputChan <- fmt.Sprintf("连接到 %s 失败: %v", targetAddr, err)
 		return
 	}
 	cli = newCli // Assign to global cli only on success
 	serverAddr = targetAddr
-	outputChan <- fmt.Sprintf("成功连接到 %s。请使用 /login 或 /register。", targetAddr)
+	if tlsOptions != nil {
+		outputChan <- fmt.Sprintf("成功通过 TLS 连接到 %s。请使用 /login 或 /register。", targetAddr)
+		if tlsOptions.InsecureSkipVerify {
+			outputChan <- "警告: 已跳过服务器证书校验，仅限开发环境使用"
+		}
+	} else {
+		outputChan <- fmt.Sprintf("成功连接到 %s。请使用 /login 或 /register。", targetAddr)
+	}
+	if info := cli.ServerInfo(); info != nil {
+		outputChan <- fmt.Sprintf("服务端版本 %s，协议版本 %d，已启用功能: %s", info.ServerVersion, info.ProtocolVersion, strings.Join(info.EnabledFeatures, ", "))
+	}
+	cli.OnStateChange(handleConnState)
 	cli.StartMsgListener(handleIncomingMessages)
 }
 
+// handleConnState 显示自动重连的进度
+func handleConnState(state client.ConnState, err error) {
+	switch state {
+	case client.StateReconnecting:
+		outputChan <- fmt.Sprintf("[连接] 与服务器的连接已断开 (%v)，正在自动重连...", err)
+	case client.StateConnected:
+		if errors.Is(err, client.ErrReauthRequired) {
+			outputChan <- fmt.Sprintf("[连接] 已重新连接，但无法自动恢复登录: %v。请使用 /login 重新登录，断线期间未发送的消息将在登录后发送。", err)
+		} else {
+			outputChan <- "[连接] 已重新连接到服务器。"
+		}
+	case client.StateClosed:
+		if err != nil {
+			outputChan <- fmt.Sprintf("[连接] %v，请使用 /connect 重新连接。", err)
+		}
+	}
+}
+
+// isConnectOption 判断 /connect 的参数是否为 TLS 选项而不是服务器地址
+func isConnectOption(arg string) bool {
+	return arg == "tls" || arg == "insecure" || strings.Contains(arg, "=")
+}
+
+// parseConnectTLS 解析 /connect 的 TLS 选项，没有任何 TLS 选项时返回 nil 表示使用明文连接
+func parseConnectTLS(args []string) (*client.TLSOptions, error) {
+	if len(args) == 0 {
+		return nil, nil
+	}
+	opts := &client.TLSOptions{}
+	for _, arg := range args {
+		key, value, hasValue := strings.Cut(arg, "=")
+		switch {
+		case key == "tls" && !hasValue:
+		case key == "insecure" && !hasValue:
+			opts.InsecureSkipVerify = true
+		case key == "ca" && value != "":
+			opts.CAFile = value
+		case key == "name" && value != "":
+			opts.ServerName = value
+		case key == "cert" && value != "":
+			opts.CertFile = value
+		case key == "key" && value != "":
+			opts.KeyFile = value
+		default:
+			return nil, fmt.Errorf("未知的连接选项 %s", arg)
+		}
+	}
+	return opts, nil
+}
+
 func handleIncomingMessages(msgID uint32, data []byte) {
 	var output string
 	switch msgID {
@@ -243,7 +422,11 @@ func handleIncomingMessages(msgID uint32, data []byte) {
 	case serverProtocol.MsgIDGroupTextMsgPush:
 		var msg model.GroupTextMsgPush
 		if err := json.Unmarshal(data, &msg); err == nil {
-			output = fmt.Sprintf("[群组消息] 群组%d - %s: %s", msg.GroupID, msg.FromUsername, msg.Content)
+			if msg.MessageType == model.GroupMessageTypeIntegration {
+				output = fmt.Sprintf("[群组消息] 群组%d - [集成] %s: %s", msg.GroupID, msg.FromUsername, msg.Content)
+			} else {
+				output = fmt.Sprintf("[群组消息] 群组%d - %s: %s", msg.GroupID, msg.FromUsername, msg.Content)
+			}
 		} else {
 			output = fmt.Sprintf("[错误] 解析群组消息失败: %v. 内容: %s", err, string(data))
 		}
@@ -333,7 +516,7 @@ func handleIncomingMessages(msgID uint32, data []byte) {
 			}
 			for i, msg := range resp.Messages {
 				timestamp := time.Unix(msg.Timestamp, 0).Format("2006-01-02 15:04:05")
-				historyOutput.WriteString(fmt.Sprintf("\n  %d. [%s] (%s): %s", i+1, msg.SenderName, timestamp, msg.Content))
+				historyOutput.WriteString(fmt.Sprintf("\n  %d. (ID:%d) [%s] (%s): %s", i+1, msg.ID, msg.SenderName, timestamp, msg.Content))
 			}
 			if resp.HasMore {
 				historyOutput.WriteString("\n  (还有更多消息，使用最后一条消息的ID作为LastID参数可继续查询)")
@@ -347,6 +530,611 @@ func handleIncomingMessages(msgID uint32, data []byte) {
 				output = fmt.Sprintf("[错误] 解析群组历史消息响应失败: %v. 内容: %s", err, string(data))
 			}
 		}
+	case serverProtocol.MsgIDGetGroupMembersResp:
+		if errMsg := parseErrorResp(data); errMsg != "" {
+			output = fmt.Sprintf("[错误] 获取群成员失败: %s", errMsg)
+			break
+		}
+		var resp model.GetGroupMembersResp
+		if err := json.Unmarshal(data, &resp); err == nil {
+			var membersOutput strings.Builder
+			membersOutput.WriteString(fmt.Sprintf("[群组%d成员] 第%d页，共%d人", resp.GroupID, resp.Page, resp.Total))
+			for _, member := range resp.Members {
+				status := "离线"
+				if member.IsOnline {
+					status = "在线"
+				}
+				membersOutput.WriteString(fmt.Sprintf("\n  %s (ID:%d) [%s] %s", member.Username, member.UserID, member.Role, status))
+			}
+			if resp.HasMore {
+				membersOutput.WriteString(fmt.Sprintf("\n  (还有更多成员，使用 /members %d %d 查看下一页)", resp.GroupID, resp.Page+1))
+			}
+			output = membersOutput.String()
+		} else {
+			output = fmt.Sprintf("[错误] 解析群成员响应失败: %v. 内容: %s", err, string(data))
+		}
+	case serverProtocol.MsgIDBanGroupMemberResp, serverProtocol.MsgIDUnbanGroupMemberResp, serverProtocol.MsgIDMuteGroupMemberResp:
+		var resp map[string]string
+		if err := json.Unmarshal(data, &resp); err == nil {
+			if resp["error"] != "" {
+				output = fmt.Sprintf("[错误] %s", resp["error"])
+			} else {
+				output = fmt.Sprintf("[群组] %s", resp["message"])
+			}
+		} else {
+			output = fmt.Sprintf("[错误] 解析封禁响应失败: %v. 内容: %s", err, string(data))
+		}
+	case serverProtocol.MsgIDGetGroupBanListResp:
+		if errMsg := parseErrorResp(data); errMsg != "" {
+			output = fmt.Sprintf("[错误] 获取封禁列表失败: %s", errMsg)
+			break
+		}
+		var resp model.GetGroupBanListResp
+		if err := json.Unmarshal(data, &resp); err == nil {
+			var banOutput strings.Builder
+			banOutput.WriteString(fmt.Sprintf("[群组%d封禁列表] 共%d人", resp.GroupID, resp.Total))
+			for _, ban := range resp.Bans {
+				expires := "永久"
+				if ban.ExpiresAt != "" {
+					expires = "至 " + ban.ExpiresAt
+				}
+				banOutput.WriteString(fmt.Sprintf("\n  %s (ID:%d) 由 %s 封禁于 %s, %s, 原因: %s",
+					ban.Username, ban.UserID, ban.BannedByName, ban.CreatedAt, expires, ban.Reason))
+			}
+			output = banOutput.String()
+		} else {
+			output = fmt.Sprintf("[错误] 解析封禁列表响应失败: %v. 内容: %s", err, string(data))
+		}
+	case serverProtocol.MsgIDSendFriendRequestResp, serverProtocol.MsgIDHandleFriendRequestResp:
+		if errMsg := parseErrorResp(data); errMsg != "" {
+			output = fmt.Sprintf("[错误] %s", errMsg)
+			break
+		}
+		var info model.FriendRequestInfo
+		if err := json.Unmarshal(data, &info); err == nil {
+			output = "[好友] " + formatFriendRequest(&info)
+		} else {
+			output = fmt.Sprintf("[错误] 解析好友申请响应失败: %v. 内容: %s", err, string(data))
+		}
+	case serverProtocol.MsgIDFriendRequestPush:
+		var info model.FriendRequestInfo
+		if err := json.Unmarshal(data, &info); err == nil {
+			switch info.Status {
+			case model.FriendRequestPending:
+				output = fmt.Sprintf("[好友申请] %s (ID:%d) 请求添加你为好友: %s (使用 /accept %d 或 /reject %d 处理)",
+					info.FromUsername, info.FromUserID, info.Message, info.ID, info.ID)
+			case model.FriendRequestAccepted:
+				output = fmt.Sprintf("[好友] %s 已和你成为好友", info.ToUsername)
+			default:
+				output = fmt.Sprintf("[好友] %s 拒绝了你的好友申请", info.ToUsername)
+			}
+		} else {
+			output = fmt.Sprintf("[错误] 解析好友申请推送失败: %v. 内容: %s", err, string(data))
+		}
+	case serverProtocol.MsgIDFriendRemovedPush:
+		var push model.FriendRemovedPush
+		if err := json.Unmarshal(data, &push); err == nil {
+			output = fmt.Sprintf("[好友] %s (ID:%d) 已将你从好友列表中删除", push.Username, push.UserID)
+		} else {
+			output = fmt.Sprintf("[错误] 解析删除好友推送失败: %v. 内容: %s", err, string(data))
+		}
+	case serverProtocol.MsgIDGetFriendRequestsResp:
+		if errMsg := parseErrorResp(data); errMsg != "" {
+			output = fmt.Sprintf("[错误] 获取好友申请失败: %s", errMsg)
+			break
+		}
+		var resp model.GetFriendRequestsResp
+		if err := json.Unmarshal(data, &resp); err == nil {
+			var reqOutput strings.Builder
+			reqOutput.WriteString(fmt.Sprintf("[好友申请] 收到%d个:", len(resp.Incoming)))
+			for _, info := range resp.Incoming {
+				reqOutput.WriteString("\n  " + formatFriendRequest(info))
+			}
+			reqOutput.WriteString(fmt.Sprintf("\n[好友申请] 发出%d个:", len(resp.Outgoing)))
+			for _, info := range resp.Outgoing {
+				reqOutput.WriteString("\n  " + formatFriendRequest(info))
+			}
+			output = reqOutput.String()
+		} else {
+			output = fmt.Sprintf("[错误] 解析好友申请列表失败: %v. 内容: %s", err, string(data))
+		}
+	case serverProtocol.MsgIDGetFriendListResp:
+		if errMsg := parseErrorResp(data); errMsg != "" {
+			output = fmt.Sprintf("[错误] 获取好友列表失败: %s", errMsg)
+			break
+		}
+		var resp model.GetFriendListResp
+		if err := json.Unmarshal(data, &resp); err == nil {
+			var friendsOutput strings.Builder
+			friendsOutput.WriteString(fmt.Sprintf("[好友列表] 共%d人", resp.Total))
+			for _, friend := range resp.Friends {
+				status := "离线"
+				if friend.IsOnline {
+					status = "在线"
+				}
+				name := friend.Username
+				if friend.Remark != "" {
+					name = fmt.Sprintf("%s(%s)", friend.Remark, friend.Username)
+				}
+				friendsOutput.WriteString(fmt.Sprintf("\n  %s (ID:%d) [%s] 好友自 %s", name, friend.UserID, status, friend.Since))
+			}
+			output = friendsOutput.String()
+		} else {
+			output = fmt.Sprintf("[错误] 解析好友列表失败: %v. 内容: %s", err, string(data))
+		}
+	case serverProtocol.MsgIDSearchGroupsResp:
+		if errMsg := parseErrorResp(data); errMsg != "" {
+			output = fmt.Sprintf("[错误] 搜索群组失败: %s", errMsg)
+			break
+		}
+		var resp model.SearchGroupsResp
+		if err := json.Unmarshal(data, &resp); err == nil {
+			var groupsOutput strings.Builder
+			groupsOutput.WriteString(fmt.Sprintf("[公开群组] 共%d个, 第%d页", resp.Total, resp.Page))
+			for _, group := range resp.Groups {
+				groupsOutput.WriteString(fmt.Sprintf("\n  %s (ID:%d) %d人", group.Name, group.ID, group.MemberCount))
+				if group.Category != "" {
+					groupsOutput.WriteString(fmt.Sprintf(" [%s]", group.Category))
+				}
+				if len(group.Tags) > 0 {
+					groupsOutput.WriteString(" #" + strings.Join(group.Tags, " #"))
+				}
+				if group.Description != "" {
+					groupsOutput.WriteString(" - " + group.Description)
+				}
+			}
+			if resp.HasMore {
+				groupsOutput.WriteString(fmt.Sprintf("\n  (还有更多群组，添加 page=%d 查看下一页)", resp.Page+1))
+			}
+			output = groupsOutput.String()
+		} else {
+			output = fmt.Sprintf("[错误] 解析搜索群组响应失败: %v. 内容: %s", err, string(data))
+		}
+	case serverProtocol.MsgIDUpdateProfileResp, serverProtocol.MsgIDGetProfileResp:
+		if errMsg := parseErrorResp(data); errMsg != "" {
+			output = fmt.Sprintf("[错误] %s", errMsg)
+			break
+		}
+		var profile model.UserProfile
+		if err := json.Unmarshal(data, &profile); err == nil {
+			output = "[资料] " + formatUserProfile(&profile)
+		} else {
+			output = fmt.Sprintf("[错误] 解析用户资料失败: %v. 内容: %s", err, string(data))
+		}
+	case serverProtocol.MsgIDProfileChangedPush:
+		var profile model.UserProfile
+		if err := json.Unmarshal(data, &profile); err == nil {
+			output = "[好友资料更新] " + formatUserProfile(&profile)
+		} else {
+			output = fmt.Sprintf("[错误] 解析资料变更推送失败: %v. 内容: %s", err, string(data))
+		}
+	case serverProtocol.MsgIDSearchUsersResp:
+		if errMsg := parseErrorResp(data); errMsg != "" {
+			output = fmt.Sprintf("[错误] 搜索用户失败: %s", errMsg)
+			break
+		}
+		var resp model.SearchUsersResp
+		if err := json.Unmarshal(data, &resp); err == nil {
+			var usersOutput strings.Builder
+			usersOutput.WriteString(fmt.Sprintf("[搜索用户] 共%d人, 第%d页", resp.Total, resp.Page))
+			for _, profile := range resp.Users {
+				usersOutput.WriteString("\n  " + formatUserProfile(profile))
+			}
+			if resp.HasMore {
+				usersOutput.WriteString(fmt.Sprintf("\n  (还有更多用户，添加 page=%d 查看下一页)", resp.Page+1))
+			}
+			output = usersOutput.String()
+		} else {
+			output = fmt.Sprintf("[错误] 解析搜索用户响应失败: %v. 内容: %s", err, string(data))
+		}
+	case serverProtocol.MsgIDChangePasswordResp, serverProtocol.MsgIDRequestPasswordResetResp,
+		serverProtocol.MsgIDResetPasswordResp, serverProtocol.MsgIDSendVerifyEmailResp,
+		serverProtocol.MsgIDVerifyEmailResp, serverProtocol.MsgIDUnlockAccountResp,
+		serverProtocol.MsgIDDisableTOTPResp:
+		if errMsg := parseErrorResp(data); errMsg != "" {
+			output = fmt.Sprintf("[错误] %s", errMsg)
+			break
+		}
+		var resp map[string]string
+		if err := json.Unmarshal(data, &resp); err == nil {
+			output = fmt.Sprintf("[账号] %s", resp["message"])
+		} else {
+			output = fmt.Sprintf("[错误] 解析账号响应失败: %v. 内容: %s", err, string(data))
+		}
+	case serverProtocol.MsgIDSessionRevokedPush:
+		var push model.SessionRevokedPush
+		if err := json.Unmarshal(data, &push); err == nil {
+			output = fmt.Sprintf("[系统] 会话已失效: %s", push.Reason)
+		} else {
+			output = fmt.Sprintf("[错误] 解析会话失效推送失败: %v. 内容: %s", err, string(data))
+		}
+	case serverProtocol.MsgIDServerGoingAwayPush:
+		var push model.ServerGoingAwayPush
+		if err := json.Unmarshal(data, &push); err == nil {
+			output = fmt.Sprintf("[系统] 服务器节点即将关闭: %s，连接断开后将自动重连", push.Reason)
+		} else {
+			output = fmt.Sprintf("[错误] 解析节点下线推送失败: %v. 内容: %s", err, string(data))
+		}
+	case serverProtocol.MsgIDEnrollTOTPResp:
+		if errMsg := parseErrorResp(data); errMsg != "" {
+			output = fmt.Sprintf("[错误] %s", errMsg)
+			break
+		}
+		var resp model.EnrollTOTPResp
+		if err := json.Unmarshal(data, &resp); err == nil {
+			output = fmt.Sprintf("[两步验证] 请将密钥添加到认证器应用，然后使用 /totp confirm <验证码> 确认开启\n  密钥: %s\n  地址: %s",
+				resp.Secret, resp.ProvisioningURI)
+		} else {
+			output = fmt.Sprintf("[错误] 解析两步验证密钥失败: %v. 内容: %s", err, string(data))
+		}
+	case serverProtocol.MsgIDConfirmTOTPResp:
+		if errMsg := parseErrorResp(data); errMsg != "" {
+			output = fmt.Sprintf("[错误] %s", errMsg)
+			break
+		}
+		var resp model.ConfirmTOTPResp
+		if err := json.Unmarshal(data, &resp); err == nil {
+			output = "[两步验证] 已开启。请妥善保存以下备用恢复码，每个只能使用一次，且不会再次显示:\n  " +
+				strings.Join(resp.BackupCodes, "\n  ")
+		} else {
+			output = fmt.Sprintf("[错误] 解析两步验证响应失败: %v. 内容: %s", err, string(data))
+		}
+	case serverProtocol.MsgIDGetLoginHistoryResp:
+		if errMsg := parseErrorResp(data); errMsg != "" {
+			output = fmt.Sprintf("[错误] 获取登录历史失败: %s", errMsg)
+			break
+		}
+		var resp model.GetLoginHistoryResp
+		if err := json.Unmarshal(data, &resp); err == nil {
+			var historyOutput strings.Builder
+			historyOutput.WriteString(fmt.Sprintf("[登录历史] 最近%d条", len(resp.Records)))
+			for _, record := range resp.Records {
+				result := "成功"
+				if !record.Success {
+					result = "失败(" + record.FailReason + ")"
+				}
+				historyOutput.WriteString(fmt.Sprintf("\n  %s %s IP:%s", record.CreatedAt.Format("2006-01-02 15:04:05"), result, record.IP))
+				if record.DeviceID != "" {
+					historyOutput.WriteString(" 设备:" + shortDeviceID(record.DeviceID))
+				}
+				if record.ClientVersion != "" {
+					historyOutput.WriteString(" 版本:" + record.ClientVersion)
+				}
+			}
+			output = historyOutput.String()
+		} else {
+			output = fmt.Sprintf("[错误] 解析登录历史失败: %v. 内容: %s", err, string(data))
+		}
+	case serverProtocol.MsgIDSuspiciousLoginPush:
+		var push model.SuspiciousLoginPush
+		if err := json.Unmarshal(data, &push); err == nil {
+			var sources []string
+			if push.NewIP {
+				sources = append(sources, "新IP "+push.IP)
+			}
+			if push.NewDevice {
+				sources = append(sources, "新设备 "+shortDeviceID(push.DeviceID))
+			}
+			output = fmt.Sprintf("[安全提醒] 你的账号于 %s 从%s登录。如果不是你本人操作，请立即使用 /passwd 修改密码。",
+				time.Unix(push.LoginAt, 0).Format("2006-01-02 15:04:05"), strings.Join(sources, "、"))
+		} else {
+			output = fmt.Sprintf("[错误] 解析登录提醒失败: %v. 内容: %s", err, string(data))
+		}
+	case serverProtocol.MsgIDDeleteAccountResp, serverProtocol.MsgIDCancelAccountDeletionResp,
+		serverProtocol.MsgIDGetAccountDeletionStatusResp:
+		if errMsg := parseErrorResp(data); errMsg != "" {
+			output = fmt.Sprintf("[错误] %s", errMsg)
+			break
+		}
+		var status model.AccountDeletionStatus
+		if err := json.Unmarshal(data, &status); err == nil {
+			if status.Scheduled && status.DeletionDueAt != nil {
+				output = fmt.Sprintf("[注销] 账号将于 %s 被永久清除，在此之前可使用 /deleteaccount cancel 撤销",
+					status.DeletionDueAt.Format("2006-01-02 15:04:05"))
+			} else if msgID == serverProtocol.MsgIDCancelAccountDeletionResp {
+				output = "[注销] 已撤销注销申请"
+			} else {
+				output = "[注销] 账号未申请注销"
+			}
+		} else {
+			output = fmt.Sprintf("[错误] 解析注销响应失败: %v. 内容: %s", err, string(data))
+		}
+	case serverProtocol.MsgIDRequestDataExportResp:
+		if errMsg := parseErrorResp(data); errMsg != "" {
+			output = fmt.Sprintf("[错误] %s", errMsg)
+			break
+		}
+		var export model.DataExport
+		if err := json.Unmarshal(data, &export); err == nil {
+			output = fmt.Sprintf("[数据导出] 正在生成导出文件 (ID:%d)，完成后会通知你", export.ID)
+		} else {
+			output = fmt.Sprintf("[错误] 解析数据导出响应失败: %v. 内容: %s", err, string(data))
+		}
+	case serverProtocol.MsgIDDataExportReadyPush:
+		var push model.DataExportReadyPush
+		if err := json.Unmarshal(data, &push); err == nil && push.Export != nil {
+			if push.Export.Status == model.DataExportReady {
+				output = fmt.Sprintf("[数据导出] 导出文件已生成 (ID:%d, %d 字节)，有效期至 %s，使用 /download %d 下载",
+					push.Export.ID, push.Export.Size, push.Export.ExpiresAt.Format("2006-01-02 15:04:05"), push.Export.ID)
+			} else {
+				output = fmt.Sprintf("[数据导出] 导出失败 (ID:%d): %s", push.Export.ID, push.Export.Error)
+			}
+		} else {
+			output = fmt.Sprintf("[错误] 解析数据导出推送失败: %v. 内容: %s", err, string(data))
+		}
+	case serverProtocol.MsgIDDownloadDataExportResp:
+		output = handleExportChunk(data)
+	case serverProtocol.MsgIDCreateBotResp, serverProtocol.MsgIDRotateBotKeyResp:
+		if errMsg := parseErrorResp(data); errMsg != "" {
+			output = fmt.Sprintf("[错误] %s", errMsg)
+			break
+		}
+		var resp model.BotKeyResp
+		if err := json.Unmarshal(data, &resp); err == nil && resp.Bot != nil {
+			output = fmt.Sprintf("[机器人] %s (ID:%d) 的 API Key: %s\n  该 Key 只显示这一次，请妥善保存，机器人使用 /botlogin <API Key> 登录",
+				resp.Bot.Username, resp.Bot.ID, resp.APIKey)
+		} else {
+			output = fmt.Sprintf("[错误] 解析机器人响应失败: %v. 内容: %s", err, string(data))
+		}
+	case serverProtocol.MsgIDSetBotWebhookResp:
+		if errMsg := parseErrorResp(data); errMsg != "" {
+			output = fmt.Sprintf("[错误] %s", errMsg)
+			break
+		}
+		var resp model.SetBotWebhookResp
+		if err := json.Unmarshal(data, &resp); err == nil && resp.Webhook != nil {
+			output = fmt.Sprintf("[Webhook] 已设置机器人 %d 在群 %d 的 webhook: %s",
+				resp.Webhook.BotUserID, resp.Webhook.GroupID, formatBotWebhook(resp.Webhook))
+			if resp.Secret != "" {
+				output += fmt.Sprintf("\n  签名密钥: %s\n  该密钥只显示这一次，请在 webhook 服务中用它校验 X-Chat-Signature", resp.Secret)
+			}
+		} else {
+			output = fmt.Sprintf("[错误] 解析 webhook 响应失败: %v. 内容: %s", err, string(data))
+		}
+	case serverProtocol.MsgIDDeleteBotWebhookResp:
+		if errMsg := parseErrorResp(data); errMsg != "" {
+			output = fmt.Sprintf("[错误] %s", errMsg)
+		} else {
+			output = "[Webhook] webhook 已删除"
+		}
+	case serverProtocol.MsgIDGetBotWebhooksResp:
+		if errMsg := parseErrorResp(data); errMsg != "" {
+			output = fmt.Sprintf("[错误] %s", errMsg)
+			break
+		}
+		var resp model.GetBotWebhooksResp
+		if err := json.Unmarshal(data, &resp); err == nil {
+			if len(resp.Webhooks) == 0 {
+				output = fmt.Sprintf("[Webhook] 机器人 %d 还没有设置 webhook", resp.BotUserID)
+				break
+			}
+			var b strings.Builder
+			b.WriteString(fmt.Sprintf("[Webhook] 机器人 %d 共 %d 个:", resp.BotUserID, len(resp.Webhooks)))
+			for _, hook := range resp.Webhooks {
+				b.WriteString(fmt.Sprintf("\n  群 %d: %s", hook.GroupID, formatBotWebhook(hook)))
+			}
+			output = b.String()
+		} else {
+			output = fmt.Sprintf("[错误] 解析 webhook 列表失败: %v. 内容: %s", err, string(data))
+		}
+	case serverProtocol.MsgIDCreateIncomingWebhookResp:
+		if errMsg := parseErrorResp(data); errMsg != "" {
+			output = fmt.Sprintf("[错误] %s", errMsg)
+			break
+		}
+		var resp model.CreateIncomingWebhookResp
+		if err := json.Unmarshal(data, &resp); err == nil && resp.Webhook != nil {
+			output = fmt.Sprintf("[集成] 已为群 %d 创建 webhook %s (ID:%d)\n  URL: %s\n  该 URL 只显示这一次，持有 URL 即可向群里发消息，请妥善保存\n  示例: curl -X POST -H 'Content-Type: application/json' -d '{\"text\":\"hello\"}' %s",
+				resp.Webhook.GroupID, resp.Webhook.Name, resp.Webhook.ID, resp.URL, resp.URL)
+		} else {
+			output = fmt.Sprintf("[错误] 解析 webhook 响应失败: %v. 内容: %s", err, string(data))
+		}
+	case serverProtocol.MsgIDRevokeIncomingWebhookResp:
+		if errMsg := parseErrorResp(data); errMsg != "" {
+			output = fmt.Sprintf("[错误] %s", errMsg)
+		} else {
+			output = "[集成] webhook 已吊销"
+		}
+	case serverProtocol.MsgIDGetIncomingWebhooksResp:
+		if errMsg := parseErrorResp(data); errMsg != "" {
+			output = fmt.Sprintf("[错误] %s", errMsg)
+			break
+		}
+		var resp model.GetIncomingWebhooksResp
+		if err := json.Unmarshal(data, &resp); err == nil {
+			if len(resp.Webhooks) == 0 {
+				output = fmt.Sprintf("[集成] 群 %d 没有 webhook，使用 /hook create %d <名称> 创建", resp.GroupID, resp.GroupID)
+				break
+			}
+			var b strings.Builder
+			b.WriteString(fmt.Sprintf("[集成] 群 %d 共 %d 个 webhook:", resp.GroupID, len(resp.Webhooks)))
+			for _, hook := range resp.Webhooks {
+				lastUsed := "从未使用"
+				if hook.LastUsedAt != nil {
+					lastUsed = "最近使用 " + hook.LastUsedAt.Format("2006-01-02 15:04:05")
+				}
+				b.WriteString(fmt.Sprintf("\n  ID:%d %s (令牌 %s...) 创建者:%d, %s",
+					hook.ID, hook.Name, model.IncomingWebhookTokenPrefix+hook.Hint, hook.CreatorID, lastUsed))
+			}
+			output = b.String()
+		} else {
+			output = fmt.Sprintf("[错误] 解析 webhook 列表失败: %v. 内容: %s", err, string(data))
+		}
+	case serverProtocol.MsgIDGetMyBotsResp:
+		if errMsg := parseErrorResp(data); errMsg != "" {
+			output = fmt.Sprintf("[错误] %s", errMsg)
+			break
+		}
+		var resp model.GetMyBotsResp
+		if err := json.Unmarshal(data, &resp); err == nil {
+			if len(resp.Bots) == 0 {
+				output = "[机器人] 你还没有创建机器人，使用 /bot create <用户名> [昵称] 创建"
+				break
+			}
+			var b strings.Builder
+			b.WriteString(fmt.Sprintf("[机器人] 共 %d 个:", len(resp.Bots)))
+			for _, bot := range resp.Bots {
+				lastUsed := "从未登录"
+				if bot.LastUsed != nil {
+					lastUsed = "最近登录 " + bot.LastUsed.Format("2006-01-02 15:04:05")
+				}
+				key := bot.KeyHint + "..."
+				if bot.KeyHint == "" {
+					key = "无有效 Key"
+				}
+				b.WriteString(fmt.Sprintf("\n  ID:%d %s", bot.ID, bot.Username))
+				if bot.Nickname != "" {
+					b.WriteString(fmt.Sprintf(" (%s)", bot.Nickname))
+				}
+				b.WriteString(fmt.Sprintf(" Key:%s %s", key, lastUsed))
+			}
+			output = b.String()
+		} else {
+			output = fmt.Sprintf("[错误] 解析机器人列表失败: %v. 内容: %s", err, string(data))
+		}
+	case serverProtocol.MsgIDRemoveFriendResp, serverProtocol.MsgIDSetFriendRemarkResp,
+		serverProtocol.MsgIDBlockUserResp, serverProtocol.MsgIDUnblockUserResp:
+		if errMsg := parseErrorResp(data); errMsg != "" {
+			output = fmt.Sprintf("[错误] %s", errMsg)
+			break
+		}
+		var resp map[string]string
+		if err := json.Unmarshal(data, &resp); err == nil {
+			output = fmt.Sprintf("[联系人] %s", resp["message"])
+		} else {
+			output = fmt.Sprintf("[错误] 解析联系人响应失败: %v. 内容: %s", err, string(data))
+		}
+	case serverProtocol.MsgIDGetBlockListResp:
+		if errMsg := parseErrorResp(data); errMsg != "" {
+			output = fmt.Sprintf("[错误] 获取屏蔽列表失败: %s", errMsg)
+			break
+		}
+		var resp model.GetBlockListResp
+		if err := json.Unmarshal(data, &resp); err == nil {
+			var blockOutput strings.Builder
+			blockOutput.WriteString(fmt.Sprintf("[屏蔽列表] 共%d人", resp.Total))
+			for _, user := range resp.Users {
+				blockOutput.WriteString(fmt.Sprintf("\n  %s (ID:%d) 屏蔽于 %s", user.Username, user.UserID, user.BlockedAt))
+			}
+			output = blockOutput.String()
+		} else {
+			output = fmt.Sprintf("[错误] 解析屏蔽列表失败: %v. 内容: %s", err, string(data))
+		}
+	case serverProtocol.MsgIDSetDMPrivacyResp, serverProtocol.MsgIDGetDMPrivacyResp:
+		if errMsg := parseErrorResp(data); errMsg != "" {
+			output = fmt.Sprintf("[错误] %s", errMsg)
+			break
+		}
+		var resp model.DMPrivacyReq
+		if err := json.Unmarshal(data, &resp); err == nil {
+			output = fmt.Sprintf("[隐私] 当前私聊设置: %s", formatDMPrivacy(resp.Privacy))
+		} else {
+			output = fmt.Sprintf("[错误] 解析私聊隐私设置失败: %v. 内容: %s", err, string(data))
+		}
+	case serverProtocol.MsgIDGroupInvitedPush:
+		var push model.GroupInvitedPush
+		if err := json.Unmarshal(data, &push); err == nil {
+			output = fmt.Sprintf("[群组] %s (ID:%d) 邀请你加入了群组 %s (ID:%d)",
+				push.InviterName, push.InviterID, push.GroupName, push.GroupID)
+		} else {
+			output = fmt.Sprintf("[错误] 解析入群邀请失败: %v. 内容: %s", err, string(data))
+		}
+	case serverProtocol.MsgIDUpdateGroupInfoResp, serverProtocol.MsgIDSetMemberRoleResp, serverProtocol.MsgIDDeleteGroupRoleResp,
+		serverProtocol.MsgIDInviteGroupMemberResp:
+		if errMsg := parseErrorResp(data); errMsg != "" {
+			output = fmt.Sprintf("[错误] %s", errMsg)
+			break
+		}
+		var resp map[string]string
+		if err := json.Unmarshal(data, &resp); err == nil {
+			output = fmt.Sprintf("[群组] %s", resp["message"])
+		} else {
+			output = fmt.Sprintf("[错误] 解析角色响应失败: %v. 内容: %s", err, string(data))
+		}
+	case serverProtocol.MsgIDSaveGroupRoleResp:
+		if errMsg := parseErrorResp(data); errMsg != "" {
+			output = fmt.Sprintf("[错误] %s", errMsg)
+			break
+		}
+		var role model.GroupRoleInfo
+		if err := json.Unmarshal(data, &role); err == nil {
+			output = fmt.Sprintf("[群组] 角色已保存: %s", formatGroupRole(&role))
+		} else {
+			output = fmt.Sprintf("[错误] 解析角色响应失败: %v. 内容: %s", err, string(data))
+		}
+	case serverProtocol.MsgIDGetGroupRolesResp:
+		if errMsg := parseErrorResp(data); errMsg != "" {
+			output = fmt.Sprintf("[错误] 获取角色列表失败: %s", errMsg)
+			break
+		}
+		var resp model.GetGroupRolesResp
+		if err := json.Unmarshal(data, &resp); err == nil {
+			var rolesOutput strings.Builder
+			rolesOutput.WriteString(fmt.Sprintf("[群组%d角色列表] 共%d个", resp.GroupID, len(resp.Roles)))
+			for _, role := range resp.Roles {
+				rolesOutput.WriteString("\n  " + formatGroupRole(role))
+			}
+			output = rolesOutput.String()
+		} else {
+			output = fmt.Sprintf("[错误] 解析角色列表响应失败: %v. 内容: %s", err, string(data))
+		}
+	case serverProtocol.MsgIDUpdateGroupAnnouncementResp, serverProtocol.MsgIDAckGroupAnnouncementResp:
+		if errMsg := parseErrorResp(data); errMsg != "" {
+			output = fmt.Sprintf("[错误] 群公告操作失败: %s", errMsg)
+			break
+		}
+		var info model.GroupAnnouncementInfo
+		if err := json.Unmarshal(data, &info); err == nil {
+			output = formatAnnouncement("[群公告]", &info)
+		} else {
+			output = fmt.Sprintf("[错误] 解析群公告响应失败: %v. 内容: %s", err, string(data))
+		}
+	case serverProtocol.MsgIDGroupAnnouncementPush:
+		var push model.GroupAnnouncementPush
+		if err := json.Unmarshal(data, &push); err == nil && push.Announcement != nil {
+			output = formatAnnouncement(fmt.Sprintf("[群公告更新] 群组%d", push.GroupID), push.Announcement)
+		} else {
+			output = fmt.Sprintf("[错误] 解析群公告推送失败: %v. 内容: %s", err, string(data))
+		}
+	case serverProtocol.MsgIDGetGroupAnnouncementHistoryResp:
+		if errMsg := parseErrorResp(data); errMsg != "" {
+			output = fmt.Sprintf("[错误] 获取群公告历史失败: %s", errMsg)
+			break
+		}
+		var resp model.GetGroupAnnouncementHistoryResp
+		if err := json.Unmarshal(data, &resp); err == nil {
+			var historyOutput strings.Builder
+			historyOutput.WriteString(fmt.Sprintf("[群组%d公告历史]", resp.GroupID))
+			if len(resp.Announcements) == 0 {
+				historyOutput.WriteString("\n  (暂无公告)")
+			}
+			for _, a := range resp.Announcements {
+				historyOutput.WriteString(fmt.Sprintf("\n  v%d [%s] (%s, ID:%d): %s", a.Version, a.EditorName, a.CreatedAt, a.ID, a.Content))
+			}
+			output = historyOutput.String()
+		} else {
+			output = fmt.Sprintf("[错误] 解析群公告历史响应失败: %v. 内容: %s", err, string(data))
+		}
+	case serverProtocol.MsgIDPinGroupMessageResp, serverProtocol.MsgIDUnpinGroupMessageResp, serverProtocol.MsgIDGroupPinnedMsgPush:
+		if errMsg := parseErrorResp(data); errMsg != "" {
+			output = fmt.Sprintf("[错误] 置顶操作失败: %s", errMsg)
+			break
+		}
+		var push model.GroupPinnedMsgPush
+		if err := json.Unmarshal(data, &push); err == nil {
+			var pinnedOutput strings.Builder
+			pinnedOutput.WriteString(fmt.Sprintf("[群组%d置顶消息]", push.GroupID))
+			if len(push.PinnedMessages) == 0 {
+				pinnedOutput.WriteString("\n  (无置顶消息)")
+			}
+			for i, msg := range push.PinnedMessages {
+				timestamp := time.Unix(msg.Timestamp, 0).Format("2006-01-02 15:04:05")
+				pinnedOutput.WriteString(fmt.Sprintf("\n  %d. (ID:%d) [%s] (%s): %s", i+1, msg.GroupMessageID, msg.SenderName, timestamp, msg.Content))
+			}
+			output = pinnedOutput.String()
+		} else {
+			output = fmt.Sprintf("[错误] 解析置顶消息失败: %v. 内容: %s", err, string(data))
+		}
 	case serverProtocol.MsgIDErrorResp: // Generic error response from server
 		var errResp model.GenericMessageResp
 		if err := json.Unmarshal(data, &errResp); err == nil {
@@ -362,6 +1150,30 @@ func handleIncomingMessages(msgID uint32, data []byte) {
 	}
 }
 
+// parseErrorResp 尝试解析 {"error":"..."} 格式的错误响应，不是错误响应时返回空字符串
+func parseErrorResp(data []byte) string {
+	var errResp map[string]interface{}
+	if json.Unmarshal(data, &errResp) != nil {
+		return ""
+	}
+	errMsg, _ := errResp["error"].(string)
+	return errMsg
+}
+
+// formatAnnouncement 格式化群公告及其确认情况
+func formatAnnouncement(prefix string, info *model.GroupAnnouncementInfo) string {
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("%s v%d (ID:%d) 由 %s 编辑于 %s:\n  %s", prefix, info.Version, info.ID, info.EditorName, info.CreatedAt, info.Content))
+	if len(info.AckedBy) > 0 {
+		names := make([]string, 0, len(info.AckedBy))
+		for _, ack := range info.AckedBy {
+			names = append(names, ack.Username)
+		}
+		b.WriteString(fmt.Sprintf("\n  已确认(%d): %s", len(names), strings.Join(names, ", ")))
+	}
+	return b.String()
+}
+
 func handleRegister(args []string) {
 	if !ensureConnected() {
 		return
@@ -387,12 +1199,17 @@ func handleLogin(args []string) {
 		return
 	}
 	loginResp, err := cli.Login(args[0], args[1])
+	if errors.Is(err, client.ErrTwoFactorRequired) {
+		outputChan <- "该账号已开启两步验证，请使用 /2fa <验证码或备用恢复码> 完成登录。"
+		return
+	}
 	if err != nil {
 		outputChan <- fmt.Sprintf("登录失败: %v", err)
 		return
 	}
 	// Success message already printed by cli.Login(), or can be added here:
 	outputChan <- fmt.Sprintf("登录成功: %s (UUID: %s)", loginResp.Username, loginResp.UserUUID)
+	warnPendingDeletion(loginResp)
 }
 
 func handleSendMsg(args []string) {
@@ -452,6 +1269,25 @@ func handleSendGroupMsg(args []string) {
 	}
 }
 
+// 处理发送群组附件消息
+func handleSendGroupFile(args []string) {
+	if !ensureLoggedIn() {
+		return
+	}
+	if len(args) < 3 || (args[1] != model.GroupMessageTypeImage && args[1] != model.GroupMessageTypeFile) {
+		outputChan <- "用法: /groupfile <群组ID> <image|file> <文件地址>"
+		return
+	}
+	groupID, err := strconv.ParseUint(args[0], 10, 32)
+	if err != nil {
+		outputChan <- "无效的群组ID。"
+		return
+	}
+	if err := cli.SendGroupAttachmentMessage(uint32(groupID), args[1], args[2]); err != nil {
+		outputChan <- fmt.Sprintf("附件消息发送失败: %v", err)
+	}
+}
+
 func handleHistory(args []string) {
 	if !ensureLoggedIn() {
 		return
@@ -521,28 +1357,807 @@ func handleGroupHistory(args []string) {
 	}
 }
 
-func handleCreateGroup(args []string) {
+// handleSimpleRequest 发送不需要参数的请求
+func handleSimpleRequest(send func() error, name string) {
 	if !ensureLoggedIn() {
 		return
 	}
-	if len(args) < 1 {
-		outputChan <- "用法: /creategroup <群名称> [描述] [头像URL]"
-		return
-	}
-	name := args[0]
-	description := ""
-	avatar := ""
-	if len(args) > 1 {
-		description = args[1]
-	}
-	if len(args) > 2 {
-		avatar = args[2]
-	}
-	err := cli.SendCreateGroupReq(name, description, avatar)
-	if err != nil {
-		outputChan <- fmt.Sprintf("创建群组请求发送失败: %v", err)
+	if err := send(); err != nil {
+		outputChan <- fmt.Sprintf("获取%s请求发送失败: %v", name, err)
 	} else {
-		outputChan <- "创建群组请求已发送。等待响应..."
+		outputChan <- fmt.Sprintf("%s请求已发送。等待响应...", name)
+	}
+}
+
+func handleAddFriend(args []string) {
+	if !ensureLoggedIn() {
+		return
+	}
+	if len(args) < 1 {
+		outputChan <- "用法: /addfriend <用户名/UUID/用户ID> [附言...]"
+		return
+	}
+	err := cli.SendFriendRequestReq(args[0], strings.Join(args[1:], " "))
+	if err != nil {
+		outputChan <- fmt.Sprintf("好友申请发送失败: %v", err)
+	} else {
+		outputChan <- "好友申请已发送。等待响应..."
+	}
+}
+
+func handleFriendRequest(args []string, accept bool) {
+	if !ensureLoggedIn() {
+		return
+	}
+	if len(args) < 1 {
+		if accept {
+			outputChan <- "用法: /accept <申请ID>"
+		} else {
+			outputChan <- "用法: /reject <申请ID>"
+		}
+		return
+	}
+	requestID, err := strconv.ParseUint(args[0], 10, 32)
+	if err != nil {
+		outputChan <- "无效的申请ID。"
+		return
+	}
+	err = cli.SendHandleFriendRequestReq(uint(requestID), accept)
+	if err != nil {
+		outputChan <- fmt.Sprintf("处理好友申请请求发送失败: %v", err)
+	} else {
+		outputChan <- "处理好友申请请求已发送。等待响应..."
+	}
+}
+
+func handleRemoveFriend(args []string) {
+	if !ensureLoggedIn() {
+		return
+	}
+	if len(args) < 1 {
+		outputChan <- "用法: /unfriend <好友用户ID>"
+		return
+	}
+	friendUserID, err := strconv.ParseUint(args[0], 10, 32)
+	if err != nil {
+		outputChan <- "无效的用户ID。"
+		return
+	}
+	err = cli.SendRemoveFriendReq(uint(friendUserID))
+	if err != nil {
+		outputChan <- fmt.Sprintf("删除好友请求发送失败: %v", err)
+	} else {
+		outputChan <- "删除好友请求已发送。等待响应..."
+	}
+}
+
+func handleFriendRemark(args []string) {
+	if !ensureLoggedIn() {
+		return
+	}
+	if len(args) < 1 {
+		outputChan <- "用法: /remark <好友用户ID> [备注...] (不填备注则清除)"
+		return
+	}
+	friendUserID, err := strconv.ParseUint(args[0], 10, 32)
+	if err != nil {
+		outputChan <- "无效的用户ID。"
+		return
+	}
+	err = cli.SendSetFriendRemarkReq(uint(friendUserID), strings.Join(args[1:], " "))
+	if err != nil {
+		outputChan <- fmt.Sprintf("设置备注请求发送失败: %v", err)
+	} else {
+		outputChan <- "设置备注请求已发送。等待响应..."
+	}
+}
+
+func handleGetProfile(args []string) {
+	if !ensureLoggedIn() {
+		return
+	}
+	target := ""
+	if len(args) > 0 {
+		target = args[0]
+	}
+	if err := cli.SendGetProfileReq(target); err != nil {
+		outputChan <- fmt.Sprintf("获取资料请求发送失败: %v", err)
+	} else {
+		outputChan <- "获取资料请求已发送。等待响应..."
+	}
+}
+
+func handleSetProfile(args []string) {
+	if !ensureLoggedIn() {
+		return
+	}
+	if len(args) < 1 {
+		outputChan <- "用法: /setprofile <nickname|avatar|gender|bio|searchable> [值...] (不填值则清除)"
+		return
+	}
+	value := strings.Join(args[1:], " ")
+	var req model.UpdateProfileReq
+	switch args[0] {
+	case "nickname":
+		req.Nickname = &value
+	case "avatar":
+		req.Avatar = &value
+	case "gender":
+		req.Gender = &value
+	case "bio":
+		req.Bio = &value
+	case "searchable":
+		searchable, err := strconv.ParseBool(value)
+		if err != nil {
+			outputChan <- "用法: /setprofile searchable <true|false>"
+			return
+		}
+		req.Searchable = &searchable
+	default:
+		outputChan <- "用法: /setprofile <nickname|avatar|gender|bio|searchable> [值...] (不填值则清除)"
+		return
+	}
+	if err := cli.SendUpdateProfileReq(req); err != nil {
+		outputChan <- fmt.Sprintf("更新资料请求发送失败: %v", err)
+	} else {
+		outputChan <- "更新资料请求已发送。等待响应..."
+	}
+}
+
+func handleSearchUsers(args []string) {
+	if !ensureLoggedIn() {
+		return
+	}
+	page := 0
+	var keywords []string
+	for _, arg := range args {
+		if value, found := strings.CutPrefix(arg, "page="); found {
+			p, err := strconv.Atoi(value)
+			if err != nil || p < 1 {
+				outputChan <- "无效的页码。"
+				return
+			}
+			page = p
+			continue
+		}
+		keywords = append(keywords, arg)
+	}
+	if len(keywords) == 0 {
+		outputChan <- "用法: /searchusers <关键字> [page=页码]"
+		return
+	}
+	if err := cli.SendSearchUsersReq(strings.Join(keywords, " "), page); err != nil {
+		outputChan <- fmt.Sprintf("搜索用户请求发送失败: %v", err)
+	} else {
+		outputChan <- "搜索用户请求已发送。等待响应..."
+	}
+}
+
+func handleChangePassword(args []string) {
+	if !ensureLoggedIn() {
+		return
+	}
+	if len(args) < 2 {
+		outputChan <- "用法: /passwd <旧密码> <新密码>"
+		return
+	}
+	if err := cli.SendChangePasswordReq(args[0], args[1]); err != nil {
+		outputChan <- fmt.Sprintf("修改密码请求发送失败: %v", err)
+	} else {
+		outputChan <- "修改密码请求已发送。等待响应..."
+	}
+}
+
+func handleRequestPasswordReset(args []string) {
+	if !ensureConnected() {
+		return
+	}
+	if len(args) < 1 {
+		outputChan <- "用法: /forgotpassword <用户名/邮箱>"
+		return
+	}
+	if err := cli.SendRequestPasswordResetReq(args[0]); err != nil {
+		outputChan <- fmt.Sprintf("申请重置密码请求发送失败: %v", err)
+	} else {
+		outputChan <- "申请重置密码请求已发送。等待响应..."
+	}
+}
+
+func handleResetPassword(args []string) {
+	if !ensureConnected() {
+		return
+	}
+	if len(args) < 2 {
+		outputChan <- "用法: /resetpassword <令牌> <新密码>"
+		return
+	}
+	if err := cli.SendResetPasswordReq(args[0], args[1]); err != nil {
+		outputChan <- fmt.Sprintf("重置密码请求发送失败: %v", err)
+	} else {
+		outputChan <- "重置密码请求已发送。等待响应..."
+	}
+}
+
+func handleSendVerifyEmail() {
+	if !ensureLoggedIn() {
+		return
+	}
+	if err := cli.SendVerifyEmailMailReq(); err != nil {
+		outputChan <- fmt.Sprintf("发送验证邮件请求发送失败: %v", err)
+	} else {
+		outputChan <- "发送验证邮件请求已发送。等待响应..."
+	}
+}
+
+func handleVerifyEmail(args []string) {
+	if !ensureConnected() {
+		return
+	}
+	if len(args) < 1 {
+		outputChan <- "用法: /verifyemail <令牌>"
+		return
+	}
+	if err := cli.SendVerifyEmailReq(args[0]); err != nil {
+		outputChan <- fmt.Sprintf("验证邮箱请求发送失败: %v", err)
+	} else {
+		outputChan <- "验证邮箱请求已发送。等待响应..."
+	}
+}
+
+func handleUnlockAccount(args []string) {
+	if !ensureLoggedIn() {
+		return
+	}
+	if len(args) < 1 {
+		outputChan <- "用法: /unlock <用户名> [IP]"
+		return
+	}
+	ip := ""
+	if len(args) > 1 {
+		ip = args[1]
+	}
+	if err := cli.SendUnlockAccountReq(args[0], ip); err != nil {
+		outputChan <- fmt.Sprintf("解除锁定请求发送失败: %v", err)
+	} else {
+		outputChan <- "解除锁定请求已发送。等待响应..."
+	}
+}
+
+func handleTwoFactorLogin(args []string) {
+	if !ensureConnected() {
+		return
+	}
+	if len(args) < 1 {
+		outputChan <- "用法: /2fa <验证码或备用恢复码>"
+		return
+	}
+	loginResp, err := cli.LoginTwoFactor(args[0])
+	if err != nil {
+		outputChan <- fmt.Sprintf("登录失败: %v", err)
+		return
+	}
+	outputChan <- fmt.Sprintf("登录成功: %s (UUID: %s)", loginResp.Username, loginResp.UserUUID)
+	warnPendingDeletion(loginResp)
+}
+
+func handleGuestLogin(args []string) {
+	if !ensureConnected() {
+		return
+	}
+	loginResp, err := cli.LoginGuest(strings.Join(args, " "))
+	if err != nil {
+		outputChan <- fmt.Sprintf("访客登录失败: %v", err)
+		return
+	}
+	outputChan <- fmt.Sprintf("访客登录成功: %s (UUID: %s)", loginResp.Username, loginResp.UserUUID)
+	warnPendingDeletion(loginResp)
+}
+
+func handleBotLogin(args []string) {
+	if !ensureConnected() {
+		return
+	}
+	if len(args) < 1 {
+		outputChan <- "用法: /botlogin <API Key>"
+		return
+	}
+	loginResp, err := cli.LoginBot(args[0])
+	if err != nil {
+		outputChan <- fmt.Sprintf("机器人登录失败: %v", err)
+		return
+	}
+	outputChan <- fmt.Sprintf("机器人登录成功: %s (UUID: %s)", loginResp.Username, loginResp.UserUUID)
+}
+
+func handleBot(args []string) {
+	if !ensureLoggedIn() {
+		return
+	}
+	usage := "用法: /bot create <用户名> [昵称] | /bot rotate <机器人ID> | /bot list"
+	if len(args) < 1 {
+		outputChan <- usage
+		return
+	}
+	var err error
+	switch args[0] {
+	case "create":
+		if len(args) < 2 {
+			outputChan <- usage
+			return
+		}
+		err = cli.SendCreateBotReq(args[1], strings.Join(args[2:], " "))
+	case "rotate":
+		if len(args) < 2 {
+			outputChan <- usage
+			return
+		}
+		botID, parseErr := strconv.ParseUint(args[1], 10, 32)
+		if parseErr != nil {
+			outputChan <- "无效的机器人ID"
+			return
+		}
+		err = cli.SendRotateBotKeyReq(uint(botID))
+	case "list":
+		err = cli.SendGetMyBotsReq()
+	default:
+		outputChan <- usage
+		return
+	}
+	if err != nil {
+		outputChan <- fmt.Sprintf("机器人请求发送失败: %v", err)
+	} else {
+		outputChan <- "机器人请求已发送。等待响应..."
+	}
+}
+
+func handleBotWebhook(args []string) {
+	if !ensureLoggedIn() {
+		return
+	}
+	usage := "用法: /webhook set <机器人ID> <群ID> <URL> [/命令前缀] [mention] [rotate] | /webhook delete <机器人ID> <群ID> | /webhook list <机器人ID>"
+	if len(args) < 2 {
+		outputChan <- usage
+		return
+	}
+	botID, parseErr := strconv.ParseUint(args[1], 10, 32)
+	if parseErr != nil {
+		outputChan <- "无效的机器人ID"
+		return
+	}
+	var groupID uint64
+	if args[0] == "set" || args[0] == "delete" {
+		if len(args) < 3 {
+			outputChan <- usage
+			return
+		}
+		if groupID, parseErr = strconv.ParseUint(args[2], 10, 32); parseErr != nil {
+			outputChan <- "无效的群ID"
+			return
+		}
+	}
+
+	var err error
+	switch args[0] {
+	case "set":
+		if len(args) < 4 {
+			outputChan <- usage
+			return
+		}
+		req := &model.SetBotWebhookReq{BotUserID: uint(botID), GroupID: uint(groupID), URL: args[3]}
+		for _, opt := range args[4:] {
+			switch {
+			case strings.HasPrefix(opt, "/"):
+				req.CommandPrefix = opt
+			case opt == "mention":
+				req.OnMention = true
+			case opt == "rotate":
+				req.RotateSecret = true
+			default:
+				outputChan <- usage
+				return
+			}
+		}
+		err = cli.SendSetBotWebhookReq(req)
+	case "delete":
+		err = cli.SendDeleteBotWebhookReq(uint(botID), uint(groupID))
+	case "list":
+		err = cli.SendGetBotWebhooksReq(uint(botID))
+	default:
+		outputChan <- usage
+		return
+	}
+	if err != nil {
+		outputChan <- fmt.Sprintf("webhook 请求发送失败: %v", err)
+	} else {
+		outputChan <- "webhook 请求已发送。等待响应..."
+	}
+}
+
+// formatBotWebhook 格式化 webhook 的地址和触发方式
+func formatBotWebhook(hook *model.BotWebhook) string {
+	var triggers []string
+	if hook.CommandPrefix != "" {
+		triggers = append(triggers, "命令 "+hook.CommandPrefix)
+	}
+	if hook.OnMention {
+		triggers = append(triggers, "@ 提及")
+	}
+	return fmt.Sprintf("%s (触发: %s)", hook.URL, strings.Join(triggers, ", "))
+}
+
+func handleIncomingWebhook(args []string) {
+	if !ensureLoggedIn() {
+		return
+	}
+	usage := "用法: /hook create <群ID> <名称...> | /hook revoke <群ID> <webhookID> | /hook list <群ID>"
+	if len(args) < 2 {
+		outputChan <- usage
+		return
+	}
+	groupID, parseErr := strconv.ParseUint(args[1], 10, 32)
+	if parseErr != nil {
+		outputChan <- "无效的群ID"
+		return
+	}
+
+	var err error
+	switch args[0] {
+	case "create":
+		if len(args) < 3 {
+			outputChan <- usage
+			return
+		}
+		err = cli.SendCreateIncomingWebhookReq(uint(groupID), strings.Join(args[2:], " "))
+	case "revoke":
+		if len(args) < 3 {
+			outputChan <- usage
+			return
+		}
+		hookID, parseErr := strconv.ParseUint(args[2], 10, 32)
+		if parseErr != nil {
+			outputChan <- "无效的 webhook ID"
+			return
+		}
+		err = cli.SendRevokeIncomingWebhookReq(uint(groupID), uint(hookID))
+	case "list":
+		err = cli.SendGetIncomingWebhooksReq(uint(groupID))
+	default:
+		outputChan <- usage
+		return
+	}
+	if err != nil {
+		outputChan <- fmt.Sprintf("webhook 请求发送失败: %v", err)
+	} else {
+		outputChan <- "webhook 请求已发送。等待响应..."
+	}
+}
+
+func handleTOTP(args []string) {
+	if !ensureLoggedIn() {
+		return
+	}
+	usage := "用法: /totp enroll | /totp confirm <验证码> | /totp disable <密码> <验证码或备用恢复码>"
+	if len(args) < 1 {
+		outputChan <- usage
+		return
+	}
+	var err error
+	switch args[0] {
+	case "enroll":
+		err = cli.SendEnrollTOTPReq()
+	case "confirm":
+		if len(args) < 2 {
+			outputChan <- usage
+			return
+		}
+		err = cli.SendConfirmTOTPReq(args[1])
+	case "disable":
+		if len(args) < 3 {
+			outputChan <- usage
+			return
+		}
+		err = cli.SendDisableTOTPReq(args[1], args[2])
+	default:
+		outputChan <- usage
+		return
+	}
+	if err != nil {
+		outputChan <- fmt.Sprintf("两步验证请求发送失败: %v", err)
+	} else {
+		outputChan <- "两步验证请求已发送。等待响应..."
+	}
+}
+
+func handleLoginHistory(args []string) {
+	if !ensureLoggedIn() {
+		return
+	}
+	limit := 0
+	if len(args) > 0 {
+		l, err := strconv.Atoi(args[0])
+		if err != nil || l < 1 {
+			outputChan <- "用法: /loginhistory [条数]"
+			return
+		}
+		limit = l
+	}
+	if err := cli.SendGetLoginHistoryReq(limit); err != nil {
+		outputChan <- fmt.Sprintf("获取登录历史请求发送失败: %v", err)
+	} else {
+		outputChan <- "获取登录历史请求已发送。等待响应..."
+	}
+}
+
+// shortDeviceID 截取设备标识前8位用于显示
+func shortDeviceID(deviceID string) string {
+	if len(deviceID) > 8 {
+		return deviceID[:8]
+	}
+	return deviceID
+}
+
+// warnPendingDeletion 账号处于注销冷静期时提醒用户，访客账号提示到期时间和功能限制
+func warnPendingDeletion(loginResp *model.UserLoginResponse) {
+	if loginResp.AccountKind == model.AccountKindGuest {
+		expires := "到期后"
+		if loginResp.DeletionDueAt != nil {
+			expires = loginResp.DeletionDueAt.Format("2006-01-02 15:04:05") + " 到期后"
+		}
+		outputChan <- fmt.Sprintf("[访客] 你正在使用临时访客账号，%s将被清除。访客不能私聊、加好友或创建群组，只能加入服务器开放给访客的群组。", expires)
+		return
+	}
+	if loginResp.DeletionDueAt != nil {
+		outputChan <- fmt.Sprintf("[注销] 你的账号已申请注销，将于 %s 被永久清除。如需保留账号，请使用 /deleteaccount cancel 撤销。",
+			loginResp.DeletionDueAt.Format("2006-01-02 15:04:05"))
+	}
+}
+
+func handleDeleteAccount(args []string) {
+	if !ensureLoggedIn() {
+		return
+	}
+	if len(args) < 1 {
+		outputChan <- "用法: /deleteaccount <密码> [验证码或备用恢复码] | /deleteaccount cancel | /deleteaccount status"
+		return
+	}
+	var err error
+	switch args[0] {
+	case "cancel":
+		err = cli.SendCancelAccountDeletionReq()
+	case "status":
+		err = cli.SendGetAccountDeletionStatusReq()
+	default:
+		code := ""
+		if len(args) > 1 {
+			code = args[1]
+		}
+		err = cli.SendDeleteAccountReq(args[0], code)
+	}
+	if err != nil {
+		outputChan <- fmt.Sprintf("注销请求发送失败: %v", err)
+	} else {
+		outputChan <- "注销请求已发送。等待响应..."
+	}
+}
+
+func handleRequestDataExport() {
+	if !ensureLoggedIn() {
+		return
+	}
+	if err := cli.SendRequestDataExportReq(); err != nil {
+		outputChan <- fmt.Sprintf("数据导出请求发送失败: %v", err)
+	} else {
+		outputChan <- "数据导出请求已发送。等待响应..."
+	}
+}
+
+func handleDownloadExport(args []string) {
+	if !ensureLoggedIn() {
+		return
+	}
+	if len(args) < 1 {
+		outputChan <- "用法: /download <导出ID> [保存路径]"
+		return
+	}
+	exportID, err := strconv.ParseUint(args[0], 10, 32)
+	if err != nil {
+		outputChan <- "导出ID必须是数字"
+		return
+	}
+	path := fmt.Sprintf("chat-export-%d.zip", exportID)
+	if len(args) > 1 {
+		path = args[1]
+	}
+
+	exportDownload.Lock()
+	defer exportDownload.Unlock()
+	if exportDownload.file != nil {
+		outputChan <- fmt.Sprintf("导出文件 %d 正在下载中，请稍后", exportDownload.exportID)
+		return
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		outputChan <- fmt.Sprintf("创建文件失败: %v", err)
+		return
+	}
+	if err := cli.SendDownloadDataExportReq(uint(exportID), 0); err != nil {
+		file.Close()
+		os.Remove(path)
+		outputChan <- fmt.Sprintf("下载请求发送失败: %v", err)
+		return
+	}
+	exportDownload.exportID = uint(exportID)
+	exportDownload.path = path
+	exportDownload.file = file
+	outputChan <- fmt.Sprintf("开始下载导出文件到 %s ...", path)
+}
+
+// handleExportChunk 写入一个下载分片并请求下一片，返回需要显示的内容
+func handleExportChunk(data []byte) string {
+	exportDownload.Lock()
+	defer exportDownload.Unlock()
+	if exportDownload.file == nil {
+		return ""
+	}
+
+	finish := func() {
+		exportDownload.file.Close()
+		exportDownload.file = nil
+	}
+	if errMsg := parseErrorResp(data); errMsg != "" {
+		finish()
+		os.Remove(exportDownload.path)
+		return fmt.Sprintf("[错误] %s", errMsg)
+	}
+	var chunk model.DownloadDataExportResp
+	if err := json.Unmarshal(data, &chunk); err != nil || chunk.ExportID != exportDownload.exportID {
+		return ""
+	}
+	if _, err := exportDownload.file.WriteAt(chunk.Data, chunk.Offset); err != nil {
+		finish()
+		return fmt.Sprintf("[错误] 写入导出文件失败: %v", err)
+	}
+	if chunk.Done {
+		finish()
+		return fmt.Sprintf("[数据导出] 已保存到 %s (%d 字节)", exportDownload.path, chunk.Total)
+	}
+	if err := cli.SendDownloadDataExportReq(chunk.ExportID, chunk.Offset+int64(len(chunk.Data))); err != nil {
+		finish()
+		return fmt.Sprintf("[错误] 下载请求发送失败: %v", err)
+	}
+	return ""
+}
+
+// formatUserProfile 格式化用户资料，本人的资料额外显示隐私设置
+func formatUserProfile(profile *model.UserProfile) string {
+	name := profile.Username
+	if profile.Nickname != "" {
+		name = fmt.Sprintf("%s(%s)", profile.Nickname, profile.Username)
+	}
+	text := fmt.Sprintf("%s (ID:%d)", name, profile.ID)
+	if profile.IsOnline {
+		text += " [在线]"
+	}
+	if profile.Gender != "" {
+		text += " 性别: " + profile.Gender
+	}
+	if profile.Avatar != "" {
+		text += " 头像: " + profile.Avatar
+	}
+	if profile.Bio != "" {
+		text += " 简介: " + profile.Bio
+	}
+	if profile.Searchable != nil {
+		text += fmt.Sprintf(" | 可被搜索: %t, 私聊: %s", *profile.Searchable, formatDMPrivacy(profile.DMPrivacy))
+	}
+	return text
+}
+
+func handleBlockUser(args []string) {
+	if !ensureLoggedIn() {
+		return
+	}
+	if len(args) < 1 {
+		outputChan <- "用法: /block <用户名/UUID/用户ID>"
+		return
+	}
+	if err := cli.SendBlockUserReq(args[0]); err != nil {
+		outputChan <- fmt.Sprintf("屏蔽用户请求发送失败: %v", err)
+	} else {
+		outputChan <- "屏蔽用户请求已发送。等待响应..."
+	}
+}
+
+func handleUnblockUser(args []string) {
+	if !ensureLoggedIn() {
+		return
+	}
+	if len(args) < 1 {
+		outputChan <- "用法: /unblock <用户ID>"
+		return
+	}
+	userID, err := strconv.ParseUint(args[0], 10, 32)
+	if err != nil {
+		outputChan <- "无效的用户ID。"
+		return
+	}
+	if err := cli.SendUnblockUserReq(uint(userID)); err != nil {
+		outputChan <- fmt.Sprintf("取消屏蔽请求发送失败: %v", err)
+	} else {
+		outputChan <- "取消屏蔽请求已发送。等待响应..."
+	}
+}
+
+// handleDMPrivacy 不带参数时查询当前设置，带参数时修改设置
+func handleDMPrivacy(args []string) {
+	if !ensureLoggedIn() {
+		return
+	}
+	if len(args) < 1 {
+		handleSimpleRequest(cli.SendGetDMPrivacyReq, "私聊隐私设置")
+		return
+	}
+	privacy := strings.ToLower(args[0])
+	if privacy != model.DMPrivacyEveryone && privacy != model.DMPrivacyContacts && privacy != model.DMPrivacyNobody {
+		outputChan <- "用法: /dmprivacy [everyone|contacts|nobody]"
+		return
+	}
+	if err := cli.SendSetDMPrivacyReq(privacy); err != nil {
+		outputChan <- fmt.Sprintf("设置私聊隐私请求发送失败: %v", err)
+	} else {
+		outputChan <- "设置私聊隐私请求已发送。等待响应..."
+	}
+}
+
+// formatDMPrivacy 格式化私聊隐私设置
+func formatDMPrivacy(privacy string) string {
+	switch privacy {
+	case model.DMPrivacyContacts:
+		return "仅好友可以私聊"
+	case model.DMPrivacyNobody:
+		return "不接收私聊"
+	default:
+		return "所有人可以私聊"
+	}
+}
+
+// formatFriendRequest 格式化好友申请
+func formatFriendRequest(info *model.FriendRequestInfo) string {
+	status := map[string]string{
+		model.FriendRequestPending:  "待处理",
+		model.FriendRequestAccepted: "已接受",
+		model.FriendRequestRejected: "已拒绝",
+	}[info.Status]
+	text := fmt.Sprintf("申请ID:%d %s(ID:%d) -> %s(ID:%d) [%s] %s", info.ID, info.FromUsername, info.FromUserID,
+		info.ToUsername, info.ToUserID, status, info.CreatedAt)
+	if info.Message != "" {
+		text += " 附言: " + info.Message
+	}
+	return text
+}
+
+func handleCreateGroup(args []string) {
+	if !ensureLoggedIn() {
+		return
+	}
+	if len(args) < 1 {
+		outputChan <- "用法: /creategroup <群名称> [描述] [头像URL]"
+		return
+	}
+	name := args[0]
+	description := ""
+	avatar := ""
+	if len(args) > 1 {
+		description = args[1]
+	}
+	if len(args) > 2 {
+		avatar = args[2]
+	}
+	err := cli.SendCreateGroupReq(name, description, avatar)
+	if err != nil {
+		outputChan <- fmt.Sprintf("创建群组请求发送失败: %v", err)
+	} else {
+		outputChan <- "创建群组请求已发送。等待响应..."
 	}
 }
 
@@ -551,7 +2166,278 @@ func handleJoinGroup(args []string) {
 		return
 	}
 	if len(args) < 1 {
-		outputChan <- "用法: /joingroup <group_id>"
+		outputChan <- "用法: /joingroup <group_id>"
+		return
+	}
+	groupID, err := strconv.ParseUint(args[0], 10, 32)
+	if err != nil {
+		outputChan <- "无效的群组ID。"
+		return
+	}
+	err = cli.SendJoinGroupReq(uint(groupID))
+	if err != nil {
+		outputChan <- fmt.Sprintf("加入群组请求发送失败: %v", err)
+	} else {
+		outputChan <- "加入群组请求已发送。等待响应..."
+	}
+}
+
+func handleLeaveGroup(args []string) {
+	if !ensureLoggedIn() {
+		return
+	}
+	if len(args) < 1 {
+		outputChan <- "用法: /leavegroup <group_id>"
+		return
+	}
+	groupID, err := strconv.ParseUint(args[0], 10, 32)
+	if err != nil {
+		outputChan <- "无效的群组ID。"
+		return
+	}
+	err = cli.SendLeaveGroupReq(uint(groupID))
+	if err != nil {
+		outputChan <- fmt.Sprintf("离开群组请求发送失败: %v", err)
+	} else {
+		outputChan <- "离开群组请求已发送。等待响应..."
+	}
+}
+
+func handleGroupMembers(args []string) {
+	if !ensureLoggedIn() {
+		return
+	}
+	if len(args) < 1 {
+		outputChan <- "用法: /members <群ID> [页码] [用户名关键字]"
+		return
+	}
+	groupID, err := strconv.ParseUint(args[0], 10, 32)
+	if err != nil {
+		outputChan <- "无效的群组ID。"
+		return
+	}
+	page := 1
+	if len(args) > 1 {
+		page, err = strconv.Atoi(args[1])
+		if err != nil || page <= 0 {
+			outputChan <- "无效的页码，使用默认值 1。"
+			page = 1
+		}
+	}
+	keyword := ""
+	if len(args) > 2 {
+		keyword = args[2]
+	}
+	err = cli.SendGetGroupMembersReq(uint(groupID), page, 0, keyword)
+	if err != nil {
+		outputChan <- fmt.Sprintf("获取群成员请求发送失败: %v", err)
+	} else {
+		outputChan <- "获取群成员请求已发送。等待响应..."
+	}
+}
+
+func handleSearchGroups(args []string) {
+	if !ensureLoggedIn() {
+		return
+	}
+	var req model.SearchGroupsReq
+	var keywords []string
+	for _, arg := range args {
+		key, value, found := strings.Cut(arg, "=")
+		if !found {
+			keywords = append(keywords, arg)
+			continue
+		}
+		switch key {
+		case "category":
+			req.Category = value
+		case "tag":
+			req.Tag = value
+		case "page":
+			page, err := strconv.Atoi(value)
+			if err != nil || page < 1 {
+				outputChan <- "无效的页码。"
+				return
+			}
+			req.Page = page
+		default:
+			keywords = append(keywords, arg)
+		}
+	}
+	req.Keyword = strings.Join(keywords, " ")
+	err := cli.SendSearchGroupsReq(req)
+	if err != nil {
+		outputChan <- fmt.Sprintf("搜索群组请求发送失败: %v", err)
+	} else {
+		outputChan <- "搜索群组请求已发送。等待响应..."
+	}
+}
+
+func handleSetGroupDirectory(args []string) {
+	if !ensureLoggedIn() {
+		return
+	}
+	if len(args) < 2 || (args[1] != "public" && args[1] != "private") {
+		outputChan <- "用法: /setdirectory <群ID> <public|private> [分类|-] [标签1,标签2,...]"
+		return
+	}
+	groupID, err := strconv.ParseUint(args[0], 10, 32)
+	if err != nil {
+		outputChan <- "无效的群组ID。"
+		return
+	}
+	var category *string
+	if len(args) > 2 {
+		value := args[2]
+		if value == "-" {
+			value = ""
+		}
+		category = &value
+	}
+	var tags []string
+	if len(args) > 3 {
+		tags = []string{}
+		for _, tag := range strings.Split(args[3], ",") {
+			if tag = strings.TrimSpace(tag); tag != "" {
+				tags = append(tags, tag)
+			}
+		}
+	}
+	err = cli.SendSetGroupDirectoryReq(uint(groupID), args[1] == "public", category, tags)
+	if err != nil {
+		outputChan <- fmt.Sprintf("群组目录设置请求发送失败: %v", err)
+	} else {
+		outputChan <- "群组目录设置请求已发送。等待响应..."
+	}
+}
+
+func handleInviteMember(args []string) {
+	if !ensureLoggedIn() {
+		return
+	}
+	if len(args) < 2 {
+		outputChan <- "用法: /invite <群ID> <用户ID>"
+		return
+	}
+	groupID, err := strconv.ParseUint(args[0], 10, 32)
+	if err != nil {
+		outputChan <- "无效的群组ID。"
+		return
+	}
+	targetUserID, err := strconv.ParseUint(args[1], 10, 32)
+	if err != nil {
+		outputChan <- "无效的用户ID。"
+		return
+	}
+	err = cli.SendInviteGroupMemberReq(uint(groupID), uint(targetUserID))
+	if err != nil {
+		outputChan <- fmt.Sprintf("邀请入群请求发送失败: %v", err)
+	} else {
+		outputChan <- "邀请入群请求已发送。等待响应..."
+	}
+}
+
+func handleBanMember(args []string) {
+	if !ensureLoggedIn() {
+		return
+	}
+	if len(args) < 2 {
+		outputChan <- "用法: /ban <群ID> <用户ID> [时长(分钟), 0为永久] [原因...]"
+		return
+	}
+	groupID, err := strconv.ParseUint(args[0], 10, 32)
+	if err != nil {
+		outputChan <- "无效的群组ID。"
+		return
+	}
+	targetUserID, err := strconv.ParseUint(args[1], 10, 32)
+	if err != nil {
+		outputChan <- "无效的用户ID。"
+		return
+	}
+	var minutes int64
+	if len(args) > 2 {
+		minutes, err = strconv.ParseInt(args[2], 10, 64)
+		if err != nil || minutes < 0 {
+			outputChan <- "无效的封禁时长。"
+			return
+		}
+	}
+	reason := ""
+	if len(args) > 3 {
+		reason = strings.Join(args[3:], " ")
+	}
+	err = cli.SendBanGroupMemberReq(uint(groupID), uint(targetUserID), reason, minutes*60)
+	if err != nil {
+		outputChan <- fmt.Sprintf("封禁请求发送失败: %v", err)
+	} else {
+		outputChan <- "封禁请求已发送。等待响应..."
+	}
+}
+
+func handleUnbanMember(args []string) {
+	if !ensureLoggedIn() {
+		return
+	}
+	if len(args) < 2 {
+		outputChan <- "用法: /unban <群ID> <用户ID>"
+		return
+	}
+	groupID, err := strconv.ParseUint(args[0], 10, 32)
+	if err != nil {
+		outputChan <- "无效的群组ID。"
+		return
+	}
+	targetUserID, err := strconv.ParseUint(args[1], 10, 32)
+	if err != nil {
+		outputChan <- "无效的用户ID。"
+		return
+	}
+	err = cli.SendUnbanGroupMemberReq(uint(groupID), uint(targetUserID))
+	if err != nil {
+		outputChan <- fmt.Sprintf("解除封禁请求发送失败: %v", err)
+	} else {
+		outputChan <- "解除封禁请求已发送。等待响应..."
+	}
+}
+
+func handleMuteMember(args []string) {
+	if !ensureLoggedIn() {
+		return
+	}
+	if len(args) < 3 {
+		outputChan <- "用法: /mute <群ID> <用户ID> <时长(分钟), 0为解除禁言>"
+		return
+	}
+	groupID, err := strconv.ParseUint(args[0], 10, 32)
+	if err != nil {
+		outputChan <- "无效的群组ID。"
+		return
+	}
+	targetUserID, err := strconv.ParseUint(args[1], 10, 32)
+	if err != nil {
+		outputChan <- "无效的用户ID。"
+		return
+	}
+	minutes, err := strconv.ParseInt(args[2], 10, 64)
+	if err != nil || minutes < 0 {
+		outputChan <- "无效的禁言时长。"
+		return
+	}
+	err = cli.SendMuteGroupMemberReq(uint(groupID), uint(targetUserID), minutes*60)
+	if err != nil {
+		outputChan <- fmt.Sprintf("禁言请求发送失败: %v", err)
+	} else {
+		outputChan <- "禁言请求已发送。等待响应..."
+	}
+}
+
+func handleBanList(args []string) {
+	if !ensureLoggedIn() {
+		return
+	}
+	if len(args) < 1 {
+		outputChan <- "用法: /banlist <群ID>"
 		return
 	}
 	groupID, err := strconv.ParseUint(args[0], 10, 32)
@@ -559,20 +2445,101 @@ func handleJoinGroup(args []string) {
 		outputChan <- "无效的群组ID。"
 		return
 	}
-	err = cli.SendJoinGroupReq(uint(groupID))
+	err = cli.SendGetGroupBanListReq(uint(groupID))
 	if err != nil {
-		outputChan <- fmt.Sprintf("加入群组请求发送失败: %v", err)
+		outputChan <- fmt.Sprintf("获取封禁列表请求发送失败: %v", err)
 	} else {
-		outputChan <- "加入群组请求已发送。等待响应..."
+		outputChan <- "封禁列表请求已发送。等待响应..."
 	}
 }
 
-func handleLeaveGroup(args []string) {
+func handleSetMemberRole(args []string) {
+	if !ensureLoggedIn() {
+		return
+	}
+	if len(args) < 3 {
+		outputChan <- "用法: /setrole <群ID> <用户ID> <角色名>"
+		return
+	}
+	groupID, err := strconv.ParseUint(args[0], 10, 32)
+	if err != nil {
+		outputChan <- "无效的群组ID。"
+		return
+	}
+	targetUserID, err := strconv.ParseUint(args[1], 10, 32)
+	if err != nil {
+		outputChan <- "无效的用户ID。"
+		return
+	}
+	err = cli.SendSetGroupMemberRoleReq(uint(groupID), uint(targetUserID), args[2])
+	if err != nil {
+		outputChan <- fmt.Sprintf("设置角色请求发送失败: %v", err)
+	} else {
+		outputChan <- "设置角色请求已发送。等待响应..."
+	}
+}
+
+func handleSaveRole(args []string) {
+	if !ensureLoggedIn() {
+		return
+	}
+	if len(args) < 3 {
+		outputChan <- "用法: /saverole <群ID> <角色名> <优先级(1-99)> [权限1,权限2,...]"
+		return
+	}
+	groupID, err := strconv.ParseUint(args[0], 10, 32)
+	if err != nil {
+		outputChan <- "无效的群组ID。"
+		return
+	}
+	priority, err := strconv.Atoi(args[2])
+	if err != nil {
+		outputChan <- "无效的优先级。"
+		return
+	}
+	var permissions []string
+	if len(args) > 3 {
+		for _, perm := range strings.Split(args[3], ",") {
+			if perm = strings.TrimSpace(perm); perm != "" {
+				permissions = append(permissions, perm)
+			}
+		}
+	}
+	err = cli.SendSaveGroupRoleReq(uint(groupID), args[1], priority, permissions)
+	if err != nil {
+		outputChan <- fmt.Sprintf("保存角色请求发送失败: %v", err)
+	} else {
+		outputChan <- "保存角色请求已发送。等待响应..."
+	}
+}
+
+func handleDeleteRole(args []string) {
+	if !ensureLoggedIn() {
+		return
+	}
+	if len(args) < 2 {
+		outputChan <- "用法: /deleterole <群ID> <角色名>"
+		return
+	}
+	groupID, err := strconv.ParseUint(args[0], 10, 32)
+	if err != nil {
+		outputChan <- "无效的群组ID。"
+		return
+	}
+	err = cli.SendDeleteGroupRoleReq(uint(groupID), args[1])
+	if err != nil {
+		outputChan <- fmt.Sprintf("删除角色请求发送失败: %v", err)
+	} else {
+		outputChan <- "删除角色请求已发送。等待响应..."
+	}
+}
+
+func handleGroupRoles(args []string) {
 	if !ensureLoggedIn() {
 		return
 	}
 	if len(args) < 1 {
-		outputChan <- "用法: /leavegroup <group_id>"
+		outputChan <- "用法: /roles <群ID>"
 		return
 	}
 	groupID, err := strconv.ParseUint(args[0], 10, 32)
@@ -580,26 +2547,200 @@ func handleLeaveGroup(args []string) {
 		outputChan <- "无效的群组ID。"
 		return
 	}
-	err = cli.SendLeaveGroupReq(uint(groupID))
+	err = cli.SendGetGroupRolesReq(uint(groupID))
 	if err != nil {
-		outputChan <- fmt.Sprintf("离开群组请求发送失败: %v", err)
+		outputChan <- fmt.Sprintf("获取角色列表请求发送失败: %v", err)
 	} else {
-		outputChan <- "离开群组请求已发送。等待响应..."
+		outputChan <- "角色列表请求已发送。等待响应..."
+	}
+}
+
+// formatGroupRole 格式化角色信息
+func formatGroupRole(role *model.GroupRoleInfo) string {
+	kind := "自定义"
+	if role.IsBuiltin {
+		kind = "内置"
+	}
+	perms := "无"
+	if len(role.Permissions) > 0 {
+		perms = strings.Join(role.Permissions, ",")
+	}
+	return fmt.Sprintf("%s [%s, 优先级 %d] 权限: %s", role.Name, kind, role.Priority, perms)
+}
+
+func handleUpdateAnnouncement(args []string) {
+	if !ensureLoggedIn() {
+		return
+	}
+	if len(args) < 2 {
+		outputChan <- "用法: /announce <群ID> <公告内容...>"
+		return
+	}
+	groupID, err := strconv.ParseUint(args[0], 10, 32)
+	if err != nil {
+		outputChan <- "无效的群组ID。"
+		return
+	}
+	content := strings.Join(args[1:], " ")
+	err = cli.SendUpdateGroupAnnouncementReq(uint(groupID), content)
+	if err != nil {
+		outputChan <- fmt.Sprintf("编辑群公告请求发送失败: %v", err)
+	} else {
+		outputChan <- "编辑群公告请求已发送。等待响应..."
+	}
+}
+
+func handleAnnouncementHistory(args []string) {
+	if !ensureLoggedIn() {
+		return
+	}
+	if len(args) < 1 {
+		outputChan <- "用法: /announcehistory <群ID> [limit]"
+		return
+	}
+	groupID, err := strconv.ParseUint(args[0], 10, 32)
+	if err != nil {
+		outputChan <- "无效的群组ID。"
+		return
+	}
+	limit := 0 // 0 表示使用服务端默认值
+	if len(args) > 1 {
+		limit, err = strconv.Atoi(args[1])
+		if err != nil || limit <= 0 {
+			outputChan <- "无效的 limit 参数，使用服务端默认值。"
+			limit = 0
+		}
+	}
+	err = cli.SendGetGroupAnnouncementHistoryReq(uint(groupID), limit)
+	if err != nil {
+		outputChan <- fmt.Sprintf("获取群公告历史请求发送失败: %v", err)
+	} else {
+		outputChan <- "群公告历史请求已发送。等待响应..."
+	}
+}
+
+func handleAckAnnouncement(args []string) {
+	if !ensureLoggedIn() {
+		return
+	}
+	if len(args) < 2 {
+		outputChan <- "用法: /ackannounce <群ID> <公告ID>"
+		return
+	}
+	groupID, err := strconv.ParseUint(args[0], 10, 32)
+	if err != nil {
+		outputChan <- "无效的群组ID。"
+		return
+	}
+	announcementID, err := strconv.ParseUint(args[1], 10, 32)
+	if err != nil {
+		outputChan <- "无效的公告ID。"
+		return
+	}
+	err = cli.SendAckGroupAnnouncementReq(uint(groupID), uint(announcementID))
+	if err != nil {
+		outputChan <- fmt.Sprintf("确认群公告请求发送失败: %v", err)
+	} else {
+		outputChan <- "确认群公告请求已发送。等待响应..."
+	}
+}
+
+// handlePinMessage 处理 /pin 和 /unpin
+func handlePinMessage(args []string, pin bool) {
+	if !ensureLoggedIn() {
+		return
+	}
+	command := "/unpin"
+	if pin {
+		command = "/pin"
+	}
+	if len(args) < 2 {
+		outputChan <- fmt.Sprintf("用法: %s <群ID> <群消息ID>", command)
+		return
+	}
+	groupID, err := strconv.ParseUint(args[0], 10, 32)
+	if err != nil {
+		outputChan <- "无效的群组ID。"
+		return
+	}
+	groupMessageID, err := strconv.ParseUint(args[1], 10, 32)
+	if err != nil {
+		outputChan <- "无效的群消息ID。"
+		return
+	}
+	if pin {
+		err = cli.SendPinGroupMessageReq(uint(groupID), uint(groupMessageID))
+	} else {
+		err = cli.SendUnpinGroupMessageReq(uint(groupID), uint(groupMessageID))
+	}
+	if err != nil {
+		outputChan <- fmt.Sprintf("置顶请求发送失败: %v", err)
+	} else {
+		outputChan <- "置顶请求已发送。等待响应..."
 	}
 }
 
 func handleHelp() {
 	outputChan <- "可用命令:"
 	outputChan <- "  /connect [host:port] - 连接到服务器 (默认 127.0.0.1:9000)"
+	outputChan <- "  /connect [host:port] tls [ca=证书路径] [name=服务器名] [insecure] [cert=证书路径 key=私钥路径] - 通过 TLS 连接服务器"
 	outputChan <- "  /register <username> <password> <email> - 注册新用户"
 	outputChan <- "  /login <username> <password> - 登录"
+	outputChan <- "  /2fa <验证码或备用恢复码> - 开启了两步验证的账号在 /login 后完成登录"
+	outputChan <- "  /guest [昵称] - 以临时访客身份登录 (需服务器开启)"
+	outputChan <- "  /botlogin <API Key> - 以机器人账号登录"
 	outputChan <- "  /msg <接收者用户名/UserUUID> [消息内容...] - 发送私聊消息"
 	outputChan <- "  /groupmsg <群组ID> [消息内容...] - 发送群聊消息"
+	outputChan <- "  /groupfile <群组ID> <image|file> <文件地址> - 发送群聊附件消息 (需 upload_file 权限)"
 	outputChan <- "  /history <对方用户名或UUID> [limit] - 获取与某人的历史消息"
 	outputChan <- "  /grouphistory <群组ID> [最后一条消息ID] [limit] - 获取群组历史消息"
+	outputChan <- "  /addfriend <用户名/UUID/用户ID> [附言...] - 发送好友申请"
+	outputChan <- "  /accept <申请ID> | /reject <申请ID> - 接受/拒绝好友申请"
+	outputChan <- "  /friendrequests - 查看待处理的好友申请"
+	outputChan <- "  /friends - 查看好友列表及在线状态"
+	outputChan <- "  /unfriend <好友用户ID> - 删除好友"
+	outputChan <- "  /remark <好友用户ID> [备注...] - 设置好友备注 (不填则清除)"
+	outputChan <- "  /profile [用户名/UUID/用户ID] - 查看用户资料 (不填则查看自己)"
+	outputChan <- "  /setprofile <nickname|avatar|gender|bio|searchable> [值...] - 修改资料 (不填值则清除)"
+	outputChan <- "  /searchusers <关键字> [page=页码] - 按用户名或昵称搜索用户"
+	outputChan <- "  /passwd <旧密码> <新密码> - 修改密码，其他已登录的会话会被下线"
+	outputChan <- "  /forgotpassword <用户名/邮箱> - 申请重置密码，令牌发送到绑定邮箱"
+	outputChan <- "  /resetpassword <令牌> <新密码> - 使用邮件中的令牌重置密码"
+	outputChan <- "  /sendverify - 重新发送邮箱验证邮件"
+	outputChan <- "  /verifyemail <令牌> - 使用邮件中的令牌验证邮箱"
+	outputChan <- "  /unlock <用户名> [IP] - (管理员) 解除账号和IP的登录锁定"
+	outputChan <- "  /totp enroll | confirm <验证码> | disable <密码> <验证码> - 管理两步验证"
+	outputChan <- "  /loginhistory [条数] - 查看自己最近的登录记录"
+	outputChan <- "  /deleteaccount <密码> [验证码] | cancel | status - 申请注销账号、撤销注销或查看注销状态"
+	outputChan <- "  /export - 导出个人资料、好友、群组和聊天记录"
+	outputChan <- "  /download <导出ID> [保存路径] - 下载已生成的导出文件"
+	outputChan <- "  /bot create <用户名> [昵称] | rotate <机器人ID> | list - 创建机器人、重新生成 API Key 或查看自己的机器人"
+	outputChan <- "  /webhook set <机器人ID> <群ID> <URL> [/命令前缀] [mention] [rotate] | delete <机器人ID> <群ID> | list <机器人ID> - 管理机器人的群 webhook"
+	outputChan <- "  /block <用户名/UUID/用户ID> - 屏蔽用户，对方不能给你发私聊、加好友或拉你入群"
+	outputChan <- "  /unblock <用户ID> - 取消屏蔽"
+	outputChan <- "  /blocklist - 查看屏蔽列表"
+	outputChan <- "  /dmprivacy [everyone|contacts|nobody] - 查看或设置谁可以给你发私聊"
 	outputChan <- "  /creategroup <群名称> [描述] [头像URL] - 创建群组"
 	outputChan <- "  /joingroup <群ID> - 加入群组"
 	outputChan <- "  /leavegroup <群ID> - 离开群组"
+	outputChan <- "  /members <群ID> [页码] [用户名关键字] - 分页查看/搜索群成员"
+	outputChan <- "  /searchgroups [关键字...] [category=分类] [tag=标签] [page=页码] - 搜索公开群组"
+	outputChan <- "  /setdirectory <群ID> <public|private> [分类|-] [标签1,标签2,...] - 设置群组是否公开及分类标签 (需 edit_info 权限)"
+	outputChan <- "  /invite <群ID> <用户ID> - 邀请用户入群 (需 invite_member 权限)"
+	outputChan <- "  /ban <群ID> <用户ID> [时长(分钟)] [原因...] - 封禁并移出用户 (需 ban_member 权限)"
+	outputChan <- "  /unban <群ID> <用户ID> - 解除封禁 (需 ban_member 权限)"
+	outputChan <- "  /mute <群ID> <用户ID> <时长(分钟)> - 禁言成员，时长为 0 时解除 (需 mute_member 权限)"
+	outputChan <- "  /banlist <群ID> - 查看群组封禁列表 (需 ban_member 权限)"
+	outputChan <- "  /setrole <群ID> <用户ID> <角色名> - 设置成员角色 (需 manage_roles 权限)"
+	outputChan <- "  /saverole <群ID> <角色名> <优先级> [权限,...] - 创建/修改自定义角色 (需 manage_roles 权限)"
+	outputChan <- "  /deleterole <群ID> <角色名> - 删除自定义角色 (需 manage_roles 权限)"
+	outputChan <- "  /roles <群ID> - 查看群组角色及权限"
+	outputChan <- "  /hook create <群ID> <名称...> | revoke <群ID> <webhookID> | list <群ID> - 管理外部系统发消息用的 incoming webhook (需 manage_integrations 权限)"
+	outputChan <- "  /announce <群ID> <公告内容...> - 编辑群公告 (需 edit_announcement 权限)"
+	outputChan <- "  /announcehistory <群ID> [limit] - 查看群公告编辑历史"
+	outputChan <- "  /ackannounce <群ID> <公告ID> - 确认已阅读群公告"
+	outputChan <- "  /pin <群ID> <群消息ID> - 置顶群消息 (需 pin_message 权限)"
+	outputChan <- "  /unpin <群ID> <群消息ID> - 取消置顶群消息 (需 pin_message 权限)"
 	outputChan <- "  /cancel - 取消当前操作 (例如，在输入多行消息时)"
 	outputChan <- "  /help - 显示此帮助信息"
 	outputChan <- "  /quit 或 /exit - 退出客户端"
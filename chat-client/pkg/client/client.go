@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"io"
 	"net"
+	"sync"
 	"time"
 
 	clientProtocol "github.com/Xaytick/chat-zinx/chat-client/pkg/protocol" // Client's own protocol for Message/DataPack
@@ -13,6 +14,15 @@ import (
 	serverProtocol "github.com/Xaytick/chat-zinx/chat-server/pkg/protocol" // Alias for server's protocol constants
 )
 
+// loginCodeTwoFactorRequired 登录响应中表示需要两步验证的状态码
+const loginCodeTwoFactorRequired = 7
+
+// ErrTwoFactorRequired 密码正确但账号开启了两步验证，需调用 LoginTwoFactor 完成登录
+var ErrTwoFactorRequired = errors.New("需要两步验证")
+
+// ErrLoginRejected 服务端拒绝了登录请求（用户名或密码错误、账号被锁定等）
+var ErrLoginRejected = errors.New("登录失败")
+
 // ChatClient 聊天客户端结构体
 type ChatClient struct {
 	Conn       net.Conn
@@ -21,44 +31,101 @@ type ChatClient struct {
 	UserUUID   string // User's UUID
 	Username   string // User's username
 	Token      string // JWT Token
+	DeviceID   string // 本机设备标识，登录时上报
 
-	isLoggedIn       bool
-	heartbeatStop    chan struct{}
 	msgHandler       func(msgID uint32, data []byte)         // Callback for received messages
+	respMu           sync.Mutex                              // 保护 responseChannels，登记在调用方协程，取出在消息监听器
 	responseChannels map[uint32]chan *clientProtocol.Message // New: Map to hold channels for pending responses
 	requestTimeout   time.Duration                           // New: Timeout for requests
-}
+	tlsOptions       *TLSOptions                             // 为 nil 时使用明文 TCP
+	resume           resumeState                             // 断线恢复会话所需的令牌和推送序号
 
-// NewChatClient 创建一个新的聊天客户端
-func NewChatClient(serverAddr string) (*ChatClient, error) {
-	conn, err := net.Dial("tcp", serverAddr)
-	if err != nil {
-		return nil, fmt.Errorf("连接服务器失败: %v", err)
-	}
+	mu             sync.Mutex // 保护 Conn 的替换、写入以及以下字段
+	isLoggedIn     bool
+	reconnect      *ReconnectPolicy
+	state          ConnState
+	closed         bool
+	outbox         []outboxMsg
+	heartbeatStop  chan struct{} // 关闭时停止当前的心跳协程
+	onStateChange  func(state ConnState, err error)
+	relogin        func() error  // 会话无法恢复时重新登录，只在 API Key 登录后保存；其他账号凭 Token 重新登录，密码不在内存中保留
+	lastRecv       time.Time     // 最近一次收到服务端消息的时间，用于判断心跳是否超时
+	goingAway      bool          // 当前连接所在的节点即将关闭
+	goingAwayDelay time.Duration // 节点关闭后第一次重连前的等待时间
+	serverInfo     *model.HelloResp
+}
 
-	return &ChatClient{
-		Conn:             conn,
+// NewChatClient 创建一个新的聊天客户端，可通过 WithTLS 等选项定制连接方式
+func NewChatClient(serverAddr string, options ...func(*ChatClient)) (*ChatClient, error) {
+	c := &ChatClient{
 		ServerAddr:       serverAddr,
-		heartbeatStop:    make(chan struct{}),
+		DeviceID:         loadDeviceID(),
 		responseChannels: make(map[uint32]chan *clientProtocol.Message), // Initialize map
 		requestTimeout:   10 * time.Second,                              // Default timeout
-	}, nil
+	}
+	for _, option := range options {
+		option(c)
+	}
+
+	conn, err := c.connect()
+	if err != nil {
+		return nil, fmt.Errorf("连接服务器失败: %w", err)
+	}
+	c.Conn = conn
+	return c, nil
+}
+
+// TLSOptions 返回连接使用的 TLS 选项，明文连接时为 nil
+func (c *ChatClient) TLSOptions() *TLSOptions {
+	return c.tlsOptions
 }
 
-// Close 关闭客户端连接
+// Close 关闭客户端连接，之后不再自动重连
 func (c *ChatClient) Close() {
-		c.StopHeartbeat()
-	if c.Conn != nil {
-		c.Conn.Close()
-	}
+	c.mu.Lock()
+	c.closed = true
+	prevState := c.state
+	c.state = StateClosed
+	c.outbox = nil
 	c.isLoggedIn = false
+	conn := c.Conn
+	c.mu.Unlock()
+
+	c.StopHeartbeat()
+	if conn != nil {
+		conn.Close()
+	}
+	if prevState != StateClosed {
+		c.notifyState(StateClosed, nil)
+	}
 }
 
 // SendMessage 封装了消息的打包和发送过程
+// 开启自动重连时，断线期间或发送失败的消息进入发件箱，重连并恢复登录后按顺序发送
 func (c *ChatClient) SendMessage(msgID uint32, data []byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	queueable := c.reconnect != nil && !sessionMsgIDs[msgID]
+	if queueable && c.state == StateReconnecting {
+		return c.enqueueLocked(msgID, data)
+	}
+	err := c.writeLocked(msgID, data)
+	if err != nil && queueable && c.state == StateConnected && c.Conn != nil {
+		// 写失败说明连接已失效，关闭后由消息监听器进入重连
+		c.Conn.Close()
+		return c.enqueueLocked(msgID, data)
+	}
+	return err
+}
+
+// writeLocked 打包并写入当前连接，调用方需持有 c.mu
+func (c *ChatClient) writeLocked(msgID uint32, data []byte) error {
 	if c.Conn == nil {
 		return errors.New("connection is not established")
 	}
+	if max := c.maxPacketSizeLocked(); max > 0 && len(data) > max {
+		return fmt.Errorf("消息长度 %d 超过服务端限制 %d", len(data), max)
+	}
 	msg := &clientProtocol.Message{ // Use clientProtocol.Message
 		DataLen: uint32(len(data)),
 		ID:      msgID,
@@ -71,19 +138,23 @@ func (c *ChatClient) SendMessage(msgID uint32, data []byte) error {
 		return fmt.Errorf("failed to pack message: %w", err)
 	}
 
+	if c.reconnect != nil {
+		// 避免写阻塞时一直占着锁，超时后按连接失效处理
+		c.Conn.SetWriteDeadline(time.Now().Add(c.requestTimeout))
+	}
 	_, err = c.Conn.Write(packedMsg)
 	return err
 }
 
-// readMessage 读取并解包一个完整的消息
-func (c *ChatClient) readMessage() (*clientProtocol.Message, error) { // Return clientProtocol.Message
-	if c.Conn == nil {
+// readMessage 从 conn 读取并解包一个完整的消息
+func (c *ChatClient) readMessage(conn net.Conn) (*clientProtocol.Message, error) { // Return clientProtocol.Message
+	if conn == nil {
 		return nil, errors.New("connection is not established")
 	}
-	dp := clientProtocol.NewDataPack() // Use clientProtocol.NewDataPack
+	dp := clientProtocol.NewDataPackWithCompression(c.compression())
 
 	headData := make([]byte, dp.GetHeadLen())
-	if _, err := io.ReadFull(c.Conn, headData); err != nil {
+	if _, err := io.ReadFull(conn, headData); err != nil {
 		return nil, fmt.Errorf("read message head error: %w", err)
 	}
 
@@ -95,10 +166,13 @@ func (c *ChatClient) readMessage() (*clientProtocol.Message, error) { // Return
 	// msg := msgHead.(*clientProtocol.Message) // No type assertion needed here
 	if msg.GetDataLen() > 0 {
 		msg.Data = make([]byte, msg.GetDataLen())
-		if _, err := io.ReadFull(c.Conn, msg.Data); err != nil {
+		if _, err := io.ReadFull(conn, msg.Data); err != nil {
 			return nil, fmt.Errorf("read message data error: %w", err)
 		}
 	}
+	if err := dp.DecodeData(msg); err != nil {
+		return nil, err
+	}
 	return msg, nil
 }
 
@@ -107,22 +181,32 @@ func (c *ChatClient) SendHeartbeat() error {
 	return c.SendMessage(serverProtocol.MsgIDPing, []byte("ping"))
 }
 
-// StartHeartbeat 启动心跳
+// StartHeartbeat 启动心跳，已在运行的心跳先停止；开启自动重连时连续多个周期收不到服务端消息会主动断开并重连
 func (c *ChatClient) StartHeartbeat(interval time.Duration) {
-	if c.heartbeatStop == nil {
-		c.heartbeatStop = make(chan struct{})
+	c.mu.Lock()
+	if c.heartbeatStop != nil {
+		close(c.heartbeatStop)
 	}
+	stop := make(chan struct{})
+	c.heartbeatStop = stop
+	c.mu.Unlock()
+
 	go func() {
 		ticker := time.NewTicker(interval)
 		defer ticker.Stop()
 		for {
 			select {
 			case <-ticker.C:
+				if c.heartbeatExpired(interval) {
+					fmt.Println("心跳超时，连接可能已失效.")
+					c.dropConn()
+					return
+				}
 				if err := c.SendHeartbeat(); err != nil {
 					fmt.Printf("发送心跳失败: %v\n", err)
 					return
 				}
-			case <-c.heartbeatStop:
+			case <-stop:
 				fmt.Println("心跳已停止.")
 				return
 			}
@@ -132,11 +216,9 @@ func (c *ChatClient) StartHeartbeat(interval time.Duration) {
 
 // StopHeartbeat 停止心跳
 func (c *ChatClient) StopHeartbeat() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
 	if c.heartbeatStop != nil {
-		select {
-		case c.heartbeatStop <- struct{}{}:
-		default:
-		}
 		close(c.heartbeatStop)
 		c.heartbeatStop = nil
 	}
@@ -155,11 +237,10 @@ func (c *ChatClient) Register(username, password, email string) (*model.UserRegi
 	}
 
 	// Create a channel for this specific request
-	respChan := make(chan *clientProtocol.Message, 1)
-	c.responseChannels[serverProtocol.MsgIDRegisterResp] = respChan // Using MsgID as key for simplicity here
+	respChan := c.expectResponse(serverProtocol.MsgIDRegisterResp) // Using MsgID as key for simplicity here
 
 	if err := c.SendMessage(serverProtocol.MsgIDRegisterReq, body); err != nil {
-		delete(c.responseChannels, serverProtocol.MsgIDRegisterResp) // Clean up
+		c.cancelResponse(serverProtocol.MsgIDRegisterResp, respChan) // Clean up
 		return nil, fmt.Errorf("发送注册请求失败: %v", err)
 	}
 
@@ -186,7 +267,7 @@ func (c *ChatClient) Register(username, password, email string) (*model.UserRegi
 		}
 		return &genericResp.Data, nil
 	case <-time.After(c.requestTimeout):
-		delete(c.responseChannels, serverProtocol.MsgIDRegisterResp) // Clean up
+		c.cancelResponse(serverProtocol.MsgIDRegisterResp, respChan) // Clean up
 		return nil, fmt.Errorf("注册响应超时")
 	}
 }
@@ -194,22 +275,95 @@ func (c *ChatClient) Register(username, password, email string) (*model.UserRegi
 // Login 用户登录
 func (c *ChatClient) Login(username, password string) (*model.UserLoginResponse, error) {
 	req := model.UserLoginReq{
-		Username: username,
-		Password: password,
+		Username:      username,
+		Password:      password,
+		ClientVersion: Version,
+		DeviceID:      c.DeviceID,
 	}
 	body, err := json.Marshal(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal login request: %w", err)
 	}
 
-	respChan := make(chan *clientProtocol.Message, 1)
-	c.responseChannels[serverProtocol.MsgIDLoginResp] = respChan
+	// 不保存密码用于断线后自动重新登录：断线后先凭恢复令牌接回会话，恢复失败时凭 Token 重新登录，Token 也失效时由调用方重新输入密码
+	return c.sendLoginRequest(serverProtocol.MsgIDLoginReq, body)
+}
+
+// LoginGuest 以访客身份登录，服务端创建临时账号
+func (c *ChatClient) LoginGuest(nickname string) (*model.UserLoginResponse, error) {
+	body, err := json.Marshal(model.GuestLoginReq{
+		Nickname:      nickname,
+		ClientVersion: Version,
+		DeviceID:      c.DeviceID,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal guest login request: %w", err)
+	}
+	return c.sendLoginRequest(serverProtocol.MsgIDGuestLoginReq, body)
+}
+
+// LoginBot 使用 API Key 登录机器人账号
+func (c *ChatClient) LoginBot(apiKey string) (*model.UserLoginResponse, error) {
+	body, err := json.Marshal(model.BotLoginReq{
+		APIKey:        apiKey,
+		ClientVersion: Version,
+		DeviceID:      c.DeviceID,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal bot login request: %w", err)
+	}
+	resp, err := c.sendLoginRequest(serverProtocol.MsgIDBotLoginReq, body)
+	if err == nil {
+		c.setRelogin(func() error {
+			_, err := c.LoginBot(apiKey)
+			return err
+		})
+	}
+	return resp, err
+}
+
+// LoginWithToken 凭上次登录签发的 Token 重新登录，会话无法恢复（如重连到其他节点）时使用
+func (c *ChatClient) LoginWithToken() (*model.UserLoginResponse, error) {
+	if c.Token == "" {
+		return nil, errors.New("没有可用的登录令牌")
+	}
+	body, err := json.Marshal(model.TokenLoginReq{
+		Token:         c.Token,
+		ClientVersion: Version,
+		DeviceID:      c.DeviceID,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal token login request: %w", err)
+	}
+	return c.sendLoginRequest(serverProtocol.MsgIDTokenLoginReq, body)
+}
+
+// sendLoginRequest 发送登录请求并等待登录响应
+func (c *ChatClient) sendLoginRequest(msgID uint32, body []byte) (*model.UserLoginResponse, error) {
+	respChan := c.expectResponse(serverProtocol.MsgIDLoginResp)
+	// 服务端在登录响应之后立即推送离线消息，消息监听器可能在本协程收到响应之前就已开始计数，
+	// 因此在发送请求前清零序号，收到响应后只更新令牌
+	c.resume.reset("", 0)
 
-	if err := c.SendMessage(serverProtocol.MsgIDLoginReq, body); err != nil {
-		delete(c.responseChannels, serverProtocol.MsgIDLoginResp)
+	if err := c.SendMessage(msgID, body); err != nil {
+		c.cancelResponse(serverProtocol.MsgIDLoginResp, respChan)
 		return nil, fmt.Errorf("发送登录请求失败: %v", err)
 	}
+	return c.waitLoginResponse(respChan)
+}
+
+// LoginTwoFactor 登录第二步，提交两步验证码或备用恢复码
+func (c *ChatClient) LoginTwoFactor(code string) (*model.UserLoginResponse, error) {
+	body, err := json.Marshal(model.TwoFactorLoginReq{Code: code})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal two-factor login request: %w", err)
+	}
 
+	return c.sendLoginRequest(serverProtocol.MsgIDTwoFactorLoginReq, body)
+}
+
+// waitLoginResponse 等待登录响应，登录成功时保存用户信息并启动心跳
+func (c *ChatClient) waitLoginResponse(respChan chan *clientProtocol.Message) (*model.UserLoginResponse, error) {
 	select {
 	case respMsg := <-respChan:
 		if respMsg.GetMsgID() != serverProtocol.MsgIDLoginResp {
@@ -228,31 +382,83 @@ func (c *ChatClient) Login(username, password string) (*model.UserLoginResponse,
 			return nil, fmt.Errorf("解析登录响应失败: %v, body: %s", err, string(respMsg.GetData()))
 		}
 
+		if genericResp.Code == loginCodeTwoFactorRequired {
+			return nil, ErrTwoFactorRequired
+		}
 		if genericResp.Code != 0 {
-			return nil, fmt.Errorf("登录失败: %s (code: %d)", genericResp.Msg, genericResp.Code)
+			return nil, fmt.Errorf("%w: %s (code: %d)", ErrLoginRejected, genericResp.Msg, genericResp.Code)
 		}
+		prevUserID := c.UserID
 		c.UserID = genericResp.Data.ID
 		c.UserUUID = genericResp.Data.UserUUID
 		c.Username = genericResp.Data.Username
 		c.Token = genericResp.Data.Token
-		c.isLoggedIn = true
+		c.resume.setToken(genericResp.Data.ResumeToken)
+		// 只有机器人可以自动重新登录，由 LoginBot 在返回后重新设置
+		c.setRelogin(nil)
+		c.setLoggedIn(true)
 		// Start heartbeat after successful login
-		c.StartHeartbeat(30 * time.Second)
+		c.StartHeartbeat(c.heartbeatInterval())
+		c.flushOutboxAfterLogin(prevUserID)
 		return &genericResp.Data, nil
 	case <-time.After(c.requestTimeout):
-		delete(c.responseChannels, serverProtocol.MsgIDLoginResp)
+		c.cancelResponse(serverProtocol.MsgIDLoginResp, respChan)
 		return nil, fmt.Errorf("登录响应超时")
 	}
 }
 
+// expectResponse 登记等待 msgID 的响应，返回接收响应的通道
+func (c *ChatClient) expectResponse(msgID uint32) chan *clientProtocol.Message {
+	ch := make(chan *clientProtocol.Message, 1)
+	c.respMu.Lock()
+	c.responseChannels[msgID] = ch
+	c.respMu.Unlock()
+	return ch
+}
+
+// cancelResponse 放弃等待，只在 msgID 仍登记为 ch 时注销，不影响之后的请求登记的通道
+func (c *ChatClient) cancelResponse(msgID uint32, ch chan *clientProtocol.Message) {
+	c.respMu.Lock()
+	if c.responseChannels[msgID] == ch {
+		delete(c.responseChannels, msgID)
+	}
+	c.respMu.Unlock()
+}
+
+// takeResponse 取出并注销等待 msgID 的通道
+func (c *ChatClient) takeResponse(msgID uint32) (chan *clientProtocol.Message, bool) {
+	c.respMu.Lock()
+	defer c.respMu.Unlock()
+	ch, ok := c.responseChannels[msgID]
+	if ok {
+		delete(c.responseChannels, msgID)
+	}
+	return ch, ok
+}
+
+// setRelogin 保存会话无法恢复时使用的重新登录方式
+func (c *ChatClient) setRelogin(relogin func() error) {
+	c.mu.Lock()
+	c.relogin = relogin
+	c.mu.Unlock()
+}
+
 // IsLoggedIn 检查客户端是否已登录
 func (c *ChatClient) IsLoggedIn() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
 	return c.isLoggedIn
 }
 
+func (c *ChatClient) setLoggedIn(loggedIn bool) {
+	c.mu.Lock()
+	c.isLoggedIn = loggedIn
+	c.mu.Unlock()
+}
+
 // SendTextMessage 发送文本消息
 func (c *ChatClient) SendTextMessage(toUserIdentity string, content string) error {
-	if !c.isLoggedIn {
+	if !c.IsLoggedIn() {
 		return errors.New("请先登录再发送消息")
 	}
 	msg := model.TextMsg{
@@ -269,51 +475,73 @@ func (c *ChatClient) SendTextMessage(toUserIdentity string, content string) erro
 // StartMsgListener 启动消息监听器
 func (c *ChatClient) StartMsgListener(handler func(msgID uint32, data []byte)) {
 	c.msgHandler = handler
-	go func() {
-		for {
-			msg, err := c.readMessage()
-			if err != nil {
-				if err == io.EOF || errors.Is(err, net.ErrClosed) {
-					fmt.Printf("连接已关闭，停止监听消息: %v\n", err)
-				} else {
-					fmt.Printf("读取消息错误，停止监听: %v\n", err)
-				}
-				c.Close() // Ensure client is fully closed on read error
+	c.mu.Lock()
+	conn := c.Conn
+	c.lastRecv = time.Now()
+	c.mu.Unlock()
+	go c.listen(conn)
+}
+
+// listen 读取 conn 上的消息直到连接断开，开启自动重连时断开后进入重连，重连成功后由新连接的监听器接替
+func (c *ChatClient) listen(conn net.Conn) {
+	for {
+		msg, err := c.readMessage(conn)
+		if err != nil {
+			if c.handleConnLost(conn, err) {
 				return
 			}
+			if err == io.EOF || errors.Is(err, net.ErrClosed) {
+				fmt.Printf("连接已关闭，停止监听消息: %v\n", err)
+			} else {
+				fmt.Printf("读取消息错误，停止监听: %v\n", err)
+			}
+			c.Close() // Ensure client is fully closed on read error
+			return
+		}
+
+		c.mu.Lock()
+		c.lastRecv = time.Now()
+		c.mu.Unlock()
+		c.resume.observe(msg.GetMsgID())
+		if msg.GetMsgID() == serverProtocol.MsgIDServerGoingAwayPush {
+			c.onGoingAway(msg.GetData())
+		}
+		if msg.GetMsgID() == serverProtocol.MsgIDHelloResp {
+			// 握手超时后才到达的响应
+			if err := c.applyHelloResp(msg.GetData()); err != nil {
+				fmt.Printf("Warning: %v\n", err)
+			}
+			continue
+		}
 
-			// Check if this message ID is awaited by a synchronous call
-			// Lock mechanism would be needed if responseChannels is accessed by multiple goroutines
-			// For simplicity here, assuming StartMsgListener is the only writer to these channels.
-			if ch, ok := c.responseChannels[msg.GetMsgID()]; ok {
-				select {
-				case ch <- msg:
-					// Response sent to waiting synchronous call
-				default:
-					// Channel is full or not ready, could log this.
-					// Or, if the design guarantees the channel is always ready, this case isn't needed.
-					// For now, let's assume if a channel exists, it's ready.
-					fmt.Printf("Warning: Response channel for MsgID %d was not ready or full.\n", msg.GetMsgID())
-					// Fallback to general handler if channel send fails (e.g. full)
-					if c.msgHandler != nil {
-						c.msgHandler(msg.GetMsgID(), msg.GetData())
-					}
+		// Check if this message ID is awaited by a synchronous call
+		// Once a response is routed, the channel is removed to prevent leaks
+		// and incorrect routing of future messages with the same ID.
+		// This simple map keying by MsgID has limitations if multiple requests
+		// expect the same response MsgID concurrently. A unique request ID would be better.
+		if ch, ok := c.takeResponse(msg.GetMsgID()); ok {
+			select {
+			case ch <- msg:
+				// Response sent to waiting synchronous call
+			default:
+				// Channel is full or not ready, could log this.
+				// Or, if the design guarantees the channel is always ready, this case isn't needed.
+				// For now, let's assume if a channel exists, it's ready.
+				fmt.Printf("Warning: Response channel for MsgID %d was not ready or full.\n", msg.GetMsgID())
+				// Fallback to general handler if channel send fails (e.g. full)
+				if c.msgHandler != nil {
+					c.msgHandler(msg.GetMsgID(), msg.GetData())
 				}
-				// Once a response is routed, remove the channel to prevent leaks
-				// and incorrect routing of future messages with the same ID.
-				// This simple map keying by MsgID has limitations if multiple requests
-				// expect the same response MsgID concurrently. A unique request ID would be better.
-				delete(c.responseChannels, msg.GetMsgID())
-			} else if c.msgHandler != nil {
-				c.msgHandler(msg.GetMsgID(), msg.GetData())
 			}
+		} else if c.msgHandler != nil {
+			c.msgHandler(msg.GetMsgID(), msg.GetData())
 		}
-	}()
+	}
 }
 
 // SendHistoryMessageReq 发送获取历史消息请求
 func (c *ChatClient) SendHistoryMessageReq(targetUserIdentity string, limit int) error {
-	if !c.isLoggedIn {
+	if !c.IsLoggedIn() {
 		return errors.New("请先登录")
 	}
 	req := model.LegacyHistoryMsgReq{
@@ -336,7 +564,7 @@ func (c *ChatClient) SendHistoryMessageReq(targetUserIdentity string, limit int)
 
 // SendCreateGroupReq 发送创建群组请求
 func (c *ChatClient) SendCreateGroupReq(name, description, avatar string) error {
-	if !c.isLoggedIn {
+	if !c.IsLoggedIn() {
 		return errors.New("请先登录")
 	}
 	req := model.CreateGroupReq{
@@ -353,7 +581,7 @@ func (c *ChatClient) SendCreateGroupReq(name, description, avatar string) error
 
 // SendJoinGroupReq 发送加入群组请求
 func (c *ChatClient) SendJoinGroupReq(groupID uint) error {
-	if !c.isLoggedIn {
+	if !c.IsLoggedIn() {
 		return errors.New("请先登录")
 	}
 	req := model.JoinGroupReq{
@@ -368,7 +596,7 @@ func (c *ChatClient) SendJoinGroupReq(groupID uint) error {
 
 // SendLeaveGroupReq 发送离开群组请求
 func (c *ChatClient) SendLeaveGroupReq(groupID uint) error {
-	if !c.isLoggedIn {
+	if !c.IsLoggedIn() {
 		return errors.New("请先登录")
 	}
 	req := model.LeaveGroupReq{
@@ -383,7 +611,7 @@ func (c *ChatClient) SendLeaveGroupReq(groupID uint) error {
 
 // SendGroupTextMessage 发送群组文本消息
 func (c *ChatClient) SendGroupTextMessage(groupID uint32, content string) error {
-	if !c.isLoggedIn {
+	if !c.IsLoggedIn() {
 		return errors.New("请先登录再发送消息")
 	}
 	msg := model.GroupTextMsgReq{
@@ -397,9 +625,26 @@ func (c *ChatClient) SendGroupTextMessage(groupID uint32, content string) error
 	return c.SendMessage(serverProtocol.MsgIDGroupTextMsgReq, body)
 }
 
+// SendGroupAttachmentMessage 发送群组附件消息，messageType 为 image 或 file，url 为已上传文件的地址
+func (c *ChatClient) SendGroupAttachmentMessage(groupID uint32, messageType string, url string) error {
+	if !c.IsLoggedIn() {
+		return errors.New("请先登录再发送消息")
+	}
+	msg := model.GroupTextMsgReq{
+		GroupID:     groupID,
+		Content:     url,
+		MessageType: messageType,
+	}
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("failed to marshal group attachment message: %w", err)
+	}
+	return c.SendMessage(serverProtocol.MsgIDGroupTextMsgReq, body)
+}
+
 // SendGroupHistoryMessageReq 发送获取群组历史消息请求
 func (c *ChatClient) SendGroupHistoryMessageReq(groupID uint, lastID uint, limit int) error {
-	if !c.isLoggedIn {
+	if !c.IsLoggedIn() {
 		return errors.New("请先登录")
 	}
 	req := model.GroupHistoryMsgReq{
@@ -413,3 +658,666 @@ func (c *ChatClient) SendGroupHistoryMessageReq(groupID uint, lastID uint, limit
 	}
 	return c.SendMessage(serverProtocol.MsgIDGroupHistoryMsgReq, body)
 }
+
+// SendGetGroupMembersReq 发送分页获取群成员请求，keyword 不为空时按用户名搜索
+func (c *ChatClient) SendGetGroupMembersReq(groupID uint, page, pageSize int, keyword string) error {
+	if !c.IsLoggedIn() {
+		return errors.New("请先登录")
+	}
+	req := model.GetGroupMembersReq{
+		GroupID:  groupID,
+		Page:     page,
+		PageSize: pageSize,
+		Keyword:  keyword,
+	}
+	body, err := json.Marshal(req)
+	if err != nil {
+		return fmt.Errorf("failed to marshal get group members request: %w", err)
+	}
+	return c.SendMessage(serverProtocol.MsgIDGetGroupMembersReq, body)
+}
+
+// SendBanGroupMemberReq 发送封禁群成员请求，durationSeconds 为 0 表示永久封禁
+func (c *ChatClient) SendBanGroupMemberReq(groupID uint, targetUserID uint, reason string, durationSeconds int64) error {
+	if !c.IsLoggedIn() {
+		return errors.New("请先登录")
+	}
+	req := model.BanGroupMemberReq{
+		GroupID:         groupID,
+		TargetUserID:    targetUserID,
+		Reason:          reason,
+		DurationSeconds: durationSeconds,
+	}
+	body, err := json.Marshal(req)
+	if err != nil {
+		return fmt.Errorf("failed to marshal ban group member request: %w", err)
+	}
+	return c.SendMessage(serverProtocol.MsgIDBanGroupMemberReq, body)
+}
+
+// SendUnbanGroupMemberReq 发送解除群组封禁请求
+func (c *ChatClient) SendUnbanGroupMemberReq(groupID uint, targetUserID uint) error {
+	if !c.IsLoggedIn() {
+		return errors.New("请先登录")
+	}
+	req := model.UnbanGroupMemberReq{
+		GroupID:      groupID,
+		TargetUserID: targetUserID,
+	}
+	body, err := json.Marshal(req)
+	if err != nil {
+		return fmt.Errorf("failed to marshal unban group member request: %w", err)
+	}
+	return c.SendMessage(serverProtocol.MsgIDUnbanGroupMemberReq, body)
+}
+
+// SendMuteGroupMemberReq 发送禁言群成员请求，durationSeconds 为 0 表示解除禁言
+func (c *ChatClient) SendMuteGroupMemberReq(groupID uint, targetUserID uint, durationSeconds int64) error {
+	if !c.IsLoggedIn() {
+		return errors.New("请先登录")
+	}
+	req := model.MuteGroupMemberReq{
+		GroupID:         groupID,
+		TargetUserID:    targetUserID,
+		DurationSeconds: durationSeconds,
+	}
+	body, err := json.Marshal(req)
+	if err != nil {
+		return fmt.Errorf("failed to marshal mute group member request: %w", err)
+	}
+	return c.SendMessage(serverProtocol.MsgIDMuteGroupMemberReq, body)
+}
+
+// SendGetGroupBanListReq 发送获取群组封禁列表请求
+func (c *ChatClient) SendGetGroupBanListReq(groupID uint) error {
+	if !c.IsLoggedIn() {
+		return errors.New("请先登录")
+	}
+	body, err := json.Marshal(model.GetGroupBanListReq{GroupID: groupID})
+	if err != nil {
+		return fmt.Errorf("failed to marshal group ban list request: %w", err)
+	}
+	return c.SendMessage(serverProtocol.MsgIDGetGroupBanListReq, body)
+}
+
+// SendSetGroupDirectoryReq 发送修改群组目录设置（是否公开、分类、标签）请求
+// category 为 nil 时不修改分类，指向空字符串时取消分类；tags 为 nil 时不修改标签
+func (c *ChatClient) SendSetGroupDirectoryReq(groupID uint, isPublic bool, category *string, tags []string) error {
+	if !c.IsLoggedIn() {
+		return errors.New("请先登录")
+	}
+	req := model.UpdateGroupInfoReq{
+		GroupID:  groupID,
+		IsPublic: &isPublic,
+		Category: category,
+		Tags:     tags,
+	}
+	body, err := json.Marshal(req)
+	if err != nil {
+		return fmt.Errorf("failed to marshal update group info request: %w", err)
+	}
+	return c.SendMessage(serverProtocol.MsgIDUpdateGroupInfoReq, body)
+}
+
+// SendSearchGroupsReq 发送搜索公开群组请求
+func (c *ChatClient) SendSearchGroupsReq(req model.SearchGroupsReq) error {
+	if !c.IsLoggedIn() {
+		return errors.New("请先登录")
+	}
+	body, err := json.Marshal(req)
+	if err != nil {
+		return fmt.Errorf("failed to marshal search groups request: %w", err)
+	}
+	return c.SendMessage(serverProtocol.MsgIDSearchGroupsReq, body)
+}
+
+// SendSetGroupMemberRoleReq 发送设置群成员角色请求
+func (c *ChatClient) SendSetGroupMemberRoleReq(groupID, targetUserID uint, role string) error {
+	if !c.IsLoggedIn() {
+		return errors.New("请先登录")
+	}
+	req := model.SetGroupMemberRoleReq{
+		GroupID:      groupID,
+		TargetUserID: targetUserID,
+		NewRole:      role,
+	}
+	body, err := json.Marshal(req)
+	if err != nil {
+		return fmt.Errorf("failed to marshal set member role request: %w", err)
+	}
+	return c.SendMessage(serverProtocol.MsgIDSetMemberRoleReq, body)
+}
+
+// SendSaveGroupRoleReq 发送创建或修改群组自定义角色请求
+func (c *ChatClient) SendSaveGroupRoleReq(groupID uint, name string, priority int, permissions []string) error {
+	if !c.IsLoggedIn() {
+		return errors.New("请先登录")
+	}
+	req := model.SaveGroupRoleReq{
+		GroupID:     groupID,
+		Name:        name,
+		Permissions: permissions,
+		Priority:    priority,
+	}
+	body, err := json.Marshal(req)
+	if err != nil {
+		return fmt.Errorf("failed to marshal save group role request: %w", err)
+	}
+	return c.SendMessage(serverProtocol.MsgIDSaveGroupRoleReq, body)
+}
+
+// SendDeleteGroupRoleReq 发送删除群组自定义角色请求
+func (c *ChatClient) SendDeleteGroupRoleReq(groupID uint, name string) error {
+	if !c.IsLoggedIn() {
+		return errors.New("请先登录")
+	}
+	body, err := json.Marshal(model.DeleteGroupRoleReq{GroupID: groupID, Name: name})
+	if err != nil {
+		return fmt.Errorf("failed to marshal delete group role request: %w", err)
+	}
+	return c.SendMessage(serverProtocol.MsgIDDeleteGroupRoleReq, body)
+}
+
+// SendGetGroupRolesReq 发送获取群组角色列表请求
+func (c *ChatClient) SendGetGroupRolesReq(groupID uint) error {
+	if !c.IsLoggedIn() {
+		return errors.New("请先登录")
+	}
+	body, err := json.Marshal(model.GetGroupRolesReq{GroupID: groupID})
+	if err != nil {
+		return fmt.Errorf("failed to marshal group roles request: %w", err)
+	}
+	return c.SendMessage(serverProtocol.MsgIDGetGroupRolesReq, body)
+}
+
+// SendFriendRequestReq 发送好友申请，target 可以是对方的用户名、UUID 或数字ID
+func (c *ChatClient) SendFriendRequestReq(target, message string) error {
+	if !c.IsLoggedIn() {
+		return errors.New("请先登录")
+	}
+	body, err := json.Marshal(model.SendFriendRequestReq{Target: target, Message: message})
+	if err != nil {
+		return fmt.Errorf("failed to marshal friend request: %w", err)
+	}
+	return c.SendMessage(serverProtocol.MsgIDSendFriendRequestReq, body)
+}
+
+// SendHandleFriendRequestReq 发送接受或拒绝好友申请请求
+func (c *ChatClient) SendHandleFriendRequestReq(requestID uint, accept bool) error {
+	if !c.IsLoggedIn() {
+		return errors.New("请先登录")
+	}
+	body, err := json.Marshal(model.HandleFriendRequestReq{RequestID: requestID, Accept: accept})
+	if err != nil {
+		return fmt.Errorf("failed to marshal handle friend request: %w", err)
+	}
+	return c.SendMessage(serverProtocol.MsgIDHandleFriendRequestReq, body)
+}
+
+// SendGetFriendRequestsReq 发送获取待处理好友申请请求
+func (c *ChatClient) SendGetFriendRequestsReq() error {
+	if !c.IsLoggedIn() {
+		return errors.New("请先登录")
+	}
+	return c.SendMessage(serverProtocol.MsgIDGetFriendRequestsReq, []byte("{}"))
+}
+
+// SendGetFriendListReq 发送获取好友列表请求
+func (c *ChatClient) SendGetFriendListReq() error {
+	if !c.IsLoggedIn() {
+		return errors.New("请先登录")
+	}
+	return c.SendMessage(serverProtocol.MsgIDGetFriendListReq, []byte("{}"))
+}
+
+// SendRemoveFriendReq 发送删除好友请求
+func (c *ChatClient) SendRemoveFriendReq(friendUserID uint) error {
+	if !c.IsLoggedIn() {
+		return errors.New("请先登录")
+	}
+	body, err := json.Marshal(model.RemoveFriendReq{FriendUserID: friendUserID})
+	if err != nil {
+		return fmt.Errorf("failed to marshal remove friend request: %w", err)
+	}
+	return c.SendMessage(serverProtocol.MsgIDRemoveFriendReq, body)
+}
+
+// SendSetFriendRemarkReq 发送设置好友备注请求，remark 为空表示清除备注
+func (c *ChatClient) SendSetFriendRemarkReq(friendUserID uint, remark string) error {
+	if !c.IsLoggedIn() {
+		return errors.New("请先登录")
+	}
+	body, err := json.Marshal(model.SetFriendRemarkReq{FriendUserID: friendUserID, Remark: remark})
+	if err != nil {
+		return fmt.Errorf("failed to marshal set friend remark request: %w", err)
+	}
+	return c.SendMessage(serverProtocol.MsgIDSetFriendRemarkReq, body)
+}
+
+// SendBlockUserReq 发送屏蔽用户请求，target 可以是对方的用户名、UUID 或数字ID
+func (c *ChatClient) SendBlockUserReq(target string) error {
+	if !c.IsLoggedIn() {
+		return errors.New("请先登录")
+	}
+	body, err := json.Marshal(model.BlockUserReq{Target: target})
+	if err != nil {
+		return fmt.Errorf("failed to marshal block user request: %w", err)
+	}
+	return c.SendMessage(serverProtocol.MsgIDBlockUserReq, body)
+}
+
+// SendUnblockUserReq 发送取消屏蔽请求
+func (c *ChatClient) SendUnblockUserReq(userID uint) error {
+	if !c.IsLoggedIn() {
+		return errors.New("请先登录")
+	}
+	body, err := json.Marshal(model.UnblockUserReq{UserID: userID})
+	if err != nil {
+		return fmt.Errorf("failed to marshal unblock user request: %w", err)
+	}
+	return c.SendMessage(serverProtocol.MsgIDUnblockUserReq, body)
+}
+
+// SendGetBlockListReq 发送获取屏蔽列表请求
+func (c *ChatClient) SendGetBlockListReq() error {
+	if !c.IsLoggedIn() {
+		return errors.New("请先登录")
+	}
+	return c.SendMessage(serverProtocol.MsgIDGetBlockListReq, []byte("{}"))
+}
+
+// SendSetDMPrivacyReq 发送设置私聊隐私请求
+func (c *ChatClient) SendSetDMPrivacyReq(privacy string) error {
+	if !c.IsLoggedIn() {
+		return errors.New("请先登录")
+	}
+	body, err := json.Marshal(model.DMPrivacyReq{Privacy: privacy})
+	if err != nil {
+		return fmt.Errorf("failed to marshal dm privacy request: %w", err)
+	}
+	return c.SendMessage(serverProtocol.MsgIDSetDMPrivacyReq, body)
+}
+
+// SendGetDMPrivacyReq 发送获取私聊隐私设置请求
+func (c *ChatClient) SendGetDMPrivacyReq() error {
+	if !c.IsLoggedIn() {
+		return errors.New("请先登录")
+	}
+	return c.SendMessage(serverProtocol.MsgIDGetDMPrivacyReq, []byte("{}"))
+}
+
+// SendInviteGroupMemberReq 发送邀请用户入群请求
+func (c *ChatClient) SendInviteGroupMemberReq(groupID, targetUserID uint) error {
+	if !c.IsLoggedIn() {
+		return errors.New("请先登录")
+	}
+	body, err := json.Marshal(model.InviteGroupMemberReq{GroupID: groupID, TargetUserID: targetUserID})
+	if err != nil {
+		return fmt.Errorf("failed to marshal invite group member request: %w", err)
+	}
+	return c.SendMessage(serverProtocol.MsgIDInviteGroupMemberReq, body)
+}
+
+// SendUpdateProfileReq 发送更新用户资料请求，只修改 req 中不为 nil 的字段
+func (c *ChatClient) SendUpdateProfileReq(req model.UpdateProfileReq) error {
+	if !c.IsLoggedIn() {
+		return errors.New("请先登录")
+	}
+	body, err := json.Marshal(req)
+	if err != nil {
+		return fmt.Errorf("failed to marshal update profile request: %w", err)
+	}
+	return c.SendMessage(serverProtocol.MsgIDUpdateProfileReq, body)
+}
+
+// SendGetProfileReq 发送获取用户资料请求，target 为空时获取自己的资料
+func (c *ChatClient) SendGetProfileReq(target string) error {
+	if !c.IsLoggedIn() {
+		return errors.New("请先登录")
+	}
+	body, err := json.Marshal(model.GetProfileReq{Target: target})
+	if err != nil {
+		return fmt.Errorf("failed to marshal get profile request: %w", err)
+	}
+	return c.SendMessage(serverProtocol.MsgIDGetProfileReq, body)
+}
+
+// SendSearchUsersReq 发送搜索用户请求
+func (c *ChatClient) SendSearchUsersReq(keyword string, page int) error {
+	if !c.IsLoggedIn() {
+		return errors.New("请先登录")
+	}
+	body, err := json.Marshal(model.SearchUsersReq{Keyword: keyword, Page: page})
+	if err != nil {
+		return fmt.Errorf("failed to marshal search users request: %w", err)
+	}
+	return c.SendMessage(serverProtocol.MsgIDSearchUsersReq, body)
+}
+
+// SendChangePasswordReq 发送修改密码请求
+func (c *ChatClient) SendChangePasswordReq(oldPassword, newPassword string) error {
+	if !c.IsLoggedIn() {
+		return errors.New("请先登录")
+	}
+	body, err := json.Marshal(model.ChangePasswordReq{OldPassword: oldPassword, NewPassword: newPassword})
+	if err != nil {
+		return fmt.Errorf("failed to marshal change password request: %w", err)
+	}
+	return c.SendMessage(serverProtocol.MsgIDChangePasswordReq, body)
+}
+
+// SendRequestPasswordResetReq 发送申请重置密码请求，无需登录
+func (c *ChatClient) SendRequestPasswordResetReq(account string) error {
+	body, err := json.Marshal(model.RequestPasswordResetReq{Account: account})
+	if err != nil {
+		return fmt.Errorf("failed to marshal password reset request: %w", err)
+	}
+	return c.SendMessage(serverProtocol.MsgIDRequestPasswordResetReq, body)
+}
+
+// SendResetPasswordReq 发送使用令牌重置密码请求，无需登录
+func (c *ChatClient) SendResetPasswordReq(token, newPassword string) error {
+	body, err := json.Marshal(model.ResetPasswordReq{Token: token, NewPassword: newPassword})
+	if err != nil {
+		return fmt.Errorf("failed to marshal reset password request: %w", err)
+	}
+	return c.SendMessage(serverProtocol.MsgIDResetPasswordReq, body)
+}
+
+// SendVerifyEmailMailReq 发送重新发送邮箱验证邮件请求
+func (c *ChatClient) SendVerifyEmailMailReq() error {
+	if !c.IsLoggedIn() {
+		return errors.New("请先登录")
+	}
+	return c.SendMessage(serverProtocol.MsgIDSendVerifyEmailReq, []byte("{}"))
+}
+
+// SendVerifyEmailReq 发送验证邮箱请求，无需登录
+func (c *ChatClient) SendVerifyEmailReq(token string) error {
+	body, err := json.Marshal(model.VerifyEmailReq{Token: token})
+	if err != nil {
+		return fmt.Errorf("failed to marshal verify email request: %w", err)
+	}
+	return c.SendMessage(serverProtocol.MsgIDVerifyEmailReq, body)
+}
+
+// SendUnlockAccountReq 发送管理员解除登录锁定请求
+func (c *ChatClient) SendUnlockAccountReq(username, ip string) error {
+	if !c.IsLoggedIn() {
+		return errors.New("请先登录")
+	}
+	body, err := json.Marshal(model.UnlockAccountReq{Username: username, IP: ip})
+	if err != nil {
+		return fmt.Errorf("failed to marshal unlock account request: %w", err)
+	}
+	return c.SendMessage(serverProtocol.MsgIDUnlockAccountReq, body)
+}
+
+// SendEnrollTOTPReq 发送生成两步验证密钥请求
+func (c *ChatClient) SendEnrollTOTPReq() error {
+	if !c.IsLoggedIn() {
+		return errors.New("请先登录")
+	}
+	return c.SendMessage(serverProtocol.MsgIDEnrollTOTPReq, []byte("{}"))
+}
+
+// SendConfirmTOTPReq 发送确认开启两步验证请求
+func (c *ChatClient) SendConfirmTOTPReq(code string) error {
+	if !c.IsLoggedIn() {
+		return errors.New("请先登录")
+	}
+	body, err := json.Marshal(model.ConfirmTOTPReq{Code: code})
+	if err != nil {
+		return fmt.Errorf("failed to marshal confirm totp request: %w", err)
+	}
+	return c.SendMessage(serverProtocol.MsgIDConfirmTOTPReq, body)
+}
+
+// SendDisableTOTPReq 发送关闭两步验证请求
+func (c *ChatClient) SendDisableTOTPReq(password, code string) error {
+	if !c.IsLoggedIn() {
+		return errors.New("请先登录")
+	}
+	body, err := json.Marshal(model.DisableTOTPReq{Password: password, Code: code})
+	if err != nil {
+		return fmt.Errorf("failed to marshal disable totp request: %w", err)
+	}
+	return c.SendMessage(serverProtocol.MsgIDDisableTOTPReq, body)
+}
+
+// SendGetLoginHistoryReq 发送查询登录历史请求
+func (c *ChatClient) SendGetLoginHistoryReq(limit int) error {
+	if !c.IsLoggedIn() {
+		return errors.New("请先登录")
+	}
+	body, err := json.Marshal(model.GetLoginHistoryReq{Limit: limit})
+	if err != nil {
+		return fmt.Errorf("failed to marshal login history request: %w", err)
+	}
+	return c.SendMessage(serverProtocol.MsgIDGetLoginHistoryReq, body)
+}
+
+// SendDeleteAccountReq 发送申请注销账号请求，未开启两步验证时 code 可为空
+func (c *ChatClient) SendDeleteAccountReq(password, code string) error {
+	if !c.IsLoggedIn() {
+		return errors.New("请先登录")
+	}
+	body, err := json.Marshal(model.DeleteAccountReq{Password: password, Code: code})
+	if err != nil {
+		return fmt.Errorf("failed to marshal delete account request: %w", err)
+	}
+	return c.SendMessage(serverProtocol.MsgIDDeleteAccountReq, body)
+}
+
+// SendCancelAccountDeletionReq 发送撤销注销申请请求
+func (c *ChatClient) SendCancelAccountDeletionReq() error {
+	if !c.IsLoggedIn() {
+		return errors.New("请先登录")
+	}
+	return c.SendMessage(serverProtocol.MsgIDCancelAccountDeletionReq, []byte("{}"))
+}
+
+// SendGetAccountDeletionStatusReq 发送查询注销申请状态请求
+func (c *ChatClient) SendGetAccountDeletionStatusReq() error {
+	if !c.IsLoggedIn() {
+		return errors.New("请先登录")
+	}
+	return c.SendMessage(serverProtocol.MsgIDGetAccountDeletionStatusReq, []byte("{}"))
+}
+
+// SendRequestDataExportReq 发送申请导出个人数据请求
+func (c *ChatClient) SendRequestDataExportReq() error {
+	if !c.IsLoggedIn() {
+		return errors.New("请先登录")
+	}
+	return c.SendMessage(serverProtocol.MsgIDRequestDataExportReq, []byte("{}"))
+}
+
+// SendDownloadDataExportReq 发送分片下载导出文件请求
+func (c *ChatClient) SendDownloadDataExportReq(exportID uint, offset int64) error {
+	if !c.IsLoggedIn() {
+		return errors.New("请先登录")
+	}
+	body, err := json.Marshal(model.DownloadDataExportReq{ExportID: exportID, Offset: offset})
+	if err != nil {
+		return fmt.Errorf("failed to marshal download data export request: %w", err)
+	}
+	return c.SendMessage(serverProtocol.MsgIDDownloadDataExportReq, body)
+}
+
+// SendCreateBotReq 发送创建机器人请求
+func (c *ChatClient) SendCreateBotReq(username, nickname string) error {
+	if !c.IsLoggedIn() {
+		return errors.New("请先登录")
+	}
+	body, err := json.Marshal(model.CreateBotReq{Username: username, Nickname: nickname})
+	if err != nil {
+		return fmt.Errorf("failed to marshal create bot request: %w", err)
+	}
+	return c.SendMessage(serverProtocol.MsgIDCreateBotReq, body)
+}
+
+// SendRotateBotKeyReq 发送重新生成机器人 API Key 请求
+func (c *ChatClient) SendRotateBotKeyReq(botUserID uint) error {
+	if !c.IsLoggedIn() {
+		return errors.New("请先登录")
+	}
+	body, err := json.Marshal(model.RotateBotKeyReq{BotUserID: botUserID})
+	if err != nil {
+		return fmt.Errorf("failed to marshal rotate bot key request: %w", err)
+	}
+	return c.SendMessage(serverProtocol.MsgIDRotateBotKeyReq, body)
+}
+
+// SendGetMyBotsReq 发送查询自己创建的机器人请求
+func (c *ChatClient) SendGetMyBotsReq() error {
+	if !c.IsLoggedIn() {
+		return errors.New("请先登录")
+	}
+	return c.SendMessage(serverProtocol.MsgIDGetMyBotsReq, []byte("{}"))
+}
+
+// SendSetBotWebhookReq 发送创建或修改机器人 webhook 请求
+func (c *ChatClient) SendSetBotWebhookReq(req *model.SetBotWebhookReq) error {
+	if !c.IsLoggedIn() {
+		return errors.New("请先登录")
+	}
+	body, err := json.Marshal(req)
+	if err != nil {
+		return fmt.Errorf("failed to marshal set bot webhook request: %w", err)
+	}
+	return c.SendMessage(serverProtocol.MsgIDSetBotWebhookReq, body)
+}
+
+// SendDeleteBotWebhookReq 发送删除机器人 webhook 请求
+func (c *ChatClient) SendDeleteBotWebhookReq(botUserID, groupID uint) error {
+	if !c.IsLoggedIn() {
+		return errors.New("请先登录")
+	}
+	body, err := json.Marshal(model.DeleteBotWebhookReq{BotUserID: botUserID, GroupID: groupID})
+	if err != nil {
+		return fmt.Errorf("failed to marshal delete bot webhook request: %w", err)
+	}
+	return c.SendMessage(serverProtocol.MsgIDDeleteBotWebhookReq, body)
+}
+
+// SendGetBotWebhooksReq 发送查询机器人 webhook 请求
+func (c *ChatClient) SendGetBotWebhooksReq(botUserID uint) error {
+	if !c.IsLoggedIn() {
+		return errors.New("请先登录")
+	}
+	body, err := json.Marshal(model.GetBotWebhooksReq{BotUserID: botUserID})
+	if err != nil {
+		return fmt.Errorf("failed to marshal get bot webhooks request: %w", err)
+	}
+	return c.SendMessage(serverProtocol.MsgIDGetBotWebhooksReq, body)
+}
+
+// SendCreateIncomingWebhookReq 发送创建群组 incoming webhook 请求
+func (c *ChatClient) SendCreateIncomingWebhookReq(groupID uint, name string) error {
+	if !c.IsLoggedIn() {
+		return errors.New("请先登录")
+	}
+	body, err := json.Marshal(model.CreateIncomingWebhookReq{GroupID: groupID, Name: name})
+	if err != nil {
+		return fmt.Errorf("failed to marshal create incoming webhook request: %w", err)
+	}
+	return c.SendMessage(serverProtocol.MsgIDCreateIncomingWebhookReq, body)
+}
+
+// SendRevokeIncomingWebhookReq 发送吊销群组 incoming webhook 请求
+func (c *ChatClient) SendRevokeIncomingWebhookReq(groupID, webhookID uint) error {
+	if !c.IsLoggedIn() {
+		return errors.New("请先登录")
+	}
+	body, err := json.Marshal(model.RevokeIncomingWebhookReq{GroupID: groupID, WebhookID: webhookID})
+	if err != nil {
+		return fmt.Errorf("failed to marshal revoke incoming webhook request: %w", err)
+	}
+	return c.SendMessage(serverProtocol.MsgIDRevokeIncomingWebhookReq, body)
+}
+
+// SendGetIncomingWebhooksReq 发送查询群组 incoming webhook 请求
+func (c *ChatClient) SendGetIncomingWebhooksReq(groupID uint) error {
+	if !c.IsLoggedIn() {
+		return errors.New("请先登录")
+	}
+	body, err := json.Marshal(model.GetIncomingWebhooksReq{GroupID: groupID})
+	if err != nil {
+		return fmt.Errorf("failed to marshal get incoming webhooks request: %w", err)
+	}
+	return c.SendMessage(serverProtocol.MsgIDGetIncomingWebhooksReq, body)
+}
+
+// SendUpdateGroupAnnouncementReq 发送编辑群公告请求
+func (c *ChatClient) SendUpdateGroupAnnouncementReq(groupID uint, content string) error {
+	if !c.IsLoggedIn() {
+		return errors.New("请先登录")
+	}
+	req := model.UpdateGroupAnnouncementReq{
+		GroupID: groupID,
+		Content: content,
+	}
+	body, err := json.Marshal(req)
+	if err != nil {
+		return fmt.Errorf("failed to marshal update group announcement request: %w", err)
+	}
+	return c.SendMessage(serverProtocol.MsgIDUpdateGroupAnnouncementReq, body)
+}
+
+// SendGetGroupAnnouncementHistoryReq 发送获取群公告历史请求
+func (c *ChatClient) SendGetGroupAnnouncementHistoryReq(groupID uint, limit int) error {
+	if !c.IsLoggedIn() {
+		return errors.New("请先登录")
+	}
+	req := model.GetGroupAnnouncementHistoryReq{
+		GroupID: groupID,
+		Limit:   limit,
+	}
+	body, err := json.Marshal(req)
+	if err != nil {
+		return fmt.Errorf("failed to marshal group announcement history request: %w", err)
+	}
+	return c.SendMessage(serverProtocol.MsgIDGetGroupAnnouncementHistoryReq, body)
+}
+
+// SendAckGroupAnnouncementReq 发送确认群公告请求
+func (c *ChatClient) SendAckGroupAnnouncementReq(groupID uint, announcementID uint) error {
+	if !c.IsLoggedIn() {
+		return errors.New("请先登录")
+	}
+	req := model.AckGroupAnnouncementReq{
+		GroupID:        groupID,
+		AnnouncementID: announcementID,
+	}
+	body, err := json.Marshal(req)
+	if err != nil {
+		return fmt.Errorf("failed to marshal ack group announcement request: %w", err)
+	}
+	return c.SendMessage(serverProtocol.MsgIDAckGroupAnnouncementReq, body)
+}
+
+// SendPinGroupMessageReq 发送置顶群消息请求
+func (c *ChatClient) SendPinGroupMessageReq(groupID uint, groupMessageID uint) error {
+	return c.sendPinChangeReq(serverProtocol.MsgIDPinGroupMessageReq, groupID, groupMessageID)
+}
+
+// SendUnpinGroupMessageReq 发送取消置顶群消息请求
+func (c *ChatClient) SendUnpinGroupMessageReq(groupID uint, groupMessageID uint) error {
+	return c.sendPinChangeReq(serverProtocol.MsgIDUnpinGroupMessageReq, groupID, groupMessageID)
+}
+
+func (c *ChatClient) sendPinChangeReq(msgID uint32, groupID uint, groupMessageID uint) error {
+	if !c.IsLoggedIn() {
+		return errors.New("请先登录")
+	}
+	req := model.PinGroupMessageReq{
+		GroupID:        groupID,
+		GroupMessageID: groupMessageID,
+	}
+	body, err := json.Marshal(req)
+	if err != nil {
+		return fmt.Errorf("failed to marshal pin group message request: %w", err)
+	}
+	return c.SendMessage(msgID, body)
+}
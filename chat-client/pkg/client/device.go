@@ -0,0 +1,41 @@
+package client
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Version 客户端版本，登录时上报给服务端记录到登录历史
+const Version = "1.0.0"
+
+// deviceIDFile 设备标识保存在用户配置目录下的文件
+const deviceIDFile = "chat-zinx/device_id"
+
+// loadDeviceID 读取本机的设备标识，不存在时生成并保存
+// 无法读写配置目录时返回本次运行内有效的随机标识
+func loadDeviceID() string {
+	var path string
+	if dir, err := os.UserConfigDir(); err == nil {
+		path = filepath.Join(dir, deviceIDFile)
+		if data, err := os.ReadFile(path); err == nil {
+			if id := strings.TrimSpace(string(data)); id != "" {
+				return id
+			}
+		}
+	}
+
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return ""
+	}
+	id := hex.EncodeToString(buf)
+	if path != "" {
+		if err := os.MkdirAll(filepath.Dir(path), 0700); err == nil {
+			_ = os.WriteFile(path, []byte(id+"\n"), 0600)
+		}
+	}
+	return id
+}
@@ -0,0 +1,155 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+
+	clientProtocol "github.com/Xaytick/chat-zinx/chat-client/pkg/protocol"
+	"github.com/Xaytick/chat-zinx/chat-server/pkg/model"
+	serverProtocol "github.com/Xaytick/chat-zinx/chat-server/pkg/protocol"
+)
+
+// ClientName 握手时上报的客户端名称
+const ClientName = "chat-zinx-cli"
+
+// ErrHandshakeRejected 服务端拒绝了握手，通常是客户端版本过低，需要升级
+var ErrHandshakeRejected = errors.New("服务端拒绝握手")
+
+// helloTimeout 等待握手响应的时间
+// 旧版服务端没有握手路由，收到握手请求不会回复，超时后按旧版服务端继续，因此不宜过长
+const helloTimeout = 3 * time.Second
+
+// defaultHeartbeatInterval 服务端没有给出心跳间隔时使用
+const defaultHeartbeatInterval = 30 * time.Second
+
+// connect 建立连接并完成握手
+func (c *ChatClient) connect() (net.Conn, error) {
+	conn, err := c.dial()
+	if err != nil {
+		return nil, err
+	}
+	if err := c.hello(conn); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return conn, nil
+}
+
+// hello 在消息监听器启动前同步完成握手，协商结果保存在 ServerInfo 中
+func (c *ChatClient) hello(conn net.Conn) error {
+	body, err := json.Marshal(model.HelloReq{
+		ProtocolVersion: serverProtocol.ProtocolVersion,
+		ClientName:      ClientName,
+		ClientVersion:   Version,
+		Codecs:          []string{serverProtocol.CodecJSON},
+		Compression:     []string{serverProtocol.CompressionZstd, serverProtocol.CompressionDeflate, serverProtocol.CompressionNone},
+		Features:        []string{serverProtocol.FeatureSessionResume, serverProtocol.FeatureTwoFactor, serverProtocol.FeatureGuestLogin, serverProtocol.FeatureBots},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal hello request: %w", err)
+	}
+	packed, err := clientProtocol.NewDataPack().Pack(&clientProtocol.Message{
+		DataLen: uint32(len(body)),
+		ID:      serverProtocol.MsgIDHelloReq,
+		Data:    body,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to pack hello request: %w", err)
+	}
+
+	conn.SetDeadline(time.Now().Add(min(helloTimeout, c.requestTimeout)))
+	defer conn.SetDeadline(time.Time{})
+	if _, err := conn.Write(packed); err != nil {
+		return fmt.Errorf("发送握手请求失败: %v", err)
+	}
+	respMsg, err := c.readMessage(conn)
+	if err != nil {
+		var netErr net.Error
+		if errors.As(err, &netErr) && netErr.Timeout() {
+			// 按旧版服务端处理：不压缩、使用默认心跳间隔、不启用需要协商的功能
+			fmt.Println("服务端未响应握手，按旧版服务端继续")
+			c.mu.Lock()
+			c.serverInfo = nil
+			c.mu.Unlock()
+			return nil
+		}
+		return fmt.Errorf("等待握手响应失败: %v", err)
+	}
+	if respMsg.GetMsgID() == serverProtocol.MsgIDServerGoingAwayPush {
+		// 节点正在关闭，不再接受新连接，按建议的时间换到其他节点重连
+		c.onGoingAway(respMsg.GetData())
+		return ErrServerGoingAway
+	}
+	if respMsg.GetMsgID() != serverProtocol.MsgIDHelloResp {
+		return fmt.Errorf("响应消息ID错误，期望%d，实际%d", serverProtocol.MsgIDHelloResp, respMsg.GetMsgID())
+	}
+	return c.applyHelloResp(respMsg.GetData())
+}
+
+// applyHelloResp 解析握手响应并保存协商结果
+// 握手超时后服务端的响应仍可能晚到，监听器收到时也经由这里保存，之后的消息按协商结果解压
+func (c *ChatClient) applyHelloResp(data []byte) error {
+	var genericResp struct {
+		Code uint32          `json:"code"`
+		Msg  string          `json:"msg"`
+		Data model.HelloResp `json:"data"`
+	}
+	if err := json.Unmarshal(data, &genericResp); err != nil {
+		return fmt.Errorf("解析握手响应失败: %v", err)
+	}
+	if genericResp.Code != 0 {
+		return fmt.Errorf("%w: %s (code: %d)", ErrHandshakeRejected, genericResp.Msg, genericResp.Code)
+	}
+	c.mu.Lock()
+	c.serverInfo = &genericResp.Data
+	c.mu.Unlock()
+	return nil
+}
+
+// ServerInfo 最近一次握手得到的服务端信息，包括协商的协议版本、限制和已启用的功能
+func (c *ChatClient) ServerInfo() *model.HelloResp {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.serverInfo
+}
+
+// HasFeature 服务端是否启用了 feature 且在握手中协商成功
+func (c *ChatClient) HasFeature(feature string) bool {
+	info := c.ServerInfo()
+	if info == nil {
+		return false
+	}
+	for _, f := range info.Features {
+		if f == feature {
+			return true
+		}
+	}
+	return false
+}
+
+// heartbeatInterval 按服务端要求的间隔发送心跳
+func (c *ChatClient) heartbeatInterval() time.Duration {
+	if info := c.ServerInfo(); info != nil && info.Limits.HeartbeatInterval > 0 {
+		return time.Duration(info.Limits.HeartbeatInterval) * time.Second
+	}
+	return defaultHeartbeatInterval
+}
+
+// compression 握手协商的压缩算法，服务端发来的压缩消息按此解压
+func (c *ChatClient) compression() string {
+	if info := c.ServerInfo(); info != nil {
+		return info.Compression
+	}
+	return serverProtocol.CompressionNone
+}
+
+// maxPacketSizeLocked 服务端允许的最大消息长度，未知时为 0，调用方需持有 c.mu
+func (c *ChatClient) maxPacketSizeLocked() int {
+	if c.serverInfo == nil {
+		return 0
+	}
+	return c.serverInfo.Limits.MaxPacketSize
+}
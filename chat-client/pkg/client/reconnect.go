@@ -0,0 +1,372 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net"
+	"time"
+
+	"github.com/Xaytick/chat-zinx/chat-server/pkg/model"
+	serverProtocol "github.com/Xaytick/chat-zinx/chat-server/pkg/protocol"
+)
+
+var (
+	// ErrOutboxFull 断线期间待发送的消息过多，新消息被拒绝
+	ErrOutboxFull = errors.New("发件箱已满")
+	// ErrReauthRequired 已重新连上服务器，但会话无法恢复且 Token 已失效（如密码已修改），需要调用方重新提供凭据登录
+	ErrReauthRequired = errors.New("需要重新登录")
+	// ErrReconnectFailed 超过最大重连次数，客户端已关闭
+	ErrReconnectFailed = errors.New("重连失败")
+	// ErrServerGoingAway 服务端节点正在关闭，连接断开后重连到其他节点
+	ErrServerGoingAway = errors.New("服务器节点已下线")
+)
+
+// ConnState 连接状态
+type ConnState int
+
+const (
+	StateConnected    ConnState = iota // 已连接
+	StateReconnecting                  // 连接断开，正在重连
+	StateClosed                        // 已关闭，不再重连
+)
+
+func (s ConnState) String() string {
+	switch s {
+	case StateConnected:
+		return "connected"
+	case StateReconnecting:
+		return "reconnecting"
+	case StateClosed:
+		return "closed"
+	default:
+		return fmt.Sprintf("ConnState(%d)", int(s))
+	}
+}
+
+// ReconnectPolicy 自动重连策略
+type ReconnectPolicy struct {
+	InitialBackoff time.Duration // 第一次重连前的等待时间
+	MaxBackoff     time.Duration // 等待时间上限
+	Multiplier     float64       // 每次失败后等待时间的倍数
+	Jitter         float64       // 随机抖动比例（0~1），避免大量客户端同时重连
+	MaxAttempts    int           // 最大重连次数，0 表示不限
+	MaxMissedPongs int           // 连续多少个心跳周期没有收到服务端消息即认为连接已失效
+	OutboxSize     int           // 断线期间最多缓存的待发送消息数
+}
+
+// DefaultReconnectPolicy 默认重连策略：1 秒起步，每次翻倍，最长 30 秒
+func DefaultReconnectPolicy() *ReconnectPolicy {
+	return &ReconnectPolicy{
+		InitialBackoff: time.Second,
+		MaxBackoff:     30 * time.Second,
+		Multiplier:     2,
+		Jitter:         0.2,
+		MaxMissedPongs: 3,
+		OutboxSize:     100,
+	}
+}
+
+// WithReconnect 连接断开后按 policy 自动重连并恢复登录，policy 为 nil 时不重连
+func WithReconnect(policy *ReconnectPolicy) func(*ChatClient) {
+	return func(c *ChatClient) {
+		c.reconnect = policy
+	}
+}
+
+// backoff 第 attempt 次重连前的等待时间
+func (p *ReconnectPolicy) backoff(attempt int) time.Duration {
+	delay := float64(p.InitialBackoff)
+	for i := 1; i < attempt && delay < float64(p.MaxBackoff); i++ {
+		delay *= p.Multiplier
+	}
+	if max := float64(p.MaxBackoff); max > 0 && delay > max {
+		delay = max
+	}
+	if p.Jitter > 0 {
+		delay += delay * p.Jitter * (2*rand.Float64() - 1)
+	}
+	return time.Duration(delay)
+}
+
+// outboxMsg 断线期间缓存的消息
+type outboxMsg struct {
+	msgID uint32
+	data  []byte
+}
+
+// sessionMsgIDs 建立会话本身所需的消息，断线期间不进入发件箱，直接在当前连接上发送
+var sessionMsgIDs = map[uint32]bool{
+	serverProtocol.MsgIDPing:              true,
+	serverProtocol.MsgIDRegisterReq:       true,
+	serverProtocol.MsgIDLoginReq:          true,
+	serverProtocol.MsgIDGuestLoginReq:     true,
+	serverProtocol.MsgIDBotLoginReq:       true,
+	serverProtocol.MsgIDTwoFactorLoginReq: true,
+	serverProtocol.MsgIDResumeSessionReq:  true,
+	serverProtocol.MsgIDTokenLoginReq:     true,
+}
+
+// OnStateChange 设置连接状态变化的回调，err 为导致状态变化的原因；回调在客户端内部的协程中执行，不应阻塞
+func (c *ChatClient) OnStateChange(handler func(state ConnState, err error)) {
+	c.mu.Lock()
+	c.onStateChange = handler
+	c.mu.Unlock()
+}
+
+// State 当前连接状态
+func (c *ChatClient) State() ConnState {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.state
+}
+
+func (c *ChatClient) notifyState(state ConnState, err error) {
+	c.mu.Lock()
+	handler := c.onStateChange
+	c.mu.Unlock()
+	if handler != nil {
+		handler(state, err)
+	}
+}
+
+// enqueueLocked 把消息放入发件箱，调用方需持有 c.mu
+func (c *ChatClient) enqueueLocked(msgID uint32, data []byte) error {
+	if len(c.outbox) >= c.reconnect.OutboxSize {
+		return ErrOutboxFull
+	}
+	c.outbox = append(c.outbox, outboxMsg{msgID: msgID, data: data})
+	return nil
+}
+
+// heartbeatExpired 开启自动重连时，超过 MaxMissedPongs 个心跳周期没有收到任何消息即认为连接已失效
+func (c *ChatClient) heartbeatExpired(interval time.Duration) bool {
+	if c.reconnect == nil || c.reconnect.MaxMissedPongs <= 0 {
+		return false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return time.Since(c.lastRecv) > interval*time.Duration(c.reconnect.MaxMissedPongs)
+}
+
+// dropConn 主动关闭当前连接，由消息监听器发现读错误后进入重连
+func (c *ChatClient) dropConn() {
+	c.mu.Lock()
+	conn := c.Conn
+	c.mu.Unlock()
+	if conn != nil {
+		conn.Close()
+	}
+}
+
+// onGoingAway 收到节点即将关闭的推送，记录服务端建议的重连时间；连接随后由服务端关闭，
+// 在此之前已入队的推送仍会正常收到
+func (c *ChatClient) onGoingAway(data []byte) {
+	var push model.ServerGoingAwayPush
+	if err := json.Unmarshal(data, &push); err != nil {
+		return
+	}
+	var delay time.Duration
+	if push.ReconnectWithin > 0 {
+		delay = time.Duration(rand.Int63n(int64(push.ReconnectWithin) * int64(time.Second)))
+	}
+	c.mu.Lock()
+	c.goingAway = true
+	c.goingAwayDelay = delay
+	c.mu.Unlock()
+}
+
+// handleConnLost 消息监听器读取 conn 失败时调用，返回 true 表示已由重连逻辑接管
+func (c *ChatClient) handleConnLost(conn net.Conn, cause error) bool {
+	if c.reconnect == nil {
+		return false
+	}
+	c.mu.Lock()
+	if c.closed || c.Conn != conn || c.state != StateConnected {
+		// 客户端已关闭、连接已被替换，或重连过程中新连接再次失败，均由重连循环处理
+		c.mu.Unlock()
+		return true
+	}
+	c.state = StateReconnecting
+	if c.goingAway {
+		cause = ErrServerGoingAway
+	}
+	wasLoggedIn := c.isLoggedIn
+	c.mu.Unlock()
+
+	conn.Close()
+	c.StopHeartbeat()
+	c.notifyState(StateReconnecting, cause)
+	go c.reconnectLoop(wasLoggedIn)
+	return true
+}
+
+// reconnectLoop 按退避策略重连，连上后恢复登录并发送发件箱中的消息
+func (c *ChatClient) reconnectLoop(wasLoggedIn bool) {
+	var lastErr error
+	for attempt := 1; ; attempt++ {
+		if c.isClosed() {
+			return
+		}
+		if c.reconnect.MaxAttempts > 0 && attempt > c.reconnect.MaxAttempts {
+			c.giveUp(lastErr)
+			return
+		}
+		time.Sleep(c.reconnectDelay(attempt))
+		if c.isClosed() {
+			return
+		}
+
+		conn, err := c.connect()
+		if err != nil {
+			lastErr = err
+			fmt.Printf("第 %d 次重连失败: %v\n", attempt, err)
+			continue
+		}
+		c.mu.Lock()
+		if c.closed {
+			c.mu.Unlock()
+			conn.Close()
+			return
+		}
+		c.Conn = conn
+		c.lastRecv = time.Now()
+		c.goingAway = false
+		c.mu.Unlock()
+		go c.listen(conn)
+
+		err = c.reauthenticate(wasLoggedIn)
+		if err != nil && !errors.Is(err, ErrReauthRequired) {
+			lastErr = err
+			fmt.Printf("第 %d 次重连后恢复登录失败: %v\n", attempt, err)
+			conn.Close()
+			continue
+		}
+		c.finishReconnect(err)
+		return
+	}
+}
+
+// reconnectDelay 第 attempt 次重连前的等待时间，节点下线后的第一次重连使用服务端建议范围内的随机时间，避免同时涌向其他节点
+func (c *ChatClient) reconnectDelay(attempt int) time.Duration {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if attempt == 1 && c.goingAway {
+		return c.goingAwayDelay
+	}
+	return c.reconnect.backoff(attempt)
+}
+
+// reauthenticate 在新连接上恢复登录：优先恢复会话（不会丢失断线期间的推送），
+// 会话无法恢复时机器人用保存的 API Key 重新登录，其他账号凭 Token 重新登录，Token 失效时返回 ErrReauthRequired
+func (c *ChatClient) reauthenticate(wasLoggedIn bool) error {
+	if !wasLoggedIn {
+		return nil
+	}
+	if c.CanResume() {
+		_, err := c.ResumeSession()
+		if err == nil {
+			return nil
+		}
+		if !errors.Is(err, ErrResumeRejected) {
+			return err
+		}
+	}
+
+	c.mu.Lock()
+	relogin := c.relogin
+	c.mu.Unlock()
+	if relogin == nil && c.Token != "" {
+		relogin = func() error {
+			_, err := c.LoginWithToken()
+			return err
+		}
+	}
+	if relogin == nil {
+		return ErrReauthRequired
+	}
+	err := relogin()
+	if errors.Is(err, ErrLoginRejected) || errors.Is(err, ErrTwoFactorRequired) {
+		return fmt.Errorf("%w: %v", ErrReauthRequired, err)
+	}
+	return err
+}
+
+// finishReconnect 重连完成，按顺序发送发件箱中的消息；reauthErr 不为空时登录未能恢复，
+// 发件箱中的消息保留到调用方重新登录同一账号后发送
+func (c *ChatClient) finishReconnect(reauthErr error) {
+	c.mu.Lock()
+	if reauthErr != nil {
+		if len(c.outbox) > 0 {
+			fmt.Printf("未能恢复登录，%d 条待发送消息将在重新登录后发送\n", len(c.outbox))
+		}
+		c.isLoggedIn = false
+	}
+	var flushErr error
+	if c.isLoggedIn {
+		flushErr = c.flushOutboxLocked()
+	}
+	c.state = StateConnected
+	c.mu.Unlock()
+
+	if flushErr != nil {
+		fmt.Printf("发送待发消息失败: %v\n", flushErr)
+	}
+	c.notifyState(StateConnected, reauthErr)
+}
+
+// flushOutboxLocked 按顺序发送发件箱中的消息，调用方需持有 c.mu
+func (c *ChatClient) flushOutboxLocked() error {
+	for len(c.outbox) > 0 {
+		if err := c.writeLocked(c.outbox[0].msgID, c.outbox[0].data); err != nil {
+			// 剩余的消息留在发件箱，关闭连接后由监听器再次进入重连
+			c.Conn.Close()
+			return err
+		}
+		c.outbox = c.outbox[1:]
+	}
+	return nil
+}
+
+// flushOutboxAfterLogin 登录成功后发送断线时未能自动恢复登录而保留的消息，登录的不是原账号时丢弃
+// 重连过程中的登录由 finishReconnect 发送
+func (c *ChatClient) flushOutboxAfterLogin(prevUserID uint) {
+	c.mu.Lock()
+	if c.state != StateConnected || len(c.outbox) == 0 {
+		c.mu.Unlock()
+		return
+	}
+	if prevUserID != c.UserID {
+		fmt.Printf("登录的账号已变更，丢弃 %d 条待发送消息\n", len(c.outbox))
+		c.outbox = nil
+		c.mu.Unlock()
+		return
+	}
+	err := c.flushOutboxLocked()
+	c.mu.Unlock()
+	if err != nil {
+		fmt.Printf("发送待发消息失败: %v\n", err)
+	}
+}
+
+func (c *ChatClient) isClosed() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.closed
+}
+
+// giveUp 超过最大重连次数后关闭客户端
+func (c *ChatClient) giveUp(lastErr error) {
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return
+	}
+	c.closed = true
+	c.state = StateClosed
+	c.outbox = nil
+	c.isLoggedIn = false
+	c.mu.Unlock()
+	c.notifyState(StateClosed, fmt.Errorf("%w: %v", ErrReconnectFailed, lastErr))
+}
@@ -0,0 +1,105 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/Xaytick/chat-zinx/chat-server/pkg/model"
+	serverProtocol "github.com/Xaytick/chat-zinx/chat-server/pkg/protocol"
+)
+
+// ErrResumeRejected 服务端无法恢复会话（已过期、错过的推送过多等），需要重新登录
+var ErrResumeRejected = errors.New("会话无法恢复")
+
+// resumeState 断线恢复所需的状态：登录时签发的恢复令牌和最后收到的推送序号
+type resumeState struct {
+	mu      sync.Mutex
+	token   string
+	lastSeq uint64
+}
+
+// reset 登录或恢复成功后更新令牌，seq 为服务端确认的序号
+func (s *resumeState) reset(token string, seq uint64) {
+	s.mu.Lock()
+	s.token = token
+	s.lastSeq = seq
+	s.mu.Unlock()
+}
+
+// observe 收到服务端推送时递增序号，与服务端的编号规则一致
+func (s *resumeState) observe(msgID uint32) {
+	if !serverProtocol.IsPushMsgID(msgID) {
+		return
+	}
+	s.mu.Lock()
+	s.lastSeq++
+	s.mu.Unlock()
+}
+
+// setToken 只替换令牌，序号由消息监听器继续维护
+func (s *resumeState) setToken(token string) {
+	s.mu.Lock()
+	s.token = token
+	s.mu.Unlock()
+}
+
+func (s *resumeState) snapshot() (string, uint64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.token, s.lastSeq
+}
+
+// CanResume 是否持有可用于恢复会话的令牌
+func (c *ChatClient) CanResume() bool {
+	token, _ := c.resume.snapshot()
+	return token != ""
+}
+
+// ResumeSession 在新建立的连接上恢复之前的会话，代替重新登录
+// 服务端随后补发断线期间错过的推送，由消息监听器照常处理；返回 ErrResumeRejected 时应改为调用 Login
+func (c *ChatClient) ResumeSession() (*model.ResumeSessionResp, error) {
+	token, lastSeq := c.resume.snapshot()
+	if token == "" {
+		return nil, ErrResumeRejected
+	}
+	body, err := json.Marshal(model.ResumeSessionReq{ResumeToken: token, LastSeq: lastSeq})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal resume request: %w", err)
+	}
+
+	respChan := c.expectResponse(serverProtocol.MsgIDResumeSessionResp)
+	if err := c.SendMessage(serverProtocol.MsgIDResumeSessionReq, body); err != nil {
+		c.cancelResponse(serverProtocol.MsgIDResumeSessionResp, respChan)
+		return nil, fmt.Errorf("发送恢复会话请求失败: %v", err)
+	}
+
+	select {
+	case respMsg := <-respChan:
+		var genericResp struct {
+			Code uint32                  `json:"code"`
+			Msg  string                  `json:"msg"`
+			Data model.ResumeSessionResp `json:"data"`
+		}
+		if err := json.Unmarshal(respMsg.GetData(), &genericResp); err != nil {
+			return nil, fmt.Errorf("解析恢复会话响应失败: %v", err)
+		}
+		if genericResp.Code != 0 {
+			c.resume.setToken("")
+			return nil, fmt.Errorf("%w: %s (code: %d)", ErrResumeRejected, genericResp.Msg, genericResp.Code)
+		}
+		// 补发的推送在响应之后到达，由消息监听器在上报的序号上继续计数
+		c.resume.setToken(genericResp.Data.ResumeToken)
+		c.UserID = genericResp.Data.ID
+		c.UserUUID = genericResp.Data.UserUUID
+		c.Username = genericResp.Data.Username
+		c.setLoggedIn(true)
+		c.StartHeartbeat(c.heartbeatInterval())
+		return &genericResp.Data, nil
+	case <-time.After(c.requestTimeout):
+		c.cancelResponse(serverProtocol.MsgIDResumeSessionResp, respChan)
+		return nil, fmt.Errorf("恢复会话响应超时")
+	}
+}
@@ -0,0 +1,79 @@
+package client
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+)
+
+// TLSOptions 通过 TLS 连接服务器时的选项，对应服务器配置中的 TLS 网关端口
+type TLSOptions struct {
+	CAFile             string // 自签名证书或内部 CA 的证书文件，为空时使用系统根证书
+	ServerName         string // 校验证书时使用的服务器名，为空时取地址中的主机部分
+	InsecureSkipVerify bool   // 跳过证书校验，只用于开发环境
+	CertFile           string // 客户端证书，服务器要求双向认证时需要
+	KeyFile            string
+}
+
+// WithTLS 使用 TLS 连接服务器，opts 为 nil 时仍使用明文连接
+func WithTLS(opts *TLSOptions) func(*ChatClient) {
+	return func(c *ChatClient) {
+		c.tlsOptions = opts
+	}
+}
+
+// buildTLSConfig 根据选项生成 crypto/tls 配置
+func (o *TLSOptions) buildTLSConfig(serverAddr string) (*tls.Config, error) {
+	config := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		ServerName:         o.ServerName,
+		InsecureSkipVerify: o.InsecureSkipVerify,
+	}
+	if config.ServerName == "" {
+		host, _, err := net.SplitHostPort(serverAddr)
+		if err != nil {
+			return nil, fmt.Errorf("服务器地址格式错误: %v", err)
+		}
+		config.ServerName = host
+	}
+
+	if o.CAFile != "" {
+		pem, err := os.ReadFile(o.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("读取 CA 证书失败: %v", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("CA 证书文件 %s 中没有有效的证书", o.CAFile)
+		}
+		config.RootCAs = pool
+	}
+
+	if o.CertFile != "" || o.KeyFile != "" {
+		if o.CertFile == "" || o.KeyFile == "" {
+			return nil, errors.New("客户端证书和私钥需要同时提供")
+		}
+		cert, err := tls.LoadX509KeyPair(o.CertFile, o.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("加载客户端证书失败: %v", err)
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+	return config, nil
+}
+
+// dial 建立到服务器的连接，设置了 TLS 选项时完成握手后再返回
+func (c *ChatClient) dial() (net.Conn, error) {
+	if c.tlsOptions == nil {
+		return net.Dial("tcp", c.ServerAddr)
+	}
+	config, err := c.tlsOptions.buildTLSConfig(c.ServerAddr)
+	if err != nil {
+		return nil, err
+	}
+	dialer := &net.Dialer{Timeout: c.requestTimeout}
+	return tls.DialWithDialer(dialer, "tcp", c.ServerAddr, config)
+}
@@ -3,24 +3,38 @@ package protocol
 import (
 	"bytes"
 	"encoding/binary"
+	"fmt"
 	// "errors" // Not strictly needed for this basic version but good for future additions
+
+	serverProtocol "github.com/Xaytick/chat-zinx/chat-server/pkg/protocol"
 )
 
+// maxDecompressedSize 解压后消息体的上限，防止异常数据占满内存
+const maxDecompressedSize = 16 << 20
+
 // IDataPack 封包、拆包的接口
 type IDataPack interface {
 	GetHeadLen() uint32
 	Pack(msg *Message) ([]byte, error)
 	Unpack([]byte) (*Message, error)
+	DecodeData(msg *Message) error
 }
 
 // DataPack 封包拆包实例，根据服务端Zinx实际使用情况，这里改为 LittleEndian
-type DataPack struct{}
+type DataPack struct {
+	compression string // 握手协商的压缩算法，服务端发来的压缩消息按此解压
+}
 
 // NewDataPack 初始化 DataPack
 func NewDataPack() IDataPack {
 	return &DataPack{}
 }
 
+// NewDataPackWithCompression 初始化按 compression 解压服务端消息的 DataPack
+func NewDataPackWithCompression(compression string) IDataPack {
+	return &DataPack{compression: compression}
+}
+
 // GetHeadLen 获取包头长度方法 (uint32 for DataLen + uint32 for ID)
 func (dp *DataPack) GetHeadLen() uint32 {
 	return 8
@@ -62,6 +76,11 @@ func (dp *DataPack) Unpack(binaryData []byte) (*Message, error) {
 	if err := binary.Read(dataBuff, binary.LittleEndian, &msg.ID); err != nil {
 		return nil, err
 	}
+	// 最高位表示消息体已压缩，读完数据后由 DecodeData 解压
+	if msg.ID&serverProtocol.CompressedFlag != 0 {
+		msg.ID &^= serverProtocol.CompressedFlag
+		msg.Compressed = true
+	}
 
 	// 可选：在这里检查DataLen是否超出允许的最大包长度
 	// if msg.DataLen > MaxPacketSize {
@@ -70,3 +89,21 @@ func (dp *DataPack) Unpack(binaryData []byte) (*Message, error) {
 
 	return msg, nil
 }
+
+// DecodeData 读取完消息体后调用，解压服务端压缩过的消息
+func (dp *DataPack) DecodeData(msg *Message) error {
+	if !msg.Compressed {
+		return nil
+	}
+	if dp.compression == "" || dp.compression == serverProtocol.CompressionNone {
+		return fmt.Errorf("收到压缩消息 (MsgID %d)，但连接未协商压缩算法", msg.ID)
+	}
+	data, err := serverProtocol.Decompress(dp.compression, msg.Data, maxDecompressedSize)
+	if err != nil {
+		return fmt.Errorf("解压消息 (MsgID %d) 失败: %w", msg.ID, err)
+	}
+	msg.Data = data
+	msg.DataLen = uint32(len(data))
+	msg.Compressed = false
+	return nil
+}
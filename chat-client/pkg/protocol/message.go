@@ -5,6 +5,8 @@ type Message struct {
 	DataLen uint32 // 消息长度
 	ID      uint32 // 消息ID
 	Data    []byte // 消息内容
+
+	Compressed bool // 消息体是否为压缩数据，拆包时根据消息ID的标志位设置
 }
 
 // GetDataLen 获取消息数据段长度
@@ -2,9 +2,11 @@ package conf
 
 import (
 	"encoding/json"
+	"fmt"
 	"io"
 	"os"
 	"path/filepath"
+	"strconv"
 )
 
 // DatabaseConfig 数据库配置
@@ -49,9 +51,11 @@ type HeartbeatConfig struct {
 
 // AuthConfig 认证配置结构体
 type AuthConfig struct {
-	JWT             JWTConfig      `json:"JWT"`             // JWT配置
-	Security        SecurityConfig `json:"Security"`        // 安全配置
-	SignatureSecret string         `json:"SignatureSecret"` // 签名密钥
+	JWT             JWTConfig             `json:"JWT"`             // JWT配置
+	Security        SecurityConfig        `json:"Security"`        // 安全配置
+	LoginProtection LoginProtectionConfig `json:"LoginProtection"` // 登录与注册防暴力破解配置
+	SignatureSecret string                `json:"SignatureSecret"` // 签名密钥
+	Admins          []string              `json:"Admins"`          // 管理员用户名列表，可解锁被锁定的账号
 }
 
 // JWTConfig JWT配置结构体
@@ -66,20 +70,186 @@ type SecurityConfig struct {
 	TimestampTolerance int `json:"TimestampTolerance"` // 时间戳容忍度
 	NonceExpiration    int `json:"NonceExpiration"`    // 非对称加密过期时间
 	SessionExpiration  int `json:"SessionExpiration"`  // 会话过期时间
+	PasswordResetTTL   int `json:"PasswordResetTTL"`   // 密码重置令牌有效期（秒）
+	EmailVerifyTTL     int `json:"EmailVerifyTTL"`     // 邮箱验证令牌有效期（秒）
+}
+
+// LoginProtectionConfig 登录与注册防暴力破解配置，计数保存在 Redis 中
+type LoginProtectionConfig struct {
+	FailureWindow      int `json:"FailureWindow"`      // 失败次数的统计窗口（秒）
+	MaxAccountFailures int `json:"MaxAccountFailures"` // 单个账号在窗口内允许的失败次数，超过后锁定账号
+	MaxIPFailures      int `json:"MaxIPFailures"`      // 单个IP在窗口内允许的失败次数，超过后锁定该IP
+	LockoutDuration    int `json:"LockoutDuration"`    // 锁定时长（秒）
+	BackoffAfter       int `json:"BackoffAfter"`       // 连续失败多少次后开始退避
+	BackoffBase        int `json:"BackoffBase"`        // 首次退避等待时间（秒），之后每次失败翻倍
+	RegisterPerIP      int `json:"RegisterPerIP"`      // 单个IP在窗口内允许的注册次数
+	RegisterWindow     int `json:"RegisterWindow"`     // 注册次数的统计窗口（秒）
+	ResetPerAccount    int `json:"ResetPerAccount"`    // 单个账号在窗口内允许申请重置密码的次数，超过后不再发送邮件
+	ResetPerIP         int `json:"ResetPerIP"`         // 单个IP在窗口内允许申请重置密码的次数
+	ResetWindow        int `json:"ResetWindow"`        // 重置密码申请次数的统计窗口（秒）
+}
+
+// GroupConfig 群组配置结构体
+type GroupConfig struct {
+	TierMemberLimits map[string]int `json:"TierMemberLimits"` // 各群组等级的成员上限
+	DefaultTier      string         `json:"DefaultTier"`      // 新建群组的默认等级
+	MemberCacheTTL   int            `json:"MemberCacheTTL"`   // 群成员ID集合缓存时间（秒）
+	FanoutWorkers    int            `json:"FanoutWorkers"`    // 群消息推送协程数
+	FanoutQueueSize  int            `json:"FanoutQueueSize"`  // 群消息推送任务队列长度
+	FanoutBatchSize  int            `json:"FanoutBatchSize"`  // 每个推送任务包含的成员数
+}
+
+// FriendConfig 好友配置结构体
+type FriendConfig struct {
+	MaxFriends         int  `json:"MaxFriends"`         // 每个用户的好友上限
+	OnlyFriendsCanChat bool `json:"OnlyFriendsCanChat"` // 是否只允许好友之间发起私聊
+}
+
+// MailConfig 邮件配置结构体
+type MailConfig struct {
+	Driver   string `json:"Driver"`   // 发送方式: smtp、file 或 log
+	SMTPHost string `json:"SMTPHost"` // SMTP 服务器地址
+	SMTPPort int    `json:"SMTPPort"` // SMTP 端口
+	Username string `json:"Username"` // SMTP 用户名，为空时不进行认证
+	Password string `json:"Password"` // SMTP 密码
+	From     string `json:"From"`     // 发件人地址
+	FilePath string `json:"FilePath"` // file 方式下邮件写入的文件
+}
+
+// AccountConfig 账号注销、个人数据导出以及访客和机器人账号配置
+type AccountConfig struct {
+	DeletionGracePeriod int    `json:"DeletionGracePeriod"` // 申请注销后的冷静期（秒），期间可以撤销
+	MessagePolicy       string `json:"MessagePolicy"`       // 注销后群消息的处理方式: anonymize 匿名保留，delete 删除
+	PurgeInterval       int    `json:"PurgeInterval"`       // 扫描到期注销账号的间隔（秒）
+	ExportDir           string `json:"ExportDir"`           // 个人数据导出文件的存放目录
+	ExportTTL           int    `json:"ExportTTL"`           // 导出文件的保留时间（秒），过期后删除
+	GuestEnabled        bool   `json:"GuestEnabled"`        // 是否允许访客登录
+	GuestTTL            int    `json:"GuestTTL"`            // 访客账号的有效期（秒），到期后按注销流程清除
+	GuestGroups         []uint `json:"GuestGroups"`         // 访客可以加入的群组ID
+	MaxBotsPerUser      int    `json:"MaxBotsPerUser"`      // 每个用户最多创建的机器人账号数
+}
+
+// WebhookConfig 机器人 outgoing webhook 配置
+type WebhookConfig struct {
+	Workers              int  `json:"Workers"`              // 投递 webhook 的协程数
+	QueueSize            int  `json:"QueueSize"`            // 待投递队列长度，队列满时丢弃新的投递
+	Timeout              int  `json:"Timeout"`              // 单次 HTTP 请求超时（毫秒）
+	MaxRetries           int  `json:"MaxRetries"`           // 网络错误、5xx 和 429 时的最大重试次数
+	RetryBackoff         int  `json:"RetryBackoff"`         // 首次重试前的等待时间（毫秒），之后每次翻倍
+	MaxReplyLen          int  `json:"MaxReplyLen"`          // 机器人回复发到群里的最大字符数，超出部分截断
+	AllowPrivateNetworks bool `json:"AllowPrivateNetworks"` // 是否允许投递到回环和内网地址，本地调试时开启
+}
+
+// HTTPConfig chat-server 内置 HTTP 服务配置，目前用于 incoming webhook
+type HTTPConfig struct {
+	Enabled      bool   `json:"Enabled"`      // 是否启动 HTTP 服务
+	Addr         string `json:"Addr"`         // 监听地址
+	PublicURL    string `json:"PublicURL"`    // 对外访问地址，用于生成 webhook URL，经过反向代理时需要设置
+	ReadTimeout  int    `json:"ReadTimeout"`  // 读取请求超时（秒）
+	WriteTimeout int    `json:"WriteTimeout"` // 写入响应超时（秒）
+	MaxBodySize  int64  `json:"MaxBodySize"`  // 请求体最大字节数
+	MetricsToken string `json:"MetricsToken"` // 访问 /metrics 接口需携带的 Bearer Token，为空时不开放这些接口
+}
+
+// TLSConfig TCP 聊天端口的 TLS 配置
+// zinx 只处理明文 TCP，开启后由 TLS 网关在 Addr 上终止 TLS，再转发到本机的 zinx 端口
+type TLSConfig struct {
+	Enabled               bool   `json:"Enabled"`               // 是否启动 TLS 网关
+	Addr                  string `json:"Addr"`                  // TLS 监听地址
+	Upstream              string `json:"Upstream"`              // zinx 明文监听地址，默认 127.0.0.1:TcpPort
+	CertFile              string `json:"CertFile"`              // 服务器证书（PEM，可包含中间证书）
+	KeyFile               string `json:"KeyFile"`               // 服务器私钥（PEM）
+	ClientCAFile          string `json:"ClientCAFile"`          // 校验客户端证书的 CA，设置后启用双向 TLS
+	ClientAuth            string `json:"ClientAuth"`            // optional: 客户端提供证书时才校验；require: 必须提供有效证书
+	MinVersion            string `json:"MinVersion"`            // 最低 TLS 版本，1.2 或 1.3
+	ReloadInterval        int    `json:"ReloadInterval"`        // 检查证书文件是否更新的间隔（秒）
+	HandshakeTimeout      int    `json:"HandshakeTimeout"`      // TLS 握手超时（秒）
+	PlaintextLoopbackOnly bool   `json:"PlaintextLoopbackOnly"` // 开启 TLS 后拒绝来自非本机地址的明文连接
+}
+
+// WebSocketConfig 浏览器使用的 WebSocket 网关配置，挂在内置 HTTP 服务上，需要同时开启 HTTP
+// 每个 WebSocket 连接在网关内对应一条到 zinx 端口的 TCP 连接，与 TCP 客户端共用路由和在线状态
+type WebSocketConfig struct {
+	Enabled        bool     `json:"Enabled"`        // 是否启用 WebSocket 网关
+	Path           string   `json:"Path"`           // WebSocket 路径
+	Upstream       string   `json:"Upstream"`       // zinx 明文监听地址，默认 127.0.0.1:TcpPort
+	AllowedOrigins []string `json:"AllowedOrigins"` // 允许的 Origin，为空时只允许与请求 Host 相同的来源，"*" 允许所有来源
+	MaxMessageSize int      `json:"MaxMessageSize"` // 单条 WebSocket 消息的最大字节数
+	PingInterval   int      `json:"PingInterval"`   // 向浏览器发送 WebSocket ping 的间隔（秒），用于保持代理上的空闲连接
+	WriteTimeout   int      `json:"WriteTimeout"`   // 向浏览器写入单条消息的超时（秒）
+}
+
+// SessionResumeConfig 断线重连时恢复会话的配置
+// 连接断开后会话保留 Window 秒，期间用户不会被标记为离线，客户端可凭恢复令牌接回会话并补发错过的推送
+type SessionResumeConfig struct {
+	Window     int `json:"Window"`     // 断线后保留会话的时间（秒）
+	BufferSize int `json:"BufferSize"` // 每个会话缓存的最近推送条数，错过的推送超过该数量时只能重新登录
+}
+
+// BackpressureConfig 推送的背压配置
+// 每个已登录连接有一个长度为 QueueSize 的发送队列，由独立的协程写出，推送只入队不阻塞业务 worker。
+// 队列积压达到 HighWater 并持续 SlowTimeout 秒视为慢消费者，按 Policy 处理；被丢弃的推送转存离线，积压回落或下次登录时补发
+type BackpressureConfig struct {
+	QueueSize   int    `json:"QueueSize"`   // 每个连接的发送队列长度，队列满时新推送总是被丢弃
+	HighWater   int    `json:"HighWater"`   // 积压告警线，不超过 QueueSize
+	SlowTimeout int    `json:"SlowTimeout"` // 积压持续超过告警线多久（秒）视为慢消费者
+	Policy      string `json:"Policy"`      // 慢消费者的处理方式：drop 丢弃新推送直到积压回落，disconnect 断开连接（可凭恢复令牌接回会话）
+}
+
+// ShutdownConfig 收到 SIGTERM 或 SIGINT 后优雅关闭的配置
+type ShutdownConfig struct {
+	Timeout         int `json:"Timeout"`         // 整个关闭过程的最长时间（秒），超时的步骤不再等待
+	ReconnectWithin int `json:"ReconnectWithin"` // 通知客户端在该时间（秒）内随机挑选时机重连
+}
+
+// HandshakeConfig 连接建立后的 hello 握手配置，客户端在登录前上报协议版本和支持的功能
+type HandshakeConfig struct {
+	Required           bool `json:"Required"`           // 是否要求先完成握手才能登录，开启后不发送 hello 的旧客户端无法登录
+	MinProtocolVersion int  `json:"MinProtocolVersion"` // 接受的最低协议版本，低于该版本的客户端在握手时被拒绝
+}
+
+// CompressionConfig 服务端发往客户端的消息体压缩配置，算法在握手时按连接协商，未握手的旧客户端不压缩
+type CompressionConfig struct {
+	Enabled    bool     `json:"Enabled"`    // 是否允许协商压缩
+	Algorithms []string `json:"Algorithms"` // 允许的算法（zstd、deflate），客户端从中按自己的优先级选择
+	Threshold  int      `json:"Threshold"`  // 消息体达到该字节数才压缩，小消息压缩收益不大
+}
+
+// IncomingWebhookConfig 外部系统向群组发消息的 incoming webhook 配置
+type IncomingWebhookConfig struct {
+	MaxPerGroup int `json:"MaxPerGroup"` // 每个群最多创建的 webhook 数
+	RateLimit   int `json:"RateLimit"`   // 每个 webhook 在 RateWindow 内最多发送的消息数
+	RateWindow  int `json:"RateWindow"`  // 限流统计窗口（秒）
+	MaxTextLen  int `json:"MaxTextLen"`  // 组装后消息内容的最大字符数
+	MaxFields   int `json:"MaxFields"`   // 每条消息最多携带的字段数
 }
 
 // Config 应用配置结构体
 type Config struct {
-	Name           string          `json:"Name"`           // 名称
-	Host           string          `json:"Host"`           // 主机地址
-	TcpPort        int             `json:"TcpPort"`        // 端口号
-	MaxConn        int             `json:"MaxConn"`        // 最大连接数
-	WorkerPoolSize int             `json:"WorkerPoolSize"` // 工作池大小
-	MaxMsgChanLen  int             `json:"MaxMsgChanLen"`  // 最大消息通道长度
-	MaxPacketSize  int             `json:"MaxPacketSize"`  // 最大包大小
-	Heartbeat      HeartbeatConfig `json:"Heartbeat"`      // 心跳配置
-	Database       DatabaseConfig  `json:"Database"`       // 数据库配置
-	Auth           AuthConfig      `json:"Auth"`           // 认证配置
+	Name            string                `json:"Name"`            // 名称
+	Host            string                `json:"Host"`            // 主机地址
+	TcpPort         int                   `json:"TcpPort"`         // 端口号
+	MaxConn         int                   `json:"MaxConn"`         // 最大连接数
+	WorkerPoolSize  int                   `json:"WorkerPoolSize"`  // 工作池大小
+	MaxMsgChanLen   int                   `json:"MaxMsgChanLen"`   // 最大消息通道长度
+	MaxPacketSize   int                   `json:"MaxPacketSize"`   // 最大包大小
+	Heartbeat       HeartbeatConfig       `json:"Heartbeat"`       // 心跳配置
+	Database        DatabaseConfig        `json:"Database"`        // 数据库配置
+	Auth            AuthConfig            `json:"Auth"`            // 认证配置
+	Group           GroupConfig           `json:"Group"`           // 群组配置
+	Friend          FriendConfig          `json:"Friend"`          // 好友配置
+	Mail            MailConfig            `json:"Mail"`            // 邮件配置
+	Account         AccountConfig         `json:"Account"`         // 账号注销与数据导出配置
+	Webhook         WebhookConfig         `json:"Webhook"`         // 机器人 webhook 配置
+	HTTP            HTTPConfig            `json:"HTTP"`            // HTTP 服务配置
+	IncomingWebhook IncomingWebhookConfig `json:"IncomingWebhook"` // incoming webhook 配置
+	TLS             TLSConfig             `json:"TLS"`             // TLS 配置
+	WebSocket       WebSocketConfig       `json:"WebSocket"`       // WebSocket 网关配置
+	SessionResume   SessionResumeConfig   `json:"SessionResume"`   // 会话恢复配置
+	Backpressure    BackpressureConfig    `json:"Backpressure"`    // 推送背压配置
+	Shutdown        ShutdownConfig        `json:"Shutdown"`        // 优雅关闭配置
+	Handshake       HandshakeConfig       `json:"Handshake"`       // 握手配置
+	Compression     CompressionConfig     `json:"Compression"`     // 消息压缩配置
 }
 
 // 全局配置实例
@@ -114,6 +284,23 @@ func LoadConfig(filePath string) (*Config, error) {
 	// 设置默认值
 	setDefaultAuthConfig(&config.Auth)
 	setDefaultHeartbeatConfig(&config.Heartbeat)
+	setDefaultGroupConfig(&config.Group)
+	setDefaultFriendConfig(&config.Friend)
+	setDefaultMailConfig(&config.Mail)
+	setDefaultAccountConfig(&config.Account)
+	setDefaultWebhookConfig(&config.Webhook)
+	setDefaultHTTPConfig(&config.HTTP)
+	setDefaultIncomingWebhookConfig(&config.IncomingWebhook)
+	setDefaultTLSConfig(&config.TLS, config.TcpPort)
+	setDefaultWebSocketConfig(&config.WebSocket, config.TcpPort)
+	setDefaultSessionResumeConfig(&config.SessionResume)
+	setDefaultBackpressureConfig(&config.Backpressure)
+	setDefaultShutdownConfig(&config.Shutdown)
+	setDefaultHandshakeConfig(&config.Handshake)
+	setDefaultCompressionConfig(&config.Compression)
+	if config.MaxPacketSize == 0 {
+		config.MaxPacketSize = 8192 // 与 zinx.json 的 MaxPackageSize 保持一致
+	}
 
 	// 更新全局配置
 	GlobalConfig = &config
@@ -143,6 +330,48 @@ func setDefaultAuthConfig(authConfig *AuthConfig) {
 	if authConfig.Security.SessionExpiration == 0 {
 		authConfig.Security.SessionExpiration = 86400 // 24小时
 	}
+	if authConfig.Security.PasswordResetTTL == 0 {
+		authConfig.Security.PasswordResetTTL = 1800 // 30分钟
+	}
+	if authConfig.Security.EmailVerifyTTL == 0 {
+		authConfig.Security.EmailVerifyTTL = 86400 // 24小时
+	}
+
+	// 防暴力破解配置默认值
+	protection := &authConfig.LoginProtection
+	if protection.FailureWindow == 0 {
+		protection.FailureWindow = 900 // 15分钟
+	}
+	if protection.MaxAccountFailures == 0 {
+		protection.MaxAccountFailures = 5
+	}
+	if protection.MaxIPFailures == 0 {
+		protection.MaxIPFailures = 20
+	}
+	if protection.LockoutDuration == 0 {
+		protection.LockoutDuration = 900 // 15分钟
+	}
+	if protection.BackoffAfter == 0 {
+		protection.BackoffAfter = 3
+	}
+	if protection.BackoffBase == 0 {
+		protection.BackoffBase = 2
+	}
+	if protection.RegisterPerIP == 0 {
+		protection.RegisterPerIP = 5
+	}
+	if protection.RegisterWindow == 0 {
+		protection.RegisterWindow = 3600 // 1小时
+	}
+	if protection.ResetPerAccount == 0 {
+		protection.ResetPerAccount = 3
+	}
+	if protection.ResetPerIP == 0 {
+		protection.ResetPerIP = 10
+	}
+	if protection.ResetWindow == 0 {
+		protection.ResetWindow = 3600 // 1小时
+	}
 
 	// 签名密钥默认值
 	if authConfig.SignatureSecret == "" {
@@ -161,6 +390,32 @@ func setDefaultHeartbeatConfig(heartbeatConfig *HeartbeatConfig) {
 	}
 }
 
+// 设置群组配置默认值
+func setDefaultGroupConfig(groupConfig *GroupConfig) {
+	if len(groupConfig.TierMemberLimits) == 0 {
+		groupConfig.TierMemberLimits = map[string]int{
+			"normal": 500,
+			"large":  2000,
+			"super":  20000,
+		}
+	}
+	if groupConfig.DefaultTier == "" {
+		groupConfig.DefaultTier = "normal"
+	}
+	if groupConfig.MemberCacheTTL == 0 {
+		groupConfig.MemberCacheTTL = 3600 // 1小时
+	}
+	if groupConfig.FanoutWorkers == 0 {
+		groupConfig.FanoutWorkers = 16
+	}
+	if groupConfig.FanoutQueueSize == 0 {
+		groupConfig.FanoutQueueSize = 1024
+	}
+	if groupConfig.FanoutBatchSize == 0 {
+		groupConfig.FanoutBatchSize = 200
+	}
+}
+
 // GetMySQLConfig 获取MySQL配置
 func GetMySQLConfig() *MySQLConfig {
 	if GlobalConfig == nil {
@@ -188,6 +443,16 @@ func GetAuthConfig() *AuthConfig {
 	return &authConfig
 }
 
+// GetLoginProtectionConfig 获取防暴力破解配置
+func GetLoginProtectionConfig() *LoginProtectionConfig {
+	authConfig := GetAuthConfig()
+	if authConfig == nil {
+		authConfig = &AuthConfig{}
+		setDefaultAuthConfig(authConfig)
+	}
+	return &authConfig.LoginProtection
+}
+
 // GetHeartbeatConfig 获取心跳配置
 func GetHeartbeatConfig() *HeartbeatConfig {
 	if GlobalConfig == nil {
@@ -223,3 +488,408 @@ func IsHeartbeatEnabled() bool {
 	}
 	return config.Enabled
 }
+
+// 设置好友配置默认值
+func setDefaultFriendConfig(friendConfig *FriendConfig) {
+	if friendConfig.MaxFriends == 0 {
+		friendConfig.MaxFriends = 1000
+	}
+}
+
+// GetGroupConfig 获取群组配置
+func GetGroupConfig() *GroupConfig {
+	if GlobalConfig == nil {
+		groupConfig := GroupConfig{}
+		setDefaultGroupConfig(&groupConfig)
+		return &groupConfig
+	}
+	groupConfig := GlobalConfig.Group
+	return &groupConfig
+}
+
+// GetGroupMemberLimit 获取指定等级群组的成员上限，未配置的等级使用默认等级的上限
+func GetGroupMemberLimit(tier string) int {
+	config := GetGroupConfig()
+	if limit, ok := config.TierMemberLimits[tier]; ok {
+		return limit
+	}
+	return config.TierMemberLimits[config.DefaultTier]
+}
+
+// GetFriendConfig 获取好友配置
+func GetFriendConfig() *FriendConfig {
+	if GlobalConfig == nil {
+		friendConfig := FriendConfig{}
+		setDefaultFriendConfig(&friendConfig)
+		return &friendConfig
+	}
+	friendConfig := GlobalConfig.Friend
+	return &friendConfig
+}
+
+// 设置邮件配置默认值
+func setDefaultMailConfig(mailConfig *MailConfig) {
+	if mailConfig.Driver == "" {
+		mailConfig.Driver = "log"
+	}
+	if mailConfig.SMTPPort == 0 {
+		mailConfig.SMTPPort = 587
+	}
+	if mailConfig.From == "" {
+		mailConfig.From = "no-reply@chat-zinx.local"
+	}
+}
+
+// GetMailConfig 获取邮件配置
+func GetMailConfig() *MailConfig {
+	if GlobalConfig == nil {
+		mailConfig := MailConfig{}
+		setDefaultMailConfig(&mailConfig)
+		return &mailConfig
+	}
+	mailConfig := GlobalConfig.Mail
+	return &mailConfig
+}
+
+// IsAdmin 判断用户名是否在管理员列表中
+func IsAdmin(username string) bool {
+	authConfig := GetAuthConfig()
+	if authConfig == nil {
+		return false
+	}
+	for _, admin := range authConfig.Admins {
+		if admin == username {
+			return true
+		}
+	}
+	return false
+}
+
+// 设置账号注销与数据导出配置默认值
+func setDefaultAccountConfig(accountConfig *AccountConfig) {
+	if accountConfig.DeletionGracePeriod == 0 {
+		accountConfig.DeletionGracePeriod = 604800 // 7天
+	}
+	if accountConfig.MessagePolicy == "" {
+		accountConfig.MessagePolicy = "anonymize"
+	}
+	if accountConfig.PurgeInterval == 0 {
+		accountConfig.PurgeInterval = 300 // 5分钟
+	}
+	if accountConfig.ExportDir == "" {
+		accountConfig.ExportDir = "./data/exports"
+	}
+	if accountConfig.ExportTTL == 0 {
+		accountConfig.ExportTTL = 86400 // 24小时
+	}
+	if accountConfig.GuestTTL == 0 {
+		accountConfig.GuestTTL = 86400 // 24小时
+	}
+	if accountConfig.MaxBotsPerUser == 0 {
+		accountConfig.MaxBotsPerUser = 5
+	}
+}
+
+// GetAccountConfig 获取账号配置
+func GetAccountConfig() *AccountConfig {
+	if GlobalConfig == nil {
+		accountConfig := AccountConfig{}
+		setDefaultAccountConfig(&accountConfig)
+		return &accountConfig
+	}
+	accountConfig := GlobalConfig.Account
+	return &accountConfig
+}
+
+// IsGuestGroup 判断访客是否可以加入该群组
+func IsGuestGroup(groupID uint) bool {
+	for _, id := range GetAccountConfig().GuestGroups {
+		if id == groupID {
+			return true
+		}
+	}
+	return false
+}
+
+// 设置机器人 webhook 配置默认值
+func setDefaultWebhookConfig(webhookConfig *WebhookConfig) {
+	if webhookConfig.Workers == 0 {
+		webhookConfig.Workers = 4
+	}
+	if webhookConfig.QueueSize == 0 {
+		webhookConfig.QueueSize = 256
+	}
+	if webhookConfig.Timeout == 0 {
+		webhookConfig.Timeout = 5000 // 5秒
+	}
+	if webhookConfig.MaxRetries == 0 {
+		webhookConfig.MaxRetries = 3
+	}
+	if webhookConfig.RetryBackoff == 0 {
+		webhookConfig.RetryBackoff = 500
+	}
+	if webhookConfig.MaxReplyLen == 0 {
+		webhookConfig.MaxReplyLen = 2000
+	}
+}
+
+// GetWebhookConfig 获取机器人 webhook 配置
+func GetWebhookConfig() *WebhookConfig {
+	if GlobalConfig == nil {
+		webhookConfig := WebhookConfig{}
+		setDefaultWebhookConfig(&webhookConfig)
+		return &webhookConfig
+	}
+	webhookConfig := GlobalConfig.Webhook
+	return &webhookConfig
+}
+
+// 设置 HTTP 服务配置默认值
+func setDefaultHTTPConfig(httpConfig *HTTPConfig) {
+	if httpConfig.Addr == "" {
+		httpConfig.Addr = ":8080"
+	}
+	if httpConfig.ReadTimeout == 0 {
+		httpConfig.ReadTimeout = 10
+	}
+	if httpConfig.WriteTimeout == 0 {
+		httpConfig.WriteTimeout = 10
+	}
+	if httpConfig.MaxBodySize == 0 {
+		httpConfig.MaxBodySize = 64 << 10 // 64KB
+	}
+}
+
+// GetHTTPConfig 获取 HTTP 服务配置
+func GetHTTPConfig() *HTTPConfig {
+	if GlobalConfig == nil {
+		httpConfig := HTTPConfig{}
+		setDefaultHTTPConfig(&httpConfig)
+		return &httpConfig
+	}
+	httpConfig := GlobalConfig.HTTP
+	return &httpConfig
+}
+
+// 设置 incoming webhook 配置默认值
+func setDefaultIncomingWebhookConfig(incomingConfig *IncomingWebhookConfig) {
+	if incomingConfig.MaxPerGroup == 0 {
+		incomingConfig.MaxPerGroup = 10
+	}
+	if incomingConfig.RateLimit == 0 {
+		incomingConfig.RateLimit = 30
+	}
+	if incomingConfig.RateWindow == 0 {
+		incomingConfig.RateWindow = 60 // 每分钟 30 条
+	}
+	if incomingConfig.MaxTextLen == 0 {
+		incomingConfig.MaxTextLen = 4000
+	}
+	if incomingConfig.MaxFields == 0 {
+		incomingConfig.MaxFields = 10
+	}
+}
+
+// GetIncomingWebhookConfig 获取 incoming webhook 配置
+func GetIncomingWebhookConfig() *IncomingWebhookConfig {
+	if GlobalConfig == nil {
+		incomingConfig := IncomingWebhookConfig{}
+		setDefaultIncomingWebhookConfig(&incomingConfig)
+		return &incomingConfig
+	}
+	incomingConfig := GlobalConfig.IncomingWebhook
+	return &incomingConfig
+}
+
+// 设置 TLS 配置默认值，tcpPort 为 zinx 的明文端口
+func setDefaultTLSConfig(tlsConfig *TLSConfig, tcpPort int) {
+	if tlsConfig.Addr == "" {
+		tlsConfig.Addr = ":9443"
+	}
+	if tlsConfig.Upstream == "" {
+		tlsConfig.Upstream = loopbackUpstream(tcpPort)
+	}
+	if tlsConfig.ClientAuth == "" {
+		tlsConfig.ClientAuth = "optional"
+	}
+	if tlsConfig.MinVersion == "" {
+		tlsConfig.MinVersion = "1.2"
+	}
+	if tlsConfig.ReloadInterval == 0 {
+		tlsConfig.ReloadInterval = 30
+	}
+	if tlsConfig.HandshakeTimeout == 0 {
+		tlsConfig.HandshakeTimeout = 10
+	}
+}
+
+// GetTLSConfig 获取 TLS 配置
+func GetTLSConfig() *TLSConfig {
+	if GlobalConfig == nil {
+		tlsConfig := TLSConfig{}
+		setDefaultTLSConfig(&tlsConfig, 0)
+		return &tlsConfig
+	}
+	tlsConfig := GlobalConfig.TLS
+	return &tlsConfig
+}
+
+// loopbackUpstream 网关转发的默认目标，即本机的 zinx 明文端口
+func loopbackUpstream(tcpPort int) string {
+	if tcpPort == 0 {
+		tcpPort = 9000
+	}
+	return "127.0.0.1:" + strconv.Itoa(tcpPort)
+}
+
+// 设置 WebSocket 网关配置默认值
+func setDefaultWebSocketConfig(wsConfig *WebSocketConfig, tcpPort int) {
+	if wsConfig.Path == "" {
+		wsConfig.Path = "/ws"
+	}
+	if wsConfig.Upstream == "" {
+		wsConfig.Upstream = loopbackUpstream(tcpPort)
+	}
+	if wsConfig.MaxMessageSize == 0 {
+		wsConfig.MaxMessageSize = 64 << 10 // 64KB
+	}
+	if wsConfig.PingInterval == 0 {
+		wsConfig.PingInterval = 30
+	}
+	if wsConfig.WriteTimeout == 0 {
+		wsConfig.WriteTimeout = 10
+	}
+}
+
+// GetWebSocketConfig 获取 WebSocket 网关配置
+func GetWebSocketConfig() *WebSocketConfig {
+	if GlobalConfig == nil {
+		wsConfig := WebSocketConfig{}
+		setDefaultWebSocketConfig(&wsConfig, 0)
+		return &wsConfig
+	}
+	wsConfig := GlobalConfig.WebSocket
+	wsConfig.AllowedOrigins = append([]string(nil), wsConfig.AllowedOrigins...)
+	return &wsConfig
+}
+
+// 设置会话恢复配置默认值
+func setDefaultSessionResumeConfig(resumeConfig *SessionResumeConfig) {
+	if resumeConfig.Window == 0 {
+		resumeConfig.Window = 60
+	}
+	if resumeConfig.BufferSize == 0 {
+		resumeConfig.BufferSize = 256
+	}
+}
+
+// GetSessionResumeConfig 获取会话恢复配置
+func GetSessionResumeConfig() *SessionResumeConfig {
+	if GlobalConfig == nil {
+		resumeConfig := SessionResumeConfig{}
+		setDefaultSessionResumeConfig(&resumeConfig)
+		return &resumeConfig
+	}
+	resumeConfig := GlobalConfig.SessionResume
+	return &resumeConfig
+}
+
+// setDefaultBackpressureConfig 设置推送背压配置默认值
+func setDefaultBackpressureConfig(bpConfig *BackpressureConfig) {
+	if bpConfig.QueueSize <= 0 {
+		bpConfig.QueueSize = 1024
+	}
+	if bpConfig.HighWater <= 0 || bpConfig.HighWater > bpConfig.QueueSize {
+		bpConfig.HighWater = bpConfig.QueueSize * 3 / 4
+	}
+	if bpConfig.SlowTimeout <= 0 {
+		bpConfig.SlowTimeout = 10
+	}
+	switch bpConfig.Policy {
+	case "drop", "disconnect":
+	case "":
+		bpConfig.Policy = "drop"
+	default:
+		fmt.Printf("警告: 未知的 Backpressure.Policy %q，使用 drop\n", bpConfig.Policy)
+		bpConfig.Policy = "drop"
+	}
+}
+
+// GetBackpressureConfig 获取推送背压配置
+func GetBackpressureConfig() *BackpressureConfig {
+	if GlobalConfig == nil {
+		bpConfig := BackpressureConfig{}
+		setDefaultBackpressureConfig(&bpConfig)
+		return &bpConfig
+	}
+	bpConfig := GlobalConfig.Backpressure
+	return &bpConfig
+}
+
+// setDefaultShutdownConfig 设置优雅关闭配置默认值
+func setDefaultShutdownConfig(shutdownConfig *ShutdownConfig) {
+	if shutdownConfig.Timeout == 0 {
+		shutdownConfig.Timeout = 30
+	}
+	if shutdownConfig.ReconnectWithin == 0 {
+		shutdownConfig.ReconnectWithin = 10
+	}
+}
+
+// GetShutdownConfig 获取优雅关闭配置
+func GetShutdownConfig() *ShutdownConfig {
+	if GlobalConfig == nil {
+		shutdownConfig := ShutdownConfig{}
+		setDefaultShutdownConfig(&shutdownConfig)
+		return &shutdownConfig
+	}
+	shutdownConfig := GlobalConfig.Shutdown
+	return &shutdownConfig
+}
+
+// setDefaultHandshakeConfig 设置握手配置默认值
+func setDefaultHandshakeConfig(handshakeConfig *HandshakeConfig) {
+	if handshakeConfig.MinProtocolVersion == 0 {
+		handshakeConfig.MinProtocolVersion = 1
+	}
+}
+
+// GetHandshakeConfig 获取握手配置
+func GetHandshakeConfig() *HandshakeConfig {
+	if GlobalConfig == nil {
+		handshakeConfig := HandshakeConfig{}
+		setDefaultHandshakeConfig(&handshakeConfig)
+		return &handshakeConfig
+	}
+	handshakeConfig := GlobalConfig.Handshake
+	return &handshakeConfig
+}
+
+// GetMaxPacketSize 获取单个消息包的最大长度（字节）
+func GetMaxPacketSize() int {
+	if GlobalConfig == nil || GlobalConfig.MaxPacketSize == 0 {
+		return 8192
+	}
+	return GlobalConfig.MaxPacketSize
+}
+
+// setDefaultCompressionConfig 设置消息压缩配置默认值
+func setDefaultCompressionConfig(compressionConfig *CompressionConfig) {
+	if len(compressionConfig.Algorithms) == 0 {
+		compressionConfig.Algorithms = []string{"zstd", "deflate"}
+	}
+	if compressionConfig.Threshold == 0 {
+		compressionConfig.Threshold = 1024
+	}
+}
+
+// GetCompressionConfig 获取消息压缩配置
+func GetCompressionConfig() *CompressionConfig {
+	if GlobalConfig == nil {
+		compressionConfig := CompressionConfig{}
+		setDefaultCompressionConfig(&compressionConfig)
+		return &compressionConfig
+	}
+	compressionConfig := GlobalConfig.Compression
+	return &compressionConfig
+}
@@ -0,0 +1,89 @@
+package mysql
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Xaytick/chat-zinx/chat-server/pkg/model"
+	"gorm.io/gorm"
+)
+
+// ErrInvalidToken 令牌不存在、已使用或已过期
+var ErrInvalidToken = errors.New("invalid or expired token")
+
+// CreateUserToken 创建一次性令牌，同一用户同一用途之前未使用的令牌一并作废
+func CreateUserToken(token *model.UserToken) error {
+	return DB.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		err := tx.Model(&model.UserToken{}).
+			Where("user_id = ? AND purpose = ? AND used_at IS NULL", token.UserID, token.Purpose).
+			Update("used_at", now).Error
+		if err != nil {
+			return fmt.Errorf("failed to revoke old tokens: %w", err)
+		}
+		token.CreatedAt = now
+		if err := tx.Create(token).Error; err != nil {
+			return fmt.Errorf("failed to create token: %w", err)
+		}
+		return nil
+	})
+}
+
+// ConsumeUserToken 标记令牌为已使用并返回令牌记录，令牌无效时返回 ErrInvalidToken
+// 通过条件更新保证同一令牌只能被使用一次
+func ConsumeUserToken(tokenHash, purpose string) (*model.UserToken, error) {
+	now := time.Now()
+	result := DB.Model(&model.UserToken{}).
+		Where("token_hash = ? AND purpose = ? AND used_at IS NULL AND expires_at > ?", tokenHash, purpose, now).
+		Update("used_at", now)
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to consume token: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return nil, ErrInvalidToken
+	}
+
+	var token model.UserToken
+	if err := DB.Where("token_hash = ?", tokenHash).First(&token).Error; err != nil {
+		return nil, fmt.Errorf("failed to get token: %w", err)
+	}
+	return &token, nil
+}
+
+// GetUserByEmail 根据邮箱查询用户信息，不存在时返回 ErrRecordNotFound
+func GetUserByEmail(email string) (*model.User, error) {
+	var user model.User
+	result := DB.Where("email = ?", email).First(&user)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, ErrRecordNotFound
+		}
+		return nil, result.Error
+	}
+	return &user, nil
+}
+
+// UpdateUserPassword 更新密码哈希并递增 Token 版本，使之前签发的 Token 全部失效
+func UpdateUserPassword(userID uint, hashedPassword string) error {
+	updates := map[string]interface{}{
+		"password":      hashedPassword,
+		"token_version": gorm.Expr("token_version + 1"),
+		"updated_at":    time.Now(),
+	}
+	return DB.Model(&model.User{}).Where("id = ?", userID).Updates(updates).Error
+}
+
+// MarkUserEmailVerified 将邮箱标记为已验证，仅当用户当前邮箱与令牌中的邮箱一致时生效
+func MarkUserEmailVerified(userID uint, email string) error {
+	result := DB.Model(&model.User{}).
+		Where("id = ? AND email = ?", userID, email).
+		Updates(map[string]interface{}{"email_verified": true, "updated_at": time.Now()})
+	if result.Error != nil {
+		return fmt.Errorf("failed to verify email: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("email has changed since the verification mail was sent")
+	}
+	return nil
+}
@@ -0,0 +1,267 @@
+package mysql
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Xaytick/chat-zinx/chat-server/pkg/model"
+	"gorm.io/gorm"
+)
+
+// ScheduleUserDeletion 设置账号的清除时间，已处于冷静期时不修改，返回实际生效的清除时间
+func ScheduleUserDeletion(userID uint, dueAt time.Time) (time.Time, error) {
+	result := DB.Model(&model.User{}).
+		Where("id = ? AND deletion_due_at IS NULL", userID).
+		Update("deletion_due_at", dueAt)
+	if result.Error != nil {
+		return time.Time{}, fmt.Errorf("failed to schedule user deletion: %w", result.Error)
+	}
+	if result.RowsAffected > 0 {
+		return dueAt, nil
+	}
+
+	user, err := GetUserByID(userID)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if user.DeletionDueAt == nil {
+		return time.Time{}, errors.New("failed to schedule user deletion")
+	}
+	return *user.DeletionDueAt, nil
+}
+
+// CancelUserDeletion 撤销注销申请，返回此前是否处于冷静期
+func CancelUserDeletion(userID uint) (bool, error) {
+	result := DB.Model(&model.User{}).
+		Where("id = ? AND deletion_due_at IS NOT NULL", userID).
+		Update("deletion_due_at", nil)
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to cancel user deletion: %w", result.Error)
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// GetUsersDueForDeletion 获取冷静期已结束的用户，按清除时间排序
+func GetUsersDueForDeletion(now time.Time, limit int) ([]*model.User, error) {
+	var users []*model.User
+	err := DB.Where("deletion_due_at IS NOT NULL AND deletion_due_at <= ?", now).
+		Order("deletion_due_at ASC").
+		Limit(limit).
+		Find(&users).Error
+	return users, err
+}
+
+// GetUserGroupMemberships 获取用户在所有群组中的成员记录
+func GetUserGroupMemberships(userID uint) ([]*model.GroupMember, error) {
+	var members []*model.GroupMember
+	err := DB.Where("user_id = ?", userID).Find(&members).Error
+	return members, err
+}
+
+// GetGroupSuccessor 选出群主离开后的新群主：优先最早加入的管理员，其次最早加入的成员
+// 群内没有其他成员时返回 nil, nil
+func GetGroupSuccessor(groupID, ownerID uint) (*model.GroupMember, error) {
+	var member model.GroupMember
+	result := DB.Where("group_id = ? AND user_id <> ?", groupID, ownerID).
+		Order(gorm.Expr("CASE WHEN role = ? THEN 0 ELSE 1 END", model.GroupRoleAdmin)).
+		Order("joined_at ASC, id ASC").
+		First(&member)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, result.Error
+	}
+	return &member, nil
+}
+
+// TransferGroupOwnership 将群主转让给 newOwnerID，原群主降为普通成员
+func TransferGroupOwnership(groupID, oldOwnerID, newOwnerID uint) error {
+	return DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&model.Group{}).
+			Where("id = ? AND owner_user_id = ?", groupID, oldOwnerID).
+			Update("owner_user_id", newOwnerID)
+		if result.Error != nil {
+			return fmt.Errorf("failed to update group owner: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return errors.New("group not found or owner changed")
+		}
+
+		now := time.Now()
+		if err := tx.Model(&model.GroupMember{}).
+			Where("group_id = ? AND user_id = ?", groupID, newOwnerID).
+			Updates(map[string]interface{}{"role": model.GroupRoleOwner, "updated_at": now}).Error; err != nil {
+			return fmt.Errorf("failed to promote new owner: %w", err)
+		}
+		if err := tx.Model(&model.GroupMember{}).
+			Where("group_id = ? AND user_id = ?", groupID, oldOwnerID).
+			Updates(map[string]interface{}{"role": model.GroupRoleMember, "updated_at": now}).Error; err != nil {
+			return fmt.Errorf("failed to demote old owner: %w", err)
+		}
+		return nil
+	})
+}
+
+// DeleteGroup 解散群组，删除群组及其成员、消息、公告、置顶、封禁、角色、标签和 webhook
+func DeleteGroup(groupID uint) error {
+	return DB.Transaction(func(tx *gorm.DB) error {
+		related := []interface{}{
+			&model.GroupMember{}, &model.GroupMessage{},
+			&model.GroupAnnouncement{}, &model.GroupAnnouncementAck{}, &model.GroupPinnedMessage{},
+			&model.GroupBan{}, &model.GroupRole{}, &model.GroupTag{}, &model.BotWebhook{},
+			&model.IncomingWebhook{},
+		}
+		for _, table := range related {
+			if err := tx.Where("group_id = ?", groupID).Delete(table).Error; err != nil {
+				return fmt.Errorf("failed to delete group data: %w", err)
+			}
+		}
+		if err := tx.Delete(&model.Group{}, groupID).Error; err != nil {
+			return fmt.Errorf("failed to delete group: %w", err)
+		}
+		return nil
+	})
+}
+
+// AnonymizeUserGroupMessages 将用户发送的群消息和编辑的群公告的发送者替换为 model.DeletedUserName
+func AnonymizeUserGroupMessages(userID uint) error {
+	return DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&model.GroupMessage{}).Where("sender_id = ?", userID).
+			Updates(map[string]interface{}{"sender_id": 0, "sender_uuid": "", "sender_name": model.DeletedUserName}).Error; err != nil {
+			return fmt.Errorf("failed to anonymize group messages: %w", err)
+		}
+		if err := tx.Model(&model.GroupAnnouncement{}).Where("editor_user_id = ?", userID).
+			Updates(map[string]interface{}{"editor_user_id": 0, "editor_name": model.DeletedUserName}).Error; err != nil {
+			return fmt.Errorf("failed to anonymize group announcements: %w", err)
+		}
+		return nil
+	})
+}
+
+// DeleteUserGroupMessages 删除用户发送的所有群消息及其置顶记录，群公告只做匿名化以保留版本历史
+func DeleteUserGroupMessages(userID uint) error {
+	return DB.Transaction(func(tx *gorm.DB) error {
+		msgIDs := tx.Model(&model.GroupMessage{}).Select("id").Where("sender_id = ?", userID)
+		if err := tx.Where("group_message_id IN (?)", msgIDs).Delete(&model.GroupPinnedMessage{}).Error; err != nil {
+			return fmt.Errorf("failed to delete pinned messages: %w", err)
+		}
+		if err := tx.Where("sender_id = ?", userID).Delete(&model.GroupMessage{}).Error; err != nil {
+			return fmt.Errorf("failed to delete group messages: %w", err)
+		}
+		if err := tx.Model(&model.GroupAnnouncement{}).Where("editor_user_id = ?", userID).
+			Updates(map[string]interface{}{"editor_user_id": 0, "editor_name": model.DeletedUserName}).Error; err != nil {
+			return fmt.Errorf("failed to anonymize group announcements: %w", err)
+		}
+		return nil
+	})
+}
+
+// GetBlockerIDs 获取屏蔽了该用户的用户ID
+func GetBlockerIDs(userID uint) ([]uint, error) {
+	var ids []uint
+	err := DB.Model(&model.UserBlock{}).Where("blocked_user_id = ?", userID).Pluck("user_id", &ids).Error
+	return ids, err
+}
+
+// DeleteUserAccount 删除用户记录及其好友关系、好友申请、屏蔽、令牌、恢复码、登录记录、群封禁、公告确认记录以及机器人的 API Key 和 webhook
+// 调用前需先让用户退出所有群组
+func DeleteUserAccount(user *model.User) error {
+	return DB.Transaction(func(tx *gorm.DB) error {
+		deletes := []struct {
+			table interface{}
+			query string
+			args  []interface{}
+		}{
+			{&model.Friend{}, "user_id = ? OR friend_id = ?", []interface{}{user.ID, user.ID}},
+			{&model.FriendRequest{}, "from_user_id = ? OR to_user_id = ?", []interface{}{user.ID, user.ID}},
+			{&model.UserBlock{}, "user_id = ? OR blocked_user_id = ?", []interface{}{user.ID, user.ID}},
+			{&model.UserToken{}, "user_id = ?", []interface{}{user.ID}},
+			{&model.UserBackupCode{}, "user_id = ?", []interface{}{user.ID}},
+			{&model.LoginRecord{}, "user_id = ? OR (user_id = 0 AND username = ?)", []interface{}{user.ID, user.Username}},
+			{&model.GroupBan{}, "user_id = ?", []interface{}{user.ID}},
+			{&model.GroupAnnouncementAck{}, "user_id = ?", []interface{}{user.ID}},
+			{&model.BotAPIKey{}, "bot_user_id = ?", []interface{}{user.ID}},
+			{&model.BotWebhook{}, "bot_user_id = ?", []interface{}{user.ID}},
+		}
+		for _, d := range deletes {
+			if err := tx.Where(d.query, d.args...).Delete(d.table).Error; err != nil {
+				return fmt.Errorf("failed to delete user data: %w", err)
+			}
+		}
+		if err := tx.Delete(&model.User{}, user.ID).Error; err != nil {
+			return fmt.Errorf("failed to delete user: %w", err)
+		}
+		return nil
+	})
+}
+
+// CreateDataExport 创建导出记录
+func CreateDataExport(export *model.DataExport) error {
+	if err := DB.Create(export).Error; err != nil {
+		return fmt.Errorf("failed to create data export: %w", err)
+	}
+	return nil
+}
+
+// UpdateDataExport 更新导出记录的状态、文件和大小
+func UpdateDataExport(export *model.DataExport) error {
+	err := DB.Model(export).Updates(map[string]interface{}{
+		"status":    export.Status,
+		"file_name": export.FileName,
+		"size":      export.Size,
+		"error":     export.Error,
+	}).Error
+	if err != nil {
+		return fmt.Errorf("failed to update data export: %w", err)
+	}
+	return nil
+}
+
+// GetDataExport 获取用户的导出记录，不存在或不属于该用户时返回 nil, nil
+func GetDataExport(userID, exportID uint) (*model.DataExport, error) {
+	var export model.DataExport
+	result := DB.Where("id = ? AND user_id = ?", exportID, userID).First(&export)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, result.Error
+	}
+	return &export, nil
+}
+
+// HasPendingDataExport 检查用户是否有正在生成的导出
+func HasPendingDataExport(userID uint) (bool, error) {
+	var count int64
+	err := DB.Model(&model.DataExport{}).
+		Where("user_id = ? AND status = ? AND expires_at > ?", userID, model.DataExportPending, time.Now()).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// GetExpiredDataExports 获取已过期的导出记录
+func GetExpiredDataExports(now time.Time) ([]*model.DataExport, error) {
+	var exports []*model.DataExport
+	err := DB.Where("expires_at <= ?", now).Find(&exports).Error
+	return exports, err
+}
+
+// GetUserDataExports 获取用户的所有导出记录
+func GetUserDataExports(userID uint) ([]*model.DataExport, error) {
+	var exports []*model.DataExport
+	err := DB.Where("user_id = ?", userID).Find(&exports).Error
+	return exports, err
+}
+
+// DeleteDataExports 删除导出记录
+func DeleteDataExports(ids []uint) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	if err := DB.Delete(&model.DataExport{}, ids).Error; err != nil {
+		return fmt.Errorf("failed to delete data exports: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,130 @@
+package mysql
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Xaytick/chat-zinx/chat-server/pkg/model"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// unusablePassword 访客和机器人账号的密码字段，不是合法的 bcrypt 哈希，任何密码都无法通过校验
+const unusablePassword = "!"
+
+// createRestrictedUser 在事务中创建不能用密码登录的账号，并关闭用户搜索
+// Searchable 带有 default:true，创建时零值会被忽略，因此单独更新
+func createRestrictedUser(tx *gorm.DB, user *model.User) error {
+	user.Password = unusablePassword
+	user.UserUUID = uuid.NewString()
+	user.LastLogin = time.Now()
+	user.Searchable = false
+	if err := tx.Create(user).Error; err != nil {
+		return fmt.Errorf("failed to create %s account: %w", user.AccountKind, err)
+	}
+	if err := tx.Model(user).Update("searchable", false).Error; err != nil {
+		return fmt.Errorf("failed to hide %s account from search: %w", user.AccountKind, err)
+	}
+	return nil
+}
+
+// CreateGuestUser 创建访客账号，调用方需设置用户名、邮箱占位和到期时间
+func CreateGuestUser(user *model.User) error {
+	user.AccountKind = model.AccountKindGuest
+	return DB.Transaction(func(tx *gorm.DB) error {
+		return createRestrictedUser(tx, user)
+	})
+}
+
+// CreateBotUser 创建机器人账号及其第一个 API Key
+func CreateBotUser(bot *model.User, key *model.BotAPIKey) error {
+	bot.AccountKind = model.AccountKindBot
+	return DB.Transaction(func(tx *gorm.DB) error {
+		if err := createRestrictedUser(tx, bot); err != nil {
+			return err
+		}
+		key.BotUserID = bot.ID
+		if err := tx.Create(key).Error; err != nil {
+			return fmt.Errorf("failed to create bot api key: %w", err)
+		}
+		return nil
+	})
+}
+
+// CountBotsByOwner 统计用户创建的机器人数量
+func CountBotsByOwner(ownerID uint) (int64, error) {
+	var count int64
+	err := DB.Model(&model.User{}).
+		Where("account_kind = ? AND bot_owner_id = ?", model.AccountKindBot, ownerID).
+		Count(&count).Error
+	return count, err
+}
+
+// GetBotsByOwner 获取用户创建的机器人，按创建时间排序
+func GetBotsByOwner(ownerID uint) ([]*model.User, error) {
+	var bots []*model.User
+	err := DB.Where("account_kind = ? AND bot_owner_id = ?", model.AccountKindBot, ownerID).
+		Order("id ASC").
+		Find(&bots).Error
+	return bots, err
+}
+
+// GetActiveBotAPIKeys 获取机器人当前有效的 API Key
+func GetActiveBotAPIKeys(botUserIDs []uint) ([]*model.BotAPIKey, error) {
+	var keys []*model.BotAPIKey
+	if len(botUserIDs) == 0 {
+		return keys, nil
+	}
+	err := DB.Where("bot_user_id IN ? AND revoked_at IS NULL", botUserIDs).Find(&keys).Error
+	return keys, err
+}
+
+// GetBotAPIKeyByHash 根据摘要查找有效的 API Key，不存在或已吊销时返回 nil, nil
+func GetBotAPIKeyByHash(keyHash string) (*model.BotAPIKey, error) {
+	var key model.BotAPIKey
+	result := DB.Where("key_hash = ? AND revoked_at IS NULL", keyHash).First(&key)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, result.Error
+	}
+	return &key, nil
+}
+
+// RotateBotAPIKey 吊销机器人现有的 API Key 并保存新的 Key，同时递增 Token 版本使旧 Key 换取的 Token 失效
+func RotateBotAPIKey(botUserID uint, key *model.BotAPIKey) error {
+	return DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&model.BotAPIKey{}).
+			Where("bot_user_id = ? AND revoked_at IS NULL", botUserID).
+			Update("revoked_at", time.Now()).Error; err != nil {
+			return fmt.Errorf("failed to revoke bot api keys: %w", err)
+		}
+		if err := tx.Model(&model.User{}).Where("id = ?", botUserID).
+			Update("token_version", gorm.Expr("token_version + 1")).Error; err != nil {
+			return fmt.Errorf("failed to bump bot token version: %w", err)
+		}
+		key.BotUserID = botUserID
+		if err := tx.Create(key).Error; err != nil {
+			return fmt.Errorf("failed to create bot api key: %w", err)
+		}
+		return nil
+	})
+}
+
+// TouchBotAPIKey 记录 API Key 的最近使用时间
+func TouchBotAPIKey(keyID uint) error {
+	return DB.Model(&model.BotAPIKey{}).Where("id = ?", keyID).Update("last_used_at", time.Now()).Error
+}
+
+// ScheduleOwnedBotsDeletion 用户被清除时，将其创建的机器人一并加入清除队列
+func ScheduleOwnedBotsDeletion(ownerID uint, dueAt time.Time) error {
+	err := DB.Model(&model.User{}).
+		Where("account_kind = ? AND bot_owner_id = ? AND deletion_due_at IS NULL", model.AccountKindBot, ownerID).
+		Update("deletion_due_at", dueAt).Error
+	if err != nil {
+		return fmt.Errorf("failed to schedule bot deletion: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,59 @@
+package mysql
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Xaytick/chat-zinx/chat-server/pkg/model"
+	"gorm.io/gorm/clause"
+)
+
+// CreateUserBlock 创建屏蔽记录，已屏蔽时不做任何修改
+func CreateUserBlock(userID, blockedUserID uint) error {
+	block := &model.UserBlock{UserID: userID, BlockedUserID: blockedUserID, CreatedAt: time.Now()}
+	if err := DB.Clauses(clause.OnConflict{DoNothing: true}).Create(block).Error; err != nil {
+		return fmt.Errorf("failed to block user: %w", err)
+	}
+	return nil
+}
+
+// DeleteUserBlock 删除屏蔽记录
+func DeleteUserBlock(userID, blockedUserID uint) error {
+	result := DB.Where("user_id = ? AND blocked_user_id = ?", userID, blockedUserID).Delete(&model.UserBlock{})
+	if result.Error != nil {
+		return fmt.Errorf("failed to unblock user: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("user is not blocked")
+	}
+	return nil
+}
+
+// GetUserBlocks 获取用户的屏蔽记录，按屏蔽时间从新到旧排列
+func GetUserBlocks(userID uint) ([]*model.UserBlock, error) {
+	var blocks []*model.UserBlock
+	if err := DB.Where("user_id = ?", userID).Order("id DESC").Find(&blocks).Error; err != nil {
+		return nil, fmt.Errorf("failed to get blocked users: %w", err)
+	}
+	return blocks, nil
+}
+
+// GetBlockedUserIDs 获取用户屏蔽的全部用户ID
+func GetBlockedUserIDs(userID uint) ([]uint, error) {
+	var ids []uint
+	err := DB.Model(&model.UserBlock{}).Where("user_id = ?", userID).Pluck("blocked_user_id", &ids).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get blocked user ids: %w", err)
+	}
+	return ids, nil
+}
+
+// UpdateUserDMPrivacy 更新用户的私聊隐私设置
+func UpdateUserDMPrivacy(userID uint, privacy string) error {
+	updates := map[string]interface{}{
+		"dm_privacy": privacy,
+		"updated_at": time.Now(),
+	}
+	return DB.Model(&model.User{}).Where("id = ?", userID).Updates(updates).Error
+}
@@ -0,0 +1,74 @@
+package mysql
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/Xaytick/chat-zinx/chat-server/pkg/model"
+	"gorm.io/gorm"
+)
+
+// GetBotWebhook 获取机器人在群里的 webhook，不存在时返回 nil, nil
+func GetBotWebhook(botUserID, groupID uint) (*model.BotWebhook, error) {
+	var hook model.BotWebhook
+	result := DB.Where("bot_user_id = ? AND group_id = ?", botUserID, groupID).First(&hook)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, result.Error
+	}
+	return &hook, nil
+}
+
+// SaveBotWebhook 创建或更新 webhook
+func SaveBotWebhook(hook *model.BotWebhook) error {
+	if err := DB.Save(hook).Error; err != nil {
+		return fmt.Errorf("failed to save bot webhook: %w", err)
+	}
+	return nil
+}
+
+// DeleteBotWebhook 删除机器人在群里的 webhook，返回是否存在
+func DeleteBotWebhook(botUserID, groupID uint) (bool, error) {
+	result := DB.Where("bot_user_id = ? AND group_id = ?", botUserID, groupID).Delete(&model.BotWebhook{})
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to delete bot webhook: %w", result.Error)
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// GetBotWebhooksByBot 获取机器人的所有 webhook
+func GetBotWebhooksByBot(botUserID uint) ([]*model.BotWebhook, error) {
+	var hooks []*model.BotWebhook
+	err := DB.Where("bot_user_id = ?", botUserID).Order("group_id ASC").Find(&hooks).Error
+	return hooks, err
+}
+
+// GetGroupWebhooks 获取群里所有机器人的 webhook，并填充机器人用户名
+func GetGroupWebhooks(groupID uint) ([]*model.BotWebhook, error) {
+	var hooks []*model.BotWebhook
+	if err := DB.Where("group_id = ?", groupID).Find(&hooks).Error; err != nil {
+		return nil, err
+	}
+	if len(hooks) == 0 {
+		return hooks, nil
+	}
+
+	botIDs := make([]uint, 0, len(hooks))
+	for _, hook := range hooks {
+		botIDs = append(botIDs, hook.BotUserID)
+	}
+	bots, err := GetUsersByIDs(botIDs)
+	if err != nil {
+		return nil, err
+	}
+	usernames := make(map[uint]string, len(bots))
+	for _, bot := range bots {
+		usernames[bot.ID] = bot.Username
+	}
+	for _, hook := range hooks {
+		hook.BotUsername = usernames[hook.BotUserID]
+	}
+	return hooks, nil
+}
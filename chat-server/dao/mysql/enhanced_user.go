@@ -132,10 +132,11 @@ func (dao *EnhancedUserDAO) BatchCreateUsers(ctx context.Context, users []*model
 	})
 }
 
-// SearchUsers 搜索用户（跨分片查询）
+// SearchUsers 搜索用户（跨分片查询），只匹配允许被搜索的用户的用户名和昵称
 func (dao *EnhancedUserDAO) SearchUsers(ctx context.Context, keyword string, limit int) ([]*model.User, error) {
 	results, err := dao.repo.CrossShardQuery(ctx, "users", func(db *gorm.DB) *gorm.DB {
-		return db.Where("username LIKE ? OR email LIKE ?", "%"+keyword+"%", "%"+keyword+"%").Limit(limit)
+		like := containsPattern(keyword)
+		return db.Where("searchable = ? AND (username LIKE ? OR nickname LIKE ?)", true, like, like).Limit(limit)
 	})
 
 	if err != nil {
@@ -152,8 +153,8 @@ func (dao *EnhancedUserDAO) SearchUsers(ctx context.Context, keyword string, lim
 		if username, ok := result["username"].(string); ok {
 			user.Username = username
 		}
-		if email, ok := result["email"].(string); ok {
-			user.Email = email
+		if nickname, ok := result["nickname"].(string); ok {
+			user.Nickname = nickname
 		}
 		// ... 映射其他字段
 		users = append(users, user)
@@ -0,0 +1,216 @@
+package mysql
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Xaytick/chat-zinx/chat-server/pkg/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	// ErrFriendRequestHandled 好友申请已被处理
+	ErrFriendRequestHandled = errors.New("friend request already handled")
+	// ErrFriendLimitReached 处理申请的用户好友数已达上限
+	ErrFriendLimitReached = errors.New("friend limit reached")
+	// ErrRequesterFriendLimitReached 申请方的好友数已达上限
+	ErrRequesterFriendLimitReached = errors.New("requester friend limit reached")
+)
+
+// CreateFriendRequest 创建好友申请 (GORM实现)
+func CreateFriendRequest(req *model.FriendRequest) error {
+	req.Status = model.FriendRequestPending
+	req.CreatedAt = time.Now()
+	req.UpdatedAt = time.Now()
+	if err := DB.Create(req).Error; err != nil {
+		return fmt.Errorf("failed to create friend request: %w", err)
+	}
+	return nil
+}
+
+// GetFriendRequestByID 根据ID获取好友申请，不存在时返回 nil, nil
+func GetFriendRequestByID(requestID uint) (*model.FriendRequest, error) {
+	var req model.FriendRequest
+	result := DB.First(&req, requestID)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, result.Error
+	}
+	return &req, nil
+}
+
+// GetPendingFriendRequest 获取 fromUserID 发给 toUserID 的待处理申请，不存在时返回 nil, nil
+func GetPendingFriendRequest(fromUserID, toUserID uint) (*model.FriendRequest, error) {
+	var req model.FriendRequest
+	result := DB.Where("from_user_id = ? AND to_user_id = ? AND status = ?", fromUserID, toUserID, model.FriendRequestPending).
+		First(&req)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, result.Error
+	}
+	return &req, nil
+}
+
+// GetPendingFriendRequests 获取用户收到和发出的待处理好友申请，按时间从新到旧排列
+func GetPendingFriendRequests(userID uint) (incoming, outgoing []*model.FriendRequest, err error) {
+	err = DB.Where("to_user_id = ? AND status = ?", userID, model.FriendRequestPending).
+		Order("id DESC").Find(&incoming).Error
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get incoming friend requests: %w", err)
+	}
+	err = DB.Where("from_user_id = ? AND status = ?", userID, model.FriendRequestPending).
+		Order("id DESC").Find(&outgoing).Error
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get outgoing friend requests: %w", err)
+	}
+	return incoming, outgoing, nil
+}
+
+// AcceptFriendRequest 接受好友申请并建立双向好友关系，对方发来的反向申请一并标记为已接受
+// maxFriends > 0 时在事务内检查双方的好友数上限
+func AcceptFriendRequest(req *model.FriendRequest, maxFriends int) error {
+	return DB.Transaction(func(tx *gorm.DB) error {
+		// 0. 按ID顺序锁定双方的用户记录，同一用户的并发接受串行执行，计数后再插入不会超过上限
+		if maxFriends > 0 {
+			var locked []model.User
+			err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").
+				Where("id IN ?", []uint{req.FromUserID, req.ToUserID}).Order("id ASC").Find(&locked).Error
+			if err != nil {
+				return fmt.Errorf("failed to lock users: %w", err)
+			}
+			if err := checkFriendCountInTx(tx, req.ToUserID, maxFriends, ErrFriendLimitReached); err != nil {
+				return err
+			}
+			if err := checkFriendCountInTx(tx, req.FromUserID, maxFriends, ErrRequesterFriendLimitReached); err != nil {
+				return err
+			}
+		}
+
+		// 1. 条件更新申请状态，防止重复处理
+		result := tx.Model(&model.FriendRequest{}).
+			Where("id = ? AND status = ?", req.ID, model.FriendRequestPending).
+			Updates(map[string]interface{}{"status": model.FriendRequestAccepted, "updated_at": time.Now()})
+		if result.Error != nil {
+			return fmt.Errorf("failed to update friend request: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return ErrFriendRequestHandled
+		}
+		err := tx.Model(&model.FriendRequest{}).
+			Where("from_user_id = ? AND to_user_id = ? AND status = ?", req.ToUserID, req.FromUserID, model.FriendRequestPending).
+			Updates(map[string]interface{}{"status": model.FriendRequestAccepted, "updated_at": time.Now()}).Error
+		if err != nil {
+			return fmt.Errorf("failed to update reverse friend request: %w", err)
+		}
+
+		// 2. 建立双向好友关系，已存在时忽略
+		now := time.Now()
+		friends := []*model.Friend{
+			{UserID: req.FromUserID, FriendID: req.ToUserID, CreatedAt: now, UpdatedAt: now},
+			{UserID: req.ToUserID, FriendID: req.FromUserID, CreatedAt: now, UpdatedAt: now},
+		}
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&friends).Error; err != nil {
+			return fmt.Errorf("failed to create friendship: %w", err)
+		}
+		return nil
+	})
+}
+
+// checkFriendCountInTx 在事务内检查用户的好友数，达到上限时返回 limitErr
+func checkFriendCountInTx(tx *gorm.DB, userID uint, maxFriends int, limitErr error) error {
+	var count int64
+	if err := tx.Model(&model.Friend{}).Where("user_id = ?", userID).Count(&count).Error; err != nil {
+		return fmt.Errorf("failed to count friends: %w", err)
+	}
+	if count >= int64(maxFriends) {
+		return limitErr
+	}
+	return nil
+}
+
+// RejectFriendRequest 拒绝好友申请
+func RejectFriendRequest(requestID uint) error {
+	result := DB.Model(&model.FriendRequest{}).
+		Where("id = ? AND status = ?", requestID, model.FriendRequestPending).
+		Updates(map[string]interface{}{"status": model.FriendRequestRejected, "updated_at": time.Now()})
+	if result.Error != nil {
+		return fmt.Errorf("failed to reject friend request: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrFriendRequestHandled
+	}
+	return nil
+}
+
+// GetFriend 获取用户对某个好友的关系记录，不是好友时返回 nil, nil
+func GetFriend(userID, friendID uint) (*model.Friend, error) {
+	var friend model.Friend
+	result := DB.Where("user_id = ? AND friend_id = ?", userID, friendID).First(&friend)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, result.Error
+	}
+	return &friend, nil
+}
+
+// GetFriends 获取用户的所有好友关系，按成为好友的时间排列
+func GetFriends(userID uint) ([]*model.Friend, error) {
+	var friends []*model.Friend
+	if err := DB.Where("user_id = ?", userID).Order("id ASC").Find(&friends).Error; err != nil {
+		return nil, fmt.Errorf("failed to get friends: %w", err)
+	}
+	return friends, nil
+}
+
+// GetFriendIDs 获取用户全部好友的用户ID
+func GetFriendIDs(userID uint) ([]uint, error) {
+	var ids []uint
+	if err := DB.Model(&model.Friend{}).Where("user_id = ?", userID).Pluck("friend_id", &ids).Error; err != nil {
+		return nil, fmt.Errorf("failed to get friend ids: %w", err)
+	}
+	return ids, nil
+}
+
+// CountFriends 统计用户的好友数量
+func CountFriends(userID uint) (int64, error) {
+	var count int64
+	if err := DB.Model(&model.Friend{}).Where("user_id = ?", userID).Count(&count).Error; err != nil {
+		return 0, fmt.Errorf("failed to count friends: %w", err)
+	}
+	return count, nil
+}
+
+// RemoveFriend 删除双向好友关系
+func RemoveFriend(userID, friendID uint) error {
+	result := DB.Where("(user_id = ? AND friend_id = ?) OR (user_id = ? AND friend_id = ?)",
+		userID, friendID, friendID, userID).Delete(&model.Friend{})
+	if result.Error != nil {
+		return fmt.Errorf("failed to remove friend: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("friend not found")
+	}
+	return nil
+}
+
+// UpdateFriendRemark 更新用户对好友的备注
+func UpdateFriendRemark(userID, friendID uint, remark string) error {
+	result := DB.Model(&model.Friend{}).
+		Where("user_id = ? AND friend_id = ?", userID, friendID).
+		Updates(map[string]interface{}{"remark": remark, "updated_at": time.Now()})
+	if result.Error != nil {
+		return fmt.Errorf("failed to update friend remark: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("friend not found")
+	}
+	return nil
+}
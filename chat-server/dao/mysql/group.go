@@ -9,8 +9,11 @@ import (
 	"gorm.io/gorm"
 )
 
-// CreateGroup 在数据库中创建群组记录，并自动添加群主为成员 (GORM实现)
-func CreateGroup(group *model.Group, ownerAsMember *model.GroupMember) error {
+// ErrGroupMemberLimit 群成员数量已达上限
+var ErrGroupMemberLimit = errors.New("group member limit reached")
+
+// CreateGroup 在数据库中创建群组记录，并自动添加群主为成员及群组标签 (GORM实现)
+func CreateGroup(group *model.Group, ownerAsMember *model.GroupMember, tags []string) error {
 	return DB.Transaction(func(tx *gorm.DB) error {
 		// 1. 设置群组创建时间并创建群组
 		group.CreatedAt = time.Now()
@@ -31,7 +34,8 @@ func CreateGroup(group *model.Group, ownerAsMember *model.GroupMember) error {
 			return fmt.Errorf("failed to add owner as group member in transaction: %w", err)
 		}
 
-		return nil
+		// 3. 创建群组标签
+		return createGroupTags(tx, group.ID, tags)
 	})
 }
 
@@ -48,9 +52,26 @@ func GetGroupByID(groupID uint) (*model.Group, error) {
 	return &group, nil
 }
 
-// AddGroupMember向群组中添加成员 (GORM实现)
-func AddGroupMember(member *model.GroupMember) error {
+// AddGroupMember向群组中添加成员，maxMembers > 0 时限制群成员上限，被封禁的用户返回 ErrGroupMemberBanned (GORM实现)
+func AddGroupMember(member *model.GroupMember, maxMembers int) error {
 	return DB.Transaction(func(tx *gorm.DB) error {
+		// 先更新群组成员数量，条件更新保证并发加群时不会超过上限
+		query := tx.Model(&model.Group{}).Where("id = ?", member.GroupID)
+		if maxMembers > 0 {
+			query = query.Where("member_count < ?", maxMembers)
+		}
+		result := query.UpdateColumn("member_count", gorm.Expr("member_count + ?", 1))
+		if result.Error != nil {
+			return fmt.Errorf("failed to update group member_count in transaction: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return ErrGroupMemberLimit
+		}
+		// 群组记录已被上面的更新锁定，封禁检查与 BanGroupMember 互斥
+		if err := checkGroupBanInTx(tx, member.GroupID, member.UserID); err != nil {
+			return err
+		}
+
 		member.JoinedAt = time.Now()
 		member.CreatedAt = time.Now()
 		member.UpdatedAt = time.Now()
@@ -58,11 +79,6 @@ func AddGroupMember(member *model.GroupMember) error {
 			// 可能是 uniqueIndex(idx_group_user) 冲突，即用户已在群组中
 			return fmt.Errorf("failed to add group member in transaction: %w", err)
 		}
-
-		// 更新群组成员数量
-		if err := tx.Model(&model.Group{}).Where("id = ?", member.GroupID).UpdateColumn("member_count", gorm.Expr("member_count + ?", 1)).Error; err != nil {
-			return fmt.Errorf("failed to update group member_count in transaction: %w", err)
-		}
 		return nil
 	})
 }
@@ -119,6 +135,41 @@ func GetGroupMembers(groupID uint) ([]*model.GroupMember, error) {
 	return members, nil
 }
 
+// GetGroupMembersPage 分页获取群组成员（包含用户信息），keyword 不为空时按用户名模糊搜索
+// 返回当前页成员和符合条件的成员总数
+func GetGroupMembersPage(groupID uint, keyword string, offset, limit int) ([]*model.GroupMemberInfo, int64, error) {
+	baseQuery := func() *gorm.DB {
+		db := DB.Table("group_members").
+			Joins("JOIN users ON users.id = group_members.user_id").
+			Where("group_members.group_id = ?", groupID)
+		if keyword != "" {
+			db = db.Where("users.username LIKE ?", containsPattern(keyword))
+		}
+		return db
+	}
+
+	var total int64
+	if err := baseQuery().Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count group members: %w", err)
+	}
+	if total == 0 || offset >= int(total) {
+		return []*model.GroupMemberInfo{}, total, nil
+	}
+
+	var members []*model.GroupMemberInfo
+	err := baseQuery().
+		Select("group_members.id AS member_id, group_members.user_id, users.user_uuid, users.username, " +
+			"group_members.role, group_members.joined_at, users.is_online").
+		Order("group_members.id ASC").
+		Offset(offset).
+		Limit(limit).
+		Scan(&members).Error
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get group members page: %w", err)
+	}
+	return members, total, nil
+}
+
 // IsUserInGroup 检查用户是否在群组中 (GORM实现)
 func IsUserInGroup(userID, groupID uint) (bool, error) {
 	var count int64
@@ -174,14 +225,19 @@ func UpdateGroupMemberRole(groupID, userID uint, newRole string) error {
 	return nil
 }
 
-// UpdateGroupInfo 更新群组信息 (GORM实现)
-func UpdateGroupInfo(groupID uint, updates map[string]interface{}) error {
-	result := DB.Model(&model.Group{}).Where("id = ?", groupID).Updates(updates)
-	if result.Error != nil {
-		return fmt.Errorf("failed to update group info: %w", result.Error)
-	}
-	if result.RowsAffected == 0 {
-		return fmt.Errorf("group not found or no changes made")
-	}
-	return nil
+// UpdateGroupInfo 在同一事务内更新群组信息并替换群组标签，tags 为 nil 时不修改标签 (GORM实现)
+func UpdateGroupInfo(groupID uint, updates map[string]interface{}, tags []string) error {
+	return DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&model.Group{}).Where("id = ?", groupID).Updates(updates)
+		if result.Error != nil {
+			return fmt.Errorf("failed to update group info: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return fmt.Errorf("group not found or no changes made")
+		}
+		if tags == nil {
+			return nil
+		}
+		return replaceGroupTags(tx, groupID, tags)
+	})
 }
@@ -0,0 +1,141 @@
+package mysql
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Xaytick/chat-zinx/chat-server/pkg/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrPinnedMessagesLimit 置顶消息数量已达上限
+var ErrPinnedMessagesLimit = errors.New("pinned messages limit reached")
+
+// CreateGroupAnnouncement 新增一个群公告版本，版本号在事务内自动递增 (GORM实现)
+func CreateGroupAnnouncement(announcement *model.GroupAnnouncement) error {
+	return DB.Transaction(func(tx *gorm.DB) error {
+		var maxVersion uint
+		// 锁定该群组的公告记录，避免并发编辑产生相同版本号
+		err := tx.Model(&model.GroupAnnouncement{}).
+			Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("group_id = ?", announcement.GroupID).
+			Select("COALESCE(MAX(version), 0)").
+			Scan(&maxVersion).Error
+		if err != nil {
+			return fmt.Errorf("failed to get latest announcement version: %w", err)
+		}
+
+		announcement.Version = maxVersion + 1
+		announcement.CreatedAt = time.Now()
+		if err := tx.Create(announcement).Error; err != nil {
+			return fmt.Errorf("failed to create group announcement: %w", err)
+		}
+		return nil
+	})
+}
+
+// GetLatestGroupAnnouncement 获取群组当前公告（版本号最大的一条），不存在时返回 nil, nil
+func GetLatestGroupAnnouncement(groupID uint) (*model.GroupAnnouncement, error) {
+	var announcement model.GroupAnnouncement
+	result := DB.Where("group_id = ?", groupID).Order("version DESC").First(&announcement)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, result.Error
+	}
+	return &announcement, nil
+}
+
+// GetGroupAnnouncementByID 根据ID获取群公告，不存在时返回 nil, nil
+func GetGroupAnnouncementByID(announcementID uint) (*model.GroupAnnouncement, error) {
+	var announcement model.GroupAnnouncement
+	result := DB.First(&announcement, announcementID)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, result.Error
+	}
+	return &announcement, nil
+}
+
+// GetGroupAnnouncementHistory 获取群公告编辑历史，按版本号从新到旧排列
+func GetGroupAnnouncementHistory(groupID uint, limit int) ([]*model.GroupAnnouncement, error) {
+	var announcements []*model.GroupAnnouncement
+	err := DB.Where("group_id = ?", groupID).Order("version DESC").Limit(limit).Find(&announcements).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get group announcement history: %w", err)
+	}
+	return announcements, nil
+}
+
+// AddGroupAnnouncementAck 记录用户对公告的确认，重复确认时忽略
+func AddGroupAnnouncementAck(ack *model.GroupAnnouncementAck) error {
+	ack.AckedAt = time.Now()
+	err := DB.Clauses(clause.OnConflict{DoNothing: true}).Create(ack).Error
+	if err != nil {
+		return fmt.Errorf("failed to save group announcement ack: %w", err)
+	}
+	return nil
+}
+
+// GetGroupAnnouncementAcks 获取公告的确认记录，按确认时间排列
+func GetGroupAnnouncementAcks(announcementID uint) ([]*model.GroupAnnouncementAck, error) {
+	var acks []*model.GroupAnnouncementAck
+	err := DB.Where("announcement_id = ?", announcementID).Order("acked_at ASC").Find(&acks).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get group announcement acks: %w", err)
+	}
+	return acks, nil
+}
+
+// AddGroupPinnedMessage 置顶群消息，在锁定群组记录的事务内检查置顶数量上限
+func AddGroupPinnedMessage(pin *model.GroupPinnedMessage, maxPinned int) error {
+	return DB.Transaction(func(tx *gorm.DB) error {
+		// 锁定群组记录，使同一群组的置顶串行执行，避免并发置顶在计数之后同时插入而超过上限
+		var group model.Group
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(&group, pin.GroupID).Error; err != nil {
+			return fmt.Errorf("failed to lock group: %w", err)
+		}
+
+		var count int64
+		if err := tx.Model(&model.GroupPinnedMessage{}).Where("group_id = ?", pin.GroupID).Count(&count).Error; err != nil {
+			return fmt.Errorf("failed to count pinned messages: %w", err)
+		}
+		if count >= int64(maxPinned) {
+			return ErrPinnedMessagesLimit
+		}
+
+		pin.PinnedAt = time.Now()
+		if err := tx.Create(pin).Error; err != nil {
+			// 可能是 uniqueIndex(idx_group_pinned_msg) 冲突，即消息已置顶
+			return fmt.Errorf("failed to pin group message: %w", err)
+		}
+		return nil
+	})
+}
+
+// RemoveGroupPinnedMessage 取消置顶群消息
+func RemoveGroupPinnedMessage(groupID, groupMessageID uint) error {
+	result := DB.Where("group_id = ? AND group_message_id = ?", groupID, groupMessageID).Delete(&model.GroupPinnedMessage{})
+	if result.Error != nil {
+		return fmt.Errorf("failed to unpin group message: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("message is not pinned")
+	}
+	return nil
+}
+
+// GetGroupPinnedMessages 获取群组的置顶消息记录，按置顶时间排列
+func GetGroupPinnedMessages(groupID uint) ([]*model.GroupPinnedMessage, error) {
+	var pins []*model.GroupPinnedMessage
+	err := DB.Where("group_id = ?", groupID).Order("pinned_at ASC").Find(&pins).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pinned messages: %w", err)
+	}
+	return pins, nil
+}
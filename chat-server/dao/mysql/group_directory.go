@@ -0,0 +1,112 @@
+package mysql
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Xaytick/chat-zinx/chat-server/pkg/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// createGroupTags 在事务中为群组批量创建标签
+func createGroupTags(tx *gorm.DB, groupID uint, tags []string) error {
+	if len(tags) == 0 {
+		return nil
+	}
+	now := time.Now()
+	groupTags := make([]*model.GroupTag, 0, len(tags))
+	for _, tag := range tags {
+		groupTags = append(groupTags, &model.GroupTag{GroupID: groupID, Tag: tag, CreatedAt: now})
+	}
+	if err := tx.Create(&groupTags).Error; err != nil {
+		return fmt.Errorf("failed to create group tags: %w", err)
+	}
+	return nil
+}
+
+// replaceGroupTags 在事务中用新的标签列表替换群组的全部标签
+func replaceGroupTags(tx *gorm.DB, groupID uint, tags []string) error {
+	if err := tx.Where("group_id = ?", groupID).Delete(&model.GroupTag{}).Error; err != nil {
+		return fmt.Errorf("failed to delete group tags: %w", err)
+	}
+	return createGroupTags(tx, groupID, tags)
+}
+
+// likeEscaper 转义 LIKE 模式中的通配符，MySQL 默认以反斜杠作为转义字符
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// containsPattern 生成匹配包含 keyword 的 LIKE 模式，keyword 中的 % 和 _ 按字面匹配
+func containsPattern(keyword string) string {
+	return "%" + likeEscaper.Replace(keyword) + "%"
+}
+
+// prefixPattern 生成匹配以 keyword 开头的 LIKE 模式
+func prefixPattern(keyword string) string {
+	return likeEscaper.Replace(keyword) + "%"
+}
+
+// GetGroupTags 批量获取群组标签，返回 groupID 到标签列表的映射
+func GetGroupTags(groupIDs []uint) (map[uint][]string, error) {
+	result := make(map[uint][]string, len(groupIDs))
+	if len(groupIDs) == 0 {
+		return result, nil
+	}
+
+	var tags []*model.GroupTag
+	err := DB.Where("group_id IN ?", groupIDs).Order("id ASC").Find(&tags).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get group tags: %w", err)
+	}
+	for _, tag := range tags {
+		result[tag.GroupID] = append(result[tag.GroupID], tag.Tag)
+	}
+	return result, nil
+}
+
+// SearchPublicGroups 分页搜索公开群组，keyword 匹配群名、简介或标签，category/tag 不为空时精确过滤
+// 群名完全匹配和前缀匹配的结果（可使用 idx_group_name 索引）排在前面，其余按成员数从多到少排列
+func SearchPublicGroups(keyword, category, tag string, offset, limit int) ([]*model.Group, int64, error) {
+	baseQuery := func() *gorm.DB {
+		db := DB.Model(&model.Group{}).Where("is_public = ?", true)
+		if category != "" {
+			db = db.Where("category = ?", category)
+		}
+		if tag != "" {
+			db = db.Where("id IN (?)", DB.Model(&model.GroupTag{}).Select("group_id").Where("tag = ?", tag))
+		}
+		if keyword != "" {
+			like := containsPattern(keyword)
+			tagQuery := DB.Model(&model.GroupTag{}).Select("group_id").Where("tag = ?", strings.ToLower(keyword))
+			db = db.Where("(name LIKE ? OR description LIKE ? OR id IN (?))", like, like, tagQuery)
+		}
+		return db
+	}
+
+	var total int64
+	if err := baseQuery().Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count public groups: %w", err)
+	}
+	if total == 0 || offset >= int(total) {
+		return []*model.Group{}, total, nil
+	}
+
+	query := baseQuery()
+	if keyword != "" {
+		query = query.Order(clause.OrderBy{Expression: clause.Expr{
+			SQL:                "CASE WHEN name = ? THEN 0 WHEN name LIKE ? THEN 1 ELSE 2 END",
+			Vars:               []interface{}{keyword, prefixPattern(keyword)},
+			WithoutParentheses: true,
+		}})
+	}
+	var groups []*model.Group
+	err := query.Order("member_count DESC").Order("id ASC").
+		Offset(offset).
+		Limit(limit).
+		Find(&groups).Error
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to search public groups: %w", err)
+	}
+	return groups, total, nil
+}
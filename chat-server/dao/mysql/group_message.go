@@ -1,11 +1,13 @@
 package mysql
 
 import (
+	"errors"
 	"fmt"
 	"time"
 
 	"github.com/Xaytick/chat-zinx/chat-server/pkg/model"
 	"github.com/google/uuid"
+	"gorm.io/gorm"
 )
 
 // SaveGroupMessage 保存群组消息到数据库
@@ -60,3 +62,38 @@ func GetGroupHistoryMessages(groupID uint, lastID uint, limit int) ([]*model.Gro
 
 	return messages, hasMore, nil
 }
+
+// GetGroupMessageByID 根据ID获取群组消息，不存在时返回 nil, nil
+func GetGroupMessageByID(id uint) (*model.GroupMessage, error) {
+	var message model.GroupMessage
+	result := DB.First(&message, id)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, result.Error
+	}
+	return &message, nil
+}
+
+// GetGroupMessagesByIDs 批量获取群组消息
+func GetGroupMessagesByIDs(ids []uint) ([]*model.GroupMessage, error) {
+	if len(ids) == 0 {
+		return []*model.GroupMessage{}, nil
+	}
+
+	var messages []*model.GroupMessage
+	if err := DB.Where("id IN ?", ids).Find(&messages).Error; err != nil {
+		return nil, fmt.Errorf("failed to get group messages by IDs: %w", err)
+	}
+	return messages, nil
+}
+
+// GetGroupMessagesBySender 按时间顺序获取用户发送的所有群消息，用于个人数据导出
+func GetGroupMessagesBySender(senderID uint) ([]*model.GroupMessage, error) {
+	var messages []*model.GroupMessage
+	if err := DB.Where("sender_id = ?", senderID).Order("id ASC").Find(&messages).Error; err != nil {
+		return nil, fmt.Errorf("failed to get group messages by sender: %w", err)
+	}
+	return messages, nil
+}
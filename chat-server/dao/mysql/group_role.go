@@ -0,0 +1,72 @@
+package mysql
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Xaytick/chat-zinx/chat-server/pkg/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// SaveGroupRole 创建或更新群组自定义角色，按 (group_id, name) 判断是否已存在 (GORM实现)
+func SaveGroupRole(role *model.GroupRole) error {
+	role.CreatedAt = time.Now()
+	role.UpdatedAt = time.Now()
+	err := DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "group_id"}, {Name: "name"}},
+		DoUpdates: clause.AssignmentColumns([]string{"permissions", "priority", "updated_at"}),
+	}).Create(role).Error
+	if err != nil {
+		return fmt.Errorf("failed to save group role: %w", err)
+	}
+	return nil
+}
+
+// GetGroupRole 获取群组自定义角色，不存在时返回 nil, nil
+func GetGroupRole(groupID uint, name string) (*model.GroupRole, error) {
+	var role model.GroupRole
+	result := DB.Where("group_id = ? AND name = ?", groupID, name).First(&role)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, result.Error
+	}
+	return &role, nil
+}
+
+// GetGroupRoles 获取群组所有自定义角色，按优先级从高到低排列
+func GetGroupRoles(groupID uint) ([]*model.GroupRole, error) {
+	var roles []*model.GroupRole
+	err := DB.Where("group_id = ?", groupID).Order("priority DESC, id ASC").Find(&roles).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get group roles: %w", err)
+	}
+	return roles, nil
+}
+
+// DeleteGroupRole 删除群组自定义角色，并在同一事务内将持有该角色的成员恢复为普通成员
+func DeleteGroupRole(groupID uint, name string) error {
+	return DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("group_id = ? AND name = ?", groupID, name).Delete(&model.GroupRole{})
+		if result.Error != nil {
+			return fmt.Errorf("failed to delete group role in transaction: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return errors.New("role not found")
+		}
+
+		err := tx.Model(&model.GroupMember{}).
+			Where("group_id = ? AND role = ?", groupID, name).
+			Updates(map[string]interface{}{
+				"role":       model.GroupRoleMember,
+				"updated_at": time.Now(),
+			}).Error
+		if err != nil {
+			return fmt.Errorf("failed to reset members of deleted role in transaction: %w", err)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,61 @@
+package mysql
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Xaytick/chat-zinx/chat-server/pkg/model"
+	"gorm.io/gorm"
+)
+
+// CreateIncomingWebhook 创建 incoming webhook
+func CreateIncomingWebhook(hook *model.IncomingWebhook) error {
+	if err := DB.Create(hook).Error; err != nil {
+		return fmt.Errorf("failed to create incoming webhook: %w", err)
+	}
+	return nil
+}
+
+// GetIncomingWebhookByHash 根据令牌摘要查找有效的 webhook，不存在或已吊销时返回 nil, nil
+func GetIncomingWebhookByHash(tokenHash string) (*model.IncomingWebhook, error) {
+	var hook model.IncomingWebhook
+	result := DB.Where("token_hash = ? AND revoked_at IS NULL", tokenHash).First(&hook)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, result.Error
+	}
+	return &hook, nil
+}
+
+// GetActiveIncomingWebhooks 获取群组未吊销的 webhook
+func GetActiveIncomingWebhooks(groupID uint) ([]*model.IncomingWebhook, error) {
+	var hooks []*model.IncomingWebhook
+	err := DB.Where("group_id = ? AND revoked_at IS NULL", groupID).Order("id ASC").Find(&hooks).Error
+	return hooks, err
+}
+
+// CountActiveIncomingWebhooks 统计群组未吊销的 webhook 数量
+func CountActiveIncomingWebhooks(groupID uint) (int64, error) {
+	var count int64
+	err := DB.Model(&model.IncomingWebhook{}).Where("group_id = ? AND revoked_at IS NULL", groupID).Count(&count).Error
+	return count, err
+}
+
+// RevokeIncomingWebhook 吊销群组的 webhook，返回是否存在未吊销的记录
+func RevokeIncomingWebhook(groupID, hookID uint) (bool, error) {
+	result := DB.Model(&model.IncomingWebhook{}).
+		Where("id = ? AND group_id = ? AND revoked_at IS NULL", hookID, groupID).
+		Update("revoked_at", time.Now())
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to revoke incoming webhook: %w", result.Error)
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// TouchIncomingWebhook 记录 webhook 的最近使用时间
+func TouchIncomingWebhook(hookID uint) error {
+	return DB.Model(&model.IncomingWebhook{}).Where("id = ?", hookID).Update("last_used_at", time.Now()).Error
+}
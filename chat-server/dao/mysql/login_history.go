@@ -0,0 +1,53 @@
+package mysql
+
+import (
+	"fmt"
+
+	"github.com/Xaytick/chat-zinx/chat-server/pkg/model"
+	"gorm.io/gorm"
+)
+
+// CreateLoginRecord 写入一条登录记录
+func CreateLoginRecord(record *model.LoginRecord) error {
+	if err := DB.Create(record).Error; err != nil {
+		return fmt.Errorf("failed to create login record: %w", err)
+	}
+	return nil
+}
+
+// GetLoginRecords 按时间倒序获取用户最近的登录记录
+func GetLoginRecords(userID uint, limit int) ([]*model.LoginRecord, error) {
+	var records []*model.LoginRecord
+	err := DB.Where("user_id = ?", userID).
+		Order("created_at DESC, id DESC").
+		Limit(limit).
+		Find(&records).Error
+	return records, err
+}
+
+// GetLoginSourcesSeen 检查用户此前是否成功登录过，以及是否从该IP、该设备成功登录过
+func GetLoginSourcesSeen(userID uint, ip, deviceID string) (anyLogin, ipSeen, deviceSeen bool, err error) {
+	base := DB.Model(&model.LoginRecord{}).Where("user_id = ? AND success = ?", userID, true)
+
+	var count int64
+	if err = base.Session(&gorm.Session{}).Count(&count).Error; err != nil {
+		return false, false, false, err
+	}
+	if count == 0 {
+		return false, false, false, nil
+	}
+	if err = base.Session(&gorm.Session{}).Where("ip = ?", ip).Limit(1).Count(&count).Error; err != nil {
+		return false, false, false, err
+	}
+	ipSeen = count > 0
+
+	// 旧版本客户端不上报设备标识，此时不做设备判断
+	deviceSeen = true
+	if deviceID != "" {
+		if err = base.Session(&gorm.Session{}).Where("device_id = ?", deviceID).Limit(1).Count(&count).Error; err != nil {
+			return false, false, false, err
+		}
+		deviceSeen = count > 0
+	}
+	return true, ipSeen, deviceSeen, nil
+}
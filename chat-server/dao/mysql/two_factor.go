@@ -0,0 +1,105 @@
+package mysql
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Xaytick/chat-zinx/chat-server/pkg/model"
+	"gorm.io/gorm"
+)
+
+// ErrTOTPAlreadyEnabled 用户已开启两步验证
+var ErrTOTPAlreadyEnabled = errors.New("two-factor authentication already enabled")
+
+// SetUserTOTPSecret 保存待确认的两步验证密钥，已开启两步验证的用户不会被覆盖
+func SetUserTOTPSecret(userID uint, secret string) error {
+	result := DB.Model(&model.User{}).
+		Where("id = ? AND totp_enabled = ?", userID, false).
+		Updates(map[string]interface{}{"totp_secret": secret, "totp_last_step": 0})
+	if result.Error != nil {
+		return fmt.Errorf("failed to save totp secret: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrTOTPAlreadyEnabled
+	}
+	return nil
+}
+
+// EnableUserTOTP 开启两步验证并替换备用恢复码
+func EnableUserTOTP(userID uint, step int64, codeHashes []string) error {
+	return DB.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&model.User{}).Where("id = ?", userID).
+			Updates(map[string]interface{}{"totp_enabled": true, "totp_last_step": step}).Error
+		if err != nil {
+			return fmt.Errorf("failed to enable totp: %w", err)
+		}
+		if err := replaceBackupCodes(tx, userID, codeHashes); err != nil {
+			return err
+		}
+		return nil
+	})
+}
+
+// DisableUserTOTP 关闭两步验证，清除密钥和备用恢复码
+func DisableUserTOTP(userID uint) error {
+	return DB.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&model.User{}).Where("id = ?", userID).
+			Updates(map[string]interface{}{"totp_enabled": false, "totp_secret": "", "totp_last_step": 0}).Error
+		if err != nil {
+			return fmt.Errorf("failed to disable totp: %w", err)
+		}
+		if err := tx.Where("user_id = ?", userID).Delete(&model.UserBackupCode{}).Error; err != nil {
+			return fmt.Errorf("failed to delete backup codes: %w", err)
+		}
+		return nil
+	})
+}
+
+// AdvanceUserTOTPStep 记录已使用的验证码时间步，时间步不大于上次记录时返回 false（验证码被重放）
+func AdvanceUserTOTPStep(userID uint, step int64) (bool, error) {
+	result := DB.Model(&model.User{}).
+		Where("id = ? AND totp_last_step < ?", userID, step).
+		Update("totp_last_step", step)
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to update totp step: %w", result.Error)
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// ConsumeBackupCode 使用一个备用恢复码，恢复码不存在或已使用时返回 false
+func ConsumeBackupCode(userID uint, codeHash string) (bool, error) {
+	result := DB.Model(&model.UserBackupCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, codeHash).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to consume backup code: %w", result.Error)
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// CountUnusedBackupCodes 统计剩余可用的备用恢复码数量
+func CountUnusedBackupCodes(userID uint) (int64, error) {
+	var count int64
+	err := DB.Model(&model.UserBackupCode{}).
+		Where("user_id = ? AND used_at IS NULL", userID).
+		Count(&count).Error
+	return count, err
+}
+
+func replaceBackupCodes(tx *gorm.DB, userID uint, codeHashes []string) error {
+	if err := tx.Where("user_id = ?", userID).Delete(&model.UserBackupCode{}).Error; err != nil {
+		return fmt.Errorf("failed to delete backup codes: %w", err)
+	}
+	codes := make([]model.UserBackupCode, 0, len(codeHashes))
+	for _, hash := range codeHashes {
+		codes = append(codes, model.UserBackupCode{UserID: userID, CodeHash: hash})
+	}
+	if len(codes) == 0 {
+		return nil
+	}
+	if err := tx.Create(&codes).Error; err != nil {
+		return fmt.Errorf("failed to create backup codes: %w", err)
+	}
+	return nil
+}
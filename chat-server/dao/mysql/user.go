@@ -48,7 +48,19 @@ func InitMySQL(cfg *conf.MySQLConfig) (err error) {
 	}
 
 	// 自动迁移时，请确保您的 User 模型与数据库表结构匹配 GORM 的约定或使用了正确的 gorm tags
-	err = DB.AutoMigrate(&model.User{}, &model.Group{}, &model.GroupMember{}, &model.GroupMessage{}) // 添加GroupMessage表迁移
+	err = DB.AutoMigrate(&model.User{}, &model.Group{}, &model.GroupMember{}, &model.GroupMessage{}, // 添加GroupMessage表迁移
+		&model.GroupAnnouncement{}, &model.GroupAnnouncementAck{}, &model.GroupPinnedMessage{}, // 群公告与置顶消息
+		&model.GroupBan{}, &model.GroupRole{}, // 群组封禁与自定义角色
+		&model.GroupTag{},                       // 群组目录标签
+		&model.FriendRequest{}, &model.Friend{}, // 好友申请与好友关系
+		&model.UserBlock{},       // 用户屏蔽
+		&model.UserToken{},       // 密码重置与邮箱验证令牌
+		&model.UserBackupCode{},  // 两步验证备用恢复码
+		&model.LoginRecord{},     // 登录历史
+		&model.DataExport{},      // 个人数据导出
+		&model.BotAPIKey{},       // 机器人 API Key
+		&model.BotWebhook{},      // 机器人 outgoing webhook
+		&model.IncomingWebhook{}) // 群组 incoming webhook
 	if err != nil {
 		return fmt.Errorf("failed to auto migrate tables: %w", err)
 	}
@@ -66,6 +78,18 @@ func InitMySQL(cfg *conf.MySQLConfig) (err error) {
 	return nil
 }
 
+// Close 关闭数据库连接池
+func Close() error {
+	if DB == nil {
+		return nil
+	}
+	sqlDB, err := DB.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.Close()
+}
+
 // GetUserByUsername 根据用户名查询用户信息 (GORM实现)
 func GetUserByUsername(username string) (*model.User, error) {
 	var user model.User
@@ -136,12 +160,33 @@ func UpdateUserOnlineStatus(userID uint, isOnline bool) error {
 	return DB.Model(&model.User{}).Where("id = ?", userID).Updates(updates).Error
 }
 
-// UpdateUserLastLoginInfo 更新用户最后登录时间 (GORM实现)
-func UpdateUserLastLoginInfo(userID uint) error {
+// MarkUsersOffline 批量把用户标记为离线，节点关闭时使用
+// loggedInAt 为用户在本节点登录的时间，之后又在其他节点登录过（last_login 更晚）的用户保持在线
+func MarkUsersOffline(loggedInAt map[uint]time.Time) error {
+	if len(loggedInAt) == 0 {
+		return nil
+	}
 	updates := map[string]interface{}{
-		"last_login": time.Now(),
+		"is_online":  false,
 		"updated_at": time.Now(),
 	}
+	return DB.Transaction(func(tx *gorm.DB) error {
+		for userID, at := range loggedInAt {
+			if err := tx.Model(&model.User{}).Where("id = ? AND last_login <= ?", userID, at).Updates(updates).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// UpdateUserLastLoginInfo 更新用户最后登录时间和IP (GORM实现)
+func UpdateUserLastLoginInfo(userID uint, ip string) error {
+	updates := map[string]interface{}{
+		"last_login":    time.Now(),
+		"last_login_ip": ip,
+		"updated_at":    time.Now(),
+	}
 	return DB.Model(&model.User{}).Where("id = ?", userID).Updates(updates).Error
 }
 
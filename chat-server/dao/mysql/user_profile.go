@@ -0,0 +1,59 @@
+package mysql
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/Xaytick/chat-zinx/chat-server/pkg/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// UpdateUserProfile 更新用户资料，updates 的键为列名
+func UpdateUserProfile(userID uint, updates map[string]interface{}) error {
+	if len(updates) == 0 {
+		return nil
+	}
+	updates["updated_at"] = time.Now()
+	if err := DB.Model(&model.User{}).Where("id = ?", userID).Updates(updates).Error; err != nil {
+		return fmt.Errorf("failed to update user profile: %w", err)
+	}
+	return nil
+}
+
+// SearchUsers 分页搜索允许被搜索的用户，keyword 匹配用户名或昵称
+// 结果不包含搜索者本人和屏蔽了搜索者的用户；用户名完全匹配和前缀匹配的结果排在前面
+func SearchUsers(viewerID uint, keyword string, offset, limit int) ([]*model.User, int64, error) {
+	baseQuery := func() *gorm.DB {
+		like := containsPattern(keyword)
+		blockedBy := DB.Model(&model.UserBlock{}).Select("user_id").Where("blocked_user_id = ?", viewerID)
+		return DB.Model(&model.User{}).
+			Where("searchable = ? AND id <> ?", true, viewerID).
+			Where("id NOT IN (?)", blockedBy).
+			Where("(username LIKE ? OR nickname LIKE ?)", like, like)
+	}
+
+	var total int64
+	if err := baseQuery().Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count users: %w", err)
+	}
+	if total == 0 || offset >= int(total) {
+		return []*model.User{}, total, nil
+	}
+
+	var users []*model.User
+	err := baseQuery().
+		Order(clause.OrderBy{Expression: clause.Expr{
+			SQL:                "CASE WHEN username = ? THEN 0 WHEN username LIKE ? THEN 1 ELSE 2 END",
+			Vars:               []interface{}{keyword, prefixPattern(keyword)},
+			WithoutParentheses: true,
+		}}).
+		Order("id ASC").
+		Offset(offset).
+		Limit(limit).
+		Find(&users).Error
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to search users: %w", err)
+	}
+	return users, total, nil
+}
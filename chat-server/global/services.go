@@ -3,8 +3,16 @@ package global
 import (
 	"fmt"
 
+	"github.com/Xaytick/chat-zinx/chat-server/conf"
 	"github.com/Xaytick/chat-zinx/chat-server/pkg/cache"
+	"github.com/Xaytick/chat-zinx/chat-server/pkg/fanout"
+	"github.com/Xaytick/chat-zinx/chat-server/pkg/httpapi"
+	"github.com/Xaytick/chat-zinx/chat-server/pkg/mailer"
 	"github.com/Xaytick/chat-zinx/chat-server/pkg/service"
+	"github.com/Xaytick/chat-zinx/chat-server/pkg/session"
+	"github.com/Xaytick/chat-zinx/chat-server/pkg/tlsgateway"
+	"github.com/Xaytick/chat-zinx/chat-server/pkg/webhook"
+	"github.com/Xaytick/chat-zinx/chat-server/pkg/wsgateway"
 	"github.com/Xaytick/zinx/ziface"
 )
 
@@ -33,9 +41,36 @@ var (
 	// GroupService 群组服务实例
 	GroupService service.IGroupService
 
+	// FriendService 好友服务实例
+	FriendService service.IFriendService
+
+	// BotService 机器人 webhook 服务实例
+	BotService service.IBotService
+
+	// IncomingWebhookService 群组 incoming webhook 服务实例
+	IncomingWebhookService service.IIncomingWebhookService
+
 	// CacheService 缓存服务实例
 	CacheService cache.CacheService
 
+	// Sessions 可断线恢复的会话，所有推送经过这里编号和缓存，在服务器创建后初始化
+	Sessions *session.Manager
+
+	// GroupFanout 群消息推送协程池，在服务器创建后初始化
+	GroupFanout *fanout.Dispatcher
+
+	// BotWebhooks 机器人 webhook 投递协程池，在服务器创建后初始化
+	BotWebhooks *webhook.Dispatcher
+
+	// HTTPServer 内置 HTTP 服务，配置中未启用时为 nil
+	HTTPServer *httpapi.Server
+
+	// TLSGateway TCP 聊天端口的 TLS 网关，配置中未启用时为 nil
+	TLSGateway *tlsgateway.Gateway
+
+	// WSGateway 浏览器使用的 WebSocket 网关，挂在 HTTPServer 上，未启用时为 nil
+	WSGateway *wsgateway.Gateway
+
 	// Config 应用配置
 	Config *AppConfig
 )
@@ -56,8 +91,15 @@ func InitServices() {
 	// 初始化缓存服务
 	CacheService = cache.NewCacheService()
 
+	// 初始化邮件发送器，配置错误时退回到日志输出
+	mail, err := mailer.New(conf.GetMailConfig())
+	if err != nil {
+		fmt.Printf("邮件发送器初始化失败, 邮件将只输出到日志: %v\n", err)
+		mail = mailer.NewFileMailer("")
+	}
+
 	// 初始化用户服务(使用MySQL实现)
-	UserService = service.NewMySQLUserService()
+	UserService = service.NewMySQLUserService(mail)
 
 	// 初始化消息服务(使用Redis实现)
 	MessageService = service.NewRedisMessageService()
@@ -65,5 +107,14 @@ func InitServices() {
 	// 初始化群组服务
 	GroupService = service.NewGroupService()
 
+	// 初始化好友服务
+	FriendService = service.NewFriendService(UserService)
+
+	// 初始化机器人服务
+	BotService = service.NewBotService(UserService)
+
+	// 初始化 incoming webhook 服务
+	IncomingWebhookService = service.NewIncomingWebhookService()
+
 	fmt.Println("所有服务初始化完毕!")
 }
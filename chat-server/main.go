@@ -3,12 +3,24 @@ package main
 import (
 	"fmt"
 	"log"
+	"net"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/Xaytick/chat-zinx/chat-server/conf"
 	"github.com/Xaytick/chat-zinx/chat-server/dao/mysql"
 	"github.com/Xaytick/chat-zinx/chat-server/dao/redis"
 	"github.com/Xaytick/chat-zinx/chat-server/global"
+	"github.com/Xaytick/chat-zinx/chat-server/pkg/fanout"
+	"github.com/Xaytick/chat-zinx/chat-server/pkg/httpapi"
+	"github.com/Xaytick/chat-zinx/chat-server/pkg/model"
 	"github.com/Xaytick/chat-zinx/chat-server/pkg/protocol"
+	"github.com/Xaytick/chat-zinx/chat-server/pkg/session"
+	"github.com/Xaytick/chat-zinx/chat-server/pkg/tlsgateway"
+	"github.com/Xaytick/chat-zinx/chat-server/pkg/webhook"
+	"github.com/Xaytick/chat-zinx/chat-server/pkg/wsgateway"
 	"github.com/Xaytick/chat-zinx/chat-server/router"
 	"github.com/Xaytick/zinx/ziface"
 	"github.com/Xaytick/zinx/znet"
@@ -55,22 +67,52 @@ func main() {
 	fmt.Println("创建服务器...")
 	global.GlobalServer = znet.NewServer(config.Name)
 
+	// 创建会话管理器，断线的会话在恢复窗口结束后才把用户标记为离线
+	// 推送经每个连接的发送队列写出，慢消费者被丢弃的推送转存离线
+	resumeConfig := conf.GetSessionResumeConfig()
+	bpConfig := conf.GetBackpressureConfig()
+	global.Sessions = session.NewManager(global.GlobalServer.GetConnManager(),
+		time.Duration(resumeConfig.Window)*time.Second, resumeConfig.BufferSize, session.Backpressure{
+			QueueSize:   bpConfig.QueueSize,
+			HighWater:   bpConfig.HighWater,
+			SlowTimeout: time.Duration(bpConfig.SlowTimeout) * time.Second,
+			Policy:      bpConfig.Policy,
+			OnDropped:   router.SaveDroppedPush,
+			OnRecovered: router.ResendOfflinePushes,
+		}, markOfflineIfGone)
+
+	// 启动群消息推送协程池
+	groupConfig := conf.GetGroupConfig()
+	global.GroupFanout = fanout.NewDispatcher(global.Sessions,
+		groupConfig.FanoutWorkers, groupConfig.FanoutQueueSize, groupConfig.FanoutBatchSize)
+	global.GroupFanout.Start()
+
+	// 启动机器人 webhook 投递协程池，webhook 的回复以机器人身份发回群里
+	global.BotWebhooks = webhook.NewDispatcher(conf.GetWebhookConfig(), router.PostBotReply)
+	global.BotWebhooks.Start()
+
 	// 5. 注册业务路由
+	// 访客和机器人不具备的功能通过 router.RequireCapability 包装，按连接的账号类型拒绝
 	fmt.Println("注册路由...")
 
 	// 注册/登录路由
+	global.GlobalServer.AddRouter(protocol.MsgIDHelloReq, &router.HelloRouter{})
 	global.GlobalServer.AddRouter(protocol.MsgIDRegisterReq, &router.RegisterRouter{})
-	global.GlobalServer.AddRouter(protocol.MsgIDLoginReq, &router.LoginRouter{})
+	global.GlobalServer.AddRouter(protocol.MsgIDLoginReq, router.RequireHello(protocol.MsgIDLoginResp, &router.LoginRouter{}))
+	global.GlobalServer.AddRouter(protocol.MsgIDGuestLoginReq, router.RequireHello(protocol.MsgIDLoginResp, &router.GuestLoginRouter{}))
+	global.GlobalServer.AddRouter(protocol.MsgIDBotLoginReq, router.RequireHello(protocol.MsgIDLoginResp, &router.BotLoginRouter{}))
+	global.GlobalServer.AddRouter(protocol.MsgIDResumeSessionReq, router.RequireHello(protocol.MsgIDResumeSessionResp, &router.ResumeSessionRouter{}))
+	global.GlobalServer.AddRouter(protocol.MsgIDTokenLoginReq, router.RequireHello(protocol.MsgIDLoginResp, &router.TokenLoginRouter{}))
 
 	// 聊天消息路由
-	global.GlobalServer.AddRouter(protocol.MsgIDTextMsg, &router.TextMsgRouter{})
+	global.GlobalServer.AddRouter(protocol.MsgIDTextMsg, router.RequireCapability(model.CapDirectMessage, protocol.MsgIDErrorResp, &router.TextMsgRouter{}))
 
 	// 历史消息和聊天关系路由
-	global.GlobalServer.AddRouter(protocol.MsgIDHistoryMsgReq, &router.HistoryMsgRouter{})
-	global.GlobalServer.AddRouter(protocol.MsgIDChatRelationReq, &router.ChatRelationRouter{})
+	global.GlobalServer.AddRouter(protocol.MsgIDHistoryMsgReq, router.RequireCapability(model.CapDirectMessage, protocol.MsgIDHistoryMsgResp, &router.HistoryMsgRouter{}))
+	global.GlobalServer.AddRouter(protocol.MsgIDChatRelationReq, router.RequireCapability(model.CapDirectMessage, protocol.MsgIDChatRelationResp, &router.ChatRelationRouter{}))
 
 	// 群组功能路由 (Group feature routers)
-	global.GlobalServer.AddRouter(protocol.MsgIDCreateGroupReq, &router.CreateGroupRouter{})
+	global.GlobalServer.AddRouter(protocol.MsgIDCreateGroupReq, router.RequireCapability(model.CapCreateGroup, protocol.MsgIDCreateGroupResp, &router.CreateGroupRouter{}))
 	global.GlobalServer.AddRouter(protocol.MsgIDJoinGroupReq, &router.JoinGroupRouter{})
 	global.GlobalServer.AddRouter(protocol.MsgIDLeaveGroupReq, &router.LeaveGroupRouter{})
 
@@ -85,6 +127,81 @@ func main() {
 	global.GlobalServer.AddRouter(protocol.MsgIDUpdateGroupInfoReq, &router.UpdateGroupInfoRouter{})
 	global.GlobalServer.AddRouter(protocol.MsgIDSetMemberRoleReq, &router.SetMemberRoleRouter{})
 	global.GlobalServer.AddRouter(protocol.MsgIDRemoveMemberReq, &router.RemoveMemberRouter{})
+	global.GlobalServer.AddRouter(protocol.MsgIDInviteGroupMemberReq, &router.InviteGroupMemberRouter{})
+	global.GlobalServer.AddRouter(protocol.MsgIDBanGroupMemberReq, &router.BanGroupMemberRouter{})
+	global.GlobalServer.AddRouter(protocol.MsgIDUnbanGroupMemberReq, &router.UnbanGroupMemberRouter{})
+	global.GlobalServer.AddRouter(protocol.MsgIDMuteGroupMemberReq, &router.MuteGroupMemberRouter{})
+	global.GlobalServer.AddRouter(protocol.MsgIDGetGroupBanListReq, &router.GetGroupBanListRouter{})
+
+	// 群公告与置顶消息路由
+	global.GlobalServer.AddRouter(protocol.MsgIDUpdateGroupAnnouncementReq, &router.UpdateGroupAnnouncementRouter{})
+	global.GlobalServer.AddRouter(protocol.MsgIDGetGroupAnnouncementHistoryReq, &router.GetGroupAnnouncementHistoryRouter{})
+	global.GlobalServer.AddRouter(protocol.MsgIDAckGroupAnnouncementReq, &router.AckGroupAnnouncementRouter{})
+	global.GlobalServer.AddRouter(protocol.MsgIDPinGroupMessageReq, &router.PinGroupMessageRouter{})
+	global.GlobalServer.AddRouter(protocol.MsgIDUnpinGroupMessageReq, &router.UnpinGroupMessageRouter{})
+
+	// 公开群组目录路由
+	global.GlobalServer.AddRouter(protocol.MsgIDSearchGroupsReq, &router.SearchGroupsRouter{})
+
+	// 群组角色与权限路由
+	global.GlobalServer.AddRouter(protocol.MsgIDSaveGroupRoleReq, &router.SaveGroupRoleRouter{})
+	global.GlobalServer.AddRouter(protocol.MsgIDDeleteGroupRoleReq, &router.DeleteGroupRoleRouter{})
+	global.GlobalServer.AddRouter(protocol.MsgIDGetGroupRolesReq, &router.GetGroupRolesRouter{})
+
+	// 好友路由
+	global.GlobalServer.AddRouter(protocol.MsgIDSendFriendRequestReq, router.RequireCapability(model.CapFriends, protocol.MsgIDSendFriendRequestResp, &router.SendFriendRequestRouter{}))
+	global.GlobalServer.AddRouter(protocol.MsgIDHandleFriendRequestReq, router.RequireCapability(model.CapFriends, protocol.MsgIDHandleFriendRequestResp, &router.HandleFriendRequestRouter{}))
+	global.GlobalServer.AddRouter(protocol.MsgIDGetFriendRequestsReq, router.RequireCapability(model.CapFriends, protocol.MsgIDGetFriendRequestsResp, &router.GetFriendRequestsRouter{}))
+	global.GlobalServer.AddRouter(protocol.MsgIDGetFriendListReq, router.RequireCapability(model.CapFriends, protocol.MsgIDGetFriendListResp, &router.GetFriendListRouter{}))
+	global.GlobalServer.AddRouter(protocol.MsgIDRemoveFriendReq, router.RequireCapability(model.CapFriends, protocol.MsgIDRemoveFriendResp, &router.RemoveFriendRouter{}))
+	global.GlobalServer.AddRouter(protocol.MsgIDSetFriendRemarkReq, router.RequireCapability(model.CapFriends, protocol.MsgIDSetFriendRemarkResp, &router.SetFriendRemarkRouter{}))
+
+	// 屏蔽与私聊隐私路由
+	global.GlobalServer.AddRouter(protocol.MsgIDBlockUserReq, &router.BlockUserRouter{})
+	global.GlobalServer.AddRouter(protocol.MsgIDUnblockUserReq, &router.UnblockUserRouter{})
+	global.GlobalServer.AddRouter(protocol.MsgIDGetBlockListReq, &router.GetBlockListRouter{})
+	global.GlobalServer.AddRouter(protocol.MsgIDSetDMPrivacyReq, router.RequireCapability(model.CapDirectMessage, protocol.MsgIDSetDMPrivacyResp, &router.SetDMPrivacyRouter{}))
+	global.GlobalServer.AddRouter(protocol.MsgIDGetDMPrivacyReq, &router.GetDMPrivacyRouter{})
+
+	// 用户资料与用户搜索路由
+	global.GlobalServer.AddRouter(protocol.MsgIDUpdateProfileReq, &router.UpdateProfileRouter{})
+	global.GlobalServer.AddRouter(protocol.MsgIDGetProfileReq, &router.GetProfileRouter{})
+	global.GlobalServer.AddRouter(protocol.MsgIDSearchUsersReq, &router.SearchUsersRouter{})
+
+	// 账号安全路由
+	global.GlobalServer.AddRouter(protocol.MsgIDChangePasswordReq, router.RequireCapability(model.CapAccountSecurity, protocol.MsgIDChangePasswordResp, &router.ChangePasswordRouter{}))
+	global.GlobalServer.AddRouter(protocol.MsgIDRequestPasswordResetReq, &router.RequestPasswordResetRouter{})
+	global.GlobalServer.AddRouter(protocol.MsgIDResetPasswordReq, &router.ResetPasswordRouter{})
+	global.GlobalServer.AddRouter(protocol.MsgIDSendVerifyEmailReq, router.RequireCapability(model.CapAccountSecurity, protocol.MsgIDSendVerifyEmailResp, &router.SendVerifyEmailRouter{}))
+	global.GlobalServer.AddRouter(protocol.MsgIDVerifyEmailReq, &router.VerifyEmailRouter{})
+	global.GlobalServer.AddRouter(protocol.MsgIDUnlockAccountReq, &router.UnlockAccountRouter{})
+
+	// 两步验证路由
+	global.GlobalServer.AddRouter(protocol.MsgIDTwoFactorLoginReq, &router.TwoFactorLoginRouter{})
+	global.GlobalServer.AddRouter(protocol.MsgIDEnrollTOTPReq, router.RequireCapability(model.CapAccountSecurity, protocol.MsgIDEnrollTOTPResp, &router.EnrollTOTPRouter{}))
+	global.GlobalServer.AddRouter(protocol.MsgIDConfirmTOTPReq, router.RequireCapability(model.CapAccountSecurity, protocol.MsgIDConfirmTOTPResp, &router.ConfirmTOTPRouter{}))
+	global.GlobalServer.AddRouter(protocol.MsgIDDisableTOTPReq, router.RequireCapability(model.CapAccountSecurity, protocol.MsgIDDisableTOTPResp, &router.DisableTOTPRouter{}))
+	global.GlobalServer.AddRouter(protocol.MsgIDGetLoginHistoryReq, &router.GetLoginHistoryRouter{})
+
+	// 账号注销与数据导出路由
+	global.GlobalServer.AddRouter(protocol.MsgIDDeleteAccountReq, router.RequireCapability(model.CapAccountSecurity, protocol.MsgIDDeleteAccountResp, &router.DeleteAccountRouter{}))
+	global.GlobalServer.AddRouter(protocol.MsgIDCancelAccountDeletionReq, router.RequireCapability(model.CapAccountSecurity, protocol.MsgIDCancelAccountDeletionResp, &router.CancelAccountDeletionRouter{}))
+	global.GlobalServer.AddRouter(protocol.MsgIDGetAccountDeletionStatusReq, router.RequireCapability(model.CapAccountSecurity, protocol.MsgIDGetAccountDeletionStatusResp, &router.GetAccountDeletionStatusRouter{}))
+	global.GlobalServer.AddRouter(protocol.MsgIDRequestDataExportReq, router.RequireCapability(model.CapAccountSecurity, protocol.MsgIDRequestDataExportResp, &router.RequestDataExportRouter{}))
+	global.GlobalServer.AddRouter(protocol.MsgIDDownloadDataExportReq, router.RequireCapability(model.CapAccountSecurity, protocol.MsgIDDownloadDataExportResp, &router.DownloadDataExportRouter{}))
+
+	// 机器人管理路由
+	global.GlobalServer.AddRouter(protocol.MsgIDCreateBotReq, router.RequireCapability(model.CapManageBots, protocol.MsgIDCreateBotResp, &router.CreateBotRouter{}))
+	global.GlobalServer.AddRouter(protocol.MsgIDRotateBotKeyReq, router.RequireCapability(model.CapManageBots, protocol.MsgIDRotateBotKeyResp, &router.RotateBotKeyRouter{}))
+	global.GlobalServer.AddRouter(protocol.MsgIDGetMyBotsReq, router.RequireCapability(model.CapManageBots, protocol.MsgIDGetMyBotsResp, &router.GetMyBotsRouter{}))
+	global.GlobalServer.AddRouter(protocol.MsgIDSetBotWebhookReq, router.RequireCapability(model.CapManageBots, protocol.MsgIDSetBotWebhookResp, &router.SetBotWebhookRouter{}))
+	global.GlobalServer.AddRouter(protocol.MsgIDDeleteBotWebhookReq, router.RequireCapability(model.CapManageBots, protocol.MsgIDDeleteBotWebhookResp, &router.DeleteBotWebhookRouter{}))
+	global.GlobalServer.AddRouter(protocol.MsgIDGetBotWebhooksReq, router.RequireCapability(model.CapManageBots, protocol.MsgIDGetBotWebhooksResp, &router.GetBotWebhooksRouter{}))
+
+	// 群组 incoming webhook 管理路由
+	global.GlobalServer.AddRouter(protocol.MsgIDCreateIncomingWebhookReq, &router.CreateIncomingWebhookRouter{})
+	global.GlobalServer.AddRouter(protocol.MsgIDRevokeIncomingWebhookReq, &router.RevokeIncomingWebhookRouter{})
+	global.GlobalServer.AddRouter(protocol.MsgIDGetIncomingWebhooksReq, &router.GetIncomingWebhooksRouter{})
 
 	// 6. 设置心跳检测，启用心跳检测会自动启动心跳路由
 	fmt.Println("启用心跳检测...")
@@ -93,6 +210,16 @@ func main() {
 	// 7. 启动服务器勾子
 	// 设置连接开始时的钩子函数
 	global.GlobalServer.SetOnConnStart(func(conn ziface.IConnection) {
+		if draining.Load() {
+			fmt.Println("服务正在关闭，拒绝新连接 ConnID=", conn.GetConnID())
+			rejectDraining(conn)
+			return
+		}
+		if rejectPlaintext(conn) {
+			fmt.Println("拒绝明文连接 ConnID=", conn.GetConnID(), "IP:", conn.RemoteAddr().String(), "，请使用 TLS 端口")
+			conn.Stop()
+			return
+		}
 		fmt.Println("新连接 ConnID=", conn.GetConnID(), "IP:", conn.RemoteAddr().String())
 		fmt.Printf("心跳检测已启用，间隔: %d秒，超时: %d秒\n",
 			conf.GetHeartbeatInterval(), conf.GetHeartbeatTimeout())
@@ -104,12 +231,168 @@ func main() {
 			username, _ := conn.GetProperty("username")
 			fmt.Printf("连接断开 ConnID=%d, 用户: %s(ID=%s)\n",
 				conn.GetConnID(), username, userID)
+			// 会话在恢复窗口内保留，短暂断线不改变在线状态，到期后由 markOfflineIfGone 处理
+			if global.Sessions.Detach(conn) {
+				return
+			}
+			// 更新在线状态，好友列表依赖该状态
+			// 用户已在其他连接上登录时（如修改密码后保留的会话）不标记离线
+			if uid, ok := userID.(uint); ok {
+				markOfflineIfGone(uid, conn.GetConnID())
+			}
 		} else {
 			fmt.Println("连接断开 ConnID=", conn.GetConnID(), "未登录用户")
 		}
 	})
 
-	// 8. 启动并阻塞服务
+	// 分布式模式下，其他节点作废某个用户的会话时关闭该用户在本节点上的连接
+	if node, ok := global.DistributedManager.(sessionRevocationSubscriber); ok {
+		if err := node.SubscribeSessionRevoked(func(userID uint, reason string) {
+			router.RevokeLocalSessions(userID, reason)
+		}); err != nil {
+			log.Fatalf("订阅会话作废通知失败: %v", err)
+		}
+	}
+
+	// 启动到期账号和过期导出文件的定期清理
+	stopPurger := startAccountPurger()
+
+	// 启动 HTTP 服务，外部系统通过 incoming webhook 向群组发消息，浏览器通过 WebSocket 网关接入
+	startHTTPServer()
+
+	// 启动 TLS 网关，证书无效时直接退出，避免在运维以为已加密的情况下只提供明文端口
+	if err := startTLSGateway(); err != nil {
+		log.Fatalf("启动 TLS 网关失败: %v", err)
+	}
+
+	// 8. 启动服务，阻塞到收到 SIGTERM 或 SIGINT 后优雅关闭
 	fmt.Println("启动服务器...")
-	global.GlobalServer.Serve()
+	global.GlobalServer.Start()
+	waitForShutdown(stopPurger)
+}
+
+// sessionRevocationSubscriber 分布式模式下接收其他节点的会话作废通知，global.DistributedManager 为 interface{}，按需断言
+type sessionRevocationSubscriber interface {
+	SubscribeSessionRevoked(handler func(userID uint, reason string)) error
+}
+
+// markOfflineIfGone 连接断开或会话过期后，用户在当前节点上没有其他连接时标记为离线
+// 节点关闭期间由 shutdown 统一标记，这里跳过，避免把已在其他节点重新登录的用户标记为离线
+func markOfflineIfGone(userID uint, connID uint32) {
+	if draining.Load() {
+		return
+	}
+	current := global.GlobalServer.GetConnManager().GetConnByUserID(userID)
+	if current != nil && current.GetConnID() != connID {
+		return
+	}
+	if err := global.UserService.UpdateUserOnlineStatus(userID, false); err != nil {
+		fmt.Printf("更新用户 %d 离线状态失败: %v\n", userID, err)
+	}
+}
+
+// startAccountPurger 定期清除冷静期已结束的账号和过期的数据导出文件，返回的函数停止清理并等待进行中的一轮结束
+func startAccountPurger() (stop func()) {
+	interval := time.Duration(conf.GetAccountConfig().PurgeInterval) * time.Second
+	quit := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-quit:
+				return
+			case <-ticker.C:
+			}
+			purged, err := global.UserService.PurgeDueAccounts()
+			if err != nil {
+				fmt.Printf("清除到期注销账号失败: %v\n", err)
+			}
+			for _, uid := range purged {
+				router.DisconnectPurgedUser(uid)
+			}
+			if err := global.UserService.PurgeExpiredExports(); err != nil {
+				fmt.Printf("清除过期数据导出失败: %v\n", err)
+			}
+		}
+	}()
+	return func() {
+		close(quit)
+		<-done
+	}
+}
+
+// startHTTPServer 启动内置 HTTP 服务并注册 REST 接口、incoming webhook 和 WebSocket 路由，未启用时跳过
+func startHTTPServer() {
+	httpConfig := conf.GetHTTPConfig()
+	wsConfig := conf.GetWebSocketConfig()
+	if !httpConfig.Enabled {
+		fmt.Println("HTTP 服务未启用")
+		if wsConfig.Enabled {
+			fmt.Println("警告: WebSocket 网关依赖 HTTP 服务，请同时开启 HTTP.Enabled")
+		}
+		return
+	}
+	global.HTTPServer = httpapi.NewServer(httpConfig)
+	if httpConfig.MetricsToken != "" {
+		global.HTTPServer.Handle("GET /metrics/push-queues", httpapi.PushQueueMetricsHandler(global.Sessions, httpConfig.MetricsToken))
+	}
+	global.HTTPServer.Handle("POST "+model.IncomingWebhookPathPrefix+"{token}",
+		httpapi.IncomingWebhookHandler(global.IncomingWebhookService, router.PublishIntegrationMessage, httpConfig.MaxBodySize))
+	httpapi.NewRESTAPI(global.UserService, global.GroupService, global.MessageService, httpapi.RESTHooks{
+		RecordLogin:    router.RecordLoginAttempt,
+		ProfileChanged: router.PushProfileChanged,
+	}, httpConfig.MaxBodySize).Register(global.HTTPServer)
+	if wsConfig.Enabled {
+		global.WSGateway = wsgateway.NewGateway(wsConfig)
+		global.HTTPServer.Handle("GET "+wsConfig.Path, global.WSGateway)
+		fmt.Printf("[WS] WebSocket gateway on %s%s, forwarding to %s\n", httpConfig.Addr, wsConfig.Path, wsConfig.Upstream)
+	}
+	global.HTTPServer.Start()
+}
+
+// startTLSGateway 启动 TLS 网关，收到 SIGHUP 时立即重新加载证书，未启用时跳过
+func startTLSGateway() error {
+	tlsConfig := conf.GetTLSConfig()
+	if !tlsConfig.Enabled {
+		return nil
+	}
+	gateway, err := tlsgateway.NewGateway(tlsConfig)
+	if err != nil {
+		return err
+	}
+	if err := gateway.Start(); err != nil {
+		return err
+	}
+	global.TLSGateway = gateway
+
+	if !tlsConfig.PlaintextLoopbackOnly {
+		fmt.Printf("警告: 明文端口仍接受外部连接，可开启 TLS.PlaintextLoopbackOnly 或将 zinx.json 的 Host 设为 127.0.0.1\n")
+	}
+
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	go func() {
+		for range hup {
+			if err := gateway.Reload(); err != nil {
+				fmt.Printf("重新加载 TLS 证书失败，继续使用原证书: %v\n", err)
+			}
+		}
+	}()
+	return nil
+}
+
+// rejectPlaintext 开启 TLS.PlaintextLoopbackOnly 后，只允许本机（包括 TLS 网关）直接连接明文端口
+func rejectPlaintext(conn ziface.IConnection) bool {
+	if global.TLSGateway == nil || !conf.GetTLSConfig().PlaintextLoopbackOnly {
+		return false
+	}
+	host, _, err := net.SplitHostPort(conn.RemoteAddr().String())
+	if err != nil {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip == nil || !ip.IsLoopback()
 }
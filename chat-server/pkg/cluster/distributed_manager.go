@@ -25,6 +25,7 @@ type DistributedManager struct {
 	groupSubs     map[string]bool
 	mutex         sync.RWMutex
 	isRunning     bool
+	deregistered  bool
 }
 
 func NewDistributedManager(serverID, serverAddr string, serverPort int) (*DistributedManager, error) {
@@ -176,10 +177,27 @@ func (dm *DistributedManager) SendCrossServerMessage(targetUserUUID string, mess
 		return fmt.Errorf("user is on local server")
 	}
 
+	if err := checkPrivateChatAllowed(message.FromUserID, targetUserUUID); err != nil {
+		return err
+	}
+
 	log.Printf("Sending cross-server message to user %s on server %s", targetUserUUID, targetServer)
 	return dm.natsService.SendP2PMessage(targetUserUUID, message)
 }
 
+// checkPrivateChatAllowed 检查屏蔽关系和接收方的私聊隐私设置，发送和投递跨服务器私聊时都需要检查
+func checkPrivateChatAllowed(fromUserUUID, toUserUUID string) error {
+	fromUser, err := global.UserService.GetUserByUUID(fromUserUUID)
+	if err != nil {
+		return fmt.Errorf("failed to get sender %s: %w", fromUserUUID, err)
+	}
+	toUser, err := global.UserService.GetUserByUUID(toUserUUID)
+	if err != nil {
+		return fmt.Errorf("failed to get receiver %s: %w", toUserUUID, err)
+	}
+	return global.FriendService.CheckPrivateChatAllowed(fromUser.ID, toUser.ID)
+}
+
 // 发送群组消息
 func (dm *DistributedManager) SendGroupMessage(groupID string, message *model.GroupTextMsgReq) error {
 	log.Printf("Sending group message to group %s", groupID)
@@ -229,6 +247,13 @@ func (dm *DistributedManager) handleP2PMessage(msg *messaging.CrossServerMessage
 		return
 	}
 
+	// 发送方节点检查后屏蔽关系或隐私设置可能已变化，投递前再检查一次
+	fromUserUUID, _ := msgData["from_user_id"].(string)
+	if err := checkPrivateChatAllowed(fromUserUUID, msg.TargetUserID); err != nil {
+		log.Printf("Dropped P2P message from %s to %s: %v", fromUserUUID, msg.TargetUserID, err)
+		return
+	}
+
 	// 查找本地连接
 	connManager := global.GlobalServer.GetConnManager()
 	found := false
@@ -238,7 +263,7 @@ func (dm *DistributedManager) handleP2PMessage(msg *messaging.CrossServerMessage
 			if userUUIDStr, ok := userUUIDProp.(string); ok && userUUIDStr == msg.TargetUserID {
 				// 找到目标用户，转发消息
 				jsonData, _ := json.Marshal(msgData)
-				err := conn.SendMsg(protocol.MsgIDTextMsg, jsonData)
+				err := global.Sessions.SendMsg(conn, protocol.MsgIDTextMsg, jsonData)
 				if err != nil {
 					log.Printf("Failed to forward P2P message: %v", err)
 				} else {
@@ -265,42 +290,23 @@ func (dm *DistributedManager) handleGroupMessage(msg *messaging.CrossServerMessa
 		return
 	}
 
-	// 获取本服务器上该群组的在线成员
-	connManager := global.GlobalServer.GetConnManager()
-	memberCount := 0
-
-	for _, conn := range connManager.All() {
-		// 检查用户是否是群组成员
-		if userIDProp, err := conn.GetProperty("userID"); err == nil {
-			userID, ok := userIDProp.(uint)
-			if !ok {
-				continue
-			}
-
-			// 检查用户是否在该群组中
-			groupIDUint, err := strconv.ParseUint(groupID, 10, 32)
-			if err != nil {
-				log.Printf("Invalid group ID: %s", groupID)
-				continue
-			}
-
-			isMember, err := global.GroupService.IsUserInGroup(userID, uint(groupIDUint))
-			if err != nil || !isMember {
-				continue
-			}
+	groupIDUint, err := strconv.ParseUint(groupID, 10, 32)
+	if err != nil {
+		log.Printf("Invalid group ID: %s", groupID)
+		return
+	}
 
-			// 转发群组消息
-			jsonData, _ := json.Marshal(msgData)
-			err = conn.SendMsg(protocol.MsgIDGroupTextMsgResp, jsonData)
-			if err != nil {
-				log.Printf("Failed to forward group message: %v", err)
-			} else {
-				memberCount++
-			}
-		}
+	// 获取群组成员（优先走缓存），由推送协程池推送给本服务器上的在线成员
+	memberIDs, err := global.GroupService.GetGroupMemberIDs(uint(groupIDUint))
+	if err != nil {
+		log.Printf("Failed to get member IDs for group %s: %v", groupID, err)
+		return
 	}
 
-	log.Printf("Forwarded group message to %d members on this server", memberCount)
+	jsonData, _ := json.Marshal(msgData)
+	memberCount := global.GroupFanout.Dispatch(uint(groupIDUint), protocol.MsgIDGroupTextMsgResp, jsonData, memberIDs)
+
+	log.Printf("Queued group message for %d members on this server", memberCount)
 }
 
 // 获取集群状态
@@ -409,15 +415,31 @@ func (dm *DistributedManager) BroadcastSystemMessage(messageType, content string
 	return dm.natsService.PublishSystemEvent("system_broadcast", string(data))
 }
 
-// 关闭分布式管理器
-func (dm *DistributedManager) Stop() error {
+// PublishSessionRevoked 通知其他节点关闭该用户的连接，用于修改密码、注销账号等需要全局下线的场景
+func (dm *DistributedManager) PublishSessionRevoked(userID uint, reason string) error {
+	return dm.natsService.PublishSessionRevoked(userID, reason)
+}
+
+// SubscribeSessionRevoked 收到其他节点的会话作废通知时调用 handler
+func (dm *DistributedManager) SubscribeSessionRevoked(handler func(userID uint, reason string)) error {
+	return dm.natsService.SubscribeSessionRevoked(handler)
+}
+
+// Deregister 从 Consul 注销本节点并通知其他节点，之后不再被分配新连接
+// NATS 连接保留到 Stop，节点关闭期间仍可投递跨节点消息
+func (dm *DistributedManager) Deregister() error {
+	dm.mutex.Lock()
+	if dm.deregistered {
+		dm.mutex.Unlock()
+		return nil
+	}
+	dm.deregistered = true
 	dm.isRunning = false
 
 	// 发布服务停止事件
 	dm.natsService.PublishSystemEvent("server_stopping", dm.serverID)
 
 	// 清理所有用户订阅
-	dm.mutex.Lock()
 	for userUUID := range dm.userSubs {
 		dm.consulService.SetUserOffline(userUUID)
 	}
@@ -425,6 +447,14 @@ func (dm *DistributedManager) Stop() error {
 
 	// 注销服务
 	if err := dm.consulService.DeregisterService(); err != nil {
+		return fmt.Errorf("failed to deregister service: %w", err)
+	}
+	return nil
+}
+
+// 关闭分布式管理器
+func (dm *DistributedManager) Stop() error {
+	if err := dm.Deregister(); err != nil {
 		log.Printf("Failed to deregister service: %v", err)
 	}
 
@@ -0,0 +1,115 @@
+package fanout
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/Xaytick/chat-zinx/chat-server/pkg/framing"
+)
+
+// Pusher 按用户推送消息，用户不在当前节点上时返回 false
+type Pusher interface {
+	PushFrame(userID uint, f *framing.Frame) bool
+}
+
+// job 一个推送批次：把同一条消息发给一批用户
+type job struct {
+	groupID uint
+	frame   *framing.Frame // 所有批次共用，消息体只压缩一次
+	userIDs []uint
+}
+
+// Dispatcher 群消息推送协程池
+// 大群的成员列表被切分成批次投递到任务队列，由固定数量的 worker 并发推送，
+// 避免在 zinx 的请求 worker 中同步遍历上万个成员。
+type Dispatcher struct {
+	pusher    Pusher
+	jobs      chan *job
+	workers   int
+	batchSize int
+
+	mu      sync.RWMutex // 保护 stopped，避免向已关闭的队列投递
+	stopped bool
+	wg      sync.WaitGroup
+}
+
+// NewDispatcher 创建群消息推送协程池
+func NewDispatcher(pusher Pusher, workers, queueSize, batchSize int) *Dispatcher {
+	if workers <= 0 {
+		workers = 1
+	}
+	if batchSize <= 0 {
+		batchSize = 1
+	}
+	return &Dispatcher{
+		pusher:    pusher,
+		jobs:      make(chan *job, queueSize),
+		workers:   workers,
+		batchSize: batchSize,
+	}
+}
+
+// Start 启动推送 worker
+func (d *Dispatcher) Start() {
+	for i := 0; i < d.workers; i++ {
+		d.wg.Add(1)
+		go d.worker()
+	}
+	fmt.Printf("[Fanout] Started %d workers, batch size %d, queue size %d\n", d.workers, d.batchSize, cap(d.jobs))
+}
+
+// Stop 停止接收新任务，并等待队列中已有的任务推送完成
+func (d *Dispatcher) Stop() {
+	d.mu.Lock()
+	if d.stopped {
+		d.mu.Unlock()
+		return
+	}
+	d.stopped = true
+	close(d.jobs)
+	d.mu.Unlock()
+
+	d.wg.Wait()
+	fmt.Println("[Fanout] All workers stopped")
+}
+
+// Dispatch 将消息按批次投递给推送 worker，返回投递的用户数
+// 队列已满时由调用方直接推送该批次，以此向上游施加背压而不是丢弃消息
+func (d *Dispatcher) Dispatch(groupID uint, msgID uint32, data []byte, userIDs []uint) int {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	frame := framing.NewFrame(msgID, data)
+	for start := 0; start < len(userIDs); start += d.batchSize {
+		end := start + d.batchSize
+		if end > len(userIDs) {
+			end = len(userIDs)
+		}
+		j := &job{groupID: groupID, frame: frame, userIDs: userIDs[start:end]}
+
+		if d.stopped {
+			d.push(j)
+			continue
+		}
+		select {
+		case d.jobs <- j:
+		default:
+			d.push(j)
+		}
+	}
+	return len(userIDs)
+}
+
+func (d *Dispatcher) worker() {
+	defer d.wg.Done()
+	for j := range d.jobs {
+		d.push(j)
+	}
+}
+
+// push 向一个批次内的用户推送消息，不在当前节点上的用户直接跳过
+func (d *Dispatcher) push(j *job) {
+	for _, userID := range j.userIDs {
+		d.pusher.PushFrame(userID, j.frame)
+	}
+}
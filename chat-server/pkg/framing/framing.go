@@ -0,0 +1,84 @@
+package framing
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/Xaytick/chat-zinx/chat-server/pkg/protocol"
+	"github.com/Xaytick/zinx/ziface"
+)
+
+// compressionKey 连接属性，握手协商出压缩算法后记录 *compression
+const compressionKey = "frameCompression"
+
+type compression struct {
+	algorithm string
+	threshold int
+}
+
+// EnableCompression 握手协商出压缩算法后调用，之后经 SendMsg 发往该连接且不小于 threshold 字节的消息体会被压缩
+// 需在握手响应发出之后调用，握手响应本身始终不压缩
+func EnableCompression(conn ziface.IConnection, algorithm string, threshold int) {
+	if algorithm == "" || algorithm == protocol.CompressionNone {
+		return
+	}
+	conn.SetProperty(compressionKey, &compression{algorithm: algorithm, threshold: threshold})
+}
+
+// SendMsg 向连接发送消息，连接协商了压缩且消息体足够大时压缩后发送，并在消息ID上置 protocol.CompressedFlag
+// 压缩失败或压缩后没有变小时按原样发送；同一条消息发往多个连接时应使用 Frame，避免重复压缩
+func SendMsg(conn ziface.IConnection, msgID uint32, data []byte) error {
+	return NewFrame(msgID, data).SendTo(conn)
+}
+
+// Frame 一条待发送的消息，按算法缓存压缩结果
+// 群消息等发往多个连接的推送共用一个 Frame，每种算法只压缩一次
+type Frame struct {
+	MsgID uint32
+	Data  []byte
+
+	mu         sync.Mutex
+	compressed map[string][]byte // 算法 -> 压缩结果，nil 表示压缩失败或没有变小
+}
+
+// NewFrame 创建待发送的消息，data 在发送期间不能被修改
+func NewFrame(msgID uint32, data []byte) *Frame {
+	return &Frame{MsgID: msgID, Data: data}
+}
+
+// SendTo 按连接协商的压缩算法发送消息
+func (f *Frame) SendTo(conn ziface.IConnection) error {
+	value, err := conn.GetProperty(compressionKey)
+	if err != nil || value == nil {
+		return conn.SendMsg(f.MsgID, f.Data)
+	}
+	c := value.(*compression)
+	if len(f.Data) < c.threshold {
+		return conn.SendMsg(f.MsgID, f.Data)
+	}
+	if compressed := f.compress(c.algorithm); compressed != nil {
+		return conn.SendMsg(f.MsgID|protocol.CompressedFlag, compressed)
+	}
+	return conn.SendMsg(f.MsgID, f.Data)
+}
+
+// compress 返回 algorithm 的压缩结果，首次调用时压缩并缓存；并发调用的发送方等待同一次压缩
+func (f *Frame) compress(algorithm string) []byte {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if compressed, ok := f.compressed[algorithm]; ok {
+		return compressed
+	}
+	compressed, err := protocol.Compress(algorithm, f.Data)
+	if err != nil {
+		fmt.Printf("[Framing] Failed to compress MsgID %d with %s: %v\n", f.MsgID, algorithm, err)
+		compressed = nil
+	} else if len(compressed) >= len(f.Data) {
+		compressed = nil
+	}
+	if f.compressed == nil {
+		f.compressed = make(map[string][]byte, 1)
+	}
+	f.compressed[algorithm] = compressed
+	return compressed
+}
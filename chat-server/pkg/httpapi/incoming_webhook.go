@@ -0,0 +1,65 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/Xaytick/chat-zinx/chat-server/pkg/model"
+	"github.com/Xaytick/chat-zinx/chat-server/pkg/service"
+)
+
+// IntegrationPublisher 以集成身份把消息发到群里，返回消息ID
+// 由 router 包实现，与 TCP 群消息走同一条保存和推送路径
+type IntegrationPublisher func(hook *model.IncomingWebhook, content string) (string, error)
+
+// IncomingWebhookHandler 处理 POST {IncomingWebhookPathPrefix}{token}
+// 令牌无效和已吊销都返回 404，不向调用方区分两种情况
+func IncomingWebhookHandler(svc service.IIncomingWebhookService, publish IntegrationPublisher, maxBodySize int64) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hook, err := svc.Authenticate(r.PathValue("token"))
+		if err != nil {
+			var throttled *service.ThrottledError
+			switch {
+			case errors.Is(err, service.ErrInvalidWebhookToken):
+				writeJSON(w, http.StatusNotFound, model.IncomingWebhookResult{Error: "webhook not found"})
+			case errors.As(err, &throttled):
+				w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(throttled.RetryAfter.Seconds()))))
+				writeJSON(w, http.StatusTooManyRequests, model.IncomingWebhookResult{Error: "rate limit exceeded"})
+			default:
+				// 限流依赖 Redis，Redis 不可用时拒绝请求，由调用方稍后重试
+				fmt.Printf("[HTTP] Failed to authenticate incoming webhook: %v\n", err)
+				w.Header().Set("Retry-After", "5")
+				writeJSON(w, http.StatusServiceUnavailable, model.IncomingWebhookResult{Error: "service unavailable"})
+			}
+			return
+		}
+
+		var payload model.IncomingWebhookPayload
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBodySize)).Decode(&payload); err != nil {
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				writeJSON(w, http.StatusRequestEntityTooLarge, model.IncomingWebhookResult{Error: "request body too large"})
+				return
+			}
+			writeJSON(w, http.StatusBadRequest, model.IncomingWebhookResult{Error: "invalid json"})
+			return
+		}
+		content, err := svc.FormatMessage(&payload)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, model.IncomingWebhookResult{Error: err.Error()})
+			return
+		}
+
+		msgID, err := publish(hook, content)
+		if err != nil {
+			fmt.Printf("[HTTP] Incoming webhook %d failed to publish to GroupID %d: %v\n", hook.ID, hook.GroupID, err)
+			writeJSON(w, http.StatusInternalServerError, model.IncomingWebhookResult{Error: "failed to publish message"})
+			return
+		}
+		writeJSON(w, http.StatusOK, model.IncomingWebhookResult{OK: true, MsgID: msgID})
+	})
+}
@@ -0,0 +1,30 @@
+package httpapi
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"github.com/Xaytick/chat-zinx/chat-server/pkg/session"
+)
+
+// PushQueueMetricsHandler 返回各连接发送队列的积压、慢消费者和丢弃计数，供监控采集
+// 请求需在 Authorization 头中携带配置的 HTTP.MetricsToken: "Bearer <token>"
+func PushQueueMetricsHandler(sessions *session.Manager, token string) http.Handler {
+	return requireMetricsToken(token, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, sessions.Stats())
+	}))
+}
+
+// requireMetricsToken 校验监控接口的 Bearer Token，失败时返回 401
+func requireMetricsToken(token string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || token == "" || subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="chat-zinx-metrics"`)
+			writeError(w, http.StatusUnauthorized, "invalid metrics token")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,16 @@
+package httpapi
+
+import (
+	_ "embed"
+	"net/http"
+)
+
+// openAPISpec REST 接口的 OpenAPI 3 文档，修改路由时需同步更新 openapi.json
+//
+//go:embed openapi.json
+var openAPISpec []byte
+
+func serveOpenAPI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	_, _ = w.Write(openAPISpec)
+}
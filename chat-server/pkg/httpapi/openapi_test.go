@@ -0,0 +1,74 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/Xaytick/chat-zinx/chat-server/conf"
+)
+
+// TestOpenAPIMatchesRoutes 核对 RESTAPI.Register 注册的路由与 openapi.json 中的路径和方法一一对应
+func TestOpenAPIMatchesRoutes(t *testing.T) {
+	var spec struct {
+		Servers []struct {
+			URL string `json:"url"`
+		} `json:"servers"`
+		Paths map[string]map[string]json.RawMessage `json:"paths"`
+	}
+	if err := json.Unmarshal(openAPISpec, &spec); err != nil {
+		t.Fatalf("invalid openapi.json: %v", err)
+	}
+	if len(spec.Servers) != 1 || spec.Servers[0].URL != RESTPrefix {
+		t.Fatalf("openapi.json servers = %+v, want a single %s", spec.Servers, RESTPrefix)
+	}
+
+	documented := make(map[string]bool)
+	for path, operations := range spec.Paths {
+		for method := range operations {
+			documented[strings.ToUpper(method)+" "+RESTPrefix+path] = true
+		}
+	}
+
+	s := NewServer(&conf.HTTPConfig{})
+	NewRESTAPI(nil, nil, nil, RESTHooks{}, 0).Register(s)
+	registered := make(map[string]bool)
+	for _, pattern := range s.patterns {
+		method, path, ok := strings.Cut(pattern, " ")
+		if !ok || !strings.HasPrefix(path, RESTPrefix+"/") {
+			continue
+		}
+		registered[pattern] = true
+
+		// 文档中的路径要能被 ServeMux 路由到同一个 pattern
+		req := httptest.NewRequest(method, path, nil)
+		if _, matched := s.mux.Handler(req); matched != pattern {
+			t.Errorf("%s %s routed to %q, want %q", method, path, matched, pattern)
+		}
+	}
+
+	if len(registered) == 0 {
+		t.Fatal("no REST routes registered")
+	}
+	for _, pattern := range sortedKeys(registered) {
+		if !documented[pattern] {
+			t.Errorf("route %q is not documented in openapi.json", pattern)
+		}
+	}
+	for _, pattern := range sortedKeys(documented) {
+		if !registered[pattern] {
+			t.Errorf("openapi.json documents %q but no such route is registered", pattern)
+		}
+	}
+}
+
+func sortedKeys(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,202 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/Xaytick/chat-zinx/chat-server/conf"
+	"github.com/Xaytick/chat-zinx/chat-server/pkg/model"
+	"github.com/Xaytick/chat-zinx/chat-server/pkg/service"
+)
+
+// RESTPrefix REST 接口的路径前缀，接口说明见 GET {RESTPrefix}/openapi.json
+const RESTPrefix = "/api/v1"
+
+// RESTHooks 需要通知 TCP 长连接的操作，由 router 包实现，保证与 TCP 路由的行为一致
+type RESTHooks struct {
+	// RecordLogin 记录登录尝试，新的IP或设备登录时提醒该用户已登录的连接
+	RecordLogin func(userID uint, username string, client *model.LoginClientInfo, loginErr error)
+	// ProfileChanged 资料修改后推送给在线好友
+	ProfileChanged func(userID uint, profile *model.UserProfile)
+}
+
+// RESTAPI 供非实时场景使用的 REST/JSON 接口，与 TCP 路由调用同一套 service
+// 除注册和登录外都需要在 Authorization 头中携带登录时签发的 JWT: "Bearer <token>"
+type RESTAPI struct {
+	users       service.IUserService
+	groups      service.IGroupService
+	messages    service.IMessageService
+	hooks       RESTHooks
+	maxBodySize int64
+}
+
+// NewRESTAPI 创建 REST 接口，通过 Register 注册到 HTTP 服务
+func NewRESTAPI(users service.IUserService, groups service.IGroupService, messages service.IMessageService,
+	hooks RESTHooks, maxBodySize int64) *RESTAPI {
+	return &RESTAPI{
+		users:       users,
+		groups:      groups,
+		messages:    messages,
+		hooks:       hooks,
+		maxBodySize: maxBodySize,
+	}
+}
+
+// authedHandler 已通过 JWT 认证的请求处理函数
+type authedHandler func(w http.ResponseWriter, r *http.Request, user *model.User)
+
+// Register 注册所有 REST 路由
+func (api *RESTAPI) Register(s *Server) {
+	s.Handle("GET "+RESTPrefix+"/openapi.json", http.HandlerFunc(serveOpenAPI))
+	s.Handle("POST "+RESTPrefix+"/auth/register", http.HandlerFunc(api.register))
+	s.Handle("POST "+RESTPrefix+"/auth/login", http.HandlerFunc(api.login))
+
+	s.Handle("GET "+RESTPrefix+"/users/me", api.auth(api.getMe))
+	s.Handle("PATCH "+RESTPrefix+"/users/me", api.auth(api.updateMe))
+	s.Handle("DELETE "+RESTPrefix+"/users/me", api.auth(api.requireCap(model.CapAccountSecurity, api.deleteMe)))
+	s.Handle("GET "+RESTPrefix+"/users", api.auth(api.searchUsers))
+	s.Handle("GET "+RESTPrefix+"/users/{user}", api.auth(api.getUser))
+
+	s.Handle("GET "+RESTPrefix+"/conversations", api.auth(api.requireCap(model.CapDirectMessage, api.listConversations)))
+	s.Handle("GET "+RESTPrefix+"/conversations/{user}/messages", api.auth(api.requireCap(model.CapDirectMessage, api.getConversationHistory)))
+
+	s.Handle("GET "+RESTPrefix+"/groups", api.auth(api.listGroups))
+	s.Handle("POST "+RESTPrefix+"/groups", api.auth(api.requireCap(model.CapCreateGroup, api.createGroup)))
+	s.Handle("GET "+RESTPrefix+"/groups/{id}", api.auth(api.getGroup))
+	s.Handle("PATCH "+RESTPrefix+"/groups/{id}", api.auth(api.updateGroup))
+	s.Handle("GET "+RESTPrefix+"/groups/{id}/members", api.auth(api.listGroupMembers))
+	s.Handle("POST "+RESTPrefix+"/groups/{id}/members", api.auth(api.joinGroup))
+	s.Handle("DELETE "+RESTPrefix+"/groups/{id}/members/{user}", api.auth(api.removeGroupMember))
+	s.Handle("GET "+RESTPrefix+"/groups/{id}/messages", api.auth(api.getGroupHistory))
+
+	s.Handle("POST "+RESTPrefix+"/admin/unlock", api.auth(api.requireAdmin(api.unlockAccount)))
+	s.Handle("GET "+RESTPrefix+"/admin/users/{user}", api.auth(api.requireAdmin(api.adminGetUser)))
+	s.Handle("GET "+RESTPrefix+"/admin/users/{user}/logins", api.auth(api.requireAdmin(api.adminGetLoginHistory)))
+}
+
+// auth 校验 Bearer Token，失败时返回 401
+func (api *RESTAPI) auth(next authedHandler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || strings.TrimSpace(token) == "" {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="chat-zinx"`)
+			writeError(w, http.StatusUnauthorized, "missing bearer token")
+			return
+		}
+		user, err := api.users.VerifyToken(strings.TrimSpace(token))
+		if err != nil {
+			if errors.Is(err, service.ErrInvalidToken) {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="chat-zinx", error="invalid_token"`)
+				writeError(w, http.StatusUnauthorized, "invalid or expired token")
+				return
+			}
+			fmt.Printf("[HTTP] Failed to verify token: %v\n", err)
+			writeError(w, http.StatusInternalServerError, "internal error")
+			return
+		}
+		next(w, r, user)
+	})
+}
+
+// requireCap 账号类型不具备 capability 时返回 403，与 TCP 的 router.RequireCapability 对应
+func (api *RESTAPI) requireCap(capability model.AccountCapability, next authedHandler) authedHandler {
+	return func(w http.ResponseWriter, r *http.Request, user *model.User) {
+		if !model.AccountKindHas(model.NormalizeAccountKind(user.AccountKind), capability) {
+			writeError(w, http.StatusForbidden, "not available for this account type")
+			return
+		}
+		next(w, r, user)
+	}
+}
+
+// requireAdmin 只允许配置中 Auth.Admins 列出的用户
+func (api *RESTAPI) requireAdmin(next authedHandler) authedHandler {
+	return func(w http.ResponseWriter, r *http.Request, user *model.User) {
+		if !conf.IsAdmin(user.Username) {
+			writeError(w, http.StatusForbidden, "admin permission required")
+			return
+		}
+		next(w, r, user)
+	}
+}
+
+// decodeBody 解析 JSON 请求体，失败时已写入 400 或 413
+func (api *RESTAPI) decodeBody(w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, api.maxBodySize)).Decode(v); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			writeError(w, http.StatusRequestEntityTooLarge, "request body too large")
+			return false
+		}
+		writeError(w, http.StatusBadRequest, "invalid json")
+		return false
+	}
+	return true
+}
+
+// writeServiceError 把 service 层的错误映射为 HTTP 状态码，未识别的错误按 fallback 状态返回
+func writeServiceError(w http.ResponseWriter, err error, fallback int) {
+	var throttled *service.ThrottledError
+	switch {
+	case errors.As(err, &throttled):
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(throttled.RetryAfter.Seconds()))))
+		writeError(w, http.StatusTooManyRequests, err.Error())
+	case errors.Is(err, service.ErrUserNotFound):
+		writeError(w, http.StatusNotFound, "user not found")
+	case errors.Is(err, service.ErrInvalidCredentials), errors.Is(err, service.ErrInvalidTwoFactorCode),
+		errors.Is(err, service.ErrPasswordIncorrect), errors.Is(err, service.ErrTwoFactorChallenge),
+		errors.Is(err, service.ErrTooManyTwoFactorTries):
+		writeError(w, http.StatusUnauthorized, err.Error())
+	case errors.Is(err, service.ErrNotGroupMember), errors.Is(err, service.ErrGroupPermissionDenied),
+		errors.Is(err, service.ErrNotAdmin), errors.Is(err, service.ErrAccountKindDenied),
+		errors.Is(err, service.ErrGuestGroupDenied):
+		writeError(w, http.StatusForbidden, err.Error())
+	case errors.Is(err, service.ErrUserAlreadyExists), errors.Is(err, service.ErrUsernameExists):
+		writeError(w, http.StatusConflict, err.Error())
+	default:
+		if fallback >= http.StatusInternalServerError {
+			fmt.Printf("[HTTP] Request failed: %v\n", err)
+			writeError(w, fallback, "internal error")
+			return
+		}
+		writeError(w, fallback, err.Error())
+	}
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{"error": message})
+}
+
+// pathID 解析路径中的数字ID，失败时已写入 400
+func pathID(w http.ResponseWriter, r *http.Request, name string) (uint, bool) {
+	id, err := strconv.ParseUint(r.PathValue(name), 10, 64)
+	if err != nil || id == 0 {
+		writeError(w, http.StatusBadRequest, "invalid "+name)
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// queryInt 读取整数查询参数，缺省或格式错误时返回 def
+func queryInt(r *http.Request, name string, def int) int {
+	value, err := strconv.Atoi(r.URL.Query().Get(name))
+	if err != nil {
+		return def
+	}
+	return value
+}
+
+// requestIP 请求的对端IP，用于登录和注册限流，不信任 X-Forwarded-For 等可伪造的请求头
+func requestIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
@@ -0,0 +1,53 @@
+package httpapi
+
+import (
+	"net/http"
+
+	"github.com/Xaytick/chat-zinx/chat-server/pkg/model"
+)
+
+// POST /admin/unlock 解除账号（以及可选的IP）的登录锁定
+func (api *RESTAPI) unlockAccount(w http.ResponseWriter, r *http.Request, user *model.User) {
+	var req model.UnlockAccountReq
+	if !api.decodeBody(w, r, &req) {
+		return
+	}
+	if req.Username == "" {
+		writeError(w, http.StatusBadRequest, "username is required")
+		return
+	}
+	wasLocked, err := api.users.UnlockAccount(user.ID, &req)
+	if err != nil {
+		writeServiceError(w, err, http.StatusBadRequest)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]bool{"was_locked": wasLocked})
+}
+
+// GET /admin/users/{user} 账号的完整记录，用于排查问题
+func (api *RESTAPI) adminGetUser(w http.ResponseWriter, r *http.Request, user *model.User) {
+	target, err := api.users.ResolveUser(r.PathValue("user"))
+	if err != nil {
+		writeServiceError(w, err, http.StatusBadRequest)
+		return
+	}
+	writeJSON(w, http.StatusOK, target)
+}
+
+// GET /admin/users/{user}/logins?limit=
+func (api *RESTAPI) adminGetLoginHistory(w http.ResponseWriter, r *http.Request, user *model.User) {
+	target, err := api.users.ResolveUser(r.PathValue("user"))
+	if err != nil {
+		writeServiceError(w, err, http.StatusBadRequest)
+		return
+	}
+	records, err := api.users.GetLoginHistory(target.ID, queryInt(r, "limit", 0))
+	if err != nil {
+		writeServiceError(w, err, http.StatusInternalServerError)
+		return
+	}
+	if records == nil {
+		records = []*model.LoginRecord{}
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"user_id": target.ID, "records": records})
+}
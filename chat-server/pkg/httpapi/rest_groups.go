@@ -0,0 +1,235 @@
+package httpapi
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/Xaytick/chat-zinx/chat-server/conf"
+	"github.com/Xaytick/chat-zinx/chat-server/pkg/model"
+)
+
+// restGroupDetails 群组详情，字段与 TCP 的群组详情响应一致
+type restGroupDetails struct {
+	ID             uint                         `json:"id"`
+	Name           string                       `json:"name"`
+	OwnerUserID    uint                         `json:"owner_user_id"`
+	Description    string                       `json:"description"`
+	Avatar         string                       `json:"avatar"`
+	MemberCount    uint                         `json:"member_count"`
+	Tier           string                       `json:"tier"`
+	MemberLimit    int                          `json:"member_limit"`
+	IsPublic       bool                         `json:"is_public"`
+	Category       string                       `json:"category"`
+	Tags           []string                     `json:"tags"`
+	CreatedAt      string                       `json:"created_at"`
+	UpdatedAt      string                       `json:"updated_at"`
+	Announcement   *model.GroupAnnouncementInfo `json:"announcement,omitempty"`
+	PinnedMessages []*model.GroupPinnedMsgInfo  `json:"pinned_messages"`
+}
+
+// GET /groups 当前用户加入的群组
+func (api *RESTAPI) listGroups(w http.ResponseWriter, r *http.Request, user *model.User) {
+	groups, err := api.groups.GetUserGroups(user.ID)
+	if err != nil {
+		writeServiceError(w, err, http.StatusInternalServerError)
+		return
+	}
+	infos := make([]*model.GroupBasicInfo, 0, len(groups))
+	for _, group := range groups {
+		infos = append(infos, &model.GroupBasicInfo{
+			ID:          group.ID,
+			Name:        group.Name,
+			MemberCount: group.MemberCount,
+			Description: group.Description,
+		})
+	}
+	writeJSON(w, http.StatusOK, model.GetUserGroupsResp{Groups: infos, Total: len(infos)})
+}
+
+// POST /groups
+func (api *RESTAPI) createGroup(w http.ResponseWriter, r *http.Request, user *model.User) {
+	var req model.CreateGroupReq
+	if !api.decodeBody(w, r, &req) {
+		return
+	}
+	group, err := api.groups.CreateGroup(user.ID, &req)
+	if err != nil {
+		writeServiceError(w, err, http.StatusBadRequest)
+		return
+	}
+	writeJSON(w, http.StatusCreated, model.CreateGroupResp{
+		ID:          group.ID,
+		Name:        group.Name,
+		OwnerUserID: group.OwnerUserID,
+		Description: group.Description,
+		Avatar:      group.Avatar,
+		MemberCount: group.MemberCount,
+		CreatedAt:   group.CreatedAt.Format("2006-01-02 15:04:05"),
+	})
+}
+
+// GET /groups/{id} 只有群成员可以查看
+func (api *RESTAPI) getGroup(w http.ResponseWriter, r *http.Request, user *model.User) {
+	groupID, ok := api.memberGroupID(w, r, user)
+	if !ok {
+		return
+	}
+	group, err := api.groups.GetGroupDetails(groupID)
+	if err != nil {
+		writeServiceError(w, err, http.StatusInternalServerError)
+		return
+	}
+	if group == nil {
+		writeError(w, http.StatusNotFound, "group not found")
+		return
+	}
+
+	// 公告、置顶消息和标签获取失败不影响详情返回
+	announcement, err := api.groups.GetGroupAnnouncement(groupID)
+	if err != nil {
+		fmt.Printf("[HTTP] Failed to get announcement for group %d: %v\n", groupID, err)
+	}
+	pinned, err := api.groups.GetGroupPinnedMessages(groupID)
+	if err != nil || pinned == nil {
+		pinned = []*model.GroupPinnedMsgInfo{}
+	}
+	tags, err := api.groups.GetGroupTags(groupID)
+	if err != nil || tags == nil {
+		tags = []string{}
+	}
+
+	writeJSON(w, http.StatusOK, restGroupDetails{
+		ID:             group.ID,
+		Name:           group.Name,
+		OwnerUserID:    group.OwnerUserID,
+		Description:    group.Description,
+		Avatar:         group.Avatar,
+		MemberCount:    group.MemberCount,
+		Tier:           group.Tier,
+		MemberLimit:    conf.GetGroupMemberLimit(group.Tier),
+		IsPublic:       group.IsPublic,
+		Category:       group.Category,
+		Tags:           tags,
+		CreatedAt:      group.CreatedAt.Format("2006-01-02 15:04:05"),
+		UpdatedAt:      group.UpdatedAt.Format("2006-01-02 15:04:05"),
+		Announcement:   announcement,
+		PinnedMessages: pinned,
+	})
+}
+
+// PATCH /groups/{id} 权限由 service 按群角色检查
+func (api *RESTAPI) updateGroup(w http.ResponseWriter, r *http.Request, user *model.User) {
+	groupID, ok := pathID(w, r, "id")
+	if !ok {
+		return
+	}
+	var req model.UpdateGroupInfoReq
+	if !api.decodeBody(w, r, &req) {
+		return
+	}
+	req.GroupID = groupID
+	if err := api.groups.UpdateGroupInfo(user.ID, groupID, &req); err != nil {
+		writeServiceError(w, err, http.StatusBadRequest)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GET /groups/{id}/members?page=&page_size=&keyword=
+func (api *RESTAPI) listGroupMembers(w http.ResponseWriter, r *http.Request, user *model.User) {
+	groupID, ok := api.memberGroupID(w, r, user)
+	if !ok {
+		return
+	}
+	resp, err := api.groups.GetGroupMembersPage(&model.GetGroupMembersReq{
+		GroupID:  groupID,
+		Page:     queryInt(r, "page", 1),
+		PageSize: queryInt(r, "page_size", 0),
+		Keyword:  r.URL.Query().Get("keyword"),
+	})
+	if err != nil {
+		writeServiceError(w, err, http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// POST /groups/{id}/members 当前用户加入群组
+func (api *RESTAPI) joinGroup(w http.ResponseWriter, r *http.Request, user *model.User) {
+	groupID, ok := pathID(w, r, "id")
+	if !ok {
+		return
+	}
+	if err := api.groups.JoinGroup(user.ID, &model.JoinGroupReq{GroupID: groupID}); err != nil {
+		writeServiceError(w, err, http.StatusBadRequest)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// DELETE /groups/{id}/members/{user}，user 为 "me" 或自己的ID时退出群组，否则按管理权限移除成员
+func (api *RESTAPI) removeGroupMember(w http.ResponseWriter, r *http.Request, user *model.User) {
+	groupID, ok := pathID(w, r, "id")
+	if !ok {
+		return
+	}
+	var err error
+	if target := r.PathValue("user"); target == "me" || target == strconv.FormatUint(uint64(user.ID), 10) {
+		err = api.groups.LeaveGroup(user.ID, &model.LeaveGroupReq{GroupID: groupID})
+	} else {
+		targetID, ok := pathID(w, r, "user")
+		if !ok {
+			return
+		}
+		err = api.groups.RemoveMemberFromGroup(user.ID, groupID, targetID)
+	}
+	if err != nil {
+		writeServiceError(w, err, http.StatusBadRequest)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GET /groups/{id}/messages?before=&limit= 从 before 之前的消息开始向前翻页
+func (api *RESTAPI) getGroupHistory(w http.ResponseWriter, r *http.Request, user *model.User) {
+	groupID, ok := pathID(w, r, "id")
+	if !ok {
+		return
+	}
+	limit := queryInt(r, "limit", 20)
+	if limit <= 0 {
+		limit = 20
+	} else if limit > 100 {
+		limit = 100
+	}
+	before := queryInt(r, "before", 0)
+	if before < 0 {
+		before = 0
+	}
+
+	resp, err := api.messages.GetGroupHistory(user.ID, groupID, uint(before), limit)
+	if err != nil {
+		writeServiceError(w, err, http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// memberGroupID 解析路径中的群组ID并确认当前用户是群成员，失败时已写入响应
+func (api *RESTAPI) memberGroupID(w http.ResponseWriter, r *http.Request, user *model.User) (uint, bool) {
+	groupID, ok := pathID(w, r, "id")
+	if !ok {
+		return 0, false
+	}
+	isMember, err := api.groups.IsUserInGroup(user.ID, groupID)
+	if err != nil {
+		writeServiceError(w, err, http.StatusInternalServerError)
+		return 0, false
+	}
+	if !isMember {
+		writeError(w, http.StatusForbidden, "not a member of this group")
+		return 0, false
+	}
+	return groupID, true
+}
@@ -0,0 +1,228 @@
+package httpapi
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/Xaytick/chat-zinx/chat-server/pkg/model"
+	"github.com/Xaytick/chat-zinx/chat-server/pkg/service"
+)
+
+// restLoginReq HTTP 登录请求
+// 开启两步验证的账号分两步登录：先提交用户名和密码，得到 two_factor_challenge；
+// 再提交 two_factor_challenge 和 two_factor_code，不需要重复提交密码
+type restLoginReq struct {
+	model.UserLoginReq
+	TwoFactorChallenge string `json:"two_factor_challenge,omitempty"`
+	TwoFactorCode      string `json:"two_factor_code,omitempty"`
+}
+
+// POST /auth/register
+func (api *RESTAPI) register(w http.ResponseWriter, r *http.Request) {
+	var req model.UserRegisterReq
+	if !api.decodeBody(w, r, &req) {
+		return
+	}
+	if req.Username == "" || req.Password == "" || req.Email == "" {
+		writeError(w, http.StatusBadRequest, "username, password and email are required")
+		return
+	}
+	req.ClientIP = requestIP(r)
+
+	user, err := api.users.Register(&req)
+	if err != nil {
+		writeServiceError(w, err, http.StatusBadRequest)
+		return
+	}
+	writeJSON(w, http.StatusCreated, model.UserRegisterResponse{
+		ID:       user.ID,
+		UserUUID: user.UserUUID,
+		Username: user.Username,
+		Email:    user.Email,
+		Avatar:   user.Avatar,
+	})
+}
+
+// POST /auth/login
+// 只签发 Token，不建立长连接，因此登录后恢复账号原来的在线状态
+func (api *RESTAPI) login(w http.ResponseWriter, r *http.Request) {
+	var req restLoginReq
+	if !api.decodeBody(w, r, &req) {
+		return
+	}
+	req.ClientIP = requestIP(r)
+	client := &model.LoginClientInfo{IP: req.ClientIP, ClientVersion: req.ClientVersion, DeviceID: req.DeviceID}
+
+	var (
+		recordUserID uint
+		token        string
+		user         *model.User
+		err          error
+	)
+	if req.TwoFactorChallenge != "" {
+		if req.TwoFactorCode == "" {
+			writeError(w, http.StatusBadRequest, "two_factor_code is required")
+			return
+		}
+		recordUserID, token, user, err = api.users.VerifyTwoFactorChallenge(req.TwoFactorChallenge, req.TwoFactorCode, req.ClientIP)
+	} else {
+		if req.Username == "" || req.Password == "" {
+			writeError(w, http.StatusBadRequest, "username and password are required")
+			return
+		}
+		token, user, err = api.users.LoginForToken(&req.UserLoginReq)
+		if errors.Is(err, service.ErrTwoFactorRequired) {
+			// 验证码只能凭挑战令牌提交，每个令牌的尝试次数有上限，不能随密码一起反复提交
+			challenge, err := api.users.CreateTwoFactorChallenge(user.ID)
+			if err != nil {
+				writeServiceError(w, err, http.StatusInternalServerError)
+				return
+			}
+			writeJSON(w, http.StatusUnauthorized, map[string]interface{}{
+				"error":                "two-factor code required",
+				"two_factor_required":  true,
+				"two_factor_challenge": challenge,
+				"expires_in":           int(model.TwoFactorChallengeTTL.Seconds()),
+			})
+			return
+		}
+	}
+	if err != nil {
+		api.recordLogin(recordUserID, req.Username, client, err)
+		writeServiceError(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	api.recordLogin(user.ID, user.Username, client, nil)
+
+	writeJSON(w, http.StatusOK, model.UserLoginResponse{
+		ID:            user.ID,
+		UserUUID:      user.UserUUID,
+		Username:      user.Username,
+		Email:         user.Email,
+		Avatar:        user.Avatar,
+		LastLogin:     user.LastLogin,
+		LastLoginIP:   user.LastLoginIP,
+		Token:         token,
+		EmailVerified: user.EmailVerified,
+		DeletionDueAt: user.DeletionDueAt,
+		AccountKind:   model.NormalizeAccountKind(user.AccountKind),
+	})
+}
+
+func (api *RESTAPI) recordLogin(userID uint, username string, client *model.LoginClientInfo, loginErr error) {
+	if api.hooks.RecordLogin != nil {
+		api.hooks.RecordLogin(userID, username, client, loginErr)
+	}
+}
+
+// GET /users/me
+func (api *RESTAPI) getMe(w http.ResponseWriter, r *http.Request, user *model.User) {
+	profile, err := api.users.GetProfile(user.ID, "")
+	if err != nil {
+		writeServiceError(w, err, http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, profile)
+}
+
+// PATCH /users/me
+func (api *RESTAPI) updateMe(w http.ResponseWriter, r *http.Request, user *model.User) {
+	var req model.UpdateProfileReq
+	if !api.decodeBody(w, r, &req) {
+		return
+	}
+	profile, err := api.users.UpdateProfile(user.ID, &req)
+	if err != nil {
+		writeServiceError(w, err, http.StatusBadRequest)
+		return
+	}
+	if api.hooks.ProfileChanged != nil {
+		api.hooks.ProfileChanged(user.ID, profile)
+	}
+	writeJSON(w, http.StatusOK, profile)
+}
+
+// DELETE /users/me 申请注销，冷静期内可通过 TCP 客户端撤销
+func (api *RESTAPI) deleteMe(w http.ResponseWriter, r *http.Request, user *model.User) {
+	var req model.DeleteAccountReq
+	if !api.decodeBody(w, r, &req) {
+		return
+	}
+	status, err := api.users.RequestAccountDeletion(user.ID, &req)
+	if err != nil {
+		writeServiceError(w, err, http.StatusBadRequest)
+		return
+	}
+	writeJSON(w, http.StatusAccepted, status)
+}
+
+// GET /users?keyword=&page=&page_size=
+func (api *RESTAPI) searchUsers(w http.ResponseWriter, r *http.Request, user *model.User) {
+	req := &model.SearchUsersReq{
+		Keyword:  r.URL.Query().Get("keyword"),
+		Page:     queryInt(r, "page", 1),
+		PageSize: queryInt(r, "page_size", 0),
+	}
+	if req.Keyword == "" {
+		writeError(w, http.StatusBadRequest, "keyword is required")
+		return
+	}
+	resp, err := api.users.SearchUsers(user.ID, req)
+	if err != nil {
+		writeServiceError(w, err, http.StatusBadRequest)
+		return
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// GET /users/{user}，user 可以是 UUID、用户名或数字ID
+func (api *RESTAPI) getUser(w http.ResponseWriter, r *http.Request, user *model.User) {
+	profile, err := api.users.GetProfile(user.ID, r.PathValue("user"))
+	if err != nil {
+		writeServiceError(w, err, http.StatusBadRequest)
+		return
+	}
+	writeJSON(w, http.StatusOK, profile)
+}
+
+// GET /conversations 有过私聊的用户ID列表
+func (api *RESTAPI) listConversations(w http.ResponseWriter, r *http.Request, user *model.User) {
+	relations, err := api.messages.GetChatRelations(user.ID)
+	if err != nil {
+		writeServiceError(w, err, http.StatusInternalServerError)
+		return
+	}
+	if relations == nil {
+		relations = []string{}
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"user_ids": relations})
+}
+
+// GET /conversations/{user}/messages?limit= 最近的私聊记录，与 TCP 一样最多 200 条
+func (api *RESTAPI) getConversationHistory(w http.ResponseWriter, r *http.Request, user *model.User) {
+	peer, err := api.users.ResolveUser(r.PathValue("user"))
+	if err != nil {
+		writeServiceError(w, err, http.StatusBadRequest)
+		return
+	}
+	limit := queryInt(r, "limit", 50)
+	if limit <= 0 {
+		limit = 50
+	} else if limit > 200 {
+		limit = 200
+	}
+
+	messages, err := api.messages.GetHistoryMessages(user.ID, peer.ID, limit)
+	if err != nil {
+		writeServiceError(w, err, http.StatusInternalServerError)
+		return
+	}
+	if messages == nil {
+		messages = []map[string]interface{}{}
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"peer":     &model.UserBasicInfo{ID: peer.ID, UserUUID: peer.UserUUID, Username: peer.Username},
+		"messages": messages,
+	})
+}
@@ -0,0 +1,68 @@
+package httpapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/Xaytick/chat-zinx/chat-server/conf"
+)
+
+// Server chat-server 内置的 HTTP 服务，与 TCP 服务共用同一套 service，供不保持长连接的外部系统调用
+type Server struct {
+	cfg *conf.HTTPConfig
+	mux *http.ServeMux
+	srv *http.Server
+	// patterns 已注册的路由，用于核对 OpenAPI 文档
+	patterns []string
+}
+
+// NewServer 创建 HTTP 服务，路由通过 Handle 注册后再调用 Start，GET /health 始终可用
+func NewServer(cfg *conf.HTTPConfig) *Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /health", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+	})
+	return &Server{
+		cfg: cfg,
+		mux: mux,
+		srv: &http.Server{
+			Addr:              cfg.Addr,
+			Handler:           mux,
+			ReadHeaderTimeout: 5 * time.Second,
+			ReadTimeout:       time.Duration(cfg.ReadTimeout) * time.Second,
+			WriteTimeout:      time.Duration(cfg.WriteTimeout) * time.Second,
+		},
+	}
+}
+
+// Handle 注册路由，pattern 使用 net/http 的 "METHOD /path/{param}" 格式
+func (s *Server) Handle(pattern string, handler http.Handler) {
+	s.mux.Handle(pattern, handler)
+	s.patterns = append(s.patterns, pattern)
+}
+
+// Start 在后台启动监听，监听失败只记录日志，不影响 TCP 服务
+func (s *Server) Start() {
+	go func() {
+		fmt.Printf("[HTTP] Listening on %s\n", s.cfg.Addr)
+		if err := s.srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			fmt.Printf("[HTTP] Server stopped with error: %v\n", err)
+		}
+	}()
+}
+
+// Stop 停止接收新请求，并等待处理中的请求完成或 ctx 到期
+func (s *Server) Stop(ctx context.Context) error {
+	return s.srv.Shutdown(ctx)
+}
+
+// writeJSON 写入 JSON 响应
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,42 @@
+package mailer
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+// FileMailer 把邮件追加写入文件，路径为空时输出到日志，用于开发和测试环境
+type FileMailer struct {
+	path string
+	mu   sync.Mutex
+}
+
+// NewFileMailer 创建文件邮件发送器
+func NewFileMailer(path string) *FileMailer {
+	return &FileMailer{path: path}
+}
+
+// Send 记录邮件
+func (m *FileMailer) Send(msg *Message) error {
+	entry := fmt.Sprintf("=== %s\nTo: %s\nSubject: %s\n\n%s\n\n",
+		time.Now().Format("2006-01-02 15:04:05"), msg.To, msg.Subject, msg.Body)
+	if m.path == "" {
+		log.Printf("[Mail] %s", entry)
+		return nil
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	f, err := os.OpenFile(m.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to open mail file: %w", err)
+	}
+	defer f.Close()
+	if _, err := f.WriteString(entry); err != nil {
+		return fmt.Errorf("failed to write mail file: %w", err)
+	}
+	return nil
+}
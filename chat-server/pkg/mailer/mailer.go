@@ -0,0 +1,36 @@
+package mailer
+
+import (
+	"fmt"
+
+	"github.com/Xaytick/chat-zinx/chat-server/conf"
+)
+
+// Message 一封纯文本邮件
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer 邮件发送接口，生产环境使用 SMTP，开发和测试环境使用文件或日志
+type Mailer interface {
+	Send(msg *Message) error
+}
+
+// New 根据配置创建邮件发送器
+func New(cfg *conf.MailConfig) (Mailer, error) {
+	switch cfg.Driver {
+	case "smtp":
+		return NewSMTPMailer(cfg)
+	case "file":
+		if cfg.FilePath == "" {
+			return nil, fmt.Errorf("mail driver 'file' requires FilePath")
+		}
+		return NewFileMailer(cfg.FilePath), nil
+	case "log", "":
+		return NewFileMailer(""), nil
+	default:
+		return nil, fmt.Errorf("unknown mail driver: %s", cfg.Driver)
+	}
+}
@@ -265,6 +265,53 @@ func (ns *NATSService) PublishSystemEvent(eventType, data string) error {
 	return err
 }
 
+// 会话作废通知的主题，不经过 JetStream：只需通知当前在线的节点，节点重启后已签发的令牌由 TokenVersion 失效
+const sessionRevokedSubject = "cluster.session.revoked"
+
+// SessionRevokedEvent 某个用户的会话被作废，各节点关闭该用户在本节点上的连接
+type SessionRevokedEvent struct {
+	UserID       uint   `json:"user_id"`
+	Reason       string `json:"reason"`
+	SourceServer string `json:"source_server"`
+}
+
+// PublishSessionRevoked 通知其他节点作废用户的会话
+func (ns *NATSService) PublishSessionRevoked(userID uint, reason string) error {
+	data, err := json.Marshal(&SessionRevokedEvent{UserID: userID, Reason: reason, SourceServer: ns.serverID})
+	if err != nil {
+		return fmt.Errorf("failed to marshal session revoked event: %w", err)
+	}
+	return ns.nc.Publish(sessionRevokedSubject, data)
+}
+
+// SubscribeSessionRevoked 订阅其他节点发出的会话作废通知
+func (ns *NATSService) SubscribeSessionRevoked(handler func(userID uint, reason string)) error {
+	ns.mutex.Lock()
+	defer ns.mutex.Unlock()
+
+	if _, exists := ns.subs[sessionRevokedSubject]; exists {
+		return nil
+	}
+
+	sub, err := ns.nc.Subscribe(sessionRevokedSubject, func(msg *nats.Msg) {
+		var event SessionRevokedEvent
+		if err := json.Unmarshal(msg.Data, &event); err != nil {
+			log.Printf("Failed to unmarshal session revoked event: %v", err)
+			return
+		}
+		// 发出通知的节点已在本地处理
+		if event.SourceServer != ns.serverID {
+			handler(event.UserID, event.Reason)
+		}
+	})
+	if err != nil {
+		return fmt.Errorf("failed to subscribe to session revoked events: %w", err)
+	}
+
+	ns.subs[sessionRevokedSubject] = sub
+	return nil
+}
+
 // 获取连接状态
 func (ns *NATSService) IsConnected() bool {
 	return ns.nc != nil && ns.nc.IsConnected()
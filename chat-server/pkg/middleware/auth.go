@@ -139,6 +139,11 @@ func (m *AuthMiddleware) Verify(request ziface.IRequest) (bool, *model.User, err
 				return false, nil, fmt.Errorf("获取用户信息失败 (JWT): %v", err)
 			}
 
+			// 修改或重置密码后 TokenVersion 递增，之前签发的 token 全部失效
+			if claims.TokenVersion != userInfo.TokenVersion {
+				return false, nil, errors.New("token已失效，请重新登录")
+			}
+
 			// 3. 验证Redis会话（如果启用）
 			if m.EnableRedisCheck {
 				if !m.verifyRedisSession(claims.ID, token) {
@@ -0,0 +1,62 @@
+package model
+
+import "time"
+
+// 一次性令牌的用途
+const (
+	TokenPurposePasswordReset = "password_reset"
+	TokenPurposeEmailVerify   = "email_verify"
+)
+
+// 密码长度限制，与注册时的校验保持一致
+const (
+	MinPasswordLen = 6
+	MaxPasswordLen = 50
+)
+
+// UserToken 通过邮件发送的一次性令牌，数据库只保存令牌的 SHA-256 摘要
+type UserToken struct {
+	ID        uint       `json:"id" gorm:"primarykey"`
+	UserID    uint       `json:"user_id" gorm:"not null;index:idx_user_token_user"`
+	Purpose   string     `json:"purpose" gorm:"type:varchar(20);not null;index:idx_user_token_user"`
+	TokenHash string     `json:"-" gorm:"type:char(64);not null;uniqueIndex"`
+	Email     string     `json:"email" gorm:"type:varchar(100)"` // 令牌发往的邮箱，邮箱验证时需与当前邮箱一致
+	ExpiresAt time.Time  `json:"expires_at" gorm:"not null"`
+	UsedAt    *time.Time `json:"used_at"` // 为空表示尚未使用
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// --- Request and Response Structs ---
+
+// ChangePasswordReq 修改密码请求，成功后该用户的其他会话会被下线
+type ChangePasswordReq struct {
+	OldPassword string `json:"old_password" binding:"required"`
+	NewPassword string `json:"new_password" binding:"required,min=6,max=50"`
+}
+
+// RequestPasswordResetReq 申请重置密码请求，无需登录
+type RequestPasswordResetReq struct {
+	Account string `json:"account" binding:"required"` // 用户名或邮箱
+}
+
+// ResetPasswordReq 使用邮件中的令牌重置密码，无需登录
+type ResetPasswordReq struct {
+	Token       string `json:"token" binding:"required"`
+	NewPassword string `json:"new_password" binding:"required,min=6,max=50"`
+}
+
+// VerifyEmailReq 使用邮件中的令牌验证邮箱
+type VerifyEmailReq struct {
+	Token string `json:"token" binding:"required"`
+}
+
+// SessionRevokedPush 会话失效推送，客户端收到后连接会被服务端关闭
+type SessionRevokedPush struct {
+	Reason string `json:"reason"`
+}
+
+// UnlockAccountReq 管理员解除登录锁定请求
+type UnlockAccountReq struct {
+	Username string `json:"username" binding:"required"`
+	IP       string `json:"ip,omitempty"` // 可选，同时解除该IP的锁定
+}
@@ -0,0 +1,95 @@
+package model
+
+import "time"
+
+// 账号注销后群消息的处理方式，见 conf.AccountConfig.MessagePolicy
+const (
+	DeletedMessagePolicyAnonymize = "anonymize" // 保留消息内容，发送者显示为 DeletedUserName
+	DeletedMessagePolicyDelete    = "delete"    // 删除该用户发送的所有群消息
+)
+
+// DeletedUserName 匿名化后群消息的发送者名称
+const DeletedUserName = "已注销用户"
+
+// 个人数据导出状态
+const (
+	DataExportPending = "pending"
+	DataExportReady   = "ready"
+	DataExportFailed  = "failed"
+)
+
+// DataExportChunkSize 下载导出文件时每个分片的字节数，base64 编码后需小于最大包长
+const DataExportChunkSize = 4096
+
+// DataExport 个人数据导出记录，导出文件保存在 conf.AccountConfig.ExportDir 下
+type DataExport struct {
+	ID        uint      `json:"id" gorm:"primarykey"`
+	UserID    uint      `json:"user_id" gorm:"not null;index"`
+	FileName  string    `json:"-" gorm:"type:varchar(100)"`
+	Status    string    `json:"status" gorm:"type:varchar(10);not null;default:'pending'"`
+	Size      int64     `json:"size" gorm:"not null;default:0"`
+	Error     string    `json:"error,omitempty" gorm:"type:varchar(255)"`
+	ExpiresAt time.Time `json:"expires_at" gorm:"index"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// --- Request and Response Structs ---
+
+// DeleteAccountReq 申请注销账号，开启了两步验证时还需提交验证码或备用恢复码
+type DeleteAccountReq struct {
+	Password string `json:"password" binding:"required"`
+	Code     string `json:"code,omitempty"`
+}
+
+// AccountDeletionStatus 注销申请状态，申请、撤销和查询注销都返回该结构
+type AccountDeletionStatus struct {
+	Scheduled     bool       `json:"scheduled"`
+	DeletionDueAt *time.Time `json:"deletion_due_at,omitempty"`
+}
+
+// DownloadDataExportReq 分片下载导出文件
+type DownloadDataExportReq struct {
+	ExportID uint  `json:"export_id" binding:"required"`
+	Offset   int64 `json:"offset"`
+}
+
+// DownloadDataExportResp 导出文件的一个分片，Done 为 true 时表示已是最后一片
+type DownloadDataExportResp struct {
+	ExportID uint   `json:"export_id"`
+	Offset   int64  `json:"offset"`
+	Data     []byte `json:"data"`
+	Total    int64  `json:"total"`
+	Done     bool   `json:"done"`
+}
+
+// DataExportReadyPush 导出完成（或失败）时推送给用户
+type DataExportReadyPush struct {
+	Export *DataExport `json:"export"`
+}
+
+// 导出文件中的数据结构，字段使用导出文件自己的格式，不直接暴露数据库模型
+
+// ExportedContact 导出的好友
+type ExportedContact struct {
+	UserID    uint      `json:"user_id"`
+	Username  string    `json:"username"`
+	Remark    string    `json:"remark,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ExportedGroup 导出的群组及自己在群内的角色
+type ExportedGroup struct {
+	GroupID  uint      `json:"group_id"`
+	Name     string    `json:"name"`
+	Role     string    `json:"role"`
+	JoinedAt time.Time `json:"joined_at"`
+}
+
+// ExportedPrivateMessage 导出的私聊消息
+type ExportedPrivateMessage struct {
+	FromUserID uint   `json:"from_user_id"`
+	ToUserID   uint   `json:"to_user_id"`
+	Content    string `json:"content"`
+	Timestamp  int64  `json:"timestamp"`
+}
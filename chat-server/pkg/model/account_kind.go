@@ -0,0 +1,113 @@
+package model
+
+import "time"
+
+// 账号类型
+const (
+	AccountKindHuman = "human" // 普通用户，使用用户名和密码登录
+	AccountKindGuest = "guest" // 访客，临时账号，到期后自动清除
+	AccountKindBot   = "bot"   // 机器人，使用 API Key 登录，归属于创建它的用户
+)
+
+// AccountCapability 按账号类型授予的能力，路由层注册时通过 router.RequireCapability 检查
+type AccountCapability string
+
+const (
+	CapDirectMessage   AccountCapability = "direct_message"   // 发送和接收私聊、查看私聊记录
+	CapFriends         AccountCapability = "friends"          // 好友申请与好友关系
+	CapCreateGroup     AccountCapability = "create_group"     // 创建群组
+	CapAccountSecurity AccountCapability = "account_security" // 密码、邮箱、两步验证、注销与数据导出
+	CapManageBots      AccountCapability = "manage_bots"      // 创建和管理自己的机器人账号
+)
+
+var accountKindCapabilities = map[string]map[AccountCapability]bool{
+	AccountKindHuman: {
+		CapDirectMessage: true, CapFriends: true, CapCreateGroup: true,
+		CapAccountSecurity: true, CapManageBots: true,
+	},
+	AccountKindGuest: {},
+	AccountKindBot: {
+		CapDirectMessage: true,
+	},
+}
+
+// NormalizeAccountKind 历史数据中账号类型为空时视为普通用户
+func NormalizeAccountKind(kind string) string {
+	if kind == "" {
+		return AccountKindHuman
+	}
+	return kind
+}
+
+// AccountKindHas 判断账号类型是否具备某项能力，未知类型不具备任何能力
+func AccountKindHas(kind string, capability AccountCapability) bool {
+	return accountKindCapabilities[NormalizeAccountKind(kind)][capability]
+}
+
+// API Key 格式: 前缀 + 48 位十六进制随机数
+const (
+	BotAPIKeyPrefix     = "czb_"
+	BotAPIKeyHintLen    = 8 // 展示给用户用于识别的 Key 前缀长度（不含 BotAPIKeyPrefix）
+	MaxBotUsernameLen   = 50
+	GuestUsernamePrefix = "guest_"
+)
+
+// BotAPIKey 机器人登录使用的长期 API Key，数据库只保存 SHA-256 摘要
+type BotAPIKey struct {
+	ID         uint       `json:"id" gorm:"primarykey"`
+	BotUserID  uint       `json:"bot_user_id" gorm:"not null;index"`
+	KeyHash    string     `json:"-" gorm:"type:char(64);not null;uniqueIndex"`
+	Hint       string     `json:"hint" gorm:"type:varchar(16)"` // Key 的前几位，便于用户区分
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"` // 为空表示仍然有效
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// --- Request and Response Structs ---
+
+// GuestLoginReq 访客登录请求，无需注册，服务端创建临时账号
+type GuestLoginReq struct {
+	Nickname      string `json:"nickname,omitempty"`
+	ClientVersion string `json:"client_version,omitempty"`
+	DeviceID      string `json:"device_id,omitempty"`
+}
+
+// BotLoginReq 机器人使用 API Key 登录
+type BotLoginReq struct {
+	APIKey        string `json:"api_key" binding:"required"`
+	ClientVersion string `json:"client_version,omitempty"`
+	DeviceID      string `json:"device_id,omitempty"`
+}
+
+// CreateBotReq 创建机器人账号请求
+type CreateBotReq struct {
+	Username string `json:"username" binding:"required,min=3,max=50"`
+	Nickname string `json:"nickname,omitempty"`
+}
+
+// RotateBotKeyReq 重新生成机器人的 API Key，旧 Key 立即失效
+type RotateBotKeyReq struct {
+	BotUserID uint `json:"bot_user_id" binding:"required"`
+}
+
+// BotInfo 机器人账号信息
+type BotInfo struct {
+	ID        uint       `json:"id"`
+	UserUUID  string     `json:"user_uuid"`
+	Username  string     `json:"username"`
+	Nickname  string     `json:"nickname,omitempty"`
+	KeyHint   string     `json:"key_hint,omitempty"`
+	LastUsed  *time.Time `json:"last_used_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// BotKeyResp 创建机器人或重新生成 Key 的响应，APIKey 只在此时返回一次
+type BotKeyResp struct {
+	Bot    *BotInfo `json:"bot"`
+	APIKey string   `json:"api_key"`
+}
+
+// GetMyBotsResp 查询自己创建的机器人响应
+type GetMyBotsResp struct {
+	Bots []*BotInfo `json:"bots"`
+}
@@ -7,5 +7,7 @@ type CustomClaims struct {
 	ID       uint   `json:"id"`        // 用户主键ID
 	UserUUID string `json:"user_uuid"` // 用户业务UUID
 	Username string `json:"username"`
+	// TokenVersion 签发时用户的 Token 版本，与当前版本不一致说明密码已修改，Token 失效
+	TokenVersion uint `json:"token_version"`
 	jwt.StandardClaims
 }
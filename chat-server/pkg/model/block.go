@@ -0,0 +1,59 @@
+package model
+
+import "time"
+
+// 私聊隐私设置，决定哪些用户可以向自己发送私聊消息
+const (
+	DMPrivacyEveryone = "everyone" // 所有人
+	DMPrivacyContacts = "contacts" // 仅好友
+	DMPrivacyNobody   = "nobody"   // 不接收任何私聊
+)
+
+// IsValidDMPrivacy 判断私聊隐私设置是否合法
+func IsValidDMPrivacy(privacy string) bool {
+	switch privacy {
+	case DMPrivacyEveryone, DMPrivacyContacts, DMPrivacyNobody:
+		return true
+	}
+	return false
+}
+
+// UserBlock 用户屏蔽记录，被屏蔽的用户不能向屏蔽者发私聊、发好友申请或将其拉入群组
+type UserBlock struct {
+	ID            uint      `json:"id" gorm:"primarykey"`
+	UserID        uint      `json:"user_id" gorm:"not null;uniqueIndex:idx_user_block"`         // 发起屏蔽的用户ID
+	BlockedUserID uint      `json:"blocked_user_id" gorm:"not null;uniqueIndex:idx_user_block"` // 被屏蔽的用户ID
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// --- Request and Response Structs ---
+
+// BlockedUserInfo 被屏蔽的用户信息
+type BlockedUserInfo struct {
+	UserID    uint   `json:"user_id"`
+	UserUUID  string `json:"user_uuid"`
+	Username  string `json:"username"`
+	Avatar    string `json:"avatar"`
+	BlockedAt string `json:"blocked_at"`
+}
+
+// BlockUserReq 屏蔽用户请求
+type BlockUserReq struct {
+	Target string `json:"target" binding:"required"` // 对方的 UserUUID、用户名或数字ID
+}
+
+// UnblockUserReq 取消屏蔽请求
+type UnblockUserReq struct {
+	UserID uint `json:"user_id" binding:"required"`
+}
+
+// GetBlockListResp 获取屏蔽列表响应
+type GetBlockListResp struct {
+	Users []*BlockedUserInfo `json:"users"`
+	Total int                `json:"total"`
+}
+
+// DMPrivacyReq 设置私聊隐私请求，同时用作查询响应
+type DMPrivacyReq struct {
+	Privacy string `json:"privacy" binding:"required,oneof=everyone contacts nobody"`
+}
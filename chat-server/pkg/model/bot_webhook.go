@@ -0,0 +1,99 @@
+package model
+
+import "time"
+
+// Webhook 事件类型与触发方式
+const (
+	WebhookEventGroupMessage = "group_message"
+
+	WebhookTriggerCommand = "command" // 消息以配置的命令前缀开头
+	WebhookTriggerMention = "mention" // 消息中 @ 了机器人
+)
+
+// 投递请求头，接收方用 Secret 对 "时间戳.请求体" 计算 HMAC-SHA256 并与签名比较
+const (
+	WebhookHeaderEvent     = "X-Chat-Event"
+	WebhookHeaderDelivery  = "X-Chat-Delivery"
+	WebhookHeaderTimestamp = "X-Chat-Timestamp"
+	WebhookHeaderSignature = "X-Chat-Signature" // 格式: sha256=<hex>
+)
+
+// MaxWebhookURLLen webhook 地址的最大长度
+const MaxWebhookURLLen = 500
+
+// BotWebhook 机器人在某个群里的 outgoing webhook，每个机器人在每个群最多一个
+type BotWebhook struct {
+	ID            uint      `json:"id" gorm:"primarykey"`
+	BotUserID     uint      `json:"bot_user_id" gorm:"not null;uniqueIndex:idx_bot_webhook_bot_group"`
+	GroupID       uint      `json:"group_id" gorm:"not null;uniqueIndex:idx_bot_webhook_bot_group;index"`
+	URL           string    `json:"url" gorm:"type:varchar(500);not null"`
+	Secret        string    `json:"-" gorm:"type:varchar(64);not null"` // 签名密钥，投递时需要原文，因此不做哈希
+	CommandPrefix string    `json:"command_prefix,omitempty" gorm:"type:varchar(32)"`
+	OnMention     bool      `json:"on_mention" gorm:"not null;default:false"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+
+	BotUsername string `json:"bot_username,omitempty" gorm:"-"` // 匹配 @ 提及时使用，查询时填充
+}
+
+// WebhookEvent 投递给 webhook 地址的请求体
+type WebhookEvent struct {
+	Event      string          `json:"event"`
+	DeliveryID string          `json:"delivery_id"`
+	Timestamp  int64           `json:"timestamp"`
+	GroupID    uint            `json:"group_id"`
+	Bot        *UserBasicInfo  `json:"bot"`
+	Message    *WebhookMessage `json:"message"`
+	Trigger    string          `json:"trigger"`           // command 或 mention
+	Command    string          `json:"command,omitempty"` // 命令触发时为命令前缀
+	Args       string          `json:"args,omitempty"`    // 命令前缀之后的内容
+}
+
+// WebhookMessage 触发 webhook 的群消息
+type WebhookMessage struct {
+	ID           string `json:"id"`
+	FromUserID   uint   `json:"from_user_id"`
+	FromUserUUID string `json:"from_user_uuid"`
+	FromUsername string `json:"from_username"`
+	Content      string `json:"content"`
+}
+
+// WebhookReply webhook 的响应体，Reply 不为空时以机器人身份发到群里
+type WebhookReply struct {
+	Reply string `json:"reply"`
+}
+
+// --- Request and Response Structs ---
+
+// SetBotWebhookReq 创建或修改机器人在群里的 webhook，CommandPrefix 和 OnMention 至少设置一个
+type SetBotWebhookReq struct {
+	BotUserID     uint   `json:"bot_user_id" binding:"required"`
+	GroupID       uint   `json:"group_id" binding:"required"`
+	URL           string `json:"url" binding:"required"`
+	CommandPrefix string `json:"command_prefix,omitempty"`
+	OnMention     bool   `json:"on_mention"`
+	RotateSecret  bool   `json:"rotate_secret,omitempty"` // 修改时是否重新生成签名密钥
+}
+
+// SetBotWebhookResp 设置 webhook 响应，Secret 只在新建或重新生成时返回
+type SetBotWebhookResp struct {
+	Webhook *BotWebhook `json:"webhook"`
+	Secret  string      `json:"secret,omitempty"`
+}
+
+// DeleteBotWebhookReq 删除机器人在群里的 webhook
+type DeleteBotWebhookReq struct {
+	BotUserID uint `json:"bot_user_id" binding:"required"`
+	GroupID   uint `json:"group_id" binding:"required"`
+}
+
+// GetBotWebhooksReq 查询机器人的所有 webhook
+type GetBotWebhooksReq struct {
+	BotUserID uint `json:"bot_user_id" binding:"required"`
+}
+
+// GetBotWebhooksResp 查询机器人 webhook 响应
+type GetBotWebhooksResp struct {
+	BotUserID uint          `json:"bot_user_id"`
+	Webhooks  []*BotWebhook `json:"webhooks"`
+}
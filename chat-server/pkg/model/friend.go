@@ -0,0 +1,103 @@
+package model
+
+import "time"
+
+// 好友申请状态
+const (
+	FriendRequestPending  = "pending"
+	FriendRequestAccepted = "accepted"
+	FriendRequestRejected = "rejected"
+)
+
+// 好友相关的长度限制
+const (
+	MaxFriendRequestMessageLen = 100 // 好友申请附言最大字符数
+	MaxFriendRemarkLen         = 30  // 好友备注最大字符数
+)
+
+// FriendRequest 好友申请记录
+type FriendRequest struct {
+	ID         uint      `json:"id" gorm:"primarykey"`
+	FromUserID uint      `json:"from_user_id" gorm:"not null;index:idx_friend_request_pair"`
+	ToUserID   uint      `json:"to_user_id" gorm:"not null;index:idx_friend_request_pair;index:idx_friend_request_to"`
+	Message    string    `json:"message" gorm:"type:varchar(100)"`
+	Status     string    `json:"status" gorm:"type:varchar(20);not null;default:'pending';index:idx_friend_request_to"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// Friend 好友关系，每对好友存储两条记录，各自维护对对方的备注
+type Friend struct {
+	ID        uint      `json:"id" gorm:"primarykey"`
+	UserID    uint      `json:"user_id" gorm:"not null;uniqueIndex:idx_friend_pair"`
+	FriendID  uint      `json:"friend_id" gorm:"not null;uniqueIndex:idx_friend_pair"`
+	Remark    string    `json:"remark" gorm:"type:varchar(30)"` // 用户对好友的备注名
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// --- Request and Response Structs ---
+
+// FriendInfo 好友信息（包含用户信息和在线状态）
+type FriendInfo struct {
+	UserID   uint   `json:"user_id"`
+	UserUUID string `json:"user_uuid"`
+	Username string `json:"username"`
+	Avatar   string `json:"avatar"`
+	Remark   string `json:"remark,omitempty"`
+	IsOnline bool   `json:"is_online"`
+	Since    string `json:"since"` // 成为好友的时间
+}
+
+// FriendRequestInfo 好友申请信息
+type FriendRequestInfo struct {
+	ID           uint   `json:"id"`
+	FromUserID   uint   `json:"from_user_id"`
+	FromUsername string `json:"from_username"`
+	ToUserID     uint   `json:"to_user_id"`
+	ToUsername   string `json:"to_username"`
+	Message      string `json:"message,omitempty"`
+	Status       string `json:"status"`
+	CreatedAt    string `json:"created_at"`
+}
+
+// SendFriendRequestReq 发送好友申请请求
+type SendFriendRequestReq struct {
+	Target  string `json:"target" binding:"required"` // 对方的 UserUUID、用户名或数字ID
+	Message string `json:"message,omitempty" binding:"max=100"`
+}
+
+// HandleFriendRequestReq 处理（接受/拒绝）好友申请请求
+type HandleFriendRequestReq struct {
+	RequestID uint `json:"request_id" binding:"required"`
+	Accept    bool `json:"accept"`
+}
+
+// RemoveFriendReq 删除好友请求
+type RemoveFriendReq struct {
+	FriendUserID uint `json:"friend_user_id" binding:"required"`
+}
+
+// FriendRemovedPush 被对方删除好友时推送给被删除的一方
+type FriendRemovedPush struct {
+	UserID   uint   `json:"user_id"`  // 执行删除的用户
+	Username string `json:"username"` // 执行删除的用户名
+}
+
+// SetFriendRemarkReq 设置好友备注请求，Remark 为空表示清除备注
+type SetFriendRemarkReq struct {
+	FriendUserID uint   `json:"friend_user_id" binding:"required"`
+	Remark       string `json:"remark" binding:"max=30"`
+}
+
+// GetFriendListResp 获取好友列表响应
+type GetFriendListResp struct {
+	Friends []*FriendInfo `json:"friends"`
+	Total   int           `json:"total"`
+}
+
+// GetFriendRequestsResp 获取待处理好友申请响应
+type GetFriendRequestsResp struct {
+	Incoming []*FriendRequestInfo `json:"incoming"` // 收到的申请
+	Outgoing []*FriendRequestInfo `json:"outgoing"` // 发出的申请
+}
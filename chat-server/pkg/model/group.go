@@ -10,19 +10,23 @@ type Group struct {
 	Avatar      string    `json:"avatar" gorm:"type:varchar(255)"`
 	Description string    `json:"description" gorm:"type:varchar(500)"`
 	MemberCount uint      `json:"member_count" gorm:"default:1"`
+	Tier        string    `json:"tier" gorm:"type:varchar(20);default:'normal'"`                     // 群组等级，决定成员上限
+	IsPublic    bool      `json:"is_public" gorm:"not null;default:false;index:idx_group_directory"` // 是否出现在公开群组目录中
+	Category    string    `json:"category" gorm:"type:varchar(20);index:idx_group_directory"`        // 群组分类，见 GroupCategories
 	CreatedAt   time.Time `json:"created_at"`
 	UpdatedAt   time.Time `json:"updated_at"`
 }
 
 // GroupMember 群组成员信息
 type GroupMember struct {
-	ID        uint      `json:"id" gorm:"primarykey"`
-	GroupID   uint      `json:"group_id" gorm:"not null;uniqueIndex:idx_group_user"` // 外键，关联 Group 表的 ID
-	UserID    uint      `json:"user_id" gorm:"not null;uniqueIndex:idx_group_user"`  // 外键，关联 User 表的 ID
-	Role      string    `json:"role" gorm:"type:varchar(20);default:'member'"`       // e.g., "owner", "admin", "member"
-	JoinedAt  time.Time `json:"joined_at"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID         uint       `json:"id" gorm:"primarykey"`
+	GroupID    uint       `json:"group_id" gorm:"not null;uniqueIndex:idx_group_user"` // 外键，关联 Group 表的 ID
+	UserID     uint       `json:"user_id" gorm:"not null;uniqueIndex:idx_group_user"`  // 外键，关联 User 表的 ID
+	Role       string     `json:"role" gorm:"type:varchar(20);default:'member'"`       // 内置角色 "owner", "admin", "member" 或群组自定义角色名
+	MutedUntil *time.Time `json:"muted_until,omitempty"`                               // 禁言到期时间，为空表示未禁言
+	JoinedAt   time.Time  `json:"joined_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
 }
 
 // Constants for GroupMember Role
@@ -32,13 +36,29 @@ const (
 	GroupRoleMember = "member"
 )
 
+// Constants for Group Tier, 各等级的成员上限见 conf.GroupConfig
+const (
+	GroupTierNormal = "normal"
+	GroupTierLarge  = "large"
+	GroupTierSuper  = "super"
+)
+
+// 群成员分页参数
+const (
+	DefaultGroupMemberPageSize = 50
+	MaxGroupMemberPageSize     = 200
+)
+
 // --- Request and Response Structs ---
 
 // CreateGroupReq 创建群组请求
 type CreateGroupReq struct {
-	Name        string `json:"name" binding:"required,min=2,max=30"`
-	Description string `json:"description" binding:"max=200"`
-	Avatar      string `json:"avatar"`
+	Name        string   `json:"name" binding:"required,min=2,max=30"`
+	Description string   `json:"description" binding:"max=200"`
+	Avatar      string   `json:"avatar"`
+	IsPublic    bool     `json:"is_public,omitempty"` // 是否公开到群组目录
+	Category    string   `json:"category,omitempty"`
+	Tags        []string `json:"tags,omitempty" binding:"omitempty,max=5"`
 }
 
 // CreateGroupResp 创建群组响应
@@ -57,6 +77,20 @@ type JoinGroupReq struct {
 	GroupID uint `json:"group_id" binding:"required"`
 }
 
+// InviteGroupMemberReq 邀请用户入群请求
+type InviteGroupMemberReq struct {
+	GroupID      uint `json:"group_id" binding:"required"`
+	TargetUserID uint `json:"target_user_id" binding:"required"`
+}
+
+// GroupInvitedPush 被邀请入群时推送给被邀请人的通知
+type GroupInvitedPush struct {
+	GroupID     uint   `json:"group_id"`
+	GroupName   string `json:"group_name"`
+	InviterID   uint   `json:"inviter_id"`
+	InviterName string `json:"inviter_name"`
+}
+
 // LeaveGroupReq 退出群组请求
 type LeaveGroupReq struct {
 	GroupID uint `json:"group_id" binding:"required"`
@@ -64,10 +98,13 @@ type LeaveGroupReq struct {
 
 // GroupBasicInfo 群组基本信息，用于列表等场景
 type GroupBasicInfo struct {
-	ID          uint   `json:"id"`
-	Name        string `json:"name"`
-	MemberCount uint   `json:"member_count"`
-	Description string `json:"description"`
+	ID          uint     `json:"id"`
+	Name        string   `json:"name"`
+	MemberCount uint     `json:"member_count"`
+	Description string   `json:"description"`
+	Avatar      string   `json:"avatar,omitempty"`
+	Category    string   `json:"category,omitempty"`
+	Tags        []string `json:"tags,omitempty"`
 }
 
 // 新增模型定义
@@ -83,16 +120,22 @@ type GroupMemberInfo struct {
 	IsOnline bool      `json:"is_online"` // 在线状态
 }
 
-// GetGroupMembersReq 获取群成员列表请求
+// GetGroupMembersReq 获取群成员列表请求（分页，可按用户名搜索）
 type GetGroupMembersReq struct {
-	GroupID uint `json:"group_id" binding:"required"`
+	GroupID  uint   `json:"group_id" binding:"required"`
+	Page     int    `json:"page,omitempty"`      // 页码，从1开始
+	PageSize int    `json:"page_size,omitempty"` // 每页数量
+	Keyword  string `json:"keyword,omitempty"`   // 用户名关键字
 }
 
 // GetGroupMembersResp 获取群成员列表响应
 type GetGroupMembersResp struct {
-	GroupID uint               `json:"group_id"`
-	Members []*GroupMemberInfo `json:"members"`
-	Total   int                `json:"total"`
+	GroupID  uint               `json:"group_id"`
+	Members  []*GroupMemberInfo `json:"members"`
+	Total    int                `json:"total"` // 符合条件的成员总数
+	Page     int                `json:"page"`
+	PageSize int                `json:"page_size"`
+	HasMore  bool               `json:"has_more"`
 }
 
 // GetUserGroupsReq 获取用户加入的群列表请求
@@ -108,17 +151,20 @@ type GetUserGroupsResp struct {
 
 // UpdateGroupInfoReq 更新群组信息请求
 type UpdateGroupInfoReq struct {
-	GroupID     uint   `json:"group_id" binding:"required"`
-	Name        string `json:"name,omitempty" binding:"omitempty,min=2,max=30"`
-	Description string `json:"description,omitempty" binding:"omitempty,max=200"`
-	Avatar      string `json:"avatar,omitempty"`
+	GroupID     uint     `json:"group_id" binding:"required"`
+	Name        string   `json:"name,omitempty" binding:"omitempty,min=2,max=30"`
+	Description string   `json:"description,omitempty" binding:"omitempty,max=200"`
+	Avatar      string   `json:"avatar,omitempty"`
+	IsPublic    *bool    `json:"is_public,omitempty"`            // nil 表示不修改
+	Category    *string  `json:"category,omitempty"`             // nil 表示不修改，空字符串表示取消分类
+	Tags        []string `json:"tags" binding:"omitempty,max=5"` // nil 表示不修改，空数组表示清空标签
 }
 
 // SetGroupMemberRoleReq 设置群组成员角色请求
 type SetGroupMemberRoleReq struct {
 	GroupID      uint   `json:"group_id" binding:"required"`
 	TargetUserID uint   `json:"target_user_id" binding:"required"`
-	NewRole      string `json:"new_role" binding:"required,oneof=admin member"`
+	NewRole      string `json:"new_role" binding:"required,max=20"` // 内置角色 admin/member 或群组自定义角色名
 }
 
 // RemoveMemberReq 将成员移出群组请求
@@ -0,0 +1,115 @@
+package model
+
+import "time"
+
+// 群公告与置顶消息的数量限制
+const (
+	MaxGroupAnnouncementLen = 2000 // 群公告内容最大长度（字符数）
+	MaxGroupPinnedMessages  = 5    // 每个群组最多置顶的消息数量
+)
+
+// GroupAnnouncement 群公告数据库存储模型
+// 每次编辑都会插入一条新记录，版本号最大的一条即为当前公告，其余为历史记录
+type GroupAnnouncement struct {
+	ID           uint      `json:"id" gorm:"primarykey"`
+	GroupID      uint      `json:"group_id" gorm:"not null;index:idx_group_version"`
+	Version      uint      `json:"version" gorm:"not null;index:idx_group_version"` // 公告版本号，从1开始递增
+	Content      string    `json:"content" gorm:"type:text"`
+	EditorUserID uint      `json:"editor_user_id" gorm:"not null"` // 编辑者的用户ID
+	EditorName   string    `json:"editor_name" gorm:"type:varchar(50)"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// GroupAnnouncementAck 群公告确认记录（"已读确认"列表）
+type GroupAnnouncementAck struct {
+	ID             uint      `json:"id" gorm:"primarykey"`
+	AnnouncementID uint      `json:"announcement_id" gorm:"not null;uniqueIndex:idx_announcement_user"`
+	GroupID        uint      `json:"group_id" gorm:"not null;index"`
+	UserID         uint      `json:"user_id" gorm:"not null;uniqueIndex:idx_announcement_user"`
+	AckedAt        time.Time `json:"acked_at"`
+}
+
+// GroupPinnedMessage 群置顶消息，引用 group_messages 表中的记录
+type GroupPinnedMessage struct {
+	ID             uint      `json:"id" gorm:"primarykey"`
+	GroupID        uint      `json:"group_id" gorm:"not null;uniqueIndex:idx_group_pinned_msg"`
+	GroupMessageID uint      `json:"group_message_id" gorm:"not null;uniqueIndex:idx_group_pinned_msg"` // 关联 GroupMessage 表的 ID
+	PinnedBy       uint      `json:"pinned_by" gorm:"not null"`                                         // 置顶操作者的用户ID
+	PinnedAt       time.Time `json:"pinned_at"`
+}
+
+// --- Request and Response Structs ---
+
+// GroupAnnouncementAckInfo 公告确认者信息
+type GroupAnnouncementAckInfo struct {
+	UserID   uint   `json:"user_id"`
+	Username string `json:"username"`
+	AckedAt  string `json:"acked_at"`
+}
+
+// GroupAnnouncementInfo 群公告信息，用于详情、历史和推送
+type GroupAnnouncementInfo struct {
+	ID           uint                        `json:"id"`
+	GroupID      uint                        `json:"group_id"`
+	Version      uint                        `json:"version"`
+	Content      string                      `json:"content"`
+	EditorUserID uint                        `json:"editor_user_id"`
+	EditorName   string                      `json:"editor_name"`
+	CreatedAt    string                      `json:"created_at"`
+	AckedBy      []*GroupAnnouncementAckInfo `json:"acked_by,omitempty"` // 仅当前公告返回确认列表
+}
+
+// GroupPinnedMsgInfo 置顶消息信息
+type GroupPinnedMsgInfo struct {
+	GroupMessageID uint   `json:"group_message_id"` // group_messages 表中的ID
+	MsgID          string `json:"msg_id"`           // 消息唯一标识
+	SenderID       uint   `json:"sender_id"`
+	SenderName     string `json:"sender_name"`
+	Content        string `json:"content"`
+	MessageType    string `json:"message_type"`
+	Timestamp      int64  `json:"timestamp"` // 消息发送时间（Unix秒）
+	PinnedBy       uint   `json:"pinned_by"`
+	PinnedAt       string `json:"pinned_at"`
+}
+
+// UpdateGroupAnnouncementReq 编辑群公告请求
+type UpdateGroupAnnouncementReq struct {
+	GroupID uint   `json:"group_id" binding:"required"`
+	Content string `json:"content" binding:"max=2000"`
+}
+
+// GetGroupAnnouncementHistoryReq 获取群公告历史请求
+type GetGroupAnnouncementHistoryReq struct {
+	GroupID uint `json:"group_id" binding:"required"`
+	Limit   int  `json:"limit,omitempty"`
+}
+
+// GetGroupAnnouncementHistoryResp 获取群公告历史响应
+type GetGroupAnnouncementHistoryResp struct {
+	GroupID       uint                     `json:"group_id"`
+	Announcements []*GroupAnnouncementInfo `json:"announcements"`
+}
+
+// AckGroupAnnouncementReq 确认群公告请求
+type AckGroupAnnouncementReq struct {
+	GroupID        uint `json:"group_id" binding:"required"`
+	AnnouncementID uint `json:"announcement_id" binding:"required"`
+}
+
+// PinGroupMessageReq 置顶/取消置顶群消息请求
+type PinGroupMessageReq struct {
+	GroupID        uint `json:"group_id" binding:"required"`
+	GroupMessageID uint `json:"group_message_id" binding:"required"`
+}
+
+// GroupAnnouncementPush S->C 群公告变更推送
+type GroupAnnouncementPush struct {
+	GroupID      uint                   `json:"group_id"`
+	Announcement *GroupAnnouncementInfo `json:"announcement"`
+}
+
+// GroupPinnedMsgPush S->C 群置顶消息变更推送，携带变更后的完整置顶列表
+type GroupPinnedMsgPush struct {
+	GroupID        uint                  `json:"group_id"`
+	PinnedMessages []*GroupPinnedMsgInfo `json:"pinned_messages"`
+}
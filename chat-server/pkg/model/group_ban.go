@@ -0,0 +1,68 @@
+package model
+
+import "time"
+
+// MaxGroupBanReasonLen 封禁原因最大长度（字符数）
+const MaxGroupBanReasonLen = 200
+
+// GroupBan 群组封禁记录，被封禁的用户在封禁期内不能再加入该群组
+type GroupBan struct {
+	ID        uint       `json:"id" gorm:"primarykey"`
+	GroupID   uint       `json:"group_id" gorm:"not null;uniqueIndex:idx_group_ban_user"`
+	UserID    uint       `json:"user_id" gorm:"not null;uniqueIndex:idx_group_ban_user"` // 被封禁的用户ID
+	Reason    string     `json:"reason" gorm:"type:varchar(255)"`
+	BannedBy  uint       `json:"banned_by" gorm:"not null"` // 执行封禁的群主/管理员用户ID
+	ExpiresAt *time.Time `json:"expires_at"`                // 为空表示永久封禁
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// IsActive 判断封禁在指定时间是否仍然有效
+func (b *GroupBan) IsActive(now time.Time) bool {
+	return b.ExpiresAt == nil || b.ExpiresAt.After(now)
+}
+
+// --- Request and Response Structs ---
+
+// GroupBanInfo 群组封禁信息
+type GroupBanInfo struct {
+	UserID       uint   `json:"user_id"`
+	Username     string `json:"username"`
+	Reason       string `json:"reason"`
+	BannedBy     uint   `json:"banned_by"`
+	BannedByName string `json:"banned_by_name"`
+	ExpiresAt    string `json:"expires_at,omitempty"` // 为空表示永久封禁
+	CreatedAt    string `json:"created_at"`
+}
+
+// BanGroupMemberReq 封禁群成员请求，目标用户如在群内会被一并移出
+type BanGroupMemberReq struct {
+	GroupID         uint   `json:"group_id" binding:"required"`
+	TargetUserID    uint   `json:"target_user_id" binding:"required"`
+	Reason          string `json:"reason,omitempty" binding:"max=200"`
+	DurationSeconds int64  `json:"duration_seconds,omitempty"` // 封禁时长，0 表示永久封禁
+}
+
+// UnbanGroupMemberReq 解除群组封禁请求
+type UnbanGroupMemberReq struct {
+	GroupID      uint `json:"group_id" binding:"required"`
+	TargetUserID uint `json:"target_user_id" binding:"required"`
+}
+
+// MuteGroupMemberReq 禁言群成员请求，禁言期间不能发送群消息
+type MuteGroupMemberReq struct {
+	GroupID         uint  `json:"group_id" binding:"required"`
+	TargetUserID    uint  `json:"target_user_id" binding:"required"`
+	DurationSeconds int64 `json:"duration_seconds"` // 禁言时长，0 表示解除禁言
+}
+
+// GetGroupBanListReq 获取群组封禁列表请求
+type GetGroupBanListReq struct {
+	GroupID uint `json:"group_id" binding:"required"`
+}
+
+// GetGroupBanListResp 获取群组封禁列表响应
+type GetGroupBanListResp struct {
+	GroupID uint            `json:"group_id"`
+	Bans    []*GroupBanInfo `json:"bans"`
+	Total   int             `json:"total"`
+}
@@ -0,0 +1,71 @@
+package model
+
+import "time"
+
+// 群组分类
+const (
+	GroupCategoryTech   = "tech"
+	GroupCategoryGaming = "gaming"
+	GroupCategoryStudy  = "study"
+	GroupCategoryLife   = "life"
+	GroupCategorySports = "sports"
+	GroupCategoryMusic  = "music"
+	GroupCategoryOther  = "other"
+)
+
+// GroupCategories 所有可选的群组分类
+var GroupCategories = []string{
+	GroupCategoryTech,
+	GroupCategoryGaming,
+	GroupCategoryStudy,
+	GroupCategoryLife,
+	GroupCategorySports,
+	GroupCategoryMusic,
+	GroupCategoryOther,
+}
+
+// IsValidGroupCategory 判断是否为有效的群组分类
+func IsValidGroupCategory(category string) bool {
+	for _, c := range GroupCategories {
+		if c == category {
+			return true
+		}
+	}
+	return false
+}
+
+// 群组标签与目录搜索的限制
+const (
+	MaxGroupTags                  = 5  // 每个群组最多标签数
+	MaxGroupTagLen                = 20 // 单个标签最大字符数
+	DefaultGroupDirectoryPageSize = 20
+	MaxGroupDirectoryPageSize     = 50
+)
+
+// GroupTag 群组标签，标签统一存储为小写
+type GroupTag struct {
+	ID        uint      `json:"id" gorm:"primarykey"`
+	GroupID   uint      `json:"group_id" gorm:"not null;uniqueIndex:idx_group_tag"`
+	Tag       string    `json:"tag" gorm:"type:varchar(20);not null;uniqueIndex:idx_group_tag;index:idx_tag"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// --- Request and Response Structs ---
+
+// SearchGroupsReq 搜索公开群组请求，各条件均可为空，全部为空时按热度列出公开群组
+type SearchGroupsReq struct {
+	Keyword  string `json:"keyword,omitempty"`  // 匹配群名、简介或标签
+	Category string `json:"category,omitempty"` // 按分类过滤
+	Tag      string `json:"tag,omitempty"`      // 按标签过滤
+	Page     int    `json:"page,omitempty"`     // 页码，从1开始
+	PageSize int    `json:"page_size,omitempty"`
+}
+
+// SearchGroupsResp 搜索公开群组响应
+type SearchGroupsResp struct {
+	Groups   []*GroupBasicInfo `json:"groups"`
+	Total    int               `json:"total"`
+	Page     int               `json:"page"`
+	PageSize int               `json:"page_size"`
+	HasMore  bool              `json:"has_more"`
+}
@@ -2,10 +2,18 @@ package model
 
 import "time"
 
+// 成员可发送的群消息类型，附件消息的 content 为文件地址，需要 upload_file 权限
+const (
+	GroupMessageTypeText  = "text"
+	GroupMessageTypeImage = "image"
+	GroupMessageTypeFile  = "file"
+)
+
 // GroupTextMsgReq C->S 发送群组文本消息请求
 type GroupTextMsgReq struct {
-	GroupID uint32 `json:"group_id"` // 群组ID
-	Content string `json:"content"`  // 消息内容
+	GroupID     uint32 `json:"group_id"`               // 群组ID
+	Content     string `json:"content"`                // 消息内容
+	MessageType string `json:"message_type,omitempty"` // 为空表示普通文本，image/file 表示附件
 }
 
 // GroupTextMsgResp S->C 发送群组文本消息响应
@@ -17,12 +25,13 @@ type GroupTextMsgResp struct {
 
 // GroupTextMsgPush S->C 推送群组文本消息
 type GroupTextMsgPush struct {
-	GroupID      uint32 `json:"group_id"`       // 群组ID
-	FromUserID   uint   `json:"from_user_id"`   // 发送者DB User ID
-	FromUserUUID string `json:"from_user_uuid"` // 发送者User UUID
-	FromUsername string `json:"from_username"`  // 发送者用户名
-	Content      string `json:"content"`        // 消息内容
-	Timestamp    int64  `json:"timestamp"`      // 服务器收到消息时的时间戳 (Unix秒)
+	GroupID      uint32 `json:"group_id"`               // 群组ID
+	FromUserID   uint   `json:"from_user_id"`           // 发送者DB User ID
+	FromUserUUID string `json:"from_user_uuid"`         // 发送者User UUID
+	FromUsername string `json:"from_username"`          // 发送者用户名
+	Content      string `json:"content"`                // 消息内容
+	Timestamp    int64  `json:"timestamp"`              // 服务器收到消息时的时间戳 (Unix秒)
+	MessageType  string `json:"message_type,omitempty"` // 为空表示普通文本，image/file 表示附件，integration 表示来自 incoming webhook
 }
 
 // GroupMessage 群组消息数据库存储模型
@@ -0,0 +1,170 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// GroupPermission 群组权限位，一个角色的能力由多个权限位组合而成
+type GroupPermission uint64
+
+// 群组权限定义
+// 权限位按位存储在自定义角色中，删除权限时保留其位置，不能调整顺序
+const (
+	GroupPermSendMessage        GroupPermission = 1 << iota // 发送群消息
+	GroupPermUploadFile                                     // 发送图片、文件等附件消息
+	GroupPermPinMessage                                     // 置顶/取消置顶消息
+	GroupPermMuteMember                                     // 禁言/解除禁言成员
+	GroupPermInviteMember                                   // 邀请成员
+	GroupPermEditInfo                                       // 编辑群资料
+	GroupPermEditAnnouncement                               // 编辑群公告
+	GroupPermRemoveMember                                   // 移除成员
+	GroupPermBanMember                                      // 封禁/解封用户
+	GroupPermManageRoles                                    // 管理角色及成员角色
+	GroupPermManageIntegrations                             // 管理 incoming webhook 等外部集成
+
+	GroupPermAll = GroupPermSendMessage | GroupPermUploadFile | GroupPermPinMessage | GroupPermMuteMember |
+		GroupPermInviteMember | GroupPermEditInfo | GroupPermEditAnnouncement | GroupPermRemoveMember |
+		GroupPermBanMember | GroupPermManageRoles | GroupPermManageIntegrations
+)
+
+// groupPermissionNames 权限位与协议中使用的权限名称的对应关系，按权限位顺序排列
+var groupPermissionNames = []struct {
+	perm GroupPermission
+	name string
+}{
+	{GroupPermSendMessage, "send_message"},
+	{GroupPermUploadFile, "upload_file"},
+	{GroupPermPinMessage, "pin_message"},
+	{GroupPermMuteMember, "mute_member"},
+	{GroupPermInviteMember, "invite_member"},
+	{GroupPermEditInfo, "edit_info"},
+	{GroupPermEditAnnouncement, "edit_announcement"},
+	{GroupPermRemoveMember, "remove_member"},
+	{GroupPermBanMember, "ban_member"},
+	{GroupPermManageRoles, "manage_roles"},
+	{GroupPermManageIntegrations, "manage_integrations"},
+}
+
+// Has 判断是否包含指定的全部权限
+func (p GroupPermission) Has(perm GroupPermission) bool {
+	return p&perm == perm
+}
+
+// Names 返回权限对应的名称列表
+func (p GroupPermission) Names() []string {
+	names := make([]string, 0, len(groupPermissionNames))
+	for _, item := range groupPermissionNames {
+		if p.Has(item.perm) {
+			names = append(names, item.name)
+		}
+	}
+	return names
+}
+
+// String 返回权限名称，多个权限以逗号分隔
+func (p GroupPermission) String() string {
+	names := p.Names()
+	if len(names) == 0 {
+		return "none"
+	}
+	return strings.Join(names, ",")
+}
+
+// ParseGroupPermissions 将权限名称列表解析为权限位
+func ParseGroupPermissions(names []string) (GroupPermission, error) {
+	var perms GroupPermission
+	for _, name := range names {
+		found := false
+		for _, item := range groupPermissionNames {
+			if item.name == name {
+				perms |= item.perm
+				found = true
+				break
+			}
+		}
+		if !found {
+			return 0, fmt.Errorf("unknown permission: %s", name)
+		}
+	}
+	return perms, nil
+}
+
+// 内置角色的优先级，优先级高的角色才能管理优先级低的成员
+const (
+	GroupRolePriorityOwner  = 100
+	GroupRolePriorityAdmin  = 50
+	GroupRolePriorityMember = 10
+)
+
+// GroupRole 群组自定义角色
+// 内置角色 owner/admin/member 不存储在数据库中，见 BuiltinGroupRole
+type GroupRole struct {
+	ID          uint            `json:"id" gorm:"primarykey"`
+	GroupID     uint            `json:"group_id" gorm:"not null;uniqueIndex:idx_group_role_name"`
+	Name        string          `json:"name" gorm:"type:varchar(20);not null;uniqueIndex:idx_group_role_name"` // 与 GroupMember.Role 对应
+	Permissions GroupPermission `json:"permissions" gorm:"type:bigint unsigned;not null;default:0"`
+	Priority    int             `json:"priority" gorm:"not null;default:0"` // 取值 1-99，介于普通成员和群主之间
+	CreatedAt   time.Time       `json:"created_at"`
+	UpdatedAt   time.Time       `json:"updated_at"`
+}
+
+// BuiltinGroupRole 获取内置角色定义，name 不是内置角色时返回 nil
+func BuiltinGroupRole(name string) *GroupRole {
+	switch name {
+	case GroupRoleOwner:
+		return &GroupRole{Name: GroupRoleOwner, Permissions: GroupPermAll, Priority: GroupRolePriorityOwner}
+	case GroupRoleAdmin:
+		return &GroupRole{Name: GroupRoleAdmin, Permissions: GroupPermAll &^ GroupPermManageRoles, Priority: GroupRolePriorityAdmin}
+	case GroupRoleMember:
+		return &GroupRole{Name: GroupRoleMember, Permissions: GroupPermSendMessage | GroupPermUploadFile | GroupPermInviteMember, Priority: GroupRolePriorityMember}
+	}
+	return nil
+}
+
+// GroupMemberAccess 群成员的角色和禁言状态，鉴权时使用，与群成员一起缓存
+type GroupMemberAccess struct {
+	Role       *GroupRole `json:"role"`
+	MutedUntil *time.Time `json:"muted_until,omitempty"`
+}
+
+// IsMuted 判断成员在指定时间是否处于禁言中
+func (a *GroupMemberAccess) IsMuted(now time.Time) bool {
+	return a.MutedUntil != nil && a.MutedUntil.After(now)
+}
+
+// --- Request and Response Structs ---
+
+// GroupRoleInfo 群组角色信息
+type GroupRoleInfo struct {
+	Name        string   `json:"name"`
+	Permissions []string `json:"permissions"`
+	Priority    int      `json:"priority"`
+	IsBuiltin   bool     `json:"is_builtin"`
+}
+
+// SaveGroupRoleReq 创建或修改群组自定义角色请求
+type SaveGroupRoleReq struct {
+	GroupID     uint     `json:"group_id" binding:"required"`
+	Name        string   `json:"name" binding:"required,max=20"`
+	Permissions []string `json:"permissions"`
+	Priority    int      `json:"priority" binding:"min=1,max=99"`
+}
+
+// DeleteGroupRoleReq 删除群组自定义角色请求，持有该角色的成员会恢复为普通成员
+type DeleteGroupRoleReq struct {
+	GroupID uint   `json:"group_id" binding:"required"`
+	Name    string `json:"name" binding:"required"`
+}
+
+// GetGroupRolesReq 获取群组角色列表请求
+type GetGroupRolesReq struct {
+	GroupID uint `json:"group_id" binding:"required"`
+}
+
+// GetGroupRolesResp 获取群组角色列表响应（包含内置角色）
+type GetGroupRolesResp struct {
+	GroupID uint             `json:"group_id"`
+	Roles   []*GroupRoleInfo `json:"roles"`
+}
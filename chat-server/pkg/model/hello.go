@@ -0,0 +1,44 @@
+package model
+
+// HelloReq 连接建立后客户端发送的第一条消息，上报协议版本和支持的能力
+// Codecs、Compression 按客户端的优先级排列，为空时视为只支持 json 编码、不压缩
+type HelloReq struct {
+	ProtocolVersion int      `json:"protocol_version"`
+	ClientName      string   `json:"client_name,omitempty"`
+	ClientVersion   string   `json:"client_version,omitempty"`
+	Codecs          []string `json:"codecs,omitempty"`
+	Compression     []string `json:"compression,omitempty"`
+	Features        []string `json:"features,omitempty"`
+}
+
+// HelloLimits 服务端对连接的限制
+type HelloLimits struct {
+	MaxPacketSize     int `json:"max_packet_size"`         // 单个消息包的最大长度（字节）
+	HeartbeatInterval int `json:"heartbeat_interval"`      // 建议的心跳间隔（秒）
+	HeartbeatTimeout  int `json:"heartbeat_timeout"`       // 超过该时间没有心跳即断开连接（秒）
+	ResumeWindow      int `json:"resume_window,omitempty"` // 断线后保留会话的时间（秒）
+}
+
+// HelloResp 握手响应
+// ProtocolVersion 为双方协商后使用的版本；Features 为服务端已启用且客户端声明支持的功能
+type HelloResp struct {
+	ProtocolVersion       int         `json:"protocol_version"`
+	ServerProtocolVersion int         `json:"server_protocol_version"`
+	MinProtocolVersion    int         `json:"min_protocol_version"` // 服务端接受的最低协议版本
+	ServerVersion         string      `json:"server_version"`
+	Codec                 string      `json:"codec"`
+	Compression           string      `json:"compression"`
+	Features              []string    `json:"features"`
+	EnabledFeatures       []string    `json:"enabled_features"` // 服务端启用的全部功能
+	Limits                HelloLimits `json:"limits"`
+}
+
+// Handshake 握手完成后记录在连接上的协商结果
+type Handshake struct {
+	ProtocolVersion int
+	ClientName      string
+	ClientVersion   string
+	Codec           string
+	Compression     string
+	Features        map[string]bool
+}
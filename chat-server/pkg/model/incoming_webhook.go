@@ -0,0 +1,79 @@
+package model
+
+import "time"
+
+// Incoming webhook 令牌格式: 前缀 + 48 位十六进制随机数，URL 为 {PublicURL}/hooks/{令牌}
+const (
+	IncomingWebhookTokenPrefix  = "czh_"
+	IncomingWebhookTokenHintLen = 8
+	IncomingWebhookPathPrefix   = "/hooks/"
+	MaxIncomingWebhookNameLen   = 32
+
+	// GroupMessageTypeIntegration 外部系统通过 incoming webhook 发送的群消息类型，发送者ID为 0
+	GroupMessageTypeIntegration = "integration"
+)
+
+// IncomingWebhook 群组的 incoming webhook，外部系统持有令牌即可向群里发消息，数据库只保存令牌的 SHA-256 摘要
+type IncomingWebhook struct {
+	ID         uint       `json:"id" gorm:"primarykey"`
+	GroupID    uint       `json:"group_id" gorm:"not null;index"`
+	Name       string     `json:"name" gorm:"type:varchar(32);not null"` // 集成名称，作为消息的发送者名称
+	TokenHash  string     `json:"-" gorm:"type:char(64);not null;uniqueIndex"`
+	Hint       string     `json:"hint" gorm:"type:varchar(16)"`
+	CreatorID  uint       `json:"creator_id" gorm:"not null"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"` // 为空表示仍然有效
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// IncomingWebhookPayload 外部系统 POST 的请求体，Text 和 Title 至少有一个
+type IncomingWebhookPayload struct {
+	Text   string                  `json:"text"`
+	Title  string                  `json:"title,omitempty"`
+	Fields []*IncomingWebhookField `json:"fields,omitempty"`
+}
+
+// IncomingWebhookField 附加的键值字段，例如构建号、告警级别
+type IncomingWebhookField struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// IncomingWebhookResult HTTP 响应体
+type IncomingWebhookResult struct {
+	OK    bool   `json:"ok"`
+	MsgID string `json:"msg_id,omitempty"`
+	Error string `json:"error,omitempty"`
+}
+
+// --- Request and Response Structs ---
+
+// CreateIncomingWebhookReq 为群组创建 incoming webhook，需要 manage_integrations 权限
+type CreateIncomingWebhookReq struct {
+	GroupID uint   `json:"group_id" binding:"required"`
+	Name    string `json:"name" binding:"required,max=32"`
+}
+
+// CreateIncomingWebhookResp 创建响应，URL 和 Token 只在此时返回一次
+type CreateIncomingWebhookResp struct {
+	Webhook *IncomingWebhook `json:"webhook"`
+	URL     string           `json:"url"`
+	Token   string           `json:"token"`
+}
+
+// RevokeIncomingWebhookReq 吊销 incoming webhook，吊销后令牌立即失效
+type RevokeIncomingWebhookReq struct {
+	GroupID   uint `json:"group_id" binding:"required"`
+	WebhookID uint `json:"webhook_id" binding:"required"`
+}
+
+// GetIncomingWebhooksReq 查询群组的 incoming webhook
+type GetIncomingWebhooksReq struct {
+	GroupID uint `json:"group_id" binding:"required"`
+}
+
+// GetIncomingWebhooksResp 查询响应，只包含未吊销的 webhook
+type GetIncomingWebhooksResp struct {
+	GroupID  uint               `json:"group_id"`
+	Webhooks []*IncomingWebhook `json:"webhooks"`
+}
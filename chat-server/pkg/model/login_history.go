@@ -0,0 +1,66 @@
+package model
+
+import "time"
+
+// 登录失败原因
+const (
+	LoginFailInvalidCredentials = "invalid_credentials" // 账号不存在或密码错误
+	LoginFailInvalidTwoFactor   = "invalid_two_factor"  // 两步验证码错误
+	LoginFailThrottled          = "throttled"           // 处于退避或锁定状态
+	LoginFailError              = "error"               // 服务端错误
+)
+
+// 登录历史查询条数
+const (
+	DefaultLoginHistoryLimit = 20
+	MaxLoginHistoryLimit     = 100
+)
+
+// 客户端上报字段的长度限制，与 LoginRecord 的列宽一致
+const (
+	MaxClientVersionLen = 32
+	MaxDeviceIDLen      = 64
+)
+
+// LoginClientInfo 发起登录的客户端信息，IP 由服务端根据连接获取
+type LoginClientInfo struct {
+	IP            string
+	ClientVersion string
+	DeviceID      string
+}
+
+// LoginRecord 登录记录，成功和失败的登录都会记录
+// 账号不存在时 UserID 为 0，只保留尝试登录的用户名
+type LoginRecord struct {
+	ID            uint      `json:"id" gorm:"primarykey"`
+	UserID        uint      `json:"user_id" gorm:"not null;index:idx_login_record_user,priority:1"`
+	Username      string    `json:"username" gorm:"type:varchar(50)"`
+	Success       bool      `json:"success" gorm:"not null"`
+	FailReason    string    `json:"fail_reason,omitempty" gorm:"type:varchar(32)"`
+	IP            string    `json:"ip" gorm:"type:varchar(45)"`
+	ClientVersion string    `json:"client_version,omitempty" gorm:"type:varchar(32)"`
+	DeviceID      string    `json:"device_id,omitempty" gorm:"type:varchar(64)"`
+	CreatedAt     time.Time `json:"created_at" gorm:"index:idx_login_record_user,priority:2"`
+}
+
+// --- Request and Response Structs ---
+
+// GetLoginHistoryReq 查询自己的登录历史
+type GetLoginHistoryReq struct {
+	Limit int `json:"limit,omitempty"` // 默认 20，最多 100
+}
+
+// GetLoginHistoryResp 登录历史响应，按时间倒序
+type GetLoginHistoryResp struct {
+	Records []*LoginRecord `json:"records"`
+}
+
+// SuspiciousLoginPush 账号在新的IP或设备上登录时推送给该用户
+type SuspiciousLoginPush struct {
+	IP            string `json:"ip"`
+	DeviceID      string `json:"device_id,omitempty"`
+	ClientVersion string `json:"client_version,omitempty"`
+	NewIP         bool   `json:"new_ip"`
+	NewDevice     bool   `json:"new_device"`
+	LoginAt       int64  `json:"login_at"`
+}
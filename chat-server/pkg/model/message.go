@@ -16,6 +16,13 @@ type TextMsg struct {
 	SentAt     time.Time `json:"sent_at,omitempty"`      // 发送时间 (服务端设置)
 }
 
+// OfflinePush 因接收方连接积压而未能发送的推送，保存原消息ID，下次登录时补发
+type OfflinePush struct {
+	MsgID     uint32 `json:"msg_id"`
+	Data      []byte `json:"data"`
+	Timestamp int64  `json:"timestamp"`
+}
+
 // LegacyHistoryMsgReq 旧版获取历史消息请求结构
 // 由客户端发送给服务端
 type LegacyHistoryMsgReq struct {
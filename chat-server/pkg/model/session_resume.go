@@ -0,0 +1,34 @@
+package model
+
+// ResumeSessionReq 断线重连后恢复会话的请求，在新连接上代替登录请求发送
+// LastSeq 为客户端最后收到的推送序号：登录后从 0 开始，每收到一条 protocol.IsPushMsgID 为真的消息加一
+type ResumeSessionReq struct {
+	ResumeToken string `json:"resume_token" binding:"required"`
+	LastSeq     uint64 `json:"last_seq"`
+}
+
+// TokenLoginReq 会话无法恢复时凭登录时签发的 JWT 重新登录，客户端不需要保存密码
+type TokenLoginReq struct {
+	Token         string `json:"token" binding:"required"`
+	ClientVersion string `json:"client_version,omitempty"`
+	DeviceID      string `json:"device_id,omitempty"`
+}
+
+// ResumeSessionResp 恢复会话成功的响应，之后服务端按顺序补发 Replayed 条错过的推送
+// ResumeToken 为新的恢复令牌，旧令牌已失效
+type ResumeSessionResp struct {
+	ID          uint   `json:"id"`
+	UserUUID    string `json:"user_uuid"`
+	Username    string `json:"username"`
+	AccountKind string `json:"account_kind,omitempty"`
+	ResumeToken string `json:"resume_token"`
+	Seq         uint64 `json:"seq"`      // 补发完成后的推送序号
+	Replayed    int    `json:"replayed"` // 补发的推送条数
+}
+
+// ServerGoingAwayPush 服务端节点即将关闭的推送，之前已入队的推送发送完毕后服务端关闭连接
+// 会话只保存在当前节点，重连到其他节点后需要凭 JWT 重新登录；客户端在 ReconnectWithin 秒内随机挑选时机重连，避免同时涌向其他节点
+type ServerGoingAwayPush struct {
+	Reason          string `json:"reason"`
+	ReconnectWithin int    `json:"reconnect_within"`
+}
@@ -0,0 +1,51 @@
+package model
+
+import "time"
+
+// BackupCodeCount 开启两步验证时生成的备用恢复码数量
+const BackupCodeCount = 10
+
+const (
+	// TwoFactorChallengeTTL 密码验证通过后提交验证码的时限
+	TwoFactorChallengeTTL = 5 * time.Minute
+	// TwoFactorMaxAttempts 同一次登录允许提交验证码的次数，用完需重新输入密码
+	TwoFactorMaxAttempts = 5
+)
+
+// UserBackupCode 两步验证备用恢复码，每个只能使用一次，数据库只保存摘要
+type UserBackupCode struct {
+	ID        uint       `json:"id" gorm:"primarykey"`
+	UserID    uint       `json:"user_id" gorm:"not null;index"`
+	CodeHash  string     `json:"-" gorm:"type:char(64);not null"`
+	UsedAt    *time.Time `json:"used_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// --- Request and Response Structs ---
+
+// EnrollTOTPResp 开始启用两步验证的响应，用户需将密钥添加到认证器应用
+type EnrollTOTPResp struct {
+	Secret          string `json:"secret"`
+	ProvisioningURI string `json:"provisioning_uri"`
+}
+
+// ConfirmTOTPReq 用认证器生成的验证码确认启用两步验证
+type ConfirmTOTPReq struct {
+	Code string `json:"code" binding:"required"`
+}
+
+// ConfirmTOTPResp 两步验证启用成功，备用恢复码只在此时返回一次
+type ConfirmTOTPResp struct {
+	BackupCodes []string `json:"backup_codes"`
+}
+
+// DisableTOTPReq 关闭两步验证，需要同时提供密码和验证码（或备用恢复码）
+type DisableTOTPReq struct {
+	Password string `json:"password" binding:"required"`
+	Code     string `json:"code" binding:"required"`
+}
+
+// TwoFactorLoginReq 登录第二步，提交验证码或备用恢复码
+type TwoFactorLoginReq struct {
+	Code string `json:"code" binding:"required"`
+}
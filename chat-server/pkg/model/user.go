@@ -6,17 +6,30 @@ import (
 
 // User 用户模型，包含用户基本信息
 type User struct {
-	ID        uint      `json:"id" gorm:"primarykey"`                                   // GORM 主键
-	UserUUID  string    `json:"user_uuid" gorm:"type:varchar(36);uniqueIndex;not null"` // 用户唯一业务标识 (替代原 UserID)
-	Username  string    `json:"username" gorm:"type:varchar(50);uniqueIndex;not null"`
-	Password  string    `json:"-" gorm:"type:varchar(100);not null"` // 存储哈希后的密码, JSON中忽略
-	Email     string    `json:"email" gorm:"type:varchar(100);uniqueIndex"`
-	Avatar    string    `json:"avatar" gorm:"type:varchar(255)"` // 新增头像字段
-	Gender    string    `json:"gender" gorm:"type:varchar(10)"`  // 新增性别字段
-	IsOnline  bool      `json:"is_online" gorm:"default:false"`  // Added IsOnline field
-	CreatedAt time.Time `json:"created_at" gorm:"autoCreateTime"`
-	UpdatedAt time.Time `json:"updated_at" gorm:"autoUpdateTime"`
-	LastLogin time.Time `json:"last_login,omitempty"`
+	ID            uint       `json:"id" gorm:"primarykey"`                                   // GORM 主键
+	UserUUID      string     `json:"user_uuid" gorm:"type:varchar(36);uniqueIndex;not null"` // 用户唯一业务标识 (替代原 UserID)
+	Username      string     `json:"username" gorm:"type:varchar(50);uniqueIndex;not null"`
+	Password      string     `json:"-" gorm:"type:varchar(100);not null"` // 存储哈希后的密码, JSON中忽略
+	Email         string     `json:"email" gorm:"type:varchar(100);uniqueIndex"`
+	Nickname      string     `json:"nickname" gorm:"type:varchar(50);index:idx_user_nickname"`       // 昵称，可以重复
+	Avatar        string     `json:"avatar" gorm:"type:varchar(255)"`                                // 新增头像字段
+	Gender        string     `json:"gender" gorm:"type:varchar(10)"`                                 // 新增性别字段
+	Bio           string     `json:"bio" gorm:"type:varchar(255)"`                                   // 个人简介
+	IsOnline      bool       `json:"is_online" gorm:"default:false"`                                 // Added IsOnline field
+	DMPrivacy     string     `json:"dm_privacy" gorm:"type:varchar(20);not null;default:'everyone'"` // 谁可以给我发私聊
+	Searchable    bool       `json:"searchable" gorm:"not null;default:true"`                        // 是否允许被其他用户搜索到
+	EmailVerified bool       `json:"email_verified" gorm:"not null;default:false"`                   // 邮箱是否已验证
+	TokenVersion  uint       `json:"-" gorm:"not null;default:0"`                                    // 修改或重置密码时递增，使之前签发的 Token 失效
+	TOTPEnabled   bool       `json:"totp_enabled" gorm:"column:totp_enabled;not null;default:false"` // 是否已开启两步验证
+	TOTPSecret    string     `json:"-" gorm:"column:totp_secret;type:varchar(64)"`                   // 两步验证密钥，确认前为待启用状态
+	TOTPLastStep  int64      `json:"-" gorm:"column:totp_last_step;not null;default:0"`              // 最近一次使用的验证码时间步，防止重放
+	CreatedAt     time.Time  `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt     time.Time  `json:"updated_at" gorm:"autoUpdateTime"`
+	LastLogin     time.Time  `json:"last_login,omitempty"`
+	LastLoginIP   string     `json:"last_login_ip,omitempty" gorm:"type:varchar(45)"`                     // 最后登录IP
+	DeletionDueAt *time.Time `json:"deletion_due_at,omitempty" gorm:"index"`                              // 申请注销后账号被清除的时间，为空表示未申请注销
+	AccountKind   string     `json:"account_kind" gorm:"type:varchar(10);not null;default:'human';index"` // 账号类型: human、guest、bot
+	BotOwnerID    uint       `json:"bot_owner_id,omitempty" gorm:"index;not null;default:0"`              // 机器人账号的创建者
 }
 
 // UserRegisterReq 用户注册请求结构
@@ -24,12 +37,16 @@ type UserRegisterReq struct {
 	Username string `json:"username" binding:"required,min=3,max=50"`
 	Password string `json:"password" binding:"required,min=6,max=50"`
 	Email    string `json:"email" binding:"required,email"`
+	ClientIP string `json:"-"` // 由服务端根据连接填充，用于注册限流
 }
 
 // UserLoginReq 用户登录请求结构
 type UserLoginReq struct {
-	Username string `json:"username" binding:"required"`
-	Password string `json:"password" binding:"required"`
+	Username      string `json:"username" binding:"required"`
+	Password      string `json:"password" binding:"required"`
+	ClientIP      string `json:"-"`                        // 由服务端根据连接填充，用于按IP统计登录失败
+	ClientVersion string `json:"client_version,omitempty"` // 客户端版本，记录到登录历史
+	DeviceID      string `json:"device_id,omitempty"`      // 客户端设备标识，用于识别新设备登录
 }
 
 // UserBasicInfo 用户基本信息，用于列表或嵌入其他响应中
@@ -42,13 +59,18 @@ type UserBasicInfo struct {
 
 // UserLoginResponse 用户登录响应结构
 type UserLoginResponse struct {
-	ID        uint      `json:"id"`
-	UserUUID  string    `json:"user_uuid"`
-	Username  string    `json:"username"`
-	Email     string    `json:"email"`
-	Avatar    string    `json:"avatar"`
-	LastLogin time.Time `json:"last_login"`
-	Token     string    `json:"token"`
+	ID            uint       `json:"id"`
+	UserUUID      string     `json:"user_uuid"`
+	Username      string     `json:"username"`
+	Email         string     `json:"email"`
+	Avatar        string     `json:"avatar"`
+	LastLogin     time.Time  `json:"last_login"`
+	LastLoginIP   string     `json:"last_login_ip,omitempty"` // 上一次登录的IP
+	Token         string     `json:"token"`
+	EmailVerified bool       `json:"email_verified"`
+	DeletionDueAt *time.Time `json:"deletion_due_at,omitempty"` // 账号处于注销冷静期时返回清除时间，访客账号为到期时间
+	AccountKind   string     `json:"account_kind,omitempty"`    // 账号类型，客户端据此隐藏不可用的功能
+	ResumeToken   string     `json:"resume_token,omitempty"`    // 断线重连时用于恢复会话，仅 TCP 登录返回
 }
 
 // UserRegisterResponse 用户注册响应结构 (通常注册成功后直接返回用户信息和Token，类似登录响应)
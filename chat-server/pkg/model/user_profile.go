@@ -0,0 +1,74 @@
+package model
+
+// 性别取值，空字符串表示未设置
+const (
+	GenderMale   = "male"
+	GenderFemale = "female"
+	GenderOther  = "other"
+)
+
+// 用户资料相关的长度限制（字符数）
+const (
+	MaxNicknameLen  = 30
+	MaxBioLen       = 200
+	MaxAvatarURLLen = 255
+)
+
+// 用户搜索分页参数
+const (
+	DefaultUserSearchPageSize = 20
+	MaxUserSearchPageSize     = 50
+)
+
+// IsValidGender 判断性别取值是否合法
+func IsValidGender(gender string) bool {
+	switch gender {
+	case "", GenderMale, GenderFemale, GenderOther:
+		return true
+	}
+	return false
+}
+
+// UserProfile 用户资料，查看他人资料时不包含隐私设置
+type UserProfile struct {
+	ID         uint   `json:"id"`
+	UserUUID   string `json:"user_uuid"`
+	Username   string `json:"username"`
+	Nickname   string `json:"nickname"`
+	Avatar     string `json:"avatar"`
+	Gender     string `json:"gender"`
+	Bio        string `json:"bio"`
+	IsOnline   bool   `json:"is_online"`            // 仅本人和好友可见，其他人看到的始终为 false
+	DMPrivacy  string `json:"dm_privacy,omitempty"` // 仅本人可见
+	Searchable *bool  `json:"searchable,omitempty"` // 仅本人可见
+}
+
+// UpdateProfileReq 更新用户资料请求，字段为 nil 表示不修改，空字符串表示清除
+type UpdateProfileReq struct {
+	Nickname   *string `json:"nickname,omitempty" binding:"omitempty,max=30"`
+	Avatar     *string `json:"avatar,omitempty" binding:"omitempty,max=255"`
+	Gender     *string `json:"gender,omitempty" binding:"omitempty,oneof=male female other"`
+	Bio        *string `json:"bio,omitempty" binding:"omitempty,max=200"`
+	Searchable *bool   `json:"searchable,omitempty"`
+}
+
+// GetProfileReq 获取用户资料请求，Target 为空时获取自己的资料
+type GetProfileReq struct {
+	Target string `json:"target,omitempty"` // 对方的 UserUUID、用户名或数字ID
+}
+
+// SearchUsersReq 搜索用户请求，按用户名或昵称匹配
+type SearchUsersReq struct {
+	Keyword  string `json:"keyword" binding:"required"`
+	Page     int    `json:"page,omitempty"`      // 从 1 开始，默认为 1
+	PageSize int    `json:"page_size,omitempty"` // 默认为 20，最大为 50
+}
+
+// SearchUsersResp 搜索用户响应
+type SearchUsersResp struct {
+	Users    []*UserProfile `json:"users"`
+	Total    int            `json:"total"`
+	Page     int            `json:"page"`
+	PageSize int            `json:"page_size"`
+	HasMore  bool           `json:"has_more"`
+}
@@ -0,0 +1,140 @@
+package protocol
+
+import (
+	"bytes"
+	"compress/flate"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// 消息体压缩算法，握手时按连接协商，只用于服务端发往客户端的消息
+// 服务端收包使用 zinx 自带的拆包逻辑，客户端发送的消息始终不压缩
+const (
+	CompressionNone    = "none"
+	CompressionDeflate = "deflate"
+	CompressionZstd    = "zstd"
+)
+
+// CompressedFlag 消息ID的最高位，置位表示消息体已按连接协商的算法压缩，客户端拆包时去掉该位再解压
+const CompressedFlag uint32 = 1 << 31
+
+// maxDecoderMemory zstd 解码时允许使用的最大内存，防止构造的数据导致内存耗尽
+const maxDecoderMemory = 64 << 20
+
+// ErrDecompressedTooLarge 解压后的数据超过上限
+var ErrDecompressedTooLarge = errors.New("decompressed message too large")
+
+var (
+	flateWriters = sync.Pool{New: func() interface{} {
+		w, _ := flate.NewWriter(nil, flate.DefaultCompression)
+		return w
+	}}
+
+	zstdOnce    sync.Once
+	zstdEncoder *zstd.Encoder
+	zstdDecoder *zstd.Decoder
+	zstdErr     error
+
+	// zstdStreams 有长度上限时使用的流式解码器，边解码边计数，超过上限即停止，不会先解出完整的数据
+	zstdStreams = sync.Pool{New: func() interface{} {
+		d, err := zstd.NewReader(nil, zstd.WithDecoderConcurrency(1), zstd.WithDecoderMaxMemory(maxDecoderMemory))
+		if err != nil {
+			return err
+		}
+		return d
+	}}
+)
+
+func initZstd() {
+	zstdOnce.Do(func() {
+		zstdEncoder, zstdErr = zstd.NewWriter(nil, zstd.WithEncoderLevel(zstd.SpeedDefault))
+		if zstdErr != nil {
+			return
+		}
+		zstdDecoder, zstdErr = zstd.NewReader(nil, zstd.WithDecoderConcurrency(0), zstd.WithDecoderMaxMemory(maxDecoderMemory))
+	})
+}
+
+// Compress 按 algorithm 压缩消息体
+func Compress(algorithm string, data []byte) ([]byte, error) {
+	switch algorithm {
+	case CompressionDeflate:
+		var buf bytes.Buffer
+		w := flateWriters.Get().(*flate.Writer)
+		defer flateWriters.Put(w)
+		w.Reset(&buf)
+		if _, err := w.Write(data); err != nil {
+			return nil, err
+		}
+		if err := w.Close(); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	case CompressionZstd:
+		if initZstd(); zstdErr != nil {
+			return nil, zstdErr
+		}
+		return zstdEncoder.EncodeAll(data, nil), nil
+	default:
+		return nil, fmt.Errorf("unsupported compression %q", algorithm)
+	}
+}
+
+// Decompress 按 algorithm 解压消息体，解压后超过 limit 字节时返回 ErrDecompressedTooLarge，limit 为 0 表示不限
+func Decompress(algorithm string, data []byte, limit int) ([]byte, error) {
+	var out []byte
+	switch algorithm {
+	case CompressionDeflate:
+		r := flate.NewReader(bytes.NewReader(data))
+		defer r.Close()
+		var src io.Reader = r
+		if limit > 0 {
+			src = io.LimitReader(r, int64(limit)+1)
+		}
+		var err error
+		if out, err = io.ReadAll(src); err != nil {
+			return nil, err
+		}
+	case CompressionZstd:
+		if initZstd(); zstdErr != nil {
+			return nil, zstdErr
+		}
+		var err error
+		if limit > 0 {
+			out, err = zstdDecodeLimited(data, limit)
+		} else {
+			out, err = zstdDecoder.DecodeAll(data, nil)
+		}
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unsupported compression %q", algorithm)
+	}
+	if limit > 0 && len(out) > limit {
+		return nil, ErrDecompressedTooLarge
+	}
+	return out, nil
+}
+
+// zstdDecodeLimited 流式解码，最多读出 limit+1 字节，由调用方判断是否超过上限
+// 传入 bytes.Reader 而不是 bytes.Buffer，否则解码器会退化为一次性解码整段数据
+func zstdDecodeLimited(data []byte, limit int) ([]byte, error) {
+	v := zstdStreams.Get()
+	d, ok := v.(*zstd.Decoder)
+	if !ok {
+		return nil, v.(error)
+	}
+	defer func() {
+		_ = d.Reset(nil)
+		zstdStreams.Put(d)
+	}()
+	if err := d.Reset(bytes.NewReader(data)); err != nil {
+		return nil, err
+	}
+	return io.ReadAll(io.LimitReader(d, int64(limit)+1))
+}
@@ -0,0 +1,40 @@
+package protocol
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestDecompressRoundTrip(t *testing.T) {
+	data := bytes.Repeat([]byte(`{"content":"hello"}`), 100)
+	for _, algorithm := range []string{CompressionDeflate, CompressionZstd} {
+		compressed, err := Compress(algorithm, data)
+		if err != nil {
+			t.Fatalf("%s: %v", algorithm, err)
+		}
+		for _, limit := range []int{0, len(data)} {
+			out, err := Decompress(algorithm, compressed, limit)
+			if err != nil {
+				t.Fatalf("%s limit %d: %v", algorithm, limit, err)
+			}
+			if !bytes.Equal(out, data) {
+				t.Fatalf("%s limit %d: got %d bytes, want %d", algorithm, limit, len(out), len(data))
+			}
+		}
+	}
+}
+
+func TestDecompressRejectsOversizedOutput(t *testing.T) {
+	// 高压缩比的数据，解压后远大于上限
+	data := make([]byte, 8<<20)
+	for _, algorithm := range []string{CompressionDeflate, CompressionZstd} {
+		compressed, err := Compress(algorithm, data)
+		if err != nil {
+			t.Fatalf("%s: %v", algorithm, err)
+		}
+		if _, err := Decompress(algorithm, compressed, 1024); !errors.Is(err, ErrDecompressedTooLarge) {
+			t.Fatalf("%s: err = %v, want ErrDecompressedTooLarge", algorithm, err)
+		}
+	}
+}
@@ -46,10 +46,168 @@ const (
 	MsgIDGroupHistoryMsgReq  // 227: 获取群聊历史消息请求
 	MsgIDGroupHistoryMsgResp // 228: 获取群聊历史消息响应
 
+	// 群成员邀请相关 300 - 309
+	MsgIDInviteGroupMemberReq  uint32 = 300 // C->S 邀请用户入群请求
+	MsgIDInviteGroupMemberResp uint32 = 301 // S->C 邀请用户入群响应
+	MsgIDGroupInvitedPush      uint32 = 302 // S->C 推送被邀请入群通知
+
 	// 群组消息相关 310 - 319
 	MsgIDGroupTextMsgReq  uint32 = 310 // C->S 发送群组文本消息请求
 	MsgIDGroupTextMsgResp uint32 = 311 // S->C 发送群组文本消息响应
 	MsgIDGroupTextMsgPush uint32 = 312 // S->C 推送群组文本消息
+
+	// 群公告与置顶消息相关 320 - 339
+	MsgIDUpdateGroupAnnouncementReq      uint32 = 320 // C->S 编辑群公告请求
+	MsgIDUpdateGroupAnnouncementResp     uint32 = 321 // S->C 编辑群公告响应
+	MsgIDGetGroupAnnouncementHistoryReq  uint32 = 322 // C->S 获取群公告历史请求
+	MsgIDGetGroupAnnouncementHistoryResp uint32 = 323 // S->C 获取群公告历史响应
+	MsgIDAckGroupAnnouncementReq         uint32 = 324 // C->S 确认群公告请求
+	MsgIDAckGroupAnnouncementResp        uint32 = 325 // S->C 确认群公告响应
+	MsgIDPinGroupMessageReq              uint32 = 326 // C->S 置顶群消息请求
+	MsgIDPinGroupMessageResp             uint32 = 327 // S->C 置顶群消息响应
+	MsgIDUnpinGroupMessageReq            uint32 = 328 // C->S 取消置顶群消息请求
+	MsgIDUnpinGroupMessageResp           uint32 = 329 // S->C 取消置顶群消息响应
+	MsgIDGroupAnnouncementPush           uint32 = 330 // S->C 推送群公告变更
+	MsgIDGroupPinnedMsgPush              uint32 = 331 // S->C 推送群置顶消息变更
+
+	// 群组封禁与禁言相关 340 - 349
+	MsgIDBanGroupMemberReq    uint32 = 340 // C->S 封禁群成员请求
+	MsgIDBanGroupMemberResp   uint32 = 341 // S->C 封禁群成员响应
+	MsgIDUnbanGroupMemberReq  uint32 = 342 // C->S 解除群组封禁请求
+	MsgIDUnbanGroupMemberResp uint32 = 343 // S->C 解除群组封禁响应
+	MsgIDGetGroupBanListReq   uint32 = 344 // C->S 获取群组封禁列表请求
+	MsgIDGetGroupBanListResp  uint32 = 345 // S->C 获取群组封禁列表响应
+	MsgIDMuteGroupMemberReq   uint32 = 346 // C->S 禁言/解除禁言群成员请求
+	MsgIDMuteGroupMemberResp  uint32 = 347 // S->C 禁言/解除禁言群成员响应
+
+	// 群组角色与权限相关 350 - 359
+	MsgIDSaveGroupRoleReq    uint32 = 350 // C->S 创建/修改群组自定义角色请求
+	MsgIDSaveGroupRoleResp   uint32 = 351 // S->C 创建/修改群组自定义角色响应
+	MsgIDDeleteGroupRoleReq  uint32 = 352 // C->S 删除群组自定义角色请求
+	MsgIDDeleteGroupRoleResp uint32 = 353 // S->C 删除群组自定义角色响应
+	MsgIDGetGroupRolesReq    uint32 = 354 // C->S 获取群组角色列表请求
+	MsgIDGetGroupRolesResp   uint32 = 355 // S->C 获取群组角色列表响应
+
+	// 公开群组目录相关 360 - 369
+	MsgIDSearchGroupsReq  uint32 = 360 // C->S 搜索公开群组请求
+	MsgIDSearchGroupsResp uint32 = 361 // S->C 搜索公开群组响应
+
+	// 好友相关 370 - 389
+	MsgIDSendFriendRequestReq    uint32 = 370 // C->S 发送好友申请请求
+	MsgIDSendFriendRequestResp   uint32 = 371 // S->C 发送好友申请响应
+	MsgIDHandleFriendRequestReq  uint32 = 372 // C->S 接受/拒绝好友申请请求
+	MsgIDHandleFriendRequestResp uint32 = 373 // S->C 接受/拒绝好友申请响应
+	MsgIDGetFriendRequestsReq    uint32 = 374 // C->S 获取待处理好友申请请求
+	MsgIDGetFriendRequestsResp   uint32 = 375 // S->C 获取待处理好友申请响应
+	MsgIDGetFriendListReq        uint32 = 376 // C->S 获取好友列表请求
+	MsgIDGetFriendListResp       uint32 = 377 // S->C 获取好友列表响应
+	MsgIDRemoveFriendReq         uint32 = 378 // C->S 删除好友请求
+	MsgIDRemoveFriendResp        uint32 = 379 // S->C 删除好友响应
+	MsgIDSetFriendRemarkReq      uint32 = 380 // C->S 设置好友备注请求
+	MsgIDSetFriendRemarkResp     uint32 = 381 // S->C 设置好友备注响应
+	MsgIDFriendRequestPush       uint32 = 382 // S->C 推送新的好友申请或申请处理结果
+	MsgIDFriendRemovedPush       uint32 = 383 // S->C 推送被对方删除好友
+
+	// 用户屏蔽与私聊隐私相关 390 - 399
+	MsgIDBlockUserReq     uint32 = 390 // C->S 屏蔽用户请求
+	MsgIDBlockUserResp    uint32 = 391 // S->C 屏蔽用户响应
+	MsgIDUnblockUserReq   uint32 = 392 // C->S 取消屏蔽请求
+	MsgIDUnblockUserResp  uint32 = 393 // S->C 取消屏蔽响应
+	MsgIDGetBlockListReq  uint32 = 394 // C->S 获取屏蔽列表请求
+	MsgIDGetBlockListResp uint32 = 395 // S->C 获取屏蔽列表响应
+	MsgIDSetDMPrivacyReq  uint32 = 396 // C->S 设置私聊隐私请求
+	MsgIDSetDMPrivacyResp uint32 = 397 // S->C 设置私聊隐私响应
+	MsgIDGetDMPrivacyReq  uint32 = 398 // C->S 获取私聊隐私设置请求
+	MsgIDGetDMPrivacyResp uint32 = 399 // S->C 获取私聊隐私设置响应
+
+	// 用户资料与用户搜索相关 400 - 409
+	MsgIDUpdateProfileReq   uint32 = 400 // C->S 更新用户资料请求
+	MsgIDUpdateProfileResp  uint32 = 401 // S->C 更新用户资料响应
+	MsgIDGetProfileReq      uint32 = 402 // C->S 获取用户资料请求
+	MsgIDGetProfileResp     uint32 = 403 // S->C 获取用户资料响应
+	MsgIDSearchUsersReq     uint32 = 404 // C->S 搜索用户请求
+	MsgIDSearchUsersResp    uint32 = 405 // S->C 搜索用户响应
+	MsgIDProfileChangedPush uint32 = 406 // S->C 推送好友资料变更
+
+	// 账号安全相关 410 - 429
+	MsgIDChangePasswordReq        uint32 = 410 // C->S 修改密码请求
+	MsgIDChangePasswordResp       uint32 = 411 // S->C 修改密码响应
+	MsgIDRequestPasswordResetReq  uint32 = 412 // C->S 申请重置密码请求
+	MsgIDRequestPasswordResetResp uint32 = 413 // S->C 申请重置密码响应
+	MsgIDResetPasswordReq         uint32 = 414 // C->S 使用令牌重置密码请求
+	MsgIDResetPasswordResp        uint32 = 415 // S->C 使用令牌重置密码响应
+	MsgIDSendVerifyEmailReq       uint32 = 416 // C->S 发送邮箱验证邮件请求
+	MsgIDSendVerifyEmailResp      uint32 = 417 // S->C 发送邮箱验证邮件响应
+	MsgIDVerifyEmailReq           uint32 = 418 // C->S 验证邮箱请求
+	MsgIDVerifyEmailResp          uint32 = 419 // S->C 验证邮箱响应
+	MsgIDSessionRevokedPush       uint32 = 420 // S->C 推送会话已失效（密码被修改或账号已注销）
+	MsgIDUnlockAccountReq         uint32 = 421 // C->S 管理员解除登录锁定请求
+	MsgIDUnlockAccountResp        uint32 = 422 // S->C 管理员解除登录锁定响应
+
+	// 两步验证相关 430 - 439
+	MsgIDEnrollTOTPReq     uint32 = 430 // C->S 生成两步验证密钥请求
+	MsgIDEnrollTOTPResp    uint32 = 431 // S->C 生成两步验证密钥响应
+	MsgIDConfirmTOTPReq    uint32 = 432 // C->S 确认开启两步验证请求
+	MsgIDConfirmTOTPResp   uint32 = 433 // S->C 确认开启两步验证响应（含备用恢复码）
+	MsgIDDisableTOTPReq    uint32 = 434 // C->S 关闭两步验证请求
+	MsgIDDisableTOTPResp   uint32 = 435 // S->C 关闭两步验证响应
+	MsgIDTwoFactorLoginReq uint32 = 436 // C->S 登录第二步提交验证码，响应为 MsgIDLoginResp
+
+	// 登录历史相关 440 - 449
+	MsgIDGetLoginHistoryReq  uint32 = 440 // C->S 查询登录历史请求
+	MsgIDGetLoginHistoryResp uint32 = 441 // S->C 查询登录历史响应
+	MsgIDSuspiciousLoginPush uint32 = 442 // S->C 推送新IP或新设备登录提醒
+
+	// 账号注销与数据导出相关 450 - 469
+	MsgIDDeleteAccountReq             uint32 = 450 // C->S 申请注销账号请求
+	MsgIDDeleteAccountResp            uint32 = 451 // S->C 申请注销账号响应
+	MsgIDCancelAccountDeletionReq     uint32 = 452 // C->S 撤销注销申请请求
+	MsgIDCancelAccountDeletionResp    uint32 = 453 // S->C 撤销注销申请响应
+	MsgIDGetAccountDeletionStatusReq  uint32 = 454 // C->S 查询注销申请状态请求
+	MsgIDGetAccountDeletionStatusResp uint32 = 455 // S->C 查询注销申请状态响应
+	MsgIDRequestDataExportReq         uint32 = 456 // C->S 申请导出个人数据请求
+	MsgIDRequestDataExportResp        uint32 = 457 // S->C 申请导出个人数据响应
+	MsgIDDownloadDataExportReq        uint32 = 458 // C->S 分片下载导出文件请求
+	MsgIDDownloadDataExportResp       uint32 = 459 // S->C 分片下载导出文件响应
+	MsgIDDataExportReadyPush          uint32 = 460 // S->C 推送导出文件已生成（或生成失败）
+
+	// 访客与机器人账号相关 470 - 479，访客和机器人登录的响应使用 MsgIDLoginResp
+	MsgIDGuestLoginReq    uint32 = 470 // C->S 访客登录请求
+	MsgIDBotLoginReq      uint32 = 471 // C->S 机器人使用 API Key 登录请求
+	MsgIDCreateBotReq     uint32 = 472 // C->S 创建机器人请求
+	MsgIDCreateBotResp    uint32 = 473 // S->C 创建机器人响应
+	MsgIDRotateBotKeyReq  uint32 = 474 // C->S 重新生成机器人 API Key 请求
+	MsgIDRotateBotKeyResp uint32 = 475 // S->C 重新生成机器人 API Key 响应
+	MsgIDGetMyBotsReq     uint32 = 476 // C->S 查询自己创建的机器人请求
+	MsgIDGetMyBotsResp    uint32 = 477 // S->C 查询自己创建的机器人响应
+
+	// 机器人 outgoing webhook 相关 480 - 489
+	MsgIDSetBotWebhookReq     uint32 = 480 // C->S 创建或修改机器人在群里的 webhook 请求
+	MsgIDSetBotWebhookResp    uint32 = 481 // S->C 创建或修改 webhook 响应
+	MsgIDDeleteBotWebhookReq  uint32 = 482 // C->S 删除机器人在群里的 webhook 请求
+	MsgIDDeleteBotWebhookResp uint32 = 483 // S->C 删除 webhook 响应
+	MsgIDGetBotWebhooksReq    uint32 = 484 // C->S 查询机器人的 webhook 请求
+	MsgIDGetBotWebhooksResp   uint32 = 485 // S->C 查询机器人的 webhook 响应
+
+	// 群组 incoming webhook 相关 490 - 499，外部系统通过 HTTP 发消息，这里只负责管理
+	MsgIDCreateIncomingWebhookReq  uint32 = 490 // C->S 创建群组 incoming webhook 请求
+	MsgIDCreateIncomingWebhookResp uint32 = 491 // S->C 创建 incoming webhook 响应，包含只显示一次的 URL
+	MsgIDRevokeIncomingWebhookReq  uint32 = 492 // C->S 吊销 incoming webhook 请求
+	MsgIDRevokeIncomingWebhookResp uint32 = 493 // S->C 吊销 incoming webhook 响应
+	MsgIDGetIncomingWebhooksReq    uint32 = 494 // C->S 查询群组 incoming webhook 请求
+	MsgIDGetIncomingWebhooksResp   uint32 = 495 // S->C 查询群组 incoming webhook 响应
+
+	// 会话恢复相关 500 - 509，登录响应中的 resume_token 用于断线后恢复会话
+	MsgIDResumeSessionReq  uint32 = 500 // C->S 凭恢复令牌和最后收到的推送序号恢复会话
+	MsgIDResumeSessionResp uint32 = 501 // S->C 恢复会话响应，随后补发错过的推送
+	MsgIDTokenLoginReq     uint32 = 502 // C->S 会话无法恢复时（如重连到其他节点）凭登录签发的 JWT 重新登录，响应为 MsgIDLoginResp
+
+	// 握手相关 510 - 519，连接建立后、登录前协商协议版本和功能
+	MsgIDHelloReq  uint32 = 510 // C->S 上报协议版本、客户端信息和支持的编码、压缩、功能
+	MsgIDHelloResp uint32 = 511 // S->C 协商结果以及服务端版本、限制和已启用的功能
+
+	// 节点下线 520 - 529
+	MsgIDServerGoingAwayPush uint32 = 520 // S->C 服务端节点即将关闭，客户端应在连接断开后重连到其他节点
 )
 
 // 单独定义通用错误响应ID，避免破坏现有 iota 序列
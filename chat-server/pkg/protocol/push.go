@@ -0,0 +1,22 @@
+package protocol
+
+// pushMsgIDs 服务端主动推送的消息ID，会话内按发送顺序编号，断线恢复时据此补发
+// 客户端收到这些ID的消息时把推送序号加一，恢复会话时上报最后的序号
+// MsgIDSessionRevokedPush 和 MsgIDServerGoingAwayPush 在关闭连接前发送，不参与编号
+var pushMsgIDs = map[uint32]bool{
+	MsgIDTextMsg:               true,
+	MsgIDGroupInvitedPush:      true,
+	MsgIDGroupTextMsgPush:      true,
+	MsgIDGroupAnnouncementPush: true,
+	MsgIDGroupPinnedMsgPush:    true,
+	MsgIDFriendRequestPush:     true,
+	MsgIDFriendRemovedPush:     true,
+	MsgIDProfileChangedPush:    true,
+	MsgIDSuspiciousLoginPush:   true,
+	MsgIDDataExportReadyPush:   true,
+}
+
+// IsPushMsgID 判断服务端发出的消息是否为参与会话编号的推送
+func IsPushMsgID(msgID uint32) bool {
+	return pushMsgIDs[msgID]
+}
@@ -0,0 +1,27 @@
+package protocol
+
+// 协议版本，握手时客户端上报自己实现的版本，双方按较小的版本通信
+// 修改已有消息的格式或语义时递增 ProtocolVersion，新增消息ID不需要
+const (
+	ProtocolVersion = 1       // 服务端实现的协议版本
+	ServerVersion   = "1.0.0" // 服务端程序版本，只用于展示和排查问题
+)
+
+// 消息体编码
+const (
+	CodecJSON = "json"
+)
+
+// 可协商的功能，客户端在握手时声明自己支持的功能，服务端返回其中已启用的部分
+const (
+	FeatureSessionResume = "session_resume" // 断线后凭恢复令牌接回会话
+	FeatureTwoFactor     = "two_factor"     // 登录时的两步验证
+	FeatureGuestLogin    = "guest_login"    // 访客登录
+	FeatureBots          = "bots"           // 机器人账号和 webhook
+)
+
+// SupportedCodecs 服务端支持的编码，按优先级排列
+var SupportedCodecs = []string{CodecJSON}
+
+// SupportedCompression 实现了的压缩算法，服务端实际允许的算法由配置决定
+var SupportedCompression = []string{CompressionZstd, CompressionDeflate, CompressionNone}
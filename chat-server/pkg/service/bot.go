@@ -0,0 +1,28 @@
+package service
+
+import (
+	"errors"
+
+	"github.com/Xaytick/chat-zinx/chat-server/pkg/model"
+	"github.com/Xaytick/chat-zinx/chat-server/pkg/webhook"
+)
+
+var (
+	ErrBotNotInGroup     = errors.New("bot is not a member of this group")
+	ErrWebhookNotFound   = errors.New("webhook not found")
+	ErrWebhookNoTrigger  = errors.New("webhook needs a command prefix or mention trigger")
+	ErrInvalidBotCommand = errors.New("command prefix must start with / and contain no spaces")
+	ErrInvalidWebhookURL = errors.New("invalid webhook url")
+)
+
+// IBotService 定义机器人 webhook 服务接口
+type IBotService interface {
+	// SetWebhook 创建或修改机器人在群里的 webhook，只有机器人的创建者可以操作，且机器人需已在群内
+	SetWebhook(ownerID uint, req *model.SetBotWebhookReq) (*model.SetBotWebhookResp, error)
+	// DeleteWebhook 删除机器人在群里的 webhook
+	DeleteWebhook(ownerID, botUserID, groupID uint) error
+	// GetWebhooks 获取机器人的所有 webhook
+	GetWebhooks(ownerID, botUserID uint) ([]*model.BotWebhook, error)
+	// MatchWebhooks 找出被群消息触发的 webhook，memberIDs 为当前群成员，已不在群内的机器人不会被触发
+	MatchWebhooks(groupID uint, msg *model.WebhookMessage, memberIDs []uint) ([]*webhook.Delivery, error)
+}
@@ -0,0 +1,43 @@
+package service
+
+import (
+	"github.com/Xaytick/chat-zinx/chat-server/pkg/model"
+)
+
+// IFriendService 定义好友服务接口
+type IFriendService interface {
+	// SendFriendRequest 发送好友申请，对方已向自己发出申请时直接成为好友
+	SendFriendRequest(fromUserID uint, req *model.SendFriendRequestReq) (*model.FriendRequestInfo, error)
+	// HandleFriendRequest 接受或拒绝收到的好友申请
+	HandleFriendRequest(userID uint, requestID uint, accept bool) (*model.FriendRequestInfo, error)
+	// GetFriendRequests 获取收到和发出的待处理好友申请
+	GetFriendRequests(userID uint) (*model.GetFriendRequestsResp, error)
+
+	// GetFriendList 获取好友列表
+	GetFriendList(userID uint) ([]*model.FriendInfo, error)
+	// GetFriendIDs 获取全部好友的用户ID，用于向好友推送通知
+	GetFriendIDs(userID uint) ([]uint, error)
+	// RemoveFriend 删除好友，双方的好友关系同时解除
+	RemoveFriend(userID uint, friendUserID uint) error
+	// SetFriendRemark 设置好友备注
+	SetFriendRemark(userID uint, friendUserID uint, remark string) error
+	// AreFriends 判断两个用户是否为好友
+	AreFriends(userID uint, otherUserID uint) (bool, error)
+
+	// BlockUser 屏蔽用户，target 可以是对方的用户名、UUID 或数字ID
+	BlockUser(userID uint, target string) (*model.BlockedUserInfo, error)
+	// UnblockUser 取消屏蔽
+	UnblockUser(userID uint, blockedUserID uint) error
+	// GetBlockList 获取屏蔽列表
+	GetBlockList(userID uint) ([]*model.BlockedUserInfo, error)
+	// IsBlocked 判断 userID 是否屏蔽了 targetID
+	IsBlocked(userID uint, targetID uint) (bool, error)
+
+	// SetDMPrivacy 设置谁可以向自己发送私聊：everyone、contacts 或 nobody
+	SetDMPrivacy(userID uint, privacy string) error
+	// GetDMPrivacy 获取私聊隐私设置
+	GetDMPrivacy(userID uint) (string, error)
+
+	// CheckPrivateChatAllowed 根据屏蔽关系、接收方隐私设置和服务端策略检查是否允许发起私聊
+	CheckPrivateChatAllowed(fromUserID uint, toUserID uint) error
+}
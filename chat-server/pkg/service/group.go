@@ -1,6 +1,10 @@
 package service
 
-import "github.com/Xaytick/chat-zinx/chat-server/pkg/model"
+import (
+	"time"
+
+	"github.com/Xaytick/chat-zinx/chat-server/pkg/model"
+)
 
 // IGroupService 定义群组服务接口
 type IGroupService interface {
@@ -20,9 +24,41 @@ type IGroupService interface {
 	GetGroupMembers(groupID uint) ([]*model.GroupMember, error)
 	// 获取群组成员详细信息(包含用户信息) - 新增
 	GetGroupMembersWithUserInfo(groupID uint) ([]*model.GroupMemberInfo, error)
+	// 分页获取群组成员详细信息，支持按用户名搜索
+	GetGroupMembersPage(req *model.GetGroupMembersReq) (*model.GetGroupMembersResp, error)
+
+	// 公开群组目录相关
+	SearchPublicGroups(req *model.SearchGroupsReq) (*model.SearchGroupsResp, error)
+	GetGroupTags(groupID uint) ([]string, error)
+
+	// 群组权限检查，所有需要鉴权的群组操作都通过该方法
+	CheckPermission(userID uint, groupID uint, perm model.GroupPermission) error
 
 	// 群组管理相关 - 新增
+	InviteGroupMember(operatorID uint, req *model.InviteGroupMemberReq) (*model.Group, error)
 	SetGroupMemberRole(operatorID uint, groupID uint, targetUserID uint, newRole string) error
 	RemoveMemberFromGroup(operatorID uint, groupID uint, targetUserID uint) error
 	UpdateGroupInfo(operatorID uint, groupID uint, updateReq *model.UpdateGroupInfoReq) error
+
+	// 群组自定义角色相关
+	SaveGroupRole(operatorID uint, req *model.SaveGroupRoleReq) (*model.GroupRoleInfo, error)
+	DeleteGroupRole(operatorID uint, groupID uint, name string) error
+	GetGroupRoles(userID uint, groupID uint) ([]*model.GroupRoleInfo, error)
+
+	// 群组封禁相关
+	BanGroupMember(operatorID uint, groupID uint, targetUserID uint, reason string, duration time.Duration) error
+	UnbanGroupMember(operatorID uint, groupID uint, targetUserID uint) error
+	MuteGroupMember(operatorID uint, groupID uint, targetUserID uint, duration time.Duration) error
+	GetGroupBanList(operatorID uint, groupID uint) ([]*model.GroupBanInfo, error)
+
+	// 群公告相关
+	UpdateGroupAnnouncement(operatorID uint, groupID uint, content string) (*model.GroupAnnouncementInfo, error)
+	GetGroupAnnouncement(groupID uint) (*model.GroupAnnouncementInfo, error)
+	GetGroupAnnouncementHistory(userID uint, groupID uint, limit int) ([]*model.GroupAnnouncementInfo, error)
+	AckGroupAnnouncement(userID uint, groupID uint, announcementID uint) (*model.GroupAnnouncementInfo, error)
+
+	// 群置顶消息相关
+	PinGroupMessage(operatorID uint, groupID uint, groupMessageID uint) ([]*model.GroupPinnedMsgInfo, error)
+	UnpinGroupMessage(operatorID uint, groupID uint, groupMessageID uint) ([]*model.GroupPinnedMsgInfo, error)
+	GetGroupPinnedMessages(groupID uint) ([]*model.GroupPinnedMsgInfo, error)
 }
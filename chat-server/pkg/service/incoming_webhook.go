@@ -0,0 +1,33 @@
+package service
+
+import (
+	"errors"
+
+	"github.com/Xaytick/chat-zinx/chat-server/pkg/model"
+)
+
+var (
+	ErrIncomingWebhookNotFound = errors.New("incoming webhook not found")
+	ErrInvalidWebhookToken     = errors.New("invalid webhook token")
+	ErrTooManyIncomingWebhooks = errors.New("incoming webhook limit reached for this group")
+	ErrInvalidIntegrationName  = errors.New("integration name must be 1-32 characters")
+	ErrEmptyWebhookMessage     = errors.New("text or title is required")
+	ErrWebhookMessageTooLong   = errors.New("message too long")
+	ErrTooManyWebhookFields    = errors.New("too many fields")
+	ErrWebhookRateLimited      = errors.New("incoming webhook rate limit exceeded")
+)
+
+// IIncomingWebhookService 定义群组 incoming webhook 服务接口
+type IIncomingWebhookService interface {
+	// Create 为群组创建 webhook，需要 manage_integrations 权限，返回只显示一次的令牌和 URL
+	Create(userID uint, req *model.CreateIncomingWebhookReq) (*model.CreateIncomingWebhookResp, error)
+	// Revoke 吊销群组的 webhook
+	Revoke(userID, groupID, hookID uint) error
+	// List 获取群组未吊销的 webhook
+	List(userID, groupID uint) ([]*model.IncomingWebhook, error)
+	// Authenticate 根据 URL 中的令牌查找有效的 webhook 并计入限流，令牌无效或已吊销时返回 ErrInvalidWebhookToken，
+	// 超过限制时返回 *ThrottledError，限流不可用时返回错误而不是放行
+	Authenticate(token string) (*model.IncomingWebhook, error)
+	// FormatMessage 把请求体组装成群消息内容
+	FormatMessage(payload *model.IncomingWebhookPayload) (string, error)
+}
@@ -22,6 +22,12 @@ type IMessageService interface {
 	// HasOfflineMessages 检查用户是否有离线消息
 	HasOfflineMessages(userID uint) bool
 
+	// SaveOfflinePush 保存因连接积压被丢弃的推送
+	SaveOfflinePush(userID uint, msgID uint32, data []byte) error
+
+	// GetOfflinePushes 获取并清空用户的离线推送
+	GetOfflinePushes(userID uint) ([]*model.OfflinePush, error)
+
 	// GetHistoryMessages 获取历史消息
 	GetHistoryMessages(userID1, userID2 uint, limit int) ([]map[string]interface{}, error)
 
@@ -73,6 +79,16 @@ func (s *RedisMessageService) HasOfflineMessages(userID uint) bool {
 	return has
 }
 
+// SaveOfflinePush 保存因连接积压被丢弃的推送
+func (s *RedisMessageService) SaveOfflinePush(userID uint, msgID uint32, data []byte) error {
+	return s.storage.SaveOfflinePush(userID, msgID, data)
+}
+
+// GetOfflinePushes 获取并清空用户的离线推送
+func (s *RedisMessageService) GetOfflinePushes(userID uint) ([]*model.OfflinePush, error) {
+	return s.storage.GetOfflinePushes(userID)
+}
+
 // GetHistoryMessages 获取历史消息
 func (s *RedisMessageService) GetHistoryMessages(userID1, userID2 uint, limit int) ([]map[string]interface{}, error) {
 	return s.storage.GetHistoryMessages(userID1, userID2, int64(limit))
@@ -118,7 +134,7 @@ func (s *RedisMessageService) GetGroupHistory(userID, groupID uint, lastID uint,
 		return nil, fmt.Errorf("failed to check group membership: %w", err)
 	}
 	if !isMember {
-		return nil, fmt.Errorf("user %d is not a member of group %d", userID, groupID)
+		return nil, ErrNotGroupMember
 	}
 
 	// 2. 获取历史消息
@@ -0,0 +1,211 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/Xaytick/chat-zinx/chat-server/dao/mysql"
+	"github.com/Xaytick/chat-zinx/chat-server/pkg/model"
+	"github.com/Xaytick/chat-zinx/chat-server/pkg/storage"
+)
+
+var (
+	// ErrBlockedByUser 对方屏蔽了当前用户
+	ErrBlockedByUser = errors.New("this user does not accept messages from you")
+	// ErrUserBlockedByYou 当前用户屏蔽了对方
+	ErrUserBlockedByYou = errors.New("you have blocked this user")
+	// ErrDMDisabled 对方关闭了私聊
+	ErrDMDisabled = errors.New("this user does not accept private messages")
+	// ErrDMContactsOnly 对方只接收好友的私聊
+	ErrDMContactsOnly = errors.New("this user only accepts private messages from friends")
+)
+
+// isUserBlocked 判断 userID 是否屏蔽了 targetID，优先查询 Redis 缓存
+func isUserBlocked(cache *storage.BlockListCache, userID, targetID uint) (bool, error) {
+	blocked, hit, err := cache.IsBlocked(userID, targetID)
+	if err != nil {
+		// 缓存不可用时直接回源数据库
+		fmt.Printf("Warning: %v\n", err)
+	} else if hit {
+		return blocked, nil
+	}
+
+	// 版本号需在读取数据库之前获取，读取期间屏蔽列表有变更时不写入旧数据
+	gen, genErr := cache.Generation(userID)
+	blockedIDs, err := mysql.GetBlockedUserIDs(userID)
+	if err != nil {
+		return false, err
+	}
+	if genErr != nil {
+		fmt.Printf("Warning: %v\n", genErr)
+	} else if err := cache.SetBlockedIDs(userID, gen, blockedIDs); err != nil {
+		fmt.Printf("Warning: %v\n", err)
+	}
+	for _, id := range blockedIDs {
+		if id == targetID {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// checkBlockBetween 检查 fromUserID 与 toUserID 之间是否存在任一方向的屏蔽
+func checkBlockBetween(cache *storage.BlockListCache, fromUserID, toUserID uint) error {
+	blocked, err := isUserBlocked(cache, toUserID, fromUserID)
+	if err != nil {
+		return err
+	}
+	if blocked {
+		return ErrBlockedByUser
+	}
+	blocked, err = isUserBlocked(cache, fromUserID, toUserID)
+	if err != nil {
+		return err
+	}
+	if blocked {
+		return ErrUserBlockedByYou
+	}
+	return nil
+}
+
+// BlockUser 屏蔽用户
+func (s *friendService) BlockUser(userID uint, target string) (*model.BlockedUserInfo, error) {
+	targetUser, err := s.userService.ResolveUser(strings.TrimSpace(target))
+	if err != nil {
+		if errors.Is(err, ErrUserNotFound) {
+			return nil, errors.New("target user not found")
+		}
+		return nil, err
+	}
+	if targetUser.ID == userID {
+		return nil, errors.New("cannot block yourself")
+	}
+
+	if err := mysql.CreateUserBlock(userID, targetUser.ID); err != nil {
+		return nil, err
+	}
+	s.invalidateBlockList(userID)
+
+	return &model.BlockedUserInfo{
+		UserID:   targetUser.ID,
+		UserUUID: targetUser.UserUUID,
+		Username: targetUser.Username,
+		Avatar:   targetUser.Avatar,
+	}, nil
+}
+
+// UnblockUser 取消屏蔽
+func (s *friendService) UnblockUser(userID uint, blockedUserID uint) error {
+	if err := mysql.DeleteUserBlock(userID, blockedUserID); err != nil {
+		return err
+	}
+	s.invalidateBlockList(userID)
+	return nil
+}
+
+// GetBlockList 获取屏蔽列表
+func (s *friendService) GetBlockList(userID uint) ([]*model.BlockedUserInfo, error) {
+	blocks, err := mysql.GetUserBlocks(userID)
+	if err != nil {
+		return nil, err
+	}
+	if len(blocks) == 0 {
+		return []*model.BlockedUserInfo{}, nil
+	}
+
+	userIDs := make([]uint, 0, len(blocks))
+	for _, block := range blocks {
+		userIDs = append(userIDs, block.BlockedUserID)
+	}
+	users, err := mysql.GetUsersByIDs(userIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get users info: %w", err)
+	}
+	userMap := make(map[uint]*model.User, len(users))
+	for _, user := range users {
+		userMap[user.ID] = user
+	}
+
+	result := make([]*model.BlockedUserInfo, 0, len(blocks))
+	for _, block := range blocks {
+		user, exists := userMap[block.BlockedUserID]
+		if !exists {
+			continue
+		}
+		result = append(result, &model.BlockedUserInfo{
+			UserID:    user.ID,
+			UserUUID:  user.UserUUID,
+			Username:  user.Username,
+			Avatar:    user.Avatar,
+			BlockedAt: block.CreatedAt.Format("2006-01-02 15:04:05"),
+		})
+	}
+	return result, nil
+}
+
+// IsBlocked 判断 userID 是否屏蔽了 targetID
+func (s *friendService) IsBlocked(userID uint, targetID uint) (bool, error) {
+	return isUserBlocked(s.blockCache, userID, targetID)
+}
+
+// SetDMPrivacy 设置谁可以向自己发送私聊
+func (s *friendService) SetDMPrivacy(userID uint, privacy string) error {
+	if !model.IsValidDMPrivacy(privacy) {
+		return fmt.Errorf("invalid privacy: must be one of %s, %s, %s",
+			model.DMPrivacyEveryone, model.DMPrivacyContacts, model.DMPrivacyNobody)
+	}
+	if err := mysql.UpdateUserDMPrivacy(userID, privacy); err != nil {
+		return fmt.Errorf("failed to update dm privacy: %w", err)
+	}
+	return nil
+}
+
+// GetDMPrivacy 获取私聊隐私设置
+func (s *friendService) GetDMPrivacy(userID uint) (string, error) {
+	user, err := s.userService.GetUserByID(userID)
+	if err != nil {
+		return "", err
+	}
+	return dmPrivacyOf(user), nil
+}
+
+// checkDMPrivacy 根据接收方的账号类型和私聊隐私设置检查发送方能否发起私聊
+func (s *friendService) checkDMPrivacy(fromUserID uint, toUserID uint) error {
+	toUser, err := s.userService.GetUserByID(toUserID)
+	if err != nil {
+		return err
+	}
+	// 访客不能收发私聊
+	if !model.AccountKindHas(toUser.AccountKind, model.CapDirectMessage) {
+		return ErrDMDisabled
+	}
+	switch dmPrivacyOf(toUser) {
+	case model.DMPrivacyNobody:
+		return ErrDMDisabled
+	case model.DMPrivacyContacts:
+		isFriend, err := s.AreFriends(fromUserID, toUserID)
+		if err != nil {
+			return err
+		}
+		if !isFriend {
+			return ErrDMContactsOnly
+		}
+	}
+	return nil
+}
+
+// invalidateBlockList 屏蔽列表变更后删除缓存，下次查询时从数据库重建
+func (s *friendService) invalidateBlockList(userID uint) {
+	if err := s.blockCache.Invalidate(userID); err != nil {
+		fmt.Printf("Warning: failed to invalidate block list of user %d: %v\n", userID, err)
+	}
+}
+
+// dmPrivacyOf 返回用户的私聊隐私设置，历史数据为空时视为允许所有人
+func dmPrivacyOf(user *model.User) string {
+	if user.DMPrivacy == "" {
+		return model.DMPrivacyEveryone
+	}
+	return user.DMPrivacy
+}
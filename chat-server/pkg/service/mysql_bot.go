@@ -0,0 +1,241 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/Xaytick/chat-zinx/chat-server/conf"
+	"github.com/Xaytick/chat-zinx/chat-server/dao/mysql"
+	"github.com/Xaytick/chat-zinx/chat-server/pkg/model"
+	"github.com/Xaytick/chat-zinx/chat-server/pkg/webhook"
+	"github.com/google/uuid"
+)
+
+const (
+	// groupWebhookCacheTTL 群 webhook 列表在本节点的缓存时间，其他节点上的修改最多延迟这么久生效
+	groupWebhookCacheTTL = 30 * time.Second
+	// maxCommandPrefixLen 命令前缀的最大长度
+	maxCommandPrefixLen = 32
+)
+
+type cachedWebhooks struct {
+	hooks     []*model.BotWebhook
+	expiresAt time.Time
+}
+
+type botService struct {
+	userService IUserService
+
+	mu        sync.RWMutex
+	hookCache map[uint]*cachedWebhooks // 按群ID缓存 webhook，每条群消息都要匹配
+}
+
+// NewBotService 创建一个新的机器人服务实例
+func NewBotService(userService IUserService) IBotService {
+	return &botService{
+		userService: userService,
+		hookCache:   make(map[uint]*cachedWebhooks),
+	}
+}
+
+// SetWebhook 创建或修改 webhook，新建或要求重新生成时返回签名密钥
+func (s *botService) SetWebhook(ownerID uint, req *model.SetBotWebhookReq) (*model.SetBotWebhookResp, error) {
+	if _, err := s.getOwnedBot(ownerID, req.BotUserID); err != nil {
+		return nil, err
+	}
+
+	url := strings.TrimSpace(req.URL)
+	if err := webhook.ValidateURL(url, conf.GetWebhookConfig().AllowPrivateNetworks); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidWebhookURL, err)
+	}
+	prefix := strings.TrimSpace(req.CommandPrefix)
+	if prefix != "" && (!strings.HasPrefix(prefix, "/") || strings.ContainsAny(prefix, " \t\n") ||
+		utf8.RuneCountInString(prefix) > maxCommandPrefixLen) {
+		return nil, ErrInvalidBotCommand
+	}
+	if prefix == "" && !req.OnMention {
+		return nil, ErrWebhookNoTrigger
+	}
+
+	member, err := mysql.GetGroupMember(req.GroupID, req.BotUserID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check group member: %w", err)
+	}
+	if member == nil {
+		return nil, ErrBotNotInGroup
+	}
+
+	hook, err := mysql.GetBotWebhook(req.BotUserID, req.GroupID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get webhook: %w", err)
+	}
+	resp := &model.SetBotWebhookResp{}
+	if hook == nil {
+		hook = &model.BotWebhook{BotUserID: req.BotUserID, GroupID: req.GroupID}
+	}
+	if hook.Secret == "" || req.RotateSecret {
+		secret, err := randomHex(24)
+		if err != nil {
+			return nil, err
+		}
+		hook.Secret = secret
+		resp.Secret = secret
+	}
+	hook.URL = url
+	hook.CommandPrefix = prefix
+	hook.OnMention = req.OnMention
+	if err := mysql.SaveBotWebhook(hook); err != nil {
+		return nil, err
+	}
+
+	s.invalidate(req.GroupID)
+	resp.Webhook = hook
+	return resp, nil
+}
+
+// DeleteWebhook 删除 webhook
+func (s *botService) DeleteWebhook(ownerID, botUserID, groupID uint) error {
+	if _, err := s.getOwnedBot(ownerID, botUserID); err != nil {
+		return err
+	}
+	deleted, err := mysql.DeleteBotWebhook(botUserID, groupID)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return ErrWebhookNotFound
+	}
+	s.invalidate(groupID)
+	return nil
+}
+
+// GetWebhooks 获取机器人的所有 webhook
+func (s *botService) GetWebhooks(ownerID, botUserID uint) ([]*model.BotWebhook, error) {
+	if _, err := s.getOwnedBot(ownerID, botUserID); err != nil {
+		return nil, err
+	}
+	hooks, err := mysql.GetBotWebhooksByBot(botUserID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get webhooks: %w", err)
+	}
+	return hooks, nil
+}
+
+// MatchWebhooks 按命令前缀或 @ 提及匹配群消息，机器人自己发的消息不会触发自己的 webhook
+func (s *botService) MatchWebhooks(groupID uint, msg *model.WebhookMessage, memberIDs []uint) ([]*webhook.Delivery, error) {
+	hooks, err := s.groupWebhooks(groupID)
+	if err != nil {
+		return nil, err
+	}
+	if len(hooks) == 0 {
+		return nil, nil
+	}
+
+	members := make(map[uint]bool, len(memberIDs))
+	for _, id := range memberIDs {
+		members[id] = true
+	}
+
+	var deliveries []*webhook.Delivery
+	for _, hook := range hooks {
+		if hook.BotUserID == msg.FromUserID || !members[hook.BotUserID] {
+			continue
+		}
+		event := matchWebhook(hook, msg.Content)
+		if event == nil {
+			continue
+		}
+		event.Event = model.WebhookEventGroupMessage
+		event.DeliveryID = uuid.NewString()
+		event.Timestamp = time.Now().Unix()
+		event.GroupID = groupID
+		event.Bot = &model.UserBasicInfo{ID: hook.BotUserID, Username: hook.BotUsername}
+		event.Message = msg
+		deliveries = append(deliveries, &webhook.Delivery{Hook: hook, Event: event})
+	}
+	return deliveries, nil
+}
+
+// matchWebhook 检查消息是否触发 webhook，命令优先于 @ 提及
+func matchWebhook(hook *model.BotWebhook, content string) *model.WebhookEvent {
+	content = strings.TrimSpace(content)
+	if prefix := hook.CommandPrefix; prefix != "" {
+		if content == prefix || strings.HasPrefix(content, prefix+" ") {
+			return &model.WebhookEvent{
+				Trigger: model.WebhookTriggerCommand,
+				Command: prefix,
+				Args:    strings.TrimSpace(content[len(prefix):]),
+			}
+		}
+	}
+	if hook.OnMention && hook.BotUsername != "" && containsMention(content, hook.BotUsername) {
+		return &model.WebhookEvent{Trigger: model.WebhookTriggerMention}
+	}
+	return nil
+}
+
+// containsMention 判断内容中是否有完整的 @username，@username2 不算提及 username
+func containsMention(content, username string) bool {
+	mention := "@" + username
+	for offset := 0; ; {
+		i := strings.Index(content[offset:], mention)
+		if i < 0 {
+			return false
+		}
+		end := offset + i + len(mention)
+		if end == len(content) {
+			return true
+		}
+		next, _ := utf8.DecodeRuneInString(content[end:])
+		if !unicode.IsLetter(next) && !unicode.IsDigit(next) && next != '_' {
+			return true
+		}
+		offset = end
+	}
+}
+
+// getOwnedBot 获取 ownerID 创建的机器人，不存在或不属于该用户时返回 ErrBotNotFound
+func (s *botService) getOwnedBot(ownerID, botUserID uint) (*model.User, error) {
+	bot, err := s.userService.GetUserByID(botUserID)
+	if err != nil {
+		if errors.Is(err, ErrUserNotFound) {
+			return nil, ErrBotNotFound
+		}
+		return nil, err
+	}
+	if bot.AccountKind != model.AccountKindBot || bot.BotOwnerID != ownerID {
+		return nil, ErrBotNotFound
+	}
+	return bot, nil
+}
+
+// groupWebhooks 获取群的 webhook 列表，优先使用本地缓存
+func (s *botService) groupWebhooks(groupID uint) ([]*model.BotWebhook, error) {
+	s.mu.RLock()
+	cached, ok := s.hookCache[groupID]
+	s.mu.RUnlock()
+	if ok && time.Now().Before(cached.expiresAt) {
+		return cached.hooks, nil
+	}
+
+	hooks, err := mysql.GetGroupWebhooks(groupID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get group webhooks: %w", err)
+	}
+	s.mu.Lock()
+	s.hookCache[groupID] = &cachedWebhooks{hooks: hooks, expiresAt: time.Now().Add(groupWebhookCacheTTL)}
+	s.mu.Unlock()
+	return hooks, nil
+}
+
+// invalidate 删除群 webhook 缓存
+func (s *botService) invalidate(groupID uint) {
+	s.mu.Lock()
+	delete(s.hookCache, groupID)
+	s.mu.Unlock()
+}
@@ -0,0 +1,317 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/Xaytick/chat-zinx/chat-server/conf"
+	"github.com/Xaytick/chat-zinx/chat-server/dao/mysql"
+	"github.com/Xaytick/chat-zinx/chat-server/pkg/model"
+	"github.com/Xaytick/chat-zinx/chat-server/pkg/storage"
+)
+
+// ErrNotFriends 双方不是好友
+var ErrNotFriends = errors.New("you are not friends with this user")
+
+type friendService struct {
+	userService IUserService
+	blockCache  *storage.BlockListCache // 用户屏蔽列表缓存
+}
+
+// NewFriendService 创建一个新的好友服务实例
+func NewFriendService(userService IUserService) IFriendService {
+	return &friendService{
+		userService: userService,
+		blockCache:  storage.NewBlockListCache(),
+	}
+}
+
+// SendFriendRequest 发送好友申请
+func (s *friendService) SendFriendRequest(fromUserID uint, req *model.SendFriendRequestReq) (*model.FriendRequestInfo, error) {
+	// 1. 查找目标用户
+	target, err := s.userService.ResolveUser(strings.TrimSpace(req.Target))
+	if err != nil {
+		if errors.Is(err, ErrUserNotFound) {
+			return nil, errors.New("target user not found")
+		}
+		return nil, err
+	}
+	if target.ID == fromUserID {
+		return nil, errors.New("cannot add yourself as a friend")
+	}
+	if !model.AccountKindHas(target.AccountKind, model.CapFriends) {
+		return nil, ErrAccountKindDenied
+	}
+
+	message := strings.TrimSpace(req.Message)
+	if utf8.RuneCountInString(message) > model.MaxFriendRequestMessageLen {
+		return nil, fmt.Errorf("message too long: at most %d characters", model.MaxFriendRequestMessageLen)
+	}
+
+	// 2. 检查屏蔽关系、是否已是好友或已有待处理的申请
+	if err := checkBlockBetween(s.blockCache, fromUserID, target.ID); err != nil {
+		return nil, err
+	}
+	isFriend, err := s.AreFriends(fromUserID, target.ID)
+	if err != nil {
+		return nil, err
+	}
+	if isFriend {
+		return nil, errors.New("you are already friends")
+	}
+	if err := checkFriendLimit(fromUserID); err != nil {
+		return nil, err
+	}
+
+	existing, err := mysql.GetPendingFriendRequest(fromUserID, target.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check friend request: %w", err)
+	}
+	if existing != nil {
+		return nil, errors.New("friend request already sent, waiting for response")
+	}
+
+	// 3. 对方已向自己发出申请时，视为同意对方的申请
+	reverse, err := mysql.GetPendingFriendRequest(target.ID, fromUserID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check friend request: %w", err)
+	}
+	if reverse != nil {
+		return s.HandleFriendRequest(fromUserID, reverse.ID, true)
+	}
+
+	// 4. 创建申请
+	friendReq := &model.FriendRequest{
+		FromUserID: fromUserID,
+		ToUserID:   target.ID,
+		Message:    message,
+	}
+	if err := mysql.CreateFriendRequest(friendReq); err != nil {
+		return nil, err
+	}
+	return buildFriendRequestInfo(friendReq)
+}
+
+// HandleFriendRequest 接受或拒绝收到的好友申请
+func (s *friendService) HandleFriendRequest(userID uint, requestID uint, accept bool) (*model.FriendRequestInfo, error) {
+	friendReq, err := mysql.GetFriendRequestByID(requestID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get friend request: %w", err)
+	}
+	// 只有申请的接收方可以处理，其他用户看到的是申请不存在
+	if friendReq == nil || friendReq.ToUserID != userID {
+		return nil, errors.New("friend request not found")
+	}
+	if friendReq.Status != model.FriendRequestPending {
+		return nil, mysql.ErrFriendRequestHandled
+	}
+
+	if accept {
+		if err := checkFriendLimit(friendReq.FromUserID); err != nil {
+			return nil, fmt.Errorf("requester cannot add more friends: %w", err)
+		}
+		if err := checkFriendLimit(userID); err != nil {
+			return nil, err
+		}
+		// 上面的检查用于尽早返回，并发接受时以事务内的检查为准
+		maxFriends := conf.GetFriendConfig().MaxFriends
+		if err := mysql.AcceptFriendRequest(friendReq, maxFriends); err != nil {
+			switch {
+			case errors.Is(err, mysql.ErrRequesterFriendLimitReached):
+				return nil, fmt.Errorf("requester cannot add more friends: friend limit reached: at most %d friends", maxFriends)
+			case errors.Is(err, mysql.ErrFriendLimitReached):
+				return nil, fmt.Errorf("friend limit reached: at most %d friends", maxFriends)
+			}
+			return nil, err
+		}
+		friendReq.Status = model.FriendRequestAccepted
+	} else {
+		if err := mysql.RejectFriendRequest(friendReq.ID); err != nil {
+			return nil, err
+		}
+		friendReq.Status = model.FriendRequestRejected
+	}
+	return buildFriendRequestInfo(friendReq)
+}
+
+// GetFriendRequests 获取收到和发出的待处理好友申请
+func (s *friendService) GetFriendRequests(userID uint) (*model.GetFriendRequestsResp, error) {
+	incoming, outgoing, err := mysql.GetPendingFriendRequests(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	all := append(append([]*model.FriendRequest{}, incoming...), outgoing...)
+	usernames, err := getUsernames(all)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &model.GetFriendRequestsResp{
+		Incoming: make([]*model.FriendRequestInfo, 0, len(incoming)),
+		Outgoing: make([]*model.FriendRequestInfo, 0, len(outgoing)),
+	}
+	for _, req := range incoming {
+		resp.Incoming = append(resp.Incoming, toFriendRequestInfo(req, usernames))
+	}
+	for _, req := range outgoing {
+		resp.Outgoing = append(resp.Outgoing, toFriendRequestInfo(req, usernames))
+	}
+	return resp, nil
+}
+
+// GetFriendList 获取好友列表
+func (s *friendService) GetFriendList(userID uint) ([]*model.FriendInfo, error) {
+	// 1. 获取好友关系
+	friends, err := mysql.GetFriends(userID)
+	if err != nil {
+		return nil, err
+	}
+	if len(friends) == 0 {
+		return []*model.FriendInfo{}, nil
+	}
+
+	// 2. 批量获取好友的用户信息
+	friendIDs := make([]uint, 0, len(friends))
+	for _, friend := range friends {
+		friendIDs = append(friendIDs, friend.FriendID)
+	}
+	users, err := mysql.GetUsersByIDs(friendIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get users info: %w", err)
+	}
+	userMap := make(map[uint]*model.User, len(users))
+	for _, user := range users {
+		userMap[user.ID] = user
+	}
+
+	// 3. 构建结果
+	result := make([]*model.FriendInfo, 0, len(friends))
+	for _, friend := range friends {
+		user, exists := userMap[friend.FriendID]
+		if !exists {
+			fmt.Printf("Warning: User %d not found for friend of user %d\n", friend.FriendID, userID)
+			continue
+		}
+		result = append(result, &model.FriendInfo{
+			UserID:   user.ID,
+			UserUUID: user.UserUUID,
+			Username: user.Username,
+			Avatar:   user.Avatar,
+			Remark:   friend.Remark,
+			IsOnline: user.IsOnline,
+			Since:    friend.CreatedAt.Format("2006-01-02 15:04:05"),
+		})
+	}
+	return result, nil
+}
+
+// GetFriendIDs 获取全部好友的用户ID
+func (s *friendService) GetFriendIDs(userID uint) ([]uint, error) {
+	return mysql.GetFriendIDs(userID)
+}
+
+// RemoveFriend 删除好友
+func (s *friendService) RemoveFriend(userID uint, friendUserID uint) error {
+	return mysql.RemoveFriend(userID, friendUserID)
+}
+
+// SetFriendRemark 设置好友备注，remark 为空表示清除备注
+func (s *friendService) SetFriendRemark(userID uint, friendUserID uint, remark string) error {
+	remark = strings.TrimSpace(remark)
+	if utf8.RuneCountInString(remark) > model.MaxFriendRemarkLen {
+		return fmt.Errorf("remark too long: at most %d characters", model.MaxFriendRemarkLen)
+	}
+	return mysql.UpdateFriendRemark(userID, friendUserID, remark)
+}
+
+// AreFriends 判断两个用户是否为好友
+func (s *friendService) AreFriends(userID uint, otherUserID uint) (bool, error) {
+	friend, err := mysql.GetFriend(userID, otherUserID)
+	if err != nil {
+		return false, fmt.Errorf("failed to check friendship: %w", err)
+	}
+	return friend != nil, nil
+}
+
+// CheckPrivateChatAllowed 依次检查屏蔽关系、接收方的私聊隐私设置和服务端的 OnlyFriendsCanChat 策略
+func (s *friendService) CheckPrivateChatAllowed(fromUserID uint, toUserID uint) error {
+	if fromUserID == toUserID {
+		return nil
+	}
+	if err := checkBlockBetween(s.blockCache, fromUserID, toUserID); err != nil {
+		return err
+	}
+	if err := s.checkDMPrivacy(fromUserID, toUserID); err != nil {
+		return err
+	}
+	if !conf.GetFriendConfig().OnlyFriendsCanChat {
+		return nil
+	}
+	isFriend, err := s.AreFriends(fromUserID, toUserID)
+	if err != nil {
+		return err
+	}
+	if !isFriend {
+		return ErrNotFriends
+	}
+	return nil
+}
+
+// checkFriendLimit 检查用户的好友数是否已达上限
+func checkFriendLimit(userID uint) error {
+	maxFriends := conf.GetFriendConfig().MaxFriends
+	if maxFriends <= 0 {
+		return nil
+	}
+	count, err := mysql.CountFriends(userID)
+	if err != nil {
+		return err
+	}
+	if count >= int64(maxFriends) {
+		return fmt.Errorf("friend limit reached: at most %d friends", maxFriends)
+	}
+	return nil
+}
+
+// getUsernames 批量获取好友申请双方的用户名
+func getUsernames(requests []*model.FriendRequest) (map[uint]string, error) {
+	userIDs := make([]uint, 0, len(requests)*2)
+	for _, req := range requests {
+		userIDs = append(userIDs, req.FromUserID, req.ToUserID)
+	}
+	users, err := mysql.GetUsersByIDs(userIDs)
+	if err != nil {
+		return nil, err
+	}
+	usernames := make(map[uint]string, len(users))
+	for _, user := range users {
+		usernames[user.ID] = user.Username
+	}
+	return usernames, nil
+}
+
+// buildFriendRequestInfo 构建单个好友申请的响应
+func buildFriendRequestInfo(req *model.FriendRequest) (*model.FriendRequestInfo, error) {
+	usernames, err := getUsernames([]*model.FriendRequest{req})
+	if err != nil {
+		return nil, err
+	}
+	return toFriendRequestInfo(req, usernames), nil
+}
+
+// toFriendRequestInfo 转换为好友申请响应格式
+func toFriendRequestInfo(req *model.FriendRequest, usernames map[uint]string) *model.FriendRequestInfo {
+	return &model.FriendRequestInfo{
+		ID:           req.ID,
+		FromUserID:   req.FromUserID,
+		FromUsername: usernames[req.FromUserID],
+		ToUserID:     req.ToUserID,
+		ToUsername:   usernames[req.ToUserID],
+		Message:      req.Message,
+		Status:       req.Status,
+		CreatedAt:    req.CreatedAt.Format("2006-01-02 15:04:05"),
+	}
+}
@@ -3,17 +3,26 @@ package service
 import (
 	"errors"
 	"fmt"
+	"strings"
 	"time"
 
+	"github.com/Xaytick/chat-zinx/chat-server/conf"
 	"github.com/Xaytick/chat-zinx/chat-server/dao/mysql"
 	"github.com/Xaytick/chat-zinx/chat-server/pkg/model"
+	"github.com/Xaytick/chat-zinx/chat-server/pkg/storage"
 )
 
-type groupService struct{}
+type groupService struct {
+	memberCache *storage.GroupMemberCache // 群成员ID缓存
+	blockCache  *storage.BlockListCache   // 用户屏蔽列表缓存，邀请入群时检查
+}
 
 // NewGroupService 创建一个新的群组服务实例
 func NewGroupService() IGroupService {
-	return &groupService{}
+	return &groupService{
+		memberCache: storage.NewGroupMemberCache(),
+		blockCache:  storage.NewBlockListCache(),
+	}
 }
 
 // CreateGroup 创建群组
@@ -24,17 +33,28 @@ func (s *groupService) CreateGroup(userID uint, req *model.CreateGroupReq) (*mod
 	// 	 return nil, fmt.Errorf("owner user not found: %w", err)
 	// }
 
+	if err := validateGroupCategory(req.Category); err != nil {
+		return nil, err
+	}
+	tags, err := normalizeGroupTags(req.Tags)
+	if err != nil {
+		return nil, err
+	}
+
 	group := &model.Group{
 		Name:        req.Name,
 		OwnerUserID: userID,
 		Avatar:      req.Avatar, // 可能需要处理默认头像
 		Description: req.Description,
+		Tier:        conf.GetGroupConfig().DefaultTier,
+		IsPublic:    req.IsPublic,
+		Category:    req.Category,
 		// MemberCount 默认为1，在DAO层设置
 	}
 
 	ownerAsMember := &model.GroupMember{}
 
-	err := mysql.CreateGroup(group, ownerAsMember) // DAO层会处理群组创建和群主成员的添加
+	err = mysql.CreateGroup(group, ownerAsMember, tags) // DAO层会处理群组创建、群主成员和标签的添加
 	if err != nil {
 		// TODO: 更细致的错误处理，例如群名是否已存在 (数据库层面通过unique约束保证，这里可以转换为更友好的错误信息)
 		return nil, fmt.Errorf("failed to create group: %w", err)
@@ -63,16 +83,109 @@ func (s *groupService) JoinGroup(userID uint, req *model.JoinGroupReq) error {
 		return errors.New("user already in this group")
 	}
 
+	// 3. 检查用户是否被该群组封禁
+	if err := checkNotBanned(req.GroupID, userID); err != nil {
+		return err
+	}
+
+	// 4. 添加成员
+	return s.addMember(group, userID)
+}
+
+// InviteGroupMember 邀请用户入群，需要 invite_member 权限，且对方没有屏蔽邀请人
+func (s *groupService) InviteGroupMember(operatorID uint, req *model.InviteGroupMemberReq) (*model.Group, error) {
+	// 1. 检查权限
+	if req.TargetUserID == operatorID {
+		return nil, errors.New("cannot invite yourself")
+	}
+	if _, err := requirePermission(s.memberCache, req.GroupID, operatorID, model.GroupPermInviteMember); err != nil {
+		return nil, err
+	}
+	group, err := mysql.GetGroupByID(req.GroupID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get group: %w", err)
+	}
+	if group == nil {
+		return nil, errors.New("group not found")
+	}
+
+	// 2. 校验目标用户
+	if _, err := mysql.GetUserByID(req.TargetUserID); err != nil {
+		if errors.Is(err, mysql.ErrRecordNotFound) {
+			return nil, errors.New("target user not found")
+		}
+		return nil, fmt.Errorf("failed to get target user: %w", err)
+	}
+	blocked, err := isUserBlocked(s.blockCache, req.TargetUserID, operatorID)
+	if err != nil {
+		return nil, err
+	}
+	if blocked {
+		return nil, ErrBlockedByUser
+	}
+	existingMember, err := mysql.GetGroupMember(req.GroupID, req.TargetUserID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check group member: %w", err)
+	}
+	if existingMember != nil {
+		return nil, errors.New("user already in this group")
+	}
+	if err := checkNotBanned(req.GroupID, req.TargetUserID); err != nil {
+		return nil, err
+	}
+
 	// 3. 添加成员
+	if err := s.addMember(group, req.TargetUserID); err != nil {
+		return nil, err
+	}
+	return group, nil
+}
+
+// addMember 以普通成员身份将用户加入群组，并同步群成员缓存
+func (s *groupService) addMember(group *model.Group, userID uint) error {
+	if err := checkGuestGroup(group.ID, userID); err != nil {
+		return err
+	}
 	newMember := &model.GroupMember{
-		GroupID:  req.GroupID,
+		GroupID:  group.ID,
 		UserID:   userID,
 		Role:     model.GroupRoleMember, // 默认角色为普通成员
 		JoinedAt: time.Now(),
 	}
-	if err := mysql.AddGroupMember(newMember); err != nil {
+	memberLimit := conf.GetGroupMemberLimit(group.Tier)
+	if err := mysql.AddGroupMember(newMember, memberLimit); err != nil {
+		if errors.Is(err, mysql.ErrGroupMemberLimit) {
+			return fmt.Errorf("group is full: at most %d members", memberLimit)
+		}
+		if errors.Is(err, mysql.ErrGroupMemberBanned) {
+			// 前置检查之后才被封禁，重新检查以返回封禁详情
+			if err := checkNotBanned(group.ID, userID); err != nil {
+				return err
+			}
+			return err
+		}
 		return fmt.Errorf("failed to add user to group: %w", err)
 	}
+
+	if err := s.memberCache.AddMember(group.ID, userID); err != nil {
+		fmt.Printf("Warning: %v, invalidating cache of group %d\n", err, group.ID)
+		_ = s.memberCache.Invalidate(group.ID)
+	}
+	return nil
+}
+
+// checkGuestGroup 访客只能加入配置中允许的群组
+func checkGuestGroup(groupID, userID uint) error {
+	if conf.IsGuestGroup(groupID) {
+		return nil
+	}
+	user, err := mysql.GetUserByID(userID)
+	if err != nil {
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+	if user.AccountKind == model.AccountKindGuest {
+		return ErrGuestGroupDenied
+	}
 	return nil
 }
 
@@ -109,17 +222,49 @@ func (s *groupService) LeaveGroup(userID uint, req *model.LeaveGroupReq) error {
 	if err := mysql.RemoveGroupMember(req.GroupID, userID); err != nil {
 		return fmt.Errorf("failed to remove user from group: %w", err)
 	}
+	s.removeCachedMember(req.GroupID, userID)
 	return nil
 }
 
-// IsUserInGroup 检查用户是否在指定的群组中
+// IsUserInGroup 检查用户是否在指定的群组中，缓存命中时不访问数据库
 func (s *groupService) IsUserInGroup(userID uint, groupID uint) (bool, error) {
+	isMember, hit, err := s.memberCache.IsMember(groupID, userID)
+	if err == nil && hit {
+		return isMember, nil
+	}
 	return mysql.IsUserInGroup(userID, groupID)
 }
 
-// GetGroupMemberIDs 获取指定群组的所有成员 UserID 列表
+// GetGroupMemberIDs 获取指定群组的所有成员 UserID 列表，优先从 Redis 缓存读取
 func (s *groupService) GetGroupMemberIDs(groupID uint) ([]uint, error) {
-	return mysql.GetGroupMemberIDs(groupID)
+	memberIDs, hit, err := s.memberCache.GetMemberIDs(groupID)
+	if err != nil {
+		// 缓存不可用时直接回源数据库
+		fmt.Printf("Warning: %v\n", err)
+	} else if hit {
+		return memberIDs, nil
+	}
+
+	// 版本号需在读取数据库之前获取，读取期间成员有变更时不写入旧数据
+	gen, genErr := s.memberCache.Generation(groupID)
+	memberIDs, err = mysql.GetGroupMemberIDs(groupID)
+	if err != nil {
+		return nil, err
+	}
+	if genErr != nil {
+		fmt.Printf("Warning: %v\n", genErr)
+	} else if err := s.memberCache.SetMemberIDs(groupID, gen, memberIDs); err != nil {
+		fmt.Printf("Warning: %v\n", err)
+	}
+	return memberIDs, nil
+}
+
+// removeCachedMember 从群成员缓存中移除成员，失败时删除整个缓存
+func (s *groupService) removeCachedMember(groupID, userID uint) {
+	if err := s.memberCache.RemoveMember(groupID, userID); err != nil {
+		fmt.Printf("Warning: %v, invalidating cache of group %d\n", err, groupID)
+		_ = s.memberCache.Invalidate(groupID)
+	}
 }
 
 // --- 新增方法实现 --- //
@@ -194,92 +339,96 @@ func (s *groupService) GetGroupMembersWithUserInfo(groupID uint) ([]*model.Group
 	return result, nil
 }
 
-// SetGroupMemberRole 设置群组成员角色
-func (s *groupService) SetGroupMemberRole(operatorID uint, groupID uint, targetUserID uint, newRole string) error {
-	// 1. 验证群组存在
-	group, err := mysql.GetGroupByID(groupID)
-	if err != nil {
-		return fmt.Errorf("failed to get group: %w", err)
+// GetGroupMembersPage 分页获取群组成员详细信息，支持按用户名搜索
+func (s *groupService) GetGroupMembersPage(req *model.GetGroupMembersReq) (*model.GetGroupMembersResp, error) {
+	page := req.Page
+	if page <= 0 {
+		page = 1
 	}
-	if group == nil {
-		return errors.New("group not found")
+	pageSize := req.PageSize
+	if pageSize <= 0 {
+		pageSize = model.DefaultGroupMemberPageSize
+	} else if pageSize > model.MaxGroupMemberPageSize {
+		pageSize = model.MaxGroupMemberPageSize
 	}
 
-	// 2. 检查权限：只有群主可以设置管理员
-	if group.OwnerUserID != operatorID {
-		return errors.New("permission denied: only group owner can change member roles")
-	}
-
-	// 3. 检查目标用户是否为群成员
-	targetMember, err := mysql.GetGroupMember(groupID, targetUserID)
+	offset := (page - 1) * pageSize
+	members, total, err := mysql.GetGroupMembersPage(req.GroupID, strings.TrimSpace(req.Keyword), offset, pageSize)
 	if err != nil {
-		return fmt.Errorf("failed to get target member: %w", err)
-	}
-	if targetMember == nil {
-		return errors.New("target user is not a member of this group")
+		return nil, err
 	}
 
-	// 4. 检查是否试图修改群主角色
-	if targetUserID == group.OwnerUserID {
-		return errors.New("cannot change role of the group owner")
-	}
-
-	// 5. 验证角色有效性
-	if newRole != model.GroupRoleAdmin && newRole != model.GroupRoleMember {
-		return errors.New("invalid role: must be 'admin' or 'member'")
-	}
-
-	// 6. 更新角色
-	return mysql.UpdateGroupMemberRole(groupID, targetUserID, newRole)
+	return &model.GetGroupMembersResp{
+		GroupID:  req.GroupID,
+		Members:  members,
+		Total:    int(total),
+		Page:     page,
+		PageSize: pageSize,
+		HasMore:  int64(offset+len(members)) < total,
+	}, nil
 }
 
-// RemoveMemberFromGroup 将成员移出群组
-func (s *groupService) RemoveMemberFromGroup(operatorID uint, groupID uint, targetUserID uint) error {
-	// 1. 验证群组存在
-	group, err := mysql.GetGroupByID(groupID)
+// SetGroupMemberRole 设置群组成员角色，可设置为 admin、member 或群组的自定义角色
+func (s *groupService) SetGroupMemberRole(operatorID uint, groupID uint, targetUserID uint, newRole string) error {
+	// 1. 检查权限：需要管理角色权限，且目标成员的角色低于操作者
+	operatorRole, targetRole, err := requirePermissionOver(s.memberCache, groupID, operatorID, targetUserID, model.GroupPermManageRoles)
 	if err != nil {
-		return fmt.Errorf("failed to get group: %w", err)
+		return err
 	}
-	if group == nil {
-		return errors.New("group not found")
+	if targetRole == nil {
+		return errors.New("target user is not a member of this group")
 	}
 
-	// 2. 检查操作者是否有权限（群主可以移除任何人，管理员可以移除普通成员）
-	if operatorID != group.OwnerUserID {
-		// 如果不是群主，检查是否是管理员
-		operatorMember, err := mysql.GetGroupMember(groupID, operatorID)
+	// 2. 验证角色有效性，群主身份只能通过转让获得
+	if newRole == model.GroupRoleOwner {
+		return errors.New("invalid role: cannot assign the owner role")
+	}
+	role := model.BuiltinGroupRole(newRole)
+	if role == nil {
+		role, err = mysql.GetGroupRole(groupID, newRole)
 		if err != nil {
-			return fmt.Errorf("failed to get operator's membership: %w", err)
+			return fmt.Errorf("failed to get group role: %w", err)
 		}
-		if operatorMember == nil || operatorMember.Role != model.GroupRoleAdmin {
-			return errors.New("permission denied: only group owner and admins can remove members")
+		if role == nil {
+			return fmt.Errorf("invalid role: '%s' does not exist in this group", newRole)
 		}
+	}
 
-		// 管理员不能移除其他管理员或群主
-		targetMember, err := mysql.GetGroupMember(groupID, targetUserID)
-		if err != nil {
-			return fmt.Errorf("failed to get target member: %w", err)
-		}
-		if targetMember == nil {
-			return errors.New("target user is not a member of this group")
-		}
-		if targetMember.Role == model.GroupRoleAdmin || targetUserID == group.OwnerUserID {
-			return errors.New("permission denied: admins cannot remove other admins or the owner")
-		}
+	// 3. 只能授予低于自身的角色
+	if role.Priority >= operatorRole.Priority {
+		return fmt.Errorf("%w: cannot assign a role at or above your own", ErrGroupPermissionDenied)
 	}
 
-	// 3. 不能移除自己（应该使用 LeaveGroup 接口）
+	// 4. 更新角色
+	if err := mysql.UpdateGroupMemberRole(groupID, targetUserID, newRole); err != nil {
+		return err
+	}
+	invalidateMemberRoles(s.memberCache, groupID)
+	return nil
+}
+
+// RemoveMemberFromGroup 将成员移出群组
+func (s *groupService) RemoveMemberFromGroup(operatorID uint, groupID uint, targetUserID uint) error {
+	// 1. 不能移除自己（应该使用 LeaveGroup 接口）
 	if operatorID == targetUserID {
 		return errors.New("cannot remove yourself from group, use leave group instead")
 	}
 
-	// 4. 不能移除群主
-	if targetUserID == group.OwnerUserID {
-		return errors.New("cannot remove the group owner")
+	// 2. 检查权限：需要移除成员权限，且只能移除角色低于自己的成员
+	_, targetRole, err := requirePermissionOver(s.memberCache, groupID, operatorID, targetUserID, model.GroupPermRemoveMember)
+	if err != nil {
+		return err
+	}
+	if targetRole == nil {
+		return errors.New("target user is not a member of this group")
 	}
 
-	// 5. 执行移除操作
-	return mysql.RemoveGroupMember(groupID, targetUserID)
+	// 3. 执行移除操作
+	if err := mysql.RemoveGroupMember(groupID, targetUserID); err != nil {
+		return err
+	}
+	s.removeCachedMember(groupID, targetUserID)
+	return nil
 }
 
 // UpdateGroupInfo 更新群组信息
@@ -293,16 +442,9 @@ func (s *groupService) UpdateGroupInfo(operatorID uint, groupID uint, updateReq
 		return errors.New("group not found")
 	}
 
-	// 2. 检查权限：只有群主和管理员可以更新群组信息
-	if operatorID != group.OwnerUserID {
-		// 如果不是群主，检查是否是管理员
-		operatorMember, err := mysql.GetGroupMember(groupID, operatorID)
-		if err != nil {
-			return fmt.Errorf("failed to get operator's membership: %w", err)
-		}
-		if operatorMember == nil || operatorMember.Role != model.GroupRoleAdmin {
-			return errors.New("permission denied: only group owner and admins can update group info")
-		}
+	// 2. 检查权限
+	if _, err := requirePermission(s.memberCache, groupID, operatorID, model.GroupPermEditInfo); err != nil {
+		return err
 	}
 
 	// 3. 更新群组信息
@@ -316,12 +458,29 @@ func (s *groupService) UpdateGroupInfo(operatorID uint, groupID uint, updateReq
 	if updateReq.Avatar != "" {
 		updates["avatar"] = updateReq.Avatar
 	}
+	if updateReq.IsPublic != nil {
+		updates["is_public"] = *updateReq.IsPublic
+	}
+	if updateReq.Category != nil {
+		category := strings.TrimSpace(*updateReq.Category)
+		if err := validateGroupCategory(category); err != nil {
+			return err
+		}
+		updates["category"] = category
+	}
+	var tags []string
+	if updateReq.Tags != nil {
+		if tags, err = normalizeGroupTags(updateReq.Tags); err != nil {
+			return err
+		}
+	}
 
-	if len(updates) == 0 {
+	if len(updates) == 0 && updateReq.Tags == nil {
 		return errors.New("no updates provided")
 	}
 
 	updates["updated_at"] = time.Now()
 
-	return mysql.UpdateGroupInfo(groupID, updates)
+	// 群组资料和标签在同一事务内更新，tags 为 nil 时不修改标签
+	return mysql.UpdateGroupInfo(groupID, updates, tags)
 }
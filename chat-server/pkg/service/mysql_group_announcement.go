@@ -0,0 +1,264 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"unicode/utf8"
+
+	"github.com/Xaytick/chat-zinx/chat-server/dao/mysql"
+	"github.com/Xaytick/chat-zinx/chat-server/pkg/model"
+)
+
+const (
+	defaultAnnouncementHistoryLimit = 10 // 公告历史默认返回条数
+	maxAnnouncementHistoryLimit     = 50 // 公告历史最多返回条数
+)
+
+// UpdateGroupAnnouncement 编辑群公告，生成一个新版本
+func (s *groupService) UpdateGroupAnnouncement(operatorID uint, groupID uint, content string) (*model.GroupAnnouncementInfo, error) {
+	// 1. 检查权限
+	if _, err := requirePermission(s.memberCache, groupID, operatorID, model.GroupPermEditAnnouncement); err != nil {
+		return nil, err
+	}
+
+	// 2. 校验内容长度
+	if utf8.RuneCountInString(content) > model.MaxGroupAnnouncementLen {
+		return nil, fmt.Errorf("announcement too long: at most %d characters", model.MaxGroupAnnouncementLen)
+	}
+
+	// 3. 获取编辑者信息
+	editor, err := mysql.GetUserByID(operatorID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get editor info: %w", err)
+	}
+
+	// 4. 保存新版本
+	announcement := &model.GroupAnnouncement{
+		GroupID:      groupID,
+		Content:      content,
+		EditorUserID: operatorID,
+		EditorName:   editor.Username,
+	}
+	if err := mysql.CreateGroupAnnouncement(announcement); err != nil {
+		return nil, err
+	}
+
+	// 新版本尚无人确认
+	info := toGroupAnnouncementInfo(announcement)
+	info.AckedBy = []*model.GroupAnnouncementAckInfo{}
+	return info, nil
+}
+
+// GetGroupAnnouncement 获取群组当前公告及其确认列表，没有公告时返回 nil, nil
+func (s *groupService) GetGroupAnnouncement(groupID uint) (*model.GroupAnnouncementInfo, error) {
+	announcement, err := mysql.GetLatestGroupAnnouncement(groupID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get group announcement: %w", err)
+	}
+	if announcement == nil {
+		return nil, nil
+	}
+	return buildAnnouncementInfoWithAcks(announcement)
+}
+
+// GetGroupAnnouncementHistory 获取群公告的编辑历史，仅群成员可查看
+func (s *groupService) GetGroupAnnouncementHistory(userID uint, groupID uint, limit int) ([]*model.GroupAnnouncementInfo, error) {
+	isMember, err := mysql.IsUserInGroup(userID, groupID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check group membership: %w", err)
+	}
+	if !isMember {
+		return nil, errors.New("user is not a member of this group")
+	}
+
+	if limit <= 0 {
+		limit = defaultAnnouncementHistoryLimit
+	} else if limit > maxAnnouncementHistoryLimit {
+		limit = maxAnnouncementHistoryLimit
+	}
+
+	announcements, err := mysql.GetGroupAnnouncementHistory(groupID, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*model.GroupAnnouncementInfo, 0, len(announcements))
+	for _, announcement := range announcements {
+		result = append(result, toGroupAnnouncementInfo(announcement))
+	}
+	return result, nil
+}
+
+// AckGroupAnnouncement 群成员确认已阅读当前公告，返回更新后的公告信息
+func (s *groupService) AckGroupAnnouncement(userID uint, groupID uint, announcementID uint) (*model.GroupAnnouncementInfo, error) {
+	// 1. 检查用户是否为群成员
+	isMember, err := mysql.IsUserInGroup(userID, groupID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check group membership: %w", err)
+	}
+	if !isMember {
+		return nil, errors.New("user is not a member of this group")
+	}
+
+	// 2. 只允许确认当前版本的公告
+	current, err := mysql.GetLatestGroupAnnouncement(groupID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get group announcement: %w", err)
+	}
+	if current == nil {
+		return nil, errors.New("group has no announcement")
+	}
+	if current.ID != announcementID {
+		return nil, errors.New("announcement is outdated, please acknowledge the latest version")
+	}
+
+	// 3. 记录确认
+	ack := &model.GroupAnnouncementAck{
+		AnnouncementID: announcementID,
+		GroupID:        groupID,
+		UserID:         userID,
+	}
+	if err := mysql.AddGroupAnnouncementAck(ack); err != nil {
+		return nil, err
+	}
+
+	return buildAnnouncementInfoWithAcks(current)
+}
+
+// PinGroupMessage 置顶群消息，返回变更后的置顶列表
+func (s *groupService) PinGroupMessage(operatorID uint, groupID uint, groupMessageID uint) ([]*model.GroupPinnedMsgInfo, error) {
+	// 1. 检查权限
+	if _, err := requirePermission(s.memberCache, groupID, operatorID, model.GroupPermPinMessage); err != nil {
+		return nil, err
+	}
+
+	// 2. 检查消息存在且属于该群组
+	message, err := mysql.GetGroupMessageByID(groupMessageID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get group message: %w", err)
+	}
+	if message == nil || message.GroupID != groupID {
+		return nil, errors.New("message not found in this group")
+	}
+
+	// 3. 置顶
+	pin := &model.GroupPinnedMessage{
+		GroupID:        groupID,
+		GroupMessageID: groupMessageID,
+		PinnedBy:       operatorID,
+	}
+	if err := mysql.AddGroupPinnedMessage(pin, model.MaxGroupPinnedMessages); err != nil {
+		if errors.Is(err, mysql.ErrPinnedMessagesLimit) {
+			return nil, fmt.Errorf("at most %d messages can be pinned, please unpin one first", model.MaxGroupPinnedMessages)
+		}
+		return nil, err
+	}
+
+	return s.GetGroupPinnedMessages(groupID)
+}
+
+// UnpinGroupMessage 取消置顶群消息，返回变更后的置顶列表
+func (s *groupService) UnpinGroupMessage(operatorID uint, groupID uint, groupMessageID uint) ([]*model.GroupPinnedMsgInfo, error) {
+	if _, err := requirePermission(s.memberCache, groupID, operatorID, model.GroupPermPinMessage); err != nil {
+		return nil, err
+	}
+
+	if err := mysql.RemoveGroupPinnedMessage(groupID, groupMessageID); err != nil {
+		return nil, err
+	}
+
+	return s.GetGroupPinnedMessages(groupID)
+}
+
+// GetGroupPinnedMessages 获取群组置顶消息列表（包含消息内容）
+func (s *groupService) GetGroupPinnedMessages(groupID uint) ([]*model.GroupPinnedMsgInfo, error) {
+	// 1. 获取置顶记录
+	pins, err := mysql.GetGroupPinnedMessages(groupID)
+	if err != nil {
+		return nil, err
+	}
+	if len(pins) == 0 {
+		return []*model.GroupPinnedMsgInfo{}, nil
+	}
+
+	// 2. 批量获取被引用的消息
+	messageIDs := make([]uint, 0, len(pins))
+	for _, pin := range pins {
+		messageIDs = append(messageIDs, pin.GroupMessageID)
+	}
+	messages, err := mysql.GetGroupMessagesByIDs(messageIDs)
+	if err != nil {
+		return nil, err
+	}
+	messageMap := make(map[uint]*model.GroupMessage, len(messages))
+	for _, message := range messages {
+		messageMap[message.ID] = message
+	}
+
+	// 3. 按置顶顺序构建结果
+	result := make([]*model.GroupPinnedMsgInfo, 0, len(pins))
+	for _, pin := range pins {
+		message, exists := messageMap[pin.GroupMessageID]
+		if !exists {
+			fmt.Printf("Warning: Pinned message %d not found in group %d\n", pin.GroupMessageID, groupID)
+			continue
+		}
+		result = append(result, &model.GroupPinnedMsgInfo{
+			GroupMessageID: message.ID,
+			MsgID:          message.MsgID,
+			SenderID:       message.SenderID,
+			SenderName:     message.SenderName,
+			Content:        message.Content,
+			MessageType:    message.MessageType,
+			Timestamp:      message.CreatedAt.Unix(),
+			PinnedBy:       pin.PinnedBy,
+			PinnedAt:       pin.PinnedAt.Format("2006-01-02 15:04:05"),
+		})
+	}
+	return result, nil
+}
+
+// toGroupAnnouncementInfo 转换为公告响应格式（不含确认列表）
+func toGroupAnnouncementInfo(announcement *model.GroupAnnouncement) *model.GroupAnnouncementInfo {
+	return &model.GroupAnnouncementInfo{
+		ID:           announcement.ID,
+		GroupID:      announcement.GroupID,
+		Version:      announcement.Version,
+		Content:      announcement.Content,
+		EditorUserID: announcement.EditorUserID,
+		EditorName:   announcement.EditorName,
+		CreatedAt:    announcement.CreatedAt.Format("2006-01-02 15:04:05"),
+	}
+}
+
+// buildAnnouncementInfoWithAcks 构建包含确认列表的公告信息
+func buildAnnouncementInfoWithAcks(announcement *model.GroupAnnouncement) (*model.GroupAnnouncementInfo, error) {
+	acks, err := mysql.GetGroupAnnouncementAcks(announcement.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	userIDs := make([]uint, 0, len(acks))
+	for _, ack := range acks {
+		userIDs = append(userIDs, ack.UserID)
+	}
+	users, err := mysql.GetUsersByIDs(userIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get users info: %w", err)
+	}
+	usernameMap := make(map[uint]string, len(users))
+	for _, user := range users {
+		usernameMap[user.ID] = user.Username
+	}
+
+	info := toGroupAnnouncementInfo(announcement)
+	info.AckedBy = make([]*model.GroupAnnouncementAckInfo, 0, len(acks))
+	for _, ack := range acks {
+		info.AckedBy = append(info.AckedBy, &model.GroupAnnouncementAckInfo{
+			UserID:   ack.UserID,
+			Username: usernameMap[ack.UserID],
+			AckedAt:  ack.AckedAt.Format("2006-01-02 15:04:05"),
+		})
+	}
+	return info, nil
+}
@@ -0,0 +1,161 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/Xaytick/chat-zinx/chat-server/dao/mysql"
+	"github.com/Xaytick/chat-zinx/chat-server/pkg/model"
+)
+
+// checkNotBanned 检查用户是否被群组封禁，加入群组和邀请入群前都需要调用
+func checkNotBanned(groupID, userID uint) error {
+	ban, err := mysql.GetActiveGroupBan(groupID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to check group ban: %w", err)
+	}
+	if ban == nil {
+		return nil
+	}
+
+	msg := "user is banned from this group"
+	if ban.ExpiresAt != nil {
+		msg += " until " + ban.ExpiresAt.Format("2006-01-02 15:04:05")
+	}
+	if ban.Reason != "" {
+		msg += ", reason: " + ban.Reason
+	}
+	return errors.New(msg)
+}
+
+// BanGroupMember 封禁用户，目标用户如在群内会被一并移出；duration 为 0 表示永久封禁
+func (s *groupService) BanGroupMember(operatorID uint, groupID uint, targetUserID uint, reason string, duration time.Duration) error {
+	// 1. 校验目标用户
+	if targetUserID == operatorID {
+		return errors.New("cannot ban yourself")
+	}
+	if _, err := mysql.GetUserByID(targetUserID); err != nil {
+		if errors.Is(err, mysql.ErrRecordNotFound) {
+			return errors.New("target user not found")
+		}
+		return fmt.Errorf("failed to get target user: %w", err)
+	}
+
+	// 2. 检查权限：需要封禁权限，且目标如在群内，其角色必须低于操作者（群主因此不会被封禁）
+	if _, _, err := requirePermissionOver(s.memberCache, groupID, operatorID, targetUserID, model.GroupPermBanMember); err != nil {
+		return err
+	}
+
+	// 3. 校验封禁参数
+	reason = strings.TrimSpace(reason)
+	if utf8.RuneCountInString(reason) > model.MaxGroupBanReasonLen {
+		return fmt.Errorf("ban reason too long: at most %d characters", model.MaxGroupBanReasonLen)
+	}
+	if duration < 0 {
+		return errors.New("invalid ban duration")
+	}
+
+	// 4. 保存封禁记录并移出群组
+	ban := &model.GroupBan{
+		GroupID:  groupID,
+		UserID:   targetUserID,
+		Reason:   reason,
+		BannedBy: operatorID,
+	}
+	if duration > 0 {
+		expiresAt := time.Now().Add(duration)
+		ban.ExpiresAt = &expiresAt
+	}
+	if err := mysql.BanGroupMember(ban); err != nil {
+		return err
+	}
+	s.removeCachedMember(groupID, targetUserID)
+	return nil
+}
+
+// MuteGroupMember 禁言群成员，禁言期间不能在群内发送消息；duration 为 0 表示解除禁言
+func (s *groupService) MuteGroupMember(operatorID uint, groupID uint, targetUserID uint, duration time.Duration) error {
+	if targetUserID == operatorID {
+		return errors.New("cannot mute yourself")
+	}
+	if duration < 0 {
+		return errors.New("invalid mute duration")
+	}
+
+	// 需要禁言权限，且目标角色必须低于操作者
+	_, targetRole, err := requirePermissionOver(s.memberCache, groupID, operatorID, targetUserID, model.GroupPermMuteMember)
+	if err != nil {
+		return err
+	}
+	if targetRole == nil {
+		return ErrNotGroupMember
+	}
+
+	var until *time.Time
+	if duration > 0 {
+		t := time.Now().Add(duration)
+		until = &t
+	}
+	if err := mysql.SetGroupMemberMutedUntil(groupID, targetUserID, until); err != nil {
+		return err
+	}
+	invalidateMemberRoles(s.memberCache, groupID)
+	return nil
+}
+
+// UnbanGroupMember 解除群组封禁
+func (s *groupService) UnbanGroupMember(operatorID uint, groupID uint, targetUserID uint) error {
+	if _, err := requirePermission(s.memberCache, groupID, operatorID, model.GroupPermBanMember); err != nil {
+		return err
+	}
+	return mysql.RemoveGroupBan(groupID, targetUserID)
+}
+
+// GetGroupBanList 获取群组仍然有效的封禁列表，需要封禁权限
+func (s *groupService) GetGroupBanList(operatorID uint, groupID uint) ([]*model.GroupBanInfo, error) {
+	if _, err := requirePermission(s.memberCache, groupID, operatorID, model.GroupPermBanMember); err != nil {
+		return nil, err
+	}
+
+	bans, err := mysql.GetActiveGroupBans(groupID)
+	if err != nil {
+		return nil, err
+	}
+	if len(bans) == 0 {
+		return []*model.GroupBanInfo{}, nil
+	}
+
+	// 批量获取被封禁者和操作者的用户名
+	userIDs := make([]uint, 0, len(bans)*2)
+	for _, ban := range bans {
+		userIDs = append(userIDs, ban.UserID, ban.BannedBy)
+	}
+	users, err := mysql.GetUsersByIDs(userIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get users info: %w", err)
+	}
+	usernameMap := make(map[uint]string, len(users))
+	for _, user := range users {
+		usernameMap[user.ID] = user.Username
+	}
+
+	result := make([]*model.GroupBanInfo, 0, len(bans))
+	for _, ban := range bans {
+		info := &model.GroupBanInfo{
+			UserID:       ban.UserID,
+			Username:     usernameMap[ban.UserID],
+			Reason:       ban.Reason,
+			BannedBy:     ban.BannedBy,
+			BannedByName: usernameMap[ban.BannedBy],
+			CreatedAt:    ban.CreatedAt.Format("2006-01-02 15:04:05"),
+		}
+		if ban.ExpiresAt != nil {
+			info.ExpiresAt = ban.ExpiresAt.Format("2006-01-02 15:04:05")
+		}
+		result = append(result, info)
+	}
+	return result, nil
+}
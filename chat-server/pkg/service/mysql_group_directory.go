@@ -0,0 +1,111 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/Xaytick/chat-zinx/chat-server/dao/mysql"
+	"github.com/Xaytick/chat-zinx/chat-server/pkg/model"
+)
+
+// validateGroupCategory 校验群组分类，空字符串表示未分类
+func validateGroupCategory(category string) error {
+	if category != "" && !model.IsValidGroupCategory(category) {
+		return fmt.Errorf("invalid category: must be one of %s", strings.Join(model.GroupCategories, ", "))
+	}
+	return nil
+}
+
+// normalizeGroupTags 校验群组标签并统一为小写，同时去除空白和重复标签
+func normalizeGroupTags(tags []string) ([]string, error) {
+	normalized := make([]string, 0, len(tags))
+	seen := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" || seen[tag] {
+			continue
+		}
+		if utf8.RuneCountInString(tag) > model.MaxGroupTagLen {
+			return nil, fmt.Errorf("tag '%s' too long: at most %d characters", tag, model.MaxGroupTagLen)
+		}
+		seen[tag] = true
+		normalized = append(normalized, tag)
+	}
+	if len(normalized) > model.MaxGroupTags {
+		return nil, fmt.Errorf("too many tags: at most %d", model.MaxGroupTags)
+	}
+	return normalized, nil
+}
+
+// GetGroupTags 获取群组标签
+func (s *groupService) GetGroupTags(groupID uint) ([]string, error) {
+	tags, err := mysql.GetGroupTags([]uint{groupID})
+	if err != nil {
+		return nil, err
+	}
+	if tags[groupID] == nil {
+		return []string{}, nil
+	}
+	return tags[groupID], nil
+}
+
+// SearchPublicGroups 分页搜索公开群组目录
+func (s *groupService) SearchPublicGroups(req *model.SearchGroupsReq) (*model.SearchGroupsResp, error) {
+	// 1. 校验并规范化查询条件
+	keyword := strings.TrimSpace(req.Keyword)
+	category := strings.TrimSpace(req.Category)
+	if err := validateGroupCategory(category); err != nil {
+		return nil, err
+	}
+	tag := strings.ToLower(strings.TrimSpace(req.Tag))
+
+	page := req.Page
+	if page <= 0 {
+		page = 1
+	}
+	pageSize := req.PageSize
+	if pageSize <= 0 {
+		pageSize = model.DefaultGroupDirectoryPageSize
+	} else if pageSize > model.MaxGroupDirectoryPageSize {
+		pageSize = model.MaxGroupDirectoryPageSize
+	}
+
+	// 2. 查询群组及其标签
+	offset := (page - 1) * pageSize
+	groups, total, err := mysql.SearchPublicGroups(keyword, category, tag, offset, pageSize)
+	if err != nil {
+		return nil, err
+	}
+
+	groupIDs := make([]uint, 0, len(groups))
+	for _, group := range groups {
+		groupIDs = append(groupIDs, group.ID)
+	}
+	tags, err := mysql.GetGroupTags(groupIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	// 3. 构建结果
+	infos := make([]*model.GroupBasicInfo, 0, len(groups))
+	for _, group := range groups {
+		infos = append(infos, &model.GroupBasicInfo{
+			ID:          group.ID,
+			Name:        group.Name,
+			MemberCount: group.MemberCount,
+			Description: group.Description,
+			Avatar:      group.Avatar,
+			Category:    group.Category,
+			Tags:        tags[group.ID],
+		})
+	}
+
+	return &model.SearchGroupsResp{
+		Groups:   infos,
+		Total:    int(total),
+		Page:     page,
+		PageSize: pageSize,
+		HasMore:  int64(offset+len(groups)) < total,
+	}, nil
+}
@@ -0,0 +1,261 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/Xaytick/chat-zinx/chat-server/dao/mysql"
+	"github.com/Xaytick/chat-zinx/chat-server/pkg/model"
+	"github.com/Xaytick/chat-zinx/chat-server/pkg/storage"
+)
+
+var (
+	// ErrNotGroupMember 用户不是群组成员
+	ErrNotGroupMember = errors.New("user is not a member of this group")
+	// ErrGroupPermissionDenied 用户在群组中的角色没有所需权限
+	ErrGroupPermissionDenied = errors.New("permission denied")
+	// ErrGroupMemberMuted 用户在群组中被禁言，不能发送消息
+	ErrGroupMemberMuted = fmt.Errorf("%w: muted in this group", ErrGroupPermissionDenied)
+)
+
+// resolveGroupRole 根据角色名获取角色定义，内置角色不查询数据库
+func resolveGroupRole(groupID uint, roleName string) (*model.GroupRole, error) {
+	if role := model.BuiltinGroupRole(roleName); role != nil {
+		return role, nil
+	}
+	role, err := mysql.GetGroupRole(groupID, roleName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get group role: %w", err)
+	}
+	if role == nil {
+		// 角色已被删除但成员记录尚未更新时，按普通成员处理
+		return model.BuiltinGroupRole(model.GroupRoleMember), nil
+	}
+	return role, nil
+}
+
+// getMemberRole 获取用户在群组中的角色，用户不是群成员时返回 nil, nil
+func getMemberRole(cache *storage.GroupMemberCache, groupID, userID uint) (*model.GroupRole, error) {
+	access, err := getMemberAccess(cache, groupID, userID)
+	if access == nil {
+		return nil, err
+	}
+	return access.Role, nil
+}
+
+// getMemberAccess 获取用户在群组中的角色和禁言状态，用户不是群成员时返回 nil, nil
+// 群消息等高频操作都要鉴权，角色与群成员一起缓存在 Redis 中，缓存不可用时回源数据库
+func getMemberAccess(cache *storage.GroupMemberCache, groupID, userID uint) (*model.GroupMemberAccess, error) {
+	access, hit, err := cache.GetAccess(groupID, userID)
+	if err != nil {
+		fmt.Printf("Warning: %v\n", err)
+	} else if hit {
+		return access, nil
+	}
+	isMember, hit, err := cache.IsMember(groupID, userID)
+	if err == nil && hit && !isMember {
+		return nil, nil
+	}
+
+	// 版本号需在读取数据库之前获取，读取期间成员或角色有变更时不写入旧数据
+	gen, genErr := cache.Generation(groupID)
+	member, err := mysql.GetGroupMember(groupID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get group membership: %w", err)
+	}
+	if member == nil {
+		return nil, nil
+	}
+	role, err := resolveGroupRole(groupID, member.Role)
+	if err != nil {
+		return nil, err
+	}
+	access = &model.GroupMemberAccess{Role: role, MutedUntil: member.MutedUntil}
+	if genErr != nil {
+		fmt.Printf("Warning: %v\n", genErr)
+	} else if err := cache.SetAccess(groupID, userID, gen, access); err != nil {
+		fmt.Printf("Warning: %v\n", err)
+	}
+	return access, nil
+}
+
+// invalidateMemberRoles 成员角色、角色权限或禁言状态变更后删除群组的角色缓存
+func invalidateMemberRoles(cache *storage.GroupMemberCache, groupID uint) {
+	if err := cache.InvalidateRoles(groupID); err != nil {
+		fmt.Printf("Warning: failed to invalidate member roles of group %d: %v\n", groupID, err)
+	}
+}
+
+// requirePermission 校验用户是群成员且其角色拥有指定权限，返回用户的角色
+func requirePermission(cache *storage.GroupMemberCache, groupID, userID uint, perm model.GroupPermission) (*model.GroupRole, error) {
+	role, err := getMemberRole(cache, groupID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if role == nil {
+		return nil, ErrNotGroupMember
+	}
+	if !role.Permissions.Has(perm) {
+		return nil, fmt.Errorf("%w: role '%s' lacks %s", ErrGroupPermissionDenied, role.Name, perm)
+	}
+	return role, nil
+}
+
+// requirePermissionOver 在 requirePermission 的基础上，要求操作者的角色优先级高于目标用户
+// 目标用户不是群成员时 targetRole 为 nil
+func requirePermissionOver(cache *storage.GroupMemberCache, groupID, operatorID, targetUserID uint, perm model.GroupPermission) (operatorRole, targetRole *model.GroupRole, err error) {
+	operatorRole, err = requirePermission(cache, groupID, operatorID, perm)
+	if err != nil {
+		return nil, nil, err
+	}
+	targetRole, err = getMemberRole(cache, groupID, targetUserID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if targetRole != nil && targetRole.Priority >= operatorRole.Priority {
+		return nil, nil, fmt.Errorf("%w: target's role '%s' is not lower than yours", ErrGroupPermissionDenied, targetRole.Name)
+	}
+	return operatorRole, targetRole, nil
+}
+
+// CheckPermission 检查用户在群组中是否拥有指定权限，所有群组操作都通过该方法鉴权
+// 检查发言权限时，禁言中的成员返回 ErrGroupMemberMuted
+func (s *groupService) CheckPermission(userID uint, groupID uint, perm model.GroupPermission) error {
+	access, err := getMemberAccess(s.memberCache, groupID, userID)
+	if err != nil {
+		return err
+	}
+	if access == nil {
+		return ErrNotGroupMember
+	}
+	if !access.Role.Permissions.Has(perm) {
+		return fmt.Errorf("%w: role '%s' lacks %s", ErrGroupPermissionDenied, access.Role.Name, perm)
+	}
+	if perm&model.GroupPermSendMessage != 0 && access.IsMuted(time.Now()) {
+		return fmt.Errorf("%w until %s", ErrGroupMemberMuted, access.MutedUntil.Format("2006-01-02 15:04:05"))
+	}
+	return nil
+}
+
+// SaveGroupRole 创建或修改群组自定义角色
+func (s *groupService) SaveGroupRole(operatorID uint, req *model.SaveGroupRoleReq) (*model.GroupRoleInfo, error) {
+	// 1. 检查权限
+	operatorRole, err := requirePermission(s.memberCache, req.GroupID, operatorID, model.GroupPermManageRoles)
+	if err != nil {
+		return nil, err
+	}
+
+	// 2. 校验角色名和优先级
+	name := strings.TrimSpace(req.Name)
+	if name == "" || utf8.RuneCountInString(name) > 20 {
+		return nil, errors.New("invalid role name: must be 1-20 characters")
+	}
+	if model.BuiltinGroupRole(name) != nil {
+		return nil, fmt.Errorf("cannot modify built-in role '%s'", name)
+	}
+	if req.Priority < 1 || req.Priority >= model.GroupRolePriorityOwner {
+		return nil, fmt.Errorf("invalid priority: must be between 1 and %d", model.GroupRolePriorityOwner-1)
+	}
+	if req.Priority >= operatorRole.Priority {
+		return nil, fmt.Errorf("%w: cannot create a role at or above your own priority", ErrGroupPermissionDenied)
+	}
+
+	// 3. 解析权限，不能授予自己没有的权限
+	perms, err := model.ParseGroupPermissions(req.Permissions)
+	if err != nil {
+		return nil, err
+	}
+	if !operatorRole.Permissions.Has(perms) {
+		return nil, fmt.Errorf("%w: cannot grant permissions you do not have", ErrGroupPermissionDenied)
+	}
+
+	// 4. 修改已有角色时，原角色也必须低于操作者
+	existing, err := mysql.GetGroupRole(req.GroupID, name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get group role: %w", err)
+	}
+	if existing != nil && existing.Priority >= operatorRole.Priority {
+		return nil, fmt.Errorf("%w: role '%s' is not lower than yours", ErrGroupPermissionDenied, name)
+	}
+
+	role := &model.GroupRole{
+		GroupID:     req.GroupID,
+		Name:        name,
+		Permissions: perms,
+		Priority:    req.Priority,
+	}
+	if err := mysql.SaveGroupRole(role); err != nil {
+		return nil, err
+	}
+	invalidateMemberRoles(s.memberCache, req.GroupID)
+	return toGroupRoleInfo(role, false), nil
+}
+
+// DeleteGroupRole 删除群组自定义角色，持有该角色的成员恢复为普通成员
+func (s *groupService) DeleteGroupRole(operatorID uint, groupID uint, name string) error {
+	operatorRole, err := requirePermission(s.memberCache, groupID, operatorID, model.GroupPermManageRoles)
+	if err != nil {
+		return err
+	}
+	if model.BuiltinGroupRole(name) != nil {
+		return fmt.Errorf("cannot delete built-in role '%s'", name)
+	}
+
+	role, err := mysql.GetGroupRole(groupID, name)
+	if err != nil {
+		return fmt.Errorf("failed to get group role: %w", err)
+	}
+	if role == nil {
+		return errors.New("role not found")
+	}
+	if role.Priority >= operatorRole.Priority {
+		return fmt.Errorf("%w: role '%s' is not lower than yours", ErrGroupPermissionDenied, name)
+	}
+	if err := mysql.DeleteGroupRole(groupID, name); err != nil {
+		return err
+	}
+	invalidateMemberRoles(s.memberCache, groupID)
+	return nil
+}
+
+// GetGroupRoles 获取群组的全部角色（含内置角色），按优先级从高到低排列，仅群成员可查看
+func (s *groupService) GetGroupRoles(userID uint, groupID uint) ([]*model.GroupRoleInfo, error) {
+	isMember, err := s.IsUserInGroup(userID, groupID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check group membership: %w", err)
+	}
+	if !isMember {
+		return nil, ErrNotGroupMember
+	}
+
+	customRoles, err := mysql.GetGroupRoles(groupID)
+	if err != nil {
+		return nil, err
+	}
+
+	roles := make([]*model.GroupRoleInfo, 0, len(customRoles)+3)
+	for _, name := range []string{model.GroupRoleOwner, model.GroupRoleAdmin, model.GroupRoleMember} {
+		roles = append(roles, toGroupRoleInfo(model.BuiltinGroupRole(name), true))
+	}
+	for _, role := range customRoles {
+		roles = append(roles, toGroupRoleInfo(role, false))
+	}
+	sort.SliceStable(roles, func(i, j int) bool {
+		return roles[i].Priority > roles[j].Priority
+	})
+	return roles, nil
+}
+
+// toGroupRoleInfo 转换为角色响应格式
+func toGroupRoleInfo(role *model.GroupRole, isBuiltin bool) *model.GroupRoleInfo {
+	return &model.GroupRoleInfo{
+		Name:        role.Name,
+		Permissions: role.Permissions.Names(),
+		Priority:    role.Priority,
+		IsBuiltin:   isBuiltin,
+	}
+}
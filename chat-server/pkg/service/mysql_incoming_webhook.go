@@ -0,0 +1,168 @@
+package service
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/Xaytick/chat-zinx/chat-server/conf"
+	"github.com/Xaytick/chat-zinx/chat-server/dao/mysql"
+	"github.com/Xaytick/chat-zinx/chat-server/pkg/model"
+	"github.com/Xaytick/chat-zinx/chat-server/pkg/storage"
+)
+
+type incomingWebhookService struct {
+	cfg         *conf.IncomingWebhookConfig
+	limiter     *storage.RateLimiter
+	memberCache *storage.GroupMemberCache // 群成员及角色缓存，鉴权时使用
+}
+
+// NewIncomingWebhookService 创建一个新的 incoming webhook 服务实例
+func NewIncomingWebhookService() IIncomingWebhookService {
+	cfg := conf.GetIncomingWebhookConfig()
+	return &incomingWebhookService{
+		cfg:         cfg,
+		limiter:     storage.NewRateLimiter("incoming_webhook", cfg.RateLimit, time.Duration(cfg.RateWindow)*time.Second),
+		memberCache: storage.NewGroupMemberCache(),
+	}
+}
+
+// Create 创建 webhook，令牌只保存摘要
+func (s *incomingWebhookService) Create(userID uint, req *model.CreateIncomingWebhookReq) (*model.CreateIncomingWebhookResp, error) {
+	if _, err := requirePermission(s.memberCache, req.GroupID, userID, model.GroupPermManageIntegrations); err != nil {
+		return nil, err
+	}
+	name := strings.TrimSpace(req.Name)
+	if name == "" || utf8.RuneCountInString(name) > model.MaxIncomingWebhookNameLen {
+		return nil, ErrInvalidIntegrationName
+	}
+
+	count, err := mysql.CountActiveIncomingWebhooks(req.GroupID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count incoming webhooks: %w", err)
+	}
+	if count >= int64(s.cfg.MaxPerGroup) {
+		return nil, ErrTooManyIncomingWebhooks
+	}
+
+	raw, err := randomHex(24)
+	if err != nil {
+		return nil, err
+	}
+	token := model.IncomingWebhookTokenPrefix + raw
+	hook := &model.IncomingWebhook{
+		GroupID:   req.GroupID,
+		Name:      name,
+		TokenHash: hashToken(token),
+		Hint:      raw[:model.IncomingWebhookTokenHintLen],
+		CreatorID: userID,
+	}
+	if err := mysql.CreateIncomingWebhook(hook); err != nil {
+		return nil, err
+	}
+	return &model.CreateIncomingWebhookResp{Webhook: hook, URL: incomingWebhookURL(token), Token: token}, nil
+}
+
+// Revoke 吊销 webhook
+func (s *incomingWebhookService) Revoke(userID, groupID, hookID uint) error {
+	if _, err := requirePermission(s.memberCache, groupID, userID, model.GroupPermManageIntegrations); err != nil {
+		return err
+	}
+	revoked, err := mysql.RevokeIncomingWebhook(groupID, hookID)
+	if err != nil {
+		return err
+	}
+	if !revoked {
+		return ErrIncomingWebhookNotFound
+	}
+	return nil
+}
+
+// List 获取群组未吊销的 webhook
+func (s *incomingWebhookService) List(userID, groupID uint) ([]*model.IncomingWebhook, error) {
+	if _, err := requirePermission(s.memberCache, groupID, userID, model.GroupPermManageIntegrations); err != nil {
+		return nil, err
+	}
+	hooks, err := mysql.GetActiveIncomingWebhooks(groupID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get incoming webhooks: %w", err)
+	}
+	return hooks, nil
+}
+
+// Authenticate 校验令牌并按 webhook 计数，同一个群的多个 webhook 各自计算额度
+// 只有通过限流的请求才记录使用时间，被限流的调用方不会因为重试而持续写数据库
+func (s *incomingWebhookService) Authenticate(token string) (*model.IncomingWebhook, error) {
+	token = strings.TrimSpace(token)
+	if !strings.HasPrefix(token, model.IncomingWebhookTokenPrefix) {
+		return nil, ErrInvalidWebhookToken
+	}
+	hook, err := mysql.GetIncomingWebhookByHash(hashToken(token))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get incoming webhook: %w", err)
+	}
+	if hook == nil {
+		return nil, ErrInvalidWebhookToken
+	}
+
+	wait, err := s.limiter.Allow(strconv.FormatUint(uint64(hook.ID), 10))
+	if err != nil {
+		return nil, fmt.Errorf("failed to check rate limit: %w", err)
+	}
+	if wait > 0 {
+		return nil, &ThrottledError{Reason: ErrWebhookRateLimited, RetryAfter: wait}
+	}
+
+	if err := mysql.TouchIncomingWebhook(hook.ID); err != nil {
+		fmt.Printf("警告: 更新 webhook 使用时间失败: %v\n", err)
+	}
+	return hook, nil
+}
+
+// FormatMessage 依次拼接标题、正文和字段，每个字段占一行
+func (s *incomingWebhookService) FormatMessage(payload *model.IncomingWebhookPayload) (string, error) {
+	title := strings.TrimSpace(payload.Title)
+	text := strings.TrimSpace(payload.Text)
+	if title == "" && text == "" {
+		return "", ErrEmptyWebhookMessage
+	}
+	if len(payload.Fields) > s.cfg.MaxFields {
+		return "", fmt.Errorf("%w: at most %d", ErrTooManyWebhookFields, s.cfg.MaxFields)
+	}
+
+	lines := make([]string, 0, len(payload.Fields)+2)
+	if title != "" {
+		lines = append(lines, "【"+title+"】")
+	}
+	if text != "" {
+		lines = append(lines, text)
+	}
+	for _, field := range payload.Fields {
+		if field == nil || strings.TrimSpace(field.Name) == "" {
+			continue
+		}
+		lines = append(lines, strings.TrimSpace(field.Name)+": "+strings.TrimSpace(field.Value))
+	}
+
+	content := strings.Join(lines, "\n")
+	if utf8.RuneCountInString(content) > s.cfg.MaxTextLen {
+		return "", fmt.Errorf("%w: at most %d characters", ErrWebhookMessageTooLong, s.cfg.MaxTextLen)
+	}
+	return content, nil
+}
+
+// incomingWebhookURL 生成 webhook 的完整地址，未配置 PublicURL 时使用监听端口拼接本机地址
+func incomingWebhookURL(token string) string {
+	httpConfig := conf.GetHTTPConfig()
+	base := strings.TrimRight(httpConfig.PublicURL, "/")
+	if base == "" {
+		addr := httpConfig.Addr
+		if strings.HasPrefix(addr, ":") {
+			addr = "localhost" + addr
+		}
+		base = "http://" + addr
+	}
+	return base + model.IncomingWebhookPathPrefix + token
+}
@@ -0,0 +1,88 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Xaytick/chat-zinx/chat-server/dao/mysql"
+	"github.com/Xaytick/chat-zinx/chat-server/pkg/model"
+)
+
+// RecordLoginAttempt 记录登录尝试，成功登录时检查是否来自新的IP或设备
+func (s *userService) RecordLoginAttempt(userID uint, username string, client *model.LoginClientInfo, success bool, failReason string) (*model.SuspiciousLoginPush, error) {
+	// 失败的登录也记到对应用户名下，用户可以看到针对自己账号的尝试
+	if userID == 0 && username != "" {
+		user, err := mysql.GetUserByUsername(username)
+		if err != nil && !errors.Is(err, mysql.ErrRecordNotFound) {
+			return nil, fmt.Errorf("failed to get user: %w", err)
+		}
+		if user != nil {
+			userID = user.ID
+			username = user.Username
+		}
+	}
+	// 不存在的用户名没有人能查看这些记录，只由登录限流计数，不写入数据库，避免记录表被无效用户名撑大
+	if userID == 0 {
+		return nil, nil
+	}
+
+	record := &model.LoginRecord{
+		UserID:        userID,
+		Username:      truncate(username, 50),
+		Success:       success,
+		IP:            client.IP,
+		ClientVersion: truncate(client.ClientVersion, model.MaxClientVersionLen),
+		DeviceID:      truncate(client.DeviceID, model.MaxDeviceIDLen),
+	}
+	if !success {
+		record.FailReason = failReason
+	}
+
+	// 新IP、新设备的判断要在写入本次记录之前完成
+	var push *model.SuspiciousLoginPush
+	if success {
+		anyLogin, ipSeen, deviceSeen, err := mysql.GetLoginSourcesSeen(userID, record.IP, record.DeviceID)
+		if err != nil {
+			fmt.Printf("警告: 检查登录来源失败: %v\n", err)
+		} else if anyLogin && (!ipSeen || !deviceSeen) {
+			push = &model.SuspiciousLoginPush{
+				IP:            record.IP,
+				DeviceID:      record.DeviceID,
+				ClientVersion: record.ClientVersion,
+				NewIP:         !ipSeen,
+				NewDevice:     !deviceSeen,
+				LoginAt:       time.Now().Unix(),
+			}
+		}
+	}
+
+	if err := mysql.CreateLoginRecord(record); err != nil {
+		return push, err
+	}
+	return push, nil
+}
+
+// GetLoginHistory 获取自己最近的登录记录
+func (s *userService) GetLoginHistory(userID uint, limit int) ([]*model.LoginRecord, error) {
+	if limit <= 0 {
+		limit = model.DefaultLoginHistoryLimit
+	}
+	if limit > model.MaxLoginHistoryLimit {
+		limit = model.MaxLoginHistoryLimit
+	}
+	records, err := mysql.GetLoginRecords(userID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get login history: %w", err)
+	}
+	return records, nil
+}
+
+// truncate 按字符截断字符串
+func truncate(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n])
+}
@@ -3,27 +3,55 @@ package service
 import (
 	"errors"
 	"fmt"
+	"strconv"
 	"time"
 
 	"github.com/Xaytick/chat-zinx/chat-server/conf"
 	"github.com/Xaytick/chat-zinx/chat-server/dao/mysql"
+	"github.com/Xaytick/chat-zinx/chat-server/pkg/mailer"
 	"github.com/Xaytick/chat-zinx/chat-server/pkg/model"
+	"github.com/Xaytick/chat-zinx/chat-server/pkg/storage"
 	"github.com/golang-jwt/jwt"
 	"golang.org/x/crypto/bcrypt"
 )
 
 // userService 结构体不需要导出
-type userService struct{}
+type userService struct {
+	blockCache *storage.BlockListCache // 用户屏蔽列表缓存，查看资料时检查
+	mailer     mailer.Mailer           // 发送密码重置和邮箱验证邮件
+	loginGuard *storage.LoginGuard     // 登录失败计数与锁定
+	dummyHash  []byte                  // 账号不存在时用于比较的密码哈希，使响应时间与密码错误一致
+
+	msgStorage  *storage.RedisMsgStorage  // 私聊消息存储，数据导出和注销清除时使用
+	memberCache *storage.GroupMemberCache // 群成员ID缓存，注销清除时更新
+}
 
 // NewMySQLUserService 创建一个新的用户服务实例 (MySQL实现)
 // 函数名更改为 NewMySQLUserService 以明确其实现方式
-func NewMySQLUserService() IUserService { // 保持 NewUserService，外部通过接口调用
-	return &userService{}
+func NewMySQLUserService(m mailer.Mailer) IUserService { // 保持 NewUserService，外部通过接口调用
+	dummyHash, _ := bcrypt.GenerateFromPassword([]byte("chat-zinx-dummy-password"), bcrypt.DefaultCost)
+	return &userService{
+		blockCache: storage.NewBlockListCache(),
+		mailer:     m,
+		loginGuard: storage.NewLoginGuard(),
+		dummyHash:  dummyHash,
+
+		msgStorage:  storage.NewRedisMsgStorage(),
+		memberCache: storage.NewGroupMemberCache(),
+	}
 }
 
 // Register 处理用户注册逻辑
 func (s *userService) Register(req *model.UserRegisterReq) (*model.User, error) {
-	// 1. 检查用户名是否已存在
+	// 0. 按IP限制注册频率
+	if err := s.checkRegisterAllowed(req.ClientIP); err != nil {
+		return nil, err
+	}
+
+	// 1. 检查用户名是否保留或已存在
+	if err := checkReservedUsername(req.Username); err != nil {
+		return nil, err
+	}
 	existingUser, err := mysql.GetUserByUsername(req.Username)
 	if err != nil {
 		// 只有当错误不是 "record not found" 时，才认为是检查过程的失败
@@ -58,50 +86,104 @@ func (s *userService) Register(req *model.UserRegisterReq) (*model.User, error)
 		return nil, fmt.Errorf("创建用户失败: %w", creationErr)
 	}
 
+	// 4. 发送邮箱验证邮件，失败不影响注册，用户可以稍后重新发送
+	if err := s.sendEmailVerification(user); err != nil {
+		fmt.Printf("警告: 发送邮箱验证邮件失败: %v\n", err)
+	}
+
 	return user, nil // 返回创建后的用户信息（包含ID, UserUUID等）
 }
 
 // Login 处理用户登录逻辑
 func (s *userService) Login(req *model.UserLoginReq) (string, *model.User, error) {
-	// 1. 根据用户名获取用户信息
+	return s.login(req, true)
+}
+
+// LoginForToken HTTP 登录，不更新在线状态，避免覆盖同一用户的长连接登录状态
+func (s *userService) LoginForToken(req *model.UserLoginReq) (string, *model.User, error) {
+	return s.login(req, false)
+}
+
+// login 校验用户名和密码，markOnline 为 true 时把用户标记为在线
+func (s *userService) login(req *model.UserLoginReq, markOnline bool) (string, *model.User, error) {
+	// 1. 检查账号和IP是否处于退避或锁定状态
+	if err := s.checkLoginAllowed(req.Username, req.ClientIP); err != nil {
+		return "", nil, err
+	}
+
+	// 2. 根据用户名获取用户信息并验证密码 (user.Password 是哈希后的密码)
+	// 账号不存在和密码错误返回同样的错误，避免泄露用户名是否存在
 	user, err := mysql.GetUserByUsername(req.Username)
 	if err != nil {
-		// Log the detailed error, but return a generic one to the client
-		fmt.Printf("Error in Login - GetUserByUsername for '%s': %v\n", req.Username, err)
-		return "", nil, ErrInvalidCredentials // Generic error for security
+		if !errors.Is(err, mysql.ErrRecordNotFound) {
+			fmt.Printf("Error in Login - GetUserByUsername for '%s': %v\n", req.Username, err)
+			return "", nil, ErrInvalidCredentials
+		}
+		// 账号不存在时同样做一次哈希比较，使响应时间与密码错误时一致
+		_ = bcrypt.CompareHashAndPassword(s.dummyHash, []byte(req.Password))
+		s.recordLoginFailure(req.Username, req.ClientIP)
+		return "", nil, ErrInvalidCredentials
 	}
-	if user == nil {
-		return "", nil, ErrUserNotFound
+	// 访客和机器人账号不能用密码登录，按密码错误处理
+	if model.NormalizeAccountKind(user.AccountKind) != model.AccountKindHuman {
+		_ = bcrypt.CompareHashAndPassword(s.dummyHash, []byte(req.Password))
+		s.recordLoginFailure(req.Username, req.ClientIP)
+		return "", nil, ErrInvalidCredentials
 	}
 
-	// 2. 验证密码 (user.Password 是哈希后的密码)
 	err = bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.Password))
 	if err != nil {
-		if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
-			return "", nil, ErrPasswordIncorrect
+		if !errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+			fmt.Printf("登录失败 - 密码验证失败: %v\n", err)
 		}
-		fmt.Printf("登录失败 - 密码验证失败: %v\n", err)
-		return "", nil, ErrInvalidCredentials // Generic error for other bcrypt issues
+		s.recordLoginFailure(req.Username, req.ClientIP)
+		return "", nil, ErrInvalidCredentials
+	}
+
+	// 3. 开启了两步验证时还需要提交验证码，此时不签发 Token
+	// 失败计数要等验证码也通过后才清除，否则重新提交密码即可清零，验证码可被无限次尝试
+	if user.TOTPEnabled {
+		return "", user, ErrTwoFactorRequired
 	}
+	s.resetLoginFailures(req.Username)
 
-	// 3. 更新最后登录信息和在线状态
-	if err := s.UpdateUserLastLoginInfo(user.ID); err != nil {
+	tokenString, err := s.finishLogin(user, req.ClientIP, markOnline)
+	if err != nil {
+		return "", nil, err
+	}
+	return tokenString, user, nil
+}
+
+// completeLogin 身份验证通过后更新登录状态并签发JWT Token
+func (s *userService) completeLogin(user *model.User, clientIP string) (string, error) {
+	return s.finishLogin(user, clientIP, true)
+}
+
+// finishLogin 更新最后登录信息并签发JWT Token，markOnline 为 false 时不修改在线状态
+// 在线状态由长连接维护，只签发 Token 的 HTTP 登录不能改动，否则会覆盖并发的 TCP 登录
+func (s *userService) finishLogin(user *model.User, clientIP string, markOnline bool) (string, error) {
+	// 1. 更新最后登录信息和在线状态
+	if err := s.UpdateUserLastLoginInfo(user.ID, clientIP); err != nil {
 		fmt.Printf("警告: 更新用户最后登录信息失败: %v\n", err)
 		// Non-critical error, proceed with login
 	}
-	if err := s.UpdateUserOnlineStatus(user.ID, true); err != nil {
-		fmt.Printf("警告: 更新用户在线状态失败: %v\n", err)
-		// Non-critical error, proceed with login
+	if markOnline {
+		if err := s.UpdateUserOnlineStatus(user.ID, true); err != nil {
+			fmt.Printf("警告: 更新用户在线状态失败: %v\n", err)
+			// Non-critical error, proceed with login
+		}
 	}
 
-	// 4. 生成JWT Token
+	// 2. 生成JWT Token
 	jwtConf := conf.GetAuthConfig().JWT
 	claims := model.CustomClaims{
-		ID:       user.ID,
-		UserUUID: user.UserUUID,
-		Username: user.Username,
+		ID:           user.ID,
+		UserUUID:     user.UserUUID,
+		Username:     user.Username,
+		TokenVersion: user.TokenVersion,
 		StandardClaims: jwt.StandardClaims{
 			ExpiresAt: time.Now().Add(time.Second * time.Duration(jwtConf.ExpiresIn)).Unix(),
+			IssuedAt:  time.Now().Unix(),
 			Issuer:    jwtConf.Issuer,
 			NotBefore: time.Now().Unix(),
 		},
@@ -110,10 +192,59 @@ func (s *userService) Login(req *model.UserLoginReq) (string, *model.User, error
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 	tokenString, err := token.SignedString([]byte(jwtConf.Secret))
 	if err != nil {
-		return "", nil, fmt.Errorf("生成JWT Token失败: %w", err)
+		return "", fmt.Errorf("生成JWT Token失败: %w", err)
 	}
+	return tokenString, nil
+}
 
-	return tokenString, user, nil
+// VerifyToken 校验 JWT Token，供不经过 TCP 登录的 HTTP 接口使用
+func (s *userService) VerifyToken(tokenString string) (*model.User, error) {
+	claims := &model.CustomClaims{}
+	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method %v", token.Header["alg"])
+		}
+		return []byte(conf.GetAuthConfig().JWT.Secret), nil
+	})
+	if err != nil || !token.Valid {
+		return nil, ErrInvalidToken
+	}
+
+	return s.VerifySessionUser(claims.ID, claims.TokenVersion)
+}
+
+// TokenLogin 会话无法恢复时（如重连到其他节点）凭 JWT 重新登录，只更新登录信息和在线状态，不签发新 Token
+func (s *userService) TokenLogin(tokenString, clientIP string) (*model.User, error) {
+	user, err := s.VerifyToken(tokenString)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.UpdateUserLastLoginInfo(user.ID, clientIP); err != nil {
+		fmt.Printf("警告: 更新用户最后登录信息失败: %v\n", err)
+	}
+	if err := s.UpdateUserOnlineStatus(user.ID, true); err != nil {
+		fmt.Printf("警告: 更新用户在线状态失败: %v\n", err)
+	}
+	return user, nil
+}
+
+// VerifySessionUser 账号已删除、已到清除时间或修改过密码时，之前签发的 Token 和会话都失效
+func (s *userService) VerifySessionUser(userID, tokenVersion uint) (*model.User, error) {
+	user, err := s.GetUserByID(userID)
+	if err != nil {
+		if errors.Is(err, ErrUserNotFound) {
+			return nil, ErrInvalidToken
+		}
+		return nil, err
+	}
+	if user.TokenVersion != tokenVersion {
+		return nil, ErrInvalidToken
+	}
+	// 到期未清除的账号（如过期访客）等待清除任务处理，期间不能继续使用
+	if user.DeletionDueAt != nil && !user.DeletionDueAt.After(time.Now()) {
+		return nil, ErrInvalidToken
+	}
+	return user, nil
 }
 
 // GetUserByID 根据用户ID获取用户信息
@@ -163,13 +294,34 @@ func (s *userService) GetUserByUsername(username string) (*model.User, error) {
 	return user, nil
 }
 
+// ResolveUser 依次按 UUID、用户名、数字ID 查找用户，客户端可以用任意一种方式指定用户
+func (s *userService) ResolveUser(identifier string) (*model.User, error) {
+	user, err := s.GetUserByUUID(identifier)
+	if err == nil || !errors.Is(err, ErrUserNotFound) {
+		return user, err
+	}
+	user, err = s.GetUserByUsername(identifier)
+	if err == nil || !errors.Is(err, ErrUserNotFound) {
+		return user, err
+	}
+	id, parseErr := strconv.ParseUint(identifier, 10, 32)
+	if parseErr != nil {
+		return nil, ErrUserNotFound
+	}
+	return s.GetUserByID(uint(id))
+}
+
 // UpdateUserOnlineStatus 更新用户在线状态
 func (s *userService) UpdateUserOnlineStatus(userID uint, isOnline bool) error {
 	return mysql.UpdateUserOnlineStatus(userID, isOnline) // Assumes mysql.UpdateUserOnlineStatus will be implemented
 }
 
+// MarkUsersOffline 批量把用户标记为离线，之后在其他节点登录过的用户不受影响
+func (s *userService) MarkUsersOffline(loggedInAt map[uint]time.Time) error {
+	return mysql.MarkUsersOffline(loggedInAt)
+}
+
 // UpdateUserLastLoginInfo 更新用户最后登录IP和时间
-func (s *userService) UpdateUserLastLoginInfo(userID uint) error {
-	// Potentially add clientIP if available/needed
-	return mysql.UpdateUserLastLoginInfo(userID) // Assumes mysql.UpdateUserLastLoginInfo will be implemented
+func (s *userService) UpdateUserLastLoginInfo(userID uint, ip string) error {
+	return mysql.UpdateUserLastLoginInfo(userID, ip)
 }
@@ -0,0 +1,216 @@
+package service
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Xaytick/chat-zinx/chat-server/conf"
+	"github.com/Xaytick/chat-zinx/chat-server/dao/mysql"
+	"github.com/Xaytick/chat-zinx/chat-server/pkg/mailer"
+	"github.com/Xaytick/chat-zinx/chat-server/pkg/model"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// ChangePassword 校验旧密码后修改密码
+func (s *userService) ChangePassword(userID uint, oldPassword, newPassword string) error {
+	user, err := s.GetUserByID(userID)
+	if err != nil {
+		return err
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(oldPassword)); err != nil {
+		return ErrPasswordIncorrect
+	}
+	if oldPassword == newPassword {
+		return errors.New("new password must differ from the old one")
+	}
+	return setPassword(userID, newPassword)
+}
+
+// RequestPasswordReset 向账号绑定的邮箱发送密码重置令牌
+// 为避免通过该接口探测账号是否存在，查询账号和发送邮件都在后台进行，除IP限流外总是返回 nil；
+// 同一账号在窗口内申请过多时不再发送邮件，同样返回 nil
+func (s *userService) RequestPasswordReset(account, ip string) error {
+	account = strings.TrimSpace(account)
+	if account == "" {
+		return errors.New("account is required")
+	}
+
+	// Redis 不可用时不发送邮件，避免限流失效时被用来向用户邮箱发送大量邮件
+	ipWait, accountLimited, err := s.loginGuard.AllowPasswordReset(account, ip)
+	if err != nil {
+		fmt.Printf("警告: 检查重置密码限制失败: %v\n", err)
+		return nil
+	}
+	if ipWait > 0 {
+		return &ThrottledError{Reason: ErrTooManyResetRequests, RetryAfter: ipWait}
+	}
+	if accountLimited {
+		fmt.Printf("Password reset for %s throttled\n", account)
+		return nil
+	}
+
+	go func() {
+		if err := s.sendPasswordReset(account); err != nil {
+			fmt.Printf("Failed to send password reset for %s: %v\n", account, err)
+		}
+	}()
+	return nil
+}
+
+// sendPasswordReset 查询账号并向其绑定的邮箱发送重置令牌，账号不存在或未绑定邮箱时什么都不做
+func (s *userService) sendPasswordReset(account string) error {
+	var user *model.User
+	var err error
+	if strings.Contains(account, "@") {
+		user, err = mysql.GetUserByEmail(account)
+	} else {
+		user, err = mysql.GetUserByUsername(account)
+	}
+	if err != nil {
+		if errors.Is(err, mysql.ErrRecordNotFound) {
+			fmt.Printf("Password reset requested for unknown account %s\n", account)
+			return nil
+		}
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+	if user.Email == "" {
+		fmt.Printf("Password reset requested for user %d without email\n", user.ID)
+		return nil
+	}
+	// 访客和机器人账号没有密码，也没有真实邮箱
+	if model.NormalizeAccountKind(user.AccountKind) != model.AccountKindHuman {
+		fmt.Printf("Password reset requested for %s account %d\n", user.AccountKind, user.ID)
+		return nil
+	}
+
+	ttl := time.Duration(conf.GetAuthConfig().Security.PasswordResetTTL) * time.Second
+	token, err := issueUserToken(user, model.TokenPurposePasswordReset, ttl)
+	if err != nil {
+		return err
+	}
+	return s.mailer.Send(&mailer.Message{
+		To:      user.Email,
+		Subject: "重置你的 chat-zinx 密码",
+		Body: fmt.Sprintf("%s，你好：\n\n我们收到了重置密码的申请。你的重置令牌为：\n\n%s\n\n"+
+			"令牌 %d 分钟内有效，且只能使用一次。如果不是你本人操作，请忽略本邮件。\n",
+			user.Username, token, int(ttl.Minutes())),
+	})
+}
+
+// ResetPassword 使用一次性令牌重置密码，新密码不符合要求时不消耗令牌
+func (s *userService) ResetPassword(token, newPassword string) (uint, error) {
+	if err := validatePassword(newPassword); err != nil {
+		return 0, err
+	}
+	record, err := mysql.ConsumeUserToken(hashToken(token), model.TokenPurposePasswordReset)
+	if err != nil {
+		if errors.Is(err, mysql.ErrInvalidToken) {
+			return 0, ErrInvalidToken
+		}
+		return 0, err
+	}
+	if err := setPassword(record.UserID, newPassword); err != nil {
+		return 0, err
+	}
+	return record.UserID, nil
+}
+
+// SendEmailVerification 重新发送邮箱验证邮件
+func (s *userService) SendEmailVerification(userID uint) error {
+	user, err := s.GetUserByID(userID)
+	if err != nil {
+		return err
+	}
+	if user.Email == "" {
+		return errors.New("no email bound to this account")
+	}
+	if user.EmailVerified {
+		return errors.New("email already verified")
+	}
+	return s.sendEmailVerification(user)
+}
+
+// VerifyEmail 使用一次性令牌验证邮箱
+func (s *userService) VerifyEmail(token string) (uint, error) {
+	record, err := mysql.ConsumeUserToken(hashToken(token), model.TokenPurposeEmailVerify)
+	if err != nil {
+		if errors.Is(err, mysql.ErrInvalidToken) {
+			return 0, ErrInvalidToken
+		}
+		return 0, err
+	}
+	if err := mysql.MarkUserEmailVerified(record.UserID, record.Email); err != nil {
+		return 0, err
+	}
+	return record.UserID, nil
+}
+
+// sendEmailVerification 生成邮箱验证令牌并发送邮件
+func (s *userService) sendEmailVerification(user *model.User) error {
+	ttl := time.Duration(conf.GetAuthConfig().Security.EmailVerifyTTL) * time.Second
+	token, err := issueUserToken(user, model.TokenPurposeEmailVerify, ttl)
+	if err != nil {
+		return err
+	}
+	return s.mailer.Send(&mailer.Message{
+		To:      user.Email,
+		Subject: "验证你的 chat-zinx 邮箱",
+		Body: fmt.Sprintf("%s，你好：\n\n请使用以下令牌验证你的邮箱：\n\n%s\n\n令牌 %d 小时内有效，且只能使用一次。\n",
+			user.Username, token, int(ttl.Hours())),
+	})
+}
+
+// validatePassword 校验新密码长度
+func validatePassword(newPassword string) error {
+	if len(newPassword) < model.MinPasswordLen || len(newPassword) > model.MaxPasswordLen {
+		return fmt.Errorf("password must be %d-%d characters", model.MinPasswordLen, model.MaxPasswordLen)
+	}
+	return nil
+}
+
+// setPassword 校验新密码长度后保存密码哈希
+func setPassword(userID uint, newPassword string) error {
+	if err := validatePassword(newPassword); err != nil {
+		return err
+	}
+	hashed, err := bcrypt.GenerateFromPassword([]byte(newPassword), bcrypt.DefaultCost)
+	if err != nil {
+		return fmt.Errorf("failed to hash password: %w", err)
+	}
+	if err := mysql.UpdateUserPassword(userID, string(hashed)); err != nil {
+		return fmt.Errorf("failed to update password: %w", err)
+	}
+	return nil
+}
+
+// issueUserToken 生成随机令牌并保存其摘要，返回发给用户的明文令牌
+func issueUserToken(user *model.User, purpose string, ttl time.Duration) (string, error) {
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate token: %w", err)
+	}
+	token := hex.EncodeToString(buf)
+
+	err := mysql.CreateUserToken(&model.UserToken{
+		UserID:    user.ID,
+		Purpose:   purpose,
+		TokenHash: hashToken(token),
+		Email:     user.Email,
+		ExpiresAt: time.Now().Add(ttl),
+	})
+	if err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// hashToken 计算令牌的 SHA-256 摘要
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(strings.TrimSpace(token)))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,227 @@
+package service
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/Xaytick/chat-zinx/chat-server/conf"
+	"github.com/Xaytick/chat-zinx/chat-server/dao/mysql"
+	"github.com/Xaytick/chat-zinx/chat-server/pkg/model"
+	"github.com/Xaytick/chat-zinx/chat-server/pkg/storage"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	// purgeBatchSize 每次扫描最多清除的账号数
+	purgeBatchSize = 50
+	// purgeLockTTL 清除单个账号时持有锁的最长时间
+	purgeLockTTL = 5 * time.Minute
+)
+
+// RequestAccountDeletion 申请注销账号，重复申请不会推迟已设定的清除时间
+func (s *userService) RequestAccountDeletion(userID uint, req *model.DeleteAccountReq) (*model.AccountDeletionStatus, error) {
+	user, err := s.GetUserByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.Password)); err != nil {
+		return nil, ErrPasswordIncorrect
+	}
+	if user.TOTPEnabled {
+		ok, err := s.verifySecondFactor(user, req.Code)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return nil, ErrInvalidTwoFactorCode
+		}
+	}
+
+	grace := time.Duration(conf.GetAccountConfig().DeletionGracePeriod) * time.Second
+	dueAt, err := mysql.ScheduleUserDeletion(userID, time.Now().Add(grace))
+	if err != nil {
+		return nil, err
+	}
+	return &model.AccountDeletionStatus{Scheduled: true, DeletionDueAt: &dueAt}, nil
+}
+
+// CancelAccountDeletion 撤销注销申请
+func (s *userService) CancelAccountDeletion(userID uint) (*model.AccountDeletionStatus, error) {
+	cancelled, err := mysql.CancelUserDeletion(userID)
+	if err != nil {
+		return nil, err
+	}
+	if !cancelled {
+		return nil, ErrDeletionNotScheduled
+	}
+	return &model.AccountDeletionStatus{Scheduled: false}, nil
+}
+
+// GetAccountDeletionStatus 查询注销申请状态
+func (s *userService) GetAccountDeletionStatus(userID uint) (*model.AccountDeletionStatus, error) {
+	user, err := s.GetUserByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	return &model.AccountDeletionStatus{
+		Scheduled:     user.DeletionDueAt != nil,
+		DeletionDueAt: user.DeletionDueAt,
+	}, nil
+}
+
+// PurgeDueAccounts 清除冷静期已结束的账号，单个账号失败不影响其他账号，下次扫描时重试
+func (s *userService) PurgeDueAccounts() ([]uint, error) {
+	users, err := mysql.GetUsersDueForDeletion(time.Now(), purgeBatchSize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get users due for deletion: %w", err)
+	}
+
+	purged := make([]uint, 0, len(users))
+	for _, user := range users {
+		lockToken, locked, err := storage.TryLockAccountPurge(user.ID, purgeLockTTL)
+		if err != nil || !locked {
+			continue
+		}
+		done, err := s.purgeAccount(user.ID)
+		if err != nil {
+			fmt.Printf("警告: 清除用户 %d 失败: %v\n", user.ID, err)
+		} else if done {
+			purged = append(purged, user.ID)
+		}
+		_ = storage.UnlockAccountPurge(user.ID, lockToken)
+	}
+	return purged, nil
+}
+
+// purgeAccount 退出所有群组，按配置处理群消息，删除私聊记录、关系数据、导出文件和用户记录，最后清理缓存
+// 访客账号到期后也经由这里清除
+func (s *userService) purgeAccount(userID uint) (bool, error) {
+	// 加锁后重新读取，扫描之后撤销了注销的账号返回 false
+	user, err := mysql.GetUserByID(userID)
+	if err != nil {
+		return false, err
+	}
+	if user.DeletionDueAt == nil || user.DeletionDueAt.After(time.Now()) {
+		return false, nil
+	}
+
+	if err := s.leaveAllGroups(userID); err != nil {
+		return false, err
+	}
+	// 用户创建的机器人随之清除，在之后的扫描中处理
+	if err := mysql.ScheduleOwnedBotsDeletion(userID, time.Now()); err != nil {
+		return false, err
+	}
+
+	if conf.GetAccountConfig().MessagePolicy == model.DeletedMessagePolicyDelete {
+		err = mysql.DeleteUserGroupMessages(userID)
+	} else {
+		err = mysql.AnonymizeUserGroupMessages(userID)
+	}
+	if err != nil {
+		return false, err
+	}
+
+	if err := s.msgStorage.PurgeUser(userID); err != nil {
+		return false, fmt.Errorf("failed to purge private messages: %w", err)
+	}
+
+	exports, err := mysql.GetUserDataExports(userID)
+	if err != nil {
+		return false, err
+	}
+	if err := removeDataExports(exports); err != nil {
+		return false, err
+	}
+
+	// 删除屏蔽记录前先找出屏蔽了该用户的人，之后清除他们的屏蔽列表缓存
+	blockerIDs, err := mysql.GetBlockerIDs(userID)
+	if err != nil {
+		return false, err
+	}
+	if err := mysql.DeleteUserAccount(user); err != nil {
+		return false, err
+	}
+
+	for _, id := range append(blockerIDs, userID) {
+		if err := s.blockCache.Invalidate(id); err != nil {
+			fmt.Printf("警告: 清除用户 %d 的屏蔽列表缓存失败: %v\n", id, err)
+		}
+	}
+	if err := s.loginGuard.Reset(user.Username); err != nil {
+		fmt.Printf("警告: 清除登录失败计数失败: %v\n", err)
+	}
+	fmt.Printf("User %d (%s) purged\n", userID, user.Username)
+	return true, nil
+}
+
+// leaveAllGroups 让用户退出所有群组，群主退出前转让给继任者，没有其他成员的群组直接解散
+func (s *userService) leaveAllGroups(userID uint) error {
+	memberships, err := mysql.GetUserGroupMemberships(userID)
+	if err != nil {
+		return fmt.Errorf("failed to get user groups: %w", err)
+	}
+
+	for _, member := range memberships {
+		group, err := mysql.GetGroupByID(member.GroupID)
+		if err != nil {
+			return err
+		}
+		if group != nil && group.OwnerUserID == userID {
+			successor, err := mysql.GetGroupSuccessor(group.ID, userID)
+			if err != nil {
+				return err
+			}
+			if successor == nil {
+				if err := mysql.DeleteGroup(group.ID); err != nil {
+					return err
+				}
+				_ = s.memberCache.Invalidate(group.ID)
+				fmt.Printf("Group %d dissolved, owner %d deleted account\n", group.ID, userID)
+				continue
+			}
+			if err := mysql.TransferGroupOwnership(group.ID, userID, successor.UserID); err != nil {
+				return err
+			}
+			invalidateMemberRoles(s.memberCache, group.ID)
+			fmt.Printf("Group %d ownership transferred from %d to %d\n", group.ID, userID, successor.UserID)
+		}
+
+		if err := mysql.RemoveGroupMember(member.GroupID, userID); err != nil {
+			return fmt.Errorf("failed to leave group %d: %w", member.GroupID, err)
+		}
+		if err := s.memberCache.RemoveMember(member.GroupID, userID); err != nil {
+			_ = s.memberCache.Invalidate(member.GroupID)
+		}
+	}
+	return nil
+}
+
+// PurgeExpiredExports 删除过期的导出文件和记录
+func (s *userService) PurgeExpiredExports() error {
+	exports, err := mysql.GetExpiredDataExports(time.Now())
+	if err != nil {
+		return err
+	}
+	return removeDataExports(exports)
+}
+
+// removeDataExports 删除导出文件及其记录
+func removeDataExports(exports []*model.DataExport) error {
+	if len(exports) == 0 {
+		return nil
+	}
+	dir := conf.GetAccountConfig().ExportDir
+	ids := make([]uint, 0, len(exports))
+	for _, export := range exports {
+		if export.FileName != "" {
+			if err := os.Remove(filepath.Join(dir, export.FileName)); err != nil && !os.IsNotExist(err) {
+				return fmt.Errorf("failed to remove export file: %w", err)
+			}
+		}
+		ids = append(ids, export.ID)
+	}
+	return mysql.DeleteDataExports(ids)
+}
@@ -0,0 +1,276 @@
+package service
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/Xaytick/chat-zinx/chat-server/conf"
+	"github.com/Xaytick/chat-zinx/chat-server/dao/mysql"
+	"github.com/Xaytick/chat-zinx/chat-server/pkg/model"
+)
+
+// RequestDataExport 创建导出记录，同一用户同时只能有一个正在生成的导出
+func (s *userService) RequestDataExport(userID uint) (*model.DataExport, error) {
+	if _, err := s.GetUserByID(userID); err != nil {
+		return nil, err
+	}
+	pending, err := mysql.HasPendingDataExport(userID)
+	if err != nil {
+		return nil, err
+	}
+	if pending {
+		return nil, ErrExportInProgress
+	}
+
+	ttl := time.Duration(conf.GetAccountConfig().ExportTTL) * time.Second
+	export := &model.DataExport{
+		UserID:    userID,
+		Status:    model.DataExportPending,
+		ExpiresAt: time.Now().Add(ttl),
+	}
+	if err := mysql.CreateDataExport(export); err != nil {
+		return nil, err
+	}
+	return export, nil
+}
+
+// BuildDataExport 生成 zip 格式的导出文件，失败时记录原因并删除不完整的文件
+func (s *userService) BuildDataExport(export *model.DataExport) error {
+	dir := conf.GetAccountConfig().ExportDir
+	fileName := fmt.Sprintf("export-%d-%d.zip", export.UserID, export.ID)
+
+	size, err := s.writeDataExport(export.UserID, dir, fileName)
+	if err != nil {
+		_ = os.Remove(filepath.Join(dir, fileName))
+		export.Status = model.DataExportFailed
+		export.Error = truncate(err.Error(), 255)
+	} else {
+		export.Status = model.DataExportReady
+		export.FileName = fileName
+		export.Size = size
+	}
+	if updateErr := mysql.UpdateDataExport(export); updateErr != nil {
+		return updateErr
+	}
+	return err
+}
+
+// writeDataExport 收集用户数据写入 zip 文件，返回文件大小
+func (s *userService) writeDataExport(userID uint, dir, fileName string) (int64, error) {
+	files, err := s.collectExportFiles(userID)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return 0, fmt.Errorf("failed to create export dir: %w", err)
+	}
+	f, err := os.OpenFile(filepath.Join(dir, fileName), os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o600)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create export file: %w", err)
+	}
+	defer f.Close()
+
+	zw := zip.NewWriter(f)
+	for _, file := range files {
+		w, err := zw.Create(file.name)
+		if err != nil {
+			return 0, err
+		}
+		data, err := json.MarshalIndent(file.data, "", "  ")
+		if err != nil {
+			return 0, err
+		}
+		if _, err := w.Write(data); err != nil {
+			return 0, err
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return 0, err
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		return 0, err
+	}
+	return info.Size(), nil
+}
+
+type exportFile struct {
+	name string
+	data interface{}
+}
+
+// collectExportFiles 收集导出文件中的各部分数据
+func (s *userService) collectExportFiles(userID uint) ([]exportFile, error) {
+	profile, err := s.GetProfile(userID, "")
+	if err != nil {
+		return nil, err
+	}
+
+	contacts, err := exportContacts(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	groups, err := exportGroups(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	privateMessages, err := s.exportPrivateMessages(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	groupMessages, err := mysql.GetGroupMessagesBySender(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	loginHistory, err := mysql.GetLoginRecords(userID, model.MaxLoginHistoryLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	return []exportFile{
+		{"profile.json", profile},
+		{"contacts.json", contacts},
+		{"groups.json", groups},
+		{"messages/private.json", privateMessages},
+		{"messages/group.json", groupMessages},
+		{"login_history.json", loginHistory},
+	}, nil
+}
+
+// exportContacts 导出好友列表
+func exportContacts(userID uint) ([]*model.ExportedContact, error) {
+	friends, err := mysql.GetFriends(userID)
+	if err != nil {
+		return nil, err
+	}
+	friendIDs := make([]uint, 0, len(friends))
+	for _, friend := range friends {
+		friendIDs = append(friendIDs, friend.FriendID)
+	}
+	users, err := mysql.GetUsersByIDs(friendIDs)
+	if err != nil {
+		return nil, err
+	}
+	usernames := make(map[uint]string, len(users))
+	for _, user := range users {
+		usernames[user.ID] = user.Username
+	}
+
+	contacts := make([]*model.ExportedContact, 0, len(friends))
+	for _, friend := range friends {
+		contacts = append(contacts, &model.ExportedContact{
+			UserID:    friend.FriendID,
+			Username:  usernames[friend.FriendID],
+			Remark:    friend.Remark,
+			CreatedAt: friend.CreatedAt,
+		})
+	}
+	return contacts, nil
+}
+
+// exportGroups 导出所在群组及自己的角色
+func exportGroups(userID uint) ([]*model.ExportedGroup, error) {
+	memberships, err := mysql.GetUserGroupMemberships(userID)
+	if err != nil {
+		return nil, err
+	}
+	groups := make([]*model.ExportedGroup, 0, len(memberships))
+	for _, member := range memberships {
+		group, err := mysql.GetGroupByID(member.GroupID)
+		if err != nil {
+			return nil, err
+		}
+		if group == nil {
+			continue
+		}
+		groups = append(groups, &model.ExportedGroup{
+			GroupID:  group.ID,
+			Name:     group.Name,
+			Role:     member.Role,
+			JoinedAt: member.JoinedAt,
+		})
+	}
+	return groups, nil
+}
+
+// exportPrivateMessages 导出与所有聊天对象的私聊历史
+func (s *userService) exportPrivateMessages(userID uint) ([]*model.ExportedPrivateMessage, error) {
+	peers, err := s.msgStorage.GetChatRelations(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	messages := make([]*model.ExportedPrivateMessage, 0)
+	for _, peer := range peers {
+		peerID, err := strconv.ParseUint(peer, 10, 64)
+		if err != nil {
+			continue
+		}
+		history, err := s.msgStorage.GetHistoryMessages(userID, uint(peerID), 0)
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range history {
+			from, _ := item["from_user_id"].(string)
+			fromID, _ := strconv.ParseUint(from, 10, 64)
+			msg := &model.ExportedPrivateMessage{FromUserID: uint(fromID), ToUserID: userID}
+			if uint(fromID) == userID {
+				msg.ToUserID = uint(peerID)
+			}
+			msg.Content, _ = item["content"].(string)
+			if ts, ok := item["timestamp"].(float64); ok {
+				msg.Timestamp = int64(ts)
+			}
+			messages = append(messages, msg)
+		}
+	}
+	return messages, nil
+}
+
+// ReadDataExport 从指定偏移读取导出文件的一个分片
+func (s *userService) ReadDataExport(userID uint, req *model.DownloadDataExportReq) (*model.DownloadDataExportResp, error) {
+	export, err := mysql.GetDataExport(userID, req.ExportID)
+	if err != nil {
+		return nil, err
+	}
+	if export == nil || export.ExpiresAt.Before(time.Now()) || export.Status == model.DataExportFailed {
+		return nil, ErrExportNotFound
+	}
+	if export.Status != model.DataExportReady {
+		return nil, ErrExportNotReady
+	}
+	if req.Offset < 0 || req.Offset > export.Size {
+		return nil, fmt.Errorf("invalid offset %d", req.Offset)
+	}
+
+	f, err := os.Open(filepath.Join(conf.GetAccountConfig().ExportDir, export.FileName))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open export file: %w", err)
+	}
+	defer f.Close()
+
+	buf := make([]byte, model.DataExportChunkSize)
+	n, err := f.ReadAt(buf, req.Offset)
+	if err != nil && err != io.EOF {
+		return nil, fmt.Errorf("failed to read export file: %w", err)
+	}
+	return &model.DownloadDataExportResp{
+		ExportID: export.ID,
+		Offset:   req.Offset,
+		Data:     buf[:n],
+		Total:    export.Size,
+		Done:     req.Offset+int64(n) >= export.Size,
+	}, nil
+}
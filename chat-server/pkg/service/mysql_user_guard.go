@@ -0,0 +1,76 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/Xaytick/chat-zinx/chat-server/conf"
+	"github.com/Xaytick/chat-zinx/chat-server/pkg/model"
+)
+
+// checkLoginAllowed 检查账号和IP是否处于锁定或退避状态
+// Redis 不可用时放行，避免缓存故障导致所有用户无法登录
+func (s *userService) checkLoginAllowed(account, ip string) error {
+	wait, locked, err := s.loginGuard.Check(account, ip)
+	if err != nil {
+		fmt.Printf("警告: 检查登录限制失败: %v\n", err)
+		return nil
+	}
+	if wait <= 0 {
+		return nil
+	}
+	if locked {
+		return &ThrottledError{Reason: ErrAccountLocked, RetryAfter: wait}
+	}
+	return &ThrottledError{Reason: ErrLoginBackoff, RetryAfter: wait}
+}
+
+// recordLoginFailure 记录登录失败，计数失败只记录日志
+func (s *userService) recordLoginFailure(account, ip string) {
+	if err := s.loginGuard.RecordFailure(account, ip); err != nil {
+		fmt.Printf("警告: 记录登录失败次数失败: %v\n", err)
+	}
+}
+
+// resetLoginFailures 完整登录成功后清除账号的失败计数，清除失败只记录日志
+func (s *userService) resetLoginFailures(account string) {
+	if err := s.loginGuard.Reset(account); err != nil {
+		fmt.Printf("警告: 清除登录失败计数失败: %v\n", err)
+	}
+}
+
+// checkRegisterAllowed 按IP限制注册频率
+func (s *userService) checkRegisterAllowed(ip string) error {
+	wait, err := s.loginGuard.AllowRegister(ip)
+	if err != nil {
+		fmt.Printf("警告: 检查注册限制失败: %v\n", err)
+		return nil
+	}
+	if wait > 0 {
+		return &ThrottledError{Reason: ErrTooManyRegistrations, RetryAfter: wait}
+	}
+	return nil
+}
+
+// UnlockAccount 管理员解除账号（以及可选的IP）的登录锁定
+func (s *userService) UnlockAccount(operatorID uint, req *model.UnlockAccountReq) (bool, error) {
+	operator, err := s.GetUserByID(operatorID)
+	if err != nil {
+		return false, err
+	}
+	if !conf.IsAdmin(operator.Username) {
+		return false, ErrNotAdmin
+	}
+
+	username := strings.TrimSpace(req.Username)
+	if username == "" {
+		return false, errors.New("username is required")
+	}
+	unlocked, err := s.loginGuard.Unlock(username, strings.TrimSpace(req.IP))
+	if err != nil {
+		return false, err
+	}
+	fmt.Printf("Admin %s unlocked login of %s (ip=%s, was locked: %v)\n", operator.Username, username, req.IP, unlocked)
+	return unlocked, nil
+}
@@ -0,0 +1,245 @@
+package service
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/Xaytick/chat-zinx/chat-server/conf"
+	"github.com/Xaytick/chat-zinx/chat-server/dao/mysql"
+	"github.com/Xaytick/chat-zinx/chat-server/pkg/model"
+)
+
+// 访客和机器人没有真实邮箱，email 列有唯一索引，使用保留域名下的占位地址
+const (
+	guestEmailDomain = "guest.invalid"
+	botEmailDomain   = "bot.invalid"
+)
+
+// GuestLogin 创建访客账号并登录，与注册共用按IP的频率限制
+func (s *userService) GuestLogin(req *model.GuestLoginReq, clientIP string) (string, *model.User, error) {
+	cfg := conf.GetAccountConfig()
+	if !cfg.GuestEnabled {
+		return "", nil, ErrGuestDisabled
+	}
+	if err := s.checkRegisterAllowed(clientIP); err != nil {
+		return "", nil, err
+	}
+
+	suffix, err := randomHex(6)
+	if err != nil {
+		return "", nil, err
+	}
+	nickname := strings.TrimSpace(req.Nickname)
+	if utf8.RuneCountInString(nickname) > model.MaxNicknameLen {
+		return "", nil, fmt.Errorf("nickname too long: at most %d characters", model.MaxNicknameLen)
+	}
+	if nickname == "" {
+		nickname = "访客" + suffix[:4]
+	}
+
+	username := model.GuestUsernamePrefix + suffix
+	dueAt := time.Now().Add(time.Duration(cfg.GuestTTL) * time.Second)
+	user := &model.User{
+		Username:      username,
+		Nickname:      nickname,
+		Email:         username + "@" + guestEmailDomain,
+		DeletionDueAt: &dueAt,
+	}
+	if err := mysql.CreateGuestUser(user); err != nil {
+		return "", nil, err
+	}
+
+	token, err := s.completeLogin(user, clientIP)
+	if err != nil {
+		return "", nil, err
+	}
+	return token, user, nil
+}
+
+// LoginBot 使用 API Key 登录机器人账号
+// API Key 是 192 位随机数，无法被穷举，因此不经过 loginGuard 的失败计数和退避，机器人频繁重连也不会被锁定
+func (s *userService) LoginBot(apiKey, clientIP string) (string, *model.User, error) {
+	apiKey = strings.TrimSpace(apiKey)
+	if !strings.HasPrefix(apiKey, model.BotAPIKeyPrefix) {
+		return "", nil, ErrInvalidAPIKey
+	}
+	key, err := mysql.GetBotAPIKeyByHash(hashToken(apiKey))
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to get bot api key: %w", err)
+	}
+	if key == nil {
+		return "", nil, ErrInvalidAPIKey
+	}
+
+	bot, err := s.GetUserByID(key.BotUserID)
+	if err != nil {
+		if errors.Is(err, ErrUserNotFound) {
+			return "", nil, ErrInvalidAPIKey
+		}
+		return "", nil, err
+	}
+	if bot.AccountKind != model.AccountKindBot {
+		return "", nil, ErrInvalidAPIKey
+	}
+	if err := mysql.TouchBotAPIKey(key.ID); err != nil {
+		fmt.Printf("警告: 更新 API Key 使用时间失败: %v\n", err)
+	}
+
+	token, err := s.completeLogin(bot, clientIP)
+	if err != nil {
+		return "", nil, err
+	}
+	return token, bot, nil
+}
+
+// CreateBot 创建机器人账号，每个用户最多创建 MaxBotsPerUser 个
+func (s *userService) CreateBot(ownerID uint, req *model.CreateBotReq) (*model.BotKeyResp, error) {
+	owner, err := s.GetUserByID(ownerID)
+	if err != nil {
+		return nil, err
+	}
+	if !model.AccountKindHas(owner.AccountKind, model.CapManageBots) {
+		return nil, ErrAccountKindDenied
+	}
+
+	username := strings.TrimSpace(req.Username)
+	if n := utf8.RuneCountInString(username); n < 3 || n > model.MaxBotUsernameLen {
+		return nil, fmt.Errorf("username must be 3-%d characters", model.MaxBotUsernameLen)
+	}
+	if err := checkReservedUsername(username); err != nil {
+		return nil, err
+	}
+	if _, err := mysql.GetUserByUsername(username); err == nil {
+		return nil, ErrUsernameExists
+	} else if !errors.Is(err, mysql.ErrRecordNotFound) {
+		return nil, fmt.Errorf("检查用户名是否存在失败: %w", err)
+	}
+	nickname := strings.TrimSpace(req.Nickname)
+	if utf8.RuneCountInString(nickname) > model.MaxNicknameLen {
+		return nil, fmt.Errorf("nickname too long: at most %d characters", model.MaxNicknameLen)
+	}
+
+	count, err := mysql.CountBotsByOwner(ownerID)
+	if err != nil {
+		return nil, err
+	}
+	if maxBots := conf.GetAccountConfig().MaxBotsPerUser; maxBots > 0 && count >= int64(maxBots) {
+		return nil, fmt.Errorf("%w: at most %d bots", ErrTooManyBots, maxBots)
+	}
+
+	apiKey, key, err := newBotAPIKey()
+	if err != nil {
+		return nil, err
+	}
+	bot := &model.User{
+		Username:   username,
+		Nickname:   nickname,
+		Email:      fmt.Sprintf("%s@%s", strings.ToLower(username), botEmailDomain),
+		BotOwnerID: ownerID,
+	}
+	if err := mysql.CreateBotUser(bot, key); err != nil {
+		return nil, err
+	}
+	return &model.BotKeyResp{Bot: botInfo(bot, key), APIKey: apiKey}, nil
+}
+
+// RotateBotKey 重新生成 API Key，只有机器人的创建者可以操作
+func (s *userService) RotateBotKey(ownerID, botUserID uint) (*model.BotKeyResp, error) {
+	bot, err := s.GetUserByID(botUserID)
+	if err != nil {
+		if errors.Is(err, ErrUserNotFound) {
+			return nil, ErrBotNotFound
+		}
+		return nil, err
+	}
+	// 不是自己的机器人时同样返回不存在
+	if bot.AccountKind != model.AccountKindBot || bot.BotOwnerID != ownerID {
+		return nil, ErrBotNotFound
+	}
+
+	apiKey, key, err := newBotAPIKey()
+	if err != nil {
+		return nil, err
+	}
+	if err := mysql.RotateBotAPIKey(bot.ID, key); err != nil {
+		return nil, err
+	}
+	return &model.BotKeyResp{Bot: botInfo(bot, key), APIKey: apiKey}, nil
+}
+
+// GetMyBots 获取自己创建的机器人及其当前 Key 的前缀
+func (s *userService) GetMyBots(ownerID uint) ([]*model.BotInfo, error) {
+	bots, err := mysql.GetBotsByOwner(ownerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get bots: %w", err)
+	}
+	ids := make([]uint, 0, len(bots))
+	for _, bot := range bots {
+		ids = append(ids, bot.ID)
+	}
+	keys, err := mysql.GetActiveBotAPIKeys(ids)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get bot api keys: %w", err)
+	}
+	keyByBot := make(map[uint]*model.BotAPIKey, len(keys))
+	for _, key := range keys {
+		keyByBot[key.BotUserID] = key
+	}
+
+	infos := make([]*model.BotInfo, 0, len(bots))
+	for _, bot := range bots {
+		infos = append(infos, botInfo(bot, keyByBot[bot.ID]))
+	}
+	return infos, nil
+}
+
+// checkReservedUsername 访客用户名前缀保留给系统生成的访客账号
+func checkReservedUsername(username string) error {
+	if strings.HasPrefix(strings.ToLower(username), model.GuestUsernamePrefix) {
+		return fmt.Errorf("username prefix %q is reserved", model.GuestUsernamePrefix)
+	}
+	return nil
+}
+
+// newBotAPIKey 生成 API Key，返回明文和只含摘要的记录
+func newBotAPIKey() (string, *model.BotAPIKey, error) {
+	raw, err := randomHex(24)
+	if err != nil {
+		return "", nil, err
+	}
+	apiKey := model.BotAPIKeyPrefix + raw
+	return apiKey, &model.BotAPIKey{
+		KeyHash: hashToken(apiKey),
+		Hint:    raw[:model.BotAPIKeyHintLen],
+	}, nil
+}
+
+// randomHex 生成 n 字节随机数的十六进制表示
+func randomHex(n int) (string, error) {
+	buf := make([]byte, n)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate random bytes: %w", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// botInfo 组装机器人信息，key 为空表示没有有效的 API Key
+func botInfo(bot *model.User, key *model.BotAPIKey) *model.BotInfo {
+	info := &model.BotInfo{
+		ID:        bot.ID,
+		UserUUID:  bot.UserUUID,
+		Username:  bot.Username,
+		Nickname:  bot.Nickname,
+		CreatedAt: bot.CreatedAt,
+	}
+	if key != nil {
+		info.KeyHint = model.BotAPIKeyPrefix + key.Hint
+		info.LastUsed = key.LastUsedAt
+	}
+	return info
+}
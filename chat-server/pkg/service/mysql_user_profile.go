@@ -0,0 +1,166 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/Xaytick/chat-zinx/chat-server/dao/mysql"
+	"github.com/Xaytick/chat-zinx/chat-server/pkg/model"
+)
+
+// UpdateProfile 更新自己的资料，只修改请求中不为 nil 的字段
+func (s *userService) UpdateProfile(userID uint, req *model.UpdateProfileReq) (*model.UserProfile, error) {
+	// 1. 校验并收集要修改的字段
+	updates := make(map[string]interface{})
+	if req.Nickname != nil {
+		nickname := strings.TrimSpace(*req.Nickname)
+		if utf8.RuneCountInString(nickname) > model.MaxNicknameLen {
+			return nil, fmt.Errorf("nickname too long: at most %d characters", model.MaxNicknameLen)
+		}
+		updates["nickname"] = nickname
+	}
+	if req.Avatar != nil {
+		avatar := strings.TrimSpace(*req.Avatar)
+		if utf8.RuneCountInString(avatar) > model.MaxAvatarURLLen {
+			return nil, fmt.Errorf("avatar url too long: at most %d characters", model.MaxAvatarURLLen)
+		}
+		updates["avatar"] = avatar
+	}
+	if req.Gender != nil {
+		gender := strings.ToLower(strings.TrimSpace(*req.Gender))
+		if !model.IsValidGender(gender) {
+			return nil, fmt.Errorf("invalid gender: must be one of %s, %s, %s or empty",
+				model.GenderMale, model.GenderFemale, model.GenderOther)
+		}
+		updates["gender"] = gender
+	}
+	if req.Bio != nil {
+		bio := strings.TrimSpace(*req.Bio)
+		if utf8.RuneCountInString(bio) > model.MaxBioLen {
+			return nil, fmt.Errorf("bio too long: at most %d characters", model.MaxBioLen)
+		}
+		updates["bio"] = bio
+	}
+	if req.Searchable != nil {
+		updates["searchable"] = *req.Searchable
+	}
+	if len(updates) == 0 {
+		return nil, errors.New("no profile fields to update")
+	}
+
+	// 2. 保存并返回最新资料
+	if err := mysql.UpdateUserProfile(userID, updates); err != nil {
+		return nil, err
+	}
+	user, err := s.GetUserByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	return toOwnProfile(user), nil
+}
+
+// GetProfile 获取用户资料，屏蔽了查看者的用户视为不存在
+func (s *userService) GetProfile(viewerID uint, target string) (*model.UserProfile, error) {
+	target = strings.TrimSpace(target)
+	if target == "" {
+		user, err := s.GetUserByID(viewerID)
+		if err != nil {
+			return nil, err
+		}
+		return toOwnProfile(user), nil
+	}
+
+	user, err := s.ResolveUser(target)
+	if err != nil {
+		return nil, err
+	}
+	if user.ID == viewerID {
+		return toOwnProfile(user), nil
+	}
+	blocked, err := isUserBlocked(s.blockCache, user.ID, viewerID)
+	if err != nil {
+		return nil, err
+	}
+	if blocked {
+		return nil, ErrUserNotFound
+	}
+
+	// 在线状态仅好友可见
+	friend, err := mysql.GetFriend(viewerID, user.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check friendship: %w", err)
+	}
+	return toPublicProfile(user, friend != nil), nil
+}
+
+// SearchUsers 按用户名或昵称分页搜索用户
+func (s *userService) SearchUsers(viewerID uint, req *model.SearchUsersReq) (*model.SearchUsersResp, error) {
+	// 1. 校验并规范化查询条件
+	keyword := strings.TrimSpace(req.Keyword)
+	if keyword == "" {
+		return nil, errors.New("keyword is required")
+	}
+	page := req.Page
+	if page <= 0 {
+		page = 1
+	}
+	pageSize := req.PageSize
+	if pageSize <= 0 {
+		pageSize = model.DefaultUserSearchPageSize
+	} else if pageSize > model.MaxUserSearchPageSize {
+		pageSize = model.MaxUserSearchPageSize
+	}
+
+	// 2. 查询用户和搜索者的好友，好友的在线状态可见
+	offset := (page - 1) * pageSize
+	users, total, err := mysql.SearchUsers(viewerID, keyword, offset, pageSize)
+	if err != nil {
+		return nil, err
+	}
+	friendIDs, err := mysql.GetFriendIDs(viewerID)
+	if err != nil {
+		return nil, err
+	}
+	friendSet := make(map[uint]bool, len(friendIDs))
+	for _, id := range friendIDs {
+		friendSet[id] = true
+	}
+
+	// 3. 构建结果
+	profiles := make([]*model.UserProfile, 0, len(users))
+	for _, user := range users {
+		profiles = append(profiles, toPublicProfile(user, friendSet[user.ID]))
+	}
+	return &model.SearchUsersResp{
+		Users:    profiles,
+		Total:    int(total),
+		Page:     page,
+		PageSize: pageSize,
+		HasMore:  int64(offset+len(users)) < total,
+	}, nil
+}
+
+// toPublicProfile 转换为他人可见的资料，showOnline 为 false 时隐藏在线状态
+func toPublicProfile(user *model.User, showOnline bool) *model.UserProfile {
+	return &model.UserProfile{
+		ID:       user.ID,
+		UserUUID: user.UserUUID,
+		Username: user.Username,
+		Nickname: user.Nickname,
+		Avatar:   user.Avatar,
+		Gender:   user.Gender,
+		Bio:      user.Bio,
+		IsOnline: showOnline && user.IsOnline,
+	}
+}
+
+// toOwnProfile 转换为本人查看的资料，包含隐私设置
+func toOwnProfile(user *model.User) *model.UserProfile {
+	profile := toPublicProfile(user, true)
+	profile.DMPrivacy = dmPrivacyOf(user)
+	searchable := user.Searchable
+	profile.Searchable = &searchable
+	return profile
+}
@@ -0,0 +1,222 @@
+package service
+
+import (
+	"crypto/rand"
+	"encoding/base32"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Xaytick/chat-zinx/chat-server/conf"
+	"github.com/Xaytick/chat-zinx/chat-server/dao/mysql"
+	"github.com/Xaytick/chat-zinx/chat-server/pkg/model"
+	"github.com/Xaytick/chat-zinx/chat-server/pkg/storage"
+	"github.com/Xaytick/chat-zinx/chat-server/pkg/totp"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// totpSkew 校验验证码时允许的时钟误差（时间步）
+const totpSkew = 1
+
+var backupCodeEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// EnrollTOTP 生成新的两步验证密钥，未确认前重复调用会替换密钥
+func (s *userService) EnrollTOTP(userID uint) (*model.EnrollTOTPResp, error) {
+	user, err := s.GetUserByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.TOTPEnabled {
+		return nil, ErrTOTPAlreadyEnabled
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, err
+	}
+	if err := mysql.SetUserTOTPSecret(userID, secret); err != nil {
+		if errors.Is(err, mysql.ErrTOTPAlreadyEnabled) {
+			return nil, ErrTOTPAlreadyEnabled
+		}
+		return nil, err
+	}
+	return &model.EnrollTOTPResp{
+		Secret:          secret,
+		ProvisioningURI: totp.ProvisioningURI(totpIssuer(), user.Username, secret),
+	}, nil
+}
+
+// ConfirmTOTP 校验认证器生成的验证码，通过后开启两步验证并生成备用恢复码
+func (s *userService) ConfirmTOTP(userID uint, code string) (*model.ConfirmTOTPResp, error) {
+	user, err := s.GetUserByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.TOTPEnabled {
+		return nil, ErrTOTPAlreadyEnabled
+	}
+	if user.TOTPSecret == "" {
+		return nil, ErrTOTPNotEnrolled
+	}
+
+	step, ok := totp.Validate(user.TOTPSecret, code, time.Now(), totpSkew)
+	if !ok {
+		return nil, ErrInvalidTwoFactorCode
+	}
+
+	codes, hashes, err := generateBackupCodes(model.BackupCodeCount)
+	if err != nil {
+		return nil, err
+	}
+	if err := mysql.EnableUserTOTP(userID, step, hashes); err != nil {
+		return nil, err
+	}
+	return &model.ConfirmTOTPResp{BackupCodes: codes}, nil
+}
+
+// DisableTOTP 校验密码和第二因素后关闭两步验证
+func (s *userService) DisableTOTP(userID uint, req *model.DisableTOTPReq) error {
+	user, err := s.GetUserByID(userID)
+	if err != nil {
+		return err
+	}
+	if !user.TOTPEnabled {
+		return ErrTOTPNotEnabled
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.Password)); err != nil {
+		return ErrPasswordIncorrect
+	}
+	ok, err := s.verifySecondFactor(user, req.Code)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrInvalidTwoFactorCode
+	}
+	return mysql.DisableUserTOTP(userID)
+}
+
+// VerifyTwoFactorLogin 登录第二步，失败次数与密码错误一起计入登录防护
+func (s *userService) VerifyTwoFactorLogin(userID uint, code, clientIP string) (string, *model.User, error) {
+	return s.verifyTwoFactorLogin(userID, code, clientIP, true)
+}
+
+// verifyTwoFactorLogin 校验登录第二步，markOnline 为 true 时把用户标记为在线
+func (s *userService) verifyTwoFactorLogin(userID uint, code, clientIP string, markOnline bool) (string, *model.User, error) {
+	user, err := s.GetUserByID(userID)
+	if err != nil {
+		return "", nil, err
+	}
+	if err := s.checkLoginAllowed(user.Username, clientIP); err != nil {
+		return "", nil, err
+	}
+	if !user.TOTPEnabled {
+		return "", nil, ErrTOTPNotEnabled
+	}
+
+	ok, err := s.verifySecondFactor(user, code)
+	if err != nil {
+		return "", nil, err
+	}
+	if !ok {
+		s.recordLoginFailure(user.Username, clientIP)
+		return "", nil, ErrInvalidTwoFactorCode
+	}
+	s.resetLoginFailures(user.Username)
+
+	tokenString, err := s.finishLogin(user, clientIP, markOnline)
+	if err != nil {
+		return "", nil, err
+	}
+	return tokenString, user, nil
+}
+
+// CreateTwoFactorChallenge 保存密码已验证的状态，供 HTTP 登录第二步使用
+func (s *userService) CreateTwoFactorChallenge(userID uint) (string, error) {
+	challenge, err := storage.CreateTwoFactorChallenge(userID, model.TwoFactorChallengeTTL)
+	if err != nil {
+		return "", fmt.Errorf("failed to create two-factor challenge: %w", err)
+	}
+	return challenge, nil
+}
+
+// VerifyTwoFactorChallenge 先在挑战令牌上计入一次尝试再校验验证码，并发提交同样受次数限制
+func (s *userService) VerifyTwoFactorChallenge(challenge, code, clientIP string) (uint, string, *model.User, error) {
+	userID, remaining, ok, err := storage.UseTwoFactorChallenge(challenge, model.TwoFactorMaxAttempts)
+	if err != nil {
+		return 0, "", nil, fmt.Errorf("failed to use two-factor challenge: %w", err)
+	}
+	if !ok {
+		return 0, "", nil, ErrTwoFactorChallenge
+	}
+
+	// 挑战令牌只用于 HTTP 登录，不更新在线状态
+	tokenString, user, err := s.verifyTwoFactorLogin(userID, code, clientIP, false)
+	if err != nil {
+		if errors.Is(err, ErrInvalidTwoFactorCode) && remaining == 0 {
+			return userID, "", nil, ErrTooManyTwoFactorTries
+		}
+		return userID, "", nil, err
+	}
+	if err := storage.DeleteTwoFactorChallenge(challenge); err != nil {
+		fmt.Printf("Warning: failed to delete two-factor challenge: %v\n", err)
+	}
+	return userID, tokenString, user, nil
+}
+
+// verifySecondFactor 校验6位验证码或备用恢复码，验证码和恢复码都只能使用一次
+func (s *userService) verifySecondFactor(user *model.User, code string) (bool, error) {
+	code = strings.TrimSpace(code)
+	if len(code) == totp.Digits {
+		step, ok := totp.Validate(user.TOTPSecret, code, time.Now(), totpSkew)
+		if !ok {
+			return false, nil
+		}
+		return mysql.AdvanceUserTOTPStep(user.ID, step)
+	}
+
+	normalized := normalizeBackupCode(code)
+	if normalized == "" {
+		return false, nil
+	}
+	used, err := mysql.ConsumeBackupCode(user.ID, hashToken(normalized))
+	if err != nil || !used {
+		return false, err
+	}
+	if remaining, err := mysql.CountUnusedBackupCodes(user.ID); err == nil {
+		fmt.Printf("User %d used a backup code, %d remaining\n", user.ID, remaining)
+	}
+	return true, nil
+}
+
+// generateBackupCodes 生成备用恢复码，返回展示给用户的恢复码及其摘要
+func generateBackupCodes(n int) ([]string, []string, error) {
+	codes := make([]string, 0, n)
+	hashes := make([]string, 0, n)
+	buf := make([]byte, 5)
+	for i := 0; i < n; i++ {
+		if _, err := rand.Read(buf); err != nil {
+			return nil, nil, fmt.Errorf("failed to generate backup code: %w", err)
+		}
+		raw := strings.ToLower(backupCodeEncoding.EncodeToString(buf))
+		codes = append(codes, raw[:4]+"-"+raw[4:])
+		hashes = append(hashes, hashToken(raw))
+	}
+	return codes, hashes, nil
+}
+
+// normalizeBackupCode 忽略大小写、空格和连字符
+func normalizeBackupCode(code string) string {
+	code = strings.ToLower(code)
+	code = strings.ReplaceAll(code, "-", "")
+	return strings.ReplaceAll(code, " ", "")
+}
+
+// totpIssuer 认证器应用中显示的服务名称
+func totpIssuer() string {
+	if authConfig := conf.GetAuthConfig(); authConfig != nil && authConfig.JWT.Issuer != "" {
+		return authConfig.JWT.Issuer
+	}
+	return "chat-zinx"
+}
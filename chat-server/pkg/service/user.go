@@ -2,6 +2,8 @@ package service
 
 import (
 	"errors"
+	"fmt"
+	"time"
 
 	"github.com/Xaytick/chat-zinx/chat-server/pkg/model"
 )
@@ -12,24 +14,151 @@ var (
 	ErrPasswordIncorrect  = errors.New("password incorrect")
 	ErrUsernameExists     = errors.New("username already exists")
 	ErrInvalidCredentials = errors.New("invalid username or password")
+	ErrInvalidToken       = errors.New("invalid or expired token")
+
+	ErrAccountLocked        = errors.New("too many failed attempts, temporarily locked")
+	ErrLoginBackoff         = errors.New("login attempted too frequently")
+	ErrTooManyRegistrations = errors.New("too many registrations from this address")
+	ErrTooManyResetRequests = errors.New("too many password reset requests from this address")
+	ErrNotAdmin             = errors.New("admin permission required")
+
+	ErrTwoFactorRequired     = errors.New("two-factor authentication required")
+	ErrInvalidTwoFactorCode  = errors.New("invalid two-factor code")
+	ErrTwoFactorChallenge    = errors.New("two-factor challenge is invalid or expired, log in again")
+	ErrTooManyTwoFactorTries = errors.New("too many invalid two-factor codes, log in again")
+	ErrTOTPNotEnrolled       = errors.New("two-factor authentication not enrolled")
+	ErrTOTPAlreadyEnabled    = errors.New("two-factor authentication already enabled")
+	ErrTOTPNotEnabled        = errors.New("two-factor authentication not enabled")
+
+	ErrDeletionNotScheduled = errors.New("account deletion not scheduled")
+	ErrExportInProgress     = errors.New("a data export is already in progress")
+	ErrExportNotFound       = errors.New("data export not found or expired")
+	ErrExportNotReady       = errors.New("data export not ready")
+
+	ErrGuestDisabled     = errors.New("guest login is disabled")
+	ErrInvalidAPIKey     = errors.New("invalid api key")
+	ErrTooManyBots       = errors.New("bot limit reached")
+	ErrBotNotFound       = errors.New("bot not found")
+	ErrAccountKindDenied = errors.New("not available for this account type")
+	ErrGuestGroupDenied  = errors.New("guests cannot join this group")
 )
 
+// ThrottledError 登录或注册被限流，Reason 为 ErrAccountLocked、ErrLoginBackoff 或 ErrTooManyRegistrations
+type ThrottledError struct {
+	Reason     error
+	RetryAfter time.Duration
+}
+
+func (e *ThrottledError) Error() string {
+	return fmt.Sprintf("%s, retry after %ds", e.Reason.Error(), int(e.RetryAfter.Seconds()+0.5))
+}
+
+func (e *ThrottledError) Unwrap() error {
+	return e.Reason
+}
+
 // IUserService 定义用户服务接口
 type IUserService interface {
 	// Register 用户注册，成功返回完整的User模型（包含ID, UserUUID等）
 	Register(req *model.UserRegisterReq) (*model.User, error)
 	// Login 用户登录，成功返回JWT Token和User模型
+	// 账号不存在与密码错误统一返回 ErrInvalidCredentials，被限流时返回 *ThrottledError
+	// 开启了两步验证的用户密码正确时返回 ErrTwoFactorRequired 和用户信息，需再调用 VerifyTwoFactorLogin
 	Login(req *model.UserLoginReq) (token string, user *model.User, err error)
+	// LoginForToken 与 Login 相同，但只签发 Token 不把用户标记为在线，供不建立长连接的 HTTP 登录使用
+	LoginForToken(req *model.UserLoginReq) (token string, user *model.User, err error)
+	// VerifyToken 校验 Login 签发的 JWT Token，返回 Token 所属的用户
+	// 签名错误、已过期、用户不存在或修改密码后 Token 版本不一致时返回 ErrInvalidToken
+	VerifyToken(tokenString string) (*model.User, error)
+	// TokenLogin 凭 Login 签发的 JWT 在新连接上重新登录，返回原 Token 不续期；Token 无效时返回 ErrInvalidToken
+	TokenLogin(tokenString, clientIP string) (*model.User, error)
+	// VerifySessionUser 校验登录时 TokenVersion 为 tokenVersion 的会话是否仍然有效，规则与 VerifyToken 相同，无效时返回 ErrInvalidToken
+	VerifySessionUser(userID, tokenVersion uint) (*model.User, error)
 	// GetUserByID 根据主键ID (uint) 获取用户信息
 	GetUserByID(userID uint) (*model.User, error)
 	// GetUserByUUID 根据UUID获取用户信息
 	GetUserByUUID(uuid string) (*model.User, error)
 	// GetUserByUsername 根据用户名获取用户信息
 	GetUserByUsername(username string) (*model.User, error)
+	// ResolveUser 依次按 UUID、用户名、数字ID 查找用户
+	ResolveUser(identifier string) (*model.User, error)
 	// UpdateUserOnlineStatus 更新用户在线状态
 	UpdateUserOnlineStatus(userID uint, isOnline bool) error
-	// UpdateUserLastLoginInfo 更新用户最后登录信息
-	UpdateUserLastLoginInfo(userID uint) error
+	// MarkUsersOffline 批量把用户标记为离线，loggedInAt 为用户在本节点登录的时间，之后在其他节点登录过的用户不受影响
+	MarkUsersOffline(loggedInAt map[uint]time.Time) error
+	// UpdateUserLastLoginInfo 更新用户最后登录时间和IP
+	UpdateUserLastLoginInfo(userID uint, ip string) error
+
+	// UpdateProfile 更新自己的资料，返回更新后的资料
+	UpdateProfile(userID uint, req *model.UpdateProfileReq) (*model.UserProfile, error)
+	// GetProfile 获取用户资料，target 为空时获取自己的资料
+	GetProfile(viewerID uint, target string) (*model.UserProfile, error)
+	// SearchUsers 按用户名或昵称分页搜索用户，不返回关闭了搜索或屏蔽了搜索者的用户
+	SearchUsers(viewerID uint, req *model.SearchUsersReq) (*model.SearchUsersResp, error)
+
+	// ChangePassword 校验旧密码后修改密码，之前签发的 Token 全部失效
+	ChangePassword(userID uint, oldPassword, newPassword string) error
+	// RequestPasswordReset 异步向账号绑定的邮箱发送密码重置令牌，账号不存在时同样返回成功，按账号和IP限制频率
+	RequestPasswordReset(account, ip string) error
+	// ResetPassword 使用一次性令牌重置密码，返回被重置的用户ID
+	ResetPassword(token, newPassword string) (uint, error)
+	// SendEmailVerification 重新发送邮箱验证邮件
+	SendEmailVerification(userID uint) error
+	// VerifyEmail 使用一次性令牌验证邮箱，返回邮箱所属的用户ID
+	VerifyEmail(token string) (uint, error)
+
+	// UnlockAccount 管理员解除账号（以及可选的IP）的登录锁定，返回此前是否处于锁定状态
+	UnlockAccount(operatorID uint, req *model.UnlockAccountReq) (bool, error)
+
+	// EnrollTOTP 生成两步验证密钥，需调用 ConfirmTOTP 确认后才生效
+	EnrollTOTP(userID uint) (*model.EnrollTOTPResp, error)
+	// ConfirmTOTP 校验验证码后开启两步验证，返回备用恢复码
+	ConfirmTOTP(userID uint, code string) (*model.ConfirmTOTPResp, error)
+	// DisableTOTP 校验密码和验证码（或备用恢复码）后关闭两步验证
+	DisableTOTP(userID uint, req *model.DisableTOTPReq) error
+	// VerifyTwoFactorLogin 登录第二步，校验验证码或备用恢复码，成功返回JWT Token和User模型
+	VerifyTwoFactorLogin(userID uint, code, clientIP string) (token string, user *model.User, err error)
+	// CreateTwoFactorChallenge 为无状态的 HTTP 登录保存密码已验证的状态，返回挑战令牌
+	CreateTwoFactorChallenge(userID uint) (string, error)
+	// VerifyTwoFactorChallenge 用挑战令牌完成登录第二步，每个令牌最多提交 model.TwoFactorMaxAttempts 次验证码，
+	// 令牌无效或已过期时返回 ErrTwoFactorChallenge，次数用完时返回 ErrTooManyTwoFactorTries；
+	// 令牌有效时无论验证码是否正确都返回其所属的 userID，用于记录登录历史；与 LoginForToken 一样不更新在线状态
+	VerifyTwoFactorChallenge(challenge, code, clientIP string) (userID uint, token string, user *model.User, err error)
+
+	// RecordLoginAttempt 记录一次登录尝试，userID 为 0 时按用户名查找所属用户，用户名不存在时不记录
+	// 成功登录来自新的IP或设备时返回需要推送给用户的提醒
+	RecordLoginAttempt(userID uint, username string, client *model.LoginClientInfo, success bool, failReason string) (*model.SuspiciousLoginPush, error)
+	// GetLoginHistory 获取自己最近的登录记录
+	GetLoginHistory(userID uint, limit int) ([]*model.LoginRecord, error)
+
+	// RequestAccountDeletion 校验密码（开启两步验证时还需验证码）后申请注销，冷静期结束后账号被清除
+	RequestAccountDeletion(userID uint, req *model.DeleteAccountReq) (*model.AccountDeletionStatus, error)
+	// CancelAccountDeletion 在冷静期内撤销注销申请，未申请时返回 ErrDeletionNotScheduled
+	CancelAccountDeletion(userID uint) (*model.AccountDeletionStatus, error)
+	// GetAccountDeletionStatus 查询注销申请状态
+	GetAccountDeletionStatus(userID uint) (*model.AccountDeletionStatus, error)
+	// PurgeDueAccounts 清除冷静期已结束的账号，返回被清除的用户ID
+	PurgeDueAccounts() ([]uint, error)
+
+	// RequestDataExport 创建个人数据导出记录，需再调用 BuildDataExport 生成导出文件
+	RequestDataExport(userID uint) (*model.DataExport, error)
+	// BuildDataExport 打包用户的资料、好友、群组、消息和登录历史，完成后更新导出记录的状态
+	BuildDataExport(export *model.DataExport) error
+	// ReadDataExport 分片读取已生成的导出文件
+	ReadDataExport(userID uint, req *model.DownloadDataExportReq) (*model.DownloadDataExportResp, error)
+	// PurgeExpiredExports 删除过期的导出文件和记录
+	PurgeExpiredExports() error
+
+	// GuestLogin 创建临时访客账号并登录，账号在 GuestTTL 后按注销流程清除
+	GuestLogin(req *model.GuestLoginReq, clientIP string) (token string, user *model.User, err error)
+	// LoginBot 使用 API Key 登录机器人账号，Key 无效时返回 ErrInvalidAPIKey
+	LoginBot(apiKey, clientIP string) (token string, user *model.User, err error)
+	// CreateBot 创建归属于 ownerID 的机器人账号，返回只展示一次的 API Key
+	CreateBot(ownerID uint, req *model.CreateBotReq) (*model.BotKeyResp, error)
+	// RotateBotKey 重新生成机器人的 API Key，旧 Key 立即失效
+	RotateBotKey(ownerID, botUserID uint) (*model.BotKeyResp, error)
+	// GetMyBots 获取自己创建的机器人
+	GetMyBots(ownerID uint) ([]*model.BotInfo, error)
 }
 
 /*
@@ -0,0 +1,423 @@
+package session
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Xaytick/chat-zinx/chat-server/pkg/framing"
+	"github.com/Xaytick/chat-zinx/chat-server/pkg/protocol"
+	"github.com/Xaytick/zinx/ziface"
+)
+
+var (
+	// ErrSessionNotFound 恢复令牌无效，或会话已过期、已被新的登录替换
+	ErrSessionNotFound = errors.New("session not found or expired")
+	// ErrSeqGap 客户端错过的推送已不在缓存中，只能重新登录并通过历史接口补齐
+	ErrSeqGap = errors.New("missed pushes are no longer buffered")
+)
+
+// Resumed 恢复会话的结果
+type Resumed struct {
+	UserID   uint
+	Token    string             // 新的恢复令牌，旧令牌随即失效
+	Seq      uint64             // 补发完成后的推送序号
+	Replayed int                // 补发的推送条数
+	Previous ziface.IConnection // 会话恢复前仍挂着的旧连接（如半开的 TCP 连接），由调用方关闭
+}
+
+// session 一个用户在当前节点上的会话，连接断开后在恢复窗口内保留
+type session struct {
+	mu           sync.Mutex // 保护以下字段，推送在持锁时编号并入队以保证编号与发送顺序一致
+	userID       uint
+	tokenVersion uint      // 登录时账号的 TokenVersion，恢复时与当前值比较，修改密码后会话不能再恢复
+	openedAt     time.Time // 登录时间，节点关闭时据此判断用户之后是否已在其他节点登录
+	token        string
+	conn         ziface.IConnection // 断线期间为 nil
+	out          *outbox            // conn 的发送队列，断线期间为 nil
+	seq          uint64             // 最近一条推送的序号，从 1 开始
+	recent       *ring
+	timer        *time.Timer      // 断线后的过期计时器
+	unsent       []*framing.Frame // 断线时发送队列中未写出的推送，恢复时由 recent 补发，会话作废时转存离线
+}
+
+// Manager 会话管理器
+// 登录成功时为连接创建会话并签发恢复令牌，推送经过会话时按顺序编号并缓存最近的若干条。
+// 连接断开后会话保留一段时间，客户端在新连接上提交恢复令牌和最后收到的序号，即可接回会话并补发错过的推送，
+// 期间用户不会被标记为离线。会话只保存在当前节点，重连到其他节点时需要重新登录。
+// 推送放入连接的发送队列后即返回，队列积压时按 Backpressure 丢弃推送或断开连接。
+type Manager struct {
+	connMgr    ziface.IConnManager
+	window     time.Duration
+	bufferSize int
+	bp         Backpressure
+	onExpire   func(userID uint, connID uint32)
+
+	highWaterHits atomic.Uint64
+	dropped       atomic.Uint64
+	disconnected  atomic.Uint64
+
+	mu      sync.Mutex // 保护以下索引
+	byUser  map[uint]*session
+	byToken map[string]*session
+	byConn  map[uint32]*session
+}
+
+// NewManager 创建会话管理器，onExpire 在断线的会话超过 window 仍未恢复时调用，connID 为最后使用的连接
+func NewManager(connMgr ziface.IConnManager, window time.Duration, bufferSize int, bp Backpressure, onExpire func(userID uint, connID uint32)) *Manager {
+	if bufferSize <= 0 {
+		bufferSize = 1
+	}
+	if bp.QueueSize <= 0 {
+		bp.QueueSize = 1
+	}
+	if bp.HighWater <= 0 || bp.HighWater > bp.QueueSize {
+		bp.HighWater = bp.QueueSize
+	}
+	return &Manager{
+		connMgr:    connMgr,
+		window:     window,
+		bufferSize: bufferSize,
+		bp:         bp,
+		onExpire:   onExpire,
+		byUser:     make(map[uint]*session),
+		byToken:    make(map[string]*session),
+		byConn:     make(map[uint32]*session),
+	}
+}
+
+// Open 登录成功后为连接创建会话，返回恢复令牌；用户在当前节点上已有的会话随之作废
+// tokenVersion 为登录时账号的 TokenVersion，由 Lookup 返回供恢复前校验
+func (m *Manager) Open(conn ziface.IConnection, userID, tokenVersion uint) string {
+	s := &session{
+		userID:       userID,
+		tokenVersion: tokenVersion,
+		openedAt:     time.Now(),
+		token:        newToken(),
+		conn:         conn,
+		recent:       newRing(m.bufferSize),
+	}
+	s.out = m.newOutbox(s, conn)
+
+	var unsent []userFrames
+	m.mu.Lock()
+	if old := m.byUser[userID]; old != nil {
+		unsent = append(unsent, userFrames{old.userID, m.dropLocked(old)})
+	}
+	if old := m.byConn[conn.GetConnID()]; old != nil {
+		unsent = append(unsent, userFrames{old.userID, m.dropLocked(old)})
+	}
+	m.byUser[userID] = s
+	m.byToken[s.token] = s
+	m.byConn[conn.GetConnID()] = s
+	m.mu.Unlock()
+
+	m.saveUnsent(unsent)
+	return s.token
+}
+
+// SendMsg 向指定连接发送消息，连接绑定了会话时经过发送队列，消息为推送时参与编号和缓存
+// 因积压被丢弃的推送交给 Backpressure.OnDropped 转存离线
+func (m *Manager) SendMsg(conn ziface.IConnection, msgID uint32, data []byte) error {
+	m.mu.Lock()
+	s := m.byConn[conn.GetConnID()]
+	m.mu.Unlock()
+	if s == nil {
+		return framing.SendMsg(conn, msgID, data)
+	}
+
+	f := framing.NewFrame(msgID, data)
+	s.mu.Lock()
+	if s.conn != conn {
+		s.mu.Unlock()
+		return f.SendTo(conn)
+	}
+	err := m.sendLocked(s, f)
+	s.mu.Unlock()
+	if errors.Is(err, ErrSlowConsumer) {
+		m.saveDropped(s.userID, f)
+	}
+	return err
+}
+
+// Deliver 向用户在线的连接发送消息，用户不在线、处于断线等待恢复状态或连接积压过多时返回 false，由调用方保存离线消息
+func (m *Manager) Deliver(userID uint, msgID uint32, data []byte) bool {
+	return m.deliver(userID, framing.NewFrame(msgID, data), false)
+}
+
+// PushToUser 向用户推送消息，用户处于断线等待恢复状态时缓存推送，恢复后补发；连接积压过多时推送转存离线
+func (m *Manager) PushToUser(userID uint, msgID uint32, data []byte) bool {
+	return m.deliver(userID, framing.NewFrame(msgID, data), true)
+}
+
+// PushFrame 与 PushToUser 相同，同一条推送发给多个用户时共用 f，消息体只压缩一次
+func (m *Manager) PushFrame(userID uint, f *framing.Frame) bool {
+	return m.deliver(userID, f, true)
+}
+
+func (m *Manager) deliver(userID uint, f *framing.Frame, bufferDetached bool) bool {
+	m.mu.Lock()
+	s := m.byUser[userID]
+	m.mu.Unlock()
+	if s == nil {
+		// 没有会话的连接（理论上不会出现）按原方式直接发送
+		conn := m.connMgr.GetConnByUserID(userID)
+		return conn != nil && f.SendTo(conn) == nil
+	}
+
+	s.mu.Lock()
+	if s.conn == nil && !(bufferDetached && protocol.IsPushMsgID(f.MsgID)) {
+		s.mu.Unlock()
+		return false
+	}
+	err := m.sendLocked(s, f)
+	s.mu.Unlock()
+	if err != nil {
+		fmt.Printf("[Session] Failed to push MsgID %d to UserID %d: %v\n", f.MsgID, userID, err)
+		if bufferDetached && errors.Is(err, ErrSlowConsumer) {
+			m.saveDropped(userID, f)
+		}
+		return false
+	}
+	return true
+}
+
+// Detach 连接断开时调用，连接绑定了会话时保留会话等待恢复并返回 true，此时不应把用户标记为离线
+func (m *Manager) Detach(conn ziface.IConnection) bool {
+	connID := conn.GetConnID()
+	m.mu.Lock()
+	s := m.byConn[connID]
+	delete(m.byConn, connID)
+	m.mu.Unlock()
+	if s == nil {
+		return false
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.conn != conn {
+		return false
+	}
+	s.conn = nil
+	s.unsent = append(s.unsent, s.closeOutbox()...)
+	s.timer = time.AfterFunc(m.window, func() { m.expire(s, connID) })
+	fmt.Printf("[Session] UserID %d disconnected, keeping session for %v\n", s.userID, m.window)
+	return true
+}
+
+// Lookup 查找恢复令牌对应的用户及登录时的 TokenVersion，供恢复前校验账号状态
+func (m *Manager) Lookup(token string) (userID, tokenVersion uint, ok bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s := m.byToken[token]
+	if s == nil {
+		return 0, 0, false
+	}
+	return s.userID, s.tokenVersion, true
+}
+
+// Resume 把 token 对应的会话接到新连接上，补发序号大于 lastSeq 的推送
+// onResumed 在补发之前调用，用于设置连接属性和发送恢复响应；调用期间会话被锁定，只能直接使用 conn.SendMsg
+// 补发在持锁时直接写出，之后的推送才进入新连接的发送队列
+func (m *Manager) Resume(conn ziface.IConnection, token string, lastSeq uint64, onResumed func(r *Resumed)) (*Resumed, error) {
+	m.mu.Lock()
+	s := m.byToken[token]
+	if s == nil {
+		m.mu.Unlock()
+		return nil, ErrSessionNotFound
+	}
+	s.mu.Lock()
+	missed, ok := s.recent.since(lastSeq, s.seq)
+	if !ok {
+		s.mu.Unlock()
+		m.mu.Unlock()
+		return nil, ErrSeqGap
+	}
+
+	r := &Resumed{UserID: s.userID, Token: newToken(), Seq: s.seq, Replayed: len(missed), Previous: s.conn}
+	delete(m.byToken, s.token)
+	s.token = r.Token
+	m.byToken[s.token] = s
+	if s.conn != nil {
+		delete(m.byConn, s.conn.GetConnID())
+	}
+	var unsent []userFrames
+	if old := m.byConn[conn.GetConnID()]; old != nil && old != s {
+		unsent = append(unsent, userFrames{old.userID, m.dropLocked(old)})
+	}
+	m.byConn[conn.GetConnID()] = s
+	s.conn = conn
+	// 旧连接和断线时未写出的推送序号都大于 lastSeq，已包含在 missed 中
+	s.closeOutbox()
+	s.unsent = nil
+	s.out = m.newOutbox(s, conn)
+	if s.timer != nil {
+		s.timer.Stop()
+		s.timer = nil
+	}
+	m.mu.Unlock()
+	defer m.saveUnsent(unsent) // 在释放 s.mu 之后执行
+	defer s.mu.Unlock()
+
+	onResumed(r)
+	for _, f := range missed {
+		if err := f.SendTo(conn); err != nil {
+			return r, fmt.Errorf("failed to replay push %d: %w", f.seq, err)
+		}
+	}
+	return r, nil
+}
+
+// Revoke 作废用户的会话，keep 为会话当前绑定的连接时保留，用于修改密码后保留当前连接
+func (m *Manager) Revoke(userID uint, keep ziface.IConnection) {
+	m.mu.Lock()
+	s := m.byUser[userID]
+	if s == nil {
+		m.mu.Unlock()
+		return
+	}
+	s.mu.Lock()
+	kept := keep != nil && s.conn == keep
+	s.mu.Unlock()
+	var unsent []*framing.Frame
+	if !kept {
+		unsent = m.dropLocked(s)
+	}
+	m.mu.Unlock()
+	m.saveUnsent([]userFrames{{userID, unsent}})
+}
+
+// Close 节点关闭时作废所有会话，返回会话所属的用户（包括断线等待恢复的）及其登录时间，由调用方标记离线
+// 发送队列中尚未写出的推送交给 Backpressure.OnDropped 转存离线，不会触发 onExpire
+func (m *Manager) Close() map[uint]time.Time {
+	var unsent []userFrames
+	m.mu.Lock()
+	users := make(map[uint]time.Time, len(m.byUser))
+	for userID, s := range m.byUser {
+		users[userID] = s.openedAt
+		unsent = append(unsent, userFrames{userID, m.dropLocked(s)})
+	}
+	m.mu.Unlock()
+
+	if n := m.saveUnsent(unsent); n > 0 {
+		fmt.Printf("[Session] Saved %d unsent pushes for offline delivery\n", n)
+	}
+	return users
+}
+
+// expire 断线的会话到期，仍未恢复时删除并通知调用方
+func (m *Manager) expire(s *session, connID uint32) {
+	m.mu.Lock()
+	if m.byUser[s.userID] != s {
+		m.mu.Unlock()
+		return
+	}
+	s.mu.Lock()
+	resumed := s.conn != nil
+	s.mu.Unlock()
+	if resumed {
+		m.mu.Unlock()
+		return
+	}
+	unsent := m.dropLocked(s)
+	m.mu.Unlock()
+	m.saveUnsent([]userFrames{{s.userID, unsent}})
+
+	fmt.Printf("[Session] Session of UserID %d expired\n", s.userID)
+	if m.onExpire != nil {
+		m.onExpire(s.userID, connID)
+	}
+}
+
+// dropLocked 从索引中删除会话并停止计时器，调用方需持有 m.mu
+// 返回会话中尚未写出的推送，由调用方在释放锁后经 saveUnsent 转存离线
+func (m *Manager) dropLocked(s *session) []*framing.Frame {
+	if m.byUser[s.userID] == s {
+		delete(m.byUser, s.userID)
+	}
+	delete(m.byToken, s.token)
+	s.mu.Lock()
+	if s.conn != nil && m.byConn[s.conn.GetConnID()] == s {
+		delete(m.byConn, s.conn.GetConnID())
+	}
+	if s.timer != nil {
+		s.timer.Stop()
+		s.timer = nil
+	}
+	unsent := append(s.unsent, s.closeOutbox()...)
+	s.unsent = nil
+	s.mu.Unlock()
+	return unsent
+}
+
+// sendLocked 编号、缓存并放入发送队列，调用方需持有 s.mu；会话处于断线状态时只缓存
+// 积压过多没有入队时返回 ErrSlowConsumer，推送不占用序号
+func (m *Manager) sendLocked(s *session, f *framing.Frame) error {
+	if s.conn != nil {
+		if s.out == nil {
+			// 会话已被作废但连接尚未关闭
+			return f.SendTo(s.conn)
+		}
+		if err := m.enqueue(s, f); err != nil {
+			return err
+		}
+	}
+	if protocol.IsPushMsgID(f.MsgID) {
+		s.seq++
+		s.recent.add(frame{seq: s.seq, Frame: f})
+	}
+	return nil
+}
+
+// saveDropped 推送因积压被丢弃，交给调用方转存离线
+func (m *Manager) saveDropped(userID uint, f *framing.Frame) {
+	if m.bp.OnDropped != nil && protocol.IsPushMsgID(f.MsgID) {
+		m.bp.OnDropped(userID, f.MsgID, f.Data)
+	}
+}
+
+// userFrames 一个用户尚未写出的推送
+type userFrames struct {
+	userID uint
+	frames []*framing.Frame
+}
+
+// saveUnsent 把会话作废时尚未写出的推送交给 Backpressure.OnDropped 转存离线，返回转存的条数，不能在持锁时调用
+func (m *Manager) saveUnsent(unsent []userFrames) int {
+	n := 0
+	for _, u := range unsent {
+		for _, f := range u.frames {
+			m.saveDropped(u.userID, f)
+		}
+		n += len(u.frames)
+	}
+	return n
+}
+
+// closeOutbox 停止当前连接的发送队列，返回其中尚未写出的推送，调用方需持有 s.mu
+func (s *session) closeOutbox() []*framing.Frame {
+	if s.out == nil {
+		return nil
+	}
+	s.out.close()
+	var unsent []*framing.Frame
+	for _, f := range s.out.remaining() {
+		if protocol.IsPushMsgID(f.MsgID) {
+			unsent = append(unsent, f)
+		}
+	}
+	s.out = nil
+	return unsent
+}
+
+func newToken() string {
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		panic(fmt.Sprintf("session: failed to generate resume token: %v", err))
+	}
+	return base64.RawURLEncoding.EncodeToString(buf)
+}
@@ -0,0 +1,198 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/Xaytick/chat-zinx/chat-server/pkg/framing"
+	"github.com/Xaytick/zinx/ziface"
+)
+
+// 慢消费者的处理方式
+const (
+	PolicyDrop       = "drop"       // 丢弃新推送，直到积压回落到告警线以下
+	PolicyDisconnect = "disconnect" // 断开连接，会话保留等待恢复
+)
+
+// ErrSlowConsumer 连接的发送队列积压过多，消息没有入队
+var ErrSlowConsumer = errors.New("slow consumer: outbound queue is backed up")
+
+// Backpressure 连接发送队列的配置
+type Backpressure struct {
+	QueueSize   int           // 每个连接的队列长度，队列满时新消息总是被丢弃
+	HighWater   int           // 积压告警线
+	SlowTimeout time.Duration // 积压持续超过告警线多久视为慢消费者
+	Policy      string        // PolicyDrop 或 PolicyDisconnect
+	// OnDropped 推送因积压被丢弃时调用，用于转存离线；Deliver 丢弃的消息由其调用方保存，不经过这里
+	OnDropped func(userID uint, msgID uint32, data []byte)
+	// OnRecovered 丢弃过推送的连接积压回落到告警线以下时调用，用于补发已转存的离线推送，在独立的协程中执行
+	OnRecovered func(userID uint)
+}
+
+// QueueStats 发送队列的指标，计数类字段从进程启动开始累计
+type QueueStats struct {
+	QueueSize     int    `json:"queue_size"`
+	HighWater     int    `json:"high_water"`
+	Policy        string `json:"policy"`
+	Connections   int    `json:"connections"`     // 有发送队列的连接数
+	Queued        int    `json:"queued"`          // 所有队列中待发送的消息数
+	MaxDepth      int    `json:"max_depth"`       // 积压最多的队列的长度
+	SlowConsumers int    `json:"slow_consumers"`  // 当前积压超过告警线的连接数
+	HighWaterHits uint64 `json:"high_water_hits"` // 队列越过告警线的次数
+	Dropped       uint64 `json:"dropped"`         // 因积压丢弃的消息数
+	Disconnected  uint64 `json:"disconnected"`    // 因持续积压被断开的连接数
+}
+
+// outbox 一个连接的发送队列，由独立的协程写出，连接写不动时阻塞的是这个协程而不是业务 worker
+type outbox struct {
+	conn      ziface.IConnection
+	frames    chan *framing.Frame
+	done      chan struct{}
+	highWater int
+	slowSince atomic.Int64 // 积压越过告警线的时间（UnixNano），未越过时为 0
+	dropped   atomic.Bool  // 本次积压期间丢弃过推送
+	stopping  bool         // 已因慢消费决定断开，由会话的 s.mu 保护
+	recovered func()       // 丢弃过推送后积压回落时调用
+}
+
+func newOutbox(conn ziface.IConnection, size, highWater int, recovered func()) *outbox {
+	o := &outbox{
+		conn:      conn,
+		frames:    make(chan *framing.Frame, size),
+		done:      make(chan struct{}),
+		highWater: highWater,
+		recovered: recovered,
+	}
+	go o.run()
+	return o
+}
+
+// newOutbox 为会话的连接创建发送队列，调用方需持有 s.mu
+func (m *Manager) newOutbox(s *session, conn ziface.IConnection) *outbox {
+	userID := s.userID
+	return newOutbox(conn, m.bp.QueueSize, m.bp.HighWater, func() {
+		if m.bp.OnRecovered != nil {
+			m.bp.OnRecovered(userID)
+		}
+	})
+}
+
+func (o *outbox) run() {
+	for {
+		select {
+		case <-o.done:
+			return
+		case f := <-o.frames:
+			if err := f.SendTo(o.conn); err != nil {
+				fmt.Printf("[Session] Failed to send MsgID %d on ConnID %d: %v\n", f.MsgID, o.conn.GetConnID(), err)
+			}
+			if len(o.frames) < o.highWater && o.slowSince.Swap(0) != 0 && o.dropped.Swap(false) {
+				go o.recovered()
+			}
+		}
+	}
+}
+
+// close 停止写出，之后由 remaining 取出剩余的消息
+func (o *outbox) close() {
+	close(o.done)
+}
+
+// remaining 取出队列中尚未写出的消息，需在 close 之后调用
+func (o *outbox) remaining() []*framing.Frame {
+	var frames []*framing.Frame
+	for {
+		select {
+		case f := <-o.frames:
+			frames = append(frames, f)
+		default:
+			return frames
+		}
+	}
+}
+
+// depth 队列中待发送的消息数
+func (o *outbox) depth() int {
+	return len(o.frames)
+}
+
+// enqueue 按背压策略入队，调用方需持有会话的 s.mu
+// 积压未超过告警线时直接入队；超过后记录开始时间，持续超过 SlowTimeout 或队列已满时拒绝入队，
+// PolicyDisconnect 下同时断开连接
+func (m *Manager) enqueue(s *session, f *framing.Frame) error {
+	o := s.out
+	if depth := o.depth(); depth >= m.bp.HighWater {
+		now := time.Now().UnixNano()
+		if o.slowSince.CompareAndSwap(0, now) {
+			m.highWaterHits.Add(1)
+			fmt.Printf("[Session] UserID %d on ConnID %d is falling behind, %d frames queued\n",
+				s.userID, o.conn.GetConnID(), depth)
+		}
+		if depth >= cap(o.frames) || time.Duration(now-o.slowSince.Load()) >= m.bp.SlowTimeout {
+			m.dropped.Add(1)
+			o.dropped.Store(true)
+			if m.bp.Policy == PolicyDisconnect && !o.stopping {
+				o.stopping = true
+				m.disconnected.Add(1)
+				fmt.Printf("[Session] Disconnecting slow consumer UserID %d on ConnID %d, %d frames queued\n",
+					s.userID, o.conn.GetConnID(), depth)
+				// Stop 会触发 OnConnStop 回到 Detach，不能在持有 s.mu 时同步调用
+				go o.conn.Stop()
+			}
+			return ErrSlowConsumer
+		}
+	}
+	select {
+	case o.frames <- f:
+		return nil
+	default:
+		m.dropped.Add(1)
+		o.dropped.Store(true)
+		return ErrSlowConsumer
+	}
+}
+
+// Drain 等待所有连接的发送队列写空，ctx 先到期时返回 ctx.Err()
+func (m *Manager) Drain(ctx context.Context) error {
+	ticker := time.NewTicker(50 * time.Millisecond)
+	defer ticker.Stop()
+	for m.Stats().Queued > 0 {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+	return nil
+}
+
+// Stats 返回发送队列的指标
+func (m *Manager) Stats() QueueStats {
+	stats := QueueStats{
+		QueueSize:     m.bp.QueueSize,
+		HighWater:     m.bp.HighWater,
+		Policy:        m.bp.Policy,
+		HighWaterHits: m.highWaterHits.Load(),
+		Dropped:       m.dropped.Load(),
+		Disconnected:  m.disconnected.Load(),
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, s := range m.byUser {
+		s.mu.Lock()
+		if s.out != nil {
+			depth := s.out.depth()
+			stats.Connections++
+			stats.Queued += depth
+			stats.MaxDepth = max(stats.MaxDepth, depth)
+			if s.out.slowSince.Load() != 0 {
+				stats.SlowConsumers++
+			}
+		}
+		s.mu.Unlock()
+	}
+	return stats
+}
@@ -0,0 +1,219 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Xaytick/chat-zinx/chat-server/pkg/protocol"
+)
+
+// blockingConn 写入在 release 之前阻塞的连接，用于模拟写不动的慢消费者
+type blockingConn struct {
+	id      uint32
+	entered chan struct{} // 每次进入 SendMsg 时发送一次
+	gate    chan struct{}
+	stopped chan struct{}
+
+	mu       sync.Mutex
+	sent     []string
+	released bool
+	stopOnce sync.Once
+}
+
+func newBlockingConn(id uint32) *blockingConn {
+	return &blockingConn{
+		id:      id,
+		entered: make(chan struct{}, 16),
+		gate:    make(chan struct{}),
+		stopped: make(chan struct{}),
+	}
+}
+
+func (c *blockingConn) release() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.released {
+		c.released = true
+		close(c.gate)
+	}
+}
+
+func (c *blockingConn) sentData() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]string(nil), c.sent...)
+}
+
+func (c *blockingConn) SendMsg(msgID uint32, data []byte) error {
+	c.entered <- struct{}{}
+	<-c.gate
+	c.mu.Lock()
+	c.sent = append(c.sent, string(data))
+	c.mu.Unlock()
+	return nil
+}
+
+func (c *blockingConn) SendBuffMsg(msgID uint32, data []byte) error { return c.SendMsg(msgID, data) }
+func (c *blockingConn) Stop()                                       { c.stopOnce.Do(func() { close(c.stopped) }) }
+func (c *blockingConn) Start()                                      {}
+func (c *blockingConn) Context() context.Context                    { return context.Background() }
+func (c *blockingConn) GetTCPConnection() *net.TCPConn              { return nil }
+func (c *blockingConn) GetConnID() uint32                           { return c.id }
+func (c *blockingConn) RemoteAddr() net.Addr                        { return nil }
+func (c *blockingConn) SetProperty(key string, value interface{})   {}
+func (c *blockingConn) RemoveProperty(key string)                   {}
+func (c *blockingConn) GetProperty(key string) (interface{}, error) {
+	return nil, errors.New("property not found")
+}
+
+// droppedRecorder 记录交给 Backpressure.OnDropped 的推送
+type droppedRecorder struct {
+	mu   sync.Mutex
+	data []string
+}
+
+func (r *droppedRecorder) record(userID uint, msgID uint32, data []byte) {
+	r.mu.Lock()
+	r.data = append(r.data, string(data))
+	r.mu.Unlock()
+}
+
+func (r *droppedRecorder) sorted() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	out := append([]string(nil), r.data...)
+	sort.Strings(out)
+	return out
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// stallConn 为 conn 打开会话并推送第一条消息，等待发送协程阻塞在这条消息上
+func stallConn(t *testing.T, m *Manager, conn *blockingConn, userID uint) {
+	t.Helper()
+	m.Open(conn, userID, 0)
+	if !m.PushToUser(userID, protocol.MsgIDTextMsg, []byte("1")) {
+		t.Fatal("first push rejected")
+	}
+	select {
+	case <-conn.entered:
+	case <-time.After(time.Second):
+		t.Fatal("outbox did not start writing")
+	}
+}
+
+func TestOutboxDropPolicyReportsUnsentFrames(t *testing.T) {
+	dropped := &droppedRecorder{}
+	m := NewManager(nil, time.Minute, 16, Backpressure{
+		QueueSize: 2,
+		HighWater: 1,
+		Policy:    PolicyDrop,
+		OnDropped: dropped.record,
+	}, nil)
+	conn := newBlockingConn(1)
+	defer conn.release()
+	stallConn(t, m, conn, 1)
+
+	// "2" 入队等待发送，之后积压达到告警线，SlowTimeout 为 0 时立即丢弃
+	if !m.PushToUser(1, protocol.MsgIDTextMsg, []byte("2")) {
+		t.Fatal("push 2 rejected below high water")
+	}
+	for _, data := range []string{"3", "4"} {
+		if m.PushToUser(1, protocol.MsgIDTextMsg, []byte(data)) {
+			t.Fatalf("push %s accepted above high water", data)
+		}
+	}
+	if got := dropped.sorted(); !equalStrings(got, []string{"3", "4"}) {
+		t.Fatalf("dropped = %v, want [3 4]", got)
+	}
+	select {
+	case <-conn.stopped:
+		t.Fatal("drop policy stopped the connection")
+	default:
+	}
+
+	// 节点关闭时队列中尚未写出的 "2" 也转存离线，正在写出的 "1" 不转存
+	m.Close()
+	if got := dropped.sorted(); !equalStrings(got, []string{"2", "3", "4"}) {
+		t.Fatalf("dropped after close = %v, want [2 3 4]", got)
+	}
+	conn.release()
+	time.Sleep(50 * time.Millisecond)
+	if got := conn.sentData(); !equalStrings(got, []string{"1"}) {
+		t.Fatalf("sent = %v, want [1]", got)
+	}
+}
+
+func TestOutboxDisconnectPolicyStopsConnection(t *testing.T) {
+	dropped := &droppedRecorder{}
+	m := NewManager(nil, time.Minute, 16, Backpressure{
+		QueueSize: 2,
+		HighWater: 1,
+		Policy:    PolicyDisconnect,
+		OnDropped: dropped.record,
+	}, nil)
+	conn := newBlockingConn(1)
+	defer conn.release()
+	stallConn(t, m, conn, 1)
+
+	if !m.PushToUser(1, protocol.MsgIDTextMsg, []byte("2")) {
+		t.Fatal("push 2 rejected below high water")
+	}
+	if m.PushToUser(1, protocol.MsgIDTextMsg, []byte("3")) {
+		t.Fatal("push 3 accepted above high water")
+	}
+	select {
+	case <-conn.stopped:
+	case <-time.After(time.Second):
+		t.Fatal("slow consumer was not disconnected")
+	}
+	if got := m.Stats().Disconnected; got != 1 {
+		t.Fatalf("disconnected = %d, want 1", got)
+	}
+	if got := dropped.sorted(); !equalStrings(got, []string{"3"}) {
+		t.Fatalf("dropped = %v, want [3]", got)
+	}
+}
+
+func TestDrainReturnsOnTimeout(t *testing.T) {
+	m := NewManager(nil, time.Minute, 16, Backpressure{QueueSize: 4, HighWater: 4, Policy: PolicyDrop}, nil)
+	conn := newBlockingConn(1)
+	defer conn.release()
+	stallConn(t, m, conn, 1)
+	if !m.PushToUser(1, protocol.MsgIDTextMsg, []byte("2")) {
+		t.Fatal("push 2 rejected")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if err := m.Drain(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Drain = %v, want DeadlineExceeded", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("Drain returned after %v", elapsed)
+	}
+
+	// 连接恢复写入后队列写空，Drain 正常返回
+	conn.release()
+	ctx2, cancel2 := context.WithTimeout(context.Background(), time.Second)
+	defer cancel2()
+	if err := m.Drain(ctx2); err != nil {
+		t.Fatalf("Drain after release = %v", err)
+	}
+}
@@ -0,0 +1,46 @@
+package session
+
+import "github.com/Xaytick/chat-zinx/chat-server/pkg/framing"
+
+// frame 缓存的一条推送，与发送队列共用 framing.Frame，补发时复用已有的压缩结果
+type frame struct {
+	seq uint64
+	*framing.Frame
+}
+
+// ring 固定容量的推送缓存，写满后覆盖最早的一条
+type ring struct {
+	frames []frame
+	start  int
+	count  int
+}
+
+func newRing(size int) *ring {
+	return &ring{frames: make([]frame, size)}
+}
+
+func (r *ring) add(f frame) {
+	if r.count < len(r.frames) {
+		r.frames[(r.start+r.count)%len(r.frames)] = f
+		r.count++
+		return
+	}
+	r.frames[r.start] = f
+	r.start = (r.start + 1) % len(r.frames)
+}
+
+// since 返回序号大于 lastSeq 的推送，current 为最新的序号；错过的推送已被覆盖或 lastSeq 超前时返回 false
+func (r *ring) since(lastSeq, current uint64) ([]frame, bool) {
+	if lastSeq > current {
+		return nil, false
+	}
+	missed := current - lastSeq
+	if missed > uint64(r.count) {
+		return nil, false
+	}
+	out := make([]frame, 0, missed)
+	for i := r.count - int(missed); i < r.count; i++ {
+		out = append(out, r.frames[(r.start+i)%len(r.frames)])
+	}
+	return out, true
+}
@@ -0,0 +1,88 @@
+package session
+
+import (
+	"testing"
+
+	"github.com/Xaytick/chat-zinx/chat-server/pkg/framing"
+	"github.com/Xaytick/chat-zinx/chat-server/pkg/protocol"
+)
+
+// fillRing 依次写入序号 1..n 的推送
+func fillRing(r *ring, n uint64) {
+	for seq := uint64(1); seq <= n; seq++ {
+		r.add(frame{seq: seq, Frame: framing.NewFrame(protocol.MsgIDTextMsg, nil)})
+	}
+}
+
+func seqs(frames []frame) []uint64 {
+	out := make([]uint64, 0, len(frames))
+	for _, f := range frames {
+		out = append(out, f.seq)
+	}
+	return out
+}
+
+func equalSeqs(a, b []uint64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestRingWraparound(t *testing.T) {
+	r := newRing(3)
+	fillRing(r, 5)
+
+	cases := []struct {
+		lastSeq uint64
+		want    []uint64
+	}{
+		{5, []uint64{}},
+		{4, []uint64{5}},
+		{2, []uint64{3, 4, 5}},
+	}
+	for _, c := range cases {
+		got, ok := r.since(c.lastSeq, 5)
+		if !ok {
+			t.Fatalf("since(%d): not ok", c.lastSeq)
+		}
+		if !equalSeqs(seqs(got), c.want) {
+			t.Fatalf("since(%d) = %v, want %v", c.lastSeq, seqs(got), c.want)
+		}
+	}
+}
+
+func TestRingReplayAcrossGap(t *testing.T) {
+	r := newRing(3)
+	fillRing(r, 5)
+
+	// 序号 2 已被覆盖，不能补齐
+	if got, ok := r.since(1, 5); ok {
+		t.Fatalf("since(1) = %v, want gap", seqs(got))
+	}
+	if got, ok := r.since(0, 5); ok {
+		t.Fatalf("since(0) = %v, want gap", seqs(got))
+	}
+	// 客户端上报的序号超前
+	if got, ok := r.since(6, 5); ok {
+		t.Fatalf("since(6) = %v, want rejection", seqs(got))
+	}
+}
+
+func TestRingNotFull(t *testing.T) {
+	r := newRing(4)
+	fillRing(r, 2)
+
+	got, ok := r.since(0, 2)
+	if !ok || !equalSeqs(seqs(got), []uint64{1, 2}) {
+		t.Fatalf("since(0) = %v, %v, want [1 2]", seqs(got), ok)
+	}
+	if _, ok := r.since(0, 3); ok {
+		t.Fatal("since(0) with 3 missed pushes and 2 buffered: want gap")
+	}
+}
@@ -0,0 +1,43 @@
+package storage
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"strconv"
+	"time"
+
+	"github.com/Xaytick/chat-zinx/chat-server/dao/redis"
+	goredis "github.com/go-redis/redis/v8"
+)
+
+// 账号清除锁的键前缀
+const accountPurgeLockPrefix = "account:purge:lock:"
+
+// 只有锁的值仍是自己的令牌时才删除，避免锁过期后释放了其他节点重新获取的锁
+var releaseLockScript = goredis.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('DEL', KEYS[1])
+end
+return 0`)
+
+// TryLockAccountPurge 获取账号清除锁，多个节点同时扫描到期账号时同一用户只由一个节点处理
+// 获取成功时返回持有者令牌，释放锁时需要传回
+func TryLockAccountPurge(userID uint, ttl time.Duration) (token string, locked bool, err error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", false, err
+	}
+	token = hex.EncodeToString(buf)
+	key := accountPurgeLockPrefix + strconv.FormatUint(uint64(userID), 10)
+	locked, err = redis.GetUniversalClient().SetNX(redis.Ctx, key, token, ttl).Result()
+	if err != nil || !locked {
+		return "", false, err
+	}
+	return token, true, nil
+}
+
+// UnlockAccountPurge 释放账号清除锁，锁已过期并被其他节点获取时不做任何事
+func UnlockAccountPurge(userID uint, token string) error {
+	key := accountPurgeLockPrefix + strconv.FormatUint(uint64(userID), 10)
+	return releaseLockScript.Run(redis.Ctx, redis.GetUniversalClient(), []string{key}, token).Err()
+}
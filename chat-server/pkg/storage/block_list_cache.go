@@ -0,0 +1,76 @@
+package storage
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/Xaytick/chat-zinx/chat-server/dao/redis"
+)
+
+const (
+	// 用户屏蔽列表集合的键前缀，用户ID放在 hash tag 中，集合与其版本号键位于同一个槽
+	blockListPrefix = "user:blocked:"
+	// 集合中的占位成员，用于区分"没有屏蔽任何人"和"缓存不存在"
+	blockListPlaceholder = "0"
+	// 屏蔽列表缓存过期时间
+	blockListExpiration = 24 * time.Hour
+)
+
+// BlockListCache 基于 Redis Set 的用户屏蔽列表缓存
+type BlockListCache struct {
+	expiration time.Duration
+}
+
+// NewBlockListCache 创建用户屏蔽列表缓存
+func NewBlockListCache() *BlockListCache {
+	return &BlockListCache{expiration: blockListExpiration}
+}
+
+// generateBlockListKey 生成屏蔽列表集合的键
+func generateBlockListKey(userID uint) string {
+	return blockListPrefix + "{" + strconv.FormatUint(uint64(userID), 10) + "}"
+}
+
+// IsBlocked 通过缓存判断 userID 是否屏蔽了 targetID，缓存未命中时 hit 为 false
+func (c *BlockListCache) IsBlocked(userID, targetID uint) (blocked bool, hit bool, err error) {
+	key := generateBlockListKey(userID)
+	pipe := redis.GetUniversalClient().Pipeline()
+	existsCmd := pipe.Exists(redis.Ctx, key)
+	blockedCmd := pipe.SIsMember(redis.Ctx, key, strconv.FormatUint(uint64(targetID), 10))
+	if _, err := pipe.Exec(redis.Ctx); err != nil {
+		return false, false, fmt.Errorf("failed to check cached block list: %w", err)
+	}
+	if existsCmd.Val() == 0 {
+		return false, false, nil
+	}
+	return blockedCmd.Val(), true, nil
+}
+
+// Generation 返回屏蔽列表缓存当前的版本号，重建缓存前需要在读取数据库之前获取
+func (c *BlockListCache) Generation(userID uint) (int64, error) {
+	gen, err := setCacheGeneration(generateBlockListKey(userID))
+	if err != nil {
+		return 0, fmt.Errorf("failed to get block list cache generation: %w", err)
+	}
+	return gen, nil
+}
+
+// SetBlockedIDs 用完整的屏蔽列表重建缓存，列表为空时只写入占位成员；
+// gen 为读取数据库之前获取的版本号，期间屏蔽列表有变更时放弃写入
+func (c *BlockListCache) SetBlockedIDs(userID uint, gen int64, blockedIDs []uint) error {
+	members := make([]string, 0, len(blockedIDs)+1)
+	members = append(members, blockListPlaceholder)
+	for _, id := range blockedIDs {
+		members = append(members, strconv.FormatUint(uint64(id), 10))
+	}
+	if _, err := rebuildSetCache(generateBlockListKey(userID), gen, members, c.expiration); err != nil {
+		return fmt.Errorf("failed to cache block list: %w", err)
+	}
+	return nil
+}
+
+// Invalidate 删除用户的屏蔽列表缓存
+func (c *BlockListCache) Invalidate(userID uint) error {
+	return invalidateSetCache(generateBlockListKey(userID))
+}
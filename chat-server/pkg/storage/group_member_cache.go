@@ -0,0 +1,168 @@
+package storage
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/Xaytick/chat-zinx/chat-server/conf"
+	"github.com/Xaytick/chat-zinx/chat-server/dao/redis"
+	"github.com/Xaytick/chat-zinx/chat-server/pkg/model"
+	goredis "github.com/go-redis/redis/v8"
+)
+
+const (
+	// 群成员ID集合的键前缀，群组ID放在 hash tag 中，集合与其版本号键位于同一个槽
+	groupMembersPrefix = "group:members:"
+	// 群成员角色哈希的键后缀，字段为用户ID，值为 model.GroupMemberAccess 的 JSON
+	groupMemberRolesSuffix = ":roles"
+)
+
+// GroupMemberCache 基于 Redis Set 的群成员ID缓存
+type GroupMemberCache struct {
+	expiration time.Duration // 缓存过期时间
+}
+
+// NewGroupMemberCache 创建群成员ID缓存
+func NewGroupMemberCache() *GroupMemberCache {
+	return &GroupMemberCache{
+		expiration: time.Duration(conf.GetGroupConfig().MemberCacheTTL) * time.Second,
+	}
+}
+
+// generateGroupMembersKey 生成群成员集合的键
+func generateGroupMembersKey(groupID uint) string {
+	return groupMembersPrefix + "{" + strconv.FormatUint(uint64(groupID), 10) + "}"
+}
+
+// generateGroupMemberRolesKey 生成群成员角色哈希的键
+func generateGroupMemberRolesKey(groupID uint) string {
+	return generateGroupMembersKey(groupID) + groupMemberRolesSuffix
+}
+
+// GetMemberIDs 获取缓存的群成员ID，缓存未命中时返回 nil, false, nil
+func (c *GroupMemberCache) GetMemberIDs(groupID uint) ([]uint, bool, error) {
+	members, err := redis.GetUniversalClient().SMembers(redis.Ctx, generateGroupMembersKey(groupID)).Result()
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to get cached group members: %w", err)
+	}
+	// 群组至少包含群主，空集合说明缓存不存在
+	if len(members) == 0 {
+		return nil, false, nil
+	}
+
+	memberIDs := make([]uint, 0, len(members))
+	for _, member := range members {
+		id, err := strconv.ParseUint(member, 10, 64)
+		if err != nil {
+			continue
+		}
+		memberIDs = append(memberIDs, uint(id))
+	}
+	return memberIDs, true, nil
+}
+
+// IsMember 通过缓存判断用户是否为群成员，缓存未命中时 hit 为 false
+func (c *GroupMemberCache) IsMember(groupID, userID uint) (isMember bool, hit bool, err error) {
+	key := generateGroupMembersKey(groupID)
+	pipe := redis.GetUniversalClient().Pipeline()
+	existsCmd := pipe.Exists(redis.Ctx, key)
+	isMemberCmd := pipe.SIsMember(redis.Ctx, key, strconv.FormatUint(uint64(userID), 10))
+	if _, err := pipe.Exec(redis.Ctx); err != nil {
+		return false, false, fmt.Errorf("failed to check cached group member: %w", err)
+	}
+	if existsCmd.Val() == 0 {
+		return false, false, nil
+	}
+	return isMemberCmd.Val(), true, nil
+}
+
+// Generation 返回群成员缓存当前的版本号，重建缓存前需要在读取数据库之前获取
+func (c *GroupMemberCache) Generation(groupID uint) (int64, error) {
+	gen, err := setCacheGeneration(generateGroupMembersKey(groupID))
+	if err != nil {
+		return 0, fmt.Errorf("failed to get group member cache generation: %w", err)
+	}
+	return gen, nil
+}
+
+// SetMemberIDs 用完整的成员列表重建群成员缓存，gen 为读取数据库之前获取的版本号，
+// 期间有成员变更时放弃写入
+func (c *GroupMemberCache) SetMemberIDs(groupID uint, gen int64, memberIDs []uint) error {
+	if len(memberIDs) == 0 {
+		return nil
+	}
+	members := make([]string, 0, len(memberIDs))
+	for _, id := range memberIDs {
+		members = append(members, strconv.FormatUint(uint64(id), 10))
+	}
+	if _, err := rebuildSetCache(generateGroupMembersKey(groupID), gen, members, c.expiration); err != nil {
+		return fmt.Errorf("failed to cache group members: %w", err)
+	}
+	return nil
+}
+
+// AddMember 向已缓存的群成员集合中添加成员
+func (c *GroupMemberCache) AddMember(groupID, userID uint) error {
+	if err := addToSetCache(generateGroupMembersKey(groupID), strconv.FormatUint(uint64(userID), 10)); err != nil {
+		return fmt.Errorf("failed to add member to group cache: %w", err)
+	}
+	return nil
+}
+
+// RemoveMember 从已缓存的群成员集合中移除成员
+func (c *GroupMemberCache) RemoveMember(groupID, userID uint) error {
+	err := removeFromSetCache(generateGroupMembersKey(groupID), strconv.FormatUint(uint64(userID), 10),
+		generateGroupMemberRolesKey(groupID))
+	if err != nil {
+		return fmt.Errorf("failed to remove member from group cache: %w", err)
+	}
+	return nil
+}
+
+// GetAccess 获取缓存的群成员角色和禁言状态，缓存未命中时 hit 为 false
+func (c *GroupMemberCache) GetAccess(groupID, userID uint) (access *model.GroupMemberAccess, hit bool, err error) {
+	data, err := redis.GetUniversalClient().HGet(redis.Ctx, generateGroupMemberRolesKey(groupID), strconv.FormatUint(uint64(userID), 10)).Bytes()
+	if errors.Is(err, goredis.Nil) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to get cached group member role: %w", err)
+	}
+	access = &model.GroupMemberAccess{}
+	if err := json.Unmarshal(data, access); err != nil {
+		return nil, false, fmt.Errorf("failed to decode cached group member role: %w", err)
+	}
+	if access.Role == nil {
+		// 旧格式的缓存只有角色定义，按未命中处理
+		return nil, false, nil
+	}
+	return access, true, nil
+}
+
+// SetAccess 缓存群成员的角色和禁言状态，gen 为读取数据库之前获取的版本号，期间成员、角色或禁言有变更时放弃写入
+func (c *GroupMemberCache) SetAccess(groupID, userID uint, gen int64, access *model.GroupMemberAccess) error {
+	data, err := json.Marshal(access)
+	if err != nil {
+		return fmt.Errorf("failed to encode group member role: %w", err)
+	}
+	err = setCacheField(generateGroupMembersKey(groupID), generateGroupMemberRolesKey(groupID), gen,
+		strconv.FormatUint(uint64(userID), 10), string(data), c.expiration)
+	if err != nil {
+		return fmt.Errorf("failed to cache group member role: %w", err)
+	}
+	return nil
+}
+
+// InvalidateRoles 删除群组所有成员的角色缓存，成员角色、角色权限或禁言状态变更后调用
+func (c *GroupMemberCache) InvalidateRoles(groupID uint) error {
+	// 版本号由成员集合与角色哈希共用，这里递增版本号并只删除角色哈希，成员集合不受影响
+	return invalidateRelatedCache(generateGroupMembersKey(groupID), generateGroupMemberRolesKey(groupID))
+}
+
+// Invalidate 删除群成员缓存及角色缓存
+func (c *GroupMemberCache) Invalidate(groupID uint) error {
+	return invalidateSetCache(generateGroupMembersKey(groupID), generateGroupMemberRolesKey(groupID))
+}
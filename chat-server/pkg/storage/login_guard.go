@@ -0,0 +1,197 @@
+package storage
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Xaytick/chat-zinx/chat-server/conf"
+	"github.com/Xaytick/chat-zinx/chat-server/dao/redis"
+)
+
+const (
+	loginFailPrefix    = "login:fail:"    // 失败次数计数器
+	loginLockPrefix    = "login:lock:"    // 锁定标记，存在期间拒绝登录
+	loginBackoffPrefix = "login:backoff:" // 退避标记，存在期间拒绝登录
+	registerPrefix     = "register:ip:"   // 注册次数计数器
+	resetPrefix        = "pwreset:"       // 申请重置密码次数计数器
+)
+
+// LoginGuard 基于 Redis 的登录失败计数、退避与锁定，按账号和IP分别统计
+type LoginGuard struct {
+	cfg *conf.LoginProtectionConfig
+}
+
+// NewLoginGuard 创建登录防护
+func NewLoginGuard() *LoginGuard {
+	return &LoginGuard{cfg: conf.GetLoginProtectionConfig()}
+}
+
+// accountSubject 账号统一转为小写，避免大小写变化绕过计数
+func accountSubject(account string) string {
+	return "user:" + strings.ToLower(strings.TrimSpace(account))
+}
+
+func ipSubject(ip string) string {
+	return "ip:" + ip
+}
+
+// Check 检查账号和IP当前是否允许尝试登录
+// 返回需要等待的时间，locked 表示处于锁定状态而非退避状态
+func (g *LoginGuard) Check(account, ip string) (wait time.Duration, locked bool, err error) {
+	client := redis.GetUniversalClient()
+	pipe := client.Pipeline()
+	accountLock := pipe.PTTL(redis.Ctx, loginLockPrefix+accountSubject(account))
+	ipLock := pipe.PTTL(redis.Ctx, loginLockPrefix+ipSubject(ip))
+	backoff := pipe.PTTL(redis.Ctx, loginBackoffPrefix+accountSubject(account))
+	if _, err := pipe.Exec(redis.Ctx); err != nil {
+		return 0, false, fmt.Errorf("failed to check login guard: %w", err)
+	}
+
+	// PTTL 对不存在的键返回负值
+	if lockWait := maxDuration(accountLock.Val(), ipLock.Val()); lockWait > 0 {
+		return lockWait, true, nil
+	}
+	if backoff.Val() > 0 {
+		return backoff.Val(), false, nil
+	}
+	return 0, false, nil
+}
+
+// RecordFailure 记录一次登录失败，达到阈值时设置退避或锁定
+func (g *LoginGuard) RecordFailure(account, ip string) error {
+	accountFailures, err := g.incrWithin(loginFailPrefix+accountSubject(account), g.window())
+	if err != nil {
+		return err
+	}
+	ipFailures, err := g.incrWithin(loginFailPrefix+ipSubject(ip), g.window())
+	if err != nil {
+		return err
+	}
+
+	client := redis.GetUniversalClient()
+	lockout := time.Duration(g.cfg.LockoutDuration) * time.Second
+	if accountFailures >= int64(g.cfg.MaxAccountFailures) {
+		if err := g.lock(accountSubject(account), lockout); err != nil {
+			return err
+		}
+	} else if accountFailures >= int64(g.cfg.BackoffAfter) {
+		// 退避时间按失败次数指数增长，不超过锁定时长
+		backoff := time.Duration(g.cfg.BackoffBase) * time.Second << uint(accountFailures-int64(g.cfg.BackoffAfter))
+		if backoff > lockout || backoff <= 0 {
+			backoff = lockout
+		}
+		if err := client.Set(redis.Ctx, loginBackoffPrefix+accountSubject(account), "1", backoff).Err(); err != nil {
+			return fmt.Errorf("failed to set login backoff: %w", err)
+		}
+	}
+	if ipFailures >= int64(g.cfg.MaxIPFailures) {
+		if err := g.lock(ipSubject(ip), lockout); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Reset 登录成功后清除账号的失败计数和退避，IP计数保留到窗口结束
+func (g *LoginGuard) Reset(account string) error {
+	subject := accountSubject(account)
+	return redis.GetUniversalClient().Del(redis.Ctx, loginFailPrefix+subject, loginBackoffPrefix+subject).Err()
+}
+
+// Unlock 解除账号（以及可选的IP）的锁定并清空计数，返回此前是否处于锁定状态
+func (g *LoginGuard) Unlock(account, ip string) (bool, error) {
+	subjects := []string{accountSubject(account)}
+	if ip != "" {
+		subjects = append(subjects, ipSubject(ip))
+	}
+
+	client := redis.GetUniversalClient()
+	unlocked := false
+	for _, subject := range subjects {
+		n, err := client.Del(redis.Ctx, loginLockPrefix+subject).Result()
+		if err != nil {
+			return false, fmt.Errorf("failed to unlock %s: %w", subject, err)
+		}
+		if n > 0 {
+			unlocked = true
+		}
+		if err := client.Del(redis.Ctx, loginFailPrefix+subject, loginBackoffPrefix+subject).Err(); err != nil {
+			return false, fmt.Errorf("failed to reset counters of %s: %w", subject, err)
+		}
+	}
+	return unlocked, nil
+}
+
+// AllowRegister 统计IP的注册次数，超过限制时返回需要等待的时间
+func (g *LoginGuard) AllowRegister(ip string) (time.Duration, error) {
+	return g.allowWithin(registerPrefix+ip, g.cfg.RegisterPerIP, time.Duration(g.cfg.RegisterWindow)*time.Second)
+}
+
+// AllowPasswordReset 分别统计账号和IP申请重置密码的次数
+// 返回IP超过限制时需要等待的时间，accountLimited 表示该账号在窗口内的申请次数已超过限制
+func (g *LoginGuard) AllowPasswordReset(account, ip string) (ipWait time.Duration, accountLimited bool, err error) {
+	window := time.Duration(g.cfg.ResetWindow) * time.Second
+	ipWait, err = g.allowWithin(resetPrefix+ipSubject(ip), g.cfg.ResetPerIP, window)
+	if err != nil || ipWait > 0 {
+		return ipWait, false, err
+	}
+	accountWait, err := g.allowWithin(resetPrefix+accountSubject(account), g.cfg.ResetPerAccount, window)
+	if err != nil {
+		return 0, false, err
+	}
+	return 0, accountWait > 0, nil
+}
+
+// allowWithin 计数加一，窗口内次数超过 limit 时返回距离窗口结束的时间
+func (g *LoginGuard) allowWithin(key string, limit int, window time.Duration) (time.Duration, error) {
+	count, err := g.incrWithin(key, window)
+	if err != nil {
+		return 0, err
+	}
+	if count <= int64(limit) {
+		return 0, nil
+	}
+	ttl, err := redis.GetUniversalClient().PTTL(redis.Ctx, key).Result()
+	if err != nil || ttl <= 0 {
+		return window, nil
+	}
+	return ttl, nil
+}
+
+// incrWithin 计数加一，首次计数时设置窗口过期时间
+func (g *LoginGuard) incrWithin(key string, window time.Duration) (int64, error) {
+	client := redis.GetUniversalClient()
+	count, err := client.Incr(redis.Ctx, key).Result()
+	if err != nil {
+		return 0, fmt.Errorf("failed to increase counter %s: %w", key, err)
+	}
+	if count == 1 {
+		if err := client.Expire(redis.Ctx, key, window).Err(); err != nil {
+			return 0, fmt.Errorf("failed to set counter expiration %s: %w", key, err)
+		}
+	}
+	return count, nil
+}
+
+// lock 设置锁定标记并清空计数，解锁后重新开始统计
+func (g *LoginGuard) lock(subject string, duration time.Duration) error {
+	pipe := redis.GetUniversalClient().Pipeline()
+	pipe.Set(redis.Ctx, loginLockPrefix+subject, "1", duration)
+	pipe.Del(redis.Ctx, loginFailPrefix+subject, loginBackoffPrefix+subject)
+	if _, err := pipe.Exec(redis.Ctx); err != nil {
+		return fmt.Errorf("failed to lock %s: %w", subject, err)
+	}
+	return nil
+}
+
+func (g *LoginGuard) window() time.Duration {
+	return time.Duration(g.cfg.FailureWindow) * time.Second
+}
+
+func maxDuration(a, b time.Duration) time.Duration {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,47 @@
+package storage
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/Xaytick/chat-zinx/chat-server/dao/redis"
+	goredis "github.com/go-redis/redis/v8"
+)
+
+const rateLimitPrefix = "ratelimit:"
+
+// 计数并在窗口开始时设置过期时间，返回计数和剩余毫秒数
+// 计数键没有过期时间时（例如旧版本写入后设置过期失败）同样补上，避免计数永不清零
+var rateLimitScript = goredis.NewScript(`
+local count = redis.call('INCR', KEYS[1])
+local ttl = redis.call('PTTL', KEYS[1])
+if ttl < 0 then
+	redis.call('PEXPIRE', KEYS[1], ARGV[1])
+	ttl = tonumber(ARGV[1])
+end
+return {count, ttl}`)
+
+// RateLimiter 基于 Redis 的固定窗口限流，计数保存在 Redis 中，多个节点共享同一个额度
+type RateLimiter struct {
+	name   string
+	limit  int
+	window time.Duration
+}
+
+// NewRateLimiter 创建限流器，name 用于区分不同用途的计数
+func NewRateLimiter(name string, limit int, window time.Duration) *RateLimiter {
+	return &RateLimiter{name: name, limit: limit, window: window}
+}
+
+// Allow 为 subject 计数一次，超过限制时返回距离窗口结束的时间
+func (l *RateLimiter) Allow(subject string) (time.Duration, error) {
+	key := rateLimitPrefix + l.name + ":" + subject
+	res, err := rateLimitScript.Run(redis.Ctx, redis.GetUniversalClient(), []string{key}, l.window.Milliseconds()).Int64Slice()
+	if err != nil {
+		return 0, fmt.Errorf("failed to increase rate counter %s: %w", key, err)
+	}
+	if res[0] <= int64(l.limit) {
+		return 0, nil
+	}
+	return time.Duration(res[1]) * time.Millisecond, nil
+}
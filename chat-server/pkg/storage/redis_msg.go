@@ -15,6 +15,10 @@ import (
 const (
 	// 消息列表的键前缀
 	offlineMessagePrefix = "offline:msg:"
+	// 因慢消费者被丢弃的推送的键前缀，与私聊离线消息分开存放，保留原消息ID
+	offlinePushPrefix = "offline:push:"
+	// 每个用户最多保留的离线推送条数，超出时丢弃最早的，避免长期积压的用户占满内存
+	maxOfflinePushes = 1000
 	// 历史消息的键前缀
 	historyMessagePrefix = "history:msg:"
 	// 双向消息关系的键前缀
@@ -39,6 +43,11 @@ func generateOfflineKey(userID uint) string {
 	return offlineMessagePrefix + strconv.FormatUint(uint64(userID), 10)
 }
 
+// generateOfflinePushKey 生成离线推送的键
+func generateOfflinePushKey(userID uint) string {
+	return offlinePushPrefix + strconv.FormatUint(uint64(userID), 10)
+}
+
 // generateHistoryKey 生成历史消息的键
 func generateHistoryKey(fromUserID, toUserID uint) string {
 	fromStr := strconv.FormatUint(uint64(fromUserID), 10)
@@ -184,6 +193,44 @@ func (s *RedisMsgStorage) GetOfflineMessages(userID uint) ([][]byte, error) {
 	return messages, nil
 }
 
+// SaveOfflinePush 保存因连接积压而未能发送的推送，用户下次登录时按原消息ID补发
+// 最多保留最近的 maxOfflinePushes 条
+func (s *RedisMsgStorage) SaveOfflinePush(userID uint, msgID uint32, data []byte) error {
+	entry, err := json.Marshal(model.OfflinePush{MsgID: msgID, Data: data, Timestamp: time.Now().Unix()})
+	if err != nil {
+		return err
+	}
+	key := generateOfflinePushKey(userID)
+	pipe := redis.RedisClient.TxPipeline()
+	pipe.RPush(redis.Ctx, key, entry)
+	pipe.LTrim(redis.Ctx, key, -maxOfflinePushes, -1)
+	pipe.Expire(redis.Ctx, key, s.expiration)
+	_, err = pipe.Exec(redis.Ctx)
+	return err
+}
+
+// GetOfflinePushes 获取并清空用户的离线推送
+func (s *RedisMsgStorage) GetOfflinePushes(userID uint) ([]*model.OfflinePush, error) {
+	key := generateOfflinePushKey(userID)
+	pipe := redis.RedisClient.TxPipeline()
+	rangeCmd := pipe.LRange(redis.Ctx, key, 0, -1)
+	pipe.Del(redis.Ctx, key)
+	if _, err := pipe.Exec(redis.Ctx); err != nil {
+		return nil, err
+	}
+
+	pushes := make([]*model.OfflinePush, 0, len(rangeCmd.Val()))
+	for _, entry := range rangeCmd.Val() {
+		var push model.OfflinePush
+		if err := json.Unmarshal([]byte(entry), &push); err != nil {
+			fmt.Printf("[Redis消息] 解析离线推送失败: %v\n", err)
+			continue
+		}
+		pushes = append(pushes, &push)
+	}
+	return pushes, nil
+}
+
 // HasOfflineMessages 检查用户是否有离线消息
 func (s *RedisMsgStorage) HasOfflineMessages(userID uint) (bool, error) {
 	offlineKey := generateOfflineKey(userID)
@@ -310,3 +357,35 @@ func (s *RedisMsgStorage) SaveHistoryOnly(fromUserID, toUserID uint, msgData []b
 
 	return nil
 }
+
+// PurgeUser 删除用户的离线消息、聊天关系，以及与所有聊天对象的私聊历史
+// 私聊历史按用户对存储，账号注销后对方无法再定位到该会话，因此直接删除而不做匿名化
+func (s *RedisMsgStorage) PurgeUser(userID uint) error {
+	peers, err := s.GetChatRelations(userID)
+	if err != nil {
+		return err
+	}
+
+	userIDStr := strconv.FormatUint(uint64(userID), 10)
+	for _, peer := range peers {
+		peerID, err := strconv.ParseUint(peer, 10, 64)
+		if err != nil {
+			continue
+		}
+		if err := redis.RedisClient.Del(redis.Ctx, generateHistoryKey(userID, uint(peerID))).Err(); err != nil {
+			return err
+		}
+		if err := redis.RedisClient.SRem(redis.Ctx, generateRelationKey(uint(peerID)), userIDStr).Err(); err != nil {
+			return err
+		}
+	}
+
+	// 集群模式下几个键可能不在同一个槽，分开删除
+	if err := redis.RedisClient.Del(redis.Ctx, generateOfflineKey(userID)).Err(); err != nil {
+		return err
+	}
+	if err := redis.RedisClient.Del(redis.Ctx, generateOfflinePushKey(userID)).Err(); err != nil {
+		return err
+	}
+	return redis.RedisClient.Del(redis.Ctx, generateRelationKey(userID)).Err()
+}
@@ -0,0 +1,166 @@
+package storage
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/Xaytick/chat-zinx/chat-server/dao/redis"
+	goredis "github.com/go-redis/redis/v8"
+)
+
+// 集合缓存的重建与增量更新
+//
+// 缓存未命中时调用方先读取版本号，再从数据库加载完整列表并重建缓存。
+// 每次增量更新或删除缓存都会递增版本号，重建时版本号已变化说明加载的列表可能缺少这次变更，
+// 此时放弃写入，由下一次读取重新加载。新集合先写入临时键，确认版本号后再 RENAME 为正式键，
+// 读取方不会看到写了一半的集合。
+// 与集合配套的哈希（如群成员的角色）同样受版本号保护。
+// 集合键、版本号键、临时键和关联键使用相同的 hash tag，集群模式下位于同一个槽。
+
+const (
+	// 版本号键的过期时间，需要远大于一次从数据库加载列表的耗时
+	setCacheGenerationTTL = time.Hour
+	// 临时键的过期时间，重建中途失败时由 Redis 清理
+	setCacheTempTTL = time.Minute
+	// 每批 SADD 的成员数，避免大集合一次性写入过多参数
+	setCacheWriteBatch = 1000
+)
+
+var (
+	// 版本号未变化时用临时键替换正式集合，否则丢弃临时键
+	replaceSetIfCurrentScript = goredis.NewScript(`
+local gen = redis.call('GET', KEYS[3]) or '0'
+if gen ~= ARGV[1] then
+	redis.call('DEL', KEYS[2])
+	return 0
+end
+redis.call('RENAME', KEYS[2], KEYS[1])
+redis.call('EXPIRE', KEYS[1], ARGV[2])
+return 1`)
+	// 递增版本号，集合已存在时增加成员，避免在缓存未命中时写入不完整的集合
+	addToSetIfCachedScript = goredis.NewScript(`
+redis.call('INCR', KEYS[2])
+redis.call('EXPIRE', KEYS[2], ARGV[2])
+if redis.call('EXISTS', KEYS[1]) == 1 then
+	return redis.call('SADD', KEYS[1], ARGV[1])
+end
+return 0`)
+	// 递增版本号，集合已存在时移除成员，并从其余的关联哈希中删除该成员的字段
+	removeFromSetIfCachedScript = goredis.NewScript(`
+redis.call('INCR', KEYS[2])
+redis.call('EXPIRE', KEYS[2], ARGV[2])
+for i = 3, #KEYS do
+	redis.call('HDEL', KEYS[i], ARGV[1])
+end
+if redis.call('EXISTS', KEYS[1]) == 1 then
+	return redis.call('SREM', KEYS[1], ARGV[1])
+end
+return 0`)
+	// 递增版本号 KEYS[1] 并删除其余的键
+	invalidateSetScript = goredis.NewScript(`
+redis.call('INCR', KEYS[1])
+redis.call('EXPIRE', KEYS[1], ARGV[1])
+for i = 2, #KEYS do
+	redis.call('DEL', KEYS[i])
+end
+return 1`)
+	// 版本号未变化时写入关联哈希的字段，哈希新建时设置过期时间
+	setFieldIfCurrentScript = goredis.NewScript(`
+local gen = redis.call('GET', KEYS[2]) or '0'
+if gen ~= ARGV[1] then
+	return 0
+end
+redis.call('HSET', KEYS[1], ARGV[2], ARGV[3])
+if redis.call('TTL', KEYS[1]) < 0 then
+	redis.call('EXPIRE', KEYS[1], ARGV[4])
+end
+return 1`)
+)
+
+// setCacheGenerationKey 集合缓存的版本号键
+func setCacheGenerationKey(key string) string {
+	return key + ":gen"
+}
+
+// setCacheGeneration 读取集合缓存当前的版本号，版本号不存在时为 0
+func setCacheGeneration(key string) (int64, error) {
+	gen, err := redis.GetUniversalClient().Get(redis.Ctx, setCacheGenerationKey(key)).Int64()
+	if errors.Is(err, goredis.Nil) {
+		return 0, nil
+	}
+	return gen, err
+}
+
+// rebuildSetCache 在版本号仍为 gen 时用 members 替换集合缓存，返回是否写入
+func rebuildSetCache(key string, gen int64, members []string, expiration time.Duration) (bool, error) {
+	suffix := make([]byte, 8)
+	if _, err := rand.Read(suffix); err != nil {
+		return false, err
+	}
+	tempKey := key + ":tmp:" + hex.EncodeToString(suffix)
+	client := redis.GetUniversalClient()
+
+	pipe := client.Pipeline()
+	for start := 0; start < len(members); start += setCacheWriteBatch {
+		end := min(start+setCacheWriteBatch, len(members))
+		batch := make([]interface{}, 0, end-start)
+		for _, member := range members[start:end] {
+			batch = append(batch, member)
+		}
+		pipe.SAdd(redis.Ctx, tempKey, batch...)
+	}
+	pipe.Expire(redis.Ctx, tempKey, setCacheTempTTL)
+	if _, err := pipe.Exec(redis.Ctx); err != nil {
+		client.Del(redis.Ctx, tempKey)
+		return false, err
+	}
+
+	replaced, err := replaceSetIfCurrentScript.Run(redis.Ctx, client,
+		[]string{key, tempKey, setCacheGenerationKey(key)},
+		strconv.FormatInt(gen, 10), int64(expiration/time.Second)).Int()
+	if err != nil {
+		client.Del(redis.Ctx, tempKey)
+		return false, err
+	}
+	return replaced == 1, nil
+}
+
+// addToSetCache 递增版本号，集合已缓存时增加成员
+func addToSetCache(key, member string) error {
+	return addToSetIfCachedScript.Run(redis.Ctx, redis.GetUniversalClient(),
+		[]string{key, setCacheGenerationKey(key)}, member, int64(setCacheGenerationTTL/time.Second)).Err()
+}
+
+// removeFromSetCache 递增版本号，集合已缓存时移除成员，并从 fieldKeys 这些哈希中删除该成员的字段
+func removeFromSetCache(key, member string, fieldKeys ...string) error {
+	keys := append([]string{key, setCacheGenerationKey(key)}, fieldKeys...)
+	return removeFromSetIfCachedScript.Run(redis.Ctx, redis.GetUniversalClient(),
+		keys, member, int64(setCacheGenerationTTL/time.Second)).Err()
+}
+
+// invalidateSetCache 递增版本号并删除集合缓存及 related 这些关联键，进行中的重建不会再写入旧数据
+func invalidateSetCache(key string, related ...string) error {
+	return invalidateRelatedCache(key, append([]string{key}, related...)...)
+}
+
+// invalidateRelatedCache 递增集合缓存的版本号并只删除 related 这些关联键，集合本身保留
+func invalidateRelatedCache(key string, related ...string) error {
+	keys := append([]string{setCacheGenerationKey(key)}, related...)
+	err := invalidateSetScript.Run(redis.Ctx, redis.GetUniversalClient(),
+		keys, int64(setCacheGenerationTTL/time.Second)).Err()
+	if err != nil {
+		return fmt.Errorf("failed to invalidate cache %s: %w", key, err)
+	}
+	return nil
+}
+
+// setCacheField 在集合缓存的版本号仍为 gen 时写入关联哈希 hashKey 的字段
+func setCacheField(key, hashKey string, gen int64, field, value string, expiration time.Duration) error {
+	return setFieldIfCurrentScript.Run(redis.Ctx, redis.GetUniversalClient(),
+		[]string{hashKey, setCacheGenerationKey(key)},
+		strconv.FormatInt(gen, 10), field, value, int64(expiration/time.Second)).Err()
+}
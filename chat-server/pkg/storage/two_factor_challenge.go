@@ -0,0 +1,68 @@
+package storage
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"time"
+
+	"github.com/Xaytick/chat-zinx/chat-server/dao/redis"
+	goredis "github.com/go-redis/redis/v8"
+)
+
+const (
+	// 两步验证挑战的键前缀，值为哈希 {uid, attempts}
+	twoFactorChallengePrefix = "2fa:challenge:"
+	// 挑战令牌的长度（32 字节随机数的十六进制）
+	twoFactorChallengeLen = 64
+)
+
+// 计入一次尝试并返回 {用户ID, 已尝试次数}，挑战不存在时返回 {0, 0}；次数用完时删除挑战
+var useTwoFactorChallengeScript = goredis.NewScript(`
+local uid = redis.call('HGET', KEYS[1], 'uid')
+if not uid then
+	return {0, 0}
+end
+local attempts = redis.call('HINCRBY', KEYS[1], 'attempts', 1)
+if attempts >= tonumber(ARGV[1]) then
+	redis.call('DEL', KEYS[1])
+end
+return {tonumber(uid), attempts}`)
+
+// CreateTwoFactorChallenge 保存密码已验证、等待第二因素的登录状态，返回挑战令牌
+// HTTP 登录是无状态的，挑战保存在 Redis 中，第二步可以落在任意节点上
+func CreateTwoFactorChallenge(userID uint, ttl time.Duration) (string, error) {
+	buf := make([]byte, twoFactorChallengeLen/2)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	challenge := hex.EncodeToString(buf)
+	key := twoFactorChallengePrefix + challenge
+	pipe := redis.GetUniversalClient().TxPipeline()
+	pipe.HSet(redis.Ctx, key, "uid", userID, "attempts", 0)
+	pipe.Expire(redis.Ctx, key, ttl)
+	if _, err := pipe.Exec(redis.Ctx); err != nil {
+		return "", err
+	}
+	return challenge, nil
+}
+
+// UseTwoFactorChallenge 在挑战上计入一次尝试，返回用户ID和剩余次数，ok 为 false 表示挑战不存在、已过期或次数已用完
+func UseTwoFactorChallenge(challenge string, maxAttempts int) (userID uint, remaining int, ok bool, err error) {
+	if len(challenge) != twoFactorChallengeLen {
+		return 0, 0, false, nil
+	}
+	res, err := useTwoFactorChallengeScript.Run(redis.Ctx, redis.GetUniversalClient(),
+		[]string{twoFactorChallengePrefix + challenge}, maxAttempts).Int64Slice()
+	if err != nil {
+		return 0, 0, false, err
+	}
+	if res[0] == 0 || res[1] > int64(maxAttempts) {
+		return 0, 0, false, nil
+	}
+	return uint(res[0]), maxAttempts - int(res[1]), true, nil
+}
+
+// DeleteTwoFactorChallenge 登录完成后删除挑战，令牌不能再次使用
+func DeleteTwoFactorChallenge(challenge string) error {
+	return redis.GetUniversalClient().Del(redis.Ctx, twoFactorChallengePrefix+challenge).Err()
+}
@@ -0,0 +1,103 @@
+package tlsgateway
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// certStore 保存当前使用的服务器证书和客户端 CA，文件更新后重新加载
+// 握手时通过 GetConfigForClient 读取最新的证书，已建立的连接不受影响
+type certStore struct {
+	certFile string
+	keyFile  string
+	caFile   string
+
+	mu        sync.RWMutex
+	cert      *tls.Certificate
+	clientCAs *x509.CertPool
+	modTime   time.Time // 几个文件中最新的修改时间
+}
+
+func newCertStore(certFile, keyFile, caFile string) (*certStore, error) {
+	s := &certStore{certFile: certFile, keyFile: keyFile, caFile: caFile}
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// load 读取证书、私钥和客户端 CA，任何一个失败时保留原来的证书
+func (s *certStore) load() error {
+	modTime, err := s.latestModTime()
+	if err != nil {
+		return err
+	}
+	cert, err := tls.LoadX509KeyPair(s.certFile, s.keyFile)
+	if err != nil {
+		return fmt.Errorf("failed to load certificate: %w", err)
+	}
+	var clientCAs *x509.CertPool
+	if s.caFile != "" {
+		pem, err := os.ReadFile(s.caFile)
+		if err != nil {
+			return fmt.Errorf("failed to read client CA: %w", err)
+		}
+		clientCAs = x509.NewCertPool()
+		if !clientCAs.AppendCertsFromPEM(pem) {
+			return errors.New("no valid certificates found in client CA file")
+		}
+	}
+
+	s.mu.Lock()
+	s.cert = &cert
+	s.clientCAs = clientCAs
+	s.modTime = modTime
+	s.mu.Unlock()
+	return nil
+}
+
+// reloadIfChanged 文件修改时间变化时重新加载，返回是否重新加载
+func (s *certStore) reloadIfChanged() (bool, error) {
+	modTime, err := s.latestModTime()
+	if err != nil {
+		return false, err
+	}
+	s.mu.RLock()
+	unchanged := modTime.Equal(s.modTime)
+	s.mu.RUnlock()
+	if unchanged {
+		return false, nil
+	}
+	if err := s.load(); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (s *certStore) current() (*tls.Certificate, *x509.CertPool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.cert, s.clientCAs
+}
+
+func (s *certStore) latestModTime() (time.Time, error) {
+	var latest time.Time
+	for _, file := range []string{s.certFile, s.keyFile, s.caFile} {
+		if file == "" {
+			continue
+		}
+		info, err := os.Stat(file)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("failed to stat %s: %w", file, err)
+		}
+		if info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+	}
+	return latest, nil
+}
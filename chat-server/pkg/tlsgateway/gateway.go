@@ -0,0 +1,281 @@
+package tlsgateway
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/Xaytick/chat-zinx/chat-server/conf"
+)
+
+// upstreamDialTimeout 连接本机 zinx 端口的超时时间
+const upstreamDialTimeout = 5 * time.Second
+
+// Peer 经过网关的客户端信息
+type Peer struct {
+	RemoteAddr net.Addr // 客户端的真实地址
+	ClientCN   string   // 双向 TLS 时客户端证书的 CommonName，未提供证书时为空
+	Version    uint16   // 协商的 TLS 版本
+}
+
+// Gateway TLS 网关，在 TLS 端口上终止 TLS 并把明文转发到本机的 zinx 端口
+// zinx 看到的对端地址是网关连接 zinx 时使用的本地地址，通过 LookupPeer 换回客户端的真实地址
+type Gateway struct {
+	cfg        *conf.TLSConfig
+	certs      *certStore
+	clientAuth tls.ClientAuthType
+	minVersion uint16
+
+	listener net.Listener
+	quit     chan struct{}
+	peers    sync.Map // 网关连接 zinx 使用的本地地址 -> *Peer
+
+	mu      sync.Mutex // 保护 conns 和 stopped
+	conns   map[net.Conn]struct{}
+	stopped bool
+	wg      sync.WaitGroup
+}
+
+// NewGateway 校验配置并加载证书，证书无效时直接返回错误
+func NewGateway(cfg *conf.TLSConfig) (*Gateway, error) {
+	if cfg.CertFile == "" || cfg.KeyFile == "" {
+		return nil, errors.New("tls: CertFile and KeyFile are required")
+	}
+
+	var clientAuth tls.ClientAuthType
+	switch cfg.ClientAuth {
+	case "optional", "":
+		clientAuth = tls.VerifyClientCertIfGiven
+	case "require":
+		clientAuth = tls.RequireAndVerifyClientCert
+	default:
+		return nil, fmt.Errorf("tls: unknown ClientAuth %q, expected optional or require", cfg.ClientAuth)
+	}
+	if cfg.ClientCAFile == "" {
+		if clientAuth == tls.RequireAndVerifyClientCert {
+			return nil, errors.New("tls: ClientAuth require needs ClientCAFile")
+		}
+		clientAuth = tls.NoClientCert
+	}
+
+	var minVersion uint16
+	switch cfg.MinVersion {
+	case "1.2", "":
+		minVersion = tls.VersionTLS12
+	case "1.3":
+		minVersion = tls.VersionTLS13
+	default:
+		return nil, fmt.Errorf("tls: unsupported MinVersion %q", cfg.MinVersion)
+	}
+
+	certs, err := newCertStore(cfg.CertFile, cfg.KeyFile, cfg.ClientCAFile)
+	if err != nil {
+		return nil, err
+	}
+	return &Gateway{
+		cfg:        cfg,
+		certs:      certs,
+		clientAuth: clientAuth,
+		minVersion: minVersion,
+		quit:       make(chan struct{}),
+		conns:      make(map[net.Conn]struct{}),
+	}, nil
+}
+
+// Start 开始监听 TLS 端口并定期检查证书文件
+func (g *Gateway) Start() error {
+	base := &tls.Config{
+		MinVersion: g.minVersion,
+		// 每次握手取最新的证书和客户端 CA，证书更新后新连接立即生效
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			cert, clientCAs := g.certs.current()
+			return &tls.Config{
+				MinVersion:   g.minVersion,
+				Certificates: []tls.Certificate{*cert},
+				ClientAuth:   g.clientAuth,
+				ClientCAs:    clientCAs,
+			}, nil
+		},
+	}
+	listener, err := tls.Listen("tcp", g.cfg.Addr, base)
+	if err != nil {
+		return fmt.Errorf("tls: failed to listen on %s: %w", g.cfg.Addr, err)
+	}
+	g.listener = listener
+
+	g.wg.Add(2)
+	go g.acceptLoop()
+	go g.reloadLoop()
+	fmt.Printf("[TLS] Listening on %s, forwarding to %s (client auth: %s)\n", g.cfg.Addr, g.cfg.Upstream, g.clientAuth)
+	return nil
+}
+
+// StopAccepting 关闭监听端口但保留已建立的连接，优雅关闭时先停止接入，等客户端收到下线通知后再调用 Stop
+func (g *Gateway) StopAccepting() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.listener != nil {
+		_ = g.listener.Close()
+	}
+}
+
+// Stop 停止监听并关闭所有经过网关的连接
+func (g *Gateway) Stop() {
+	g.mu.Lock()
+	if g.stopped {
+		g.mu.Unlock()
+		return
+	}
+	g.stopped = true
+	close(g.quit)
+	if g.listener != nil {
+		_ = g.listener.Close()
+	}
+	for conn := range g.conns {
+		_ = conn.Close()
+	}
+	g.mu.Unlock()
+
+	g.wg.Wait()
+	fmt.Println("[TLS] Gateway stopped")
+}
+
+// Reload 立即重新加载证书，用于收到 SIGHUP 时
+func (g *Gateway) Reload() error {
+	if err := g.certs.load(); err != nil {
+		return err
+	}
+	fmt.Println("[TLS] Certificates reloaded")
+	return nil
+}
+
+// LookupPeer 根据 zinx 连接的对端地址查找客户端信息，不是经过网关的连接时返回 false
+func (g *Gateway) LookupPeer(upstreamAddr string) (*Peer, bool) {
+	peer, ok := g.peers.Load(upstreamAddr)
+	if !ok {
+		return nil, false
+	}
+	return peer.(*Peer), true
+}
+
+func (g *Gateway) acceptLoop() {
+	defer g.wg.Done()
+	for {
+		conn, err := g.listener.Accept()
+		if err != nil {
+			select {
+			case <-g.quit:
+				return
+			default:
+			}
+			if errors.Is(err, net.ErrClosed) {
+				// StopAccepting 关闭了监听端口
+				return
+			}
+			var netErr net.Error
+			if errors.As(err, &netErr) && netErr.Timeout() {
+				time.Sleep(100 * time.Millisecond)
+				continue
+			}
+			fmt.Printf("[TLS] Accept failed: %v\n", err)
+			return
+		}
+		if !g.track(conn) {
+			_ = conn.Close()
+			return
+		}
+		g.wg.Add(1)
+		go g.handle(conn.(*tls.Conn))
+	}
+}
+
+// handle 完成握手后连接 zinx，双向转发直到任意一端关闭
+func (g *Gateway) handle(client *tls.Conn) {
+	defer g.wg.Done()
+	defer g.untrack(client)
+
+	_ = client.SetDeadline(time.Now().Add(time.Duration(g.cfg.HandshakeTimeout) * time.Second))
+	if err := client.Handshake(); err != nil {
+		fmt.Printf("[TLS] Handshake with %s failed: %v\n", client.RemoteAddr(), err)
+		return
+	}
+	_ = client.SetDeadline(time.Time{})
+
+	upstream, err := net.DialTimeout("tcp", g.cfg.Upstream, upstreamDialTimeout)
+	if err != nil {
+		fmt.Printf("[TLS] Failed to connect upstream %s: %v\n", g.cfg.Upstream, err)
+		return
+	}
+	if !g.track(upstream) {
+		_ = upstream.Close()
+		return
+	}
+	defer g.untrack(upstream)
+
+	// 在开始转发前登记，zinx 收到第一条消息时一定能查到真实地址
+	state := client.ConnectionState()
+	peer := &Peer{RemoteAddr: client.RemoteAddr(), Version: state.Version}
+	if len(state.PeerCertificates) > 0 {
+		peer.ClientCN = state.PeerCertificates[0].Subject.CommonName
+	}
+	key := upstream.LocalAddr().String()
+	g.peers.Store(key, peer)
+	defer g.peers.Delete(key)
+
+	done := make(chan struct{}, 2)
+	go func() {
+		_, _ = io.Copy(upstream, client)
+		done <- struct{}{}
+	}()
+	go func() {
+		_, _ = io.Copy(client, upstream)
+		done <- struct{}{}
+	}()
+	<-done
+	// 任意一端断开后关闭两端，另一个拷贝协程随之退出
+	_ = client.Close()
+	_ = upstream.Close()
+	<-done
+}
+
+func (g *Gateway) reloadLoop() {
+	defer g.wg.Done()
+	ticker := time.NewTicker(time.Duration(g.cfg.ReloadInterval) * time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			reloaded, err := g.certs.reloadIfChanged()
+			if err != nil {
+				// 证书正在替换时可能读到不完整的文件，保留旧证书，下个周期再试
+				fmt.Printf("[TLS] Failed to reload certificates, keeping the current ones: %v\n", err)
+			} else if reloaded {
+				fmt.Println("[TLS] Certificate files changed, reloaded")
+			}
+		case <-g.quit:
+			return
+		}
+	}
+}
+
+// track 记录连接以便 Stop 时关闭，已停止时返回 false
+func (g *Gateway) track(conn net.Conn) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.stopped {
+		return false
+	}
+	g.conns[conn] = struct{}{}
+	return true
+}
+
+func (g *Gateway) untrack(conn net.Conn) {
+	g.mu.Lock()
+	delete(g.conns, conn)
+	g.mu.Unlock()
+	_ = conn.Close()
+}
@@ -0,0 +1,79 @@
+package totp
+
+import (
+	"encoding/base32"
+	"testing"
+	"time"
+)
+
+// rfcSecret RFC 6238 附录 B 中 SHA1 使用的密钥 "12345678901234567890"
+var rfcSecret = base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString([]byte("12345678901234567890"))
+
+func TestCodeRFC6238Vectors(t *testing.T) {
+	// 附录 B 给出的是 8 位验证码，6 位验证码取其后 6 位
+	vectors := []struct {
+		unix int64
+		code string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+		{20000000000, "353130"},
+	}
+	for _, v := range vectors {
+		got, err := Code(rfcSecret, Step(time.Unix(v.unix, 0)))
+		if err != nil {
+			t.Fatalf("t=%d: %v", v.unix, err)
+		}
+		if got != v.code {
+			t.Fatalf("t=%d: got %s, want %s", v.unix, got, v.code)
+		}
+	}
+}
+
+func TestValidateAcceptsSkew(t *testing.T) {
+	now := time.Unix(1234567890, 0)
+	current := Step(now)
+	for _, offset := range []int64{-1, 0, 1} {
+		code, err := Code(rfcSecret, current+offset)
+		if err != nil {
+			t.Fatal(err)
+		}
+		step, ok := Validate(rfcSecret, code, now, 1)
+		if !ok {
+			t.Fatalf("offset %d: code rejected", offset)
+		}
+		if step != current+offset {
+			t.Fatalf("offset %d: step = %d, want %d", offset, step, current+offset)
+		}
+	}
+}
+
+func TestValidateRejectsOutsideSkew(t *testing.T) {
+	now := time.Unix(1234567890, 0)
+	current := Step(now)
+	for _, offset := range []int64{-2, 2} {
+		code, err := Code(rfcSecret, current+offset)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, ok := Validate(rfcSecret, code, now, 1); ok {
+			t.Fatalf("offset %d: code accepted outside skew", offset)
+		}
+	}
+}
+
+func TestValidateRejectsWrongLength(t *testing.T) {
+	now := time.Unix(1234567890, 0)
+	code, err := Code(rfcSecret, Step(now))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range []string{"", code[:Digits-1], code + "0", "89005924"} {
+		if _, ok := Validate(rfcSecret, c, now, 1); ok {
+			t.Fatalf("code %q accepted", c)
+		}
+	}
+}
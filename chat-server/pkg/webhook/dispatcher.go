@@ -0,0 +1,242 @@
+package webhook
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/Xaytick/chat-zinx/chat-server/conf"
+	"github.com/Xaytick/chat-zinx/chat-server/pkg/model"
+)
+
+// maxReplyBodySize 读取 webhook 响应体的上限
+const maxReplyBodySize = 64 << 10
+
+// Delivery 一次待投递的 webhook 事件
+type Delivery struct {
+	Hook  *model.BotWebhook
+	Event *model.WebhookEvent
+
+	attempt int // 已经失败的次数
+}
+
+// ReplyHandler webhook 返回了回复内容时调用，由调用方以机器人身份发到群里
+type ReplyHandler func(hook *model.BotWebhook, reply string)
+
+// Dispatcher 机器人 webhook 投递协程池
+// 群消息路由只负责匹配和入队，HTTP 请求和回复都在这里的 worker 中完成，
+// 慢的或不可用的 webhook 地址不会阻塞消息收发。
+// 失败的投递由定时器在退避时间后重新入队，等待期间不占用 worker。
+type Dispatcher struct {
+	client     *http.Client
+	maxRetries int
+	backoff    time.Duration
+	onReply    ReplyHandler
+	jobs       chan *Delivery
+	workers    int
+
+	mu      sync.RWMutex // 保护 stopped 和 retries，避免向已关闭的队列投递
+	stopped bool
+	retries map[*time.Timer]*Delivery // 等待重试的投递
+	wg      sync.WaitGroup
+}
+
+// NewDispatcher 创建 webhook 投递协程池
+func NewDispatcher(cfg *conf.WebhookConfig, onReply ReplyHandler) *Dispatcher {
+	workers := cfg.Workers
+	if workers <= 0 {
+		workers = 1
+	}
+	dialer := &net.Dialer{Timeout: 5 * time.Second}
+	if !cfg.AllowPrivateNetworks {
+		// 在建立连接时检查解析后的地址，域名解析到内网地址同样会被拒绝
+		dialer.Control = func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if isPrivateIP(net.ParseIP(host)) {
+				return fmt.Errorf("webhook address %s is in a private network", host)
+			}
+			return nil
+		}
+	}
+
+	return &Dispatcher{
+		client: &http.Client{
+			Timeout:   time.Duration(cfg.Timeout) * time.Millisecond,
+			Transport: &http.Transport{DialContext: dialer.DialContext, MaxIdleConnsPerHost: 4},
+			// 不跟随重定向，避免被重定向到未经校验的地址
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+		maxRetries: cfg.MaxRetries,
+		backoff:    time.Duration(cfg.RetryBackoff) * time.Millisecond,
+		onReply:    onReply,
+		jobs:       make(chan *Delivery, cfg.QueueSize),
+		workers:    workers,
+		retries:    make(map[*time.Timer]*Delivery),
+	}
+}
+
+// Start 启动投递 worker
+func (d *Dispatcher) Start() {
+	for i := 0; i < d.workers; i++ {
+		d.wg.Add(1)
+		go d.worker()
+	}
+	fmt.Printf("[Webhook] Started %d workers, queue size %d\n", d.workers, cap(d.jobs))
+}
+
+// Stop 停止接收新的投递，队列中剩余的事件只尝试投递一次，等待重试的投递直接放弃
+func (d *Dispatcher) Stop() {
+	d.mu.Lock()
+	if d.stopped {
+		d.mu.Unlock()
+		return
+	}
+	d.stopped = true
+	for timer, delivery := range d.retries {
+		if timer.Stop() {
+			fmt.Printf("[Webhook] Delivery %s abandoned on shutdown\n", delivery.Event.DeliveryID)
+		}
+	}
+	clear(d.retries)
+	close(d.jobs)
+	d.mu.Unlock()
+
+	d.wg.Wait()
+	fmt.Println("[Webhook] All workers stopped")
+}
+
+// Enqueue 投递事件，队列已满或已停止时丢弃并返回 false
+// webhook 是尽力而为的通知，不应该因为外部服务变慢而对群消息施加背压
+func (d *Dispatcher) Enqueue(delivery *Delivery) bool {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	if d.stopped {
+		return false
+	}
+	select {
+	case d.jobs <- delivery:
+		return true
+	default:
+		fmt.Printf("[Webhook] Queue full, dropped delivery %s to bot %d\n", delivery.Event.DeliveryID, delivery.Hook.BotUserID)
+		return false
+	}
+}
+
+func (d *Dispatcher) worker() {
+	defer d.wg.Done()
+	for delivery := range d.jobs {
+		d.deliver(delivery)
+	}
+}
+
+// deliver 投递一次事件，网络错误、5xx 和 429 时按指数退避安排重试
+func (d *Dispatcher) deliver(delivery *Delivery) {
+	body, err := json.Marshal(delivery.Event)
+	if err != nil {
+		fmt.Printf("[Webhook] Failed to marshal delivery %s: %v\n", delivery.Event.DeliveryID, err)
+		return
+	}
+
+	reply, retryable, err := d.post(delivery, body)
+	if err == nil {
+		if reply != "" && d.onReply != nil {
+			d.onReply(delivery.Hook, reply)
+		}
+		return
+	}
+	if !retryable || delivery.attempt >= d.maxRetries {
+		fmt.Printf("[Webhook] Delivery %s to %s failed after %d attempts: %v\n",
+			delivery.Event.DeliveryID, delivery.Hook.URL, delivery.attempt+1, err)
+		return
+	}
+	d.scheduleRetry(delivery, err)
+}
+
+// scheduleRetry 在退避时间后把投递重新放回队列，已停止时放弃
+func (d *Dispatcher) scheduleRetry(delivery *Delivery, cause error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.stopped {
+		fmt.Printf("[Webhook] Delivery %s abandoned on shutdown: %v\n", delivery.Event.DeliveryID, cause)
+		return
+	}
+
+	delay := d.backoff << delivery.attempt
+	delivery.attempt++
+	// 回调需要先获取 d.mu，此时 timer 已经赋值并登记
+	var timer *time.Timer
+	timer = time.AfterFunc(delay, func() {
+		d.mu.Lock()
+		defer d.mu.Unlock()
+		if _, pending := d.retries[timer]; !pending {
+			return
+		}
+		delete(d.retries, timer)
+		select {
+		case d.jobs <- delivery:
+		default:
+			fmt.Printf("[Webhook] Queue full, dropped retry of delivery %s to bot %d\n",
+				delivery.Event.DeliveryID, delivery.Hook.BotUserID)
+		}
+	})
+	d.retries[timer] = delivery
+}
+
+// post 发送一次签名的请求，返回回复内容以及失败时是否值得重试
+func (d *Dispatcher) post(delivery *Delivery, body []byte) (string, bool, error) {
+	req, err := http.NewRequest(http.MethodPost, delivery.Hook.URL, bytes.NewReader(body))
+	if err != nil {
+		return "", false, err
+	}
+	// 每次重试使用新的时间戳，接收方可以据此拒绝过旧的请求
+	timestamp := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "chat-zinx-webhook/1.0")
+	req.Header.Set(model.WebhookHeaderEvent, delivery.Event.Event)
+	req.Header.Set(model.WebhookHeaderDelivery, delivery.Event.DeliveryID)
+	req.Header.Set(model.WebhookHeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(model.WebhookHeaderSignature, "sha256="+Sign(delivery.Hook.Secret, timestamp, body))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return "", true, err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxReplyBodySize))
+	if err != nil {
+		return "", true, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		retryable := resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests
+		return "", retryable, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+
+	// 响应体为空或不是 JSON 时视为没有回复
+	var reply model.WebhookReply
+	if len(bytes.TrimSpace(data)) == 0 || json.Unmarshal(data, &reply) != nil {
+		return "", false, nil
+	}
+	return reply.Reply, false, nil
+}
+
+// isPrivateIP 判断是否为回环、内网、链路本地或未指定地址
+func isPrivateIP(ip net.IP) bool {
+	if ip == nil {
+		return true
+	}
+	return ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() ||
+		ip.IsLinkLocalMulticast() || ip.IsUnspecified()
+}
@@ -0,0 +1,199 @@
+package webhook
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Xaytick/chat-zinx/chat-server/conf"
+	"github.com/Xaytick/chat-zinx/chat-server/pkg/model"
+)
+
+const testSecret = "test-secret"
+
+// recordingServer 按顺序返回 statuses 中的状态码，之后一律返回 200 和 reply，记录每次请求
+type recordingServer struct {
+	t        *testing.T
+	statuses []int
+	reply    string
+
+	mu       sync.Mutex
+	attempts []time.Time
+}
+
+func (rs *recordingServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	timestamp, err := strconv.ParseInt(r.Header.Get(model.WebhookHeaderTimestamp), 10, 64)
+	if err != nil {
+		rs.t.Errorf("invalid timestamp header: %v", err)
+	}
+	if !Verify(testSecret, timestamp, body, r.Header.Get(model.WebhookHeaderSignature)) {
+		rs.t.Errorf("signature does not verify")
+	}
+
+	rs.mu.Lock()
+	n := len(rs.attempts)
+	rs.attempts = append(rs.attempts, time.Now())
+	rs.mu.Unlock()
+
+	if n < len(rs.statuses) {
+		w.WriteHeader(rs.statuses[n])
+		return
+	}
+	_, _ = w.Write([]byte(`{"reply":"` + rs.reply + `"}`))
+}
+
+func (rs *recordingServer) attemptTimes() []time.Time {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	return append([]time.Time(nil), rs.attempts...)
+}
+
+func newTestDispatcher(maxRetries int, backoff time.Duration, onReply ReplyHandler) *Dispatcher {
+	return NewDispatcher(&conf.WebhookConfig{
+		Workers:              2,
+		QueueSize:            16,
+		Timeout:              2000,
+		MaxRetries:           maxRetries,
+		RetryBackoff:         int(backoff / time.Millisecond),
+		AllowPrivateNetworks: true,
+	}, onReply)
+}
+
+func newTestDelivery(url string) *Delivery {
+	return &Delivery{
+		Hook:  &model.BotWebhook{BotUserID: 1, URL: url, Secret: testSecret},
+		Event: &model.WebhookEvent{Event: "message", DeliveryID: "d-1"},
+	}
+}
+
+func TestDispatcherRetriesWithBackoff(t *testing.T) {
+	rs := &recordingServer{t: t, statuses: []int{http.StatusServiceUnavailable, http.StatusTooManyRequests}, reply: "pong"}
+	server := httptest.NewServer(rs)
+	defer server.Close()
+
+	replies := make(chan string, 1)
+	backoff := 50 * time.Millisecond
+	d := newTestDispatcher(3, backoff, func(_ *model.BotWebhook, reply string) {
+		replies <- reply
+	})
+	d.Start()
+	defer d.Stop()
+
+	if !d.Enqueue(newTestDelivery(server.URL)) {
+		t.Fatal("enqueue failed")
+	}
+	select {
+	case reply := <-replies:
+		if reply != "pong" {
+			t.Fatalf("reply = %q, want %q", reply, "pong")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for reply")
+	}
+
+	attempts := rs.attemptTimes()
+	if len(attempts) != 3 {
+		t.Fatalf("attempts = %d, want 3", len(attempts))
+	}
+	// 第二次重试的等待时间是第一次的两倍
+	for i, want := range []time.Duration{backoff, 2 * backoff} {
+		if gap := attempts[i+1].Sub(attempts[i]); gap < want {
+			t.Errorf("gap before attempt %d = %v, want at least %v", i+2, gap, want)
+		}
+	}
+}
+
+func TestDispatcherGivesUpAfterMaxRetries(t *testing.T) {
+	rs := &recordingServer{t: t, statuses: []int{500, 500, 500, 500, 500}}
+	server := httptest.NewServer(rs)
+	defer server.Close()
+
+	d := newTestDispatcher(2, 10*time.Millisecond, nil)
+	d.Start()
+	d.Enqueue(newTestDelivery(server.URL))
+
+	time.Sleep(300 * time.Millisecond)
+	d.Stop()
+	if n := len(rs.attemptTimes()); n != 3 {
+		t.Fatalf("attempts = %d, want 3", n)
+	}
+}
+
+func TestDispatcherDoesNotRetryClientErrors(t *testing.T) {
+	rs := &recordingServer{t: t, statuses: []int{http.StatusBadRequest}}
+	server := httptest.NewServer(rs)
+	defer server.Close()
+
+	d := newTestDispatcher(3, 10*time.Millisecond, nil)
+	d.Start()
+	d.Enqueue(newTestDelivery(server.URL))
+
+	time.Sleep(200 * time.Millisecond)
+	d.Stop()
+	if n := len(rs.attemptTimes()); n != 1 {
+		t.Fatalf("attempts = %d, want 1", n)
+	}
+}
+
+func TestDispatcherStopAbandonsPendingRetries(t *testing.T) {
+	rs := &recordingServer{t: t, statuses: []int{500, 500}}
+	server := httptest.NewServer(rs)
+	defer server.Close()
+
+	d := newTestDispatcher(3, time.Hour, nil)
+	d.Start()
+	d.Enqueue(newTestDelivery(server.URL))
+
+	deadline := time.Now().Add(5 * time.Second)
+	for len(rs.attemptTimes()) == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// 重试等待一小时，Stop 不应被它阻塞
+	stopped := make(chan struct{})
+	go func() {
+		d.Stop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Stop blocked on a pending retry")
+	}
+	if n := len(rs.attemptTimes()); n != 1 {
+		t.Fatalf("attempts = %d, want 1", n)
+	}
+}
+
+func TestSignAndVerify(t *testing.T) {
+	body := []byte(`{"event":"message"}`)
+	var timestamp int64 = 1700000000
+	signature := "sha256=" + Sign(testSecret, timestamp, body)
+
+	if !Verify(testSecret, timestamp, body, signature) {
+		t.Fatal("valid signature rejected")
+	}
+	cases := []struct {
+		name      string
+		secret    string
+		timestamp int64
+		body      []byte
+		signature string
+	}{
+		{"wrong secret", "other", timestamp, body, signature},
+		{"wrong timestamp", testSecret, timestamp + 1, body, signature},
+		{"tampered body", testSecret, timestamp, []byte(`{"event":"other"}`), signature},
+		{"missing prefix", testSecret, timestamp, body, Sign(testSecret, timestamp, body)},
+		{"empty", testSecret, timestamp, body, ""},
+	}
+	for _, c := range cases {
+		if Verify(c.secret, c.timestamp, c.body, c.signature) {
+			t.Errorf("%s: signature accepted", c.name)
+		}
+	}
+}
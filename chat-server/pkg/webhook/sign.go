@@ -0,0 +1,62 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/Xaytick/chat-zinx/chat-server/pkg/model"
+)
+
+// ErrPrivateAddress webhook 地址指向内网
+var ErrPrivateAddress = errors.New("webhook url points to a private network address")
+
+// Sign 计算请求签名: HMAC-SHA256(secret, "时间戳.请求体") 的十六进制
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify 校验签名头，供接收方（以及本地调试工具）使用
+func Verify(secret string, timestamp int64, body []byte, signature string) bool {
+	expected := "sha256=" + Sign(secret, timestamp, body)
+	return hmac.Equal([]byte(expected), []byte(signature))
+}
+
+// ValidateURL 校验 webhook 地址，只允许 http 和 https
+// 不允许内网时拒绝 localhost 和内网 IP，域名解析到内网的情况在投递时由 Dispatcher 拦截
+func ValidateURL(raw string, allowPrivate bool) error {
+	if len(raw) > model.MaxWebhookURLLen {
+		return fmt.Errorf("url too long: at most %d characters", model.MaxWebhookURLLen)
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return fmt.Errorf("invalid url: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return errors.New("url scheme must be http or https")
+	}
+	host := u.Hostname()
+	if host == "" {
+		return errors.New("url host is required")
+	}
+	if allowPrivate {
+		return nil
+	}
+	if strings.EqualFold(host, "localhost") || strings.HasSuffix(strings.ToLower(host), ".localhost") {
+		return ErrPrivateAddress
+	}
+	if ip := net.ParseIP(host); ip != nil && isPrivateIP(ip) {
+		return ErrPrivateAddress
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+package wsgateway
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+)
+
+// 浏览器可以选择两种帧格式，通过子协议声明，未声明时以第一条消息的类型为准:
+//   - 二进制帧 (zinx.binary): 与 TCP 完全相同的 zinx 封包，小端 DataLen(4) + MsgID(4) + Data，一条消息可以包含多个封包
+//   - 文本帧 (zinx.json): {"id": MsgID, "data": ...}，data 为 JSON 时原样作为消息体，为字符串时取字符串内容；
+//     消息体不是 JSON 时（如二进制数据）改用 {"id": MsgID, "data_b64": "..."}，内容为标准 base64，两个方向相同
+//
+// 网关不解压消息体：使用文本帧的浏览器握手时不应声明压缩算法，二进制帧则需自行处理 MsgID 上的压缩标志位
+const (
+	SubprotocolBinary = "zinx.binary"
+	SubprotocolJSON   = "zinx.json"
+)
+
+// zinxHeadLen zinx 封包头长度: DataLen + MsgID
+const zinxHeadLen = 8
+
+// frameMode 网关发给浏览器的消息格式
+type frameMode int32
+
+const (
+	modeUnknown frameMode = iota
+	modeBinary
+	modeJSON
+)
+
+// jsonFrame 文本帧格式
+type jsonFrame struct {
+	ID     *uint32         `json:"id"`
+	Data   json.RawMessage `json:"data,omitempty"`
+	Data64 []byte          `json:"data_b64,omitempty"` // encoding/json 按 base64 编解码
+}
+
+// encodeBrowserFrame 把浏览器发来的消息转换为 zinx 封包
+func encodeBrowserFrame(op byte, message []byte) ([]byte, error) {
+	if op == opBinary {
+		// 逐个检查封包长度，避免半个封包把 zinx 的拆包状态带偏
+		for rest := message; len(rest) > 0; {
+			if len(rest) < zinxHeadLen {
+				return nil, fmt.Errorf("incomplete zinx header")
+			}
+			dataLen := int(binary.LittleEndian.Uint32(rest))
+			if len(rest)-zinxHeadLen < dataLen {
+				return nil, fmt.Errorf("zinx frame declares %d bytes but only %d present", dataLen, len(rest)-zinxHeadLen)
+			}
+			rest = rest[zinxHeadLen+dataLen:]
+		}
+		if len(message) == 0 {
+			return nil, fmt.Errorf("empty binary message")
+		}
+		return message, nil
+	}
+
+	var frame jsonFrame
+	if err := json.Unmarshal(message, &frame); err != nil {
+		return nil, fmt.Errorf("invalid json frame: %w", err)
+	}
+	if frame.ID == nil {
+		return nil, fmt.Errorf("json frame missing id")
+	}
+	if frame.Data64 != nil {
+		if len(frame.Data) > 0 {
+			return nil, fmt.Errorf("json frame has both data and data_b64")
+		}
+		return packZinx(*frame.ID, frame.Data64), nil
+	}
+	var data []byte
+	if len(frame.Data) > 0 && frame.Data[0] == '"' {
+		var s string
+		if err := json.Unmarshal(frame.Data, &s); err != nil {
+			return nil, fmt.Errorf("invalid json frame data: %w", err)
+		}
+		data = []byte(s)
+	} else if string(frame.Data) != "null" {
+		data = frame.Data
+	}
+	return packZinx(*frame.ID, data), nil
+}
+
+// decodeServerFrame 把 zinx 发出的一个封包按浏览器选择的格式转换为 WebSocket 消息
+func decodeServerFrame(mode frameMode, msgID uint32, data []byte) (byte, []byte) {
+	if mode != modeJSON {
+		return opBinary, packZinx(msgID, data)
+	}
+	frame := jsonFrame{ID: &msgID}
+	if json.Valid(data) {
+		frame.Data = data
+	} else {
+		// 非 JSON 的消息体可能不是合法的 UTF-8，按字符串发送会被替换字符破坏；消息体为空时两个字段都省略
+		frame.Data64 = data
+	}
+	out, _ := json.Marshal(frame)
+	return opText, out
+}
+
+func packZinx(msgID uint32, data []byte) []byte {
+	buf := make([]byte, zinxHeadLen, zinxHeadLen+len(data))
+	binary.LittleEndian.PutUint32(buf, uint32(len(data)))
+	binary.LittleEndian.PutUint32(buf[4:], msgID)
+	return append(buf, data...)
+}
@@ -0,0 +1,103 @@
+package wsgateway
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+func TestJSONFrameRoundTrip(t *testing.T) {
+	cases := []struct {
+		name string
+		data []byte
+	}{
+		{"json object", []byte(`{"user_id":1,"name":"张三"}`)},
+		{"json array", []byte(`[1,2,3]`)},
+		{"plain text", []byte("hello")},
+		{"binary", []byte{0x28, 0xB5, 0x2F, 0xFD, 0x00, 0xFF, 0xFE}},
+		{"empty", nil},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			op, message := decodeServerFrame(modeJSON, 42, c.data)
+			if op != opText {
+				t.Fatalf("op = %d, want text", op)
+			}
+			if !json.Valid(message) {
+				t.Fatalf("frame is not valid json: %s", message)
+			}
+
+			packet, err := encodeBrowserFrame(opText, message)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := packZinx(42, c.data); !bytes.Equal(packet, want) {
+				t.Fatalf("round trip = %x, want %x", packet, want)
+			}
+		})
+	}
+}
+
+func TestJSONFrameEncodesNonJSONAsBase64(t *testing.T) {
+	_, message := decodeServerFrame(modeJSON, 7, []byte{0xFF, 0x00})
+	if want := `{"id":7,"data_b64":"/wA="}`; string(message) != want {
+		t.Fatalf("frame = %s, want %s", message, want)
+	}
+}
+
+func TestEncodeBrowserJSONFrame(t *testing.T) {
+	cases := []struct {
+		name    string
+		message string
+		want    []byte
+		wantErr bool
+	}{
+		{"json data", `{"id":1,"data":{"a":1}}`, packZinx(1, []byte(`{"a":1}`)), false},
+		{"string data", `{"id":2,"data":"ping"}`, packZinx(2, []byte("ping")), false},
+		{"base64 data", `{"id":3,"data_b64":"AAE="}`, packZinx(3, []byte{0, 1}), false},
+		{"no data", `{"id":4}`, packZinx(4, nil), false},
+		{"missing id", `{"data":"x"}`, nil, true},
+		{"both data fields", `{"id":5,"data":"x","data_b64":"AAE="}`, nil, true},
+		{"invalid base64", `{"id":6,"data_b64":"!!"}`, nil, true},
+		{"not json", `hello`, nil, true},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			packet, err := encodeBrowserFrame(opText, []byte(c.message))
+			if c.wantErr {
+				if err == nil {
+					t.Fatalf("expected error, got %x", packet)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(packet, c.want) {
+				t.Fatalf("packet = %x, want %x", packet, c.want)
+			}
+		})
+	}
+}
+
+func TestBinaryFramePassesThroughZinxPackets(t *testing.T) {
+	message := append(packZinx(1, []byte("a")), packZinx(2, nil)...)
+	_, out := decodeServerFrame(modeBinary, 1, []byte("a"))
+	if !bytes.Equal(out, packZinx(1, []byte("a"))) {
+		t.Fatalf("binary frame = %x", out)
+	}
+
+	packet, err := encodeBrowserFrame(opBinary, message)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(packet, message) {
+		t.Fatalf("packet = %x, want %x", packet, message)
+	}
+
+	for _, bad := range [][]byte{nil, message[:5], message[:len(message)-1]} {
+		if _, err := encodeBrowserFrame(opBinary, bad); err == nil {
+			t.Fatalf("accepted malformed binary message %x", bad)
+		}
+	}
+}
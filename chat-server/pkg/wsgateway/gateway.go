@@ -0,0 +1,289 @@
+package wsgateway
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Xaytick/chat-zinx/chat-server/conf"
+)
+
+// upstreamDialTimeout 连接本机 zinx 端口的超时时间
+const upstreamDialTimeout = 5 * time.Second
+
+// Peer 经过网关的浏览器信息
+type Peer struct {
+	RemoteAddr net.Addr // 浏览器的真实地址
+	Origin     string
+	UserAgent  string
+}
+
+// Gateway WebSocket 网关，作为 HTTP 服务的一个路由，把每个 WebSocket 连接转发为一条到 zinx 的 TCP 连接
+// 这样浏览器用户与 TCP 客户端经过同一套路由、连接属性和在线状态，zinx 侧无需区分来源
+type Gateway struct {
+	cfg     *conf.WebSocketConfig
+	origins map[string]bool
+	peers   sync.Map // 网关连接 zinx 使用的本地地址 -> *Peer
+
+	mu      sync.Mutex // 保护 conns 和 stopped
+	conns   map[net.Conn]struct{}
+	stopped bool
+	wg      sync.WaitGroup
+}
+
+// NewGateway 创建 WebSocket 网关，通过 HTTP 服务的 Handle 注册到 cfg.Path
+func NewGateway(cfg *conf.WebSocketConfig) *Gateway {
+	origins := make(map[string]bool, len(cfg.AllowedOrigins))
+	for _, origin := range cfg.AllowedOrigins {
+		origins[strings.ToLower(strings.TrimRight(origin, "/"))] = true
+	}
+	return &Gateway{
+		cfg:     cfg,
+		origins: origins,
+		conns:   make(map[net.Conn]struct{}),
+	}
+}
+
+// ServeHTTP 完成 WebSocket 握手后在当前协程内转发，直到任意一端断开
+func (g *Gateway) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	origin := r.Header.Get("Origin")
+	if !g.checkOrigin(origin, r.Host) {
+		fmt.Printf("[WS] Rejected origin %q from %s\n", origin, r.RemoteAddr)
+		http.Error(w, "origin not allowed", http.StatusForbidden)
+		return
+	}
+
+	mode := modeUnknown
+	subprotocol := selectSubprotocol(r.Header)
+	switch subprotocol {
+	case SubprotocolBinary:
+		mode = modeBinary
+	case SubprotocolJSON:
+		mode = modeJSON
+	}
+
+	conn, br, err := upgrade(w, r, subprotocol)
+	if err != nil {
+		fmt.Printf("[WS] Handshake with %s failed: %v\n", r.RemoteAddr, err)
+		return
+	}
+	if !g.admit(conn) {
+		_ = conn.Close()
+		return
+	}
+	defer g.wg.Done()
+	defer g.untrack(conn)
+
+	ws := &wsConn{
+		conn:           conn,
+		br:             br,
+		maxMessageSize: g.cfg.MaxMessageSize,
+		writeTimeout:   time.Duration(g.cfg.WriteTimeout) * time.Second,
+	}
+
+	upstream, err := net.DialTimeout("tcp", g.cfg.Upstream, upstreamDialTimeout)
+	if err != nil {
+		fmt.Printf("[WS] Failed to connect upstream %s: %v\n", g.cfg.Upstream, err)
+		_ = ws.writeClose(closeInternalError, "upstream unavailable")
+		return
+	}
+	if !g.track(upstream) {
+		_ = upstream.Close()
+		_ = ws.writeClose(closeGoingAway, "server shutting down")
+		return
+	}
+	defer g.untrack(upstream)
+
+	// 在开始转发前登记，zinx 收到第一条消息时一定能查到真实地址
+	key := upstream.LocalAddr().String()
+	g.peers.Store(key, &Peer{RemoteAddr: conn.RemoteAddr(), Origin: origin, UserAgent: r.UserAgent()})
+	defer g.peers.Delete(key)
+
+	g.bridge(ws, upstream, mode)
+}
+
+// bridge 双向转发，浏览器未通过子协议声明格式时，以它发来的第一条消息的类型决定回复的格式
+func (g *Gateway) bridge(ws *wsConn, upstream net.Conn, initial frameMode) {
+	var mode atomic.Int32
+	mode.Store(int32(initial))
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		err := g.pumpUpstream(ws, upstream, &mode)
+		if err != nil && !errors.Is(err, net.ErrClosed) {
+			fmt.Printf("[WS] Upstream of %s closed: %v\n", ws.conn.RemoteAddr(), err)
+		}
+		// zinx 关闭了连接（如心跳超时、被踢下线），通知浏览器后关闭
+		_ = ws.writeClose(closeGoingAway, "connection closed by server")
+		_ = ws.conn.Close()
+	}()
+
+	stopPing := make(chan struct{})
+	go g.pingLoop(ws, stopPing)
+
+	err := g.pumpBrowser(ws, upstream, &mode)
+	var ce *closeError
+	if errors.As(err, &ce) {
+		fmt.Printf("[WS] Closing %s: %v\n", ws.conn.RemoteAddr(), err)
+		_ = ws.writeClose(ce.code, ce.reason)
+	}
+	close(stopPing)
+	_ = upstream.Close()
+	<-done
+}
+
+// pumpBrowser 把浏览器的消息转换为 zinx 封包写给 zinx
+func (g *Gateway) pumpBrowser(ws *wsConn, upstream net.Conn, mode *atomic.Int32) error {
+	for {
+		op, message, err := ws.readMessage()
+		if err != nil {
+			return err
+		}
+		want := modeBinary
+		if op == opText {
+			want = modeJSON
+		}
+		mode.CompareAndSwap(int32(modeUnknown), int32(want))
+
+		packet, err := encodeBrowserFrame(op, message)
+		if err != nil {
+			return &closeError{closeUnsupportedData, err.Error()}
+		}
+		if _, err := upstream.Write(packet); err != nil {
+			return err
+		}
+	}
+}
+
+// pumpUpstream 按 zinx 封包拆分 zinx 的输出，每个封包作为一条 WebSocket 消息发给浏览器
+func (g *Gateway) pumpUpstream(ws *wsConn, upstream net.Conn, mode *atomic.Int32) error {
+	reader := bufio.NewReader(upstream)
+	var head [zinxHeadLen]byte
+	for {
+		if _, err := io.ReadFull(reader, head[:]); err != nil {
+			return err
+		}
+		dataLen := binary.LittleEndian.Uint32(head[:4])
+		msgID := binary.LittleEndian.Uint32(head[4:])
+		data := make([]byte, dataLen)
+		if _, err := io.ReadFull(reader, data); err != nil {
+			return err
+		}
+		op, message := decodeServerFrame(frameMode(mode.Load()), msgID, data)
+		if err := ws.writeFrame(op, message); err != nil {
+			return err
+		}
+	}
+}
+
+// pingLoop 定期发送 WebSocket ping，防止反向代理断开空闲连接
+// 聊天协议自身的心跳仍需浏览器发送 MsgIDPing，否则会被 zinx 按心跳超时断开
+func (g *Gateway) pingLoop(ws *wsConn, stop <-chan struct{}) {
+	ticker := time.NewTicker(time.Duration(g.cfg.PingInterval) * time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := ws.writeFrame(opPing, nil); err != nil {
+				return
+			}
+		case <-stop:
+			return
+		}
+	}
+}
+
+// Stop 关闭所有 WebSocket 连接及其对应的 zinx 连接，并等待转发协程退出
+// 握手前的 HTTP 请求由 HTTP 服务自己关闭，接管后的连接不在它的管理范围内
+func (g *Gateway) Stop() {
+	g.mu.Lock()
+	if g.stopped {
+		g.mu.Unlock()
+		return
+	}
+	g.stopped = true
+	for conn := range g.conns {
+		_ = conn.Close()
+	}
+	g.mu.Unlock()
+
+	g.wg.Wait()
+	fmt.Println("[WS] Gateway stopped")
+}
+
+// LookupPeer 根据 zinx 连接的对端地址查找浏览器信息，不是经过网关的连接时返回 false
+func (g *Gateway) LookupPeer(upstreamAddr string) (*Peer, bool) {
+	peer, ok := g.peers.Load(upstreamAddr)
+	if !ok {
+		return nil, false
+	}
+	return peer.(*Peer), true
+}
+
+// checkOrigin 未配置 AllowedOrigins 时只允许同源，没有 Origin 头的非浏览器客户端总是允许
+func (g *Gateway) checkOrigin(origin, host string) bool {
+	if origin == "" || g.origins["*"] {
+		return true
+	}
+	if len(g.origins) > 0 {
+		return g.origins[strings.ToLower(strings.TrimRight(origin, "/"))]
+	}
+	u, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+	return strings.EqualFold(u.Host, host)
+}
+
+// selectSubprotocol 从浏览器提供的子协议中选择网关支持的第一个
+func selectSubprotocol(header http.Header) string {
+	for _, value := range header.Values("Sec-WebSocket-Protocol") {
+		for _, proto := range strings.Split(value, ",") {
+			switch proto = strings.TrimSpace(proto); proto {
+			case SubprotocolBinary, SubprotocolJSON:
+				return proto
+			}
+		}
+	}
+	return ""
+}
+
+// admit 登记 WebSocket 连接并计入等待组，已停止时返回 false
+func (g *Gateway) admit(conn net.Conn) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.stopped {
+		return false
+	}
+	g.conns[conn] = struct{}{}
+	g.wg.Add(1)
+	return true
+}
+
+// track 记录连接以便 Stop 时关闭，已停止时返回 false
+func (g *Gateway) track(conn net.Conn) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.stopped {
+		return false
+	}
+	g.conns[conn] = struct{}{}
+	return true
+}
+
+func (g *Gateway) untrack(conn net.Conn) {
+	g.mu.Lock()
+	delete(g.conns, conn)
+	g.mu.Unlock()
+	_ = conn.Close()
+}
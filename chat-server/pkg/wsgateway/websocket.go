@@ -0,0 +1,269 @@
+package wsgateway
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// 只实现网关需要的 RFC 6455 服务端部分：握手、分片重组、控制帧，不支持扩展（如 permessage-deflate）
+const websocketGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+const (
+	opContinuation byte = 0x0
+	opText         byte = 0x1
+	opBinary       byte = 0x2
+	opClose        byte = 0x8
+	opPing         byte = 0x9
+	opPong         byte = 0xA
+)
+
+// 关闭码，见 RFC 6455 7.4.1
+const (
+	closeNormal          = 1000
+	closeGoingAway       = 1001
+	closeProtocolError   = 1002
+	closeUnsupportedData = 1003
+	closeInvalidPayload  = 1007
+	closeMessageTooBig   = 1009
+	closeInternalError   = 1011
+)
+
+// maxControlPayload 控制帧负载的最大长度
+const maxControlPayload = 125
+
+// closeError 需要以指定关闭码断开连接的错误
+type closeError struct {
+	code   int
+	reason string
+}
+
+func (e *closeError) Error() string {
+	return fmt.Sprintf("websocket: %s (close code %d)", e.reason, e.code)
+}
+
+// errPeerClosed 浏览器主动发送了关闭帧
+var errPeerClosed = errors.New("websocket: closed by peer")
+
+// upgrade 校验握手请求并接管底层连接，失败时已向浏览器写入 HTTP 错误响应
+// subprotocol 为空表示不回应 Sec-WebSocket-Protocol
+func upgrade(w http.ResponseWriter, r *http.Request, subprotocol string) (net.Conn, *bufio.Reader, error) {
+	if !headerContainsToken(r.Header, "Connection", "upgrade") || !headerContainsToken(r.Header, "Upgrade", "websocket") {
+		http.Error(w, "websocket upgrade required", http.StatusBadRequest)
+		return nil, nil, errors.New("websocket: not an upgrade request")
+	}
+	if r.Header.Get("Sec-WebSocket-Version") != "13" {
+		w.Header().Set("Sec-WebSocket-Version", "13")
+		http.Error(w, "unsupported websocket version", http.StatusUpgradeRequired)
+		return nil, nil, errors.New("websocket: unsupported version")
+	}
+	key := r.Header.Get("Sec-WebSocket-Key")
+	if decoded, err := base64.StdEncoding.DecodeString(key); err != nil || len(decoded) != 16 {
+		http.Error(w, "invalid Sec-WebSocket-Key", http.StatusBadRequest)
+		return nil, nil, errors.New("websocket: invalid key")
+	}
+
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		http.Error(w, "websocket not supported", http.StatusInternalServerError)
+		return nil, nil, errors.New("websocket: response does not support hijacking")
+	}
+	conn, rw, err := hijacker.Hijack()
+	if err != nil {
+		return nil, nil, fmt.Errorf("websocket: hijack failed: %w", err)
+	}
+	// 接管后的连接仍带着 HTTP 服务的读写超时，长连接需要清除
+	_ = conn.SetDeadline(time.Time{})
+
+	var resp strings.Builder
+	resp.WriteString("HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n")
+	resp.WriteString("Sec-WebSocket-Accept: " + acceptKey(key) + "\r\n")
+	if subprotocol != "" {
+		resp.WriteString("Sec-WebSocket-Protocol: " + subprotocol + "\r\n")
+	}
+	resp.WriteString("\r\n")
+	if _, err := conn.Write([]byte(resp.String())); err != nil {
+		_ = conn.Close()
+		return nil, nil, fmt.Errorf("websocket: failed to write handshake: %w", err)
+	}
+	return conn, rw.Reader, nil
+}
+
+func acceptKey(key string) string {
+	sum := sha1.Sum([]byte(key + websocketGUID))
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// headerContainsToken 判断逗号分隔的请求头中是否包含 token，不区分大小写
+func headerContainsToken(header http.Header, name, token string) bool {
+	for _, value := range header.Values(name) {
+		for _, part := range strings.Split(value, ",") {
+			if strings.EqualFold(strings.TrimSpace(part), token) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// wsConn 握手完成后的 WebSocket 连接，读只在一个协程中进行，写由 wmu 串行化
+type wsConn struct {
+	conn           net.Conn
+	br             *bufio.Reader
+	maxMessageSize int
+	writeTimeout   time.Duration
+
+	wmu       sync.Mutex
+	closeOnce sync.Once // 关闭帧只能发送一次
+}
+
+// readMessage 读取一条完整的数据消息，期间收到的 ping 直接回复 pong
+func (c *wsConn) readMessage() (byte, []byte, error) {
+	var (
+		messageOp byte
+		message   []byte
+	)
+	for {
+		fin, op, payload, err := c.readFrame(len(message))
+		if err != nil {
+			return 0, nil, err
+		}
+
+		switch op {
+		case opPing:
+			if err := c.writeFrame(opPong, payload); err != nil {
+				return 0, nil, err
+			}
+			continue
+		case opPong:
+			continue
+		case opClose:
+			code := closeNormal
+			if len(payload) >= 2 {
+				code = int(binary.BigEndian.Uint16(payload))
+			}
+			_ = c.writeClose(code, "")
+			return 0, nil, errPeerClosed
+		case opContinuation:
+			if messageOp == 0 {
+				return 0, nil, &closeError{closeProtocolError, "unexpected continuation frame"}
+			}
+		case opText, opBinary:
+			if messageOp != 0 {
+				return 0, nil, &closeError{closeProtocolError, "expected continuation frame"}
+			}
+			messageOp = op
+		default:
+			return 0, nil, &closeError{closeProtocolError, fmt.Sprintf("unknown opcode %d", op)}
+		}
+
+		message = append(message, payload...)
+		if fin {
+			if messageOp == opText && !utf8.Valid(message) {
+				return 0, nil, &closeError{closeInvalidPayload, "text message is not valid UTF-8"}
+			}
+			return messageOp, message, nil
+		}
+	}
+}
+
+// readFrame 读取一个帧并去掉掩码，buffered 为当前消息已累计的长度，用于检查消息大小
+func (c *wsConn) readFrame(buffered int) (bool, byte, []byte, error) {
+	var head [2]byte
+	if _, err := io.ReadFull(c.br, head[:]); err != nil {
+		return false, 0, nil, err
+	}
+	fin := head[0]&0x80 != 0
+	op := head[0] & 0x0F
+	if head[0]&0x70 != 0 {
+		return false, 0, nil, &closeError{closeProtocolError, "reserved bits set without extension"}
+	}
+	if head[1]&0x80 == 0 {
+		return false, 0, nil, &closeError{closeProtocolError, "client frame is not masked"}
+	}
+
+	length := uint64(head[1] & 0x7F)
+	switch length {
+	case 126:
+		var ext [2]byte
+		if _, err := io.ReadFull(c.br, ext[:]); err != nil {
+			return false, 0, nil, err
+		}
+		length = uint64(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		if _, err := io.ReadFull(c.br, ext[:]); err != nil {
+			return false, 0, nil, err
+		}
+		length = binary.BigEndian.Uint64(ext[:])
+	}
+
+	if op >= opClose {
+		if !fin || length > maxControlPayload {
+			return false, 0, nil, &closeError{closeProtocolError, "invalid control frame"}
+		}
+	} else if length > uint64(c.maxMessageSize-buffered) {
+		return false, 0, nil, &closeError{closeMessageTooBig, "message too big"}
+	}
+
+	var mask [4]byte
+	if _, err := io.ReadFull(c.br, mask[:]); err != nil {
+		return false, 0, nil, err
+	}
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(c.br, payload); err != nil {
+		return false, 0, nil, err
+	}
+	for i := range payload {
+		payload[i] ^= mask[i%4]
+	}
+	return fin, op, payload, nil
+}
+
+// writeFrame 写入一个不分片、不加掩码的帧
+func (c *wsConn) writeFrame(op byte, payload []byte) error {
+	header := make([]byte, 2, 10)
+	header[0] = 0x80 | op
+	switch n := len(payload); {
+	case n <= 125:
+		header[1] = byte(n)
+	case n <= 0xFFFF:
+		header[1] = 126
+		header = binary.BigEndian.AppendUint16(header, uint16(n))
+	default:
+		header[1] = 127
+		header = binary.BigEndian.AppendUint64(header, uint64(n))
+	}
+
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+	_ = c.conn.SetWriteDeadline(time.Now().Add(c.writeTimeout))
+	if _, err := c.conn.Write(header); err != nil {
+		return err
+	}
+	_, err := c.conn.Write(payload)
+	return err
+}
+
+// writeClose 发送关闭帧，reason 超长时截断，重复调用时只发送第一次
+func (c *wsConn) writeClose(code int, reason string) error {
+	var err error
+	c.closeOnce.Do(func() {
+		if len(reason) > maxControlPayload-2 {
+			reason = reason[:maxControlPayload-2]
+		}
+		payload := binary.BigEndian.AppendUint16(nil, uint16(code))
+		err = c.writeFrame(opClose, append(payload, reason...))
+	})
+	return err
+}
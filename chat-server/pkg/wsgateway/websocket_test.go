@@ -0,0 +1,253 @@
+package wsgateway
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"testing"
+	"time"
+)
+
+type testFrame struct {
+	fin     bool
+	op      byte
+	payload []byte
+}
+
+// maskedFrame 按浏览器的方式编码一个带掩码的帧
+func maskedFrame(fin bool, op byte, payload []byte) []byte {
+	b0 := op
+	if fin {
+		b0 |= 0x80
+	}
+	buf := []byte{b0}
+	switch n := len(payload); {
+	case n <= 125:
+		buf = append(buf, 0x80|byte(n))
+	case n <= 0xFFFF:
+		buf = append(buf, 0x80|126)
+		buf = binary.BigEndian.AppendUint16(buf, uint16(n))
+	default:
+		buf = append(buf, 0x80|127)
+		buf = binary.BigEndian.AppendUint64(buf, uint64(n))
+	}
+	mask := [4]byte{0x12, 0x34, 0x56, 0x78}
+	buf = append(buf, mask[:]...)
+	for i, b := range payload {
+		buf = append(buf, b^mask[i%4])
+	}
+	return buf
+}
+
+// readServerFrame 读取网关写出的一个帧，服务端的帧不能带掩码
+func readServerFrame(r io.Reader) (testFrame, error) {
+	var head [2]byte
+	if _, err := io.ReadFull(r, head[:]); err != nil {
+		return testFrame{}, err
+	}
+	if head[1]&0x80 != 0 {
+		return testFrame{}, errors.New("server frame is masked")
+	}
+	length := uint64(head[1] & 0x7F)
+	switch length {
+	case 126:
+		var ext [2]byte
+		if _, err := io.ReadFull(r, ext[:]); err != nil {
+			return testFrame{}, err
+		}
+		length = uint64(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		if _, err := io.ReadFull(r, ext[:]); err != nil {
+			return testFrame{}, err
+		}
+		length = binary.BigEndian.Uint64(ext[:])
+	}
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return testFrame{}, err
+	}
+	return testFrame{fin: head[0]&0x80 != 0, op: head[0] & 0x0F, payload: payload}, nil
+}
+
+// newTestConn 返回服务端的 wsConn，client 写入的数据由 wsConn 读取，wsConn 写出的帧从 frames 读出
+func newTestConn(t *testing.T, maxMessageSize int) (*wsConn, func(...[]byte), <-chan testFrame) {
+	t.Helper()
+	server, client := net.Pipe()
+	t.Cleanup(func() {
+		_ = server.Close()
+		_ = client.Close()
+	})
+
+	frames := make(chan testFrame, 16)
+	go func() {
+		defer close(frames)
+		for {
+			f, err := readServerFrame(client)
+			if err != nil {
+				return
+			}
+			frames <- f
+		}
+	}()
+	send := func(data ...[]byte) {
+		go func() { _, _ = client.Write(bytes.Join(data, nil)) }()
+	}
+	ws := &wsConn{conn: server, br: bufio.NewReader(server), maxMessageSize: maxMessageSize, writeTimeout: time.Second}
+	return ws, send, frames
+}
+
+func nextFrame(t *testing.T, frames <-chan testFrame) testFrame {
+	t.Helper()
+	select {
+	case f, ok := <-frames:
+		if !ok {
+			t.Fatal("connection closed before frame arrived")
+		}
+		return f
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for frame")
+	}
+	return testFrame{}
+}
+
+func expectCloseError(t *testing.T, err error, code int) {
+	t.Helper()
+	var ce *closeError
+	if !errors.As(err, &ce) {
+		t.Fatalf("err = %v, want close error %d", err, code)
+	}
+	if ce.code != code {
+		t.Fatalf("close code = %d, want %d", ce.code, code)
+	}
+}
+
+func TestReadMessageUnmasksAllLengthEncodings(t *testing.T) {
+	for _, size := range []int{0, 125, 126, 0xFFFF, 0x10000} {
+		ws, send, _ := newTestConn(t, 1<<20)
+		payload := bytes.Repeat([]byte{0xA5}, size)
+		send(maskedFrame(true, opBinary, payload))
+
+		op, message, err := ws.readMessage()
+		if err != nil {
+			t.Fatalf("size %d: %v", size, err)
+		}
+		if op != opBinary || !bytes.Equal(message, payload) {
+			t.Fatalf("size %d: got op %d and %d bytes", size, op, len(message))
+		}
+	}
+}
+
+func TestReadMessageRejectsUnmaskedFrame(t *testing.T) {
+	ws, send, _ := newTestConn(t, 1024)
+	send([]byte{0x80 | opText, 2, 'h', 'i'})
+	_, _, err := ws.readMessage()
+	expectCloseError(t, err, closeProtocolError)
+}
+
+func TestReadMessageReassemblesFragmentsAroundPing(t *testing.T) {
+	ws, send, frames := newTestConn(t, 1024)
+	send(
+		maskedFrame(false, opText, []byte("hel")),
+		maskedFrame(true, opPing, []byte("p")),
+		maskedFrame(false, opContinuation, []byte("lo ")),
+		maskedFrame(true, opContinuation, []byte("世界")),
+	)
+
+	op, message, err := ws.readMessage()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if op != opText || string(message) != "hello 世界" {
+		t.Fatalf("got op %d message %q", op, message)
+	}
+	pong := nextFrame(t, frames)
+	if !pong.fin || pong.op != opPong || string(pong.payload) != "p" {
+		t.Fatalf("unexpected reply to ping: %+v", pong)
+	}
+}
+
+func TestReadMessageRejectsInvalidFragmentation(t *testing.T) {
+	cases := []struct {
+		name   string
+		frames [][]byte
+		code   int
+	}{
+		{"continuation without start", [][]byte{maskedFrame(true, opContinuation, []byte("x"))}, closeProtocolError},
+		{"new message inside fragmented one", [][]byte{
+			maskedFrame(false, opText, []byte("a")),
+			maskedFrame(true, opBinary, []byte("b")),
+		}, closeProtocolError},
+		{"fragmented control frame", [][]byte{maskedFrame(false, opPing, nil)}, closeProtocolError},
+		{"oversized control frame", [][]byte{maskedFrame(true, opPing, make([]byte, maxControlPayload+1))}, closeProtocolError},
+		{"reserved bits", [][]byte{{0x80 | 0x40 | opText, 0x80, 0, 0, 0, 0}}, closeProtocolError},
+		{"unknown opcode", [][]byte{maskedFrame(true, 0x3, nil)}, closeProtocolError},
+		{"fragments exceed limit", [][]byte{
+			maskedFrame(false, opBinary, make([]byte, 10)),
+			maskedFrame(true, opContinuation, make([]byte, 7)),
+		}, closeMessageTooBig},
+		{"invalid utf-8 across fragments", [][]byte{
+			maskedFrame(false, opText, []byte{0xE4, 0xB8}),
+			maskedFrame(true, opContinuation, []byte{0x41}),
+		}, closeInvalidPayload},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			ws, send, _ := newTestConn(t, 16)
+			send(c.frames...)
+			_, _, err := ws.readMessage()
+			expectCloseError(t, err, c.code)
+		})
+	}
+}
+
+func TestReadMessageEchoesPeerClose(t *testing.T) {
+	ws, send, frames := newTestConn(t, 1024)
+	send(maskedFrame(true, opClose, binary.BigEndian.AppendUint16(nil, closeGoingAway)))
+
+	if _, _, err := ws.readMessage(); !errors.Is(err, errPeerClosed) {
+		t.Fatalf("err = %v, want errPeerClosed", err)
+	}
+	f := nextFrame(t, frames)
+	if f.op != opClose || len(f.payload) < 2 || binary.BigEndian.Uint16(f.payload) != closeGoingAway {
+		t.Fatalf("unexpected close reply: %+v", f)
+	}
+
+	// 关闭帧只发送一次
+	if err := ws.writeClose(closeNormal, "again"); err != nil {
+		t.Fatal(err)
+	}
+	if err := ws.writeFrame(opText, []byte("after")); err != nil {
+		t.Fatal(err)
+	}
+	if f := nextFrame(t, frames); f.op != opText {
+		t.Fatalf("second close frame was sent: %+v", f)
+	}
+}
+
+func TestWriteCloseTruncatesReason(t *testing.T) {
+	ws, _, frames := newTestConn(t, 1024)
+	go func() { _ = ws.writeClose(closeInternalError, string(bytes.Repeat([]byte("x"), 300))) }()
+	f := nextFrame(t, frames)
+	if f.op != opClose || len(f.payload) != maxControlPayload {
+		t.Fatalf("close frame op %d with %d bytes", f.op, len(f.payload))
+	}
+	if code := binary.BigEndian.Uint16(f.payload); code != closeInternalError {
+		t.Fatalf("close code = %d", code)
+	}
+}
+
+func TestWriteFrameLengthEncodings(t *testing.T) {
+	for _, size := range []int{0, 125, 126, 0xFFFF, 0x10000} {
+		ws, _, frames := newTestConn(t, 1024)
+		payload := bytes.Repeat([]byte{0x5A}, size)
+		go func() { _ = ws.writeFrame(opBinary, payload) }()
+		f := nextFrame(t, frames)
+		if !f.fin || f.op != opBinary || !bytes.Equal(f.payload, payload) {
+			t.Fatalf("size %d: got fin %v op %d and %d bytes", size, f.fin, f.op, len(f.payload))
+		}
+	}
+}
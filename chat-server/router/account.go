@@ -0,0 +1,257 @@
+package router
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/Xaytick/chat-zinx/chat-server/global"
+	"github.com/Xaytick/chat-zinx/chat-server/pkg/model"
+	"github.com/Xaytick/chat-zinx/chat-server/pkg/protocol"
+	"github.com/Xaytick/chat-zinx/chat-server/pkg/service"
+	"github.com/Xaytick/zinx/ziface"
+	"github.com/Xaytick/zinx/znet"
+)
+
+// --- ChangePasswordRouter 修改密码 --- //
+type ChangePasswordRouter struct {
+	znet.BaseRouter
+}
+
+func (r *ChangePasswordRouter) Handle(request ziface.IRequest) {
+	userID, err := request.GetConnection().GetProperty("userID")
+	if err != nil || userID == nil {
+		fmt.Println("ChangePasswordRouter: User not authenticated")
+		_ = request.GetConnection().SendMsg(protocol.MsgIDChangePasswordResp, []byte(`{"error":"用户未登录"}`))
+		return
+	}
+	uid := userID.(uint)
+
+	var req model.ChangePasswordReq
+	if err := json.Unmarshal(request.GetData(), &req); err != nil {
+		fmt.Println("ChangePasswordRouter: Invalid request data format - ", err)
+		_ = request.GetConnection().SendMsg(protocol.MsgIDChangePasswordResp, []byte(`{"error":"请求数据格式错误"}`))
+		return
+	}
+
+	if err := global.UserService.ChangePassword(uid, req.OldPassword, req.NewPassword); err != nil {
+		fmt.Printf("ChangePasswordRouter: User %d failed to change password - %s\n", uid, err.Error())
+		errMsg := err.Error()
+		if errors.Is(err, service.ErrPasswordIncorrect) {
+			errMsg = "旧密码错误"
+		}
+		respData, _ := json.Marshal(map[string]string{"error": fmt.Sprintf("修改密码失败: %s", errMsg)})
+		_ = request.GetConnection().SendMsg(protocol.MsgIDChangePasswordResp, respData)
+		return
+	}
+
+	revoked := revokeSessions(uid, request.GetConnection(), "密码已修改，请重新登录")
+	respData, _ := json.Marshal(map[string]string{"message": fmt.Sprintf("密码已修改，%d 个其他会话已下线", revoked)})
+	_ = request.GetConnection().SendMsg(protocol.MsgIDChangePasswordResp, respData)
+	fmt.Printf("User %d changed password, %d other sessions revoked\n", uid, revoked)
+}
+
+// --- RequestPasswordResetRouter 申请重置密码，无需登录 --- //
+type RequestPasswordResetRouter struct {
+	znet.BaseRouter
+}
+
+func (r *RequestPasswordResetRouter) Handle(request ziface.IRequest) {
+	var req model.RequestPasswordResetReq
+	if err := json.Unmarshal(request.GetData(), &req); err != nil {
+		fmt.Println("RequestPasswordResetRouter: Invalid request data format - ", err)
+		_ = request.GetConnection().SendMsg(protocol.MsgIDRequestPasswordResetResp, []byte(`{"error":"请求数据格式错误"}`))
+		return
+	}
+
+	if err := global.UserService.RequestPasswordReset(req.Account, clientIP(request.GetConnection())); err != nil {
+		fmt.Printf("RequestPasswordResetRouter: Failed to request password reset for %s - %s\n", req.Account, err.Error())
+		errMsg := err.Error()
+		var throttled *service.ThrottledError
+		if errors.As(err, &throttled) {
+			errMsg = fmt.Sprintf("申请过于频繁，请 %d 秒后再试", int(math.Ceil(throttled.RetryAfter.Seconds())))
+		}
+		respData, _ := json.Marshal(map[string]string{"error": fmt.Sprintf("申请重置密码失败: %s", errMsg)})
+		_ = request.GetConnection().SendMsg(protocol.MsgIDRequestPasswordResetResp, respData)
+		return
+	}
+
+	// 无论账号是否存在都返回相同的提示，避免泄露账号信息
+	_ = request.GetConnection().SendMsg(protocol.MsgIDRequestPasswordResetResp,
+		[]byte(`{"message":"如果该账号已绑定邮箱，重置令牌已发送到对应邮箱"}`))
+}
+
+// --- ResetPasswordRouter 使用令牌重置密码，无需登录 --- //
+type ResetPasswordRouter struct {
+	znet.BaseRouter
+}
+
+func (r *ResetPasswordRouter) Handle(request ziface.IRequest) {
+	var req model.ResetPasswordReq
+	if err := json.Unmarshal(request.GetData(), &req); err != nil {
+		fmt.Println("ResetPasswordRouter: Invalid request data format - ", err)
+		_ = request.GetConnection().SendMsg(protocol.MsgIDResetPasswordResp, []byte(`{"error":"请求数据格式错误"}`))
+		return
+	}
+
+	uid, err := global.UserService.ResetPassword(req.Token, req.NewPassword)
+	if err != nil {
+		fmt.Printf("ResetPasswordRouter: Failed to reset password - %s\n", err.Error())
+		errMsg := err.Error()
+		if errors.Is(err, service.ErrInvalidToken) {
+			errMsg = "令牌无效或已过期"
+		}
+		respData, _ := json.Marshal(map[string]string{"error": fmt.Sprintf("重置密码失败: %s", errMsg)})
+		_ = request.GetConnection().SendMsg(protocol.MsgIDResetPasswordResp, respData)
+		return
+	}
+
+	// 重置密码通常意味着账号可能已泄露，下线该用户的所有会话（当前连接若属于该用户则保留）
+	revoked := revokeSessions(uid, request.GetConnection(), "密码已修改，请重新登录")
+	_ = request.GetConnection().SendMsg(protocol.MsgIDResetPasswordResp, []byte(`{"message":"密码已重置，请使用新密码登录"}`))
+	fmt.Printf("User %d reset password, %d sessions revoked\n", uid, revoked)
+}
+
+// --- SendVerifyEmailRouter 重新发送邮箱验证邮件 --- //
+type SendVerifyEmailRouter struct {
+	znet.BaseRouter
+}
+
+func (r *SendVerifyEmailRouter) Handle(request ziface.IRequest) {
+	userID, err := request.GetConnection().GetProperty("userID")
+	if err != nil || userID == nil {
+		fmt.Println("SendVerifyEmailRouter: User not authenticated")
+		_ = request.GetConnection().SendMsg(protocol.MsgIDSendVerifyEmailResp, []byte(`{"error":"用户未登录"}`))
+		return
+	}
+	uid := userID.(uint)
+
+	if err := global.UserService.SendEmailVerification(uid); err != nil {
+		fmt.Printf("SendVerifyEmailRouter: User %d failed to send verification email - %s\n", uid, err.Error())
+		respData, _ := json.Marshal(map[string]string{"error": fmt.Sprintf("发送验证邮件失败: %s", err.Error())})
+		_ = request.GetConnection().SendMsg(protocol.MsgIDSendVerifyEmailResp, respData)
+		return
+	}
+
+	_ = request.GetConnection().SendMsg(protocol.MsgIDSendVerifyEmailResp, []byte(`{"message":"验证邮件已发送"}`))
+}
+
+// --- VerifyEmailRouter 验证邮箱，无需登录 --- //
+type VerifyEmailRouter struct {
+	znet.BaseRouter
+}
+
+func (r *VerifyEmailRouter) Handle(request ziface.IRequest) {
+	var req model.VerifyEmailReq
+	if err := json.Unmarshal(request.GetData(), &req); err != nil {
+		fmt.Println("VerifyEmailRouter: Invalid request data format - ", err)
+		_ = request.GetConnection().SendMsg(protocol.MsgIDVerifyEmailResp, []byte(`{"error":"请求数据格式错误"}`))
+		return
+	}
+
+	uid, err := global.UserService.VerifyEmail(req.Token)
+	if err != nil {
+		fmt.Printf("VerifyEmailRouter: Failed to verify email - %s\n", err.Error())
+		errMsg := err.Error()
+		if errors.Is(err, service.ErrInvalidToken) {
+			errMsg = "令牌无效或已过期"
+		}
+		respData, _ := json.Marshal(map[string]string{"error": fmt.Sprintf("验证邮箱失败: %s", errMsg)})
+		_ = request.GetConnection().SendMsg(protocol.MsgIDVerifyEmailResp, respData)
+		return
+	}
+
+	_ = request.GetConnection().SendMsg(protocol.MsgIDVerifyEmailResp, []byte(`{"message":"邮箱验证成功"}`))
+	fmt.Printf("User %d verified email\n", uid)
+}
+
+// --- UnlockAccountRouter 管理员解除登录锁定 --- //
+type UnlockAccountRouter struct {
+	znet.BaseRouter
+}
+
+func (r *UnlockAccountRouter) Handle(request ziface.IRequest) {
+	userID, err := request.GetConnection().GetProperty("userID")
+	if err != nil || userID == nil {
+		fmt.Println("UnlockAccountRouter: User not authenticated")
+		_ = request.GetConnection().SendMsg(protocol.MsgIDUnlockAccountResp, []byte(`{"error":"用户未登录"}`))
+		return
+	}
+	uid := userID.(uint)
+
+	var req model.UnlockAccountReq
+	if err := json.Unmarshal(request.GetData(), &req); err != nil {
+		fmt.Println("UnlockAccountRouter: Invalid request data format - ", err)
+		_ = request.GetConnection().SendMsg(protocol.MsgIDUnlockAccountResp, []byte(`{"error":"请求数据格式错误"}`))
+		return
+	}
+
+	unlocked, err := global.UserService.UnlockAccount(uid, &req)
+	if err != nil {
+		fmt.Printf("UnlockAccountRouter: User %d failed to unlock %s - %s\n", uid, req.Username, err.Error())
+		errMsg := err.Error()
+		if errors.Is(err, service.ErrNotAdmin) {
+			errMsg = "需要管理员权限"
+		}
+		respData, _ := json.Marshal(map[string]string{"error": fmt.Sprintf("解除锁定失败: %s", errMsg)})
+		_ = request.GetConnection().SendMsg(protocol.MsgIDUnlockAccountResp, respData)
+		return
+	}
+
+	message := fmt.Sprintf("已清除 %s 的登录失败记录，该账号当前未被锁定", req.Username)
+	if unlocked {
+		message = fmt.Sprintf("已解除 %s 的登录锁定", req.Username)
+	}
+	respData, _ := json.Marshal(map[string]string{"message": message})
+	_ = request.GetConnection().SendMsg(protocol.MsgIDUnlockAccountResp, respData)
+}
+
+// sessionRevocationPublisher 分布式模式下向其他节点广播会话作废，global.DistributedManager 为 interface{}，按需断言
+type sessionRevocationPublisher interface {
+	PublishSessionRevoked(userID uint, reason string) error
+}
+
+// revokeSessions 关闭该用户除 keep 以外的所有连接，返回当前节点上关闭的连接数
+// 已签发的 JWT 通过 TokenVersion 失效，这里处理的是仍保持着的长连接；其他节点上的连接通过广播关闭
+func revokeSessions(userID uint, keep ziface.IConnection, reason string) int {
+	revoked := revokeLocalSessions(userID, keep, reason)
+	if publisher, ok := global.DistributedManager.(sessionRevocationPublisher); ok {
+		if err := publisher.PublishSessionRevoked(userID, reason); err != nil {
+			fmt.Printf("Warning: failed to broadcast session revocation for user %d: %v\n", userID, err)
+		}
+	}
+	return revoked
+}
+
+// RevokeLocalSessions 处理其他节点广播的会话作废，关闭该用户在当前节点上的所有连接
+func RevokeLocalSessions(userID uint, reason string) int {
+	return revokeLocalSessions(userID, nil, reason)
+}
+
+// revokeLocalSessions 关闭当前节点上该用户除 keep 以外的所有连接，返回关闭的连接数
+func revokeLocalSessions(userID uint, keep ziface.IConnection, reason string) int {
+	connMgr := global.GlobalServer.GetConnManager()
+	pushData, _ := json.Marshal(&model.SessionRevokedPush{Reason: reason})
+
+	// 先作废可恢复的会话，被关闭的连接不会进入断线等待恢复状态
+	global.Sessions.Revoke(userID, keep)
+
+	revoked := 0
+	for _, conn := range userConns(userID) {
+		if keep != nil && conn.GetConnID() == keep.GetConnID() {
+			continue
+		}
+		_ = conn.SendMsg(protocol.MsgIDSessionRevokedPush, pushData)
+		conn.Stop()
+		revoked++
+	}
+
+	// 被关闭的连接可能覆盖了用户到连接的映射，重新指向保留的连接
+	if keep != nil {
+		if uid, err := keep.GetProperty("userID"); err == nil && uid != nil && uid.(uint) == userID {
+			connMgr.SetConnByUserID(keep.GetConnID(), userID)
+		}
+	}
+	return revoked
+}
@@ -0,0 +1,197 @@
+package router
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/Xaytick/chat-zinx/chat-server/global"
+	"github.com/Xaytick/chat-zinx/chat-server/pkg/framing"
+	"github.com/Xaytick/chat-zinx/chat-server/pkg/model"
+	"github.com/Xaytick/chat-zinx/chat-server/pkg/protocol"
+	"github.com/Xaytick/chat-zinx/chat-server/pkg/service"
+	"github.com/Xaytick/zinx/ziface"
+	"github.com/Xaytick/zinx/znet"
+)
+
+// --- DeleteAccountRouter 申请注销账号 --- //
+type DeleteAccountRouter struct {
+	znet.BaseRouter
+}
+
+func (r *DeleteAccountRouter) Handle(request ziface.IRequest) {
+	userID, err := request.GetConnection().GetProperty("userID")
+	if err != nil || userID == nil {
+		fmt.Println("DeleteAccountRouter: User not authenticated")
+		_ = request.GetConnection().SendMsg(protocol.MsgIDDeleteAccountResp, []byte(`{"error":"用户未登录"}`))
+		return
+	}
+	uid := userID.(uint)
+
+	var req model.DeleteAccountReq
+	if err := json.Unmarshal(request.GetData(), &req); err != nil {
+		fmt.Println("DeleteAccountRouter: Invalid request data format - ", err)
+		_ = request.GetConnection().SendMsg(protocol.MsgIDDeleteAccountResp, []byte(`{"error":"请求数据格式错误"}`))
+		return
+	}
+
+	status, err := global.UserService.RequestAccountDeletion(uid, &req)
+	if err != nil {
+		fmt.Printf("DeleteAccountRouter: User %d failed to request deletion - %s\n", uid, err.Error())
+		errMsg := err.Error()
+		switch {
+		case errors.Is(err, service.ErrPasswordIncorrect):
+			errMsg = "密码错误"
+		case errors.Is(err, service.ErrInvalidTwoFactorCode):
+			errMsg = "已开启两步验证，请提供正确的验证码或备用恢复码"
+		}
+		respData, _ := json.Marshal(map[string]string{"error": fmt.Sprintf("申请注销失败: %s", errMsg)})
+		_ = request.GetConnection().SendMsg(protocol.MsgIDDeleteAccountResp, respData)
+		return
+	}
+
+	respData, _ := json.Marshal(status)
+	_ = request.GetConnection().SendMsg(protocol.MsgIDDeleteAccountResp, respData)
+	fmt.Printf("User %d scheduled account deletion at %s\n", uid, status.DeletionDueAt.Format("2006-01-02 15:04:05"))
+}
+
+// --- CancelAccountDeletionRouter 撤销注销申请 --- //
+type CancelAccountDeletionRouter struct {
+	znet.BaseRouter
+}
+
+func (r *CancelAccountDeletionRouter) Handle(request ziface.IRequest) {
+	userID, err := request.GetConnection().GetProperty("userID")
+	if err != nil || userID == nil {
+		fmt.Println("CancelAccountDeletionRouter: User not authenticated")
+		_ = request.GetConnection().SendMsg(protocol.MsgIDCancelAccountDeletionResp, []byte(`{"error":"用户未登录"}`))
+		return
+	}
+	uid := userID.(uint)
+
+	status, err := global.UserService.CancelAccountDeletion(uid)
+	if err != nil {
+		fmt.Printf("CancelAccountDeletionRouter: User %d failed to cancel deletion - %s\n", uid, err.Error())
+		errMsg := err.Error()
+		if errors.Is(err, service.ErrDeletionNotScheduled) {
+			errMsg = "账号未申请注销"
+		}
+		respData, _ := json.Marshal(map[string]string{"error": fmt.Sprintf("撤销注销失败: %s", errMsg)})
+		_ = request.GetConnection().SendMsg(protocol.MsgIDCancelAccountDeletionResp, respData)
+		return
+	}
+
+	respData, _ := json.Marshal(status)
+	_ = request.GetConnection().SendMsg(protocol.MsgIDCancelAccountDeletionResp, respData)
+	fmt.Printf("User %d cancelled account deletion\n", uid)
+}
+
+// --- GetAccountDeletionStatusRouter 查询注销申请状态 --- //
+type GetAccountDeletionStatusRouter struct {
+	znet.BaseRouter
+}
+
+func (r *GetAccountDeletionStatusRouter) Handle(request ziface.IRequest) {
+	userID, err := request.GetConnection().GetProperty("userID")
+	if err != nil || userID == nil {
+		fmt.Println("GetAccountDeletionStatusRouter: User not authenticated")
+		_ = request.GetConnection().SendMsg(protocol.MsgIDGetAccountDeletionStatusResp, []byte(`{"error":"用户未登录"}`))
+		return
+	}
+	uid := userID.(uint)
+
+	status, err := global.UserService.GetAccountDeletionStatus(uid)
+	if err != nil {
+		fmt.Printf("GetAccountDeletionStatusRouter: User %d failed to get deletion status - %s\n", uid, err.Error())
+		respData, _ := json.Marshal(map[string]string{"error": fmt.Sprintf("查询注销状态失败: %s", err.Error())})
+		_ = request.GetConnection().SendMsg(protocol.MsgIDGetAccountDeletionStatusResp, respData)
+		return
+	}
+
+	respData, _ := json.Marshal(status)
+	_ = request.GetConnection().SendMsg(protocol.MsgIDGetAccountDeletionStatusResp, respData)
+}
+
+// --- RequestDataExportRouter 申请导出个人数据 --- //
+type RequestDataExportRouter struct {
+	znet.BaseRouter
+}
+
+func (r *RequestDataExportRouter) Handle(request ziface.IRequest) {
+	userID, err := request.GetConnection().GetProperty("userID")
+	if err != nil || userID == nil {
+		fmt.Println("RequestDataExportRouter: User not authenticated")
+		_ = request.GetConnection().SendMsg(protocol.MsgIDRequestDataExportResp, []byte(`{"error":"用户未登录"}`))
+		return
+	}
+	uid := userID.(uint)
+
+	export, err := global.UserService.RequestDataExport(uid)
+	if err != nil {
+		fmt.Printf("RequestDataExportRouter: User %d failed to request data export - %s\n", uid, err.Error())
+		errMsg := err.Error()
+		if errors.Is(err, service.ErrExportInProgress) {
+			errMsg = "已有正在生成的导出，请稍后"
+		}
+		respData, _ := json.Marshal(map[string]string{"error": fmt.Sprintf("申请导出失败: %s", errMsg)})
+		_ = request.GetConnection().SendMsg(protocol.MsgIDRequestDataExportResp, respData)
+		return
+	}
+
+	respData, _ := json.Marshal(export)
+	_ = request.GetConnection().SendMsg(protocol.MsgIDRequestDataExportResp, respData)
+
+	// 打包可能耗时较长，放到后台生成，完成后推送给用户
+	go func() {
+		if err := global.UserService.BuildDataExport(export); err != nil {
+			fmt.Printf("Data export %d for user %d failed: %v\n", export.ID, uid, err)
+		}
+		pushData, _ := json.Marshal(&model.DataExportReadyPush{Export: export})
+		pushToUser(uid, protocol.MsgIDDataExportReadyPush, pushData)
+	}()
+}
+
+// --- DownloadDataExportRouter 分片下载导出文件 --- //
+type DownloadDataExportRouter struct {
+	znet.BaseRouter
+}
+
+func (r *DownloadDataExportRouter) Handle(request ziface.IRequest) {
+	userID, err := request.GetConnection().GetProperty("userID")
+	if err != nil || userID == nil {
+		fmt.Println("DownloadDataExportRouter: User not authenticated")
+		_ = request.GetConnection().SendMsg(protocol.MsgIDDownloadDataExportResp, []byte(`{"error":"用户未登录"}`))
+		return
+	}
+	uid := userID.(uint)
+
+	var req model.DownloadDataExportReq
+	if err := json.Unmarshal(request.GetData(), &req); err != nil {
+		fmt.Println("DownloadDataExportRouter: Invalid request data format - ", err)
+		_ = request.GetConnection().SendMsg(protocol.MsgIDDownloadDataExportResp, []byte(`{"error":"请求数据格式错误"}`))
+		return
+	}
+
+	chunk, err := global.UserService.ReadDataExport(uid, &req)
+	if err != nil {
+		fmt.Printf("DownloadDataExportRouter: User %d failed to download export %d - %s\n", uid, req.ExportID, err.Error())
+		errMsg := err.Error()
+		switch {
+		case errors.Is(err, service.ErrExportNotFound):
+			errMsg = "导出不存在或已过期"
+		case errors.Is(err, service.ErrExportNotReady):
+			errMsg = "导出尚未生成完成"
+		}
+		respData, _ := json.Marshal(map[string]string{"error": fmt.Sprintf("下载导出失败: %s", errMsg)})
+		_ = request.GetConnection().SendMsg(protocol.MsgIDDownloadDataExportResp, respData)
+		return
+	}
+
+	respData, _ := json.Marshal(chunk)
+	_ = framing.SendMsg(request.GetConnection(), protocol.MsgIDDownloadDataExportResp, respData)
+}
+
+// DisconnectPurgedUser 账号被清除后关闭该用户的所有连接，其他节点上的连接通过广播关闭，返回当前节点上关闭的连接数
+func DisconnectPurgedUser(userID uint) int {
+	return revokeSessions(userID, nil, "账号已注销")
+}
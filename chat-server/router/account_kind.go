@@ -0,0 +1,224 @@
+package router
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/Xaytick/chat-zinx/chat-server/global"
+	"github.com/Xaytick/chat-zinx/chat-server/pkg/model"
+	"github.com/Xaytick/chat-zinx/chat-server/pkg/protocol"
+	"github.com/Xaytick/chat-zinx/chat-server/pkg/service"
+	"github.com/Xaytick/zinx/ziface"
+	"github.com/Xaytick/zinx/znet"
+)
+
+// --- GuestLoginRouter 访客登录，响应与普通登录相同 --- //
+type GuestLoginRouter struct {
+	znet.BaseRouter
+}
+
+func (r *GuestLoginRouter) Handle(request ziface.IRequest) {
+	var req model.GuestLoginReq
+	if len(request.GetData()) > 0 {
+		if err := json.Unmarshal(request.GetData(), &req); err != nil {
+			sendLoginResponse(request, 1, "请求数据格式错误", nil)
+			return
+		}
+	}
+
+	ip := clientIP(request.GetConnection())
+	tokenString, user, err := global.UserService.GuestLogin(&req, ip)
+	if err != nil {
+		fmt.Printf("Guest login failed from %s: %v\n", ip, err)
+		if errors.Is(err, service.ErrGuestDisabled) {
+			sendLoginResponse(request, 5, "服务器未开启访客登录", nil)
+			return
+		}
+		sendLoginError(request, err)
+		return
+	}
+
+	finishLogin(request, tokenString, user, &model.LoginClientInfo{
+		IP:            ip,
+		ClientVersion: req.ClientVersion,
+		DeviceID:      req.DeviceID,
+	})
+}
+
+// --- BotLoginRouter 机器人使用 API Key 登录，响应与普通登录相同 --- //
+type BotLoginRouter struct {
+	znet.BaseRouter
+}
+
+func (r *BotLoginRouter) Handle(request ziface.IRequest) {
+	var req model.BotLoginReq
+	if err := json.Unmarshal(request.GetData(), &req); err != nil {
+		sendLoginResponse(request, 1, "请求数据格式错误", nil)
+		return
+	}
+	if req.APIKey == "" {
+		sendLoginResponse(request, 2, "API Key 不能为空", nil)
+		return
+	}
+
+	ip := clientIP(request.GetConnection())
+	tokenString, user, err := global.UserService.LoginBot(req.APIKey, ip)
+	if err != nil {
+		fmt.Printf("Bot login failed from %s: %v\n", ip, err)
+		if errors.Is(err, service.ErrInvalidAPIKey) {
+			sendLoginResponse(request, 4, "API Key 无效或已失效", nil)
+			return
+		}
+		sendLoginError(request, err)
+		return
+	}
+
+	finishLogin(request, tokenString, user, &model.LoginClientInfo{
+		IP:            ip,
+		ClientVersion: req.ClientVersion,
+		DeviceID:      req.DeviceID,
+	})
+}
+
+// --- TokenLoginRouter 凭 JWT 重新登录，响应与普通登录相同 --- //
+type TokenLoginRouter struct {
+	znet.BaseRouter
+}
+
+func (r *TokenLoginRouter) Handle(request ziface.IRequest) {
+	var req model.TokenLoginReq
+	if err := json.Unmarshal(request.GetData(), &req); err != nil {
+		sendLoginResponse(request, 1, "请求数据格式错误", nil)
+		return
+	}
+	if req.Token == "" {
+		sendLoginResponse(request, 2, "登录令牌不能为空", nil)
+		return
+	}
+
+	ip := clientIP(request.GetConnection())
+	user, err := global.UserService.TokenLogin(req.Token, ip)
+	if err != nil {
+		fmt.Printf("Token login failed from %s: %v\n", ip, err)
+		if errors.Is(err, service.ErrInvalidToken) {
+			sendLoginResponse(request, 8, "登录令牌无效或已过期，请重新登录", nil)
+			return
+		}
+		sendLoginError(request, err)
+		return
+	}
+
+	finishLogin(request, req.Token, user, &model.LoginClientInfo{
+		IP:            ip,
+		ClientVersion: req.ClientVersion,
+		DeviceID:      req.DeviceID,
+	})
+}
+
+// --- CreateBotRouter 创建机器人 --- //
+type CreateBotRouter struct {
+	znet.BaseRouter
+}
+
+func (r *CreateBotRouter) Handle(request ziface.IRequest) {
+	userID, err := request.GetConnection().GetProperty("userID")
+	if err != nil || userID == nil {
+		fmt.Println("CreateBotRouter: User not authenticated")
+		_ = request.GetConnection().SendMsg(protocol.MsgIDCreateBotResp, []byte(`{"error":"用户未登录"}`))
+		return
+	}
+	uid := userID.(uint)
+
+	var req model.CreateBotReq
+	if err := json.Unmarshal(request.GetData(), &req); err != nil {
+		fmt.Println("CreateBotRouter: Invalid request data format - ", err)
+		_ = request.GetConnection().SendMsg(protocol.MsgIDCreateBotResp, []byte(`{"error":"请求数据格式错误"}`))
+		return
+	}
+
+	resp, err := global.UserService.CreateBot(uid, &req)
+	if err != nil {
+		fmt.Printf("CreateBotRouter: User %d failed to create bot - %s\n", uid, err.Error())
+		errMsg := err.Error()
+		switch {
+		case errors.Is(err, service.ErrUsernameExists):
+			errMsg = "用户名已存在"
+		case errors.Is(err, service.ErrTooManyBots):
+			errMsg = "机器人数量已达上限"
+		}
+		respData, _ := json.Marshal(map[string]string{"error": fmt.Sprintf("创建机器人失败: %s", errMsg)})
+		_ = request.GetConnection().SendMsg(protocol.MsgIDCreateBotResp, respData)
+		return
+	}
+
+	respData, _ := json.Marshal(resp)
+	_ = request.GetConnection().SendMsg(protocol.MsgIDCreateBotResp, respData)
+	fmt.Printf("User %d created bot %d (%s)\n", uid, resp.Bot.ID, resp.Bot.Username)
+}
+
+// --- RotateBotKeyRouter 重新生成机器人 API Key --- //
+type RotateBotKeyRouter struct {
+	znet.BaseRouter
+}
+
+func (r *RotateBotKeyRouter) Handle(request ziface.IRequest) {
+	userID, err := request.GetConnection().GetProperty("userID")
+	if err != nil || userID == nil {
+		fmt.Println("RotateBotKeyRouter: User not authenticated")
+		_ = request.GetConnection().SendMsg(protocol.MsgIDRotateBotKeyResp, []byte(`{"error":"用户未登录"}`))
+		return
+	}
+	uid := userID.(uint)
+
+	var req model.RotateBotKeyReq
+	if err := json.Unmarshal(request.GetData(), &req); err != nil {
+		fmt.Println("RotateBotKeyRouter: Invalid request data format - ", err)
+		_ = request.GetConnection().SendMsg(protocol.MsgIDRotateBotKeyResp, []byte(`{"error":"请求数据格式错误"}`))
+		return
+	}
+
+	resp, err := global.UserService.RotateBotKey(uid, req.BotUserID)
+	if err != nil {
+		fmt.Printf("RotateBotKeyRouter: User %d failed to rotate key of bot %d - %s\n", uid, req.BotUserID, err.Error())
+		errMsg := err.Error()
+		if errors.Is(err, service.ErrBotNotFound) {
+			errMsg = "机器人不存在"
+		}
+		respData, _ := json.Marshal(map[string]string{"error": fmt.Sprintf("重新生成 API Key 失败: %s", errMsg)})
+		_ = request.GetConnection().SendMsg(protocol.MsgIDRotateBotKeyResp, respData)
+		return
+	}
+
+	// 使用旧 Key 登录的机器人连接立即断开
+	revoked := revokeSessions(req.BotUserID, nil, "API Key 已更换，请使用新的 Key 重新登录")
+	respData, _ := json.Marshal(resp)
+	_ = request.GetConnection().SendMsg(protocol.MsgIDRotateBotKeyResp, respData)
+	fmt.Printf("User %d rotated key of bot %d, %d sessions revoked\n", uid, req.BotUserID, revoked)
+}
+
+// --- GetMyBotsRouter 查询自己创建的机器人 --- //
+type GetMyBotsRouter struct {
+	znet.BaseRouter
+}
+
+func (r *GetMyBotsRouter) Handle(request ziface.IRequest) {
+	userID, err := request.GetConnection().GetProperty("userID")
+	if err != nil || userID == nil {
+		fmt.Println("GetMyBotsRouter: User not authenticated")
+		_ = request.GetConnection().SendMsg(protocol.MsgIDGetMyBotsResp, []byte(`{"error":"用户未登录"}`))
+		return
+	}
+	uid := userID.(uint)
+
+	bots, err := global.UserService.GetMyBots(uid)
+	if err != nil {
+		fmt.Printf("GetMyBotsRouter: User %d failed to get bots - %s\n", uid, err.Error())
+		respData, _ := json.Marshal(map[string]string{"error": fmt.Sprintf("查询机器人失败: %s", err.Error())})
+		_ = request.GetConnection().SendMsg(protocol.MsgIDGetMyBotsResp, respData)
+		return
+	}
+
+	respData, _ := json.Marshal(&model.GetMyBotsResp{Bots: bots})
+	_ = request.GetConnection().SendMsg(protocol.MsgIDGetMyBotsResp, respData)
+}
@@ -0,0 +1,164 @@
+package router
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/Xaytick/chat-zinx/chat-server/global"
+	"github.com/Xaytick/chat-zinx/chat-server/pkg/model"
+	"github.com/Xaytick/chat-zinx/chat-server/pkg/protocol"
+	"github.com/Xaytick/zinx/ziface"
+	"github.com/Xaytick/zinx/znet"
+)
+
+// --- BlockUserRouter 屏蔽用户 --- //
+type BlockUserRouter struct {
+	znet.BaseRouter
+}
+
+func (r *BlockUserRouter) Handle(request ziface.IRequest) {
+	userID, err := request.GetConnection().GetProperty("userID")
+	if err != nil || userID == nil {
+		fmt.Println("BlockUserRouter: User not authenticated")
+		_ = request.GetConnection().SendMsg(protocol.MsgIDBlockUserResp, []byte(`{"error":"用户未登录"}`))
+		return
+	}
+	uid := userID.(uint)
+
+	var req model.BlockUserReq
+	if err := json.Unmarshal(request.GetData(), &req); err != nil {
+		fmt.Println("BlockUserRouter: Invalid request data format - ", err)
+		_ = request.GetConnection().SendMsg(protocol.MsgIDBlockUserResp, []byte(`{"error":"请求数据格式错误"}`))
+		return
+	}
+
+	info, err := global.FriendService.BlockUser(uid, req.Target)
+	if err != nil {
+		fmt.Printf("BlockUserRouter: User %d failed to block %s - %s\n", uid, req.Target, err.Error())
+		respData, _ := json.Marshal(map[string]string{"error": fmt.Sprintf("屏蔽用户失败: %s", err.Error())})
+		_ = request.GetConnection().SendMsg(protocol.MsgIDBlockUserResp, respData)
+		return
+	}
+
+	respData, _ := json.Marshal(map[string]string{"message": fmt.Sprintf("已屏蔽用户 %s (ID:%d)", info.Username, info.UserID)})
+	_ = request.GetConnection().SendMsg(protocol.MsgIDBlockUserResp, respData)
+	fmt.Printf("User %d blocked user %d\n", uid, info.UserID)
+}
+
+// --- UnblockUserRouter 取消屏蔽 --- //
+type UnblockUserRouter struct {
+	znet.BaseRouter
+}
+
+func (r *UnblockUserRouter) Handle(request ziface.IRequest) {
+	userID, err := request.GetConnection().GetProperty("userID")
+	if err != nil || userID == nil {
+		fmt.Println("UnblockUserRouter: User not authenticated")
+		_ = request.GetConnection().SendMsg(protocol.MsgIDUnblockUserResp, []byte(`{"error":"用户未登录"}`))
+		return
+	}
+	uid := userID.(uint)
+
+	var req model.UnblockUserReq
+	if err := json.Unmarshal(request.GetData(), &req); err != nil {
+		fmt.Println("UnblockUserRouter: Invalid request data format - ", err)
+		_ = request.GetConnection().SendMsg(protocol.MsgIDUnblockUserResp, []byte(`{"error":"请求数据格式错误"}`))
+		return
+	}
+
+	if err := global.FriendService.UnblockUser(uid, req.UserID); err != nil {
+		fmt.Printf("UnblockUserRouter: User %d failed to unblock user %d - %s\n", uid, req.UserID, err.Error())
+		respData, _ := json.Marshal(map[string]string{"error": fmt.Sprintf("取消屏蔽失败: %s", err.Error())})
+		_ = request.GetConnection().SendMsg(protocol.MsgIDUnblockUserResp, respData)
+		return
+	}
+
+	respData, _ := json.Marshal(map[string]string{"message": "已取消屏蔽"})
+	_ = request.GetConnection().SendMsg(protocol.MsgIDUnblockUserResp, respData)
+	fmt.Printf("User %d unblocked user %d\n", uid, req.UserID)
+}
+
+// --- GetBlockListRouter 获取屏蔽列表 --- //
+type GetBlockListRouter struct {
+	znet.BaseRouter
+}
+
+func (r *GetBlockListRouter) Handle(request ziface.IRequest) {
+	userID, err := request.GetConnection().GetProperty("userID")
+	if err != nil || userID == nil {
+		fmt.Println("GetBlockListRouter: User not authenticated")
+		_ = request.GetConnection().SendMsg(protocol.MsgIDGetBlockListResp, []byte(`{"error":"用户未登录"}`))
+		return
+	}
+	uid := userID.(uint)
+
+	users, err := global.FriendService.GetBlockList(uid)
+	if err != nil {
+		fmt.Printf("GetBlockListRouter: Failed to get block list for user %d - %s\n", uid, err.Error())
+		respData, _ := json.Marshal(map[string]string{"error": fmt.Sprintf("获取屏蔽列表失败: %s", err.Error())})
+		_ = request.GetConnection().SendMsg(protocol.MsgIDGetBlockListResp, respData)
+		return
+	}
+
+	respData, _ := json.Marshal(model.GetBlockListResp{Users: users, Total: len(users)})
+	_ = request.GetConnection().SendMsg(protocol.MsgIDGetBlockListResp, respData)
+}
+
+// --- SetDMPrivacyRouter 设置私聊隐私 --- //
+type SetDMPrivacyRouter struct {
+	znet.BaseRouter
+}
+
+func (r *SetDMPrivacyRouter) Handle(request ziface.IRequest) {
+	userID, err := request.GetConnection().GetProperty("userID")
+	if err != nil || userID == nil {
+		fmt.Println("SetDMPrivacyRouter: User not authenticated")
+		_ = request.GetConnection().SendMsg(protocol.MsgIDSetDMPrivacyResp, []byte(`{"error":"用户未登录"}`))
+		return
+	}
+	uid := userID.(uint)
+
+	var req model.DMPrivacyReq
+	if err := json.Unmarshal(request.GetData(), &req); err != nil {
+		fmt.Println("SetDMPrivacyRouter: Invalid request data format - ", err)
+		_ = request.GetConnection().SendMsg(protocol.MsgIDSetDMPrivacyResp, []byte(`{"error":"请求数据格式错误"}`))
+		return
+	}
+
+	if err := global.FriendService.SetDMPrivacy(uid, req.Privacy); err != nil {
+		fmt.Printf("SetDMPrivacyRouter: User %d failed to set dm privacy - %s\n", uid, err.Error())
+		respData, _ := json.Marshal(map[string]string{"error": fmt.Sprintf("设置私聊隐私失败: %s", err.Error())})
+		_ = request.GetConnection().SendMsg(protocol.MsgIDSetDMPrivacyResp, respData)
+		return
+	}
+
+	respData, _ := json.Marshal(req)
+	_ = request.GetConnection().SendMsg(protocol.MsgIDSetDMPrivacyResp, respData)
+	fmt.Printf("User %d set dm privacy to %s\n", uid, req.Privacy)
+}
+
+// --- GetDMPrivacyRouter 获取私聊隐私设置 --- //
+type GetDMPrivacyRouter struct {
+	znet.BaseRouter
+}
+
+func (r *GetDMPrivacyRouter) Handle(request ziface.IRequest) {
+	userID, err := request.GetConnection().GetProperty("userID")
+	if err != nil || userID == nil {
+		fmt.Println("GetDMPrivacyRouter: User not authenticated")
+		_ = request.GetConnection().SendMsg(protocol.MsgIDGetDMPrivacyResp, []byte(`{"error":"用户未登录"}`))
+		return
+	}
+	uid := userID.(uint)
+
+	privacy, err := global.FriendService.GetDMPrivacy(uid)
+	if err != nil {
+		fmt.Printf("GetDMPrivacyRouter: Failed to get dm privacy for user %d - %s\n", uid, err.Error())
+		respData, _ := json.Marshal(map[string]string{"error": fmt.Sprintf("获取私聊隐私设置失败: %s", err.Error())})
+		_ = request.GetConnection().SendMsg(protocol.MsgIDGetDMPrivacyResp, respData)
+		return
+	}
+
+	respData, _ := json.Marshal(model.DMPrivacyReq{Privacy: privacy})
+	_ = request.GetConnection().SendMsg(protocol.MsgIDGetDMPrivacyResp, respData)
+}
@@ -0,0 +1,201 @@
+package router
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+	"unicode/utf8"
+
+	"github.com/Xaytick/chat-zinx/chat-server/conf"
+	"github.com/Xaytick/chat-zinx/chat-server/global"
+	"github.com/Xaytick/chat-zinx/chat-server/pkg/model"
+	"github.com/Xaytick/chat-zinx/chat-server/pkg/protocol"
+	"github.com/Xaytick/chat-zinx/chat-server/pkg/service"
+	"github.com/Xaytick/chat-zinx/chat-server/pkg/webhook"
+	"github.com/Xaytick/zinx/ziface"
+	"github.com/Xaytick/zinx/znet"
+)
+
+// --- SetBotWebhookRouter 创建或修改机器人 webhook --- //
+type SetBotWebhookRouter struct {
+	znet.BaseRouter
+}
+
+func (r *SetBotWebhookRouter) Handle(request ziface.IRequest) {
+	userID, err := request.GetConnection().GetProperty("userID")
+	if err != nil || userID == nil {
+		fmt.Println("SetBotWebhookRouter: User not authenticated")
+		_ = request.GetConnection().SendMsg(protocol.MsgIDSetBotWebhookResp, []byte(`{"error":"用户未登录"}`))
+		return
+	}
+	uid := userID.(uint)
+
+	var req model.SetBotWebhookReq
+	if err := json.Unmarshal(request.GetData(), &req); err != nil {
+		fmt.Println("SetBotWebhookRouter: Invalid request data format - ", err)
+		_ = request.GetConnection().SendMsg(protocol.MsgIDSetBotWebhookResp, []byte(`{"error":"请求数据格式错误"}`))
+		return
+	}
+
+	resp, err := global.BotService.SetWebhook(uid, &req)
+	if err != nil {
+		fmt.Printf("SetBotWebhookRouter: User %d failed to set webhook of bot %d in group %d - %s\n", uid, req.BotUserID, req.GroupID, err.Error())
+		errMsg := err.Error()
+		switch {
+		case errors.Is(err, service.ErrBotNotFound):
+			errMsg = "机器人不存在"
+		case errors.Is(err, service.ErrBotNotInGroup):
+			errMsg = "机器人不在该群中，请先邀请机器人入群"
+		case errors.Is(err, webhook.ErrPrivateAddress):
+			errMsg = "不允许使用内网地址"
+		case errors.Is(err, service.ErrInvalidWebhookURL):
+			errMsg = "webhook 地址无效，只支持 http 和 https"
+		case errors.Is(err, service.ErrInvalidBotCommand):
+			errMsg = "命令前缀需以 / 开头且不能包含空格"
+		case errors.Is(err, service.ErrWebhookNoTrigger):
+			errMsg = "请设置命令前缀或开启 @ 触发"
+		}
+		respData, _ := json.Marshal(map[string]string{"error": fmt.Sprintf("设置 webhook 失败: %s", errMsg)})
+		_ = request.GetConnection().SendMsg(protocol.MsgIDSetBotWebhookResp, respData)
+		return
+	}
+
+	respData, _ := json.Marshal(resp)
+	_ = request.GetConnection().SendMsg(protocol.MsgIDSetBotWebhookResp, respData)
+	fmt.Printf("User %d set webhook of bot %d in group %d\n", uid, req.BotUserID, req.GroupID)
+}
+
+// --- DeleteBotWebhookRouter 删除机器人 webhook --- //
+type DeleteBotWebhookRouter struct {
+	znet.BaseRouter
+}
+
+func (r *DeleteBotWebhookRouter) Handle(request ziface.IRequest) {
+	userID, err := request.GetConnection().GetProperty("userID")
+	if err != nil || userID == nil {
+		fmt.Println("DeleteBotWebhookRouter: User not authenticated")
+		_ = request.GetConnection().SendMsg(protocol.MsgIDDeleteBotWebhookResp, []byte(`{"error":"用户未登录"}`))
+		return
+	}
+	uid := userID.(uint)
+
+	var req model.DeleteBotWebhookReq
+	if err := json.Unmarshal(request.GetData(), &req); err != nil {
+		fmt.Println("DeleteBotWebhookRouter: Invalid request data format - ", err)
+		_ = request.GetConnection().SendMsg(protocol.MsgIDDeleteBotWebhookResp, []byte(`{"error":"请求数据格式错误"}`))
+		return
+	}
+
+	if err := global.BotService.DeleteWebhook(uid, req.BotUserID, req.GroupID); err != nil {
+		fmt.Printf("DeleteBotWebhookRouter: User %d failed to delete webhook of bot %d in group %d - %s\n", uid, req.BotUserID, req.GroupID, err.Error())
+		errMsg := err.Error()
+		switch {
+		case errors.Is(err, service.ErrBotNotFound):
+			errMsg = "机器人不存在"
+		case errors.Is(err, service.ErrWebhookNotFound):
+			errMsg = "机器人在该群没有 webhook"
+		}
+		respData, _ := json.Marshal(map[string]string{"error": fmt.Sprintf("删除 webhook 失败: %s", errMsg)})
+		_ = request.GetConnection().SendMsg(protocol.MsgIDDeleteBotWebhookResp, respData)
+		return
+	}
+
+	respData, _ := json.Marshal(model.GenericMessageResp{Code: 0, Message: "webhook 已删除"})
+	_ = request.GetConnection().SendMsg(protocol.MsgIDDeleteBotWebhookResp, respData)
+	fmt.Printf("User %d deleted webhook of bot %d in group %d\n", uid, req.BotUserID, req.GroupID)
+}
+
+// --- GetBotWebhooksRouter 查询机器人的 webhook --- //
+type GetBotWebhooksRouter struct {
+	znet.BaseRouter
+}
+
+func (r *GetBotWebhooksRouter) Handle(request ziface.IRequest) {
+	userID, err := request.GetConnection().GetProperty("userID")
+	if err != nil || userID == nil {
+		fmt.Println("GetBotWebhooksRouter: User not authenticated")
+		_ = request.GetConnection().SendMsg(protocol.MsgIDGetBotWebhooksResp, []byte(`{"error":"用户未登录"}`))
+		return
+	}
+	uid := userID.(uint)
+
+	var req model.GetBotWebhooksReq
+	if err := json.Unmarshal(request.GetData(), &req); err != nil {
+		fmt.Println("GetBotWebhooksRouter: Invalid request data format - ", err)
+		_ = request.GetConnection().SendMsg(protocol.MsgIDGetBotWebhooksResp, []byte(`{"error":"请求数据格式错误"}`))
+		return
+	}
+
+	hooks, err := global.BotService.GetWebhooks(uid, req.BotUserID)
+	if err != nil {
+		fmt.Printf("GetBotWebhooksRouter: User %d failed to get webhooks of bot %d - %s\n", uid, req.BotUserID, err.Error())
+		errMsg := err.Error()
+		if errors.Is(err, service.ErrBotNotFound) {
+			errMsg = "机器人不存在"
+		}
+		respData, _ := json.Marshal(map[string]string{"error": fmt.Sprintf("查询 webhook 失败: %s", errMsg)})
+		_ = request.GetConnection().SendMsg(protocol.MsgIDGetBotWebhooksResp, respData)
+		return
+	}
+
+	respData, _ := json.Marshal(&model.GetBotWebhooksResp{BotUserID: req.BotUserID, Webhooks: hooks})
+	_ = request.GetConnection().SendMsg(protocol.MsgIDGetBotWebhooksResp, respData)
+}
+
+// triggerBotWebhooks 群消息发出后匹配群内机器人的 webhook 并交给投递协程池
+// 机器人发的消息不触发任何 webhook，避免两个机器人互相应答形成循环
+func triggerBotWebhooks(conn ziface.IConnection, groupID uint, msg *model.WebhookMessage, memberIDs []uint) {
+	if global.BotWebhooks == nil {
+		return
+	}
+	if kind, err := conn.GetProperty(accountKindKey); err == nil && kind == model.AccountKindBot {
+		return
+	}
+
+	deliveries, err := global.BotService.MatchWebhooks(groupID, msg, memberIDs)
+	if err != nil {
+		fmt.Printf("[BotWebhook] Failed to match webhooks for GroupID %d: %v\n", groupID, err)
+		return
+	}
+	for _, delivery := range deliveries {
+		global.BotWebhooks.Enqueue(delivery)
+	}
+}
+
+// PostBotReply 以机器人身份把 webhook 的回复发到群里，作为 webhook.ReplyHandler 注册给投递协程池
+// 投递期间机器人可能已被移出群或禁言，因此发送前重新检查发言权限
+func PostBotReply(hook *model.BotWebhook, reply string) {
+	if err := global.GroupService.CheckPermission(hook.BotUserID, hook.GroupID, model.GroupPermSendMessage); err != nil {
+		fmt.Printf("[BotWebhook] Bot %d cannot reply in GroupID %d: %v\n", hook.BotUserID, hook.GroupID, err)
+		return
+	}
+	if maxLen := conf.GetWebhookConfig().MaxReplyLen; maxLen > 0 && utf8.RuneCountInString(reply) > maxLen {
+		reply = string([]rune(reply)[:maxLen])
+	}
+
+	bot, err := global.UserService.GetUserByID(hook.BotUserID)
+	if err != nil {
+		fmt.Printf("[BotWebhook] Failed to get bot %d: %v\n", hook.BotUserID, err)
+		return
+	}
+	memberIDs, err := global.GroupService.GetGroupMemberIDs(hook.GroupID)
+	if err != nil {
+		fmt.Printf("[BotWebhook] Failed to get member IDs for GroupID %d: %v\n", hook.GroupID, err)
+		return
+	}
+
+	_, queued, err := publishGroupMessage(&model.GroupTextMsgPush{
+		GroupID:      uint32(hook.GroupID),
+		FromUserID:   bot.ID,
+		FromUserUUID: bot.UserUUID,
+		FromUsername: bot.Username,
+		Content:      reply,
+		Timestamp:    time.Now().Unix(),
+	}, memberIDs)
+	if err != nil {
+		fmt.Printf("[BotWebhook] Failed to publish reply of bot %d in GroupID %d: %v\n", bot.ID, hook.GroupID, err)
+		return
+	}
+	fmt.Printf("[BotWebhook] Reply of bot %d in GroupID %d queued for %d members\n", bot.ID, hook.GroupID, queued)
+}
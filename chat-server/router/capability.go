@@ -0,0 +1,50 @@
+package router
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/Xaytick/chat-zinx/chat-server/pkg/model"
+	"github.com/Xaytick/chat-zinx/chat-server/pkg/protocol"
+	"github.com/Xaytick/zinx/ziface"
+)
+
+// accountKindKey 连接属性，登录成功后记录账号类型
+const accountKindKey = "accountKind"
+
+// capabilityRouter 在调用内部路由前检查当前账号类型是否具备所需能力
+type capabilityRouter struct {
+	ziface.IRouter
+	capability model.AccountCapability
+	respMsgID  uint32
+}
+
+// RequireCapability 包装路由，账号类型不具备 capability 时直接拒绝
+// respMsgID 为内部路由的响应消息ID，没有响应消息的路由传 protocol.MsgIDErrorResp
+// 未登录的连接交给内部路由处理，由其返回"用户未登录"
+func RequireCapability(capability model.AccountCapability, respMsgID uint32, r ziface.IRouter) ziface.IRouter {
+	return &capabilityRouter{IRouter: r, capability: capability, respMsgID: respMsgID}
+}
+
+func (r *capabilityRouter) Handle(request ziface.IRequest) {
+	kind, err := request.GetConnection().GetProperty(accountKindKey)
+	if err != nil || kind == nil {
+		r.IRouter.Handle(request)
+		return
+	}
+	if model.AccountKindHas(kind.(string), r.capability) {
+		r.IRouter.Handle(request)
+		return
+	}
+
+	userID, _ := request.GetConnection().GetProperty("userID")
+	fmt.Printf("Capability %s denied for %v account %v (msgID %d)\n", r.capability, kind, userID, request.GetMsgID())
+	errMsg := "当前账号类型不支持该功能"
+	if r.respMsgID == protocol.MsgIDErrorResp {
+		respData, _ := json.Marshal(model.GenericMessageResp{Code: 403, Message: errMsg})
+		_ = request.GetConnection().SendMsg(protocol.MsgIDErrorResp, respData)
+		return
+	}
+	respData, _ := json.Marshal(map[string]string{"error": errMsg})
+	_ = request.GetConnection().SendMsg(r.respMsgID, respData)
+}
@@ -0,0 +1,231 @@
+package router
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/Xaytick/chat-zinx/chat-server/global"
+	"github.com/Xaytick/chat-zinx/chat-server/pkg/model"
+	"github.com/Xaytick/chat-zinx/chat-server/pkg/protocol"
+	"github.com/Xaytick/zinx/ziface"
+	"github.com/Xaytick/zinx/znet"
+)
+
+// pushToUser 向当前节点上的用户推送消息，用户断线等待恢复时先缓存，不在当前节点时返回 false
+func pushToUser(userID uint, msgID uint32, data []byte) bool {
+	return global.Sessions.PushToUser(userID, msgID, data)
+}
+
+// --- SendFriendRequestRouter 发送好友申请 --- //
+type SendFriendRequestRouter struct {
+	znet.BaseRouter
+}
+
+func (r *SendFriendRequestRouter) Handle(request ziface.IRequest) {
+	userID, err := request.GetConnection().GetProperty("userID")
+	if err != nil || userID == nil {
+		fmt.Println("SendFriendRequestRouter: User not authenticated")
+		_ = request.GetConnection().SendMsg(protocol.MsgIDSendFriendRequestResp, []byte(`{"error":"用户未登录"}`))
+		return
+	}
+	uid := userID.(uint)
+
+	var req model.SendFriendRequestReq
+	if err := json.Unmarshal(request.GetData(), &req); err != nil {
+		fmt.Println("SendFriendRequestRouter: Invalid request data format - ", err)
+		_ = request.GetConnection().SendMsg(protocol.MsgIDSendFriendRequestResp, []byte(`{"error":"请求数据格式错误"}`))
+		return
+	}
+
+	info, err := global.FriendService.SendFriendRequest(uid, &req)
+	if err != nil {
+		fmt.Printf("SendFriendRequestRouter: User %d failed to send friend request to %s - %s\n", uid, req.Target, err.Error())
+		respData, _ := json.Marshal(map[string]string{"error": fmt.Sprintf("发送好友申请失败: %s", err.Error())})
+		_ = request.GetConnection().SendMsg(protocol.MsgIDSendFriendRequestResp, respData)
+		return
+	}
+
+	respData, _ := json.Marshal(info)
+	_ = request.GetConnection().SendMsg(protocol.MsgIDSendFriendRequestResp, respData)
+
+	// 新申请推送给接收方；对方已发出申请而直接成为好友时，推送给原申请人
+	notifyUserID := info.ToUserID
+	if info.Status == model.FriendRequestAccepted {
+		notifyUserID = info.FromUserID
+	}
+	pushToUser(notifyUserID, protocol.MsgIDFriendRequestPush, respData)
+	fmt.Printf("User %d sent friend request to %s, request %d is %s\n", uid, req.Target, info.ID, info.Status)
+}
+
+// --- HandleFriendRequestRouter 接受/拒绝好友申请 --- //
+type HandleFriendRequestRouter struct {
+	znet.BaseRouter
+}
+
+func (r *HandleFriendRequestRouter) Handle(request ziface.IRequest) {
+	userID, err := request.GetConnection().GetProperty("userID")
+	if err != nil || userID == nil {
+		fmt.Println("HandleFriendRequestRouter: User not authenticated")
+		_ = request.GetConnection().SendMsg(protocol.MsgIDHandleFriendRequestResp, []byte(`{"error":"用户未登录"}`))
+		return
+	}
+	uid := userID.(uint)
+
+	var req model.HandleFriendRequestReq
+	if err := json.Unmarshal(request.GetData(), &req); err != nil {
+		fmt.Println("HandleFriendRequestRouter: Invalid request data format - ", err)
+		_ = request.GetConnection().SendMsg(protocol.MsgIDHandleFriendRequestResp, []byte(`{"error":"请求数据格式错误"}`))
+		return
+	}
+
+	info, err := global.FriendService.HandleFriendRequest(uid, req.RequestID, req.Accept)
+	if err != nil {
+		fmt.Printf("HandleFriendRequestRouter: User %d failed to handle friend request %d - %s\n", uid, req.RequestID, err.Error())
+		respData, _ := json.Marshal(map[string]string{"error": fmt.Sprintf("处理好友申请失败: %s", err.Error())})
+		_ = request.GetConnection().SendMsg(protocol.MsgIDHandleFriendRequestResp, respData)
+		return
+	}
+
+	respData, _ := json.Marshal(info)
+	_ = request.GetConnection().SendMsg(protocol.MsgIDHandleFriendRequestResp, respData)
+	pushToUser(info.FromUserID, protocol.MsgIDFriendRequestPush, respData)
+	fmt.Printf("User %d %s friend request %d from user %d\n", uid, info.Status, info.ID, info.FromUserID)
+}
+
+// --- GetFriendRequestsRouter 获取待处理的好友申请 --- //
+type GetFriendRequestsRouter struct {
+	znet.BaseRouter
+}
+
+func (r *GetFriendRequestsRouter) Handle(request ziface.IRequest) {
+	userID, err := request.GetConnection().GetProperty("userID")
+	if err != nil || userID == nil {
+		fmt.Println("GetFriendRequestsRouter: User not authenticated")
+		_ = request.GetConnection().SendMsg(protocol.MsgIDGetFriendRequestsResp, []byte(`{"error":"用户未登录"}`))
+		return
+	}
+	uid := userID.(uint)
+
+	resp, err := global.FriendService.GetFriendRequests(uid)
+	if err != nil {
+		fmt.Printf("GetFriendRequestsRouter: Failed to get friend requests for user %d - %s\n", uid, err.Error())
+		respData, _ := json.Marshal(map[string]string{"error": fmt.Sprintf("获取好友申请失败: %s", err.Error())})
+		_ = request.GetConnection().SendMsg(protocol.MsgIDGetFriendRequestsResp, respData)
+		return
+	}
+
+	respData, _ := json.Marshal(resp)
+	_ = request.GetConnection().SendMsg(protocol.MsgIDGetFriendRequestsResp, respData)
+	fmt.Printf("Retrieved %d incoming and %d outgoing friend requests for user %d\n", len(resp.Incoming), len(resp.Outgoing), uid)
+}
+
+// --- GetFriendListRouter 获取好友列表 --- //
+type GetFriendListRouter struct {
+	znet.BaseRouter
+}
+
+func (r *GetFriendListRouter) Handle(request ziface.IRequest) {
+	userID, err := request.GetConnection().GetProperty("userID")
+	if err != nil || userID == nil {
+		fmt.Println("GetFriendListRouter: User not authenticated")
+		_ = request.GetConnection().SendMsg(protocol.MsgIDGetFriendListResp, []byte(`{"error":"用户未登录"}`))
+		return
+	}
+	uid := userID.(uint)
+
+	friends, err := global.FriendService.GetFriendList(uid)
+	if err != nil {
+		fmt.Printf("GetFriendListRouter: Failed to get friends for user %d - %s\n", uid, err.Error())
+		respData, _ := json.Marshal(map[string]string{"error": fmt.Sprintf("获取好友列表失败: %s", err.Error())})
+		_ = request.GetConnection().SendMsg(protocol.MsgIDGetFriendListResp, respData)
+		return
+	}
+
+	// 连接在当前节点上的好友一定在线，不依赖数据库中的状态
+	connMgr := global.GlobalServer.GetConnManager()
+	for _, friend := range friends {
+		if !friend.IsOnline && connMgr.GetConnByUserID(friend.UserID) != nil {
+			friend.IsOnline = true
+		}
+	}
+
+	resp := model.GetFriendListResp{
+		Friends: friends,
+		Total:   len(friends),
+	}
+	respData, _ := json.Marshal(resp)
+	_ = request.GetConnection().SendMsg(protocol.MsgIDGetFriendListResp, respData)
+	fmt.Printf("Retrieved %d friends for user %d\n", len(friends), uid)
+}
+
+// --- RemoveFriendRouter 删除好友 --- //
+type RemoveFriendRouter struct {
+	znet.BaseRouter
+}
+
+func (r *RemoveFriendRouter) Handle(request ziface.IRequest) {
+	userID, err := request.GetConnection().GetProperty("userID")
+	if err != nil || userID == nil {
+		fmt.Println("RemoveFriendRouter: User not authenticated")
+		_ = request.GetConnection().SendMsg(protocol.MsgIDRemoveFriendResp, []byte(`{"error":"用户未登录"}`))
+		return
+	}
+	uid := userID.(uint)
+
+	var req model.RemoveFriendReq
+	if err := json.Unmarshal(request.GetData(), &req); err != nil {
+		fmt.Println("RemoveFriendRouter: Invalid request data format - ", err)
+		_ = request.GetConnection().SendMsg(protocol.MsgIDRemoveFriendResp, []byte(`{"error":"请求数据格式错误"}`))
+		return
+	}
+
+	if err := global.FriendService.RemoveFriend(uid, req.FriendUserID); err != nil {
+		fmt.Printf("RemoveFriendRouter: User %d failed to remove friend %d - %s\n", uid, req.FriendUserID, err.Error())
+		respData, _ := json.Marshal(map[string]string{"error": fmt.Sprintf("删除好友失败: %s", err.Error())})
+		_ = request.GetConnection().SendMsg(protocol.MsgIDRemoveFriendResp, respData)
+		return
+	}
+
+	respData, _ := json.Marshal(map[string]string{"message": "已删除好友"})
+	_ = request.GetConnection().SendMsg(protocol.MsgIDRemoveFriendResp, respData)
+	fmt.Printf("User %d removed friend %d\n", uid, req.FriendUserID)
+
+	// 通知被删除的一方刷新好友列表
+	username, _ := request.GetConnection().GetProperty("username")
+	name, _ := username.(string)
+	pushData, _ := json.Marshal(model.FriendRemovedPush{UserID: uid, Username: name})
+	pushToUser(req.FriendUserID, protocol.MsgIDFriendRemovedPush, pushData)
+}
+
+// --- SetFriendRemarkRouter 设置好友备注 --- //
+type SetFriendRemarkRouter struct {
+	znet.BaseRouter
+}
+
+func (r *SetFriendRemarkRouter) Handle(request ziface.IRequest) {
+	userID, err := request.GetConnection().GetProperty("userID")
+	if err != nil || userID == nil {
+		fmt.Println("SetFriendRemarkRouter: User not authenticated")
+		_ = request.GetConnection().SendMsg(protocol.MsgIDSetFriendRemarkResp, []byte(`{"error":"用户未登录"}`))
+		return
+	}
+	uid := userID.(uint)
+
+	var req model.SetFriendRemarkReq
+	if err := json.Unmarshal(request.GetData(), &req); err != nil {
+		fmt.Println("SetFriendRemarkRouter: Invalid request data format - ", err)
+		_ = request.GetConnection().SendMsg(protocol.MsgIDSetFriendRemarkResp, []byte(`{"error":"请求数据格式错误"}`))
+		return
+	}
+
+	if err := global.FriendService.SetFriendRemark(uid, req.FriendUserID, req.Remark); err != nil {
+		fmt.Printf("SetFriendRemarkRouter: User %d failed to set remark for friend %d - %s\n", uid, req.FriendUserID, err.Error())
+		respData, _ := json.Marshal(map[string]string{"error": fmt.Sprintf("设置好友备注失败: %s", err.Error())})
+		_ = request.GetConnection().SendMsg(protocol.MsgIDSetFriendRemarkResp, respData)
+		return
+	}
+
+	respData, _ := json.Marshal(map[string]string{"message": "好友备注已更新"})
+	_ = request.GetConnection().SendMsg(protocol.MsgIDSetFriendRemarkResp, respData)
+	fmt.Printf("User %d set remark for friend %d\n", uid, req.FriendUserID)
+}
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 
+	"github.com/Xaytick/chat-zinx/chat-server/conf"
 	"github.com/Xaytick/chat-zinx/chat-server/global"
 	"github.com/Xaytick/chat-zinx/chat-server/pkg/model"
 	"github.com/Xaytick/chat-zinx/chat-server/pkg/protocol"
@@ -203,8 +204,8 @@ func (r *GetGroupMembersRouter) Handle(request ziface.IRequest) {
 		return
 	}
 
-	// 获取群组成员详细信息
-	members, err := global.GroupService.GetGroupMembersWithUserInfo(req.GroupID)
+	// 分页获取群组成员详细信息
+	resp, err := global.GroupService.GetGroupMembersPage(&req)
 	if err != nil {
 		fmt.Printf("GetGroupMembersRouter: Failed to get members for group %d - %s\n", req.GroupID, err.Error())
 		respData, _ := json.Marshal(map[string]string{"error": fmt.Sprintf("获取群组成员失败: %s", err.Error())})
@@ -212,15 +213,9 @@ func (r *GetGroupMembersRouter) Handle(request ziface.IRequest) {
 		return
 	}
 
-	resp := model.GetGroupMembersResp{
-		GroupID: req.GroupID,
-		Members: members,
-		Total:   len(members),
-	}
-
 	respData, _ := json.Marshal(resp)
 	_ = request.GetConnection().SendMsg(protocol.MsgIDGetGroupMembersResp, respData)
-	fmt.Printf("Retrieved %d members for group %d\n", len(members), req.GroupID)
+	fmt.Printf("Retrieved %d/%d members for group %d (page %d)\n", len(resp.Members), resp.Total, req.GroupID, resp.Page)
 }
 
 // --- GetGroupDetailsRouter 获取群组详情 --- //
@@ -280,27 +275,57 @@ func (r *GetGroupDetailsRouter) Handle(request ziface.IRequest) {
 		return
 	}
 
+	// 获取群公告和置顶消息 (获取失败不影响详情返回)
+	announcement, err := global.GroupService.GetGroupAnnouncement(req.GroupID)
+	if err != nil {
+		fmt.Printf("GetGroupDetailsRouter: Failed to get announcement for group %d - %s\n", req.GroupID, err.Error())
+	}
+	pinnedMessages, err := global.GroupService.GetGroupPinnedMessages(req.GroupID)
+	if err != nil {
+		fmt.Printf("GetGroupDetailsRouter: Failed to get pinned messages for group %d - %s\n", req.GroupID, err.Error())
+		pinnedMessages = []*model.GroupPinnedMsgInfo{}
+	}
+	tags, err := global.GroupService.GetGroupTags(req.GroupID)
+	if err != nil {
+		fmt.Printf("GetGroupDetailsRouter: Failed to get tags for group %d - %s\n", req.GroupID, err.Error())
+		tags = []string{}
+	}
+
 	// 构造响应
 	type GroupDetailsResp struct {
-		ID          uint   `json:"id"`
-		Name        string `json:"name"`
-		OwnerUserID uint   `json:"owner_user_id"`
-		Description string `json:"description"`
-		Avatar      string `json:"avatar"`
-		MemberCount uint   `json:"member_count"`
-		CreatedAt   string `json:"created_at"`
-		UpdatedAt   string `json:"updated_at"`
+		ID             uint                         `json:"id"`
+		Name           string                       `json:"name"`
+		OwnerUserID    uint                         `json:"owner_user_id"`
+		Description    string                       `json:"description"`
+		Avatar         string                       `json:"avatar"`
+		MemberCount    uint                         `json:"member_count"`
+		Tier           string                       `json:"tier"`
+		MemberLimit    int                          `json:"member_limit"`
+		IsPublic       bool                         `json:"is_public"`
+		Category       string                       `json:"category"`
+		Tags           []string                     `json:"tags"`
+		CreatedAt      string                       `json:"created_at"`
+		UpdatedAt      string                       `json:"updated_at"`
+		Announcement   *model.GroupAnnouncementInfo `json:"announcement,omitempty"`
+		PinnedMessages []*model.GroupPinnedMsgInfo  `json:"pinned_messages"`
 	}
 
 	resp := GroupDetailsResp{
-		ID:          group.ID,
-		Name:        group.Name,
-		OwnerUserID: group.OwnerUserID,
-		Description: group.Description,
-		Avatar:      group.Avatar,
-		MemberCount: group.MemberCount,
-		CreatedAt:   group.CreatedAt.Format("2006-01-02 15:04:05"),
-		UpdatedAt:   group.UpdatedAt.Format("2006-01-02 15:04:05"),
+		ID:             group.ID,
+		Name:           group.Name,
+		OwnerUserID:    group.OwnerUserID,
+		Description:    group.Description,
+		Avatar:         group.Avatar,
+		MemberCount:    group.MemberCount,
+		Tier:           group.Tier,
+		MemberLimit:    conf.GetGroupMemberLimit(group.Tier),
+		IsPublic:       group.IsPublic,
+		Category:       group.Category,
+		Tags:           tags,
+		CreatedAt:      group.CreatedAt.Format("2006-01-02 15:04:05"),
+		UpdatedAt:      group.UpdatedAt.Format("2006-01-02 15:04:05"),
+		Announcement:   announcement,
+		PinnedMessages: pinnedMessages,
 	}
 
 	respData, _ := json.Marshal(resp)
@@ -412,3 +437,48 @@ func (r *RemoveMemberRouter) Handle(request ziface.IRequest) {
 	_ = request.GetConnection().SendMsg(protocol.MsgIDRemoveMemberResp, respData)
 	fmt.Printf("User %d removed user %d from group %d successfully\n", uid, req.TargetUserID, req.GroupID)
 }
+
+// --- InviteGroupMemberRouter 邀请用户入群 --- //
+type InviteGroupMemberRouter struct {
+	znet.BaseRouter
+}
+
+func (r *InviteGroupMemberRouter) Handle(request ziface.IRequest) {
+	userID, err := request.GetConnection().GetProperty("userID")
+	if err != nil || userID == nil {
+		fmt.Println("InviteGroupMemberRouter: User not authenticated")
+		_ = request.GetConnection().SendMsg(protocol.MsgIDInviteGroupMemberResp, []byte(`{"error":"用户未登录"}`))
+		return
+	}
+	uid := userID.(uint)
+
+	var req model.InviteGroupMemberReq
+	if err := json.Unmarshal(request.GetData(), &req); err != nil {
+		fmt.Println("InviteGroupMemberRouter: Invalid request data format - ", err)
+		_ = request.GetConnection().SendMsg(protocol.MsgIDInviteGroupMemberResp, []byte(`{"error":"请求数据格式错误"}`))
+		return
+	}
+
+	group, err := global.GroupService.InviteGroupMember(uid, &req)
+	if err != nil {
+		fmt.Printf("InviteGroupMemberRouter: User %d failed to invite user %d to group %d - %s\n",
+			uid, req.TargetUserID, req.GroupID, err.Error())
+		respData, _ := json.Marshal(map[string]string{"error": fmt.Sprintf("邀请入群失败: %s", err.Error())})
+		_ = request.GetConnection().SendMsg(protocol.MsgIDInviteGroupMemberResp, respData)
+		return
+	}
+
+	respData, _ := json.Marshal(map[string]string{"message": "已将用户加入群组"})
+	_ = request.GetConnection().SendMsg(protocol.MsgIDInviteGroupMemberResp, respData)
+
+	// 通知被邀请人
+	inviterName, _ := request.GetConnection().GetProperty("username")
+	pushData, _ := json.Marshal(model.GroupInvitedPush{
+		GroupID:     group.ID,
+		GroupName:   group.Name,
+		InviterID:   uid,
+		InviterName: fmt.Sprint(inviterName),
+	})
+	pushToUser(req.TargetUserID, protocol.MsgIDGroupInvitedPush, pushData)
+	fmt.Printf("User %d invited user %d to group %d successfully\n", uid, req.TargetUserID, req.GroupID)
+}
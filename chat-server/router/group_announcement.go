@@ -0,0 +1,192 @@
+package router
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/Xaytick/chat-zinx/chat-server/global"
+	"github.com/Xaytick/chat-zinx/chat-server/pkg/model"
+	"github.com/Xaytick/chat-zinx/chat-server/pkg/protocol"
+	"github.com/Xaytick/zinx/ziface"
+	"github.com/Xaytick/zinx/znet"
+)
+
+// --- UpdateGroupAnnouncementRouter 编辑群公告 --- //
+type UpdateGroupAnnouncementRouter struct {
+	znet.BaseRouter
+}
+
+func (r *UpdateGroupAnnouncementRouter) Handle(request ziface.IRequest) {
+	userID, err := request.GetConnection().GetProperty("userID")
+	if err != nil || userID == nil {
+		fmt.Println("UpdateGroupAnnouncementRouter: User not authenticated")
+		_ = request.GetConnection().SendMsg(protocol.MsgIDUpdateGroupAnnouncementResp, []byte(`{"error":"用户未登录"}`))
+		return
+	}
+	uid := userID.(uint)
+
+	var req model.UpdateGroupAnnouncementReq
+	if err := json.Unmarshal(request.GetData(), &req); err != nil {
+		fmt.Println("UpdateGroupAnnouncementRouter: Invalid request data format - ", err)
+		_ = request.GetConnection().SendMsg(protocol.MsgIDUpdateGroupAnnouncementResp, []byte(`{"error":"请求数据格式错误"}`))
+		return
+	}
+
+	announcement, err := global.GroupService.UpdateGroupAnnouncement(uid, req.GroupID, req.Content)
+	if err != nil {
+		fmt.Printf("UpdateGroupAnnouncementRouter: User %d failed to update announcement of group %d - %s\n", uid, req.GroupID, err.Error())
+		respData, _ := json.Marshal(map[string]string{"error": fmt.Sprintf("编辑群公告失败: %s", err.Error())})
+		_ = request.GetConnection().SendMsg(protocol.MsgIDUpdateGroupAnnouncementResp, respData)
+		return
+	}
+
+	respData, _ := json.Marshal(announcement)
+	_ = request.GetConnection().SendMsg(protocol.MsgIDUpdateGroupAnnouncementResp, respData)
+
+	// 向其他群成员推送公告变更
+	pushData, _ := json.Marshal(model.GroupAnnouncementPush{GroupID: req.GroupID, Announcement: announcement})
+	queued, err := pushToGroupMembers(req.GroupID, protocol.MsgIDGroupAnnouncementPush, pushData, uid)
+	if err != nil {
+		fmt.Printf("UpdateGroupAnnouncementRouter: Failed to push announcement - %s\n", err.Error())
+	}
+	fmt.Printf("User %d updated announcement of group %d to version %d, push queued for %d members\n",
+		uid, req.GroupID, announcement.Version, queued)
+}
+
+// --- GetGroupAnnouncementHistoryRouter 获取群公告历史 --- //
+type GetGroupAnnouncementHistoryRouter struct {
+	znet.BaseRouter
+}
+
+func (r *GetGroupAnnouncementHistoryRouter) Handle(request ziface.IRequest) {
+	userID, err := request.GetConnection().GetProperty("userID")
+	if err != nil || userID == nil {
+		fmt.Println("GetGroupAnnouncementHistoryRouter: User not authenticated")
+		_ = request.GetConnection().SendMsg(protocol.MsgIDGetGroupAnnouncementHistoryResp, []byte(`{"error":"用户未登录"}`))
+		return
+	}
+	uid := userID.(uint)
+
+	var req model.GetGroupAnnouncementHistoryReq
+	if err := json.Unmarshal(request.GetData(), &req); err != nil {
+		fmt.Println("GetGroupAnnouncementHistoryRouter: Invalid request data format - ", err)
+		_ = request.GetConnection().SendMsg(protocol.MsgIDGetGroupAnnouncementHistoryResp, []byte(`{"error":"请求数据格式错误"}`))
+		return
+	}
+
+	announcements, err := global.GroupService.GetGroupAnnouncementHistory(uid, req.GroupID, req.Limit)
+	if err != nil {
+		fmt.Printf("GetGroupAnnouncementHistoryRouter: User %d failed to get announcement history of group %d - %s\n", uid, req.GroupID, err.Error())
+		respData, _ := json.Marshal(map[string]string{"error": fmt.Sprintf("获取群公告历史失败: %s", err.Error())})
+		_ = request.GetConnection().SendMsg(protocol.MsgIDGetGroupAnnouncementHistoryResp, respData)
+		return
+	}
+
+	resp := model.GetGroupAnnouncementHistoryResp{
+		GroupID:       req.GroupID,
+		Announcements: announcements,
+	}
+	respData, _ := json.Marshal(resp)
+	_ = request.GetConnection().SendMsg(protocol.MsgIDGetGroupAnnouncementHistoryResp, respData)
+	fmt.Printf("Retrieved %d announcement versions for group %d\n", len(announcements), req.GroupID)
+}
+
+// --- AckGroupAnnouncementRouter 确认群公告 --- //
+type AckGroupAnnouncementRouter struct {
+	znet.BaseRouter
+}
+
+func (r *AckGroupAnnouncementRouter) Handle(request ziface.IRequest) {
+	userID, err := request.GetConnection().GetProperty("userID")
+	if err != nil || userID == nil {
+		fmt.Println("AckGroupAnnouncementRouter: User not authenticated")
+		_ = request.GetConnection().SendMsg(protocol.MsgIDAckGroupAnnouncementResp, []byte(`{"error":"用户未登录"}`))
+		return
+	}
+	uid := userID.(uint)
+
+	var req model.AckGroupAnnouncementReq
+	if err := json.Unmarshal(request.GetData(), &req); err != nil {
+		fmt.Println("AckGroupAnnouncementRouter: Invalid request data format - ", err)
+		_ = request.GetConnection().SendMsg(protocol.MsgIDAckGroupAnnouncementResp, []byte(`{"error":"请求数据格式错误"}`))
+		return
+	}
+
+	announcement, err := global.GroupService.AckGroupAnnouncement(uid, req.GroupID, req.AnnouncementID)
+	if err != nil {
+		fmt.Printf("AckGroupAnnouncementRouter: User %d failed to ack announcement %d of group %d - %s\n", uid, req.AnnouncementID, req.GroupID, err.Error())
+		respData, _ := json.Marshal(map[string]string{"error": fmt.Sprintf("确认群公告失败: %s", err.Error())})
+		_ = request.GetConnection().SendMsg(protocol.MsgIDAckGroupAnnouncementResp, respData)
+		return
+	}
+
+	respData, _ := json.Marshal(announcement)
+	_ = request.GetConnection().SendMsg(protocol.MsgIDAckGroupAnnouncementResp, respData)
+	fmt.Printf("User %d acknowledged announcement %d of group %d\n", uid, req.AnnouncementID, req.GroupID)
+}
+
+// --- PinGroupMessageRouter 置顶群消息 --- //
+type PinGroupMessageRouter struct {
+	znet.BaseRouter
+}
+
+func (r *PinGroupMessageRouter) Handle(request ziface.IRequest) {
+	handlePinChange(request, true)
+}
+
+// --- UnpinGroupMessageRouter 取消置顶群消息 --- //
+type UnpinGroupMessageRouter struct {
+	znet.BaseRouter
+}
+
+func (r *UnpinGroupMessageRouter) Handle(request ziface.IRequest) {
+	handlePinChange(request, false)
+}
+
+// handlePinChange 置顶/取消置顶的公共处理逻辑，成功后向群成员推送最新置顶列表
+func handlePinChange(request ziface.IRequest, pin bool) {
+	respMsgID, action, logAction := protocol.MsgIDUnpinGroupMessageResp, "取消置顶", "unpin"
+	if pin {
+		respMsgID, action, logAction = protocol.MsgIDPinGroupMessageResp, "置顶", "pin"
+	}
+
+	userID, err := request.GetConnection().GetProperty("userID")
+	if err != nil || userID == nil {
+		fmt.Printf("PinGroupMessageRouter(%s): User not authenticated\n", logAction)
+		_ = request.GetConnection().SendMsg(respMsgID, []byte(`{"error":"用户未登录"}`))
+		return
+	}
+	uid := userID.(uint)
+
+	var req model.PinGroupMessageReq
+	if err := json.Unmarshal(request.GetData(), &req); err != nil {
+		fmt.Printf("PinGroupMessageRouter(%s): Invalid request data format - %v\n", logAction, err)
+		_ = request.GetConnection().SendMsg(respMsgID, []byte(`{"error":"请求数据格式错误"}`))
+		return
+	}
+
+	var pinned []*model.GroupPinnedMsgInfo
+	if pin {
+		pinned, err = global.GroupService.PinGroupMessage(uid, req.GroupID, req.GroupMessageID)
+	} else {
+		pinned, err = global.GroupService.UnpinGroupMessage(uid, req.GroupID, req.GroupMessageID)
+	}
+	if err != nil {
+		fmt.Printf("PinGroupMessageRouter(%s): User %d failed on message %d in group %d - %s\n",
+			logAction, uid, req.GroupMessageID, req.GroupID, err.Error())
+		respData, _ := json.Marshal(map[string]string{"error": fmt.Sprintf("%s消息失败: %s", action, err.Error())})
+		_ = request.GetConnection().SendMsg(respMsgID, respData)
+		return
+	}
+
+	push := model.GroupPinnedMsgPush{GroupID: req.GroupID, PinnedMessages: pinned}
+	pushData, _ := json.Marshal(push)
+	_ = request.GetConnection().SendMsg(respMsgID, pushData)
+
+	queued, err := pushToGroupMembers(req.GroupID, protocol.MsgIDGroupPinnedMsgPush, pushData, uid)
+	if err != nil {
+		fmt.Printf("PinGroupMessageRouter(%s): Failed to push pinned messages - %s\n", logAction, err.Error())
+	}
+	fmt.Printf("User %d %sned message %d in group %d, push queued for %d members\n",
+		uid, logAction, req.GroupMessageID, req.GroupID, queued)
+}
@@ -0,0 +1,160 @@
+package router
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/Xaytick/chat-zinx/chat-server/global"
+	"github.com/Xaytick/chat-zinx/chat-server/pkg/model"
+	"github.com/Xaytick/chat-zinx/chat-server/pkg/protocol"
+	"github.com/Xaytick/zinx/ziface"
+	"github.com/Xaytick/zinx/znet"
+)
+
+// --- BanGroupMemberRouter 封禁群成员 --- //
+type BanGroupMemberRouter struct {
+	znet.BaseRouter
+}
+
+func (r *BanGroupMemberRouter) Handle(request ziface.IRequest) {
+	userID, err := request.GetConnection().GetProperty("userID")
+	if err != nil || userID == nil {
+		fmt.Println("BanGroupMemberRouter: User not authenticated")
+		_ = request.GetConnection().SendMsg(protocol.MsgIDBanGroupMemberResp, []byte(`{"error":"用户未登录"}`))
+		return
+	}
+	uid := userID.(uint)
+
+	var req model.BanGroupMemberReq
+	if err := json.Unmarshal(request.GetData(), &req); err != nil {
+		fmt.Println("BanGroupMemberRouter: Invalid request data format - ", err)
+		_ = request.GetConnection().SendMsg(protocol.MsgIDBanGroupMemberResp, []byte(`{"error":"请求数据格式错误"}`))
+		return
+	}
+
+	duration := time.Duration(req.DurationSeconds) * time.Second
+	if err := global.GroupService.BanGroupMember(uid, req.GroupID, req.TargetUserID, req.Reason, duration); err != nil {
+		fmt.Printf("BanGroupMemberRouter: User %d failed to ban user %d in group %d - %s\n",
+			uid, req.TargetUserID, req.GroupID, err.Error())
+		respData, _ := json.Marshal(map[string]string{"error": fmt.Sprintf("封禁用户失败: %s", err.Error())})
+		_ = request.GetConnection().SendMsg(protocol.MsgIDBanGroupMemberResp, respData)
+		return
+	}
+
+	respData, _ := json.Marshal(map[string]string{"message": "用户已被封禁并移出群组"})
+	_ = request.GetConnection().SendMsg(protocol.MsgIDBanGroupMemberResp, respData)
+	fmt.Printf("User %d banned user %d from group %d (duration: %ds)\n", uid, req.TargetUserID, req.GroupID, req.DurationSeconds)
+}
+
+// --- MuteGroupMemberRouter 禁言群成员 --- //
+type MuteGroupMemberRouter struct {
+	znet.BaseRouter
+}
+
+func (r *MuteGroupMemberRouter) Handle(request ziface.IRequest) {
+	userID, err := request.GetConnection().GetProperty("userID")
+	if err != nil || userID == nil {
+		fmt.Println("MuteGroupMemberRouter: User not authenticated")
+		_ = request.GetConnection().SendMsg(protocol.MsgIDMuteGroupMemberResp, []byte(`{"error":"用户未登录"}`))
+		return
+	}
+	uid := userID.(uint)
+
+	var req model.MuteGroupMemberReq
+	if err := json.Unmarshal(request.GetData(), &req); err != nil {
+		fmt.Println("MuteGroupMemberRouter: Invalid request data format - ", err)
+		_ = request.GetConnection().SendMsg(protocol.MsgIDMuteGroupMemberResp, []byte(`{"error":"请求数据格式错误"}`))
+		return
+	}
+
+	duration := time.Duration(req.DurationSeconds) * time.Second
+	if err := global.GroupService.MuteGroupMember(uid, req.GroupID, req.TargetUserID, duration); err != nil {
+		fmt.Printf("MuteGroupMemberRouter: User %d failed to mute user %d in group %d - %s\n",
+			uid, req.TargetUserID, req.GroupID, err.Error())
+		respData, _ := json.Marshal(map[string]string{"error": fmt.Sprintf("禁言用户失败: %s", err.Error())})
+		_ = request.GetConnection().SendMsg(protocol.MsgIDMuteGroupMemberResp, respData)
+		return
+	}
+
+	message := "用户已被禁言"
+	if req.DurationSeconds == 0 {
+		message = "已解除禁言"
+	}
+	respData, _ := json.Marshal(map[string]string{"message": message})
+	_ = request.GetConnection().SendMsg(protocol.MsgIDMuteGroupMemberResp, respData)
+	fmt.Printf("User %d muted user %d in group %d (duration: %ds)\n", uid, req.TargetUserID, req.GroupID, req.DurationSeconds)
+}
+
+// --- UnbanGroupMemberRouter 解除群组封禁 --- //
+type UnbanGroupMemberRouter struct {
+	znet.BaseRouter
+}
+
+func (r *UnbanGroupMemberRouter) Handle(request ziface.IRequest) {
+	userID, err := request.GetConnection().GetProperty("userID")
+	if err != nil || userID == nil {
+		fmt.Println("UnbanGroupMemberRouter: User not authenticated")
+		_ = request.GetConnection().SendMsg(protocol.MsgIDUnbanGroupMemberResp, []byte(`{"error":"用户未登录"}`))
+		return
+	}
+	uid := userID.(uint)
+
+	var req model.UnbanGroupMemberReq
+	if err := json.Unmarshal(request.GetData(), &req); err != nil {
+		fmt.Println("UnbanGroupMemberRouter: Invalid request data format - ", err)
+		_ = request.GetConnection().SendMsg(protocol.MsgIDUnbanGroupMemberResp, []byte(`{"error":"请求数据格式错误"}`))
+		return
+	}
+
+	if err := global.GroupService.UnbanGroupMember(uid, req.GroupID, req.TargetUserID); err != nil {
+		fmt.Printf("UnbanGroupMemberRouter: User %d failed to unban user %d in group %d - %s\n",
+			uid, req.TargetUserID, req.GroupID, err.Error())
+		respData, _ := json.Marshal(map[string]string{"error": fmt.Sprintf("解除封禁失败: %s", err.Error())})
+		_ = request.GetConnection().SendMsg(protocol.MsgIDUnbanGroupMemberResp, respData)
+		return
+	}
+
+	respData, _ := json.Marshal(map[string]string{"message": "已解除封禁"})
+	_ = request.GetConnection().SendMsg(protocol.MsgIDUnbanGroupMemberResp, respData)
+	fmt.Printf("User %d unbanned user %d in group %d\n", uid, req.TargetUserID, req.GroupID)
+}
+
+// --- GetGroupBanListRouter 获取群组封禁列表 --- //
+type GetGroupBanListRouter struct {
+	znet.BaseRouter
+}
+
+func (r *GetGroupBanListRouter) Handle(request ziface.IRequest) {
+	userID, err := request.GetConnection().GetProperty("userID")
+	if err != nil || userID == nil {
+		fmt.Println("GetGroupBanListRouter: User not authenticated")
+		_ = request.GetConnection().SendMsg(protocol.MsgIDGetGroupBanListResp, []byte(`{"error":"用户未登录"}`))
+		return
+	}
+	uid := userID.(uint)
+
+	var req model.GetGroupBanListReq
+	if err := json.Unmarshal(request.GetData(), &req); err != nil {
+		fmt.Println("GetGroupBanListRouter: Invalid request data format - ", err)
+		_ = request.GetConnection().SendMsg(protocol.MsgIDGetGroupBanListResp, []byte(`{"error":"请求数据格式错误"}`))
+		return
+	}
+
+	bans, err := global.GroupService.GetGroupBanList(uid, req.GroupID)
+	if err != nil {
+		fmt.Printf("GetGroupBanListRouter: User %d failed to get ban list of group %d - %s\n", uid, req.GroupID, err.Error())
+		respData, _ := json.Marshal(map[string]string{"error": fmt.Sprintf("获取封禁列表失败: %s", err.Error())})
+		_ = request.GetConnection().SendMsg(protocol.MsgIDGetGroupBanListResp, respData)
+		return
+	}
+
+	resp := model.GetGroupBanListResp{
+		GroupID: req.GroupID,
+		Bans:    bans,
+		Total:   len(bans),
+	}
+	respData, _ := json.Marshal(resp)
+	_ = request.GetConnection().SendMsg(protocol.MsgIDGetGroupBanListResp, respData)
+	fmt.Printf("Retrieved %d bans for group %d\n", len(bans), req.GroupID)
+}
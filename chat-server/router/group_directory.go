@@ -0,0 +1,48 @@
+package router
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/Xaytick/chat-zinx/chat-server/global"
+	"github.com/Xaytick/chat-zinx/chat-server/pkg/framing"
+	"github.com/Xaytick/chat-zinx/chat-server/pkg/model"
+	"github.com/Xaytick/chat-zinx/chat-server/pkg/protocol"
+	"github.com/Xaytick/zinx/ziface"
+	"github.com/Xaytick/zinx/znet"
+)
+
+// --- SearchGroupsRouter 搜索公开群组目录 --- //
+type SearchGroupsRouter struct {
+	znet.BaseRouter
+}
+
+func (r *SearchGroupsRouter) Handle(request ziface.IRequest) {
+	userID, err := request.GetConnection().GetProperty("userID")
+	if err != nil || userID == nil {
+		fmt.Println("SearchGroupsRouter: User not authenticated")
+		_ = request.GetConnection().SendMsg(protocol.MsgIDSearchGroupsResp, []byte(`{"error":"用户未登录"}`))
+		return
+	}
+	uid := userID.(uint)
+
+	var req model.SearchGroupsReq
+	if err := json.Unmarshal(request.GetData(), &req); err != nil {
+		fmt.Println("SearchGroupsRouter: Invalid request data format - ", err)
+		_ = request.GetConnection().SendMsg(protocol.MsgIDSearchGroupsResp, []byte(`{"error":"请求数据格式错误"}`))
+		return
+	}
+
+	resp, err := global.GroupService.SearchPublicGroups(&req)
+	if err != nil {
+		fmt.Printf("SearchGroupsRouter: User %d failed to search groups - %s\n", uid, err.Error())
+		respData, _ := json.Marshal(map[string]string{"error": fmt.Sprintf("搜索群组失败: %s", err.Error())})
+		_ = request.GetConnection().SendMsg(protocol.MsgIDSearchGroupsResp, respData)
+		return
+	}
+
+	respData, _ := json.Marshal(resp)
+	_ = framing.SendMsg(request.GetConnection(), protocol.MsgIDSearchGroupsResp, respData)
+	fmt.Printf("User %d searched groups (keyword: %q, category: %q, tag: %q), %d of %d returned\n",
+		uid, req.Keyword, req.Category, req.Tag, len(resp.Groups), resp.Total)
+}
@@ -2,12 +2,15 @@ package router
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"time"
 
 	"github.com/Xaytick/chat-zinx/chat-server/global"
+	"github.com/Xaytick/chat-zinx/chat-server/pkg/framing"
 	"github.com/Xaytick/chat-zinx/chat-server/pkg/model"
 	"github.com/Xaytick/chat-zinx/chat-server/pkg/protocol"
+	"github.com/Xaytick/chat-zinx/chat-server/pkg/service"
 	"github.com/Xaytick/zinx/ziface"
 	"github.com/Xaytick/zinx/znet"
 )
@@ -46,18 +49,27 @@ func (r *GroupTextMsgRouter) Handle(request ziface.IRequest) {
 
 	fmt.Printf("[GroupMsgRouter] UserID %d (%s) sending message to GroupID %d: %s\n", userID, username, reqPayload.GroupID, reqPayload.Content)
 
-	// 1. 验证用户是否为群组成员
-	isMember, err := global.GroupService.IsUserInGroup(userID, uint(reqPayload.GroupID))
-	if err != nil {
-		fmt.Printf("[GroupMsgRouter] UserID %d: Error checking group membership for GroupID %d: %v\n", userID, reqPayload.GroupID, err)
-		resp := model.GroupTextMsgResp{Status: 2, Error: "Failed to verify group membership"}
+	// 1. 验证用户是否为群组成员且拥有发言权限，图片、文件等附件消息还需要上传权限
+	perm := model.GroupPermSendMessage
+	switch reqPayload.MessageType {
+	case "", model.GroupMessageTypeText:
+		reqPayload.MessageType = ""
+	case model.GroupMessageTypeImage, model.GroupMessageTypeFile:
+		perm |= model.GroupPermUploadFile
+	default:
+		resp := model.GroupTextMsgResp{Status: 1, Error: "Unsupported message type"}
 		respData, _ := json.Marshal(resp)
 		conn.SendMsg(protocol.MsgIDGroupTextMsgResp, respData)
 		return
 	}
-	if !isMember {
-		fmt.Printf("[GroupMsgRouter] UserID %d is not a member of GroupID %d. Message rejected.\n", userID, reqPayload.GroupID)
-		resp := model.GroupTextMsgResp{Status: 3, Error: "You are not a member of this group"}
+	if err := global.GroupService.CheckPermission(userID, uint(reqPayload.GroupID), perm); err != nil {
+		resp := model.GroupTextMsgResp{Status: 3, Error: err.Error()}
+		if !errors.Is(err, service.ErrNotGroupMember) && !errors.Is(err, service.ErrGroupPermissionDenied) {
+			fmt.Printf("[GroupMsgRouter] UserID %d: Error checking group permission for GroupID %d: %v\n", userID, reqPayload.GroupID, err)
+			resp = model.GroupTextMsgResp{Status: 2, Error: "Failed to verify group membership"}
+		} else {
+			fmt.Printf("[GroupMsgRouter] UserID %d cannot send to GroupID %d: %v. Message rejected.\n", userID, reqPayload.GroupID, err)
+		}
 		respData, _ := json.Marshal(resp)
 		conn.SendMsg(protocol.MsgIDGroupTextMsgResp, respData)
 		return
@@ -73,65 +85,35 @@ func (r *GroupTextMsgRouter) Handle(request ziface.IRequest) {
 		return
 	}
 
-	// 3. 保存消息到数据库
-	msgID, err := global.MessageService.SaveGroupMessage(
-		uint(reqPayload.GroupID),
-		userID,
-		userUUID,
-		username,
-		reqPayload.Content,
-		"text", // 默认为文本消息类型
-	)
-	if err != nil {
-		fmt.Printf("[GroupMsgRouter] UserID %d: Failed to save message to database for GroupID %d: %v\n", userID, reqPayload.GroupID, err)
-		// 这是一个非关键错误，我们仍然可以继续处理，但需要记录日志
-	}
-
-	// 4. 构建推送消息
-	pushMsg := model.GroupTextMsgPush{
+	// 3. 保存消息并交给推送协程池向群内其他在线成员推送（不给自己推送），避免在请求 worker 中逐个发送
+	msgID, membersQueued, err := publishGroupMessage(&model.GroupTextMsgPush{
 		GroupID:      reqPayload.GroupID,
 		FromUserID:   userID,
 		FromUserUUID: userUUID,
 		FromUsername: username,
 		Content:      reqPayload.Content,
+		MessageType:  reqPayload.MessageType,
 		Timestamp:    time.Now().Unix(),
-	}
-	pushData, err := json.Marshal(pushMsg)
+	}, memberIDs)
 	if err != nil {
-		fmt.Printf("[GroupMsgRouter] UserID %d: Failed to marshal GroupTextMsgPush for GroupID %d: %v\n", userID, reqPayload.GroupID, err)
-		// This is an internal server error, might not need to send specific error to client here,
-		// but a general success ack (step 4) might fail or be misleading.
-		// For now, let's send a generic error back if marshalling fails.
+		fmt.Printf("[GroupMsgRouter] UserID %d: Failed to publish message to GroupID %d: %v\n", userID, reqPayload.GroupID, err)
 		resp := model.GroupTextMsgResp{Status: 5, Error: "Internal server error preparing message"}
 		respData, _ := json.Marshal(resp)
 		conn.SendMsg(protocol.MsgIDGroupTextMsgResp, respData)
 		return
 	}
+	fmt.Printf("[GroupMsgRouter] Message from UserID %d to GroupID %d queued for %d members.\n", userID, reqPayload.GroupID, membersQueued)
 
-	// 5. 向群内其他在线成员推送消息
-	connMgr := global.GlobalServer.GetConnManager() // Attempt to call the GetConnManager method on *znet.Server
-	membersNotified := 0
-	for _, memberID := range memberIDs {
-		if memberID == userID { // 不给自己推送
-			continue
-		}
-		targetConn := connMgr.GetConnByUserID(memberID) // Assuming GetConnByUserID is implemented
-		if targetConn != nil {
-			fmt.Printf("[GroupMsgRouter] Pushing message from UserID %d to UserID %d in GroupID %d\n", userID, memberID, reqPayload.GroupID)
-			err := targetConn.SendMsg(protocol.MsgIDGroupTextMsgPush, pushData)
-			if err != nil {
-				fmt.Printf("[GroupMsgRouter] Failed to send group message to UserID %d in GroupID %d: %v\n", memberID, reqPayload.GroupID, err)
-				// TODO: Handle case where sending to a member fails (e.g. connection closed suddenly)
-			} else {
-				membersNotified++
-			}
-		} else {
-			// fmt.Printf("[GroupMsgRouter] UserID %d in GroupID %d is offline or not found in ConnMgr.\n", memberID, reqPayload.GroupID)
-		}
-	}
-	fmt.Printf("[GroupMsgRouter] Message from UserID %d to GroupID %d pushed to %d online members.\n", userID, reqPayload.GroupID, membersNotified)
+	// 命令前缀或 @ 提及匹配的机器人 webhook 异步投递，回复由投递协程池发回群里
+	triggerBotWebhooks(conn, uint(reqPayload.GroupID), &model.WebhookMessage{
+		ID:           msgID,
+		FromUserID:   userID,
+		FromUserUUID: userUUID,
+		FromUsername: username,
+		Content:      reqPayload.Content,
+	}, memberIDs)
 
-	// 6. 向发送者回复成功
+	// 4. 向发送者回复成功
 	successResp := model.GroupTextMsgResp{Status: 0, MsgID: msgID}
 	successRespData, _ := json.Marshal(successResp)
 	conn.SendMsg(protocol.MsgIDGroupTextMsgResp, successRespData)
@@ -181,6 +163,6 @@ func (r *GroupHistoryMsgRouter) Handle(request ziface.IRequest) {
 
 	// 发送响应
 	respData, _ := json.Marshal(resp)
-	conn.SendMsg(protocol.MsgIDGroupHistoryMsgResp, respData)
+	framing.SendMsg(conn, protocol.MsgIDGroupHistoryMsgResp, respData)
 	fmt.Printf("[GroupHistoryMsgRouter] Retrieved %d messages for GroupID %d (UserID %d)\n", len(resp.Messages), req.GroupID, userID)
 }
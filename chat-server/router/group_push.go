@@ -0,0 +1,60 @@
+package router
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/Xaytick/chat-zinx/chat-server/global"
+	"github.com/Xaytick/chat-zinx/chat-server/pkg/model"
+	"github.com/Xaytick/chat-zinx/chat-server/pkg/protocol"
+)
+
+// pushToGroupMembers 异步推送消息给群组内除 excludeUserID 外的所有在线成员，返回投递推送的成员数
+func pushToGroupMembers(groupID uint, msgID uint32, data []byte, excludeUserID uint) (int, error) {
+	memberIDs, err := global.GroupService.GetGroupMemberIDs(groupID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get member IDs for GroupID %d: %w", groupID, err)
+	}
+	return dispatchToMembers(groupID, msgID, data, memberIDs, excludeUserID), nil
+}
+
+// dispatchToMembers 将推送交给群消息推送协程池，离线成员在推送时跳过
+func dispatchToMembers(groupID uint, msgID uint32, data []byte, memberIDs []uint, excludeUserID uint) int {
+	recipients := make([]uint, 0, len(memberIDs))
+	for _, memberID := range memberIDs {
+		if memberID != excludeUserID {
+			recipients = append(recipients, memberID)
+		}
+	}
+	return global.GroupFanout.Dispatch(groupID, msgID, data, recipients)
+}
+
+// publishGroupMessage 保存群消息并推送给 memberIDs 中除发送者外的在线成员，返回消息ID和投递推送的成员数
+// 用户消息和机器人回复经过这里；保存失败只记录日志，消息仍会推送
+func publishGroupMessage(push *model.GroupTextMsgPush, memberIDs []uint) (string, int, error) {
+	msgID, err := saveGroupMessage(push)
+	if err != nil {
+		fmt.Printf("[GroupPush] Failed to save message from %s to GroupID %d: %v\n", push.FromUsername, push.GroupID, err)
+	}
+	queued, err := pushGroupMessage(push, memberIDs)
+	return msgID, queued, err
+}
+
+// saveGroupMessage 保存群消息，返回消息ID
+func saveGroupMessage(push *model.GroupTextMsgPush) (string, error) {
+	msgType := push.MessageType
+	if msgType == "" {
+		msgType = "text"
+	}
+	return global.MessageService.SaveGroupMessage(uint(push.GroupID), push.FromUserID, push.FromUserUUID,
+		push.FromUsername, push.Content, msgType)
+}
+
+// pushGroupMessage 把已保存的群消息推送给 memberIDs 中除发送者外的在线成员，返回投递推送的成员数
+func pushGroupMessage(push *model.GroupTextMsgPush, memberIDs []uint) (int, error) {
+	pushData, err := json.Marshal(push)
+	if err != nil {
+		return 0, fmt.Errorf("failed to marshal group message push: %w", err)
+	}
+	return dispatchToMembers(uint(push.GroupID), protocol.MsgIDGroupTextMsgPush, pushData, memberIDs, push.FromUserID), nil
+}
@@ -0,0 +1,117 @@
+package router
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/Xaytick/chat-zinx/chat-server/global"
+	"github.com/Xaytick/chat-zinx/chat-server/pkg/model"
+	"github.com/Xaytick/chat-zinx/chat-server/pkg/protocol"
+	"github.com/Xaytick/zinx/ziface"
+	"github.com/Xaytick/zinx/znet"
+)
+
+// --- SaveGroupRoleRouter 创建或修改群组自定义角色 --- //
+type SaveGroupRoleRouter struct {
+	znet.BaseRouter
+}
+
+func (r *SaveGroupRoleRouter) Handle(request ziface.IRequest) {
+	userID, err := request.GetConnection().GetProperty("userID")
+	if err != nil || userID == nil {
+		fmt.Println("SaveGroupRoleRouter: User not authenticated")
+		_ = request.GetConnection().SendMsg(protocol.MsgIDSaveGroupRoleResp, []byte(`{"error":"用户未登录"}`))
+		return
+	}
+	uid := userID.(uint)
+
+	var req model.SaveGroupRoleReq
+	if err := json.Unmarshal(request.GetData(), &req); err != nil {
+		fmt.Println("SaveGroupRoleRouter: Invalid request data format - ", err)
+		_ = request.GetConnection().SendMsg(protocol.MsgIDSaveGroupRoleResp, []byte(`{"error":"请求数据格式错误"}`))
+		return
+	}
+
+	role, err := global.GroupService.SaveGroupRole(uid, &req)
+	if err != nil {
+		fmt.Printf("SaveGroupRoleRouter: User %d failed to save role '%s' in group %d - %s\n", uid, req.Name, req.GroupID, err.Error())
+		respData, _ := json.Marshal(map[string]string{"error": fmt.Sprintf("保存角色失败: %s", err.Error())})
+		_ = request.GetConnection().SendMsg(protocol.MsgIDSaveGroupRoleResp, respData)
+		return
+	}
+
+	respData, _ := json.Marshal(role)
+	_ = request.GetConnection().SendMsg(protocol.MsgIDSaveGroupRoleResp, respData)
+	fmt.Printf("User %d saved role '%s' in group %d\n", uid, role.Name, req.GroupID)
+}
+
+// --- DeleteGroupRoleRouter 删除群组自定义角色 --- //
+type DeleteGroupRoleRouter struct {
+	znet.BaseRouter
+}
+
+func (r *DeleteGroupRoleRouter) Handle(request ziface.IRequest) {
+	userID, err := request.GetConnection().GetProperty("userID")
+	if err != nil || userID == nil {
+		fmt.Println("DeleteGroupRoleRouter: User not authenticated")
+		_ = request.GetConnection().SendMsg(protocol.MsgIDDeleteGroupRoleResp, []byte(`{"error":"用户未登录"}`))
+		return
+	}
+	uid := userID.(uint)
+
+	var req model.DeleteGroupRoleReq
+	if err := json.Unmarshal(request.GetData(), &req); err != nil {
+		fmt.Println("DeleteGroupRoleRouter: Invalid request data format - ", err)
+		_ = request.GetConnection().SendMsg(protocol.MsgIDDeleteGroupRoleResp, []byte(`{"error":"请求数据格式错误"}`))
+		return
+	}
+
+	if err := global.GroupService.DeleteGroupRole(uid, req.GroupID, req.Name); err != nil {
+		fmt.Printf("DeleteGroupRoleRouter: User %d failed to delete role '%s' in group %d - %s\n", uid, req.Name, req.GroupID, err.Error())
+		respData, _ := json.Marshal(map[string]string{"error": fmt.Sprintf("删除角色失败: %s", err.Error())})
+		_ = request.GetConnection().SendMsg(protocol.MsgIDDeleteGroupRoleResp, respData)
+		return
+	}
+
+	respData, _ := json.Marshal(map[string]string{"message": "角色已删除，持有该角色的成员已恢复为普通成员"})
+	_ = request.GetConnection().SendMsg(protocol.MsgIDDeleteGroupRoleResp, respData)
+	fmt.Printf("User %d deleted role '%s' in group %d\n", uid, req.Name, req.GroupID)
+}
+
+// --- GetGroupRolesRouter 获取群组角色列表 --- //
+type GetGroupRolesRouter struct {
+	znet.BaseRouter
+}
+
+func (r *GetGroupRolesRouter) Handle(request ziface.IRequest) {
+	userID, err := request.GetConnection().GetProperty("userID")
+	if err != nil || userID == nil {
+		fmt.Println("GetGroupRolesRouter: User not authenticated")
+		_ = request.GetConnection().SendMsg(protocol.MsgIDGetGroupRolesResp, []byte(`{"error":"用户未登录"}`))
+		return
+	}
+	uid := userID.(uint)
+
+	var req model.GetGroupRolesReq
+	if err := json.Unmarshal(request.GetData(), &req); err != nil {
+		fmt.Println("GetGroupRolesRouter: Invalid request data format - ", err)
+		_ = request.GetConnection().SendMsg(protocol.MsgIDGetGroupRolesResp, []byte(`{"error":"请求数据格式错误"}`))
+		return
+	}
+
+	roles, err := global.GroupService.GetGroupRoles(uid, req.GroupID)
+	if err != nil {
+		fmt.Printf("GetGroupRolesRouter: User %d failed to get roles of group %d - %s\n", uid, req.GroupID, err.Error())
+		respData, _ := json.Marshal(map[string]string{"error": fmt.Sprintf("获取角色列表失败: %s", err.Error())})
+		_ = request.GetConnection().SendMsg(protocol.MsgIDGetGroupRolesResp, respData)
+		return
+	}
+
+	resp := model.GetGroupRolesResp{
+		GroupID: req.GroupID,
+		Roles:   roles,
+	}
+	respData, _ := json.Marshal(resp)
+	_ = request.GetConnection().SendMsg(protocol.MsgIDGetGroupRolesResp, respData)
+	fmt.Printf("Retrieved %d roles for group %d\n", len(roles), req.GroupID)
+}
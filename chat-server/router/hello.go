@@ -0,0 +1,229 @@
+package router
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/Xaytick/chat-zinx/chat-server/conf"
+	"github.com/Xaytick/chat-zinx/chat-server/pkg/framing"
+	"github.com/Xaytick/chat-zinx/chat-server/pkg/model"
+	"github.com/Xaytick/chat-zinx/chat-server/pkg/protocol"
+	"github.com/Xaytick/zinx/ziface"
+	"github.com/Xaytick/zinx/znet"
+)
+
+// handshakeKey 连接属性，握手完成后记录协商结果 *model.Handshake
+const handshakeKey = "handshake"
+
+// 握手响应状态码
+const (
+	helloCodeOK                 = 0
+	helloCodeBadRequest         = 1
+	helloCodeUnsupportedVersion = 2 // 客户端协议版本过低
+	helloCodeUnsupportedCodec   = 3 // 没有双方都支持的编码
+	helloCodeAlreadyDone        = 4 // 当前连接已完成握手
+)
+
+// helloRejectCloseDelay 拒绝握手后等待响应写出再关闭连接的时间
+const helloRejectCloseDelay = time.Second
+
+// handshakeRequiredCode 开启 Handshake.Required 后拒绝未握手连接的登录请求时使用的状态码，避开登录已用的 1-9
+const handshakeRequiredCode = 10
+
+// HelloRouter 处理连接建立后的握手请求
+// 协议版本低于 Handshake.MinProtocolVersion 或没有可用编码时返回错误并关闭连接，客户端应提示用户升级
+type HelloRouter struct {
+	znet.BaseRouter
+}
+
+func (r *HelloRouter) Handle(request ziface.IRequest) {
+	conn := request.GetConnection()
+	if GetHandshake(conn) != nil {
+		sendHelloResponse(conn, helloCodeAlreadyDone, "当前连接已完成握手", nil)
+		return
+	}
+
+	var req model.HelloReq
+	if err := json.Unmarshal(request.GetData(), &req); err != nil {
+		rejectHello(conn, helloCodeBadRequest, "请求数据格式错误", nil)
+		return
+	}
+
+	cfg := conf.GetHandshakeConfig()
+	if req.ProtocolVersion < cfg.MinProtocolVersion {
+		fmt.Printf("Rejecting client %s %s on ConnID %d: protocol version %d < %d\n",
+			req.ClientName, req.ClientVersion, conn.GetConnID(), req.ProtocolVersion, cfg.MinProtocolVersion)
+		rejectHello(conn, helloCodeUnsupportedVersion, "客户端版本过低，请升级后重试", map[string]int{
+			"min_protocol_version":    cfg.MinProtocolVersion,
+			"server_protocol_version": protocol.ProtocolVersion,
+		})
+		return
+	}
+
+	codecs := req.Codecs
+	if len(codecs) == 0 {
+		codecs = []string{protocol.CodecJSON}
+	}
+	codec := negotiate(protocol.SupportedCodecs, codecs)
+	if codec == "" {
+		rejectHello(conn, helloCodeUnsupportedCodec, "没有服务端支持的消息编码", map[string][]string{
+			"codecs": protocol.SupportedCodecs,
+		})
+		return
+	}
+	compression := negotiate(allowedCompression(), req.Compression)
+	if compression == "" {
+		compression = protocol.CompressionNone
+	}
+
+	enabled := enabledFeatures()
+	handshake := &model.Handshake{
+		ProtocolVersion: min(req.ProtocolVersion, protocol.ProtocolVersion),
+		ClientName:      req.ClientName,
+		ClientVersion:   req.ClientVersion,
+		Codec:           codec,
+		Compression:     compression,
+		Features:        make(map[string]bool),
+	}
+	features := []string{}
+	for _, feature := range req.Features {
+		if containsString(enabled, feature) && !handshake.Features[feature] {
+			handshake.Features[feature] = true
+			features = append(features, feature)
+		}
+	}
+	conn.SetProperty(handshakeKey, handshake)
+
+	resume := conf.GetSessionResumeConfig()
+	sendHelloResponse(conn, helloCodeOK, "ok", &model.HelloResp{
+		ProtocolVersion:       handshake.ProtocolVersion,
+		ServerProtocolVersion: protocol.ProtocolVersion,
+		MinProtocolVersion:    cfg.MinProtocolVersion,
+		ServerVersion:         protocol.ServerVersion,
+		Codec:                 codec,
+		Compression:           compression,
+		Features:              features,
+		EnabledFeatures:       enabled,
+		Limits: model.HelloLimits{
+			MaxPacketSize:     conf.GetMaxPacketSize(),
+			HeartbeatInterval: conf.GetHeartbeatInterval(),
+			HeartbeatTimeout:  conf.GetHeartbeatTimeout(),
+			ResumeWindow:      resume.Window,
+		},
+	})
+	// 响应发出后再开启压缩，客户端收到响应才知道协商结果
+	framing.EnableCompression(conn, compression, conf.GetCompressionConfig().Threshold)
+	fmt.Printf("Handshake on ConnID %d: %s %s, protocol v%d, codec %s, compression %s\n",
+		conn.GetConnID(), req.ClientName, req.ClientVersion, handshake.ProtocolVersion, codec, compression)
+}
+
+// GetHandshake 返回连接的握手结果，未握手（旧客户端）时返回 nil
+func GetHandshake(conn ziface.IConnection) *model.Handshake {
+	value, err := conn.GetProperty(handshakeKey)
+	if err != nil || value == nil {
+		return nil
+	}
+	return value.(*model.Handshake)
+}
+
+// ProtocolVersionOf 连接协商的协议版本，未握手的旧客户端为 0，路由可据此兼容旧的消息格式
+func ProtocolVersionOf(conn ziface.IConnection) int {
+	if handshake := GetHandshake(conn); handshake != nil {
+		return handshake.ProtocolVersion
+	}
+	return 0
+}
+
+// enabledFeatures 服务端当前启用的功能
+func enabledFeatures() []string {
+	features := []string{protocol.FeatureTwoFactor, protocol.FeatureBots}
+	if conf.GetSessionResumeConfig().Window > 0 {
+		features = append(features, protocol.FeatureSessionResume)
+	}
+	if conf.GetAccountConfig().GuestEnabled {
+		features = append(features, protocol.FeatureGuestLogin)
+	}
+	return features
+}
+
+// allowedCompression 配置允许且已实现的压缩算法，始终包括不压缩
+func allowedCompression() []string {
+	allowed := []string{}
+	if cfg := conf.GetCompressionConfig(); cfg.Enabled {
+		for _, algorithm := range cfg.Algorithms {
+			if algorithm != protocol.CompressionNone && containsString(protocol.SupportedCompression, algorithm) {
+				allowed = append(allowed, algorithm)
+			}
+		}
+	}
+	return append(allowed, protocol.CompressionNone)
+}
+
+// negotiate 按客户端的优先级选出第一个服务端也支持的选项，没有时返回空字符串
+func negotiate(supported, offered []string) string {
+	for _, option := range offered {
+		if containsString(supported, option) {
+			return option
+		}
+	}
+	return ""
+}
+
+func containsString(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
+
+// rejectHello 返回握手失败的响应并关闭连接
+// SendMsg 只是把响应交给写协程，立即 Stop 可能在写出前关闭连接，客户端只能看到连接断开，因此稍后再关闭
+func rejectHello(conn ziface.IConnection, code uint32, msg string, data interface{}) {
+	sendHelloResponse(conn, code, msg, data)
+	time.AfterFunc(helloRejectCloseDelay, conn.Stop)
+}
+
+func sendHelloResponse(conn ziface.IConnection, code uint32, msg string, data interface{}) {
+	response := map[string]interface{}{
+		"code": code,
+		"msg":  msg,
+	}
+	if data != nil {
+		response["data"] = data
+	}
+	jsonData, err := json.Marshal(response)
+	if err != nil {
+		fmt.Printf("序列化握手响应失败: %v\n", err)
+		return
+	}
+	_ = conn.SendMsg(protocol.MsgIDHelloResp, jsonData)
+}
+
+// helloRequiredRouter 开启 Handshake.Required 时包装登录类路由，拒绝未握手的连接
+type helloRequiredRouter struct {
+	ziface.IRouter
+	respMsgID uint32
+}
+
+// RequireHello 包装登录类路由，respMsgID 为内部路由使用 {code,msg,data} 格式的响应消息ID
+// 未开启 Handshake.Required 时直接交给内部路由，兼容不发送 hello 的旧客户端
+func RequireHello(respMsgID uint32, r ziface.IRouter) ziface.IRouter {
+	return &helloRequiredRouter{IRouter: r, respMsgID: respMsgID}
+}
+
+func (r *helloRequiredRouter) Handle(request ziface.IRequest) {
+	conn := request.GetConnection()
+	if !conf.GetHandshakeConfig().Required || GetHandshake(conn) != nil {
+		r.IRouter.Handle(request)
+		return
+	}
+	fmt.Printf("ConnID %d sent msgID %d before handshake, rejected\n", conn.GetConnID(), request.GetMsgID())
+	respData, _ := json.Marshal(map[string]interface{}{
+		"code": handshakeRequiredCode,
+		"msg":  "客户端版本过低，请升级后重试",
+	})
+	_ = conn.SendMsg(r.respMsgID, respData)
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 
 	"github.com/Xaytick/chat-zinx/chat-server/global"
+	"github.com/Xaytick/chat-zinx/chat-server/pkg/framing"
 	"github.com/Xaytick/chat-zinx/chat-server/pkg/model"
 	"github.com/Xaytick/chat-zinx/chat-server/pkg/protocol"
 	"github.com/Xaytick/chat-zinx/chat-server/pkg/service"
@@ -106,5 +107,5 @@ func sendHistoryResponse(request ziface.IRequest, code uint32, message string, d
 		fmt.Printf("序列化历史消息响应失败: %v\n", err)
 		return
 	}
-	request.GetConnection().SendMsg(protocol.MsgIDHistoryMsgResp, jsonData)
+	framing.SendMsg(request.GetConnection(), protocol.MsgIDHistoryMsgResp, jsonData)
 }
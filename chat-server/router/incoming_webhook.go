@@ -0,0 +1,160 @@
+package router
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Xaytick/chat-zinx/chat-server/global"
+	"github.com/Xaytick/chat-zinx/chat-server/pkg/model"
+	"github.com/Xaytick/chat-zinx/chat-server/pkg/protocol"
+	"github.com/Xaytick/chat-zinx/chat-server/pkg/service"
+	"github.com/Xaytick/zinx/ziface"
+	"github.com/Xaytick/zinx/znet"
+)
+
+// incomingWebhookErrMsg 将 incoming webhook 管理的错误转换为提示信息
+func incomingWebhookErrMsg(err error) string {
+	switch {
+	case errors.Is(err, service.ErrNotGroupMember):
+		return "你不是该群成员"
+	case errors.Is(err, service.ErrGroupPermissionDenied):
+		return "没有管理集成的权限 (需 manage_integrations 权限)"
+	case errors.Is(err, service.ErrInvalidIntegrationName):
+		return fmt.Sprintf("集成名称需为 1-%d 个字符", model.MaxIncomingWebhookNameLen)
+	case errors.Is(err, service.ErrTooManyIncomingWebhooks):
+		return "该群的 webhook 数量已达上限"
+	case errors.Is(err, service.ErrIncomingWebhookNotFound):
+		return "webhook 不存在或已吊销"
+	}
+	return err.Error()
+}
+
+// --- CreateIncomingWebhookRouter 为群组创建 incoming webhook --- //
+type CreateIncomingWebhookRouter struct {
+	znet.BaseRouter
+}
+
+func (r *CreateIncomingWebhookRouter) Handle(request ziface.IRequest) {
+	userID, err := request.GetConnection().GetProperty("userID")
+	if err != nil || userID == nil {
+		fmt.Println("CreateIncomingWebhookRouter: User not authenticated")
+		_ = request.GetConnection().SendMsg(protocol.MsgIDCreateIncomingWebhookResp, []byte(`{"error":"用户未登录"}`))
+		return
+	}
+	uid := userID.(uint)
+
+	var req model.CreateIncomingWebhookReq
+	if err := json.Unmarshal(request.GetData(), &req); err != nil {
+		fmt.Println("CreateIncomingWebhookRouter: Invalid request data format - ", err)
+		_ = request.GetConnection().SendMsg(protocol.MsgIDCreateIncomingWebhookResp, []byte(`{"error":"请求数据格式错误"}`))
+		return
+	}
+
+	resp, err := global.IncomingWebhookService.Create(uid, &req)
+	if err != nil {
+		fmt.Printf("CreateIncomingWebhookRouter: User %d failed to create webhook in group %d - %s\n", uid, req.GroupID, err.Error())
+		respData, _ := json.Marshal(map[string]string{"error": fmt.Sprintf("创建 webhook 失败: %s", incomingWebhookErrMsg(err))})
+		_ = request.GetConnection().SendMsg(protocol.MsgIDCreateIncomingWebhookResp, respData)
+		return
+	}
+
+	respData, _ := json.Marshal(resp)
+	_ = request.GetConnection().SendMsg(protocol.MsgIDCreateIncomingWebhookResp, respData)
+	fmt.Printf("User %d created incoming webhook %d (%s) in group %d\n", uid, resp.Webhook.ID, resp.Webhook.Name, req.GroupID)
+}
+
+// --- RevokeIncomingWebhookRouter 吊销 incoming webhook --- //
+type RevokeIncomingWebhookRouter struct {
+	znet.BaseRouter
+}
+
+func (r *RevokeIncomingWebhookRouter) Handle(request ziface.IRequest) {
+	userID, err := request.GetConnection().GetProperty("userID")
+	if err != nil || userID == nil {
+		fmt.Println("RevokeIncomingWebhookRouter: User not authenticated")
+		_ = request.GetConnection().SendMsg(protocol.MsgIDRevokeIncomingWebhookResp, []byte(`{"error":"用户未登录"}`))
+		return
+	}
+	uid := userID.(uint)
+
+	var req model.RevokeIncomingWebhookReq
+	if err := json.Unmarshal(request.GetData(), &req); err != nil {
+		fmt.Println("RevokeIncomingWebhookRouter: Invalid request data format - ", err)
+		_ = request.GetConnection().SendMsg(protocol.MsgIDRevokeIncomingWebhookResp, []byte(`{"error":"请求数据格式错误"}`))
+		return
+	}
+
+	if err := global.IncomingWebhookService.Revoke(uid, req.GroupID, req.WebhookID); err != nil {
+		fmt.Printf("RevokeIncomingWebhookRouter: User %d failed to revoke webhook %d in group %d - %s\n", uid, req.WebhookID, req.GroupID, err.Error())
+		respData, _ := json.Marshal(map[string]string{"error": fmt.Sprintf("吊销 webhook 失败: %s", incomingWebhookErrMsg(err))})
+		_ = request.GetConnection().SendMsg(protocol.MsgIDRevokeIncomingWebhookResp, respData)
+		return
+	}
+
+	respData, _ := json.Marshal(model.GenericMessageResp{Code: 0, Message: "webhook 已吊销"})
+	_ = request.GetConnection().SendMsg(protocol.MsgIDRevokeIncomingWebhookResp, respData)
+	fmt.Printf("User %d revoked incoming webhook %d in group %d\n", uid, req.WebhookID, req.GroupID)
+}
+
+// --- GetIncomingWebhooksRouter 查询群组的 incoming webhook --- //
+type GetIncomingWebhooksRouter struct {
+	znet.BaseRouter
+}
+
+func (r *GetIncomingWebhooksRouter) Handle(request ziface.IRequest) {
+	userID, err := request.GetConnection().GetProperty("userID")
+	if err != nil || userID == nil {
+		fmt.Println("GetIncomingWebhooksRouter: User not authenticated")
+		_ = request.GetConnection().SendMsg(protocol.MsgIDGetIncomingWebhooksResp, []byte(`{"error":"用户未登录"}`))
+		return
+	}
+	uid := userID.(uint)
+
+	var req model.GetIncomingWebhooksReq
+	if err := json.Unmarshal(request.GetData(), &req); err != nil {
+		fmt.Println("GetIncomingWebhooksRouter: Invalid request data format - ", err)
+		_ = request.GetConnection().SendMsg(protocol.MsgIDGetIncomingWebhooksResp, []byte(`{"error":"请求数据格式错误"}`))
+		return
+	}
+
+	hooks, err := global.IncomingWebhookService.List(uid, req.GroupID)
+	if err != nil {
+		fmt.Printf("GetIncomingWebhooksRouter: User %d failed to get webhooks of group %d - %s\n", uid, req.GroupID, err.Error())
+		respData, _ := json.Marshal(map[string]string{"error": fmt.Sprintf("查询 webhook 失败: %s", incomingWebhookErrMsg(err))})
+		_ = request.GetConnection().SendMsg(protocol.MsgIDGetIncomingWebhooksResp, respData)
+		return
+	}
+
+	respData, _ := json.Marshal(&model.GetIncomingWebhooksResp{GroupID: req.GroupID, Webhooks: hooks})
+	_ = request.GetConnection().SendMsg(protocol.MsgIDGetIncomingWebhooksResp, respData)
+}
+
+// PublishIntegrationMessage 以集成名称把 incoming webhook 的消息发到群里，实现 httpapi.IntegrationPublisher
+// 集成不是群成员，发送者ID为 0，所有在线成员都会收到推送；不触发机器人 webhook
+// 与用户消息不同，保存失败时不推送并返回错误，调用方会收到 5xx 并可以重试
+func PublishIntegrationMessage(hook *model.IncomingWebhook, content string) (string, error) {
+	memberIDs, err := global.GroupService.GetGroupMemberIDs(hook.GroupID)
+	if err != nil {
+		return "", fmt.Errorf("failed to get member IDs for GroupID %d: %w", hook.GroupID, err)
+	}
+
+	push := &model.GroupTextMsgPush{
+		GroupID:      uint32(hook.GroupID),
+		FromUsername: hook.Name,
+		Content:      content,
+		Timestamp:    time.Now().Unix(),
+		MessageType:  model.GroupMessageTypeIntegration,
+	}
+	msgID, err := saveGroupMessage(push)
+	if err != nil {
+		return "", fmt.Errorf("failed to save message to GroupID %d: %w", hook.GroupID, err)
+	}
+	queued, err := pushGroupMessage(push, memberIDs)
+	if err != nil {
+		return msgID, err
+	}
+	fmt.Printf("[IncomingWebhook] Message from integration %d (%s) to GroupID %d queued for %d members\n", hook.ID, hook.Name, hook.GroupID, queued)
+	return msgID, nil
+}
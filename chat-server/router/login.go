@@ -4,6 +4,9 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"math"
+	"net"
+	"time"
 
 	"github.com/Xaytick/chat-zinx/chat-server/global"
 	"github.com/Xaytick/chat-zinx/chat-server/pkg/model"
@@ -41,61 +44,82 @@ func (lr *LoginRouter) Handle(request ziface.IRequest) {
 	}
 
 	// 调用用户服务验证用户名/密码
+	loginReq.ClientIP = clientIP(request.GetConnection())
+	client := &model.LoginClientInfo{
+		IP:            loginReq.ClientIP,
+		ClientVersion: loginReq.ClientVersion,
+		DeviceID:      loginReq.DeviceID,
+	}
 	tokenString, user, err := global.UserService.Login(&loginReq)
 
 	if err != nil {
-		// 尝试自动注册的逻辑 (如果仍然需要)
-		// 注意：如果Login失败是因为用户不存在，且希望自动注册，这里的错误处理需要调整
-		// 当前 UserService.Login 遇到用户不存在直接返回错误，不包含自动注册逻辑
-		// 如果需要自动注册，应该在 Login 失败后，显式调用 Register
-		fmt.Printf("Login failed for %s: %v\n", loginReq.Username, err)
-		// 根据错误类型发送不同的消息
-		errMsg := "登录失败"
-		var code uint32 = 5
-		if errors.Is(err, service.ErrUserNotFound) { // 假设 service 层定义了 ErrUserNotFound
-			code = 3
-			errMsg = "用户不存在"
-		} else if errors.Is(err, service.ErrPasswordIncorrect) { // 假设 service 层定义了 ErrPasswordIncorrect
-			code = 4
-			errMsg = "密码错误"
+		if errors.Is(err, service.ErrTwoFactorRequired) {
+			// 密码正确但开启了两步验证，在连接上记录待验证状态，此时还不设置 userID
+			request.GetConnection().SetProperty(twoFactorChallengeKey, &twoFactorChallenge{
+				UserID:    user.ID,
+				Client:    client,
+				ExpiresAt: time.Now().Add(twoFactorChallengeTTL),
+			})
+			sendLoginResponse(request, 7, "请输入两步验证码或备用恢复码", map[string]bool{"two_factor_required": true})
+			return
 		}
-		sendLoginResponse(request, code, errMsg, nil)
+		fmt.Printf("Login failed for %s from %s: %v\n", loginReq.Username, loginReq.ClientIP, err)
+		RecordLoginAttempt(0, loginReq.Username, client, err)
+		sendLoginError(request, err)
 		return
 	}
 
-	// 登录成功
+	finishLogin(request, tokenString, user, client)
+}
+
+// finishLogin 登录成功后绑定连接、返回登录信息、记录登录历史并推送离线消息
+func finishLogin(request ziface.IRequest, tokenString string, user *model.User, client *model.LoginClientInfo) {
+	request.GetConnection().RemoveProperty(twoFactorChallengeKey)
 	request.GetConnection().SetProperty("userID", user.ID) // 使用 uint 类型的 ID
 	request.GetConnection().SetProperty("userUUID", user.UserUUID)
 	request.GetConnection().SetProperty("username", user.Username)
+	request.GetConnection().SetProperty(accountKindKey, model.NormalizeAccountKind(user.AccountKind))
 
 	fmt.Printf("User %s (ID: %d, UUID: %s) logged in successfully.\n", user.Username, user.ID, user.UserUUID)
 
 	connMgr := global.GlobalServer.GetConnManager()
 	connMgr.SetConnByUserID(request.GetConnection().GetConnID(), user.ID)
 
+	// 创建可断线恢复的会话，此后的推送在会话内编号
+	resumeToken := global.Sessions.Open(request.GetConnection(), user.ID, user.TokenVersion)
+
 	// 构造返回数据
 	responseData := model.UserLoginResponse{
-		ID:        user.ID,
-		UserUUID:  user.UserUUID,
-		Username:  user.Username,
-		Email:     user.Email,
-		Avatar:    user.Avatar,
-		LastLogin: user.LastLogin, // 已是 time.Time 类型
-		Token:     tokenString,
+		ID:            user.ID,
+		UserUUID:      user.UserUUID,
+		Username:      user.Username,
+		Email:         user.Email,
+		Avatar:        user.Avatar,
+		LastLogin:     user.LastLogin, // 已是 time.Time 类型
+		LastLoginIP:   user.LastLoginIP,
+		Token:         tokenString,
+		EmailVerified: user.EmailVerified,
+		DeletionDueAt: user.DeletionDueAt,
+		AccountKind:   model.NormalizeAccountKind(user.AccountKind),
+		ResumeToken:   resumeToken,
 	}
 
 	sendLoginResponse(request, 0, "登录成功", responseData)
+	RecordLoginAttempt(user.ID, user.Username, client, nil)
+
+	pushOfflineMessages(request.GetConnection(), user.ID, user.Username)
+}
 
-	// --- 离线消息处理逻辑 (保持不变或根据 userID (uint) 调整) ---
-	userIDForOffline := user.ID // 使用 uint 类型的 ID 获取离线消息
+// pushOfflineMessages 推送用户的离线消息，登录和恢复会话后调用
+func pushOfflineMessages(conn ziface.IConnection, userID uint, username string) {
 	processedMsgs := make(map[string]bool)
 	offlineMsgs := [][]byte{}
 
 	// 1. 先获取用户ID的离线消息
-	if global.MessageService.HasOfflineMessages(userIDForOffline) { // 直接传递 uint 类型的 userIDForOffline
-		msgs, err := global.MessageService.GetOfflineMessages(userIDForOffline) // 直接传递 uint 类型的 userIDForOffline
+	if global.MessageService.HasOfflineMessages(userID) {
+		msgs, err := global.MessageService.GetOfflineMessages(userID)
 		if err != nil {
-			fmt.Printf("[Redis错误] 获取离线消息失败 for ID %d: %v\n", userIDForOffline, err)
+			fmt.Printf("[Redis错误] 获取离线消息失败 for ID %d: %v\n", userID, err)
 		} else {
 			for _, msg := range msgs {
 				msgKey := fmt.Sprintf("%x", msg)
@@ -110,15 +134,76 @@ func (lr *LoginRouter) Handle(request ziface.IRequest) {
 	// 2. 用户名下的离线消息 (如果仍然需要，通常在用户能用ID识别后，应迁移到ID下)
 	// if global.MessageService.HasOfflineMessages(user.Username) { ... }
 
-	// 3. 如果有离线消息，推送给用户
+	// 3. 如果有离线消息，经过会话推送给用户，保证参与推送编号
 	if len(offlineMsgs) > 0 {
 		fmt.Printf("[离线消息] 用户 %s(ID:%d) 共有 %d 条离线消息待推送\n",
-			user.Username, user.ID, len(offlineMsgs))
+			username, userID, len(offlineMsgs))
 
 		for _, msgData := range offlineMsgs {
-			request.GetConnection().SendMsg(protocol.MsgIDTextMsg, msgData)
+			_ = global.Sessions.SendMsg(conn, protocol.MsgIDTextMsg, msgData)
 		}
 	}
+
+	// 4. 上次在线时因连接积压被丢弃的推送，按原消息ID补发，再次积压时仍会转存
+	pushes, err := global.MessageService.GetOfflinePushes(userID)
+	if err != nil {
+		fmt.Printf("[Redis错误] 获取离线推送失败 for ID %d: %v\n", userID, err)
+		return
+	}
+	if len(pushes) > 0 {
+		fmt.Printf("[离线消息] 用户 %s(ID:%d) 共有 %d 条积压时丢弃的推送待补发\n", username, userID, len(pushes))
+	}
+	for _, push := range pushes {
+		_ = global.Sessions.SendMsg(conn, push.MsgID, push.Data)
+	}
+}
+
+// SaveDroppedPush 推送因接收方连接积压被丢弃时转存离线，作为 session.Backpressure.OnDropped
+func SaveDroppedPush(userID uint, msgID uint32, data []byte) {
+	if err := global.MessageService.SaveOfflinePush(userID, msgID, data); err != nil {
+		fmt.Printf("[Redis错误] 保存离线推送失败 for ID %d, MsgID %d: %v\n", userID, msgID, err)
+	}
+}
+
+// ResendOfflinePushes 连接积压回落后补发之前转存的离线推送，作为 session.Backpressure.OnRecovered
+// 补发途中再次积压或连接断开时，剩余的推送重新转存，下次回落或登录时补发
+func ResendOfflinePushes(userID uint) {
+	pushes, err := global.MessageService.GetOfflinePushes(userID)
+	if err != nil {
+		fmt.Printf("[Redis错误] 获取离线推送失败 for ID %d: %v\n", userID, err)
+		return
+	}
+	for i, push := range pushes {
+		if !global.Sessions.Deliver(userID, push.MsgID, push.Data) {
+			for _, rest := range pushes[i:] {
+				SaveDroppedPush(userID, rest.MsgID, rest.Data)
+			}
+			return
+		}
+	}
+	if len(pushes) > 0 {
+		fmt.Printf("[离线消息] 用户ID %d 积压回落，补发 %d 条推送\n", userID, len(pushes))
+	}
+}
+
+// sendLoginError 根据登录错误类型返回对应的错误码
+// 账号不存在和密码错误使用同一个错误码，避免泄露用户名是否存在
+func sendLoginError(request ziface.IRequest, err error) {
+	var throttled *service.ThrottledError
+	if errors.As(err, &throttled) {
+		retryAfter := int(math.Ceil(throttled.RetryAfter.Seconds()))
+		errMsg := fmt.Sprintf("登录尝试过于频繁，请 %d 秒后再试", retryAfter)
+		if errors.Is(err, service.ErrAccountLocked) {
+			errMsg = fmt.Sprintf("登录失败次数过多，已临时锁定，请 %d 秒后再试", retryAfter)
+		}
+		sendLoginResponse(request, 6, errMsg, map[string]int{"retry_after": retryAfter})
+		return
+	}
+	if errors.Is(err, service.ErrInvalidCredentials) {
+		sendLoginResponse(request, 4, "用户名或密码错误", nil)
+		return
+	}
+	sendLoginResponse(request, 5, "登录失败", nil)
 }
 
 // 登录后处理
@@ -126,6 +211,27 @@ func (lr *LoginRouter) PostHandle(request ziface.IRequest) {
 	// 这里可以记录登录日志、踢下线等
 }
 
+// clientIP 获取连接的对端IP，用于按IP统计登录和注册次数
+func clientIP(conn ziface.IConnection) string {
+	addr := conn.RemoteAddr().String()
+	// 经过 TLS 或 WebSocket 网关的连接，zinx 看到的是网关的本机地址，换回客户端的真实地址
+	if global.TLSGateway != nil {
+		if peer, ok := global.TLSGateway.LookupPeer(addr); ok {
+			addr = peer.RemoteAddr.String()
+		}
+	}
+	if global.WSGateway != nil {
+		if peer, ok := global.WSGateway.LookupPeer(addr); ok {
+			addr = peer.RemoteAddr.String()
+		}
+	}
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return addr
+	}
+	return host
+}
+
 // 发送登录响应
 func sendLoginResponse(request ziface.IRequest, code uint32, msg string, data interface{}) {
 	response := map[string]interface{}{
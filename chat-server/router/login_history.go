@@ -0,0 +1,98 @@
+package router
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/Xaytick/chat-zinx/chat-server/global"
+	"github.com/Xaytick/chat-zinx/chat-server/pkg/model"
+	"github.com/Xaytick/chat-zinx/chat-server/pkg/protocol"
+	"github.com/Xaytick/chat-zinx/chat-server/pkg/service"
+	"github.com/Xaytick/zinx/ziface"
+	"github.com/Xaytick/zinx/znet"
+)
+
+// --- GetLoginHistoryRouter 查询自己的登录历史 --- //
+type GetLoginHistoryRouter struct {
+	znet.BaseRouter
+}
+
+func (r *GetLoginHistoryRouter) Handle(request ziface.IRequest) {
+	userID, err := request.GetConnection().GetProperty("userID")
+	if err != nil || userID == nil {
+		fmt.Println("GetLoginHistoryRouter: User not authenticated")
+		_ = request.GetConnection().SendMsg(protocol.MsgIDGetLoginHistoryResp, []byte(`{"error":"用户未登录"}`))
+		return
+	}
+	uid := userID.(uint)
+
+	var req model.GetLoginHistoryReq
+	if len(request.GetData()) > 0 {
+		if err := json.Unmarshal(request.GetData(), &req); err != nil {
+			fmt.Println("GetLoginHistoryRouter: Invalid request data format - ", err)
+			_ = request.GetConnection().SendMsg(protocol.MsgIDGetLoginHistoryResp, []byte(`{"error":"请求数据格式错误"}`))
+			return
+		}
+	}
+
+	records, err := global.UserService.GetLoginHistory(uid, req.Limit)
+	if err != nil {
+		fmt.Printf("GetLoginHistoryRouter: User %d failed to get login history - %s\n", uid, err.Error())
+		respData, _ := json.Marshal(map[string]string{"error": fmt.Sprintf("获取登录历史失败: %s", err.Error())})
+		_ = request.GetConnection().SendMsg(protocol.MsgIDGetLoginHistoryResp, respData)
+		return
+	}
+
+	respData, _ := json.Marshal(&model.GetLoginHistoryResp{Records: records})
+	_ = request.GetConnection().SendMsg(protocol.MsgIDGetLoginHistoryResp, respData)
+}
+
+// RecordLoginAttempt 记录 TCP 或 HTTP 登录的结果，loginErr 为 nil 表示登录成功
+// 成功登录来自新的IP或设备时，向该用户在当前节点上的所有连接推送提醒
+func RecordLoginAttempt(userID uint, username string, client *model.LoginClientInfo, loginErr error) {
+	success := loginErr == nil
+	push, err := global.UserService.RecordLoginAttempt(userID, username, client, success, loginFailReason(loginErr))
+	if err != nil {
+		fmt.Printf("Failed to record login attempt of %s(%d): %v\n", username, userID, err)
+	}
+	if push == nil {
+		return
+	}
+
+	pushData, _ := json.Marshal(push)
+	for _, conn := range userConns(userID) {
+		_ = global.Sessions.SendMsg(conn, protocol.MsgIDSuspiciousLoginPush, pushData)
+	}
+	fmt.Printf("User %d logged in from new source (ip=%s, new ip=%v, new device=%v)\n", userID, push.IP, push.NewIP, push.NewDevice)
+}
+
+// loginFailReason 将登录错误归类为登录记录中的失败原因
+func loginFailReason(err error) string {
+	var throttled *service.ThrottledError
+	switch {
+	case err == nil:
+		return ""
+	case errors.As(err, &throttled):
+		return model.LoginFailThrottled
+	case errors.Is(err, service.ErrInvalidCredentials):
+		return model.LoginFailInvalidCredentials
+	case errors.Is(err, service.ErrInvalidTwoFactorCode), errors.Is(err, service.ErrTooManyTwoFactorTries):
+		return model.LoginFailInvalidTwoFactor
+	default:
+		return model.LoginFailError
+	}
+}
+
+// userConns 返回当前节点上属于该用户的所有连接
+func userConns(userID uint) []ziface.IConnection {
+	var conns []ziface.IConnection
+	for _, conn := range global.GlobalServer.GetConnManager().All() {
+		uid, err := conn.GetProperty("userID")
+		if err != nil || uid == nil || uid.(uint) != userID {
+			continue
+		}
+		conns = append(conns, conn)
+	}
+	return conns
+}
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"math"
 
 	"github.com/Xaytick/chat-zinx/chat-server/global"
 	// "github.com/Xaytick/chat-zinx/chat-server/pkg/middleware" // Token生成移至Service层或按需处理
@@ -42,8 +43,16 @@ func (r *RegisterRouter) Handle(request ziface.IRequest) {
 		return
 	}
 
+	registerReq.ClientIP = clientIP(request.GetConnection())
 	user, err := global.UserService.Register(&registerReq)
 	if err != nil {
+		var throttled *service.ThrottledError
+		if errors.As(err, &throttled) {
+			retryAfter := int(math.Ceil(throttled.RetryAfter.Seconds()))
+			fmt.Printf("Registration from %s throttled: %v\n", registerReq.ClientIP, err)
+			sendRegisterResponse(request, 5, fmt.Sprintf("注册过于频繁，请 %d 秒后再试", retryAfter), map[string]int{"retry_after": retryAfter})
+			return
+		}
 		errMsg := "注册失败: " + err.Error()
 		var code uint32 = 4
 		// 检查是否是 service 层定义的特定错误，例如用户已存在
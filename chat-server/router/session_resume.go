@@ -0,0 +1,117 @@
+package router
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/Xaytick/chat-zinx/chat-server/global"
+	"github.com/Xaytick/chat-zinx/chat-server/pkg/model"
+	"github.com/Xaytick/chat-zinx/chat-server/pkg/protocol"
+	"github.com/Xaytick/chat-zinx/chat-server/pkg/service"
+	"github.com/Xaytick/chat-zinx/chat-server/pkg/session"
+	"github.com/Xaytick/zinx/ziface"
+	"github.com/Xaytick/zinx/znet"
+)
+
+// 恢复会话响应的状态码，非 0 时客户端应改为完整登录
+const (
+	resumeCodeOK          = 0
+	resumeCodeBadRequest  = 1
+	resumeCodeNotFound    = 2 // 令牌无效或会话已过期
+	resumeCodeGap         = 3 // 错过的推送已不在缓存中
+	resumeCodeLoggedIn    = 4 // 当前连接已登录
+	resumeCodeServerError = 5
+)
+
+// --- ResumeSessionRouter 断线重连后凭恢复令牌接回会话，补发错过的推送 --- //
+type ResumeSessionRouter struct {
+	znet.BaseRouter
+}
+
+func (r *ResumeSessionRouter) Handle(request ziface.IRequest) {
+	conn := request.GetConnection()
+	if userID, err := conn.GetProperty("userID"); err == nil && userID != nil {
+		sendResumeResponse(conn, resumeCodeLoggedIn, "当前连接已登录", nil)
+		return
+	}
+
+	var req model.ResumeSessionReq
+	if err := json.Unmarshal(request.GetData(), &req); err != nil || req.ResumeToken == "" {
+		sendResumeResponse(conn, resumeCodeBadRequest, "请求数据格式错误", nil)
+		return
+	}
+
+	// 会话保留期间账号可能已被删除（如访客到期）或修改了密码，恢复前按 JWT 的规则重新校验
+	uid, tokenVersion, ok := global.Sessions.Lookup(req.ResumeToken)
+	if !ok {
+		sendResumeResponse(conn, resumeCodeNotFound, "会话已过期，请重新登录", nil)
+		return
+	}
+	user, err := global.UserService.VerifySessionUser(uid, tokenVersion)
+	if err != nil {
+		if !errors.Is(err, service.ErrInvalidToken) {
+			fmt.Printf("ResumeSessionRouter: Failed to verify user %d: %v\n", uid, err)
+			sendResumeResponse(conn, resumeCodeServerError, "恢复会话失败", nil)
+			return
+		}
+		global.Sessions.Revoke(uid, nil)
+		sendResumeResponse(conn, resumeCodeNotFound, "会话已失效，请重新登录", nil)
+		return
+	}
+
+	resumed, err := global.Sessions.Resume(conn, req.ResumeToken, req.LastSeq, func(r *session.Resumed) {
+		// 在补发之前完成绑定并发送响应，保证客户端先收到响应再收到补发的推送
+		conn.SetProperty("userID", user.ID)
+		conn.SetProperty("userUUID", user.UserUUID)
+		conn.SetProperty("username", user.Username)
+		conn.SetProperty(accountKindKey, model.NormalizeAccountKind(user.AccountKind))
+		global.GlobalServer.GetConnManager().SetConnByUserID(conn.GetConnID(), user.ID)
+
+		sendResumeResponse(conn, resumeCodeOK, "会话已恢复", &model.ResumeSessionResp{
+			ID:          user.ID,
+			UserUUID:    user.UserUUID,
+			Username:    user.Username,
+			AccountKind: model.NormalizeAccountKind(user.AccountKind),
+			ResumeToken: r.Token,
+			Seq:         r.Seq,
+			Replayed:    r.Replayed,
+		})
+	})
+	switch {
+	case errors.Is(err, session.ErrSessionNotFound):
+		sendResumeResponse(conn, resumeCodeNotFound, "会话已过期，请重新登录", nil)
+		return
+	case errors.Is(err, session.ErrSeqGap):
+		sendResumeResponse(conn, resumeCodeGap, "错过的消息过多，请重新登录", nil)
+		return
+	case err != nil && resumed == nil:
+		sendResumeResponse(conn, resumeCodeServerError, "恢复会话失败", nil)
+		return
+	case err != nil:
+		// 响应已发出，补发中途失败说明新连接也已断开，客户端会再次恢复
+		fmt.Printf("ResumeSessionRouter: Replay to user %d interrupted: %v\n", user.ID, err)
+		return
+	}
+
+	// 旧连接可能是尚未被心跳检测发现的半开连接，会话已转移，直接关闭
+	if resumed.Previous != nil {
+		resumed.Previous.Stop()
+	}
+	fmt.Printf("User %s (ID: %d) resumed session, replayed %d pushes\n", user.Username, user.ID, resumed.Replayed)
+
+	// 断线期间发来的私聊消息保存为离线消息，接着推送
+	pushOfflineMessages(conn, user.ID, user.Username)
+}
+
+func sendResumeResponse(conn ziface.IConnection, code uint32, msg string, data interface{}) {
+	response := map[string]interface{}{
+		"code": code,
+		"msg":  msg,
+	}
+	if data != nil {
+		response["data"] = data
+	}
+	respData, _ := json.Marshal(response)
+	_ = conn.SendMsg(protocol.MsgIDResumeSessionResp, respData)
+}
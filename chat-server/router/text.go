@@ -3,7 +3,6 @@ package router
 import (
 	"encoding/json"
 	"fmt"
-	"strconv"
 
 	"github.com/Xaytick/chat-zinx/chat-server/global"
 	"github.com/Xaytick/chat-zinx/chat-server/pkg/model"
@@ -59,23 +58,8 @@ func (r *TextMsgRouter) Handle(request ziface.IRequest) {
 		return
 	}
 
-	// 2. 查找接收者用户
-	var targetUser *model.User
-	// Try to find user by UUID first (assuming msg.ToUserID could be a UUID)
-	// This requires UserService to have GetUserByUUID method
-	targetUser, err = global.UserService.GetUserByUUID(msg.ToUserID)
-	if err != nil {
-		// If not found by UUID, try by username
-		targetUser, err = global.UserService.GetUserByUsername(msg.ToUserID)
-		if err != nil {
-			// If still not found, try if msg.ToUserID is a numeric ID string
-			parsedToID, parseErr := strconv.ParseUint(msg.ToUserID, 10, 32)
-			if parseErr == nil {
-				targetUser, err = global.UserService.GetUserByID(uint(parsedToID))
-			}
-		}
-	}
-
+	// 2. 查找接收者用户，ToUserID 可以是 UUID、用户名或数字ID
+	targetUser, err := global.UserService.ResolveUser(msg.ToUserID)
 	if err != nil || targetUser == nil {
 		fmt.Printf("[未知接收者] 用户 %s 查找失败: %v. 消息不会发送.\n", msg.ToUserID, err)
 		// TODO: Send "user not found" response to client
@@ -83,6 +67,14 @@ func (r *TextMsgRouter) Handle(request ziface.IRequest) {
 		return
 	}
 
+	// 3. 检查私聊策略（例如仅允许好友之间私聊）
+	if err := global.FriendService.CheckPrivateChatAllowed(fromUserIDUint, targetUser.ID); err != nil {
+		fmt.Printf("[私聊拒绝] 用户 %d 不能向用户 %d 发送消息: %v\n", fromUserIDUint, targetUser.ID, err)
+		errData, _ := json.Marshal(model.GenericMessageResp{Code: 403, Message: fmt.Sprintf("消息未发送: %s", err.Error())})
+		_ = request.GetConnection().SendMsg(protocol.MsgIDErrorResp, errData)
+		return
+	}
+
 	// 找到了接收者
 	toUserIDUint := targetUser.ID        // This is uint
 	toUserUUIDStr := targetUser.UserUUID // This is string
@@ -90,29 +82,12 @@ func (r *TextMsgRouter) Handle(request ziface.IRequest) {
 
 	fmt.Printf("[消息路由] 准备发送消息到用户: %s (ID: %d, UUID: %s)\n", toUsernameStr, toUserIDUint, toUserUUIDStr)
 
-	// 4. 获取全局连接管理器并寻找接收者连接
-	connManager := global.GlobalServer.GetConnManager()
-	fmt.Printf("[系统状态] 当前在线连接数: %d\n", connManager.Size())
-
-	// 5. 遍历所有连接，查找目标用户
-	foundOnline := false
-	for _, conn := range connManager.All() {
-		// 获取连接关联的用户ID
-		if userIDProp, err := conn.GetProperty("userID"); err == nil {
-			// 确保类型一致进行比较
-			userIDUint, ok := userIDProp.(uint)
-			if ok && userIDUint == toUserIDUint { // Compare uint with uint
-				fmt.Printf("[消息投递] 用户 %s (ID: %d) 在线，直接发送消息\n", toUsernameStr, toUserIDUint)
-				// 目标用户在线，直接转发消息
-				err := conn.SendMsg(protocol.MsgIDTextMsg, msgData)
-				if err != nil {
-					fmt.Printf("[消息发送] 发送失败: %v, 将保存为离线消息\n", err)
-				} else {
-					foundOnline = true
-					break
-				}
-			}
-		}
+	// 4. 目标用户在线时直接转发
+	// 断线等待恢复的用户不在这里缓存，走离线消息，恢复会话后随离线消息一起推送，会话过期也不会丢失
+	fmt.Printf("[系统状态] 当前在线连接数: %d\n", global.GlobalServer.GetConnManager().Size())
+	foundOnline := global.Sessions.Deliver(toUserIDUint, protocol.MsgIDTextMsg, msgData)
+	if foundOnline {
+		fmt.Printf("[消息投递] 用户 %s (ID: %d) 在线，已直接发送消息\n", toUsernameStr, toUserIDUint)
 	}
 
 	if !foundOnline {
@@ -0,0 +1,191 @@
+package router
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Xaytick/chat-zinx/chat-server/global"
+	"github.com/Xaytick/chat-zinx/chat-server/pkg/model"
+	"github.com/Xaytick/chat-zinx/chat-server/pkg/protocol"
+	"github.com/Xaytick/chat-zinx/chat-server/pkg/service"
+	"github.com/Xaytick/zinx/ziface"
+	"github.com/Xaytick/zinx/znet"
+)
+
+const (
+	// twoFactorChallengeKey 连接上保存两步验证待验证状态的属性名
+	twoFactorChallengeKey = "twoFactorChallenge"
+	// twoFactorChallengeTTL 密码验证通过后提交验证码的时限，与 HTTP 登录相同
+	twoFactorChallengeTTL = model.TwoFactorChallengeTTL
+	// twoFactorMaxAttempts 同一次登录允许提交验证码的次数，与 HTTP 登录相同
+	twoFactorMaxAttempts = model.TwoFactorMaxAttempts
+)
+
+// twoFactorChallenge 密码已验证、等待第二因素的登录状态
+type twoFactorChallenge struct {
+	UserID    uint
+	Client    *model.LoginClientInfo // 第一步登录时上报的客户端信息
+	ExpiresAt time.Time
+	Attempts  int
+}
+
+// --- TwoFactorLoginRouter 登录第二步 --- //
+type TwoFactorLoginRouter struct {
+	znet.BaseRouter
+}
+
+func (r *TwoFactorLoginRouter) Handle(request ziface.IRequest) {
+	conn := request.GetConnection()
+	value, err := conn.GetProperty(twoFactorChallengeKey)
+	challenge, ok := value.(*twoFactorChallenge)
+	if err != nil || !ok {
+		sendLoginResponse(request, 8, "没有待完成的两步验证，请先使用密码登录", nil)
+		return
+	}
+	if time.Now().After(challenge.ExpiresAt) {
+		conn.RemoveProperty(twoFactorChallengeKey)
+		sendLoginResponse(request, 8, "两步验证已超时，请重新登录", nil)
+		return
+	}
+
+	var req model.TwoFactorLoginReq
+	if err := json.Unmarshal(request.GetData(), &req); err != nil || req.Code == "" {
+		sendLoginResponse(request, 1, "请求数据格式错误", nil)
+		return
+	}
+
+	challenge.Attempts++
+	client := *challenge.Client
+	client.IP = clientIP(conn)
+	tokenString, user, err := global.UserService.VerifyTwoFactorLogin(challenge.UserID, req.Code, client.IP)
+	if err != nil {
+		fmt.Printf("Two-factor login failed for user %d: %v\n", challenge.UserID, err)
+		RecordLoginAttempt(challenge.UserID, "", &client, err)
+		if !errors.Is(err, service.ErrInvalidTwoFactorCode) {
+			conn.RemoveProperty(twoFactorChallengeKey)
+			sendLoginError(request, err)
+			return
+		}
+		if challenge.Attempts >= twoFactorMaxAttempts {
+			conn.RemoveProperty(twoFactorChallengeKey)
+			sendLoginResponse(request, 8, "验证码错误次数过多，请重新登录", nil)
+			return
+		}
+		sendLoginResponse(request, 9, "验证码错误", nil)
+		return
+	}
+
+	finishLogin(request, tokenString, user, &client)
+}
+
+// --- EnrollTOTPRouter 生成两步验证密钥 --- //
+type EnrollTOTPRouter struct {
+	znet.BaseRouter
+}
+
+func (r *EnrollTOTPRouter) Handle(request ziface.IRequest) {
+	userID, err := request.GetConnection().GetProperty("userID")
+	if err != nil || userID == nil {
+		fmt.Println("EnrollTOTPRouter: User not authenticated")
+		_ = request.GetConnection().SendMsg(protocol.MsgIDEnrollTOTPResp, []byte(`{"error":"用户未登录"}`))
+		return
+	}
+	uid := userID.(uint)
+
+	resp, err := global.UserService.EnrollTOTP(uid)
+	if err != nil {
+		fmt.Printf("EnrollTOTPRouter: User %d failed to enroll totp - %s\n", uid, err.Error())
+		respData, _ := json.Marshal(map[string]string{"error": fmt.Sprintf("生成两步验证密钥失败: %s", twoFactorErrMsg(err))})
+		_ = request.GetConnection().SendMsg(protocol.MsgIDEnrollTOTPResp, respData)
+		return
+	}
+
+	respData, _ := json.Marshal(resp)
+	_ = request.GetConnection().SendMsg(protocol.MsgIDEnrollTOTPResp, respData)
+}
+
+// --- ConfirmTOTPRouter 确认开启两步验证 --- //
+type ConfirmTOTPRouter struct {
+	znet.BaseRouter
+}
+
+func (r *ConfirmTOTPRouter) Handle(request ziface.IRequest) {
+	userID, err := request.GetConnection().GetProperty("userID")
+	if err != nil || userID == nil {
+		fmt.Println("ConfirmTOTPRouter: User not authenticated")
+		_ = request.GetConnection().SendMsg(protocol.MsgIDConfirmTOTPResp, []byte(`{"error":"用户未登录"}`))
+		return
+	}
+	uid := userID.(uint)
+
+	var req model.ConfirmTOTPReq
+	if err := json.Unmarshal(request.GetData(), &req); err != nil {
+		fmt.Println("ConfirmTOTPRouter: Invalid request data format - ", err)
+		_ = request.GetConnection().SendMsg(protocol.MsgIDConfirmTOTPResp, []byte(`{"error":"请求数据格式错误"}`))
+		return
+	}
+
+	resp, err := global.UserService.ConfirmTOTP(uid, req.Code)
+	if err != nil {
+		fmt.Printf("ConfirmTOTPRouter: User %d failed to confirm totp - %s\n", uid, err.Error())
+		respData, _ := json.Marshal(map[string]string{"error": fmt.Sprintf("开启两步验证失败: %s", twoFactorErrMsg(err))})
+		_ = request.GetConnection().SendMsg(protocol.MsgIDConfirmTOTPResp, respData)
+		return
+	}
+
+	respData, _ := json.Marshal(resp)
+	_ = request.GetConnection().SendMsg(protocol.MsgIDConfirmTOTPResp, respData)
+	fmt.Printf("User %d enabled two-factor authentication\n", uid)
+}
+
+// --- DisableTOTPRouter 关闭两步验证 --- //
+type DisableTOTPRouter struct {
+	znet.BaseRouter
+}
+
+func (r *DisableTOTPRouter) Handle(request ziface.IRequest) {
+	userID, err := request.GetConnection().GetProperty("userID")
+	if err != nil || userID == nil {
+		fmt.Println("DisableTOTPRouter: User not authenticated")
+		_ = request.GetConnection().SendMsg(protocol.MsgIDDisableTOTPResp, []byte(`{"error":"用户未登录"}`))
+		return
+	}
+	uid := userID.(uint)
+
+	var req model.DisableTOTPReq
+	if err := json.Unmarshal(request.GetData(), &req); err != nil {
+		fmt.Println("DisableTOTPRouter: Invalid request data format - ", err)
+		_ = request.GetConnection().SendMsg(protocol.MsgIDDisableTOTPResp, []byte(`{"error":"请求数据格式错误"}`))
+		return
+	}
+
+	if err := global.UserService.DisableTOTP(uid, &req); err != nil {
+		fmt.Printf("DisableTOTPRouter: User %d failed to disable totp - %s\n", uid, err.Error())
+		respData, _ := json.Marshal(map[string]string{"error": fmt.Sprintf("关闭两步验证失败: %s", twoFactorErrMsg(err))})
+		_ = request.GetConnection().SendMsg(protocol.MsgIDDisableTOTPResp, respData)
+		return
+	}
+
+	_ = request.GetConnection().SendMsg(protocol.MsgIDDisableTOTPResp, []byte(`{"message":"两步验证已关闭"}`))
+	fmt.Printf("User %d disabled two-factor authentication\n", uid)
+}
+
+// twoFactorErrMsg 将两步验证相关错误转换为提示信息
+func twoFactorErrMsg(err error) string {
+	switch {
+	case errors.Is(err, service.ErrInvalidTwoFactorCode):
+		return "验证码错误"
+	case errors.Is(err, service.ErrPasswordIncorrect):
+		return "密码错误"
+	case errors.Is(err, service.ErrTOTPAlreadyEnabled):
+		return "已开启两步验证"
+	case errors.Is(err, service.ErrTOTPNotEnabled):
+		return "未开启两步验证"
+	case errors.Is(err, service.ErrTOTPNotEnrolled):
+		return "请先生成两步验证密钥"
+	default:
+		return err.Error()
+	}
+}
@@ -0,0 +1,136 @@
+package router
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/Xaytick/chat-zinx/chat-server/global"
+	"github.com/Xaytick/chat-zinx/chat-server/pkg/model"
+	"github.com/Xaytick/chat-zinx/chat-server/pkg/protocol"
+	"github.com/Xaytick/zinx/ziface"
+	"github.com/Xaytick/zinx/znet"
+)
+
+// --- UpdateProfileRouter 更新用户资料 --- //
+type UpdateProfileRouter struct {
+	znet.BaseRouter
+}
+
+func (r *UpdateProfileRouter) Handle(request ziface.IRequest) {
+	userID, err := request.GetConnection().GetProperty("userID")
+	if err != nil || userID == nil {
+		fmt.Println("UpdateProfileRouter: User not authenticated")
+		_ = request.GetConnection().SendMsg(protocol.MsgIDUpdateProfileResp, []byte(`{"error":"用户未登录"}`))
+		return
+	}
+	uid := userID.(uint)
+
+	var req model.UpdateProfileReq
+	if err := json.Unmarshal(request.GetData(), &req); err != nil {
+		fmt.Println("UpdateProfileRouter: Invalid request data format - ", err)
+		_ = request.GetConnection().SendMsg(protocol.MsgIDUpdateProfileResp, []byte(`{"error":"请求数据格式错误"}`))
+		return
+	}
+
+	profile, err := global.UserService.UpdateProfile(uid, &req)
+	if err != nil {
+		fmt.Printf("UpdateProfileRouter: User %d failed to update profile - %s\n", uid, err.Error())
+		respData, _ := json.Marshal(map[string]string{"error": fmt.Sprintf("更新资料失败: %s", err.Error())})
+		_ = request.GetConnection().SendMsg(protocol.MsgIDUpdateProfileResp, respData)
+		return
+	}
+
+	respData, _ := json.Marshal(profile)
+	_ = request.GetConnection().SendMsg(protocol.MsgIDUpdateProfileResp, respData)
+	fmt.Printf("User %d updated profile\n", uid)
+
+	PushProfileChanged(uid, profile)
+}
+
+// PushProfileChanged 向当前节点上在线的好友推送资料变更，推送内容不包含隐私设置，HTTP 接口修改资料后同样调用
+func PushProfileChanged(userID uint, profile *model.UserProfile) {
+	friendIDs, err := global.FriendService.GetFriendIDs(userID)
+	if err != nil {
+		fmt.Printf("Failed to get friends of user %d for profile push: %v\n", userID, err)
+		return
+	}
+	if len(friendIDs) == 0 {
+		return
+	}
+
+	public := *profile
+	public.DMPrivacy = ""
+	public.Searchable = nil
+	pushData, _ := json.Marshal(&public)
+	for _, friendID := range friendIDs {
+		pushToUser(friendID, protocol.MsgIDProfileChangedPush, pushData)
+	}
+}
+
+// --- GetProfileRouter 获取用户资料 --- //
+type GetProfileRouter struct {
+	znet.BaseRouter
+}
+
+func (r *GetProfileRouter) Handle(request ziface.IRequest) {
+	userID, err := request.GetConnection().GetProperty("userID")
+	if err != nil || userID == nil {
+		fmt.Println("GetProfileRouter: User not authenticated")
+		_ = request.GetConnection().SendMsg(protocol.MsgIDGetProfileResp, []byte(`{"error":"用户未登录"}`))
+		return
+	}
+	uid := userID.(uint)
+
+	var req model.GetProfileReq
+	if len(request.GetData()) > 0 {
+		if err := json.Unmarshal(request.GetData(), &req); err != nil {
+			fmt.Println("GetProfileRouter: Invalid request data format - ", err)
+			_ = request.GetConnection().SendMsg(protocol.MsgIDGetProfileResp, []byte(`{"error":"请求数据格式错误"}`))
+			return
+		}
+	}
+
+	profile, err := global.UserService.GetProfile(uid, req.Target)
+	if err != nil {
+		fmt.Printf("GetProfileRouter: User %d failed to get profile of %s - %s\n", uid, req.Target, err.Error())
+		respData, _ := json.Marshal(map[string]string{"error": fmt.Sprintf("获取资料失败: %s", err.Error())})
+		_ = request.GetConnection().SendMsg(protocol.MsgIDGetProfileResp, respData)
+		return
+	}
+
+	respData, _ := json.Marshal(profile)
+	_ = request.GetConnection().SendMsg(protocol.MsgIDGetProfileResp, respData)
+}
+
+// --- SearchUsersRouter 搜索用户 --- //
+type SearchUsersRouter struct {
+	znet.BaseRouter
+}
+
+func (r *SearchUsersRouter) Handle(request ziface.IRequest) {
+	userID, err := request.GetConnection().GetProperty("userID")
+	if err != nil || userID == nil {
+		fmt.Println("SearchUsersRouter: User not authenticated")
+		_ = request.GetConnection().SendMsg(protocol.MsgIDSearchUsersResp, []byte(`{"error":"用户未登录"}`))
+		return
+	}
+	uid := userID.(uint)
+
+	var req model.SearchUsersReq
+	if err := json.Unmarshal(request.GetData(), &req); err != nil {
+		fmt.Println("SearchUsersRouter: Invalid request data format - ", err)
+		_ = request.GetConnection().SendMsg(protocol.MsgIDSearchUsersResp, []byte(`{"error":"请求数据格式错误"}`))
+		return
+	}
+
+	resp, err := global.UserService.SearchUsers(uid, &req)
+	if err != nil {
+		fmt.Printf("SearchUsersRouter: User %d failed to search users - %s\n", uid, err.Error())
+		respData, _ := json.Marshal(map[string]string{"error": fmt.Sprintf("搜索用户失败: %s", err.Error())})
+		_ = request.GetConnection().SendMsg(protocol.MsgIDSearchUsersResp, respData)
+		return
+	}
+
+	respData, _ := json.Marshal(resp)
+	_ = request.GetConnection().SendMsg(protocol.MsgIDSearchUsersResp, respData)
+}
@@ -0,0 +1,183 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/signal"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/Xaytick/chat-zinx/chat-server/conf"
+	"github.com/Xaytick/chat-zinx/chat-server/dao/mysql"
+	"github.com/Xaytick/chat-zinx/chat-server/dao/redis"
+	"github.com/Xaytick/chat-zinx/chat-server/global"
+	"github.com/Xaytick/chat-zinx/chat-server/pkg/model"
+	"github.com/Xaytick/chat-zinx/chat-server/pkg/protocol"
+	"github.com/Xaytick/zinx/ziface"
+)
+
+// draining 收到退出信号后置位，此后拒绝新的 TCP 连接
+var draining atomic.Bool
+
+const (
+	// teardownReserve 为标记离线、关闭连接和存储保留的时间，等待发送队列写空时不会占用
+	teardownReserve = 5 * time.Second
+	// drainRejectCloseDelay 关闭期间拒绝新连接时，发出下线通知后等待多久再断开，保证通知先于断开到达
+	drainRejectCloseDelay = time.Second
+)
+
+// clusterNode 分布式模式下的节点，global.DistributedManager 为 interface{}，按需断言
+type clusterNode interface {
+	Deregister() error
+	Stop() error
+}
+
+// waitForShutdown 阻塞到收到 SIGTERM 或 SIGINT，然后在 Shutdown.Timeout 内优雅关闭；关闭期间再次收到信号时立即退出
+func waitForShutdown(stopPurger func()) {
+	sig := make(chan os.Signal, 2)
+	signal.Notify(sig, syscall.SIGTERM, syscall.SIGINT)
+	fmt.Printf("收到信号 %v，开始优雅关闭...\n", <-sig)
+	go func() {
+		fmt.Printf("再次收到信号 %v，立即退出\n", <-sig)
+		os.Exit(1)
+	}()
+
+	timeout := time.Duration(conf.GetShutdownConfig().Timeout) * time.Second
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	shutdown(ctx, stopPurger)
+}
+
+// shutdown 按顺序关闭节点：
+// 先停止接入并从服务发现中注销，排空群消息和 webhook 队列后通知客户端重连到其他节点，
+// 等各连接的发送队列写空（写不完的推送转存离线）后标记用户离线，再关闭连接以及数据库、Redis 和 NATS
+func shutdown(ctx context.Context, stopPurger func()) {
+	start := time.Now()
+
+	// 1. 停止接入新连接：先关闭 TLS 网关的监听端口，之后仍连到 TCP 服务的连接由 rejectDraining 通知后断开；
+	// HTTP 服务等待处理中的请求结束，已接管的 WebSocket 连接随网关关闭
+	if global.TLSGateway != nil {
+		global.TLSGateway.StopAccepting()
+	}
+	draining.Store(true)
+	if global.HTTPServer != nil {
+		if err := global.HTTPServer.Stop(ctx); err != nil {
+			fmt.Printf("关闭 HTTP 服务失败: %v\n", err)
+		}
+	}
+	node, _ := global.DistributedManager.(clusterNode)
+	if node != nil {
+		if err := node.Deregister(); err != nil {
+			fmt.Printf("从服务发现注销失败: %v\n", err)
+		}
+	}
+
+	// 2. 停止定期清理，排空 webhook 和群消息推送队列，推送全部进入各连接的发送队列
+	within(ctx, "停止账号清理", stopPurger)
+	within(ctx, "排空机器人 webhook 队列", global.BotWebhooks.Stop)
+	within(ctx, "排空群消息推送队列", global.GroupFanout.Stop)
+
+	// 3. 通知客户端节点即将关闭，经过发送队列排在已入队的推送之后
+	users := notifyGoingAway()
+
+	// 4. 等待发送队列写空，为后面的步骤保留时间，到期仍未写出的推送转存离线
+	drainCtx, cancelDrain := context.WithTimeout(ctx, drainBudget(ctx))
+	if err := global.Sessions.Drain(drainCtx); err != nil {
+		fmt.Println("等待发送队列写空超时，未写出的推送将转存离线")
+	}
+	cancelDrain()
+	for uid, at := range global.Sessions.Close() {
+		users[uid] = at
+	}
+
+	// 5. 在关闭连接之前标记本节点上的用户离线，包括断线后等待恢复会话的用户；
+	// 收到通知后已在其他节点重新登录的用户保持在线，关闭连接时也不再逐个标记
+	if err := global.UserService.MarkUsersOffline(users); err != nil {
+		fmt.Printf("标记 %d 个用户离线失败: %v\n", len(users), err)
+	}
+
+	// 6. 关闭网关和 TCP 服务上的所有连接
+	if global.WSGateway != nil {
+		within(ctx, "关闭 WebSocket 网关", global.WSGateway.Stop)
+	}
+	if global.TLSGateway != nil {
+		within(ctx, "关闭 TLS 网关", global.TLSGateway.Stop)
+	}
+	within(ctx, "关闭 TCP 服务", global.GlobalServer.Stop)
+
+	// 7. 关闭 NATS、Redis 和 MySQL
+	if node != nil {
+		if err := node.Stop(); err != nil {
+			fmt.Printf("关闭分布式管理器失败: %v\n", err)
+		}
+	}
+	if err := redis.Close(); err != nil {
+		fmt.Printf("关闭 Redis 连接失败: %v\n", err)
+	}
+	if err := mysql.Close(); err != nil {
+		fmt.Printf("关闭 MySQL 连接失败: %v\n", err)
+	}
+	fmt.Printf("服务已关闭，%d 个用户已标记离线，用时 %v\n", len(users), time.Since(start).Round(time.Millisecond))
+}
+
+// drainBudget 等待发送队列写空的时间：剩余时间减去 teardownReserve，但至少为剩余时间的一半
+func drainBudget(ctx context.Context) time.Duration {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return time.Duration(conf.GetShutdownConfig().Timeout) * time.Second
+	}
+	remaining := time.Until(deadline)
+	return max(remaining-teardownReserve, remaining/2)
+}
+
+// goingAwayPush 节点即将关闭的推送内容
+func goingAwayPush() []byte {
+	data, _ := json.Marshal(model.ServerGoingAwayPush{
+		Reason:          "服务器正在维护，请重新连接",
+		ReconnectWithin: conf.GetShutdownConfig().ReconnectWithin,
+	})
+	return data
+}
+
+// rejectDraining 拒绝关闭期间建立的 TCP 连接
+// zinx 不能只关闭监听端口，连接仍会被接受，先告知客户端节点即将关闭，让其换到其他节点重连，再断开
+func rejectDraining(conn ziface.IConnection) {
+	_ = conn.SendMsg(protocol.MsgIDServerGoingAwayPush, goingAwayPush())
+	time.AfterFunc(drainRejectCloseDelay, conn.Stop)
+}
+
+// notifyGoingAway 向所有连接发送节点即将关闭的推送，返回这些连接上已登录的用户，登录时间按当前时间计
+// 绑定了会话的用户随后由 Sessions.Close 给出实际的登录时间
+func notifyGoingAway() map[uint]time.Time {
+	data := goingAwayPush()
+	now := time.Now()
+	users := make(map[uint]time.Time)
+	conns := global.GlobalServer.GetConnManager().All()
+	for _, conn := range conns {
+		if value, err := conn.GetProperty("userID"); err == nil {
+			if uid, ok := value.(uint); ok {
+				users[uid] = now
+			}
+		}
+		_ = global.Sessions.SendMsg(conn, protocol.MsgIDServerGoingAwayPush, data)
+	}
+	fmt.Printf("已通知 %d 个连接节点即将关闭\n", len(conns))
+	return users
+}
+
+// within 在 ctx 到期前等待 step 完成，超时后不再等待，继续后面的关闭步骤
+func within(ctx context.Context, name string, step func()) {
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		step()
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+		fmt.Printf("%s超时，不再等待\n", name)
+	}
+}
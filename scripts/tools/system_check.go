@@ -4,6 +4,7 @@ import (
 	"database/sql"
 	"fmt"
 	"net"
+	"net/http"
 	"strings"
 	"time"
 
@@ -30,12 +31,17 @@ func main() {
 	fmt.Println("\n🗄️ 测试Redis连接...")
 	testRedisConnection()
 
-	// 5. 总结报告
+	// 5. 测试HTTP接口
+	fmt.Println("\n🌐 测试HTTP接口...")
+	testHTTPHealth()
+
+	// 6. 总结报告
 	fmt.Println(strings.Repeat("=", 80))
 	fmt.Println("🎉 系统测试完成!")
 	fmt.Println("\n📊 服务访问地址:")
 	fmt.Println("   🌐 Chat Server (TCP): localhost:9000")
 	fmt.Println("   🌐 Chat Server (HTTP): localhost:8080")
+	fmt.Println("   📘 REST API 文档: http://localhost:8080/api/v1/openapi.json")
 	fmt.Println("   🔧 Adminer (数据库管理): http://localhost:8081")
 	fmt.Println("   📈 Grafana (监控): http://localhost:3000 (admin/admin)")
 	fmt.Println("   📊 Prometheus: http://localhost:9090")
@@ -202,3 +208,19 @@ func testRedisConnection() {
 		fmt.Printf("   ❌ Redis响应异常: %s", strings.TrimSpace(response))
 	}
 }
+
+func testHTTPHealth() {
+	client := &http.Client{Timeout: 3 * time.Second}
+	resp, err := client.Get("http://localhost:8080/health")
+	if err != nil {
+		fmt.Printf("   ❌ HTTP健康检查失败: %v\n", err)
+		return
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusOK {
+		fmt.Println("   ✅ HTTP服务正常: /health 返回 200")
+	} else {
+		fmt.Printf("   ❌ HTTP健康检查异常: 状态码 %d\n", resp.StatusCode)
+	}
+}
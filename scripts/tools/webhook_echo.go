@@ -0,0 +1,102 @@
+package main
+
+// 本地调试机器人 outgoing webhook 的回显服务
+//
+// 用法:
+//   go run scripts/tools/webhook_echo.go -addr :8090 -secret <设置 webhook 时返回的签名密钥>
+//
+// 本地地址属于回环地址，服务器需要在 config.json 中设置 "Webhook": {"AllowPrivateNetworks": true}，
+// 然后在客户端执行 /webhook set <机器人ID> <群ID> http://127.0.0.1:8090/ /echo mention
+// 群里发送 "/echo 你好" 后，机器人会回复 "echo: 你好"。
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// echoEvent 只解析回显需要的字段，完整结构见 chat-server/pkg/model/bot_webhook.go
+type echoEvent struct {
+	Event      string `json:"event"`
+	DeliveryID string `json:"delivery_id"`
+	GroupID    uint   `json:"group_id"`
+	Trigger    string `json:"trigger"`
+	Command    string `json:"command"`
+	Args       string `json:"args"`
+	Message    struct {
+		FromUsername string `json:"from_username"`
+		Content      string `json:"content"`
+	} `json:"message"`
+}
+
+func main() {
+	addr := flag.String("addr", ":8090", "监听地址")
+	secret := flag.String("secret", "", "webhook 签名密钥，为空时不校验签名")
+	maxSkew := flag.Duration("max-skew", 5*time.Minute, "允许的时间戳偏差")
+	flag.Parse()
+
+	http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		body, err := io.ReadAll(io.LimitReader(r.Body, 1<<20))
+		if err != nil {
+			http.Error(w, "read body failed", http.StatusBadRequest)
+			return
+		}
+
+		if *secret != "" {
+			if err := verifySignature(*secret, r.Header, body, *maxSkew); err != nil {
+				log.Printf("❌ 签名校验失败: %v", err)
+				http.Error(w, err.Error(), http.StatusUnauthorized)
+				return
+			}
+		}
+
+		var event echoEvent
+		if err := json.Unmarshal(body, &event); err != nil {
+			http.Error(w, "invalid json", http.StatusBadRequest)
+			return
+		}
+		log.Printf("📨 %s delivery=%s group=%d trigger=%s from=%s content=%q",
+			event.Event, event.DeliveryID, event.GroupID, event.Trigger, event.Message.FromUsername, event.Message.Content)
+
+		reply := "echo: " + event.Args
+		if event.Trigger != "command" {
+			reply = fmt.Sprintf("你好 %s，我收到了: %s", event.Message.FromUsername, event.Message.Content)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]string{"reply": reply})
+	})
+
+	log.Printf("🚀 webhook 回显服务监听 %s", *addr)
+	log.Fatal(http.ListenAndServe(*addr, nil))
+}
+
+// verifySignature 按服务器的签名方式校验: X-Chat-Signature = "sha256=" + hex(HMAC-SHA256(secret, "时间戳.请求体"))
+func verifySignature(secret string, header http.Header, body []byte, maxSkew time.Duration) error {
+	ts, err := strconv.ParseInt(header.Get("X-Chat-Timestamp"), 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid timestamp header")
+	}
+	if skew := time.Since(time.Unix(ts, 0)); skew > maxSkew || skew < -maxSkew {
+		return fmt.Errorf("timestamp too old or in the future")
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(ts, 10) + "."))
+	mac.Write(body)
+	expected := "sha256=" + hex.EncodeToString(mac.Sum(nil))
+	if !hmac.Equal([]byte(expected), []byte(header.Get("X-Chat-Signature"))) {
+		return fmt.Errorf("signature mismatch")
+	}
+	return nil
+}